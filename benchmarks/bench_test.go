@@ -0,0 +1,241 @@
+package benchmarks
+
+import (
+	"fmt"
+	"io/ioutil"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/andymeneely/git-churn/gitfuncs"
+	metrics "github.com/andymeneely/git-churn/matrics"
+	"github.com/andymeneely/git-churn/print"
+	"gopkg.in/src-d/go-git.v4"
+	"gopkg.in/src-d/go-git.v4/plumbing/object"
+)
+
+// Size of the fixture repository
+const (
+	fixtureCommits = 300
+	fixtureFiles   = 30
+	fixtureLines   = 200
+)
+
+// The fixture repository, generated on first use so that go test ./... does not pay for it
+var fixture struct {
+	sync.Once
+	dir string
+	err error
+}
+
+func TestMain(m *testing.M) {
+	print.Log = print.Discard
+	code := m.Run()
+	if fixture.dir != "" {
+		os.RemoveAll(fixture.dir)
+	}
+	os.Exit(code)
+}
+
+// fixtureDir returns the directory of the fixture repository
+func fixtureDir(b *testing.B) string {
+	fixture.Do(func() {
+		fixture.dir, fixture.err = ioutil.TempDir("", "git-churn-bench")
+		if fixture.err == nil {
+			fixture.err = generateFixture(fixture.dir)
+		}
+	})
+	if fixture.err != nil {
+		b.Fatal("could not generate the fixture repository: ", fixture.err)
+	}
+	return fixture.dir
+}
+
+// generateFixture commits fixtureCommits pseudo-random edits of fixtureFiles files by four authors, an hour
+// apart. The same seed always generates the same history.
+func generateFixture(dir string) error {
+	repo, err := git.PlainInit(dir, false)
+	if err != nil {
+		return err
+	}
+	w, err := repo.Worktree()
+	if err != nil {
+		return err
+	}
+	random := rand.New(rand.NewSource(1))
+	files := make([][]string, fixtureFiles)
+	for i := range files {
+		for j := 0; j < fixtureLines; j++ {
+			files[i] = append(files[i], fmt.Sprintf("\tline(%d, %d)", i, random.Intn(1000)))
+		}
+	}
+	authors := []string{"alice", "bob", "carol", "dave"}
+	start := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	for c := 0; c < fixtureCommits; c++ {
+		touched := []int{random.Intn(fixtureFiles)}
+		if c == 0 {
+			touched = random.Perm(fixtureFiles)
+		}
+		for n := random.Intn(3); n > 0; n-- {
+			touched = append(touched, random.Intn(fixtureFiles))
+		}
+		for _, i := range touched {
+			if c > 0 {
+				files[i] = editLines(random, files[i], c)
+			}
+			path := filepath.Join("src", fmt.Sprintf("file%02d.go", i))
+			if err := os.MkdirAll(filepath.Join(dir, "src"), 0755); err != nil {
+				return err
+			}
+			content := "package src\n\n" + strings.Join(files[i], "\n") + "\n"
+			if err := ioutil.WriteFile(filepath.Join(dir, path), []byte(content), 0644); err != nil {
+				return err
+			}
+			if _, err := w.Add(path); err != nil {
+				return err
+			}
+		}
+		author := authors[random.Intn(len(authors))]
+		signature := &object.Signature{Name: author, Email: author + "@example.com", When: start.Add(time.Duration(c) * time.Hour)}
+		if _, err := w.Commit(fmt.Sprintf("Change %d", c), &git.CommitOptions{Author: signature, Committer: signature}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// editLines replaces, inserts and deletes a few lines at random
+func editLines(random *rand.Rand, lines []string, commit int) []string {
+	at := random.Intn(len(lines))
+	deleted := random.Intn(4)
+	if at+deleted > len(lines) {
+		deleted = len(lines) - at
+	}
+	var inserted []string
+	for n := random.Intn(5); n > 0; n-- {
+		inserted = append(inserted, fmt.Sprintf("\tedit(%d, %d)", commit, random.Intn(1000)))
+	}
+	edited := append(append(append([]string{}, lines[:at]...), inserted...), lines[at+deleted:]...)
+	if len(edited) == 0 {
+		edited = inserted
+	}
+	return edited
+}
+
+// cloneFixture clones the fixture repository in memory, like the commands do
+func cloneFixture(b *testing.B) *git.Repository {
+	repo, err := gitfuncs.CloneRepository(fixtureDir(b))
+	if err != nil {
+		b.Fatal(err)
+	}
+	return repo
+}
+
+func headCommit(b *testing.B, repo *git.Repository) *object.Commit {
+	head, err := repo.Head()
+	if err != nil {
+		b.Fatal(err)
+	}
+	commit, err := repo.CommitObject(head.Hash())
+	if err != nil {
+		b.Fatal(err)
+	}
+	return commit
+}
+
+func BenchmarkClone(b *testing.B) {
+	fixtureDir(b)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		cloneFixture(b)
+	}
+}
+
+// BenchmarkCommitStats diffs the last 50 commits against their parent
+func BenchmarkCommitStats(b *testing.B) {
+	repo := cloneFixture(b)
+	iter, err := repo.Log(&git.LogOptions{})
+	if err != nil {
+		b.Fatal(err)
+	}
+	var commits []*object.Commit
+	for len(commits) < 50 {
+		commit, err := iter.Next()
+		if err != nil {
+			b.Fatal(err)
+		}
+		commits = append(commits, commit)
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for _, commit := range commits {
+			if _, err := gitfuncs.CommitStats(commit); err != nil {
+				b.Fatal(err)
+			}
+		}
+	}
+}
+
+func BenchmarkRangeChurn(b *testing.B) {
+	repo := cloneFixture(b)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := metrics.RangeChurn(repo, "", "HEAD"); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkRangeChurnCLIEngine(b *testing.B) {
+	defer func(engine gitfuncs.Engine) { gitfuncs.ActiveEngine = engine }(gitfuncs.ActiveEngine)
+	gitfuncs.ActiveEngine = gitfuncs.CLIEngine{}
+	repo, err := git.PlainOpen(fixtureDir(b))
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := metrics.RangeChurn(repo, "", "HEAD"); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkBlame(b *testing.B) {
+	repo := cloneFixture(b)
+	head := headCommit(b, repo)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := gitfuncs.BlameCommit(repo, head, "src/file00.go"); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// BenchmarkReworkRate blames the lines deleted by the last 50 commits
+func BenchmarkReworkRate(b *testing.B) {
+	repo := cloneFixture(b)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := metrics.ReworkRate(repo, "HEAD~50", "HEAD", 14*24*time.Hour); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// BenchmarkRecentChurn blames the lines deleted by the last 50 commits, in the recent churn mode
+func BenchmarkRecentChurn(b *testing.B) {
+	defer func(churn metrics.ChurnDefinition) { metrics.Churn = churn }(metrics.Churn)
+	metrics.Churn = metrics.ChurnDefinition{Mode: metrics.ChurnRecent, Window: 21 * 24 * time.Hour}
+	repo := cloneFixture(b)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := metrics.RangeChurn(repo, "HEAD~50", "HEAD"); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
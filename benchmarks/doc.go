@@ -0,0 +1,3 @@
+// Package benchmarks holds the benchmarks of the metrics against a generated fixture repository, run with
+// make bench. Compare them before and after a performance change with make bench-compare.
+package benchmarks
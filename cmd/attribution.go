@@ -0,0 +1,30 @@
+package cmd
+
+import (
+	"github.com/andymeneely/git-churn/gitfuncs"
+	metrics "github.com/andymeneely/git-churn/matrics"
+	"github.com/andymeneely/git-churn/print"
+	"github.com/spf13/cobra"
+)
+
+var attributionDepth int
+
+func init() {
+	rootCmd.AddCommand(attributionCmd)
+	attributionCmd.Flags().IntVar(&attributionDepth, "depth", 1, "Depth of the directories the lines are totalled by, 1 for the top level ones")
+}
+
+var attributionCmd = &cobra.Command{
+	Use:   "attribution",
+	Short: "Reports the lines every author and directory owns at a revision versus the lines ever added",
+	Long: `Blames every file at --commit (or --branch, HEAD by default), within --path and but the ignored ones, and
+reports per author and per directory the lines they own versus the lines they added in the whole history
+leading to it, i.e. how much of their churn survives in the codebase, and their share of the codebase.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		repo := gitfuncs.Clone(repoUrl)
+		report, err := metrics.ChurnAttribution(repo, requestedRevision(), attributionDepth)
+		print.CheckIfError(err)
+
+		printResult(report)
+	},
+}
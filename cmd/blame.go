@@ -0,0 +1,54 @@
+package cmd
+
+import (
+	"errors"
+	"time"
+
+	"github.com/andymeneely/git-churn/gitfuncs"
+	"github.com/andymeneely/git-churn/print"
+	"github.com/spf13/cobra"
+)
+
+var blameLines string
+
+type blamedLine struct {
+	Line   int
+	Hash   string
+	Author string
+	Date   time.Time
+	Text   string
+}
+
+func init() {
+	rootCmd.AddCommand(blameCmd)
+	blameCmd.Flags().StringVarP(&blameLines, "lines", "L", "", "Range of lines to blame as start,end, the whole file if empty")
+}
+
+var blameCmd = &cobra.Command{
+	Use:   "blame",
+	Short: "Shows the commit and author of every line of a file",
+	Long: `Blames the file given by --filepath at the revision given by --commit (or --branch, HEAD by default),
+honouring --engine, --ignore-revs-file and --blame-ignore-whitespace the way the churn metrics do.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if filepath == "" {
+			print.CheckIfError(errors.New("--filepath has to be specified"))
+		}
+		repo := gitfuncs.Clone(repoUrl)
+		hash, err := gitfuncs.ResolveRef(repo, requestedRevision())
+		print.CheckIfError(err)
+		commit, err := repo.CommitObject(*hash)
+		print.CheckIfError(err)
+		result, err := gitfuncs.ActiveEngine.Blame(repo, commit, filepath)
+		print.CheckIfError(err)
+
+		start := gitfuncs.BlameOpts.StartLine
+		if start == 0 {
+			start = 1
+		}
+		lines := make([]blamedLine, len(result.Lines))
+		for i, line := range result.Lines {
+			lines[i] = blamedLine{Line: start + i, Hash: line.Hash.String(), Author: line.Author, Date: line.Date, Text: line.Text}
+		}
+		printResult(lines)
+	},
+}
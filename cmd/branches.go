@@ -0,0 +1,51 @@
+package cmd
+
+import (
+	"time"
+
+	"github.com/andymeneely/git-churn/gitfuncs"
+	"github.com/andymeneely/git-churn/helper"
+	metrics "github.com/andymeneely/git-churn/matrics"
+	"github.com/andymeneely/git-churn/print"
+	"github.com/spf13/cobra"
+)
+
+var (
+	branchesBase      string
+	branchesSince     string
+	branchesStaleDays int
+)
+
+func init() {
+	rootCmd.AddCommand(branchesCmd)
+	flags := branchesCmd.Flags()
+	flags.StringVar(&branchesBase, "base", "", "Branch the other branches are going to be merged into, the default branch of the repository if empty")
+	flags.StringVar(&branchesSince, "since", "", "Branches last committed to before, a period back from now like 6.months or 2.weeks, or a date like 2020-01-31, are left out, none if empty")
+	flags.IntVar(&branchesStaleDays, "stale-days", 90, "Branches last committed to more days ago are flagged as stale, none if 0")
+}
+
+var branchesCmd = &cobra.Command{
+	Use:   "branches",
+	Short: "Reports the churn and staleness of every branch against the base branch",
+	Long: `Reports for every local and remote-tracking branch committed to since --since and not merged into
+--base its last commit and author, the commits it is ahead and behind, the lines and files it changed
+since their merge base and the files other branches changed too, flagging the branches last committed to
+more than --stale-days ago as stale, likely abandoned. The files changed by several branches but the
+stale ones, --base included, are reported too: the likely merge conflict hotspots.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		var options metrics.DivergenceOptions
+		if branchesSince != "" {
+			since, err := helper.ParseSince(branchesSince, time.Now())
+			print.CheckIfError(err)
+			options.Since = since
+		}
+		if branchesStaleDays > 0 {
+			options.StaleBefore = time.Now().AddDate(0, 0, -branchesStaleDays)
+		}
+		repo := gitfuncs.Clone(repoUrl)
+		report, err := metrics.DivergentBranches(repo, branchesBase, options)
+		print.CheckIfError(err)
+
+		printResult(report)
+	},
+}
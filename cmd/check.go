@@ -0,0 +1,48 @@
+package cmd
+
+import (
+	"strings"
+
+	"github.com/andymeneely/git-churn/gitfuncs"
+	metrics "github.com/andymeneely/git-churn/matrics"
+	"github.com/andymeneely/git-churn/print"
+	"github.com/spf13/cobra"
+)
+
+var checkThresholds metrics.Thresholds
+
+func init() {
+	rootCmd.AddCommand(checkCmd)
+	addRangeFlags(checkCmd)
+	flags := checkCmd.Flags()
+	flags.IntVar(&checkThresholds.MaxLines, "max-lines", metrics.NoLimit, "Most lines added and deleted by the range, -1 for no limit")
+	flags.IntVar(&checkThresholds.MaxFiles, "max-files", metrics.NoLimit, "Most files changed by the range, -1 for no limit")
+	flags.IntVar(&checkThresholds.MaxChurn, "max-churn", metrics.NoLimit, "Most churn of the range according to --churn-mode, -1 for no limit")
+	flags.StringSliceVar(&checkThresholds.ProtectedPaths, "protected-paths", nil, "Paths to be changed sparingly, in the syntax of .gitignore, e.g. migrations/,*.lock")
+	flags.IntVar(&checkThresholds.MaxProtectedChurn, "max-protected-churn", metrics.NoLimit, "Most churn in the --protected-paths, -1 for no limit")
+}
+
+var checkCmd = &cobra.Command{
+	Use:   "check",
+	Short: "Fails when the churn of a range exceeds thresholds, as a CI quality gate",
+	Long: `Totals the lines changed, the files changed and the churn of the commits from --from to --commit (or
+--branch, HEAD by default), along with the churn in the --protected-paths, and exits with a non zero status
+when any exceeds its threshold. The thresholds are best kept in the config file, e.g.
+
+  max-lines: 800
+  max-files: 30
+  protected-paths: ["migrations/", "*.lock"]
+  max-protected-churn: 0`,
+	Run: func(cmd *cobra.Command, args []string) {
+		repo := gitfuncs.Clone(repoUrl)
+		commits, err := metrics.RangeChurn(repo, rangeFrom, requestedRevision())
+		print.CheckIfError(err)
+
+		result := metrics.CheckThresholds(commits, checkThresholds)
+		printResult(result)
+		if !result.Passed {
+			print.Log.Error("the range exceeds its thresholds", "violations", strings.Join(result.Violations, ", "))
+			print.Exit(1)
+		}
+	},
+}
@@ -0,0 +1,55 @@
+package cmd
+
+import (
+	"errors"
+	"time"
+
+	"github.com/andymeneely/git-churn/gitfuncs"
+	"github.com/andymeneely/git-churn/helper"
+	metrics "github.com/andymeneely/git-churn/matrics"
+	"github.com/andymeneely/git-churn/print"
+	"github.com/spf13/cobra"
+)
+
+var (
+	codeownersFile     string
+	codeownersSince    string
+	codeownersMinChurn int
+)
+
+func init() {
+	rootCmd.AddCommand(codeownersCmd)
+	flags := codeownersCmd.Flags()
+	flags.StringVar(&codeownersFile, "codeowners", "", "CODEOWNERS file to check instead of the one of the repository")
+	flags.StringVar(&codeownersSince, "since", "6.months", "Start of the window, a period back from now like 6.months or 2.weeks, or a date like 2020-01-31")
+	flags.IntVar(&codeownersMinChurn, "min-churn", metrics.DefaultCodeownersMinChurn, "Churn under which the declared owners did not contribute meaningfully to their paths over the window")
+}
+
+var codeownersCmd = &cobra.Command{
+	Use:   "codeowners",
+	Short: "Reports the CODEOWNERS entries whose declared owners no longer contribute to their paths",
+	Long: `Compares every line of the CODEOWNERS of the repository, or of --codeowners, with the files it owns:
+the lines of the files the declared owners wrote last according to blame, their main author, and the churn
+the owners made to them since --since. The lines whose owners churned less than --min-churn are stale.
+Owners are matched by email, by team through the --teams file, or by GitHub user against the emails of the
+authors.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		since, err := helper.ParseSince(codeownersSince, time.Now())
+		print.CheckIfError(err)
+		repo := gitfuncs.Clone(repoUrl)
+		var codeowners *metrics.TeamMap
+		if codeownersFile != "" {
+			codeowners, err = metrics.ReadTeamMapFile(codeownersFile)
+		} else {
+			codeowners, err = metrics.ReadRepoCodeowners(repo)
+		}
+		print.CheckIfError(err)
+		if codeowners == nil {
+			print.CheckIfError(errors.New("the repository has no CODEOWNERS and no --codeowners file was given"))
+		}
+		report, err := metrics.CrossCheckCodeowners(repo, codeowners, requestedRevision(), since, codeownersMinChurn)
+		print.CheckIfError(err)
+
+		printResult(report)
+	},
+}
@@ -0,0 +1,38 @@
+package cmd
+
+import (
+	"os"
+
+	"github.com/andymeneely/git-churn/gitfuncs"
+	metrics "github.com/andymeneely/git-churn/matrics"
+	"github.com/andymeneely/git-churn/print"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	rootCmd.AddCommand(commitsCmd)
+	addRangeFlags(commitsCmd)
+}
+
+var commitsCmd = &cobra.Command{
+	Use:   "commits",
+	Short: "Lists the churn of every commit of a range, per file",
+	Long: `Lists the commits from --from to --commit (or --branch, HEAD by default), newest first, with their
+author, date and the lines added and deleted per file. With --format jsonl, every commit is written as a
+JSON object on its own line as soon as it is analysed, without holding the range in memory, to be piped
+into stream processors.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		repo := gitfuncs.Clone(repoUrl)
+		if outputFormat == formatJSONL {
+			err := metrics.ForEachCommitMetrics(repo, rangeFrom, requestedRevision(), func(churn *metrics.CommitChurn) error {
+				return writeJSONLine(os.Stdout, churn)
+			})
+			print.CheckIfError(err)
+			return
+		}
+		commits, err := metrics.RangeChurn(repo, rangeFrom, requestedRevision())
+		print.CheckIfError(err)
+
+		printResult(commits)
+	},
+}
@@ -0,0 +1,34 @@
+package cmd
+
+import (
+	"github.com/andymeneely/git-churn/gitfuncs"
+	metrics "github.com/andymeneely/git-churn/matrics"
+	"github.com/andymeneely/git-churn/print"
+	"github.com/spf13/cobra"
+)
+
+var (
+	compareBase string
+	compareHead string
+)
+
+func init() {
+	rootCmd.AddCommand(compareCmd)
+	compareCmd.Flags().StringVar(&compareBase, "base", "master", "Branch the head branch is going to be merged into")
+	compareCmd.Flags().StringVar(&compareHead, "head", "", "Branch whose churn footprint has to be computed")
+	print.CheckIfError(compareCmd.MarkFlagRequired("head"))
+}
+
+var compareCmd = &cobra.Command{
+	Use:   "compare",
+	Short: "Compares the churn of two branches since their merge base",
+	Long: `Computes the aggregate and per file churn that the --head branch (e.g. a feature branch) and the
+--base branch (e.g. master) accumulated since they diverged, along with the files changed on both.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		repo := gitfuncs.Clone(repoUrl)
+		comparison, err := metrics.CompareBranches(repo, compareBase, compareHead)
+		print.CheckIfError(err)
+
+		printResult(comparison)
+	},
+}
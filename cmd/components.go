@@ -0,0 +1,40 @@
+package cmd
+
+import (
+	"errors"
+
+	"github.com/andymeneely/git-churn/gitfuncs"
+	metrics "github.com/andymeneely/git-churn/matrics"
+	"github.com/andymeneely/git-churn/print"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	rootCmd.AddCommand(componentsCmd)
+	addRangeFlags(componentsCmd)
+}
+
+var componentsCmd = &cobra.Command{
+	Use:   "components",
+	Short: "Reports the churn per component of a monorepo and how the components change together",
+	Long: `Totals per component the commits, authors and lines changed over the range, and the files and lines of
+code of the component at --commit (or --branch, HEAD by default). The commits changing several components
+are counted, per pair of components, as their cross-component coupling. The components are given with
+--components, usually kept in the config file:
+
+  components: ["billing=services/billing", "services/auth", "web"]
+
+A file belongs to the component of the longest path it is under, the other files to "other".`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if len(componentSpecs) == 0 {
+			print.CheckIfError(errors.New("no component given with --components"))
+		}
+		repo := gitfuncs.Clone(repoUrl)
+		commits, err := metrics.RangeChurn(repo, rangeFrom, requestedRevision())
+		print.CheckIfError(err)
+		report, err := metrics.ChurnByComponent(repo, commits, requestedRevision())
+		print.CheckIfError(err)
+
+		printResult(report)
+	},
+}
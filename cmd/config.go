@@ -0,0 +1,124 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v2"
+)
+
+var configFile string
+
+// Config files looked for in the current directory when --config is not given
+var defaultConfigFiles = []string{"git-churn.yaml", ".git-churn.yaml"}
+
+func init() {
+	rootCmd.PersistentFlags().StringVar(&configFile, "config", "", "YAML file of default options keyed by flag name, overridden by the flags given (default git-churn.yaml or .git-churn.yaml in the current directory)")
+}
+
+// loadConfig sets the flags of the command that are not given on the command line to the values of the
+// config file, if any. The keys of the file are flag names, e.g.
+//
+//	repo: https://github.com/andymeneely/git-churn
+//	branch: master
+//	whitespace: false
+//	format: text
+//	test-patterns: ["*_test.go", "testdata/"]
+//
+// Keys naming a flag of other commands only are skipped, so that one file serves every command.
+func loadConfig(cmd *cobra.Command) error {
+	path := configFile
+	if path == "" {
+		for _, name := range defaultConfigFiles {
+			if _, err := os.Stat(name); err == nil {
+				path = name
+				break
+			}
+		}
+		if path == "" {
+			return nil
+		}
+	}
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	values := make(map[string]interface{})
+	if err := yaml.Unmarshal(data, &values); err != nil {
+		return fmt.Errorf("invalid config file %s: %v", path, err)
+	}
+
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		if key == "config" || !isFlag(cmd.Root(), key) {
+			return fmt.Errorf("unknown option %q in the config file %s", key, path)
+		}
+		flag := cmd.Flags().Lookup(key)
+		if flag == nil {
+			continue
+		}
+		if flag.Changed {
+			continue
+		}
+		value, err := configValue(values[key])
+		if err != nil {
+			return fmt.Errorf("invalid value of %q in the config file %s: %v", key, path, err)
+		}
+		if err := cmd.Flags().Set(key, value); err != nil {
+			return fmt.Errorf("invalid value of %q in the config file %s: %v", key, path, err)
+		}
+	}
+	return nil
+}
+
+// configValue formats a value of the config file as a flag value. Lists are formatted as comma separated
+// values, quoted where needed, the way list flags parse them.
+func configValue(value interface{}) (string, error) {
+	switch v := value.(type) {
+	case []interface{}:
+		record := make([]string, len(v))
+		for i, item := range v {
+			s, err := configValue(item)
+			if err != nil {
+				return "", err
+			}
+			record[i] = s
+		}
+		var b bytes.Buffer
+		w := csv.NewWriter(&b)
+		if err := w.Write(record); err != nil {
+			return "", err
+		}
+		w.Flush()
+		return strings.TrimSuffix(b.String(), "\n"), w.Error()
+	case map[interface{}]interface{}:
+		return "", fmt.Errorf("expected a value or a list")
+	case nil:
+		return "", nil
+	default:
+		return fmt.Sprint(v), nil
+	}
+}
+
+// isFlag tells whether any command has a flag of the given name
+func isFlag(cmd *cobra.Command, name string) bool {
+	if cmd.Flags().Lookup(name) != nil || cmd.PersistentFlags().Lookup(name) != nil {
+		return true
+	}
+	for _, sub := range cmd.Commands() {
+		if isFlag(sub, name) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,110 @@
+package cmd
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+)
+
+// configCommand returns a command with a few flags under a root whose other command has a flag of its own
+func configCommand() (*cobra.Command, *string, *[]string, *bool) {
+	root := &cobra.Command{Use: "root"}
+	cmd := &cobra.Command{Use: "churn"}
+	other := &cobra.Command{Use: "other"}
+	root.AddCommand(cmd, other)
+	other.Flags().Int("top", 10, "")
+	repo := cmd.Flags().String("repo", "", "")
+	patterns := cmd.Flags().StringSlice("test-patterns", nil, "")
+	whitespace := cmd.Flags().Bool("whitespace", true, "")
+	return cmd, repo, patterns, whitespace
+}
+
+// writeConfig writes the content to a temporary config file given as --config, until the returned function
+// removes it
+func writeConfig(t *testing.T, content string) func() {
+	file, err := ioutil.TempFile("", "git-churn-*.yaml")
+	assert.Nil(t, err)
+	_, err = file.WriteString(content)
+	assert.Nil(t, err)
+	assert.Nil(t, file.Close())
+	configFile = file.Name()
+	return func() {
+		configFile = ""
+		os.Remove(file.Name())
+	}
+}
+
+func TestLoadConfig(t *testing.T) {
+	assert := assert.New(t)
+	defer writeConfig(t, `repo: https://github.com/andymeneely/git-churn
+whitespace: false
+test-patterns: ["*_test.go", "a,b/"]
+top: 3
+`)()
+
+	cmd, repo, patterns, whitespace := configCommand()
+	assert.Nil(loadConfig(cmd))
+	assert.Equal("https://github.com/andymeneely/git-churn", *repo)
+	assert.False(*whitespace)
+	// The items holding a comma are quoted, not split
+	assert.Equal([]string{"*_test.go", "a,b/"}, *patterns)
+
+	// The flags given on the command line win over the file
+	cmd, repo, patterns, whitespace = configCommand()
+	assert.Nil(cmd.Flags().Parse([]string{"--repo", "other", "--whitespace=true"}))
+	assert.Nil(loadConfig(cmd))
+	assert.Equal("other", *repo)
+	assert.True(*whitespace)
+	assert.Equal([]string{"*_test.go", "a,b/"}, *patterns)
+}
+
+func TestLoadConfigErrors(t *testing.T) {
+	assert := assert.New(t)
+	for content, message := range map[string]string{
+		"branch: master\n":           `unknown option "branch" in the config file `,
+		"config: other.yaml\n":       `unknown option "config" in the config file `,
+		"repo: {url: x}\n":           `invalid value of "repo" in the config file `,
+		"whitespace: sometimes\n":    `invalid value of "whitespace" in the config file `,
+		"repo: [unterminated\n":      `invalid config file `,
+		"test-patterns:\n  a: b\n":   `invalid value of "test-patterns" in the config file `,
+		"top: 3\nbranch: master\n":   `unknown option "branch" in the config file `,
+		"repo: x\nwhitespace: yes\n": ``,
+	} {
+		cleanup := writeConfig(t, content)
+		cmd, _, _, _ := configCommand()
+		err := loadConfig(cmd)
+		if message == "" {
+			assert.Nil(err, content)
+		} else if assert.NotNil(err, content) {
+			assert.Contains(err.Error(), message, content)
+		}
+		cleanup()
+	}
+}
+
+func TestConfigValue(t *testing.T) {
+	assert := assert.New(t)
+	for _, test := range []struct {
+		value    interface{}
+		expected string
+	}{
+		{"master", "master"},
+		{42, "42"},
+		{false, "false"},
+		{nil, ""},
+		{[]interface{}{"a", "b"}, "a,b"},
+		{[]interface{}{"a,b", `say "hi"`, 3}, `"a,b","say ""hi""",3`},
+		{[]interface{}{}, ""},
+	} {
+		value, err := configValue(test.value)
+		assert.Nil(err)
+		assert.Equal(test.expected, value)
+	}
+	_, err := configValue(map[interface{}]interface{}{"a": "b"})
+	assert.EqualError(err, "expected a value or a list")
+	_, err = configValue([]interface{}{map[interface{}]interface{}{"a": "b"}})
+	assert.EqualError(err, "expected a value or a list")
+}
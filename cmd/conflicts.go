@@ -0,0 +1,36 @@
+package cmd
+
+import (
+	"github.com/andymeneely/git-churn/gitfuncs"
+	metrics "github.com/andymeneely/git-churn/matrics"
+	"github.com/andymeneely/git-churn/print"
+	"github.com/spf13/cobra"
+)
+
+var (
+	conflictsBase string
+	conflictsHead string
+)
+
+func init() {
+	rootCmd.AddCommand(conflictsCmd)
+	conflictsCmd.Flags().StringVar(&conflictsBase, "base", "master", "Branch the head branch is going to be merged into")
+	conflictsCmd.Flags().StringVar(&conflictsHead, "head", "", "Branch going to be merged")
+	print.CheckIfError(conflictsCmd.MarkFlagRequired("head"))
+}
+
+var conflictsCmd = &cobra.Command{
+	Use:   "conflicts",
+	Short: "Predicts the files and lines that will conflict when merging two branches",
+	Long: `Compares the changes --head and --base made since their merge base and lists the files both changed,
+with the runs of lines of the merge base both changed, which git will stop on, and how likely every file
+is to conflict: high when git will stop on it, medium when the changes of both branches are within a few
+lines of each other, low otherwise.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		repo := gitfuncs.Clone(repoUrl)
+		prediction, err := metrics.PredictConflicts(repo, conflictsBase, conflictsHead)
+		print.CheckIfError(err)
+
+		printResult(prediction)
+	},
+}
@@ -0,0 +1,33 @@
+package cmd
+
+import (
+	"github.com/andymeneely/git-churn/gitfuncs"
+	metrics "github.com/andymeneely/git-churn/matrics"
+	"github.com/andymeneely/git-churn/print"
+	"github.com/spf13/cobra"
+)
+
+var contributorsSort string
+
+func init() {
+	rootCmd.AddCommand(contributorsCmd)
+	addRangeFlags(contributorsCmd)
+	contributorsCmd.Flags().StringVar(&contributorsSort, "sort", "commits", "Key the authors are ranked by: commits, insertions, deletions, churn, files, first or last (commit date)")
+}
+
+var contributorsCmd = &cobra.Command{
+	Use:   "contributors",
+	Short: "Reports the commits, lines and files changed per author",
+	Long: `Totals per author the commits of the range, the lines they added and deleted, the files they touched
+and the dates of their first and last commit, ranked by --sort. The identities authors committed with
+are merged according to the .mailmap of the repository, or the --mailmap file.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		repo := gitfuncs.Clone(repoUrl)
+		commits, err := metrics.RangeChurn(repo, rangeFrom, requestedRevision())
+		print.CheckIfError(err)
+		contributors, err := metrics.Contributors(commits, contributorsSort)
+		print.CheckIfError(err)
+
+		printResult(contributors)
+	},
+}
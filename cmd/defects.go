@@ -0,0 +1,40 @@
+package cmd
+
+import (
+	"time"
+
+	"github.com/andymeneely/git-churn/gitfuncs"
+	"github.com/andymeneely/git-churn/helper"
+	metrics "github.com/andymeneely/git-churn/matrics"
+	"github.com/andymeneely/git-churn/print"
+	"github.com/spf13/cobra"
+)
+
+var (
+	defectsN     int
+	defectsSince string
+)
+
+func init() {
+	rootCmd.AddCommand(defectsCmd)
+	flags := defectsCmd.Flags()
+	flags.IntVar(&defectsN, "n", 20, "Number of files to report, all of them if 0")
+	flags.StringVar(&defectsSince, "since", "1.year", "Start of the window, a period back from now like 6.months or 2.weeks, or a date like 2020-01-31")
+}
+
+var defectsCmd = &cobra.Command{
+	Use:   "defects",
+	Short: "Correlates the churn of the files with the bug fixes made to them",
+	Long: `Detects the commits fixing bugs made since --since by their message, see --fix-patterns, counts the
+fixes per file and reports the rank correlation between the churn and the fixes of the files, with the
+--n files where high churn most coincides with frequent fixes.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		since, err := helper.ParseSince(defectsSince, time.Now())
+		print.CheckIfError(err)
+		repo := gitfuncs.Clone(repoUrl)
+		commits, err := metrics.ChurnSince(repo, requestedRevision(), since)
+		print.CheckIfError(err)
+
+		printResult(metrics.DefectDensity(commits, defectsN))
+	},
+}
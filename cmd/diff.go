@@ -0,0 +1,41 @@
+package cmd
+
+import (
+	"errors"
+
+	"github.com/andymeneely/git-churn/gitfuncs"
+	metrics "github.com/andymeneely/git-churn/matrics"
+	"github.com/andymeneely/git-churn/print"
+	"github.com/spf13/cobra"
+)
+
+var diffFrom string
+
+func init() {
+	rootCmd.AddCommand(diffCmd)
+	diffCmd.Flags().StringVar(&diffFrom, "from", "", "Revision the file is compared from, e.g. the commit of the last deploy")
+	print.CheckIfError(diffCmd.MarkFlagRequired("from"))
+}
+
+var diffCmd = &cobra.Command{
+	Use:   "diff",
+	Short: "Computes the net change of a file between two commits",
+	Long: `Computes the diff metrics of --filepath between the --from revision and --commit (or --branch, HEAD by
+default), diffing their trees whatever the commits in between, e.g. the net change of a file since the last deploy.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if filepath == "" {
+			print.CheckIfError(errors.New("--filepath is required"))
+		}
+		repo := gitfuncs.Clone(repoUrl)
+		var diffMetrics *metrics.FileDiffMetrics
+		var err error
+		if whitespace {
+			diffMetrics, err = metrics.CalculateDiffMetricsBetween(repo, diffFrom, requestedRevision(), filepath)
+		} else {
+			diffMetrics, err = metrics.CalculateDiffMetricsBetweenWhitespaceExcluded(repo, diffFrom, requestedRevision(), filepath)
+		}
+		print.CheckIfError(err)
+
+		printResult(diffMetrics)
+	},
+}
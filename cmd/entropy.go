@@ -0,0 +1,28 @@
+package cmd
+
+import (
+	"github.com/andymeneely/git-churn/gitfuncs"
+	metrics "github.com/andymeneely/git-churn/matrics"
+	"github.com/andymeneely/git-churn/print"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	rootCmd.AddCommand(entropyCmd)
+	addRangeFlags(entropyCmd)
+}
+
+var entropyCmd = &cobra.Command{
+	Use:   "entropy",
+	Short: "Reports how scattered the changes of every commit of a range are across files",
+	Long: `Computes the change entropy of every commit of the range, the Shannon entropy of its churn across the
+files it changes, in bits and normalized to 0..1, and its mean over the range. Scattered commits, of high
+entropy, are more likely to introduce defects than focused ones.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		repo := gitfuncs.Clone(repoUrl)
+		commits, err := metrics.RangeChurn(repo, rangeFrom, requestedRevision())
+		print.CheckIfError(err)
+
+		printResult(metrics.ChangeEntropy(commits))
+	},
+}
@@ -0,0 +1,53 @@
+package cmd
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/andymeneely/git-churn/exporter"
+	metrics "github.com/andymeneely/git-churn/matrics"
+	"github.com/andymeneely/git-churn/print"
+	"github.com/spf13/cobra"
+)
+
+var (
+	exportAddr     string
+	exportInterval time.Duration
+	exportDays     int
+	exportCacheTTL time.Duration
+)
+
+func init() {
+	rootCmd.AddCommand(exportCmd)
+	flags := exportCmd.Flags()
+	flags.StringVar(&exportAddr, "addr", ":9110", "Address to serve /metrics on")
+	flags.DurationVar(&exportInterval, "interval", 15*time.Minute, "How often the repositories are analyzed")
+	flags.IntVar(&exportDays, "days", 7, "Number of days, up to today, the churn is reported for")
+	flags.DurationVar(&exportCacheTTL, "cache-ttl", metrics.DefaultResultTTL, "How long the churn computed for a branch head is kept, 0 to keep it until new commits are fetched")
+}
+
+var exportCmd = &cobra.Command{
+	Use:   "export <url>[#<branch>]...",
+	Short: "Exports the churn of repositories as Prometheus metrics",
+	Long: `Analyzes the given repositories every --interval and serves the lines added and deleted, the files
+changed, the authors and the commits per repository, branch and day on /metrics for Prometheus. The default
+branch of a repository is analyzed unless another one is given after a #. The churn is cached per branch head
+for --cache-ttl, so that the analyses finding no new commit do not walk the history again.`,
+	Args:        cobra.MinimumNArgs(1),
+	Annotations: map[string]string{repoOptional: "true"},
+	Run: func(cmd *cobra.Command, args []string) {
+		targets := make([]exporter.Target, len(args))
+		for i, arg := range args {
+			target, err := exporter.ParseTarget(arg)
+			print.CheckIfError(err)
+			targets[i] = target
+		}
+		e := exporter.New(targets, exportDays)
+		e.Cache.TTL = exportCacheTTL
+		go e.Run(exportInterval, nil)
+
+		http.Handle("/metrics", e)
+		print.Info("Serving the metrics on %s/metrics", exportAddr)
+		print.CheckIfError(http.ListenAndServe(exportAddr, nil))
+	},
+}
@@ -0,0 +1,35 @@
+package cmd
+
+import (
+	"errors"
+
+	"github.com/andymeneely/git-churn/gitfuncs"
+	metrics "github.com/andymeneely/git-churn/matrics"
+	"github.com/andymeneely/git-churn/print"
+	"github.com/spf13/cobra"
+)
+
+var followSimilarity float64
+
+func init() {
+	rootCmd.AddCommand(followCmd)
+	followCmd.Flags().Float64Var(&followSimilarity, "similarity", gitfuncs.DefaultRenameSimilarity*100, "Percentage of its lines a deleted file must have in common with an added one for them to be a rename")
+}
+
+var followCmd = &cobra.Command{
+	Use:   "follow",
+	Short: "Reports the whole history of a file across its renames",
+	Long: `Follows the file given by --filepath at --commit (or --branch, HEAD by default) back to the commit that
+added it, across its renames like git log --follow, and reports its lifetime churn, the span of the commits of
+every author and the chain of its renames.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if filepath == "" {
+			print.CheckIfError(errors.New("--filepath has to be specified"))
+		}
+		repo := gitfuncs.Clone(repoUrl)
+		lifetime, err := metrics.GetFileLifetime(repo, requestedRevision(), filepath, followSimilarity/100, whitespace)
+		print.CheckIfError(err)
+
+		printResult(lifetime)
+	},
+}
@@ -0,0 +1,59 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/andymeneely/git-churn/gitfuncs"
+	metrics "github.com/andymeneely/git-churn/matrics"
+	"github.com/andymeneely/git-churn/print"
+	"github.com/andymeneely/git-churn/report"
+	"github.com/spf13/cobra"
+)
+
+var (
+	graphFormat string
+	graphOut    string
+)
+
+func init() {
+	rootCmd.AddCommand(graphCmd)
+	addRangeFlags(graphCmd)
+	graphCmd.Flags().StringVar(&graphFormat, "graph-format", "dot", "Format of the graph, dot for Graphviz or graphml for Gephi and the like")
+	graphCmd.Flags().StringVar(&graphOut, "out", "", "Path of the graph to write, stdout if empty")
+}
+
+var graphCmd = &cobra.Command{
+	Use:   "graph",
+	Short: "Exports the commit graph of a range annotated with churn, in DOT or GraphML",
+	Long: `Exports the commits of the range up to --commit (or --branch, HEAD by default) and the edges to their
+parents as a DOT graph for Graphviz, e.g. dot -Tsvg, or as GraphML for Gephi, every commit carrying its
+author and the lines it changed, so that the structure of the history and its churn can be seen together.
+In DOT the commits grow and go from grey to red with their churn.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if graphFormat != "dot" && graphFormat != "graphml" {
+			print.CheckIfError(fmt.Errorf("unknown graph format %q, expected dot or graphml", graphFormat))
+		}
+		repo := gitfuncs.Clone(repoUrl)
+		graph, err := metrics.RangeCommitGraph(repo, rangeFrom, requestedRevision())
+		print.CheckIfError(err)
+
+		var out io.Writer = os.Stdout
+		if graphOut != "" {
+			f, err := os.Create(graphOut)
+			print.CheckIfError(err)
+			defer f.Close()
+			out = f
+		}
+		if graphFormat == "graphml" {
+			print.CheckIfError(report.WriteGraphML(out, graph))
+		} else {
+			title := fmt.Sprintf("%s at %s", repoUrl, requestedRevision())
+			print.CheckIfError(report.WriteDOT(out, title, graph))
+		}
+		if graphOut != "" {
+			print.Info("Graph written to %s", graphOut)
+		}
+	},
+}
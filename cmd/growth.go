@@ -0,0 +1,33 @@
+package cmd
+
+import (
+	"github.com/andymeneely/git-churn/gitfuncs"
+	metrics "github.com/andymeneely/git-churn/matrics"
+	"github.com/andymeneely/git-churn/print"
+	"github.com/spf13/cobra"
+)
+
+var (
+	growthEvery   int
+	growthMonthly bool
+)
+
+func init() {
+	rootCmd.AddCommand(growthCmd)
+	growthCmd.Flags().IntVar(&growthEvery, "every", 100, "Samples the repository size every N commits")
+	growthCmd.Flags().BoolVar(&growthMonthly, "monthly", false, "Samples the repository size at the last commit of every month instead")
+}
+
+var growthCmd = &cobra.Command{
+	Use:   "growth",
+	Short: "Computes the growth curve of the repository size over time",
+	Long: `Computes the total lines of code of the repository at sampled points of the history leading to
+--commit (or --branch, HEAD by default), either every N commits or monthly.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		repo := gitfuncs.Clone(repoUrl)
+		curve, err := metrics.GrowthCurve(repo, requestedRevision(), growthEvery, growthMonthly, whitespace)
+		print.CheckIfError(err)
+
+		printResult(curve)
+	},
+}
@@ -0,0 +1,51 @@
+package cmd
+
+import (
+	"time"
+
+	"github.com/andymeneely/git-churn/gitfuncs"
+	metrics "github.com/andymeneely/git-churn/matrics"
+	"github.com/andymeneely/git-churn/print"
+	"github.com/spf13/cobra"
+)
+
+var (
+	heatmapColumns   string
+	heatmapPerAuthor bool
+	heatmapTimeZone  string
+)
+
+func init() {
+	rootCmd.AddCommand(heatmapCmd)
+	addRangeFlags(heatmapCmd)
+	flags := heatmapCmd.Flags()
+	flags.StringVar(&heatmapColumns, "columns", metrics.HeatmapHours, "Columns of the heatmap, the days of the week being the rows: hour of the day or week of the year")
+	flags.BoolVar(&heatmapPerAuthor, "per-author", false, "Also report the heatmap of every author")
+	flags.StringVar(&heatmapTimeZone, "timezone", "", "Time zone the commits are placed in, e.g. Europe/Paris, the local time of their author if empty")
+	flags.IntVar(&metrics.WorkdayStart, "workday-start", metrics.WorkdayStart, "Hour the working hours start at on weekdays")
+	flags.IntVar(&metrics.WorkdayEnd, "workday-end", metrics.WorkdayEnd, "Hour the working hours end at on weekdays, the churn outside of them being after hours")
+}
+
+var heatmapCmd = &cobra.Command{
+	Use:   "heatmap",
+	Short: "Reports the churn by day of the week and hour of the day, or week of the year",
+	Long: `Totals the commits and the churn of the range up to --commit (or --branch, HEAD by default) in a matrix
+of the days of the week by the hours of the day, or by the weeks of the year with --columns week, overall and
+with --per-author for every author, along with the shares of the churn after hours and on weekends, to spot
+crunches and overtime. The commits are placed in the local time of their author unless --timezone is given.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		var zone *time.Location
+		if heatmapTimeZone != "" {
+			var err error
+			zone, err = time.LoadLocation(heatmapTimeZone)
+			print.CheckIfError(err)
+		}
+		repo := gitfuncs.Clone(repoUrl)
+		commits, err := metrics.RangeChurn(repo, rangeFrom, requestedRevision())
+		print.CheckIfError(err)
+		report, err := metrics.ChurnHeatmap(commits, heatmapColumns, zone, heatmapPerAuthor)
+		print.CheckIfError(err)
+
+		printResult(report)
+	},
+}
@@ -0,0 +1,39 @@
+package cmd
+
+import (
+	"github.com/andymeneely/git-churn/gitfuncs"
+	"github.com/andymeneely/git-churn/print"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	rootCmd.AddCommand(hunksCmd)
+}
+
+var hunksCmd = &cobra.Command{
+	Use:   "hunks",
+	Short: "Lists the hunks changed by a commit",
+	Long: `Lists the hunks of the diff of the commit given by --commit (or --branch, HEAD by default) against its
+first parent: the file, the start line on both sides, the lines added and deleted and the SHA-1 of their
+content. Only the hunks of --filepath are listed when given.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		repo := gitfuncs.Clone(repoUrl)
+		hash, err := gitfuncs.ResolveRef(repo, requestedRevision())
+		print.CheckIfError(err)
+		commit, err := repo.CommitObject(*hash)
+		print.CheckIfError(err)
+		hunks, err := gitfuncs.CommitHunks(repo, commit)
+		print.CheckIfError(err)
+
+		if filepath != "" {
+			var fileHunks []gitfuncs.Hunk
+			for _, hunk := range hunks {
+				if hunk.File == filepath {
+					fileHunks = append(fileHunks, hunk)
+				}
+			}
+			hunks = fileHunks
+		}
+		printResult(hunks)
+	},
+}
@@ -0,0 +1,47 @@
+package cmd
+
+import (
+	"sort"
+
+	"github.com/andymeneely/git-churn/gitfuncs"
+	"github.com/andymeneely/git-churn/print"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	rootCmd.AddCommand(linesCmd)
+}
+
+var linesCmd = &cobra.Command{
+	Use:   "lines",
+	Short: "Lists the numbers of the lines added and deleted by a commit",
+	Long: `Lists the numbers of the lines the commit given by --commit (or --branch, HEAD by default) added, in the
+new version of the files, and deleted, in their old version, against its first parent, e.g. to tell the new
+code to coverage or static analysis tools. Blank lines are left out with --whitespace=false. Only the lines of
+--filepath are listed when given.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		repo := gitfuncs.Clone(repoUrl)
+		opts := gitfuncs.LineChangeOptions{Whitespace: gitfuncs.WhitespaceIncluded}
+		if !whitespace {
+			opts.Whitespace = gitfuncs.WhitespaceExcluded
+		}
+		changes, err := gitfuncs.LineChanges(repo, requestedRevision(), opts)
+		print.CheckIfError(err)
+
+		var files []*gitfuncs.FileLineChanges
+		for path, fileChanges := range changes {
+			if filepath == "" || path == filepath || fileChanges.NewPath == filepath {
+				files = append(files, fileChanges)
+			}
+		}
+		sort.Slice(files, func(i, j int) bool { return linesPath(files[i]) < linesPath(files[j]) })
+		printResult(files)
+	},
+}
+
+func linesPath(changes *gitfuncs.FileLineChanges) string {
+	if changes.NewPath != "" {
+		return changes.NewPath
+	}
+	return changes.OldPath
+}
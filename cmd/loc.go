@@ -0,0 +1,34 @@
+package cmd
+
+import (
+	"github.com/andymeneely/git-churn/gitfuncs"
+	metrics "github.com/andymeneely/git-churn/matrics"
+	"github.com/andymeneely/git-churn/print"
+	"github.com/spf13/cobra"
+)
+
+var (
+	locGroupBy string
+	locCode    bool
+)
+
+func init() {
+	rootCmd.AddCommand(locCmd)
+	locCmd.Flags().StringVar(&locGroupBy, "by", "", "Groups the lines of code by dir, ext, lang or component (see --components)")
+	locCmd.Flags().BoolVar(&locCode, "code", false, "Counts only the lines of code, excluding comments and blank lines")
+}
+
+var locCmd = &cobra.Command{
+	Use:   "loc",
+	Short: "Counts the lines of code of the whole repository at a revision",
+	Long: `Counts the lines of code of every file in the repository at the revision given by --commit
+(or --branch, HEAD by default), optionally grouped by top level directory, file extension or language.
+With --code, comments are left out according to the language of each file, as well as blank lines.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		repo := gitfuncs.Clone(repoUrl)
+		snapshot, err := metrics.GetLOCSnapshot(repo, requestedRevision(), locGroupBy, whitespace, locCode)
+		print.CheckIfError(err)
+
+		printResult(snapshot)
+	},
+}
@@ -0,0 +1,60 @@
+package cmd
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"time"
+
+	"github.com/andymeneely/git-churn/schema"
+	"github.com/andymeneely/git-churn/version"
+	"github.com/spf13/cobra"
+)
+
+var (
+	manifestFile string
+	runStarted   = time.Now()
+)
+
+// Manifest describes a run of git-churn, so that its output can be traced back to the tool version,
+// the repository and the options that produced it
+type Manifest struct {
+	// Version of the output contract the results of the run follow, see the schema command
+	SchemaVersion string
+	Tool          version.Info
+	Command       string
+	Args          []string
+	Repository    string `json:",omitempty"`
+	Revision      string `json:",omitempty"`
+	Started       time.Time
+	Finished      time.Time
+}
+
+func init() {
+	rootCmd.PersistentFlags().StringVar(&manifestFile, "manifest", "", "Write a JSON manifest of the run (tool version, options, timing) to this file")
+	rootCmd.PersistentPostRunE = writeManifest
+}
+
+// writeManifest writes the manifest of the run to the file given by --manifest, if any
+func writeManifest(cmd *cobra.Command, args []string) error {
+	if manifestFile == "" {
+		return nil
+	}
+	manifest := Manifest{
+		SchemaVersion: schema.Version,
+		Tool:          version.Get(),
+		Command:       cmd.CommandPath(),
+		Args:          os.Args[1:],
+		Repository:    repoUrl,
+		Started:       runStarted,
+		Finished:      time.Now(),
+	}
+	if repoUrl != "" {
+		manifest.Revision = requestedRevision()
+	}
+	out, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(manifestFile, append(out, '\n'), 0644)
+}
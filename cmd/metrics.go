@@ -0,0 +1,52 @@
+package cmd
+
+import (
+	"github.com/andymeneely/git-churn/gitfuncs"
+	metrics "github.com/andymeneely/git-churn/matrics"
+	"github.com/andymeneely/git-churn/print"
+	"github.com/spf13/cobra"
+)
+
+var (
+	metricNames   []string
+	metricPlugins []string
+)
+
+func init() {
+	rootCmd.AddCommand(metricsCmd)
+	addRangeFlags(metricsCmd)
+	flags := metricsCmd.Flags()
+	flags.StringSliceVar(&metricNames, "metric", nil, "Metrics to compute, e.g. churn,hotspots, all of them but components and ownership if empty")
+	flags.StringSliceVar(&metricPlugins, "plugin", nil, "Go plugins (built with -buildmode=plugin) registering more metrics to load first")
+}
+
+var metricsCmd = &cobra.Command{
+	Use:   "metrics",
+	Short: "Computes several metrics, custom ones included, in a single walk of a range",
+	Long: `Walks the commits of the range once, diffing every commit once, and feeds them to every --metric,
+printing their results by name. The compiled-in metrics are churn (the files ranked by churn, like the top
+command), components (see --components), contributors, coupling (the pairs of files changing together most often), entropy, hotspots,
+ownership (the main owner of every file changed, which blames them all), stats and types. More metrics are
+loaded from --plugin, Go plugins implementing the Metric interface of the metrics package and registering
+their metrics with metrics.RegisterMetric from their init functions.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		for _, path := range metricPlugins {
+			print.CheckIfError(metrics.LoadMetricPlugin(path))
+		}
+		names := metricNames
+		if len(names) == 0 {
+			names = metrics.DefaultMetrics
+		}
+		var requested []metrics.Metric
+		for _, name := range names {
+			metric, err := metrics.NewMetric(name)
+			print.CheckIfError(err)
+			requested = append(requested, metric)
+		}
+		repo := gitfuncs.Clone(repoUrl)
+		results, err := metrics.RunMetrics(repo, rangeFrom, requestedRevision(), requested)
+		print.CheckIfError(err)
+
+		printResult(results)
+	},
+}
@@ -0,0 +1,64 @@
+package cmd
+
+import (
+	"os"
+	"time"
+
+	"github.com/andymeneely/git-churn/helper"
+	"github.com/andymeneely/git-churn/integrations"
+	"github.com/andymeneely/git-churn/print"
+	"github.com/andymeneely/git-churn/survey"
+	"github.com/spf13/cobra"
+)
+
+var (
+	orgAPI       string
+	orgLanguages []string
+	orgArchived  bool
+	orgForks     bool
+	orgSince     string
+	orgWorkers   int
+	orgTop       int
+	orgHotspots  int
+)
+
+func init() {
+	rootCmd.AddCommand(orgCmd)
+	flags := orgCmd.Flags()
+	flags.StringVar(&orgAPI, "api", integrations.GitHubAPI, "Root of the GitHub API, for GitHub Enterprise")
+	flags.StringSliceVar(&orgLanguages, "language", nil, "Main languages of the repositories to analyze, e.g. go,python, all of them if empty")
+	flags.BoolVar(&orgArchived, "archived", false, "Analyze the archived repositories too")
+	flags.BoolVar(&orgForks, "forks", false, "Analyze the forks too")
+	flags.StringVar(&orgSince, "since", "1.year", "Start of the window, a period back from now like 6.months or 2.weeks, or a date like 2020-01-31")
+	flags.IntVar(&orgWorkers, "workers", 4, "Number of repositories analyzed at once")
+	flags.IntVar(&orgTop, "top", 10, "Number of most changed files to report per repository, all of them if 0")
+	flags.IntVar(&orgHotspots, "hotspots", 20, "Number of hottest files to report across the organization")
+}
+
+var orgCmd = &cobra.Command{
+	Use:   "org <organization>",
+	Short: "Reports the churn and the hotspots of the repositories of a GitHub organization",
+	Long: `Lists the repositories of the GitHub organization through the API, leaving out the archived ones
+and the forks unless --archived and --forks are given and keeping only the ones in --language if any. Their
+default branches are then cloned and analyzed --workers at a time, reporting the churn since --since of every
+repository, keyed by clone URL, its totals and the --hotspots hottest files across the organization. The
+GITHUB_TOKEN environment variable is used to authenticate, which is needed for the private repositories.`,
+	Args:        cobra.ExactArgs(1),
+	Annotations: map[string]string{repoOptional: "true"},
+	Run: func(cmd *cobra.Command, args []string) {
+		since, err := helper.ParseSince(orgSince, time.Now())
+		print.CheckIfError(err)
+		client := integrations.NewGitHubClient(os.Getenv("GITHUB_TOKEN"))
+		client.BaseURL = orgAPI
+		repos, err := client.ListOrgRepos(args[0])
+		print.CheckIfError(err)
+
+		s := survey.New(orgWorkers, since, orgTop)
+		s.Hotspots = orgHotspots
+		s.Clone = client.Clone
+		// The walks of the repositories analyzed at once would draw over each other
+		s.OnProgress, helper.OnProgress = helper.OnProgress, nil
+		filter := integrations.RepoFilter{Languages: orgLanguages, Archived: orgArchived, Forks: orgForks}
+		printResult(integrations.OrgChurn(args[0], repos, filter, s, orgHotspots))
+	},
+}
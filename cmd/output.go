@@ -0,0 +1,91 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+
+	"github.com/andymeneely/git-churn/print"
+	"github.com/andymeneely/git-churn/schema"
+)
+
+// Output formats
+const (
+	formatJSON  = "json"
+	formatJSONL = "jsonl"
+	formatText  = "text"
+	formatTree  = "tree"
+)
+
+var (
+	outputFormat    string
+	outputUnits     string
+	outputPrecision int
+)
+
+func init() {
+	pf := rootCmd.PersistentFlags()
+	pf.StringVar(&outputFormat, "format", formatJSON, "Output format, json, jsonl (a JSON object per line, streamed by the commits command), text or tree (the tree command only)")
+	pf.StringVar(&outputUnits, "units", print.UnitsLines, "Units of the line counts in text output, lines or kloc")
+	pf.IntVar(&outputPrecision, "precision", 2, "Number of decimals of ratios, scores and kLOC in text output")
+}
+
+// printResult writes the result of a command to stdout in the requested format.
+// JSON output always keeps the raw full precision values.
+func printResult(result interface{}) {
+	switch outputFormat {
+	case formatJSON:
+		out, err := json.Marshal(result)
+		print.CheckIfError(err)
+		out, err = schema.Stamp(out)
+		print.CheckIfError(err)
+		fmt.Println(string(out))
+	case formatJSONL:
+		print.CheckIfError(writeJSONL(os.Stdout, result))
+	case formatText, formatTree:
+		if outputUnits != print.UnitsLines && outputUnits != print.UnitsKLOC {
+			print.CheckIfError(fmt.Errorf("unknown units %q, expected %s or %s", outputUnits, print.UnitsLines, print.UnitsKLOC))
+		}
+		format := print.TextFormat{Units: outputUnits, Precision: outputPrecision}
+		if outputFormat == formatText {
+			print.CheckIfError(format.Write(os.Stdout, result))
+			return
+		}
+		tree, ok := result.(print.TreeNode)
+		if !ok {
+			print.CheckIfError(fmt.Errorf("the %s output format is only supported by the tree command", formatTree))
+		}
+		print.CheckIfError(format.WriteTree(os.Stdout, tree))
+	default:
+		print.CheckIfError(fmt.Errorf("unknown output format %q, expected %s, %s, %s or %s", outputFormat, formatJSON, formatJSONL, formatText, formatTree))
+	}
+}
+
+// writeJSONL writes the elements of a slice one JSON object per line, any other result on a single line
+func writeJSONL(w io.Writer, result interface{}) error {
+	v := reflect.ValueOf(result)
+	if v.Kind() != reflect.Slice && v.Kind() != reflect.Array {
+		return writeJSONLine(w, result)
+	}
+	for i := 0; i < v.Len(); i++ {
+		if err := writeJSONLine(w, v.Index(i).Interface()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeJSONLine writes the value as JSON on a line, stamped with the version of the schema
+func writeJSONLine(w io.Writer, value interface{}) error {
+	out, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	if out, err = schema.Stamp(out); err != nil {
+		return err
+	}
+	_, err = w.Write(append(out, '\n'))
+	return err
+}
@@ -0,0 +1,74 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	metrics "github.com/andymeneely/git-churn/matrics"
+	"github.com/andymeneely/git-churn/schema"
+	"github.com/andymeneely/git-churn/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+// jsonLines splits the output into its lines, checking that every one is a JSON object
+func jsonLines(t *testing.T, out string) []map[string]interface{} {
+	assert.True(t, strings.HasSuffix(out, "\n"), out)
+	var objects []map[string]interface{}
+	for _, line := range strings.Split(strings.TrimSuffix(out, "\n"), "\n") {
+		var object map[string]interface{}
+		assert.Nil(t, json.Unmarshal([]byte(line), &object), line)
+		objects = append(objects, object)
+	}
+	return objects
+}
+
+func TestWriteJSONL(t *testing.T) {
+	assert := assert.New(t)
+	var out bytes.Buffer
+	files := []metrics.FileChurn{{File: "a.go", Insertions: 2}, {File: "b\nc.go", Deletions: 1}}
+	assert.Nil(writeJSONL(&out, files))
+	// The newline of the second file name is escaped, the record staying on its line
+	lines := jsonLines(t, out.String())
+	if assert.Len(lines, 2) {
+		assert.Equal("a.go", lines[0]["File"])
+		assert.Equal("b\nc.go", lines[1]["File"])
+		assert.Equal(schema.Version, lines[1][schema.VersionField])
+	}
+
+	// Any other result on a single line
+	out.Reset()
+	assert.Nil(writeJSONL(&out, metrics.TopFile{File: "a.go", Commits: 3}))
+	lines = jsonLines(t, out.String())
+	if assert.Len(lines, 1) {
+		assert.Equal(3.0, lines[0]["Commits"])
+	}
+
+	out.Reset()
+	assert.Nil(writeJSONL(&out, []metrics.FileChurn{}))
+	assert.Empty(out.String())
+}
+
+func TestWriteJSONLineStream(t *testing.T) {
+	assert := assert.New(t)
+	repo := testutil.NewRepo(t)
+	first := repo.CommitFiles("first", map[string]string{"a.txt": "1\n"})
+	second := repo.CommitFiles("second", map[string]string{"a.txt": "1\n2\n"})
+	third := repo.As("bob").CommitFiles("third", map[string]string{"b.txt": "b\n"})
+
+	var out bytes.Buffer
+	err := metrics.ForEachCommitMetrics(repo.Repository, "", "HEAD", func(churn *metrics.CommitChurn) error {
+		return writeJSONLine(&out, churn)
+	})
+	assert.Nil(err)
+	// A line per commit, newest first
+	lines := jsonLines(t, out.String())
+	if assert.Len(lines, 3) {
+		assert.Equal(third.Hash.String(), lines[0]["Hash"])
+		assert.Equal("bob@example.com", lines[0]["Author"])
+		assert.Equal(second.Hash.String(), lines[1]["Hash"])
+		assert.Equal(1.0, lines[1]["Insertions"])
+		assert.Equal(first.Hash.String(), lines[2]["Hash"])
+	}
+}
@@ -0,0 +1,32 @@
+package cmd
+
+import (
+	"github.com/andymeneely/git-churn/gitfuncs"
+	metrics "github.com/andymeneely/git-churn/matrics"
+	"github.com/andymeneely/git-churn/print"
+	"github.com/spf13/cobra"
+)
+
+var pickaxeString string
+
+func init() {
+	rootCmd.AddCommand(pickaxeCmd)
+	addRangeFlags(pickaxeCmd)
+	pickaxeCmd.Flags().StringVar(&pickaxeString, "string", "", "String whose occurrences are tracked, e.g. the name of a deprecated function")
+	print.CheckIfError(pickaxeCmd.MarkFlagRequired("string"))
+}
+
+var pickaxeCmd = &cobra.Command{
+	Use:   "pickaxe",
+	Short: "Reports the commits adding and removing occurrences of a string",
+	Long: `Finds the commits of the range changing the number of occurrences of --string in a file, like
+git log -S, and reports how many occurrences each of them added and removed, and per month the occurrences
+added, removed and left in the tree, e.g. to follow the adoption of an API or the removal of a deprecated one.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		repo := gitfuncs.Clone(repoUrl)
+		report, err := metrics.PickaxeTrend(repo, rangeFrom, requestedRevision(), pickaxeString)
+		print.CheckIfError(err)
+
+		printResult(report)
+	},
+}
@@ -0,0 +1,80 @@
+package cmd
+
+import (
+	"os"
+	"time"
+
+	"github.com/andymeneely/git-churn/gitfuncs"
+	"github.com/andymeneely/git-churn/integrations"
+	metrics "github.com/andymeneely/git-churn/matrics"
+	"github.com/andymeneely/git-churn/print"
+	"github.com/spf13/cobra"
+)
+
+var (
+	prComment   bool
+	prTop       int
+	prAPI       string
+	prReviewers int
+)
+
+func init() {
+	rootCmd.AddCommand(prCmd)
+	flags := prCmd.Flags()
+	flags.BoolVar(&prComment, "comment", false, "Post the churn summary as a comment on the pull request")
+	flags.IntVar(&prTop, "top", 10, "Number of most changed files to report, all of them if 0")
+	flags.IntVar(&prReviewers, "reviewers", 0, "Number of reviewers to suggest, by the ownership of the lines the pull request touches and the recent activity on its files, none if 0")
+	flags.StringVar(&prAPI, "api", "", "Root of the API, told by the URL of the pull request by default")
+}
+
+// Environment variables of the tokens authenticating with the code hosting services
+var prTokens = map[string]string{
+	integrations.GitHub:    "GITHUB_TOKEN",
+	integrations.GitLab:    "GITLAB_TOKEN",
+	integrations.Bitbucket: "BITBUCKET_TOKEN",
+}
+
+var prCmd = &cobra.Command{
+	Use:   "pr <url>",
+	Short: "Reports the churn of a GitHub or Bitbucket pull request or of a GitLab merge request",
+	Long: `Looks up the base and head commits of the GitHub pull request, GitLab merge request or Bitbucket
+Cloud pull request at the given URL and reports the churn of exactly its commits. The GITHUB_TOKEN,
+GITLAB_TOKEN or BITBUCKET_TOKEN environment variable is used to authenticate, which is needed for private
+repositories and to post the summary back with --comment. BITBUCKET_TOKEN is an app password when
+BITBUCKET_USERNAME is set too. The API of GitHub Enterprise and self-hosted GitLab is found from the URL.
+With --reviewers, reviewers are suggested for it too, like the reviewers command does for a local branch.`,
+	Args:        cobra.ExactArgs(1),
+	Annotations: map[string]string{repoOptional: "true"},
+	Run: func(cmd *cobra.Command, args []string) {
+		review, err := integrations.ParseReviewURL(args[0])
+		print.CheckIfError(err)
+		api := prAPI
+		if api == "" {
+			api = review.API()
+		}
+		host, err := integrations.NewCodeHost(review.Service, api, os.Getenv(prTokens[review.Service]), os.Getenv("BITBUCKET_USERNAME"))
+		print.CheckIfError(err)
+		pr, err := host.GetPullRequest(review.Owner, review.Repo, review.Number)
+		print.CheckIfError(err)
+
+		cloneURL := pr.CloneURL
+		if repoUrl != "" {
+			// e.g. a local mirror of the repository
+			cloneURL = repoUrl
+		}
+		repo := gitfuncs.Clone(cloneURL)
+		report, err := integrations.PullRequestChurn(repo, pr, prTop)
+		print.CheckIfError(err)
+		if prReviewers > 0 {
+			options := metrics.ReviewerOptions{ActivityWindow: 90 * 24 * time.Hour, Max: prReviewers}
+			report.Reviewers, err = integrations.PullRequestReviewers(repo, pr, options)
+			print.CheckIfError(err)
+		}
+
+		if prComment {
+			print.CheckIfError(host.PostComment(review.Owner, review.Repo, review.Number, report.Markdown()))
+			print.Info("Commented on %s", pr.URL)
+		}
+		printResult(report)
+	},
+}
@@ -0,0 +1,11 @@
+package cmd
+
+import "github.com/spf13/cobra"
+
+var rangeFrom string
+
+// addRangeFlags adds the flags of the commands analysing a range of commits. The range ends at
+// --commit (or --branch, HEAD by default) and starts after --from, or at the root commit.
+func addRangeFlags(c *cobra.Command) {
+	c.Flags().StringVar(&rangeFrom, "from", "", "Revision the analysed range starts after (exclusive), the whole history if empty")
+}
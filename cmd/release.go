@@ -0,0 +1,36 @@
+package cmd
+
+import (
+	"github.com/andymeneely/git-churn/gitfuncs"
+	metrics "github.com/andymeneely/git-churn/matrics"
+	"github.com/andymeneely/git-churn/print"
+	"github.com/spf13/cobra"
+)
+
+var (
+	releaseFrom string
+	releaseTo   string
+	releaseTop  int
+)
+
+func init() {
+	rootCmd.AddCommand(releaseCmd)
+	flags := releaseCmd.Flags()
+	flags.StringVar(&releaseFrom, "from", "", "Tag of the previous release")
+	flags.StringVar(&releaseTo, "to", "", "Tag of the release")
+	flags.IntVar(&releaseTop, "top", 10, "Number of most changed files to report, all of them if 0")
+}
+
+var releaseCmd = &cobra.Command{
+	Use:   "release",
+	Short: "Reports the churn between two release tags",
+	Long: `Reports the churn totals, the most changed files and the churn per author between the --from and
+--to tags. The two latest semantic version tags are compared when no tag is given.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		repo := gitfuncs.Clone(repoUrl)
+		report, err := metrics.GetReleaseReport(repo, releaseFrom, releaseTo, releaseTop)
+		print.CheckIfError(err)
+
+		printResult(report)
+	},
+}
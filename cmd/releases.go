@@ -0,0 +1,30 @@
+package cmd
+
+import (
+	"github.com/andymeneely/git-churn/gitfuncs"
+	metrics "github.com/andymeneely/git-churn/matrics"
+	"github.com/andymeneely/git-churn/print"
+	"github.com/spf13/cobra"
+)
+
+var releasesLast int
+
+func init() {
+	rootCmd.AddCommand(releasesCmd)
+	releasesCmd.Flags().IntVar(&releasesLast, "last", 0, "Number of latest releases to report, all of them if 0")
+}
+
+var releasesCmd = &cobra.Command{
+	Use:   "releases",
+	Short: "Reports the cadence of the releases and the churn of each",
+	Long: `Pairs the consecutive semantic version tags and reports, for the --last releases, the days since the
+previous release and the churn of each, along with the mean and median days between releases, flagging
+the releases whose churn is unusually large, above the upper Tukey fence of the churn of the releases.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		repo := gitfuncs.Clone(repoUrl)
+		cadence, err := metrics.GetReleaseCadence(repo, releasesLast)
+		print.CheckIfError(err)
+
+		printResult(cadence)
+	},
+}
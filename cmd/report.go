@@ -0,0 +1,44 @@
+package cmd
+
+import (
+	"errors"
+	"os"
+
+	"github.com/andymeneely/git-churn/gitfuncs"
+	"github.com/andymeneely/git-churn/print"
+	"github.com/andymeneely/git-churn/report"
+	"github.com/spf13/cobra"
+)
+
+var (
+	reportHTML string
+	reportTop  int
+)
+
+func init() {
+	rootCmd.AddCommand(reportCmd)
+	addRangeFlags(reportCmd)
+	reportCmd.Flags().StringVar(&reportHTML, "html", "", "Path of the HTML report to write")
+	reportCmd.Flags().IntVar(&reportTop, "top", 15, "Number of hotspots and most changed files to show")
+}
+
+var reportCmd = &cobra.Command{
+	Use:   "report",
+	Short: "Renders churn, hotspots, ownership and trends into a report",
+	Long: `Renders the churn, hotspots, ownership and trends of the commits from --from to --commit (or --branch,
+HEAD by default) into a standalone HTML file that can be shared with people who do not use the CLI.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if reportHTML == "" {
+			print.CheckIfError(errors.New("the path of the report has to be given with --html"))
+		}
+		repo := gitfuncs.Clone(repoUrl)
+		data, err := report.Build(repo, repoUrl, rangeFrom, requestedRevision(), reportTop)
+		print.CheckIfError(err)
+
+		out, err := os.Create(reportHTML)
+		print.CheckIfError(err)
+		defer out.Close()
+		print.CheckIfError(report.WriteHTML(out, data))
+		print.Info("Report written to %s", reportHTML)
+	},
+}
@@ -0,0 +1,27 @@
+package cmd
+
+import (
+	"github.com/andymeneely/git-churn/gitfuncs"
+	metrics "github.com/andymeneely/git-churn/matrics"
+	"github.com/andymeneely/git-churn/print"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	rootCmd.AddCommand(retentionCmd)
+	addRangeFlags(retentionCmd)
+}
+
+var retentionCmd = &cobra.Command{
+	Use:   "retention",
+	Short: "Reports the contributor retention per quarter",
+	Long: `Reports per quarter how many authors are new, retained, returning or inactive compared to the
+previous quarter, along with the churn attributable to each group.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		repo := gitfuncs.Clone(repoUrl)
+		commits, err := metrics.RangeChurn(repo, rangeFrom, requestedRevision())
+		print.CheckIfError(err)
+
+		printResult(metrics.ContributorRetention(commits))
+	},
+}
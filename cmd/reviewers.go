@@ -0,0 +1,54 @@
+package cmd
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"time"
+
+	"github.com/andymeneely/git-churn/gitfuncs"
+	metrics "github.com/andymeneely/git-churn/matrics"
+	"github.com/andymeneely/git-churn/print"
+	"github.com/spf13/cobra"
+)
+
+var (
+	reviewersBase         string
+	reviewersHead         string
+	reviewersLoadFile     string
+	reviewersActivityDays int
+	reviewersMax          int
+)
+
+func init() {
+	rootCmd.AddCommand(reviewersCmd)
+	flags := reviewersCmd.Flags()
+	flags.StringVar(&reviewersBase, "base", "master", "Branch the changeset is going to be merged into")
+	flags.StringVar(&reviewersHead, "head", "", "Branch or commit at the tip of the changeset")
+	flags.StringVar(&reviewersLoadFile, "load", "", "JSON file mapping reviewer emails to their number of open reviews")
+	flags.IntVar(&reviewersActivityDays, "activity-days", 90, "Number of days of recent activity on the changed files to consider")
+	flags.IntVar(&reviewersMax, "max", 3, "Maximum number of reviewers to suggest, all of them if 0")
+	print.CheckIfError(reviewersCmd.MarkFlagRequired("head"))
+}
+
+var reviewersCmd = &cobra.Command{
+	Use:   "reviewers",
+	Short: "Suggests reviewers for a changeset",
+	Long: `Suggests reviewers for the changeset of --head against --base by combining the ownership of the
+lines it touches, the recent activity on the files it changes and the open reviews of every candidate.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		options := metrics.ReviewerOptions{
+			ActivityWindow: time.Duration(reviewersActivityDays) * 24 * time.Hour,
+			Max:            reviewersMax,
+		}
+		if reviewersLoadFile != "" {
+			content, err := ioutil.ReadFile(reviewersLoadFile)
+			print.CheckIfError(err)
+			print.CheckIfError(json.Unmarshal(content, &options.Load))
+		}
+		repo := gitfuncs.Clone(repoUrl)
+		suggestions, err := metrics.SuggestReviewers(repo, reviewersBase, reviewersHead, options)
+		print.CheckIfError(err)
+
+		printResult(suggestions)
+	},
+}
@@ -0,0 +1,29 @@
+package cmd
+
+import (
+	"github.com/andymeneely/git-churn/gitfuncs"
+	metrics "github.com/andymeneely/git-churn/matrics"
+	"github.com/andymeneely/git-churn/print"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	rootCmd.AddCommand(reviewsCmd)
+	addRangeFlags(reviewsCmd)
+}
+
+var reviewsCmd = &cobra.Command{
+	Use:   "reviews",
+	Short: "Reports the churn reviewed per reviewer and the churn that landed unreviewed",
+	Long: `Finds the reviewers of every commit of the range from its Reviewed-by and Signed-off-by trailers,
+and from the author and the Reviewed-by trailers of the merge it landed through, and reports the churn
+every reviewer reviewed, the share of the churn that landed unreviewed, the unreviewed commits and how
+long the reviewed commits took to land, correlated with their churn. Nobody reviews their own commits.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		repo := gitfuncs.Clone(repoUrl)
+		report, err := metrics.ReviewCoverage(repo, rangeFrom, requestedRevision())
+		print.CheckIfError(err)
+
+		printResult(report)
+	},
+}
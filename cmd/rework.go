@@ -0,0 +1,32 @@
+package cmd
+
+import (
+	"time"
+
+	"github.com/andymeneely/git-churn/gitfuncs"
+	metrics "github.com/andymeneely/git-churn/matrics"
+	"github.com/andymeneely/git-churn/print"
+	"github.com/spf13/cobra"
+)
+
+var reworkWindowDays int
+
+func init() {
+	rootCmd.AddCommand(reworkCmd)
+	addRangeFlags(reworkCmd)
+	reworkCmd.Flags().IntVar(&reworkWindowDays, "window-days", 14, "Age in days under which a deleted line counts as reworked")
+}
+
+var reworkCmd = &cobra.Command{
+	Use:   "rework",
+	Short: "Reports the rate of lines reworked shortly after being added",
+	Long: `Blames the lines deleted by every commit of the range and reports how many of them were deleted
+within --window-days of being added, overall, per file and per author who added them.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		repo := gitfuncs.Clone(repoUrl)
+		report, err := metrics.ReworkRate(repo, rangeFrom, requestedRevision(), time.Duration(reworkWindowDays)*24*time.Hour)
+		print.CheckIfError(err)
+
+		printResult(report)
+	},
+}
@@ -0,0 +1,40 @@
+package cmd
+
+import (
+	"github.com/andymeneely/git-churn/gitfuncs"
+	metrics "github.com/andymeneely/git-churn/matrics"
+	"github.com/andymeneely/git-churn/print"
+	"github.com/spf13/cobra"
+)
+
+var (
+	riskN       int
+	riskWeights string
+)
+
+func init() {
+	rootCmd.AddCommand(riskCmd)
+	addRangeFlags(riskCmd)
+	flags := riskCmd.Flags()
+	flags.IntVar(&riskN, "n", 20, "Number of commits to report, all of them if 0")
+	flags.StringVar(&riskWeights, "weights", "", "Weights of the risk factors churn, files, entropy, hotspots and experience, e.g. churn=2,entropy=0.5, 1 by default")
+}
+
+var riskCmd = &cobra.Command{
+	Use:   "risk",
+	Short: "Ranks the riskiest commits of a range",
+	Long: `Scores every commit of the range by combining its churn, the number of files it touches, the
+entropy of its changes across those files, the hotspots it touches and the experience of its author with
+those files, and reports the --n riskiest commits, e.g. to prioritize reviews.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		weights, err := metrics.ParseRiskWeights(riskWeights)
+		print.CheckIfError(err)
+		repo := gitfuncs.Clone(repoUrl)
+		commits, err := metrics.RangeChurn(repo, rangeFrom, requestedRevision())
+		print.CheckIfError(err)
+		risks, err := metrics.CommitRisks(repo, commits, requestedRevision(), weights, riskN)
+		print.CheckIfError(err)
+
+		printResult(risks)
+	},
+}
@@ -2,41 +2,128 @@ package cmd
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"github.com/andymeneely/git-churn/gitfuncs"
+	"github.com/andymeneely/git-churn/helper"
+	"github.com/andymeneely/git-churn/lang"
 	metrics "github.com/andymeneely/git-churn/matrics"
 	"github.com/andymeneely/git-churn/print"
+	"github.com/andymeneely/git-churn/schema"
+	"github.com/andymeneely/git-churn/version"
 	"github.com/spf13/cobra"
+	"gopkg.in/src-d/go-git.v4"
 	"os"
+	"os/signal"
+	"syscall"
+	"time"
 )
 
 func init() {
 	rootCmd.AddCommand(versionCmd)
+	versionCmd.Flags().BoolVar(&versionJSON, "json", false, "Print the version and build metadata as JSON")
 	pf := rootCmd.PersistentFlags()
 	pf.StringVarP(&repoUrl, "repo", "r", "", "Git Repository URL on which the churn metrics has to be computed")
-	print.CheckIfError(cobra.MarkFlagRequired(pf, "repo"))
 	pf.StringVarP(&commitId, "commit", "c", "", "Commit hash for which the metrics has to be computed")
-	print.CheckIfError(cobra.MarkFlagRequired(pf, "commit"))
+	pf.StringVarP(&branch, "branch", "b", "", "Branch, tag or any other ref to be analysed when no commit hash is given")
 	pf.StringVarP(&filepath, "filepath", "f", "", "File path for the file on which the commit metrics has to be computed")
 	pf.BoolVarP(&whitespace, "whitespace", "w", true, "Excludes whitespaces while calculating the churn metrics is set to false")
+	pf.StringSliceVar(&testPatterns, "test-patterns", nil, "Patterns of the paths of test files, e.g. *_test.go,test/ (default common test layouts)")
+	pf.StringVar(&churnMode, "churn-mode", string(metrics.ChurnTotal), "Definition of churn: added, total (added+deleted), net (added-deleted) or recent (deleted within --churn-window-days of being added)")
+	pf.IntVar(&churnWindowDays, "churn-window-days", 21, "Age in days under which a deleted line counts as churn in the recent churn mode")
+	pf.StringVar(&ignoreRevsFile, "ignore-revs-file", "", "File listing the commits blame skips, like bulk reformats, one hash per line (see git blame --ignore-revs-file)")
+	pf.BoolVar(&blameIgnoreWhitespace, "blame-ignore-whitespace", false, "Ignore whitespace changes when blaming the deleted lines (see git blame -w)")
+	pf.StringVar(&mailmapFile, "mailmap", "", "Mailmap file merging the identities of the authors instead of the .mailmap of the repository (see gitmailmap(5))")
+	pf.StringSliceVar(&componentSpecs, "components", nil, "Components of a monorepo the component metrics aggregate the files into, as name=path or path, e.g. billing=services/billing,web")
+	pf.StringVar(&teamsFile, "teams", "", "Team mapping file, in the format of CODEOWNERS, mapping paths and author emails to teams to aggregate the metrics per team")
+	pf.StringSliceVar(&botAuthors, "bot-authors", nil, "Regular expressions matching the \"Name <email>\" of the bots whose commits are left out (default dependabot, renovate and other [bot] accounts)")
+	pf.StringSliceVar(&botMessages, "bot-messages", nil, "Regular expressions matching the messages of the automated commits left out too, e.g. ^chore\\(deps\\), none by default")
+	pf.BoolVar(&botCoAuthors, "bot-co-authors", false, "Leave out the commits co-authored by bots too, per their Co-authored-by trailers")
+	pf.StringSliceVar(&fixPatterns, "fix-patterns", nil, "Regular expressions matching the messages of the commits fixing bugs, e.g. (?i)\\bfix (default fix, bug, defect, hotfix and issue references)")
+	pf.StringSliceVar(&ignorePatterns, "ignore", nil, "Patterns of more files to leave out of the churn and LOC metrics, in the syntax of .gitignore, e.g. *_gen.go")
+	pf.StringSliceVar(&pathRewrites, "path-rewrites", nil, "Rules rewriting the paths of the files before they are aggregated, as pattern=replacement with a regular expression, e.g. ^pkg/=internal/, to follow the churn of files across a restructuring")
+	pf.StringVar(&pathScope, "path", "", "Subtree of the repository the whole analysis is restricted to, e.g. services/billing, the files outside of it being left out and their trees not even read")
+	pf.BoolVar(&noIgnore, "no-ignore", false, "Keep the vendored and generated files the defaults (vendor/, node_modules/, dist/, *.pb.go) and the .churnignore of the repository leave out")
+	pf.BoolVar(&firstParent, "first-parent", false, "Follow only the first parent of the merge commits, counting a merged branch once by the diff of its merge (see git log --first-parent)")
+	pf.BoolVar(&ignoreAllSpace, "ignore-all-space", false, "Ignore the lines whose whitespace only changed, so that reformatting churns nothing (see git diff -w)")
+	pf.BoolVar(&detectMoves, "detect-moves", false, "Pair the files of the directories moved as a whole, e.g. src/a to src/b, so that only the lines edited along with the move churn, and list the moves")
+	pf.BoolVar(&ignoreEOL, "ignore-eol", false, "Ignore the lines whose line ending only changed, e.g. converted from CRLF to LF (see git diff --ignore-cr-at-eol)")
+	pf.Float64Var(&reformatWeight, "reformat-weight", 1, "Weight of the churn of the mass reformat commits in the range metrics, from 0 leaving them out to 1 counting them like the others without looking for them; reformats are the commits of .git-blame-ignore-revs and --ignore-revs-file and those changing little but whitespace")
+	pf.BoolVar(&countTokens, "tokens", false, "Also count the tokens of code added and deleted, so that re-wrapping a line churns nothing and renaming an identifier churns one token")
+	pf.StringVar(&grepMessage, "grep", "", "Only count the commits whose message matches the regular expression (see git log --grep)")
+	pf.StringVarP(&diffGrep, "diff-grep", "G", "", "Only count the commits adding or deleting a line matching the regular expression, e.g. TODO (see git log -G)")
+	pf.StringVarP(&pickaxe, "pickaxe", "S", "", "Only count the commits changing the number of occurrences of the string, e.g. a function name (see git log -S)")
+	pf.BoolVar(&includeBots, "include-bots", false, "Keep the commits of bots in the churn and author metrics")
+	pf.BoolVarP(&quiet, "quiet", "q", false, "Only print the errors and the results, without progress")
+	pf.BoolVarP(&verbose, "verbose", "v", false, "Print the details of the analysis and the time each step takes, same as --log-level debug")
+	pf.StringVar(&logLevel, "log-level", "info", "Most verbose messages printed: error, warning, info or debug")
+	pf.StringVar(&blameCacheDir, "blame-cache-dir", "", "Directory keeping the blames for the next runs, the blames being only cached in memory for the run if empty")
+	pf.StringVar(&storageBackend, "storage", gitfuncs.StorageMemory, "Where the repositories are cloned: memory, disk (temporary directories removed on exit, for big repositories) or auto (picked with --max-memory)")
+	pf.StringVar(&objectCacheSize, "object-cache", "", "Size of the cache of the objects read from the clones on disk, e.g. 512MB, 96MB if empty")
+	pf.BoolVar(&prefetch, "prefetch", false, "Read the objects of the analysed range into the object cache of the clones on disk in one pass before walking it")
+	pf.StringVar(&maxMemory, "max-memory", "", "Memory the analysis should stay under, e.g. 2GB, cloning on disk the repositories that may not fit unless --storage is given")
+	pf.StringVar(&engine, "engine", "go-git", "Engine computing the line stats and blames, go-git or cli (the git command line)")
 }
 
 var (
 	//TODO: Add whitespace exclusion bool flag
 	repoUrl    string
 	commitId   string
+	branch     string
 	filepath   string
 	whitespace bool
 
+	versionJSON  bool
+	testPatterns []string
+
+	churnMode       string
+	churnWindowDays int
+
+	ignoreRevsFile        string
+	blameIgnoreWhitespace bool
+	engine                string
+	blameCacheDir         string
+	storageBackend        string
+	objectCacheSize       string
+	prefetch              bool
+	maxMemory             string
+	mailmapFile           string
+
+	botAuthors     []string
+	botMessages    []string
+	botCoAuthors   bool
+	includeBots    bool
+	ignorePatterns []string
+	pathScope      string
+	pathRewrites   []string
+	noIgnore       bool
+	fixPatterns    []string
+	firstParent    bool
+	detectMoves    bool
+	ignoreEOL      bool
+	ignoreAllSpace bool
+	reformatWeight float64
+	countTokens    bool
+	teamsFile      string
+	componentSpecs []string
+	grepMessage    string
+	diffGrep       string
+	pickaxe        string
+
+	quiet    bool
+	verbose  bool
+	logLevel string
+
 	rootCmd = &cobra.Command{
 		Use:   "git-churn",
 		Short: "A fast tool for collecting code churn metrics from git repositories.",
 		Long: `git-churn gives the churn metrics like insertions, deletions, etc for the given commit hash in the repo specified.
                 Complete documentation is available at https://github.com/andymeneely/git-churn`,
+		PersistentPreRunE: preRun,
 		Run: func(cmd *cobra.Command, args []string) {
 			var churnMetrics interface{}
 			var err error
-			repo := gitfuncs.Checkout(repoUrl, commitId)
+			repo := checkoutRepo()
 			if whitespace {
 				if filepath != "" {
 					churnMetrics, err = metrics.GetChurnMetricsWithWhitespace(repo, filepath)
@@ -52,28 +139,193 @@ var (
 				print.CheckIfError(err)
 			}
 			//fmt.Println(fmt.Sprintf("%v", churnMetrics))
-			out, err := json.Marshal(churnMetrics)
-			if err != nil {
-				panic(err)
-			}
-
-			fmt.Println(string(out))
+			printResult(churnMetrics)
 		},
 	}
 )
 
+// preRun completes the flags with the config file, sets up the metrics with them and checks --repo
+func preRun(cmd *cobra.Command, args []string) error {
+	if err := loadConfig(cmd); err != nil {
+		return err
+	}
+	applyOptions(cmd)
+	return checkRepoFlag(cmd, args)
+}
+
+// applyOptions configures the metrics with the options given on the command line
+func applyOptions(cmd *cobra.Command) {
+	applyLogLevel()
+	metrics.TestFiles = lang.NewTestClassifier(testPatterns)
+	mode, err := metrics.ParseChurnMode(churnMode)
+	print.CheckIfError(err)
+	metrics.Churn = metrics.ChurnDefinition{Mode: mode, Window: time.Duration(churnWindowDays) * 24 * time.Hour}
+	applyStorage(cmd)
+	activeEngine, err := gitfuncs.EngineByName(engine)
+	print.CheckIfError(err)
+	gitfuncs.ActiveEngine = gitfuncs.CachedEngine{Engine: activeEngine, Cache: gitfuncs.NewBlameCache(blameCacheEntries, blameCacheDir)}
+	gitfuncs.BlameOpts = gitfuncs.BlameOptions{IgnoreWhitespace: blameIgnoreWhitespace}
+	if ignoreRevsFile != "" {
+		gitfuncs.BlameOpts.IgnoreRevs, err = gitfuncs.ReadIgnoreRevsFile(ignoreRevsFile)
+		print.CheckIfError(err)
+	}
+	if blameLines != "" {
+		if _, err := fmt.Sscanf(blameLines, "%d,%d", &gitfuncs.BlameOpts.StartLine, &gitfuncs.BlameOpts.EndLine); err != nil {
+			print.CheckIfError(fmt.Errorf("invalid line range %q, expected start,end", blameLines))
+		}
+	}
+	gitfuncs.IgnorePatterns = ignorePatterns
+	gitfuncs.IgnoreDisabled = noIgnore
+	gitfuncs.SetPathScope(pathScope)
+	gitfuncs.FirstParent = firstParent
+	gitfuncs.IgnoreEOL = ignoreEOL
+	gitfuncs.IgnoreAllSpace = ignoreAllSpace
+	gitfuncs.DetectDirMoves = detectMoves
+	metrics.Bots, err = metrics.NewBotFilter(botAuthors, botMessages, botCoAuthors)
+	print.CheckIfError(err)
+	if includeBots {
+		metrics.Bots = nil
+	}
+	metrics.CountTokens = countTokens
+	metrics.Search, err = metrics.NewCommitSearch(grepMessage, diffGrep, pickaxe)
+	print.CheckIfError(err)
+	metrics.Reformats = nil
+	if reformatWeight < 0 || reformatWeight > 1 {
+		print.CheckIfError(fmt.Errorf("--reformat-weight must be between 0 and 1, got %v", reformatWeight))
+	}
+	if reformatWeight < 1 {
+		metrics.Reformats = metrics.NewReformatFilter(reformatWeight)
+		metrics.Reformats.Revs = gitfuncs.BlameOpts.IgnoreRevs
+	}
+	metrics.Fixes, err = metrics.NewFixDetector(fixPatterns)
+	print.CheckIfError(err)
+	metrics.Teams = nil
+	if teamsFile != "" {
+		metrics.Teams, err = metrics.ReadTeamMapFile(teamsFile)
+		print.CheckIfError(err)
+	}
+	metrics.Components, err = metrics.ParseComponents(componentSpecs)
+	print.CheckIfError(err)
+	metrics.PathRewrites, err = metrics.ParsePathRewrites(pathRewrites)
+	print.CheckIfError(err)
+	if mailmapFile != "" {
+		gitfuncs.MailmapOverride, err = gitfuncs.ReadMailmapFile(mailmapFile)
+		print.CheckIfError(err)
+	}
+}
+
+// applyStorage picks the backend the repositories are cloned into, removing their temporary
+// directories on exit
+func applyStorage(cmd *cobra.Command) {
+	var err error
+	gitfuncs.Storage, err = gitfuncs.ParseStorage(storageBackend)
+	print.CheckIfError(err)
+	gitfuncs.MaxMemory = 0
+	if maxMemory != "" {
+		gitfuncs.MaxMemory, err = helper.ParseSize(maxMemory)
+		print.CheckIfError(err)
+		if !cmd.Flags().Changed("storage") {
+			gitfuncs.Storage = gitfuncs.StorageAuto
+		}
+	}
+	gitfuncs.ObjectCacheSize = 0
+	if objectCacheSize != "" {
+		gitfuncs.ObjectCacheSize, err = helper.ParseSize(objectCacheSize)
+		print.CheckIfError(err)
+	}
+	gitfuncs.Prefetch = prefetch
+	print.AtExit(gitfuncs.RemoveTempClones)
+}
+
+// applyLogLevel sets the verbosity of the messages and draws a progress bar of the long analyses on
+// terminals, unless --quiet
+func applyLogLevel() {
+	if quiet && verbose {
+		print.CheckIfError(errors.New("--quiet and --verbose are mutually exclusive"))
+	}
+	level, err := print.ParseLevel(logLevel)
+	print.CheckIfError(err)
+	if quiet {
+		level = print.LevelError
+	} else if verbose {
+		level = print.LevelDebug
+	}
+	print.LogLevel = level
+	helper.OnProgress = nil
+	if level >= print.LevelInfo && print.IsTerminal(os.Stderr) {
+		bar := print.NewProgressBar(os.Stderr)
+		helper.OnProgress = func(progress helper.Progress) {
+			bar.Draw(progress.Task, progress.Done, progress.Total, progress.Finished)
+		}
+	}
+}
+
+// Most blames kept in memory, each being the size of the file blamed
+const blameCacheEntries = 1000
+
+// Commands annotated with repoOptional do not analyse the repository given by --repo
+const repoOptional = "repoOptional"
+
+// checkRepoFlag makes sure --repo is given to the commands analysing it
+func checkRepoFlag(cmd *cobra.Command, args []string) error {
+	if repoUrl == "" && cmd.Annotations[repoOptional] == "" {
+		return errors.New(`required flag(s) "repo" not set`)
+	}
+	return nil
+}
+
+// checkoutRepo clones the repository given on the command line and checks out the requested
+// commit, or the tip of the requested branch/ref when no commit hash is given
+func checkoutRepo() *git.Repository {
+	if commitId != "" {
+		return gitfuncs.Checkout(repoUrl, commitId)
+	}
+	if branch == "" {
+		print.CheckIfError(errors.New("either --commit or --branch has to be specified"))
+	}
+	return gitfuncs.CheckoutRef(repoUrl, branch)
+}
+
+// requestedRevision returns the commit hash or ref given on the command line, or HEAD if none was given
+func requestedRevision() string {
+	if commitId != "" {
+		return commitId
+	}
+	if branch != "" {
+		return branch
+	}
+	return "HEAD"
+}
+
 var versionCmd = &cobra.Command{
-	Use:   "version",
-	Short: "Print the version number of git-churn",
-	Long:  `All software has versions. This is git-churn's`,
+	Use:         "version",
+	Short:       "Print the version number of git-churn",
+	Long:        `All software has versions. This is git-churn's`,
+	Annotations: map[string]string{repoOptional: "true"},
 	Run: func(cmd *cobra.Command, args []string) {
-		fmt.Println("git-churn version 0.1")
+		if versionJSON {
+			out, err := json.Marshal(version.Get())
+			print.CheckIfError(err)
+			out, err = schema.Stamp(out)
+			print.CheckIfError(err)
+			fmt.Println(string(out))
+			return
+		}
+		fmt.Println(version.Get())
 	},
 }
 
 func Execute() {
+	// The temporary clones are removed when interrupted too
+	interrupts := make(chan os.Signal, 1)
+	signal.Notify(interrupts, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		<-interrupts
+		print.Exit(130)
+	}()
 	if err := rootCmd.Execute(); err != nil {
 		fmt.Println(err)
-		os.Exit(1)
+		print.Exit(1)
 	}
+	gitfuncs.RemoveTempClones()
 }
@@ -0,0 +1,94 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/andymeneely/git-churn/gitfuncs"
+	"github.com/andymeneely/git-churn/integrations"
+	metrics "github.com/andymeneely/git-churn/matrics"
+	"github.com/andymeneely/git-churn/print"
+	"github.com/andymeneely/git-churn/schema"
+	"github.com/andymeneely/git-churn/survey"
+	"github.com/andymeneely/git-churn/version"
+	"github.com/andymeneely/git-churn/webhook"
+	"github.com/spf13/cobra"
+)
+
+// commandResults are the results every command prints in JSON, by command, to describe in the schema. A
+// command printing a new type of result has to be added here.
+var commandResults = map[string][]interface{}{
+	"git-churn":     {metrics.FileChurnMetrics{}, metrics.AggrChurMetrics{}},
+	"attribution":   {metrics.AttributionReport{}},
+	"blame":         {[]blamedLine{}},
+	"branches":      {metrics.DivergenceReport{}},
+	"check":         {metrics.CheckResult{}},
+	"codeowners":    {metrics.CodeownersReport{}},
+	"commits":       {[]*metrics.CommitChurn{}},
+	"components":    {metrics.ComponentReport{}},
+	"compare":       {metrics.BranchComparison{}},
+	"conflicts":     {metrics.ConflictPrediction{}},
+	"contributors":  {[]metrics.Contributor{}},
+	"defects":       {metrics.DefectReport{}},
+	"diff":          {metrics.FileDiffMetrics{}},
+	"entropy":       {metrics.EntropyReport{}},
+	"follow":        {metrics.FileLifetime{}},
+	"growth":        {[]metrics.GrowthPoint{}},
+	"heatmap":       {metrics.HeatmapReport{}},
+	"hunks":         {[]gitfuncs.Hunk{}},
+	"lines":         {[]*gitfuncs.FileLineChanges{}},
+	"loc":           {metrics.LOCSnapshot{}},
+	"metrics":       {map[string]interface{}{}},
+	"org":           {integrations.OrgReport{}},
+	"pickaxe":       {metrics.PickaxeReport{}},
+	"pr":            {integrations.PullRequestReport{}},
+	"release":       {metrics.ReleaseReport{}},
+	"releases":      {metrics.ReleaseCadence{}},
+	"retention":     {[]metrics.QuarterRetention{}},
+	"reviewers":     {metrics.ReviewerSuggestions{}},
+	"reviews":       {metrics.ReviewReport{}},
+	"rework":        {metrics.ReworkReport{}},
+	"risk":          {[]metrics.CommitRisk{}},
+	"stability":     {[]metrics.FileStability{}},
+	"stats":         {metrics.CommitSizeStats{}},
+	"survey":        {survey.Report{}},
+	"survival":      {metrics.SurvivalReport{}},
+	"symbols":       {[]metrics.SymbolChurn{}},
+	"szz":           {metrics.SZZReport{}},
+	"teams":         {[]metrics.TeamChurn{}},
+	"tenure":        {metrics.TenureReport{}},
+	"top":           {[]metrics.TopFile{}, []metrics.SymbolChurn{}},
+	"tree":          {metrics.DirChurn{}},
+	"types":         {metrics.ChangeTypeReport{}},
+	"verify-remote": {[]metrics.InvariantViolation{}},
+	"version":       {version.Info{}},
+	// Posted to --forward
+	"webhook": {webhook.Result{}},
+}
+
+func init() {
+	rootCmd.AddCommand(schemaCmd)
+}
+
+var schemaCmd = &cobra.Command{
+	Use:   "schema [<command>]",
+	Short: "Prints the JSON Schema of the JSON results of the commands",
+	Long: `Prints the JSON Schema (draft 2020-12) of the JSON results of the given command, or of every command, for
+the consumers of the results to validate them. Every JSON result, and every object of a JSON array or of
+jsonl output, carries the version of the schema it follows in its SchemaVersion field. The minor version
+is bumped when fields are added, the major one when fields are removed, renamed or change meaning.`,
+	Args:        cobra.MaximumNArgs(1),
+	Annotations: map[string]string{repoOptional: "true"},
+	Run: func(cmd *cobra.Command, args []string) {
+		doc := schema.Document(commandResults)
+		if len(args) == 1 {
+			var ok bool
+			if doc, ok = schema.For(commandResults, args[0]); !ok {
+				print.CheckIfError(fmt.Errorf("the command %q prints no JSON result", args[0]))
+			}
+		}
+		out, err := json.MarshalIndent(doc, "", "  ")
+		print.CheckIfError(err)
+		fmt.Println(string(out))
+	},
+}
@@ -0,0 +1,60 @@
+package cmd
+
+import (
+	"net/http"
+	"time"
+
+	metrics "github.com/andymeneely/git-churn/matrics"
+	"github.com/andymeneely/git-churn/print"
+	"github.com/andymeneely/git-churn/server"
+	"github.com/spf13/cobra"
+)
+
+var (
+	serveAddr     string
+	serveRefresh  time.Duration
+	serveCacheTTL time.Duration
+	serveJobs     int
+	serveQueued   int
+	serveRepos    int
+	serveAllowed  []string
+)
+
+func init() {
+	rootCmd.AddCommand(serveCmd)
+	flags := serveCmd.Flags()
+	flags.StringVar(&serveAddr, "addr", ":8080", "Address to listen on")
+	flags.DurationVar(&serveRefresh, "refresh", 5*time.Minute, "How long a cloned repository is used before fetching it again")
+	flags.IntVar(&serveJobs, "jobs", 0, "Analyses run at a time, one per CPU if 0")
+	flags.IntVar(&serveQueued, "max-queued", server.DefaultMaxQueued, "Analyses waiting to run at most, the requests beyond being answered with 503")
+	flags.IntVar(&serveRepos, "max-repos", server.DefaultMaxRepos, "Number of clones kept, the one of the repository least recently asked about being removed past it, no limit if 0")
+	flags.StringSliceVar(&serveAllowed, "allowed-repos", nil, "Prefixes of the URLs of the repositories to clone, e.g. https://github.com/org/, any http, https or ssh URL but the ones of the local network if empty")
+	flags.DurationVar(&serveCacheTTL, "cache-ttl", metrics.DefaultResultTTL, "How long the computed metrics are kept, 0 to keep them until new commits are fetched")
+}
+
+var serveCmd = &cobra.Command{
+	Use:   "serve",
+	Short: "Serves the churn metrics over HTTP",
+	Long: `Starts an HTTP server answering GET /churn?repo=<url>&commit=<hash>&file=<path> with the churn metrics
+as JSON, the same as the root command. The repositories are cloned on the first request, when their URL
+starts with one of --allowed-repos or else is an http, https or ssh URL of a host out of the local network,
+and the --max-repos last asked about are kept in memory, along with the metrics computed, which are
+cached by commit hashes and options for --cache-ttl and dropped when new commits of the repository are
+fetched. The clones and analyses run as jobs, --jobs at a time and one at a time per repository, the
+simultaneous requests for the same one sharing its result.
+GET /version returns the version of git-churn.
+
+/graphql answers GraphQL queries over the repositories, their commits, hotspots and authors, so that
+dashboards fetch nested metrics, like the top authors of the hotspots of a range, in a single request.`,
+	Annotations: map[string]string{repoOptional: "true"},
+	Run: func(cmd *cobra.Command, args []string) {
+		s := server.New(serveRefresh)
+		s.Cache.TTL = serveCacheTTL
+		s.Jobs = server.NewJobQueue(serveJobs, serveQueued)
+		s.MaxRepos = serveRepos
+		s.AllowedRepos = serveAllowed
+		print.AtExit(s.Close)
+		print.Info("Listening on %s", serveAddr)
+		print.CheckIfError(http.ListenAndServe(serveAddr, s.Handler()))
+	},
+}
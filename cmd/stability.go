@@ -0,0 +1,48 @@
+package cmd
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/andymeneely/git-churn/gitfuncs"
+	metrics "github.com/andymeneely/git-churn/matrics"
+	"github.com/andymeneely/git-churn/print"
+	"github.com/spf13/cobra"
+)
+
+var (
+	stabilityHalfLifeDays int
+	stabilityBy           string
+	stabilityN            int
+)
+
+func init() {
+	rootCmd.AddCommand(stabilityCmd)
+	addRangeFlags(stabilityCmd)
+	flags := stabilityCmd.Flags()
+	flags.IntVar(&stabilityHalfLifeDays, "half-life-days", int(metrics.DefaultHalfLife.Hours()/24), "Age in days at which the churn of a commit weighs half as much as a change made at the revision")
+	flags.StringVar(&stabilityBy, "by", "", "Group the files by dir (top level directory), ext or lang, one entry per file if empty")
+	flags.IntVar(&stabilityN, "n", 0, "Number of most stable files to report, all of them if 0")
+}
+
+var stabilityCmd = &cobra.Command{
+	Use:   "stability",
+	Short: "Ranks the files by stability, from a churn decaying with age",
+	Long: `Scores every file of the revision changed over the range with the churn of its commits weighted by
+2^(-age/half-life), so that recent changes weigh more than old ones, and ranks the files most stable first.
+The stability goes from 0, for the file churning the most lately, to 1. Grouped --by dir, it tells the
+modules that are safe to build on.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if stabilityHalfLifeDays <= 0 {
+			print.CheckIfError(fmt.Errorf("--half-life-days must be positive, got %d", stabilityHalfLifeDays))
+		}
+		repo := gitfuncs.Clone(repoUrl)
+		commits, err := metrics.RangeChurn(repo, rangeFrom, requestedRevision())
+		print.CheckIfError(err)
+		halfLife := time.Duration(stabilityHalfLifeDays) * 24 * time.Hour
+		ranking, err := metrics.StabilityRanking(repo, commits, requestedRevision(), halfLife, stabilityBy, stabilityN)
+		print.CheckIfError(err)
+
+		printResult(ranking)
+	},
+}
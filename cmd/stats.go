@@ -0,0 +1,31 @@
+package cmd
+
+import (
+	"github.com/andymeneely/git-churn/gitfuncs"
+	metrics "github.com/andymeneely/git-churn/matrics"
+	"github.com/andymeneely/git-churn/print"
+	"github.com/spf13/cobra"
+)
+
+var statsPerAuthor bool
+
+func init() {
+	rootCmd.AddCommand(statsCmd)
+	addRangeFlags(statsCmd)
+	statsCmd.Flags().BoolVar(&statsPerAuthor, "per-author", false, "Also reports the distribution of the commit sizes of every author")
+}
+
+var statsCmd = &cobra.Command{
+	Use:   "stats",
+	Short: "Reports the distribution of the sizes of the commits of a range",
+	Long: `Summarizes the sizes of the commits of the range, in lines according to the churn definition and in
+files changed: their mean, median, 90th percentile, maximum and histogram, overall and with --per-author for
+every author, e.g. to nudge a team toward smaller commits. Merges are left out.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		repo := gitfuncs.Clone(repoUrl)
+		commits, err := metrics.RangeChurn(repo, rangeFrom, requestedRevision())
+		print.CheckIfError(err)
+
+		printResult(metrics.CommitSizes(commits, statsPerAuthor))
+	},
+}
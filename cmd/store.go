@@ -0,0 +1,75 @@
+package cmd
+
+import (
+	"errors"
+	"os"
+
+	"github.com/andymeneely/git-churn/gitfuncs"
+	metrics "github.com/andymeneely/git-churn/matrics"
+	"github.com/andymeneely/git-churn/print"
+	"github.com/andymeneely/git-churn/storage"
+	"github.com/andymeneely/git-churn/storage/parquet"
+	"github.com/spf13/cobra"
+)
+
+var (
+	storeDB      string
+	storeFull    bool
+	storeParquet string
+)
+
+func init() {
+	rootCmd.AddCommand(storeCmd)
+	addRangeFlags(storeCmd)
+	storeCmd.Flags().StringVar(&storeDB, "db", "", "Database to store the churn in, a SQLite file or a postgres:// URL")
+	storeCmd.Flags().StringVar(&storeParquet, "parquet", "", "Parquet file to write the per commit and per file churn to, instead of a database")
+	storeCmd.Flags().BoolVar(&storeFull, "full", false, "Walk the whole history instead of the commits added since the last run")
+}
+
+var storeCmd = &cobra.Command{
+	Use:   "store",
+	Short: "Stores the per commit and per file churn in a SQLite or Postgres database, or a Parquet file",
+	Long: `Stores the lines added and deleted per commit and per file of the commits from --from to --commit
+(or --branch, HEAD by default) in the commits and file_churn tables of the --db database, creating or
+migrating its schema as needed. The last commit stored is recorded per branch, so that the next run without
+--from only walks the commits added since, and commits already stored are never analyzed again.
+
+With --parquet the churn is written instead to a Parquet file, a row per commit and file, to be loaded
+into Spark or DuckDB. The version of its columns is stored in the git-churn.schema_version metadata.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if storeParquet != "" {
+			writeParquet()
+			return
+		}
+		if storeDB == "" {
+			print.CheckIfError(errors.New("the database has to be given with --db, or the file with --parquet"))
+		}
+		store, err := storage.Open(storeDB)
+		print.CheckIfError(err)
+		defer store.Close()
+
+		repo := gitfuncs.Clone(repoUrl)
+		added, err := store.Sync(repo, repoUrl, rangeFrom, requestedRevision(), storeFull)
+		print.CheckIfError(err)
+		print.Info("%d new commits stored in %s", added, storeDB)
+	},
+}
+
+// writeParquet writes the churn of the commits of the range to the --parquet file
+func writeParquet() {
+	file, err := os.Create(storeParquet)
+	print.CheckIfError(err)
+	defer file.Close()
+	w, err := parquet.NewWriter(file)
+	print.CheckIfError(err)
+
+	repo := gitfuncs.Clone(repoUrl)
+	commits := 0
+	err = metrics.ForEachCommitMetrics(repo, rangeFrom, requestedRevision(), func(commit *metrics.CommitChurn) error {
+		commits++
+		return w.WriteCommit(repoUrl, commit)
+	})
+	print.CheckIfError(err)
+	print.CheckIfError(w.Close())
+	print.Info("%d commits written to %s", commits, storeParquet)
+}
@@ -0,0 +1,55 @@
+package cmd
+
+import (
+	"io"
+	"os"
+	"time"
+
+	"github.com/andymeneely/git-churn/helper"
+	"github.com/andymeneely/git-churn/print"
+	"github.com/andymeneely/git-churn/survey"
+	"github.com/spf13/cobra"
+)
+
+var (
+	surveyWorkers int
+	surveySince   string
+	surveyTop     int
+)
+
+func init() {
+	rootCmd.AddCommand(surveyCmd)
+	flags := surveyCmd.Flags()
+	flags.IntVar(&surveyWorkers, "workers", 4, "Number of repositories analyzed at once")
+	flags.StringVar(&surveySince, "since", "1.year", "Start of the window, a period back from now like 6.months or 2.weeks, or a date like 2020-01-31")
+	flags.IntVar(&surveyTop, "top", 10, "Number of most changed files to report per repository, all of them if 0")
+}
+
+var surveyCmd = &cobra.Command{
+	Use:   "survey [<file>]",
+	Short: "Reports the churn of many repositories at once",
+	Long: `Analyzes the default branch of the repositories listed in the file, one URL per line, or on the
+standard input when no file or - is given, --workers at a time. Reports the churn since --since of every
+repository, keyed by URL, and its totals. The repositories that cannot be cloned or analyzed are reported
+with their error.`,
+	Args:        cobra.MaximumNArgs(1),
+	Annotations: map[string]string{repoOptional: "true"},
+	Run: func(cmd *cobra.Command, args []string) {
+		since, err := helper.ParseSince(surveySince, time.Now())
+		print.CheckIfError(err)
+		var in io.Reader = os.Stdin
+		if len(args) == 1 && args[0] != "-" {
+			f, err := os.Open(args[0])
+			print.CheckIfError(err)
+			defer f.Close()
+			in = f
+		}
+		repos, err := survey.ReadRepos(in)
+		print.CheckIfError(err)
+
+		s := survey.New(surveyWorkers, since, surveyTop)
+		// The walks of the repositories analyzed at once would draw over each other
+		s.OnProgress, helper.OnProgress = helper.OnProgress, nil
+		printResult(s.Run(repos))
+	},
+}
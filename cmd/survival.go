@@ -0,0 +1,39 @@
+package cmd
+
+import (
+	"time"
+
+	"github.com/andymeneely/git-churn/gitfuncs"
+	metrics "github.com/andymeneely/git-churn/matrics"
+	"github.com/andymeneely/git-churn/print"
+	"github.com/spf13/cobra"
+)
+
+var (
+	survivalWindowDays    int
+	survivalWindowCommits int
+)
+
+func init() {
+	rootCmd.AddCommand(survivalCmd)
+	addRangeFlags(survivalCmd)
+	survivalCmd.Flags().IntVar(&survivalWindowDays, "window-days", 30, "Age in days under which a deleted line counts as deleted early")
+	survivalCmd.Flags().IntVar(&survivalWindowCommits, "window-commits", 0, "Number of commits under which a deleted line counts as deleted early, instead of --window-days")
+}
+
+var survivalCmd = &cobra.Command{
+	Use:   "survival",
+	Short: "Reports how long the lines added live, and how many are deleted early",
+	Long: `Follows the lines added by the commits of the range up to --commit (or --branch, HEAD by default),
+blaming the lines deleted by every commit, and reports the share of them that survived and the share that
+got deleted within --window-days (or --window-commits) of being added, wasted churn, overall, per file and
+per author who added them, with the mean age of the lines when deleted.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		repo := gitfuncs.Clone(repoUrl)
+		window := time.Duration(survivalWindowDays) * 24 * time.Hour
+		report, err := metrics.LineSurvival(repo, rangeFrom, requestedRevision(), window, survivalWindowCommits)
+		print.CheckIfError(err)
+
+		printResult(report)
+	},
+}
@@ -0,0 +1,33 @@
+package cmd
+
+import (
+	"github.com/andymeneely/git-churn/gitfuncs"
+	metrics "github.com/andymeneely/git-churn/matrics"
+	"github.com/andymeneely/git-churn/print"
+	"github.com/spf13/cobra"
+)
+
+var symbolsN int
+
+func init() {
+	rootCmd.AddCommand(symbolsCmd)
+	addRangeFlags(symbolsCmd)
+	symbolsCmd.Flags().IntVar(&symbolsN, "n", 20, "Number of symbols to report, all of them if 0")
+}
+
+var symbolsCmd = &cobra.Command{
+	Use:     "symbols",
+	Aliases: []string{"functions"},
+	Short:   "Reports the most churned functions, methods and classes",
+	Long: `Parses the files changed by every commit of the range before and after the commit and attributes
+the lines added and deleted to the functions, methods and classes they fall in, reporting the --n most
+churned ones. Go files are parsed with go/parser, the files of the other languages with universal-ctags
+when it is installed.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		repo := gitfuncs.Clone(repoUrl)
+		symbols, err := metrics.RangeSymbolChurn(repo, rangeFrom, requestedRevision(), symbolsN)
+		print.CheckIfError(err)
+
+		printResult(symbols)
+	},
+}
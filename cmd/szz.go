@@ -0,0 +1,28 @@
+package cmd
+
+import (
+	"github.com/andymeneely/git-churn/gitfuncs"
+	metrics "github.com/andymeneely/git-churn/matrics"
+	"github.com/andymeneely/git-churn/print"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	rootCmd.AddCommand(szzCmd)
+	addRangeFlags(szzCmd)
+}
+
+var szzCmd = &cobra.Command{
+	Use:   "szz",
+	Short: "Identifies the bug-introducing commits with the SZZ algorithm",
+	Long: `Blames the lines deleted by the bug fixes of the range, see --fix-patterns, to find the commits that
+introduced them, and reports these bug-introducing commits with their buggy lines and churn, totalled per
+author and per file.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		repo := gitfuncs.Clone(repoUrl)
+		report, err := metrics.SZZ(repo, rangeFrom, requestedRevision())
+		print.CheckIfError(err)
+
+		printResult(report)
+	},
+}
@@ -0,0 +1,43 @@
+package cmd
+
+import (
+	"errors"
+
+	"github.com/andymeneely/git-churn/gitfuncs"
+	metrics "github.com/andymeneely/git-churn/matrics"
+	"github.com/andymeneely/git-churn/print"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	rootCmd.AddCommand(teamsCmd)
+	addRangeFlags(teamsCmd)
+}
+
+var teamsCmd = &cobra.Command{
+	Use:   "teams",
+	Short: "Reports the commits and the churn per team",
+	Long: `Totals per team the authors, commits and lines changed over the range, and the churn of the files the
+team owns, telling how much of it came from other teams. The teams are mapped by the --teams file, or by the
+CODEOWNERS of the repository when not given. A team mapping file is a CODEOWNERS whose lines may also map an
+author email to a team, e.g.
+
+  alice@example.com  @org/platform
+  /api/              @org/api
+  *.js               @org/frontend`,
+	Run: func(cmd *cobra.Command, args []string) {
+		repo := gitfuncs.Clone(repoUrl)
+		if metrics.Teams == nil {
+			var err error
+			metrics.Teams, err = metrics.ReadRepoCodeowners(repo)
+			print.CheckIfError(err)
+			if metrics.Teams == nil {
+				print.CheckIfError(errors.New("no --teams file given and the repository has no CODEOWNERS"))
+			}
+		}
+		commits, err := metrics.RangeChurn(repo, rangeFrom, requestedRevision())
+		print.CheckIfError(err)
+
+		printResult(metrics.ChurnByTeam(commits))
+	},
+}
@@ -0,0 +1,39 @@
+package cmd
+
+import (
+	"github.com/andymeneely/git-churn/gitfuncs"
+	metrics "github.com/andymeneely/git-churn/matrics"
+	"github.com/andymeneely/git-churn/print"
+	"github.com/spf13/cobra"
+)
+
+var (
+	tenureMonths    int
+	tenureRampShare float64
+)
+
+func init() {
+	rootCmd.AddCommand(tenureCmd)
+	addRangeFlags(tenureCmd)
+	tenureCmd.Flags().IntVar(&tenureMonths, "months", metrics.DefaultRampMonths, "Months after their first commit the churn of every author is followed for")
+	tenureCmd.Flags().Float64Var(&tenureRampShare, "ramp-share", metrics.DefaultRampShare*100, "Percentage of the churn of their busiest month of the ramp an author has to reach to be ramped up")
+}
+
+var tenureCmd = &cobra.Command{
+	Use:   "tenure",
+	Short: "Reports the tenure of the authors and their churn over their first months",
+	Long: `Reports per author the dates of their first and last commit in the range up to --commit (or --branch,
+HEAD by default), the weeks they were active in, and their commits and churn month by month over the --months
+following their first commit, along with the month they ramped up in: the first one their churn reached
+--ramp-share of their busiest month. The median ramp is taken over the authors whose ramp the range covers.
+Leave out --from for the first commits to be the start of the authors.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		repo := gitfuncs.Clone(repoUrl)
+		commits, err := metrics.RangeChurn(repo, rangeFrom, requestedRevision())
+		print.CheckIfError(err)
+		report, err := metrics.AuthorTenures(commits, tenureMonths, tenureRampShare/100)
+		print.CheckIfError(err)
+
+		printResult(report)
+	},
+}
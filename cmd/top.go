@@ -0,0 +1,60 @@
+package cmd
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/andymeneely/git-churn/gitfuncs"
+	"github.com/andymeneely/git-churn/helper"
+	metrics "github.com/andymeneely/git-churn/matrics"
+	"github.com/andymeneely/git-churn/print"
+	"github.com/spf13/cobra"
+)
+
+var (
+	topN     int
+	topSince string
+	topSort  string
+	// file or symbol
+	topGranularity string
+)
+
+func init() {
+	rootCmd.AddCommand(topCmd)
+	flags := topCmd.Flags()
+	flags.IntVar(&topN, "n", 20, "Number of files to report, all of them if 0")
+	flags.StringVar(&topSince, "since", "6.months", "Start of the window, a period back from now like 6.months or 2.weeks, or a date like 2020-01-31")
+	flags.StringVar(&topSort, "sort", "churn", "Key the files are ranked by: churn, commits, insertions, deletions or last (most recently touched)")
+	flags.StringVar(&topGranularity, "granularity", "file", "Unit the churn is totalled by: file, or symbol for the functions, methods and classes")
+}
+
+var topCmd = &cobra.Command{
+	Use:   "top",
+	Short: "Reports the most churned files over a window of time",
+	Long: `Totals per file the commits, lines added and deleted and churn of the commits made since --since
+and reports the --n files ranking first by --sort, with the date each one was last touched. With
+--granularity=symbol, the functions, methods and classes are ranked instead, see the symbols command.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		since, err := helper.ParseSince(topSince, time.Now())
+		print.CheckIfError(err)
+		repo := gitfuncs.Clone(repoUrl)
+		switch topGranularity {
+		case "file":
+		case "symbol":
+			symbols, err := metrics.SymbolChurnSince(repo, requestedRevision(), since)
+			print.CheckIfError(err)
+			top, err := metrics.TopSymbols(symbols, topSort, topN)
+			print.CheckIfError(err)
+			printResult(top)
+			return
+		default:
+			print.CheckIfError(fmt.Errorf("unknown granularity %q, expected file or symbol", topGranularity))
+		}
+		commits, err := metrics.ChurnSince(repo, requestedRevision(), since)
+		print.CheckIfError(err)
+		top, err := metrics.TopFiles(commits, topSort, topN)
+		print.CheckIfError(err)
+
+		printResult(top)
+	},
+}
@@ -0,0 +1,34 @@
+package cmd
+
+import (
+	"github.com/andymeneely/git-churn/gitfuncs"
+	metrics "github.com/andymeneely/git-churn/matrics"
+	"github.com/andymeneely/git-churn/print"
+	"github.com/spf13/cobra"
+)
+
+var treeDepth int
+
+func init() {
+	rootCmd.AddCommand(treeCmd)
+	addRangeFlags(treeCmd)
+	treeCmd.Flags().IntVar(&treeDepth, "depth", 0, "Levels of directories shown below the root, the deeper ones being totalled in their parent, all of them if 0")
+}
+
+var treeCmd = &cobra.Command{
+	Use:   "tree",
+	Short: "Renders the churn of a range as a tree of directories with roll-up totals, like du",
+	Long: `Totals the lines added and deleted, the commits and the share of the churn of the range under every
+directory, down to --depth levels, and renders them as a tree, most churned first. The output is the tree
+unless --format is given, json nesting the directories in their Children.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		repo := gitfuncs.Clone(repoUrl)
+		commits, err := metrics.RangeChurn(repo, rangeFrom, requestedRevision())
+		print.CheckIfError(err)
+
+		if !cmd.Flags().Changed("format") {
+			outputFormat = formatTree
+		}
+		printResult(metrics.ChurnTree(commits, treeDepth))
+	},
+}
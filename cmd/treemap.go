@@ -0,0 +1,49 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/andymeneely/git-churn/gitfuncs"
+	metrics "github.com/andymeneely/git-churn/matrics"
+	"github.com/andymeneely/git-churn/print"
+	"github.com/andymeneely/git-churn/report"
+	"github.com/spf13/cobra"
+)
+
+var treemapSVG string
+
+func init() {
+	rootCmd.AddCommand(treemapCmd)
+	addRangeFlags(treemapCmd)
+	treemapCmd.Flags().StringVar(&treemapSVG, "svg", "", "Path of the SVG treemap to write, stdout if empty")
+}
+
+var treemapCmd = &cobra.Command{
+	Use:   "treemap",
+	Short: "Draws an SVG treemap of the files sized by lines and colored by churn",
+	Long: `Draws the files of --commit (or --branch, HEAD by default) as an SVG treemap nesting the directories,
+the area of every file being proportional to its lines and its color going from grey to red with the churn
+of the commits of the range, so that the hotspots can be seen at a glance.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		repo := gitfuncs.Clone(repoUrl)
+		commits, err := metrics.RangeChurn(repo, rangeFrom, requestedRevision())
+		print.CheckIfError(err)
+		files, err := metrics.FileHeats(repo, commits, requestedRevision())
+		print.CheckIfError(err)
+
+		var out io.Writer = os.Stdout
+		if treemapSVG != "" {
+			f, err := os.Create(treemapSVG)
+			print.CheckIfError(err)
+			defer f.Close()
+			out = f
+		}
+		title := fmt.Sprintf("%s at %s", repoUrl, requestedRevision())
+		print.CheckIfError(report.WriteTreemap(out, title, files))
+		if treemapSVG != "" {
+			print.Info("Treemap written to %s", treemapSVG)
+		}
+	},
+}
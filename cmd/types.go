@@ -0,0 +1,28 @@
+package cmd
+
+import (
+	"github.com/andymeneely/git-churn/gitfuncs"
+	metrics "github.com/andymeneely/git-churn/matrics"
+	"github.com/andymeneely/git-churn/print"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	rootCmd.AddCommand(typesCmd)
+	addRangeFlags(typesCmd)
+}
+
+var typesCmd = &cobra.Command{
+	Use:   "types",
+	Short: "Reports the churn per type of change: features, fixes, refactorings, chores, docs...",
+	Long: `Classifies the commits of the range by the type of their conventional commit message (feat, fix,
+refactor, perf, test, style, build, ci, chore, docs or revert) and totals their churn per type. The other
+messages count as fixes when they match --fix-patterns, as other changes otherwise.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		repo := gitfuncs.Clone(repoUrl)
+		commits, err := metrics.RangeChurn(repo, rangeFrom, requestedRevision())
+		print.CheckIfError(err)
+
+		printResult(metrics.ChurnByChangeType(commits))
+	},
+}
@@ -0,0 +1,35 @@
+package cmd
+
+import (
+	"github.com/andymeneely/git-churn/gitfuncs"
+	metrics "github.com/andymeneely/git-churn/matrics"
+	"github.com/andymeneely/git-churn/print"
+	"github.com/spf13/cobra"
+)
+
+var verifyCommits int
+
+func init() {
+	rootCmd.AddCommand(verifyRemoteCmd)
+	verifyRemoteCmd.Flags().IntVar(&verifyCommits, "commits", 10, "Number of commits to verify")
+}
+
+var verifyRemoteCmd = &cobra.Command{
+	Use:   "verify-remote <url>",
+	Short: "Checks the metrics invariants against a live remote repository",
+	Long: `Clones the repository at the given URL, runs the diff and churn metrics on its last commits and checks
+that their invariants hold, exiting with a non zero status otherwise. Useful to catch go-git regressions
+against real world servers.`,
+	Args:        cobra.ExactArgs(1),
+	Annotations: map[string]string{repoOptional: "true"},
+	Run: func(cmd *cobra.Command, args []string) {
+		repo := gitfuncs.Clone(args[0])
+		violations, err := metrics.VerifyInvariants(repo, requestedRevision(), verifyCommits)
+		print.CheckIfError(err)
+		if len(violations) > 0 {
+			printResult(violations)
+			print.Exit(1)
+		}
+		print.Info("All the invariants hold on the last %d commits", verifyCommits)
+	},
+}
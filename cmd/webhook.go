@@ -0,0 +1,62 @@
+package cmd
+
+import (
+	"net/http"
+	"os"
+
+	"github.com/andymeneely/git-churn/print"
+	"github.com/andymeneely/git-churn/storage"
+	"github.com/andymeneely/git-churn/webhook"
+	"github.com/spf13/cobra"
+)
+
+var (
+	webhookAddr    string
+	webhookDB      string
+	webhookForward string
+	webhookQueue   int
+	webhookTop     int
+	webhookRepos   int
+)
+
+func init() {
+	rootCmd.AddCommand(webhookCmd)
+	flags := webhookCmd.Flags()
+	flags.StringVar(&webhookAddr, "addr", ":8090", "Address to listen on")
+	flags.StringVar(&webhookDB, "db", "", "Database to store the churn of the pushed commits in, a SQLite file or a postgres:// URL")
+	flags.StringVar(&webhookForward, "forward", "", "URL to post the churn of every push to as JSON")
+	flags.IntVar(&webhookQueue, "queue", 100, "Number of pushes waiting to be analyzed before new ones are refused")
+	flags.IntVar(&webhookTop, "top", 10, "Number of most changed files to report per push, all of them if 0")
+	flags.IntVar(&webhookRepos, "max-repos", webhook.DefaultMaxRepos, "Number of clones kept, the one of the repository least recently pushed to being removed past it, no limit if 0")
+}
+
+var webhookCmd = &cobra.Command{
+	Use:   "webhook",
+	Short: "Computes the churn of the commits pushed to GitHub or GitLab as they come",
+	Long: `Listens for the push events of GitHub and GitLab webhooks on POST /webhook and computes the churn of
+the pushed commits, one push after the other, keeping a clone of the --max-repos repositories last pushed
+to. The churn is stored in the --db database, with the same schema as the store command, and posted to
+--forward. The events are checked against the secret of the webhooks given by the WEBHOOK_SECRET
+environment variable.`,
+	Annotations: map[string]string{repoOptional: "true"},
+	Run: func(cmd *cobra.Command, args []string) {
+		secret := os.Getenv("WEBHOOK_SECRET")
+		if secret == "" {
+			print.Warning("WEBHOOK_SECRET is not set, the events are not authenticated")
+		}
+		r := webhook.New(secret, webhookQueue)
+		r.Top = webhookTop
+		r.ForwardURL = webhookForward
+		r.MaxRepos = webhookRepos
+		if webhookDB != "" {
+			store, err := storage.Open(webhookDB)
+			print.CheckIfError(err)
+			defer store.Close()
+			r.Store = store
+		}
+		go r.Run(nil)
+
+		print.Info("Listening for webhooks on %s/webhook", webhookAddr)
+		print.CheckIfError(http.ListenAndServe(webhookAddr, r.Handler()))
+	},
+}
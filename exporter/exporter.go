@@ -0,0 +1,237 @@
+// Package exporter periodically analyzes repositories and exposes their churn as Prometheus metrics
+package exporter
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/andymeneely/git-churn/gitfuncs"
+	metrics "github.com/andymeneely/git-churn/matrics"
+	. "github.com/andymeneely/git-churn/print"
+	"gopkg.in/src-d/go-git.v4"
+)
+
+// Target is a branch of a repository to analyze, the default branch when Branch is empty
+type Target struct {
+	Repo   string
+	Branch string
+}
+
+// ParseTarget parses a target given as <url> or <url>#<branch>
+func ParseTarget(target string) (Target, error) {
+	parts := strings.SplitN(target, "#", 2)
+	if parts[0] == "" {
+		return Target{}, fmt.Errorf("invalid target %q, expected <url> or <url>#<branch>", target)
+	}
+	if len(parts) == 1 {
+		return Target{Repo: parts[0]}, nil
+	}
+	return Target{Repo: parts[0], Branch: parts[1]}, nil
+}
+
+// Exporter keeps a clone of every target and the churn computed on it at the last analysis
+type Exporter struct {
+	Targets []Target
+	// Number of days, up to today, the churn is reported for
+	Days int
+	// Clones a repository, gitfuncs.CloneRepository by default
+	Clone func(repoUrl string) (*git.Repository, error)
+	// Daily churn computed per branch head and day, so that the analyses finding no new commit do not
+	// walk the history again. The results of a repository are dropped when a fetch brings new commits.
+	Cache *metrics.ResultCache
+
+	mu      sync.RWMutex
+	repos   map[string]*git.Repository
+	results map[Target]*result
+}
+
+// result of the last analysis of a target
+type result struct {
+	branch   string
+	days     []metrics.PeriodChurn
+	analyzed time.Time
+	err      error
+}
+
+// New returns an exporter of the churn of the last days of the targets
+func New(targets []Target, days int) *Exporter {
+	return &Exporter{
+		Targets: targets,
+		Days:    days,
+		Clone:   gitfuncs.CloneRepository,
+		Cache:   metrics.NewResultCache(metrics.DefaultResultTTL),
+		repos:   make(map[string]*git.Repository),
+		results: make(map[Target]*result),
+	}
+}
+
+// Run analyzes the targets every interval until stop is closed
+func (e *Exporter) Run(interval time.Duration, stop <-chan struct{}) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		e.Collect()
+		select {
+		case <-ticker.C:
+		case <-stop:
+			return
+		}
+	}
+}
+
+// Collect analyzes all the targets once. The failures are reported through the git_churn_up metric.
+func (e *Exporter) Collect() {
+	for _, target := range e.Targets {
+		r := e.analyze(target)
+		if r.err != nil {
+			Warning("unable to analyze %s: %s", target.Repo, r.err)
+		}
+		e.mu.Lock()
+		if previous, ok := e.results[target]; ok && r.err != nil {
+			// Keep reporting the last successful analysis
+			r.days, r.branch = previous.days, previous.branch
+		}
+		e.results[target] = r
+		e.mu.Unlock()
+	}
+}
+
+func (e *Exporter) analyze(target Target) *result {
+	r := &result{branch: target.Branch, analyzed: time.Now()}
+	repo, err := e.repository(target.Repo)
+	if err != nil {
+		r.err = err
+		return r
+	}
+	if r.branch == "" {
+		head, err := repo.Head()
+		if err != nil {
+			r.err = err
+			return r
+		}
+		r.branch = head.Name().Short()
+	}
+	if e.Days <= 0 {
+		r.err = errors.New("the number of days to report has to be positive")
+		return r
+	}
+	today := r.analyzed.UTC().Truncate(24 * time.Hour)
+	since := today.AddDate(0, 0, 1-e.Days)
+	// The remote-tracking branch is the one moving with the fetches
+	head, err := gitfuncs.ResolveRef(repo, git.DefaultRemoteName+"/"+r.branch)
+	if err != nil {
+		r.err = err
+		return r
+	}
+	days, err := e.Cache.Load(target.Repo, metrics.ResultKey("days", head, since.Format("2006-01-02"), e.Days), func() (interface{}, error) {
+		commits, err := metrics.ChurnSince(repo, head.String(), since)
+		if err != nil {
+			return nil, err
+		}
+		// The days are those the commits were authored on, which for a rebased commit precede its committing
+		authored := commits[:0]
+		for _, commit := range commits {
+			if !commit.When.Before(since) {
+				authored = append(authored, commit)
+			}
+		}
+		return metrics.ChurnPerDay(authored), nil
+	})
+	if err != nil {
+		r.err = err
+		return r
+	}
+	r.days = days.([]metrics.PeriodChurn)
+	return r
+}
+
+// repository returns the clone of the repository up to date with its remote
+func (e *Exporter) repository(repoUrl string) (*git.Repository, error) {
+	if repo, ok := e.repos[repoUrl]; ok {
+		updated, err := gitfuncs.FetchNewRefs(repo)
+		if updated {
+			e.Cache.Invalidate(repoUrl)
+		}
+		return repo, err
+	}
+	repo, err := e.Clone(repoUrl)
+	if err != nil {
+		return nil, err
+	}
+	e.repos[repoUrl] = repo
+	return repo, nil
+}
+
+// ServeHTTP writes the metrics in the Prometheus text exposition format
+func (e *Exporter) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	e.WriteMetrics(w)
+}
+
+type gauge struct {
+	name  string
+	help  string
+	value func(metrics.PeriodChurn) int
+}
+
+var dailyGauges = []gauge{
+	{"git_churn_lines_added", "Lines added by the commits of the day.", func(p metrics.PeriodChurn) int { return p.Insertions }},
+	{"git_churn_lines_deleted", "Lines deleted by the commits of the day.", func(p metrics.PeriodChurn) int { return p.Deletions }},
+	{"git_churn_files_changed", "Distinct files changed by the commits of the day.", func(p metrics.PeriodChurn) int { return p.FilesChanged }},
+	{"git_churn_authors", "Distinct authors of the commits of the day.", func(p metrics.PeriodChurn) int { return p.Authors }},
+	{"git_churn_commits", "Commits authored during the day.", func(p metrics.PeriodChurn) int { return p.Commits }},
+}
+
+// WriteMetrics writes the results of the last analysis in the Prometheus text exposition format
+func (e *Exporter) WriteMetrics(w io.Writer) {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	targets := make([]Target, 0, len(e.results))
+	for target := range e.results {
+		targets = append(targets, target)
+	}
+	sort.Slice(targets, func(i, j int) bool {
+		if targets[i].Repo != targets[j].Repo {
+			return targets[i].Repo < targets[j].Repo
+		}
+		return targets[i].Branch < targets[j].Branch
+	})
+
+	for _, g := range dailyGauges {
+		fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s gauge\n", g.name, g.help, g.name)
+		for _, target := range targets {
+			r := e.results[target]
+			for _, day := range r.days {
+				fmt.Fprintf(w, "%s{repo=%s,branch=%s,day=%s} %d\n", g.name,
+					quote(target.Repo), quote(r.branch), quote(day.Period), g.value(day))
+			}
+		}
+	}
+	fmt.Fprintf(w, "# HELP git_churn_up Whether the last analysis of the repository succeeded.\n# TYPE git_churn_up gauge\n")
+	for _, target := range targets {
+		r := e.results[target]
+		up := 1
+		if r.err != nil {
+			up = 0
+		}
+		fmt.Fprintf(w, "git_churn_up{repo=%s,branch=%s} %d\n", quote(target.Repo), quote(r.branch), up)
+	}
+	fmt.Fprintf(w, "# HELP git_churn_last_analysis_timestamp_seconds When the repository was last analyzed.\n# TYPE git_churn_last_analysis_timestamp_seconds gauge\n")
+	for _, target := range targets {
+		r := e.results[target]
+		fmt.Fprintf(w, "git_churn_last_analysis_timestamp_seconds{repo=%s,branch=%s} %d\n", quote(target.Repo), quote(r.branch), r.analyzed.Unix())
+	}
+}
+
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// quote quotes a label value as the exposition format expects
+func quote(value string) string {
+	return `"` + labelEscaper.Replace(value) + `"`
+}
@@ -0,0 +1,67 @@
+package exporter
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/andymeneely/git-churn/testutil"
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/src-d/go-git.v4"
+)
+
+func TestParseTarget(t *testing.T) {
+	assert := assert.New(t)
+	target, err := ParseTarget("https://github.com/andymeneely/git-churn#develop")
+	assert.Nil(err)
+	assert.Equal(Target{"https://github.com/andymeneely/git-churn", "develop"}, target)
+	target, err = ParseTarget("git@github.com:andymeneely/git-churn.git")
+	assert.Nil(err)
+	assert.Equal(Target{Repo: "git@github.com:andymeneely/git-churn.git"}, target)
+	_, err = ParseTarget("#master")
+	assert.NotNil(err)
+}
+
+// testRepo commits a.txt twice today, then rebases today a commit of b.txt authored a month ago, with
+// origin/master pointing at the last commit as in a clone
+func testRepo(t *testing.T) *git.Repository {
+	repo := testutil.NewRepo(t)
+	today := time.Now().UTC().Truncate(24 * time.Hour)
+	for i, content := range []string{"1\n2\n", "1\nb\nc\n"} {
+		repo.At(today.Add(time.Duration(i)*time.Second)).Write("a.txt", content).Commit("commit")
+	}
+	repo.At(today.AddDate(0, -1, 0)).CommittedAt(today.Add(2*time.Second)).Write("b.txt", "1\n").Commit("rebased")
+	return repo.SetRef("refs/remotes/origin/master").Repository
+}
+
+func TestCollect(t *testing.T) {
+	assert := assert.New(t)
+	e := New([]Target{{Repo: "test"}, {Repo: "broken", Branch: "master"}}, 7)
+	e.Clone = func(repoUrl string) (*git.Repository, error) {
+		if repoUrl == "broken" {
+			return nil, errors.New("unreachable")
+		}
+		return testRepo(t), nil
+	}
+	e.Collect()
+
+	var out bytes.Buffer
+	e.WriteMetrics(&out)
+	metrics := out.String()
+	day := time.Now().UTC().Format("2006-01-02")
+	assert.Contains(metrics, "# TYPE git_churn_lines_added gauge\n")
+	assert.Contains(metrics, `git_churn_lines_added{repo="test",branch="master",day="`+day+`"} 4`)
+	assert.Contains(metrics, `git_churn_lines_deleted{repo="test",branch="master",day="`+day+`"} 1`)
+	assert.Contains(metrics, `git_churn_files_changed{repo="test",branch="master",day="`+day+`"} 1`)
+	assert.Contains(metrics, `git_churn_authors{repo="test",branch="master",day="`+day+`"} 1`)
+	assert.Contains(metrics, `git_churn_commits{repo="test",branch="master",day="`+day+`"} 2`)
+	// Authored before the days reported
+	assert.NotContains(metrics, time.Now().UTC().AddDate(0, -1, 0).Format("2006-01-02"))
+	assert.Contains(metrics, `git_churn_up{repo="test",branch="master"} 1`)
+	assert.Contains(metrics, `git_churn_up{repo="broken",branch="master"} 0`)
+}
+
+func TestQuote(t *testing.T) {
+	assert.Equal(t, `"a\"b\\c\nd"`, quote("a\"b\\c\nd"))
+}
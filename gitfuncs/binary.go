@@ -0,0 +1,67 @@
+package gitfuncs
+
+import (
+	"gopkg.in/src-d/go-git.v4/plumbing/object"
+)
+
+// BinaryChange is a change to a binary file, measured in bytes as it has no lines
+type BinaryChange struct {
+	Path       string
+	SizeBefore int64
+	SizeAfter  int64
+	New        bool
+	Deleted    bool
+}
+
+// SizeDelta returns the number of bytes the file grew by, negative if it shrank
+func (c BinaryChange) SizeDelta() int64 {
+	return c.SizeAfter - c.SizeBefore
+}
+
+// BinaryChanges returns the changes to the files that are binary before or after the change, by path
+func BinaryChanges(changes object.Changes) (map[string]BinaryChange, error) {
+	binaries := make(map[string]BinaryChange)
+	for _, change := range changes {
+		from, to, err := change.Files()
+		if err != nil {
+			return nil, err
+		}
+		binary, err := isBinary(from, to)
+		if err != nil {
+			return nil, err
+		}
+		if !binary {
+			continue
+		}
+		binaryChange := BinaryChange{Path: changePath(change), New: from == nil, Deleted: to == nil}
+		if from != nil {
+			binaryChange.SizeBefore = from.Size
+		}
+		if to != nil {
+			binaryChange.SizeAfter = to.Size
+		}
+		binaries[binaryChange.Path] = binaryChange
+	}
+	return binaries, nil
+}
+
+// changePath returns the path of the changed file. The files of a change are only named after their base name.
+func changePath(change *object.Change) string {
+	if change.To.Name != "" {
+		return change.To.Name
+	}
+	return change.From.Name
+}
+
+// isBinary tells whether any of the given versions of a file, nil when missing, is binary. UTF-16 text is not.
+func isBinary(files ...*object.File) (bool, error) {
+	for _, f := range files {
+		if f == nil {
+			continue
+		}
+		if binary, err := isTextBinary(f); err != nil || binary {
+			return binary, err
+		}
+	}
+	return false, nil
+}
@@ -0,0 +1,375 @@
+package gitfuncs
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+
+	. "github.com/andymeneely/git-churn/print"
+	"github.com/sergi/go-diff/diffmatchpatch"
+	"gopkg.in/src-d/go-git.v4"
+	"gopkg.in/src-d/go-git.v4/plumbing"
+	"gopkg.in/src-d/go-git.v4/plumbing/object"
+	"gopkg.in/src-d/go-git.v4/utils/diff"
+)
+
+// BlameOptions are the git-blame options supported by BlameWithOptions
+type BlameOptions struct {
+	// Commits whose changes are attributed to the commits they changed, like bulk reformats (--ignore-rev)
+	IgnoreRevs map[plumbing.Hash]bool
+	// Ignore whitespace when matching the lines of a revision with its parents (-w)
+	IgnoreWhitespace bool
+	// First and last line to blame, counting from 1 (-L start,end). Zero means the start or the end of the file.
+	StartLine int
+	EndLine   int
+}
+
+// BlameOpts are the options the blames computing the churn metrics use
+var BlameOpts BlameOptions
+
+func (o BlameOptions) isZero() bool {
+	return len(o.IgnoreRevs) == 0 && !o.IgnoreWhitespace && o.StartLine == 0 && o.EndLine == 0
+}
+
+// ReadIgnoreRevs reads a list of commit hashes in the format of git's blame.ignoreRevsFile: one full hash
+// per line, blank lines and comments starting with # ignored
+func ReadIgnoreRevs(r io.Reader) (map[plumbing.Hash]bool, error) {
+	revs := make(map[plumbing.Hash]bool)
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if i := strings.Index(line, "#"); i >= 0 {
+			line = line[:i]
+		}
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		hash := plumbing.NewHash(line)
+		if len(line) != 40 || hash.String() != strings.ToLower(line) {
+			return nil, fmt.Errorf("invalid commit hash %q in the ignore revs", line)
+		}
+		revs[hash] = true
+	}
+	return revs, scanner.Err()
+}
+
+// ReadIgnoreRevsFile reads the ignore revs file at the given path, see ReadIgnoreRevs
+func ReadIgnoreRevsFile(path string) (map[plumbing.Hash]bool, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return ReadIgnoreRevs(f)
+}
+
+// The ignore revs of the repository last looked at, so that its .git-blame-ignore-revs is read once per analysis
+var repoIgnoreRevs = struct {
+	sync.Mutex
+	repo *git.Repository
+	revs map[plumbing.Hash]bool
+}{}
+
+// RepoIgnoreRevs returns the commits listed in the .git-blame-ignore-revs at the root of the HEAD of the
+// repository, typically bulk reformats, none if there is no such file
+func RepoIgnoreRevs(repo *git.Repository) map[plumbing.Hash]bool {
+	repoIgnoreRevs.Lock()
+	defer repoIgnoreRevs.Unlock()
+	if repoIgnoreRevs.repo != repo {
+		repoIgnoreRevs.repo = repo
+		repoIgnoreRevs.revs = readRepoIgnoreRevs(repo)
+	}
+	return repoIgnoreRevs.revs
+}
+
+func readRepoIgnoreRevs(repo *git.Repository) map[plumbing.Hash]bool {
+	head, err := repo.Head()
+	if err != nil {
+		return nil
+	}
+	commit, err := repo.CommitObject(head.Hash())
+	if err != nil {
+		return nil
+	}
+	file, err := commit.File(".git-blame-ignore-revs")
+	if err != nil {
+		return nil
+	}
+	reader, err := file.Reader()
+	if err != nil {
+		return nil
+	}
+	defer reader.Close()
+	revs, err := ReadIgnoreRevs(reader)
+	if err != nil {
+		Warning("ignoring the .git-blame-ignore-revs of the repository: %v", err)
+		return nil
+	}
+	return revs
+}
+
+// BlameCommit blames the file at the given commit of the repository with BlameOpts. go-git's Blame fails on
+// some histories, typically around merges, in which case the blame is retried with BlameWithOptions, which
+// looks at every parent of the merges, and as a last resort with the git command line.
+func BlameCommit(repo *git.Repository, commit *object.Commit, path string) (*git.BlameResult, error) {
+	var result *git.BlameResult
+	var err error
+	if BlameOpts.isZero() {
+		result, err = git.Blame(commit, path)
+		if err == nil || err == object.ErrFileNotFound {
+			return result, err
+		}
+		Warning("go-git could not blame %s at %s, retrying through the parents of the merges: %v", path, commit.Hash, err)
+	}
+	result, err = BlameWithOptions(commit, path, BlameOpts)
+	if err == nil || err == object.ErrFileNotFound {
+		return result, err
+	}
+	Warning("could not blame %s at %s, retrying with git blame: %v", path, commit.Hash, err)
+	return cliBlame(repo, commit.Hash, path, BlameOpts)
+}
+
+// blamedLine is a line of the blamed file being traced back through history
+type blamedLine struct {
+	// index in the blamed file and in the revision being looked at
+	final, current int
+}
+
+// blameState holds the lines of the blamed file traced back to a commit, yet to be passed to its parents
+type blameState struct {
+	commit *object.Commit
+	lines  []blamedLine
+}
+
+// BlameWithOptions blames the file at the given commit with the given options. Unlike go-git's Blame, every
+// parent of a merge is looked at, so that a line comes from the commit adding it on a merged branch rather
+// than from the merge. Renames are not followed. When a line range is given, Lines only holds its lines.
+func BlameWithOptions(commit *object.Commit, path string, opts BlameOptions) (*git.BlameResult, error) {
+	file, err := commit.File(path)
+	if err != nil {
+		return nil, err
+	}
+	contents, err := fileText(file)
+	if err != nil {
+		return nil, err
+	}
+	texts := splitLines(contents)
+	start, end := 1, len(texts)
+	if opts.StartLine > 0 {
+		start = opts.StartLine
+	}
+	if opts.EndLine > 0 && opts.EndLine < end {
+		end = opts.EndLine
+	}
+	if start > end && (opts.StartLine > 0 || opts.EndLine > 0) {
+		return nil, fmt.Errorf("line range %d,%d out of the %d lines of %s", opts.StartLine, opts.EndLine, len(texts), path)
+	}
+
+	result := &git.BlameResult{Path: path, Rev: commit.Hash, Lines: make([]*git.Line, end-start+1)}
+	initial := &blameState{commit: commit}
+	for i := start - 1; i < end; i++ {
+		initial.lines = append(initial.lines, blamedLine{final: i, current: i})
+	}
+	pending := map[plumbing.Hash]*blameState{commit.Hash: initial}
+	files := map[plumbing.Hash]*blamedFile{commit.Hash: {blob: file.Hash, lines: texts}}
+
+	// Newest commits first, so that a commit is only looked at once all its children passed their lines on
+	for len(pending) > 0 {
+		state := newestState(pending)
+		delete(pending, state.commit.Hash)
+		remaining, err := passToParents(state, path, opts, pending, files)
+		if err != nil {
+			return nil, err
+		}
+		for _, line := range remaining {
+			result.Lines[line.final-start+1] = &git.Line{
+				Author: state.commit.Author.Email,
+				Text:   texts[line.final],
+				Date:   state.commit.Author.When,
+				Hash:   state.commit.Hash,
+			}
+		}
+	}
+	return result, nil
+}
+
+func newestState(pending map[plumbing.Hash]*blameState) *blameState {
+	var newest *blameState
+	for _, state := range pending {
+		if newest == nil || state.commit.Committer.When.After(newest.commit.Committer.When) ||
+			(state.commit.Committer.When.Equal(newest.commit.Committer.When) && state.commit.Hash.String() < newest.commit.Hash.String()) {
+			newest = state
+		}
+	}
+	return newest
+}
+
+// passToParents passes the lines of the state unchanged in a parent on to that parent, in the order of the
+// parents, and returns the lines the commit of the state is to blame for
+func passToParents(state *blameState, path string, opts BlameOptions, pending map[plumbing.Hash]*blameState,
+	files map[plumbing.Hash]*blamedFile) ([]blamedLine, error) {
+	remaining := state.lines
+	file := files[state.commit.Hash]
+	var firstParent *object.Commit
+	var firstMatches []lineMatch
+	for i := 0; i < state.commit.NumParents() && len(remaining) > 0; i++ {
+		parent, err := state.commit.Parent(i)
+		if err != nil {
+			return nil, err
+		}
+		parentFile, ok, err := fileAt(parent, path, files)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			continue
+		}
+		var matches []lineMatch
+		if parentFile.blob == file.blob {
+			matches = identicalLines(len(file.lines))
+		} else {
+			matches = matchLines(parentFile.lines, file.lines, opts.IgnoreWhitespace)
+		}
+		if i == 0 {
+			firstParent, firstMatches = parent, matches
+		}
+		remaining = passLines(parent, remaining, matches, false, pending)
+	}
+	if opts.IgnoreRevs[state.commit.Hash] && firstParent != nil {
+		remaining = passLines(firstParent, remaining, firstMatches, true, pending)
+	}
+	return remaining, nil
+}
+
+// lineMatch tells for a line of a revision the line of the parent it is unchanged from (same), or failing
+// that the line of the parent at the same offset in the hunk replacing it (replaced), -1 if none
+type lineMatch struct {
+	same, replaced int
+}
+
+// passLines passes on to the parent the lines it has a match for and returns the others. Replaced lines
+// are passed on only if replaced is true, for the commits to ignore.
+func passLines(parent *object.Commit, lines []blamedLine, matches []lineMatch, replaced bool, pending map[plumbing.Hash]*blameState) []blamedLine {
+	var remaining []blamedLine
+	for _, line := range lines {
+		match := matches[line.current].same
+		if replaced {
+			match = matches[line.current].replaced
+		}
+		if match < 0 {
+			remaining = append(remaining, line)
+			continue
+		}
+		state, ok := pending[parent.Hash]
+		if !ok {
+			state = &blameState{commit: parent}
+			pending[parent.Hash] = state
+		}
+		state.lines = append(state.lines, blamedLine{final: line.final, current: match})
+	}
+	return remaining
+}
+
+// matchLines diffs the lines of a parent with the lines of a revision and matches every line of the
+// revision with a line of the parent
+func matchLines(parent, revision []string, ignoreWhitespace bool) []lineMatch {
+	matches := make([]lineMatch, len(revision))
+	parentLine, revisionLine := 0, 0
+	// Start and length of the lines deleted and inserted by the current hunk
+	deletedFrom, deleted, insertedFrom, inserted := 0, 0, 0, 0
+	endHunk := func() {
+		for i := 0; i < inserted && i < deleted; i++ {
+			matches[insertedFrom+i].replaced = deletedFrom + i
+		}
+		deleted, inserted = 0, 0
+	}
+	for _, d := range diff.Do(joinLines(parent, ignoreWhitespace), joinLines(revision, ignoreWhitespace)) {
+		n := strings.Count(d.Text, "\n")
+		switch d.Type {
+		case diffmatchpatch.DiffEqual:
+			endHunk()
+			for i := 0; i < n; i++ {
+				matches[revisionLine+i] = lineMatch{same: parentLine + i, replaced: parentLine + i}
+			}
+			parentLine += n
+			revisionLine += n
+		case diffmatchpatch.DiffDelete:
+			if deleted == 0 {
+				deletedFrom = parentLine
+			}
+			deleted += n
+			parentLine += n
+		case diffmatchpatch.DiffInsert:
+			if inserted == 0 {
+				insertedFrom = revisionLine
+			}
+			for i := 0; i < n; i++ {
+				matches[revisionLine+i] = lineMatch{same: -1, replaced: -1}
+			}
+			inserted += n
+			revisionLine += n
+		}
+	}
+	endHunk()
+	return matches
+}
+
+// identicalLines matches every line with the same line of an identical parent
+func identicalLines(n int) []lineMatch {
+	matches := make([]lineMatch, n)
+	for i := range matches {
+		matches[i] = lineMatch{same: i, replaced: i}
+	}
+	return matches
+}
+
+// blamedFile is the content of the blamed file at a commit
+type blamedFile struct {
+	blob  plumbing.Hash
+	lines []string
+}
+
+// fileAt returns the blamed file at the given commit, and false if the commit has no such file
+func fileAt(commit *object.Commit, path string, files map[plumbing.Hash]*blamedFile) (*blamedFile, bool, error) {
+	if file, ok := files[commit.Hash]; ok {
+		return file, true, nil
+	}
+	file, err := commit.File(path)
+	if err == object.ErrFileNotFound {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	contents, err := fileText(file)
+	if err != nil {
+		return nil, false, err
+	}
+	files[commit.Hash] = &blamedFile{blob: file.Hash, lines: splitLines(contents)}
+	return files[commit.Hash], true, nil
+}
+
+func splitLines(contents string) []string {
+	if contents == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(contents, "\n"), "\n")
+}
+
+// joinLines joins the lines into a text to diff, each one ending with a newline and stripped of its
+// whitespace if ignoreWhitespace is true
+func joinLines(lines []string, ignoreWhitespace bool) string {
+	var text strings.Builder
+	for _, line := range lines {
+		if ignoreWhitespace {
+			line = strings.Join(strings.Fields(line), "")
+		}
+		text.WriteString(line)
+		text.WriteString("\n")
+	}
+	return text.String()
+}
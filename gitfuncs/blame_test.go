@@ -0,0 +1,139 @@
+package gitfuncs
+
+import (
+	"os/exec"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/andymeneely/git-churn/testutil"
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/src-d/go-git.v4"
+	"gopkg.in/src-d/go-git.v4/plumbing"
+	"gopkg.in/src-d/go-git.v4/plumbing/object"
+)
+
+// commitContents commits the successive contents of a.txt one day apart, each by the given author
+func commitContents(t *testing.T, authors []string, contents ...string) (*git.Repository, []*object.Commit) {
+	repo := testutil.NewRepo(t)
+	var commits []*object.Commit
+	for i, content := range contents {
+		commits = append(commits, repo.As(authors[i]).Write("a.txt", content).Commit("commit"))
+	}
+	return repo.Repository, commits
+}
+
+func blameAuthors(result *git.BlameResult) string {
+	var authors []string
+	for _, line := range result.Lines {
+		authors = append(authors, strings.TrimSuffix(line.Author, "@example.com"))
+	}
+	return strings.Join(authors, ",")
+}
+
+func TestBlameWithOptions(t *testing.T) {
+	assert := assert.New(t)
+	_, commits := commitContents(t, []string{"alice", "bob", "carol"},
+		"func f() {\nreturn 1\n}\n",
+		"func f() {\n    return 1\n}\n\n// g\n",
+		"func f() {\n    return 2\n}\n\n// g\n",
+	)
+	head, reformat := commits[2], commits[1]
+
+	expected, err := git.Blame(head, "a.txt")
+	assert.Nil(err)
+	result, err := BlameWithOptions(head, "a.txt", BlameOptions{})
+	assert.Nil(err)
+	assert.Equal(expected, result)
+	assert.Equal("alice,carol,alice,bob,bob", blameAuthors(result))
+
+	result, err = BlameWithOptions(reformat, "a.txt", BlameOptions{IgnoreWhitespace: true})
+	assert.Nil(err)
+	assert.Equal("alice,alice,alice,bob,bob", blameAuthors(result))
+
+	// The reformat changed line 2, inserted the last two lines
+	result, err = BlameWithOptions(reformat, "a.txt", BlameOptions{IgnoreRevs: map[plumbing.Hash]bool{reformat.Hash: true}})
+	assert.Nil(err)
+	assert.Equal("alice,alice,alice,bob,bob", blameAuthors(result))
+
+	result, err = BlameWithOptions(head, "a.txt", BlameOptions{StartLine: 2, EndLine: 3})
+	assert.Nil(err)
+	assert.Equal("carol,alice", blameAuthors(result))
+	assert.Equal("    return 2", result.Lines[0].Text)
+
+	_, err = BlameWithOptions(head, "a.txt", BlameOptions{StartLine: 9})
+	assert.NotNil(err)
+}
+
+func TestReadIgnoreRevs(t *testing.T) {
+	assert := assert.New(t)
+	revs, err := ReadIgnoreRevs(strings.NewReader("# reformat\n0123456789abcdef0123456789abcdef01234567 # gofmt\n\n"))
+	assert.Nil(err)
+	assert.Equal(map[plumbing.Hash]bool{plumbing.NewHash("0123456789abcdef0123456789abcdef01234567"): true}, revs)
+
+	_, err = ReadIgnoreRevs(strings.NewReader("0123456\n"))
+	assert.NotNil(err)
+}
+
+func TestParseBlamePorcelain(t *testing.T) {
+	assert := assert.New(t)
+	porcelain := `0123456789abcdef0123456789abcdef01234567 1 1 2
+author Alice
+author-mail <alice@example.com>
+author-time 1577872800
+author-tz +0200
+summary initial
+filename a.txt
+	first
+0123456789abcdef0123456789abcdef01234567 2 2
+	second
+`
+	lines, err := parseBlamePorcelain(strings.NewReader(porcelain))
+	assert.Nil(err)
+	assert.Equal(2, len(lines))
+	assert.Equal("alice@example.com", lines[1].Author)
+	assert.Equal("second", lines[1].Text)
+	assert.Equal("0123456789abcdef0123456789abcdef01234567", lines[1].Hash.String())
+	assert.True(time.Date(2020, 1, 1, 10, 0, 0, 0, time.UTC).Equal(lines[1].Date))
+	_, offset := lines[1].Date.Zone()
+	assert.Equal(7200, offset)
+
+	_, err = parseBlamePorcelain(strings.NewReader("author-mail <alice@example.com>\n"))
+	assert.EqualError(err, "unexpected author-mail before any commit in git blame output")
+	_, err = parseBlamePorcelain(strings.NewReader("author-time 1577872800\n"))
+	assert.NotNil(err)
+}
+
+// diskRepo commits the successive contents of a.txt one day apart in a repository on disk, for the git
+// command line, and returns it along with a function removing it
+func diskRepo(t *testing.T, contents ...string) (*git.Repository, *object.Commit, func()) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	repo := testutil.NewDiskRepo(t)
+	for _, content := range contents {
+		repo.Write("a.txt", content).Commit("commit")
+	}
+	return repo.Repository, repo.Head(), repo.Remove
+}
+
+func TestCliBlame(t *testing.T) {
+	assert := assert.New(t)
+	repo, commit, remove := diskRepo(t, "1\n2\n", "1\n3\n")
+	defer remove()
+
+	expected, err := git.Blame(commit, "a.txt")
+	assert.Nil(err)
+	result, err := cliBlame(repo, commit.Hash, "a.txt", BlameOptions{})
+	assert.Nil(err)
+	assert.Equal(len(expected.Lines), len(result.Lines))
+	for i := range expected.Lines {
+		assert.Equal(expected.Lines[i].Hash, result.Lines[i].Hash)
+		assert.Equal(expected.Lines[i].Author, result.Lines[i].Author)
+		assert.True(expected.Lines[i].Date.Equal(result.Lines[i].Date))
+	}
+
+	result, err = cliBlame(repo, commit.Hash, "a.txt", BlameOptions{StartLine: 2})
+	assert.Nil(err)
+	assert.Equal("3", result.Lines[0].Text)
+}
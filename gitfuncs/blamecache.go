@@ -0,0 +1,190 @@
+package gitfuncs
+
+import (
+	"container/list"
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	. "github.com/andymeneely/git-churn/print"
+	"gopkg.in/src-d/go-git.v4"
+	"gopkg.in/src-d/go-git.v4/plumbing"
+	"gopkg.in/src-d/go-git.v4/plumbing/object"
+)
+
+// BlameCache keeps the blames of the files by commit, path and BlameOpts so that the metrics blaming
+// the same files over and over within a run blame them once. The most recently used blames are kept
+// in memory and, when Dir is set, every blame is written there to be reused by the next runs too.
+// The cached results are shared and must not be modified.
+type BlameCache struct {
+	// Most blames kept in memory, 0 for no limit
+	MaxEntries int
+	// Directory the blames are written to, none if empty
+	Dir string
+
+	mu      sync.Mutex
+	entries map[string]*list.Element
+	lru     *list.List
+	hits    int
+	misses  int
+}
+
+type blameEntry struct {
+	key    string
+	result *git.BlameResult
+}
+
+// NewBlameCache returns a cache of at most maxEntries blames in memory, also stored in dir unless empty
+func NewBlameCache(maxEntries int, dir string) *BlameCache {
+	return &BlameCache{MaxEntries: maxEntries, Dir: dir, entries: make(map[string]*list.Element), lru: list.New()}
+}
+
+// Blame returns the cached blame of the file at the commit with BlameOpts, blaming it with blame
+// the first time. Failed blames are not cached.
+func (c *BlameCache) Blame(commit *object.Commit, path string, blame func() (*git.BlameResult, error)) (*git.BlameResult, error) {
+	key := blameKey(commit, path, BlameOpts)
+	if result := c.get(key); result != nil {
+		return result, nil
+	}
+	result, err := blame()
+	if err != nil {
+		return nil, err
+	}
+	c.put(key, result)
+	return result, nil
+}
+
+// Stats returns the number of blames served from the cache and the number of blames computed
+func (c *BlameCache) Stats() (hits int, misses int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.hits, c.misses
+}
+
+func (c *BlameCache) get(key string) *git.BlameResult {
+	c.mu.Lock()
+	if element, ok := c.entries[key]; ok {
+		c.lru.MoveToFront(element)
+		c.hits += 1
+		c.mu.Unlock()
+		return element.Value.(*blameEntry).result
+	}
+	c.mu.Unlock()
+
+	result := c.read(key)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if result == nil {
+		c.misses += 1
+		return nil
+	}
+	c.hits += 1
+	c.add(key, result)
+	return result
+}
+
+func (c *BlameCache) put(key string, result *git.BlameResult) {
+	c.mu.Lock()
+	c.add(key, result)
+	c.mu.Unlock()
+	if err := c.write(key, result); err != nil {
+		Debug("could not cache the blame of %s at %s: %v", result.Path, result.Rev, err)
+	}
+}
+
+// add keeps the result in memory, evicting the least recently used ones beyond MaxEntries
+func (c *BlameCache) add(key string, result *git.BlameResult) {
+	if element, ok := c.entries[key]; ok {
+		c.lru.MoveToFront(element)
+		return
+	}
+	c.entries[key] = c.lru.PushFront(&blameEntry{key: key, result: result})
+	for c.MaxEntries > 0 && c.lru.Len() > c.MaxEntries {
+		oldest := c.lru.Back()
+		c.lru.Remove(oldest)
+		delete(c.entries, oldest.Value.(*blameEntry).key)
+	}
+}
+
+func (c *BlameCache) read(key string) *git.BlameResult {
+	if c.Dir == "" {
+		return nil
+	}
+	data, err := ioutil.ReadFile(filepath.Join(c.Dir, key+".json"))
+	if err != nil {
+		return nil
+	}
+	result := new(git.BlameResult)
+	if err := json.Unmarshal(data, result); err != nil {
+		Debug("ignoring the corrupt cached blame %s: %v", key, err)
+		return nil
+	}
+	return result
+}
+
+// write stores the result in Dir, through a temporary file so that concurrent runs never read half a blame
+func (c *BlameCache) write(key string, result *git.BlameResult) error {
+	if c.Dir == "" {
+		return nil
+	}
+	if err := os.MkdirAll(c.Dir, 0755); err != nil {
+		return err
+	}
+	data, err := json.Marshal(result)
+	if err != nil {
+		return err
+	}
+	tmp, err := ioutil.TempFile(c.Dir, key+".*.tmp")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), filepath.Join(c.Dir, key+".json"))
+}
+
+// blameKey identifies the blame of the file at the commit with the options, which change the result
+func blameKey(commit *object.Commit, path string, opts BlameOptions) string {
+	var revs []string
+	for rev := range opts.IgnoreRevs {
+		revs = append(revs, rev.String())
+	}
+	sort.Strings(revs)
+	h := sha1.New()
+	fmt.Fprintf(h, "%s\x00%s\x00%v\x00%d\x00%d\x00%v", commit.Hash, path, opts.IgnoreWhitespace, opts.StartLine, opts.EndLine, revs)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// CachedEngine is an engine whose blames go through a BlameCache
+type CachedEngine struct {
+	Engine
+	Cache *BlameCache
+}
+
+// BatchStats computes the stats of a range with the engine, when it is a StatsBatcher
+func (e CachedEngine) BatchStats(repo *git.Repository, from, to plumbing.Hash, since time.Time) error {
+	if batcher, ok := e.Engine.(StatsBatcher); ok {
+		return batcher.BatchStats(repo, from, to, since)
+	}
+	return nil
+}
+
+func (e CachedEngine) Blame(repo *git.Repository, commit *object.Commit, path string) (*git.BlameResult, error) {
+	return e.Cache.Blame(commit, path, func() (*git.BlameResult, error) {
+		return e.Engine.Blame(repo, commit, path)
+	})
+}
@@ -0,0 +1,75 @@
+package gitfuncs
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/src-d/go-git.v4"
+	"gopkg.in/src-d/go-git.v4/plumbing/object"
+)
+
+// countingEngine counts the blames it computes
+type countingEngine struct {
+	GoGitEngine
+	blames int
+}
+
+func (e *countingEngine) Blame(repo *git.Repository, commit *object.Commit, path string) (*git.BlameResult, error) {
+	e.blames += 1
+	return e.GoGitEngine.Blame(repo, commit, path)
+}
+
+func TestBlameCache(t *testing.T) {
+	assert := assert.New(t)
+	repo, commits := commitContents(t, []string{"alice", "bob", "carol"}, "1\n", "1\n2\n", "1\n2\n 3\n")
+	dir, err := ioutil.TempDir("", "blamecache")
+	assert.Nil(err)
+	defer os.RemoveAll(dir)
+
+	counting := &countingEngine{}
+	engine := CachedEngine{Engine: counting, Cache: NewBlameCache(2, dir)}
+	first, err := engine.Blame(repo, commits[2], "a.txt")
+	assert.Nil(err)
+	again, err := engine.Blame(repo, commits[2], "a.txt")
+	assert.Nil(err)
+	assert.True(first == again)
+	assert.Equal(1, counting.blames)
+
+	// The options change the blame
+	BlameOpts.IgnoreWhitespace = true
+	_, err = engine.Blame(repo, commits[2], "a.txt")
+	BlameOpts.IgnoreWhitespace = false
+	assert.Nil(err)
+	assert.Equal(2, counting.blames)
+
+	// Failed blames are not cached
+	_, err = engine.Blame(repo, commits[2], "b.txt")
+	assert.Equal(object.ErrFileNotFound, err)
+	_, err = engine.Blame(repo, commits[2], "b.txt")
+	assert.Equal(object.ErrFileNotFound, err)
+	assert.Equal(4, counting.blames)
+
+	// The least recently used blames are evicted from memory but read back from the directory
+	_, err = engine.Blame(repo, commits[1], "a.txt")
+	assert.Nil(err)
+	_, err = engine.Blame(repo, commits[0], "a.txt")
+	assert.Nil(err)
+	assert.Equal(2, engine.Cache.lru.Len())
+	fromDisk, err := engine.Blame(repo, commits[2], "a.txt")
+	assert.Nil(err)
+	assert.Equal(6, counting.blames)
+	assert.Equal(blameAuthors(first), blameAuthors(fromDisk))
+	assert.Equal(first.Lines[2].Hash, fromDisk.Lines[2].Hash)
+	assert.True(first.Lines[2].Date.Equal(fromDisk.Lines[2].Date))
+
+	// The next runs reuse the blames of the directory
+	next := CachedEngine{Engine: counting, Cache: NewBlameCache(0, dir)}
+	_, err = next.Blame(repo, commits[1], "a.txt")
+	assert.Nil(err)
+	assert.Equal(6, counting.blames)
+	hits, misses := next.Cache.Stats()
+	assert.Equal(1, hits)
+	assert.Equal(0, misses)
+}
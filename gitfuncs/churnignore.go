@@ -0,0 +1,140 @@
+package gitfuncs
+
+import (
+	"bufio"
+	"io"
+	"strings"
+	"sync"
+
+	. "github.com/andymeneely/git-churn/print"
+	"gopkg.in/src-d/go-git.v4"
+	"gopkg.in/src-d/go-git.v4/plumbing/format/gitignore"
+	"gopkg.in/src-d/go-git.v4/plumbing/object"
+)
+
+// DefaultIgnorePatterns match the vendored dependencies, build outputs and generated code left out of the
+// churn and LOC metrics unless a .churnignore includes them back with a ! pattern
+var DefaultIgnorePatterns = []string{
+	"vendor/",
+	"node_modules/",
+	"dist/",
+	"*.pb.go",
+}
+
+// IgnorePatterns are left out on top of the .churnignore of the repositories, and take precedence over it
+var IgnorePatterns []string
+
+// IgnoreDisabled keeps every file in the churn and LOC metrics, ignoring the defaults and the .churnignore
+var IgnoreDisabled bool
+
+// Ignore tells the files left out of the churn and LOC metrics, matching their path against patterns in
+// the syntax of .gitignore. A nil Ignore keeps every file within PathScope.
+type Ignore struct {
+	matcher gitignore.Matcher
+}
+
+// NewIgnore returns an Ignore of the given patterns, the later ones taking precedence like in a .gitignore
+func NewIgnore(patterns []string) *Ignore {
+	var parsed []gitignore.Pattern
+	for _, pattern := range patterns {
+		parsed = append(parsed, gitignore.ParsePattern(pattern, nil))
+	}
+	return &Ignore{matcher: gitignore.NewMatcher(parsed)}
+}
+
+// Match tells whether the file at the given path is ignored, the files out of PathScope always being
+func (i *Ignore) Match(path string) bool {
+	if !InScope(path) {
+		return true
+	}
+	if i == nil {
+		return false
+	}
+	return i.matcher.Match(strings.Split(path, "/"), false)
+}
+
+// ReadIgnorePatterns reads the patterns of a .churnignore, in the syntax of .gitignore, skipping blank
+// lines and comments
+func ReadIgnorePatterns(r io.Reader) ([]string, error) {
+	var patterns []string
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if strings.TrimSpace(line) == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		patterns = append(patterns, line)
+	}
+	return patterns, scanner.Err()
+}
+
+// The Ignore of the repository last looked at, so that its .churnignore is read once per analysis
+var repoIgnore = struct {
+	sync.Mutex
+	repo   *git.Repository
+	ignore *Ignore
+}{}
+
+// RepoIgnore returns the files of the repository left out of the churn and LOC metrics:
+// DefaultIgnorePatterns, the patterns of the .churnignore at the root of its HEAD then IgnorePatterns, or
+// nil if IgnoreDisabled is set
+func RepoIgnore(repo *git.Repository) *Ignore {
+	if IgnoreDisabled {
+		return nil
+	}
+	repoIgnore.Lock()
+	defer repoIgnore.Unlock()
+	if repoIgnore.repo != repo {
+		repoIgnore.repo = repo
+		patterns := append(append([]string{}, DefaultIgnorePatterns...), readChurnignore(repo)...)
+		repoIgnore.ignore = NewIgnore(append(patterns, IgnorePatterns...))
+	}
+	return repoIgnore.ignore
+}
+
+func readChurnignore(repo *git.Repository) []string {
+	head, err := repo.Head()
+	if err != nil {
+		return nil
+	}
+	commit, err := repo.CommitObject(head.Hash())
+	if err != nil {
+		return nil
+	}
+	file, err := commit.File(".churnignore")
+	if err != nil {
+		return nil
+	}
+	reader, err := file.Reader()
+	if err != nil {
+		return nil
+	}
+	defer reader.Close()
+	patterns, err := ReadIgnorePatterns(reader)
+	if err != nil {
+		Warning("ignoring the .churnignore of the repository: %v", err)
+		return nil
+	}
+	return patterns
+}
+
+// IsIgnored tells whether the file at the given path of the repository is left out of the churn and LOC
+// metrics
+func IsIgnored(repo *git.Repository, path string) bool {
+	return RepoIgnore(repo).Match(path)
+}
+
+// WithoutIgnored returns the changes to the files of the repository that are not ignored
+func WithoutIgnored(repo *git.Repository, changes object.Changes) object.Changes {
+	ignore := RepoIgnore(repo)
+	if ignore == nil && PathScope == "" {
+		return changes
+	}
+	var kept object.Changes
+	for _, change := range changes {
+		if !ignore.Match(changePath(change)) {
+			kept = append(kept, change)
+		}
+	}
+	return kept
+}
@@ -0,0 +1,43 @@
+package gitfuncs
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/andymeneely/git-churn/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIgnore(t *testing.T) {
+	assert := assert.New(t)
+	ignore := NewIgnore(DefaultIgnorePatterns)
+	for _, path := range []string{"vendor/a.go", "web/node_modules/x/index.js", "dist/app.js", "api/service.pb.go"} {
+		assert.True(ignore.Match(path), path)
+	}
+	for _, path := range []string{"main.go", "vendors.go", "api/service.go", "docs/distribution.md"} {
+		assert.False(ignore.Match(path), path)
+	}
+
+	patterns, err := ReadIgnorePatterns(strings.NewReader("# generated\n*_gen.go\n\n!dist/\n"))
+	assert.Nil(err)
+	assert.Equal([]string{"*_gen.go", "!dist/"}, patterns)
+
+	var none *Ignore
+	assert.False(none.Match("vendor/a.go"))
+}
+
+func TestRepoIgnore(t *testing.T) {
+	assert := assert.New(t)
+	repo := testutil.NewRepo(t)
+	repo.CommitFiles("commit", map[string]string{"a.txt": "1\n"})
+	repo.CommitFiles("churnignore", map[string]string{".churnignore": "*_gen.go\n!vendor/\n"})
+
+	assert.True(IsIgnored(repo.Repository, "model_gen.go"))
+	assert.True(IsIgnored(repo.Repository, "node_modules/a.js"))
+	assert.False(IsIgnored(repo.Repository, "vendor/a.go"))
+	assert.False(IsIgnored(repo.Repository, "a.txt"))
+
+	IgnoreDisabled = true
+	defer func() { IgnoreDisabled = false }()
+	assert.False(IsIgnored(repo.Repository, "model_gen.go"))
+}
@@ -0,0 +1,309 @@
+package gitfuncs
+
+import (
+	"strings"
+
+	"github.com/sergi/go-diff/diffmatchpatch"
+	"gopkg.in/src-d/go-git.v4"
+	"gopkg.in/src-d/go-git.v4/plumbing/filemode"
+	"gopkg.in/src-d/go-git.v4/plumbing/format/diff"
+	"gopkg.in/src-d/go-git.v4/plumbing/object"
+)
+
+// LineType tells whether a line of a patch was added or deleted
+type LineType int
+
+const (
+	LineAdded LineType = iota + 1
+	LineDeleted
+)
+
+func (t LineType) String() string {
+	switch t {
+	case LineAdded:
+		return "added"
+	case LineDeleted:
+		return "deleted"
+	}
+	return "unknown"
+}
+
+func (t LineType) MarshalText() ([]byte, error) {
+	return []byte(t.String()), nil
+}
+
+// PatchLine is a line added or deleted by a patch. Its number counts from 1 in the new version of the
+// file for an added line and in the old version for a deleted one.
+type PatchLine struct {
+	Type   LineType
+	Number int
+	// Text of the line, without its line break
+	Text string
+	// Last change to the deleted line before the patch, once blamed with BlameDeleted
+	Origin *git.Line `json:",omitempty"`
+}
+
+// Blank tells whether the line is empty or only made of whitespace
+func (l PatchLine) Blank() bool {
+	return strings.TrimSpace(l.Text) == ""
+}
+
+// PatchHunk is a run of lines of a file replaced by other lines, without context lines: the deleted
+// lines, then the added ones
+type PatchHunk struct {
+	Lines []PatchLine
+}
+
+// FilePatch is the patch of a file. Binary files and symbolic links, whose content is the path they
+// point at, have no hunks.
+type FilePatch struct {
+	// Paths of the old and the new version of the file, empty when added and deleted respectively
+	OldPath string `json:",omitempty"`
+	NewPath string `json:",omitempty"`
+	Binary  bool
+	Symlink bool
+	Hunks   []PatchHunk
+}
+
+// Path returns the new path of the file, or its old path when deleted
+func (f *FilePatch) Path() string {
+	if f.NewPath != "" {
+		return f.NewPath
+	}
+	return f.OldPath
+}
+
+// Lines returns the lines of the type over all the hunks, the blank ones only if whitespace is true
+func (f *FilePatch) Lines(lineType LineType, whitespace bool) []PatchLine {
+	var lines []PatchLine
+	for _, hunk := range f.Hunks {
+		for _, line := range hunk.Lines {
+			if line.Type == lineType && (whitespace || !line.Blank()) {
+				lines = append(lines, line)
+			}
+		}
+	}
+	return lines
+}
+
+// LineNumbers returns the numbers of the lines of the type like Lines
+func (f *FilePatch) LineNumbers(lineType LineType, whitespace bool) []int {
+	var numbers []int
+	for _, line := range f.Lines(lineType, whitespace) {
+		numbers = append(numbers, line.Number)
+	}
+	return numbers
+}
+
+// Count returns the number of lines of the type like Lines
+func (f *FilePatch) Count(lineType LineType, whitespace bool) int {
+	return len(f.Lines(lineType, whitespace))
+}
+
+// ChurnPatch is a go-git patch broken down by file, hunk and line, the model the churn metrics read the
+// lines added and deleted from
+type ChurnPatch struct {
+	Files []*FilePatch
+}
+
+// NewChurnPatch breaks the go-git patch down by file, hunk and line
+func NewChurnPatch(patch *object.Patch) *ChurnPatch {
+	churnPatch := &ChurnPatch{}
+	for _, filePatch := range patch.FilePatches() {
+		churnPatch.Files = append(churnPatch.Files, newFilePatch(filePatch))
+	}
+	return churnPatch
+}
+
+// ChurnPatchBetween returns the patch between the two trees, a nil `from` tree being empty, the files of
+// the moved directories being paired when DetectDirMoves is set
+func ChurnPatchBetween(from, to *object.Tree) (*ChurnPatch, error) {
+	changes, err := diffTreeMoves(from, to)
+	if err != nil {
+		return nil, err
+	}
+	return ChangesChurnPatch(changes)
+}
+
+// ChangesChurnPatch returns the patch of the changes. The files are diffed like go-git's patches once
+// decoded like CountLines, so that UTF-16 files have lines too, with their CRLF line endings turned
+// into LF when IgnoreEOL is set and their lines compared regardless of whitespace when IgnoreAllSpace is.
+func ChangesChurnPatch(changes object.Changes) (*ChurnPatch, error) {
+	churnPatch := &ChurnPatch{}
+	for _, change := range changes {
+		file, err := changeFilePatch(change)
+		if err != nil {
+			return nil, err
+		}
+		churnPatch.Files = append(churnPatch.Files, file)
+	}
+	return churnPatch, nil
+}
+
+// CommitChurnPatch returns the patch of the commit against its first parent, the files of a root commit
+// being all added
+func CommitChurnPatch(commit *object.Commit) (*ChurnPatch, error) {
+	tree, err := commit.Tree()
+	if err != nil {
+		return nil, err
+	}
+	var parentTree *object.Tree
+	if commit.NumParents() > 0 {
+		parent, err := commit.Parent(0)
+		if err != nil {
+			return nil, err
+		}
+		if parentTree, err = parent.Tree(); err != nil {
+			return nil, err
+		}
+	}
+	return ChurnPatchBetween(parentTree, tree)
+}
+
+// File returns the patch of the file at the path, old or new, nil if unchanged
+func (p *ChurnPatch) File(path string) *FilePatch {
+	for _, file := range p.Files {
+		if file.NewPath == path || file.OldPath == path {
+			return file
+		}
+	}
+	return nil
+}
+
+// Count returns the number of lines of the type over all the files, the blank ones only if whitespace is true
+func (p *ChurnPatch) Count(lineType LineType, whitespace bool) int {
+	count := 0
+	for _, file := range p.Files {
+		count += file.Count(lineType, whitespace)
+	}
+	return count
+}
+
+// BlameDeleted sets the Origin of the deleted lines, blaming the old version of the files at the commit
+// the patch was made from
+func (p *ChurnPatch) BlameDeleted(repo *git.Repository, from *object.Commit) error {
+	for _, file := range p.Files {
+		if file.OldPath == "" || file.Count(LineDeleted, true) == 0 {
+			continue
+		}
+		blame, err := ActiveEngine.Blame(repo, from, file.OldPath)
+		if err != nil {
+			return err
+		}
+		for _, hunk := range file.Hunks {
+			for i, line := range hunk.Lines {
+				if line.Type == LineDeleted && line.Number <= len(blame.Lines) {
+					hunk.Lines[i].Origin = blame.Lines[line.Number-1]
+				}
+			}
+		}
+	}
+	return nil
+}
+
+func newFilePatch(filePatch diff.FilePatch) *FilePatch {
+	file := &FilePatch{Binary: filePatch.IsBinary()}
+	from, to := filePatch.Files()
+	if from != nil {
+		file.OldPath = from.Path()
+		file.Symlink = from.Mode() == filemode.Symlink
+	}
+	if to != nil {
+		file.NewPath = to.Path()
+		file.Symlink = file.Symlink || to.Mode() == filemode.Symlink
+	}
+	if file.Symlink {
+		return file
+	}
+	builder := hunkBuilder{file: file}
+	for _, chunk := range filePatch.Chunks() {
+		builder.add(chunk.Type(), chunk.Content())
+	}
+	return file
+}
+
+// changeFilePatch diffs the decoded text of the files of the change
+func changeFilePatch(change *object.Change) (*FilePatch, error) {
+	file := &FilePatch{OldPath: change.From.Name, NewPath: change.To.Name}
+	file.Symlink = change.From.TreeEntry.Mode == filemode.Symlink || change.To.TreeEntry.Mode == filemode.Symlink
+	if file.Symlink {
+		return file, nil
+	}
+	from, to, err := change.Files()
+	if err != nil {
+		return nil, err
+	}
+	if file.Binary, err = isBinary(from, to); err != nil || file.Binary {
+		return file, err
+	}
+	var fromText, toText string
+	if from != nil {
+		if fromText, err = fileText(from); err != nil {
+			return nil, err
+		}
+	}
+	if to != nil {
+		if toText, err = fileText(to); err != nil {
+			return nil, err
+		}
+	}
+	builder := hunkBuilder{file: file}
+	fromLines, toLines := textLines(fromText), textLines(toText)
+	oldLine, newLine := 0, 0
+	for _, d := range diffLines(fromLines, toLines) {
+		n := len([]rune(d.Text))
+		switch d.Type {
+		case diffmatchpatch.DiffEqual:
+			builder.add(diff.Equal, strings.Join(fromLines[oldLine:oldLine+n], ""))
+			oldLine += n
+			newLine += n
+		case diffmatchpatch.DiffInsert:
+			builder.add(diff.Add, strings.Join(toLines[newLine:newLine+n], ""))
+			newLine += n
+		case diffmatchpatch.DiffDelete:
+			builder.add(diff.Delete, strings.Join(fromLines[oldLine:oldLine+n], ""))
+			oldLine += n
+		}
+	}
+	return file, nil
+}
+
+// hunkBuilder numbers the lines of the chunks of a file's patch, in order, and groups the lines added
+// and deleted in between equal chunks into hunks
+type hunkBuilder struct {
+	file             *FilePatch
+	hunk             *PatchHunk
+	oldLine, newLine int
+}
+
+func (b *hunkBuilder) add(operation diff.Operation, content string) {
+	texts := chunkTexts(content)
+	if operation == diff.Equal {
+		b.hunk = nil
+		b.oldLine += len(texts)
+		b.newLine += len(texts)
+		return
+	}
+	if b.hunk == nil {
+		b.file.Hunks = append(b.file.Hunks, PatchHunk{})
+		b.hunk = &b.file.Hunks[len(b.file.Hunks)-1]
+	}
+	for _, text := range texts {
+		if operation == diff.Add {
+			b.newLine += 1
+			b.hunk.Lines = append(b.hunk.Lines, PatchLine{Type: LineAdded, Number: b.newLine, Text: text})
+		} else {
+			b.oldLine += 1
+			b.hunk.Lines = append(b.hunk.Lines, PatchLine{Type: LineDeleted, Number: b.oldLine, Text: text})
+		}
+	}
+}
+
+// chunkTexts splits the content of a chunk in lines. Only the last line may lack its line break, when
+// the file does not end with one.
+func chunkTexts(content string) []string {
+	if content == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(content, "\n"), "\n")
+}
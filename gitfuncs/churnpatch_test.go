@@ -0,0 +1,49 @@
+package gitfuncs
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestChurnPatch(t *testing.T) {
+	assert := assert.New(t)
+	repo, commits := commitContents(t, []string{"alice", "bob"},
+		"1\n2\n3\n4\n5\n",
+		"1\nb\n3\n4\n\n6",
+	)
+
+	patch, err := CommitChurnPatch(commits[1])
+	assert.Nil(err)
+	assert.Equal(1, len(patch.Files))
+	file := patch.File("a.txt")
+	assert.Equal("a.txt", file.OldPath)
+	assert.Equal("a.txt", file.Path())
+	assert.False(file.Binary)
+	assert.Equal([]PatchHunk{
+		{Lines: []PatchLine{{Type: LineDeleted, Number: 2, Text: "2"}, {Type: LineAdded, Number: 2, Text: "b"}}},
+		{Lines: []PatchLine{{Type: LineDeleted, Number: 5, Text: "5"}, {Type: LineAdded, Number: 5, Text: ""}, {Type: LineAdded, Number: 6, Text: "6"}}},
+	}, file.Hunks)
+	assert.Equal([]int{2, 5, 6}, file.LineNumbers(LineAdded, true))
+	assert.Equal([]int{2, 6}, file.LineNumbers(LineAdded, false))
+	assert.Equal(3, patch.Count(LineAdded, true))
+	assert.Equal(2, patch.Count(LineDeleted, false))
+	assert.Nil(patch.File("b.txt"))
+
+	assert.Nil(patch.BlameDeleted(repo, commits[0]))
+	origin := file.Hunks[1].Lines[0].Origin
+	assert.Equal("alice@example.com", origin.Author)
+	assert.Equal(commits[0].Hash, origin.Hash)
+	assert.Nil(file.Hunks[1].Lines[1].Origin)
+
+	out, err := json.Marshal(file.Hunks[0].Lines[1])
+	assert.Nil(err)
+	assert.Equal(`{"Type":"added","Number":2,"Text":"b"}`, string(out))
+
+	// The files of a root commit are all added
+	patch, err = CommitChurnPatch(commits[0])
+	assert.Nil(err)
+	assert.Equal("", patch.Files[0].OldPath)
+	assert.Equal([]int{1, 2, 3, 4, 5}, patch.Files[0].LineNumbers(LineAdded, true))
+}
@@ -0,0 +1,91 @@
+package gitfuncs
+
+import (
+	"strings"
+
+	"github.com/andymeneely/git-churn/lang"
+	"gopkg.in/src-d/go-git.v4/plumbing/object"
+)
+
+// CodeChange is the change to a text file, counting all its lines and only its lines of code
+type CodeChange struct {
+	Path           string
+	Language       string
+	Insertions     int `units:"lines"`
+	Deletions      int `units:"lines"`
+	CodeInsertions int `units:"lines"`
+	CodeDeletions  int `units:"lines"`
+}
+
+// CodeChanges counts the lines added and deleted per changed text file, blank ones only if whitespace is
+// true, and among them the lines of code, neither blank nor only comments, according to the language of
+// the file. Binary files and symbolic links are left out.
+func CodeChanges(changes object.Changes, whitespace bool) ([]CodeChange, error) {
+	var codeChanges []CodeChange
+	for _, change := range WithoutSymlinks(changes) {
+		from, to, err := change.Files()
+		if err != nil {
+			return nil, err
+		}
+		if binary, err := isBinary(from, to); err != nil {
+			return nil, err
+		} else if binary {
+			continue
+		}
+		path := changePath(change)
+		var fromCode, toCode []bool
+		if from != nil {
+			if fromCode, err = codeLines(from); err != nil {
+				return nil, err
+			}
+		}
+		if to != nil {
+			if toCode, err = codeLines(to); err != nil {
+				return nil, err
+			}
+		}
+		file, err := changeFilePatch(change)
+		if err != nil {
+			return nil, err
+		}
+		codeChange := CodeChange{Path: path, Language: lang.Detect(path)}
+		codeChange.Insertions = file.Count(LineAdded, whitespace)
+		codeChange.Deletions = file.Count(LineDeleted, whitespace)
+		codeChange.CodeInsertions = countCode(toCode, file.LineNumbers(LineAdded, true))
+		codeChange.CodeDeletions = countCode(fromCode, file.LineNumbers(LineDeleted, true))
+		codeChanges = append(codeChanges, codeChange)
+	}
+	return codeChanges, nil
+}
+
+func codeLines(f *object.File) ([]bool, error) {
+	tracker := lang.NewCodeTracker(lang.Detect(f.Name))
+	var code []bool
+	err := forEachLine(f, func(line string) {
+		code = append(code, tracker.IsCode(line))
+	})
+	return code, err
+}
+
+// chunkLines returns the number of lines in the content of a chunk, the last one may lack its line break
+func chunkLines(content string) int {
+	if content == "" {
+		return 0
+	}
+	n := strings.Count(content, "\n")
+	if !strings.HasSuffix(content, "\n") {
+		n += 1
+	}
+	return n
+}
+
+// countCode counts the lines of code among the lines of the given numbers, counting from 1
+func countCode(code []bool, numbers []int) int {
+	count := 0
+	for _, n := range numbers {
+		if n <= len(code) && code[n-1] {
+			count += 1
+		}
+	}
+	return count
+}
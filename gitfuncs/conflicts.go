@@ -0,0 +1,211 @@
+package gitfuncs
+
+import (
+	"sort"
+
+	"gopkg.in/src-d/go-git.v4/plumbing/object"
+)
+
+// Reasons git stops on a conflict when merging a file changed on both sides
+const (
+	ConflictContent      = "content"
+	ConflictModifyDelete = "modify/delete"
+	ConflictAddAdd       = "add/add"
+	ConflictBinary       = "binary"
+)
+
+// LineRange is a run of lines of a file counting from 1. An empty run, ending on the line before its start,
+// is the place lines are inserted at.
+type LineRange struct {
+	Start int
+	End   int
+}
+
+// touches tells whether the runs overlap or are adjacent, git merging the changes of such runs as one
+// conflict
+func (r LineRange) touches(o LineRange) bool {
+	return r.Start <= o.End+1 && o.Start <= r.End+1
+}
+
+// distance returns the number of lines between the runs, 0 when they touch
+func (r LineRange) distance(o LineRange) int {
+	if r.touches(o) {
+		return 0
+	}
+	if r.End < o.Start {
+		return o.Start - r.End - 1
+	}
+	return r.Start - o.End - 1
+}
+
+// MergeFile is a file changed differently on both sides of a merge since their merge base
+type MergeFile struct {
+	File string
+	// Why git will stop on a conflict, see the Conflict constants, empty when the changes merge cleanly
+	Conflict string
+	// Runs of lines of the merge base changed on both sides, overlapping or adjacent
+	Ranges []LineRange
+	// Fewest lines of the merge base between a change of one side and a change of the other, 0 when they
+	// touch
+	Distance int
+}
+
+// MergeFiles compares the changes of the two sides of a merge to their merge base, and returns the files,
+// by path, that both sides changed differently, with the lines of the merge base both changed. The hunks
+// the two sides share, like cherry-picked changes, merge cleanly and are left out. Renames are not
+// detected, a renamed file being deleted.
+func MergeFiles(base, ours, theirs *object.Tree) ([]MergeFile, error) {
+	oursChanges, err := mergeSideChanges(base, ours)
+	if err != nil {
+		return nil, err
+	}
+	theirsChanges, err := mergeSideChanges(base, theirs)
+	if err != nil {
+		return nil, err
+	}
+	var files []MergeFile
+	for path, our := range oursChanges {
+		their, ok := theirsChanges[path]
+		if !ok {
+			continue
+		}
+		from, ourTo, err := our.Files()
+		if err != nil {
+			return nil, err
+		}
+		_, theirTo, err := their.Files()
+		if err != nil {
+			return nil, err
+		}
+		if ourTo == nil && theirTo == nil || ourTo != nil && theirTo != nil && ourTo.Hash == theirTo.Hash {
+			// Deleted or changed the same way on both sides
+			continue
+		}
+		file := MergeFile{File: path}
+		if binary, err := isBinary(from, ourTo, theirTo); err != nil {
+			return nil, err
+		} else if ourTo == nil || theirTo == nil {
+			file.Conflict = ConflictModifyDelete
+		} else if binary {
+			file.Conflict = ConflictBinary
+		} else {
+			if compared, err := compareHunks(&file, from, ourTo, theirTo); err != nil {
+				return nil, err
+			} else if !compared {
+				continue
+			}
+			if file.Conflict != "" && from == nil {
+				file.Conflict = ConflictAddAdd
+			}
+		}
+		files = append(files, file)
+	}
+	sort.Slice(files, func(i, j int) bool { return files[i].File < files[j].File })
+	return files, nil
+}
+
+// mergeSideChanges returns the changes of a side of a merge by the path of the file in the merge base, or
+// by its new path when added
+func mergeSideChanges(base, side *object.Tree) (map[string]*object.Change, error) {
+	changes, err := diffTree(base, side)
+	if err != nil {
+		return nil, err
+	}
+	byPath := make(map[string]*object.Change, len(changes))
+	for _, change := range changes {
+		if change.From.Name != "" {
+			byPath[change.From.Name] = change
+		} else {
+			byPath[change.To.Name] = change
+		}
+	}
+	return byPath, nil
+}
+
+// compareHunks finds the lines of the merge base the two sides both changed, a nil `from` file standing
+// for a file added on both sides. Returns false when the changes of a side are all made by the other too.
+func compareHunks(file *MergeFile, from, ours, theirs *object.File) (bool, error) {
+	var fromText string
+	var err error
+	if from != nil {
+		if fromText, err = fileText(from); err != nil {
+			return false, err
+		}
+	}
+	oursText, err := fileText(ours)
+	if err != nil {
+		return false, err
+	}
+	theirsText, err := fileText(theirs)
+	if err != nil {
+		return false, err
+	}
+	oursHunks, theirsHunks := diffHunks(file.File, fromText, oursText), diffHunks(file.File, fromText, theirsText)
+	// The hunks replacing the same lines by the same lines, wherever they end up on each side
+	key := func(hunk Hunk) Hunk {
+		hunk.NewStart = 0
+		return hunk
+	}
+	shared := make(map[Hunk]bool, len(oursHunks))
+	for _, hunk := range oursHunks {
+		shared[key(hunk)] = false
+	}
+	for _, hunk := range theirsHunks {
+		if _, ok := shared[key(hunk)]; ok {
+			shared[key(hunk)] = true
+		}
+	}
+
+	file.Distance = -1
+	var ranges []LineRange
+	for _, our := range oursHunks {
+		if shared[key(our)] {
+			continue
+		}
+		for _, their := range theirsHunks {
+			if shared[key(their)] {
+				continue
+			}
+			a, b := hunkRange(our), hunkRange(their)
+			if distance := a.distance(b); file.Distance < 0 || distance < file.Distance {
+				file.Distance = distance
+			}
+			if a.touches(b) {
+				ranges = append(ranges, a, b)
+			}
+		}
+	}
+	if file.Distance < 0 {
+		// One side only made changes the other made too
+		return false, nil
+	}
+	file.Ranges = mergeRanges(ranges)
+	if len(file.Ranges) > 0 {
+		file.Conflict = ConflictContent
+	}
+	return true, nil
+}
+
+// hunkRange returns the lines of the old version of the file the hunk replaces
+func hunkRange(hunk Hunk) LineRange {
+	if hunk.Deleted == 0 {
+		return LineRange{Start: hunk.OldStart + 1, End: hunk.OldStart}
+	}
+	return LineRange{Start: hunk.OldStart, End: hunk.OldStart + hunk.Deleted - 1}
+}
+
+// mergeRanges merges the overlapping and adjacent runs, sorted by line
+func mergeRanges(ranges []LineRange) []LineRange {
+	sort.Slice(ranges, func(i, j int) bool { return ranges[i].Start < ranges[j].Start })
+	var merged []LineRange
+	for _, r := range ranges {
+		if last := len(merged) - 1; last >= 0 && r.Start <= merged[last].End+1 {
+			if r.End > merged[last].End {
+				merged[last].End = r.End
+			}
+			continue
+		}
+		merged = append(merged, r)
+	}
+	return merged
+}
@@ -0,0 +1,68 @@
+package gitfuncs
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/andymeneely/git-churn/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+// numberedLines returns the lines 1 to n, each one replaced by its replacement when given
+func numberedLines(n int, replaced map[int]string) string {
+	var lines strings.Builder
+	for i := 1; i <= n; i++ {
+		if line, ok := replaced[i]; ok {
+			lines.WriteString(line + "\n")
+		} else {
+			fmt.Fprintf(&lines, "%d\n", i)
+		}
+	}
+	return lines.String()
+}
+
+func TestMergeFiles(t *testing.T) {
+	assert := assert.New(t)
+	repo := testutil.NewRepo(t)
+	base := repo.CommitFiles("base", map[string]string{
+		"conflict.txt": numberedLines(20, nil),
+		"far.txt":      numberedLines(20, nil),
+		"deleted.txt":  numberedLines(3, nil),
+		"same.txt":     numberedLines(3, nil),
+		"picked.txt":   numberedLines(20, nil),
+	})
+	repo.Branch("ours").CommitFiles("ours", map[string]string{
+		"conflict.txt": numberedLines(20, map[int]string{5: "ours", 15: "ours"}),
+		"far.txt":      numberedLines(20, map[int]string{1: "ours"}),
+		"same.txt":     numberedLines(3, map[int]string{2: "both"}),
+		"picked.txt":   numberedLines(20, map[int]string{2: "both"}),
+		"added.txt":    "ours\n",
+	})
+	repo.Delete("deleted.txt").Commit("delete")
+	ours := repo.Head()
+	repo.Checkout("master").Branch("theirs").CommitFiles("theirs", map[string]string{
+		"conflict.txt": numberedLines(20, map[int]string{6: "theirs", 10: "theirs"}),
+		"far.txt":      numberedLines(20, map[int]string{10: "theirs"}),
+		"deleted.txt":  numberedLines(3, map[int]string{1: "theirs"}),
+		"same.txt":     numberedLines(3, map[int]string{2: "both"}),
+		"picked.txt":   numberedLines(20, map[int]string{2: "both", 10: "theirs"}),
+		"added.txt":    "theirs\n",
+	})
+	theirs := repo.Head()
+
+	baseTree, err := base.Tree()
+	assert.Nil(err)
+	oursTree, err := ours.Tree()
+	assert.Nil(err)
+	theirsTree, err := theirs.Tree()
+	assert.Nil(err)
+	files, err := MergeFiles(baseTree, oursTree, theirsTree)
+	assert.Nil(err)
+	assert.Equal([]MergeFile{
+		{File: "added.txt", Conflict: ConflictAddAdd, Ranges: []LineRange{{Start: 1, End: 0}}},
+		{File: "conflict.txt", Conflict: ConflictContent, Ranges: []LineRange{{Start: 5, End: 6}}},
+		{File: "deleted.txt", Conflict: ConflictModifyDelete},
+		{File: "far.txt", Distance: 8},
+	}, files)
+}
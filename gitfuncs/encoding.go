@@ -0,0 +1,143 @@
+package gitfuncs
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"io/ioutil"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+
+	"gopkg.in/src-d/go-git.v4/plumbing/object"
+)
+
+// IgnoreEOL makes the lines that only changed their line ending, e.g. from CRLF to LF, no change
+var IgnoreEOL bool
+
+var (
+	utf8BOM    = []byte{0xEF, 0xBB, 0xBF}
+	utf16LEBOM = []byte{0xFF, 0xFE}
+	utf16BEBOM = []byte{0xFE, 0xFF}
+)
+
+// decodeText returns the text read from r in UTF-8, without its byte order mark. UTF-16 is told by its
+// byte order mark and decoded, any other content is read as is.
+func decodeText(r io.Reader) io.Reader {
+	buffered := bufio.NewReader(r)
+	start, _ := buffered.Peek(len(utf8BOM))
+	switch {
+	case bytes.HasPrefix(start, utf8BOM):
+		buffered.Discard(len(utf8BOM))
+	case bytes.HasPrefix(start, utf16LEBOM):
+		buffered.Discard(len(utf16LEBOM))
+		return &utf16Reader{r: buffered, littleEndian: true}
+	case bytes.HasPrefix(start, utf16BEBOM):
+		buffered.Discard(len(utf16BEBOM))
+		return &utf16Reader{r: buffered}
+	}
+	return buffered
+}
+
+// utf16Reader decodes UTF-16 into UTF-8 as it is read
+type utf16Reader struct {
+	r            *bufio.Reader
+	littleEndian bool
+	// Decoded bytes not read yet
+	pending []byte
+	// Leading surrogate waiting for its trailing one
+	high uint16
+	err  error
+}
+
+func (u *utf16Reader) Read(p []byte) (int, error) {
+	for len(u.pending) == 0 && u.err == nil {
+		u.decode()
+	}
+	if len(u.pending) == 0 {
+		return 0, u.err
+	}
+	n := copy(p, u.pending)
+	u.pending = u.pending[n:]
+	return n, nil
+}
+
+// decode decodes the next code unit
+func (u *utf16Reader) decode() {
+	var unit [2]byte
+	if _, err := io.ReadFull(u.r, unit[:]); err != nil {
+		if err == io.ErrUnexpectedEOF {
+			// An odd byte out ends the text
+			err = io.EOF
+		}
+		if u.high != 0 {
+			u.appendRune(utf8.RuneError)
+		}
+		u.err = err
+		return
+	}
+	code := uint16(unit[0])<<8 | uint16(unit[1])
+	if u.littleEndian {
+		code = uint16(unit[1])<<8 | uint16(unit[0])
+	}
+	switch {
+	case u.high != 0 && utf16.IsSurrogate(rune(code)) && code >= 0xDC00:
+		u.appendRune(utf16.DecodeRune(rune(u.high), rune(code)))
+		u.high = 0
+	case code >= 0xD800 && code < 0xDC00:
+		if u.high != 0 {
+			u.appendRune(utf8.RuneError)
+		}
+		u.high = code
+	default:
+		if u.high != 0 {
+			u.appendRune(utf8.RuneError)
+			u.high = 0
+		}
+		u.appendRune(rune(code))
+	}
+}
+
+func (u *utf16Reader) appendRune(r rune) {
+	var buf [utf8.UTFMax]byte
+	n := utf8.EncodeRune(buf[:], r)
+	u.pending = append(u.pending, buf[:n]...)
+}
+
+// fileText returns the content of the text file decoded like decodeText, with CRLF line endings turned
+// into LF when IgnoreEOL is set
+func fileText(f *object.File) (string, error) {
+	reader, err := f.Reader()
+	if err != nil {
+		return "", err
+	}
+	defer reader.Close()
+	content, err := ioutil.ReadAll(decodeText(reader))
+	if err != nil {
+		return "", err
+	}
+	if IgnoreEOL {
+		return strings.Replace(string(content), "\r\n", "\n", -1), nil
+	}
+	return string(content), nil
+}
+
+// isTextBinary tells whether the file is binary, that is neither UTF-16 with a byte order mark nor free
+// of NUL bytes in its first 8000 bytes
+func isTextBinary(f *object.File) (bool, error) {
+	reader, err := f.Reader()
+	if err != nil {
+		return false, err
+	}
+	defer reader.Close()
+	start := make([]byte, sniffLen)
+	n, err := io.ReadFull(reader, start)
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		return false, err
+	}
+	start = start[:n]
+	if bytes.HasPrefix(start, utf16LEBOM) || bytes.HasPrefix(start, utf16BEBOM) {
+		return false, nil
+	}
+	return bytes.IndexByte(start, 0) >= 0, nil
+}
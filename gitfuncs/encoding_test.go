@@ -0,0 +1,76 @@
+package gitfuncs
+
+import (
+	"io/ioutil"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDecodeText(t *testing.T) {
+	assert := assert.New(t)
+	cases := map[string]string{
+		"a\nb":                                "a\nb",
+		"\xEF\xBB\xBFa\n":                     "a\n",
+		"\xFF\xFEa\x00\xE9\x00\n\x00":         "aé\n",
+		"\xFE\xFF\x00a\xD8\x3D\xDE\x00\x00\n": "a\U0001F600\n",
+		// Unpaired surrogates and an odd byte out
+		"\xFF\xFE=\xD8a\x00b": "�a",
+	}
+	for content, expected := range cases {
+		decoded, err := ioutil.ReadAll(decodeText(strings.NewReader(content)))
+		assert.Nil(err)
+		assert.Equal(expected, string(decoded), "%q", content)
+	}
+}
+
+func TestUTF16ChurnPatch(t *testing.T) {
+	assert := assert.New(t)
+	utf16 := func(text string) string {
+		encoded := "\xFF\xFE"
+		for _, r := range text {
+			encoded += string([]byte{byte(r), 0})
+		}
+		return encoded
+	}
+	_, commits := commitContents(t, []string{"alice", "bob"}, utf16("a\nb\nc\n"), utf16("a\nB\nc\nd\n"))
+
+	patch, err := CommitChurnPatch(commits[1])
+	assert.Nil(err)
+	file := patch.File("a.txt")
+	assert.False(file.Binary)
+	assert.Equal([]int{2, 4}, file.LineNumbers(LineAdded, true))
+	assert.Equal([]int{2}, file.LineNumbers(LineDeleted, true))
+	assert.Equal("B", file.Lines(LineAdded, true)[0].Text)
+
+	stats, err := CommitStats(commits[1])
+	assert.Nil(err)
+	assert.Equal(2, stats[0].Addition)
+	assert.Equal(1, stats[0].Deletion)
+}
+
+func TestIgnoreEOL(t *testing.T) {
+	assert := assert.New(t)
+	repo, commits := commitContents(t, []string{"alice", "bob"}, "a\r\nb\r\n\r\nc\r\n", "a\nb\n\nC\n")
+	defer func() { IgnoreEOL = false }()
+
+	patch, err := CommitChurnPatch(commits[1])
+	assert.Nil(err)
+	assert.Equal(4, patch.Count(LineAdded, true))
+	// The blank line ending with CRLF is blank too
+	assert.Equal(3, patch.Count(LineDeleted, false))
+
+	IgnoreEOL = true
+	patch, err = CommitChurnPatch(commits[1])
+	assert.Nil(err)
+	assert.Equal([]int{4}, patch.File("a.txt").LineNumbers(LineAdded, true))
+	assert.Equal([]int{4}, patch.File("a.txt").LineNumbers(LineDeleted, true))
+	stats, err := CommitStats(commits[1])
+	assert.Nil(err)
+	assert.Equal(1, stats[0].Addition)
+	assert.Equal(1, stats[0].Deletion)
+	hunks, err := CommitHunks(repo, commits[1])
+	assert.Nil(err)
+	assert.Equal(1, len(hunks))
+}
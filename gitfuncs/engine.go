@@ -0,0 +1,207 @@
+package gitfuncs
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"gopkg.in/src-d/go-git.v4"
+	"gopkg.in/src-d/go-git.v4/plumbing"
+	"gopkg.in/src-d/go-git.v4/plumbing/object"
+)
+
+// Engine computes the line level data of the history the churn metrics are made of. The commits
+// themselves are always walked with go-git.
+type Engine interface {
+	// CommitStats returns the lines added and deleted per file by the commit against its first parent
+	CommitStats(repo *git.Repository, commit *object.Commit) (object.FileStats, error)
+	// Blame blames the file at the given commit with BlameOpts
+	Blame(repo *git.Repository, commit *object.Commit, path string) (*git.BlameResult, error)
+}
+
+// GoGitEngine computes everything in process with go-git
+type GoGitEngine struct{}
+
+// CLIEngine shells out to the git command line, for parity with git itself where go-git falls short,
+// e.g. on blame performance. Repositories cloned in memory are cloned again on disk on first use.
+type CLIEngine struct{}
+
+// Engines by name, as selected on the command line
+var Engines = map[string]Engine{
+	"go-git": GoGitEngine{},
+	"cli":    CLIEngine{},
+}
+
+// ActiveEngine is the engine the metrics use
+var ActiveEngine Engine = GoGitEngine{}
+
+// EngineByName returns the engine of the given name
+func EngineByName(name string) (Engine, error) {
+	engine, ok := Engines[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown engine %q, expected go-git or cli", name)
+	}
+	return engine, nil
+}
+
+func (GoGitEngine) CommitStats(repo *git.Repository, commit *object.Commit) (object.FileStats, error) {
+	return CommitStats(commit)
+}
+
+func (GoGitEngine) Blame(repo *git.Repository, commit *object.Commit, path string) (*git.BlameResult, error) {
+	return BlameCommit(repo, commit, path)
+}
+
+// StatsBatcher is implemented by the engines computing the stats of a whole range of commits at once
+// faster than commit by commit
+type StatsBatcher interface {
+	// BatchStats computes the stats of the commits reachable from `to` but not from `from`, committed
+	// after `since`, for CommitStats to return them. A zero `from` or `since` does not bound the range.
+	BatchStats(repo *git.Repository, from, to plumbing.Hash, since time.Time) error
+}
+
+// BatchStats has the ActiveEngine compute the stats of the commits of the range at once, when it is a
+// StatsBatcher
+func BatchStats(repo *git.Repository, from, to plumbing.Hash, since time.Time) error {
+	if batcher, ok := ActiveEngine.(StatsBatcher); ok {
+		return batcher.BatchStats(repo, from, to, since)
+	}
+	return nil
+}
+
+// Stats of the commits of the last range batched by the CLIEngine, by repository directory, taken out
+// as CommitStats returns them
+var cliStats = struct {
+	sync.Mutex
+	stats map[string]map[plumbing.Hash]object.FileStats
+}{stats: make(map[string]map[plumbing.Hash]object.FileStats)}
+
+// BatchStats runs a single git log --numstat over the range. The merges are left out for CommitStats
+// to count one by one: git log -m gives no diff against the first parent of a merge that leaves it
+// unchanged, but the one against the next parent.
+func (CLIEngine) BatchStats(repo *git.Repository, from, to plumbing.Hash, since time.Time) error {
+	if DetectDirMoves {
+		return nil
+	}
+	dir, err := repositoryDir(repo)
+	if err != nil {
+		return err
+	}
+	revisions := []string{"--no-merges", to.String()}
+	if !from.IsZero() {
+		revisions = append(revisions, "^"+from.String())
+	}
+	if !since.IsZero() {
+		revisions = append(revisions, fmt.Sprintf("--since=%d", since.Unix()))
+	}
+	// The whole trees are diffed, git leaving out the commits not changing PathScope otherwise
+	stats, err := logNumstat(dir, revisions, false)
+	if err != nil {
+		return err
+	}
+	cliStats.Lock()
+	cliStats.stats[dir] = stats
+	cliStats.Unlock()
+	return nil
+}
+
+// forgetStats drops the stats batched on the repository directory
+func forgetStats(dir string) {
+	cliStats.Lock()
+	delete(cliStats.stats, dir)
+	cliStats.Unlock()
+}
+
+// CommitStats counts the lines with git log --numstat, unless they were batched. git pairs no moved
+// directories, so the commits are counted in process when DetectDirMoves is set.
+func (CLIEngine) CommitStats(repo *git.Repository, commit *object.Commit) (object.FileStats, error) {
+	if DetectDirMoves {
+		return CommitStats(commit)
+	}
+	dir, err := repositoryDir(repo)
+	if err != nil {
+		return nil, err
+	}
+	cliStats.Lock()
+	stats, ok := cliStats.stats[dir][commit.Hash]
+	delete(cliStats.stats[dir], commit.Hash)
+	cliStats.Unlock()
+	if ok {
+		return stats, nil
+	}
+	// -m with --first-parent diffs merges against their first parent, like go-git's Stats
+	commits, err := logNumstat(dir, []string{"-1", "-m", "--first-parent", commit.Hash.String()}, true)
+	if err != nil {
+		return nil, err
+	}
+	return commits[commit.Hash], nil
+}
+
+// logNumstat runs git log --numstat -z on the revisions and returns the stats of every commit logged, the
+// files out of PathScope left out. git is given PathScope when scoped.
+func logNumstat(dir string, revisions []string, scoped bool) (map[plumbing.Hash]object.FileStats, error) {
+	args := []string{"log", "--numstat", "-z", "--format=commit %H", "--no-renames", "--root"}
+	if IgnoreEOL {
+		args = append(args, "--ignore-cr-at-eol")
+	}
+	if IgnoreAllSpace {
+		args = append(args, "--ignore-all-space")
+	}
+	args = append(append(args, revisions...), "--")
+	if scoped && PathScope != "" {
+		args = append(args, PathScope)
+	}
+	out, err := runGit(dir, args...)
+	if err != nil {
+		return nil, err
+	}
+	return parseLogNumstat(out)
+}
+
+func (CLIEngine) Blame(repo *git.Repository, commit *object.Commit, path string) (*git.BlameResult, error) {
+	if _, err := commit.File(path); err != nil {
+		return nil, err
+	}
+	return cliBlame(repo, commit.Hash, path, BlameOpts)
+}
+
+// parseLogNumstat parses the output of git log --numstat -z --format="commit %H": NUL terminated records,
+// a header per commit followed by "added<TAB>deleted<TAB>path" per file, the first one after a newline.
+// Binary files, given as "-", count no lines.
+func parseLogNumstat(out []byte) (map[plumbing.Hash]object.FileStats, error) {
+	commits := make(map[plumbing.Hash]object.FileStats)
+	var current plumbing.Hash
+	for _, record := range strings.Split(string(out), "\x00") {
+		record = strings.TrimPrefix(record, "\n")
+		if record == "" {
+			continue
+		}
+		if strings.HasPrefix(record, "commit ") {
+			current = plumbing.NewHash(record[len("commit "):])
+			commits[current] = object.FileStats{}
+			continue
+		}
+		fields := strings.SplitN(record, "\t", 3)
+		if current.IsZero() || len(fields) != 3 {
+			return nil, fmt.Errorf("invalid numstat record %q", record)
+		}
+		stat := object.FileStat{Name: fields[2]}
+		if fields[0] != "-" {
+			added, err := strconv.Atoi(fields[0])
+			if err != nil {
+				return nil, fmt.Errorf("invalid numstat record %q", record)
+			}
+			deleted, err := strconv.Atoi(fields[1])
+			if err != nil {
+				return nil, fmt.Errorf("invalid numstat record %q", record)
+			}
+			stat.Addition, stat.Deletion = added, deleted
+		}
+		if InScope(stat.Name) {
+			commits[current] = append(commits[current], stat)
+		}
+	}
+	return commits, nil
+}
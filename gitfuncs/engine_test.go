@@ -0,0 +1,108 @@
+package gitfuncs
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/andymeneely/git-churn/testutil"
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/src-d/go-git.v4"
+	"gopkg.in/src-d/go-git.v4/config"
+	"gopkg.in/src-d/go-git.v4/plumbing"
+	"gopkg.in/src-d/go-git.v4/plumbing/object"
+)
+
+func TestParseLogNumstat(t *testing.T) {
+	assert := assert.New(t)
+	root, empty, last := plumbing.NewHash("01"), plumbing.NewHash("02"), plumbing.NewHash("03")
+	out := "commit " + last.String() + "\x00\n1\t0\ttab\tand\nnewline.go\x00commit " + empty.String() + "\x00commit " +
+		root.String() + "\x00\n3\t1\tmain.go\x00-\t-\tlogo.png\x00"
+	commits, err := parseLogNumstat([]byte(out))
+	assert.Nil(err)
+	assert.Equal(map[plumbing.Hash]object.FileStats{
+		last:  {{Name: "tab\tand\nnewline.go", Addition: 1}},
+		empty: {},
+		root:  {{Name: "main.go", Addition: 3, Deletion: 1}, {Name: "logo.png"}},
+	}, commits)
+
+	_, err = parseLogNumstat([]byte("commit " + root.String() + "\x00\nx\t1\tmain.go\x00"))
+	assert.NotNil(err)
+	_, err = parseLogNumstat([]byte("3\t1\tmain.go\x00"))
+	assert.NotNil(err)
+}
+
+func TestCLIEngineCommitStats(t *testing.T) {
+	assert := assert.New(t)
+	repo, commit, remove := diskRepo(t, "1\n2\n", "1\n3\n4\n")
+	defer remove()
+
+	expected, err := GoGitEngine{}.CommitStats(repo, commit)
+	assert.Nil(err)
+	stats, err := CLIEngine{}.CommitStats(repo, commit)
+	assert.Nil(err)
+	assert.Equal(expected, stats)
+
+	parent, err := commit.Parent(0)
+	assert.Nil(err)
+	stats, err = CLIEngine{}.CommitStats(repo, parent)
+	assert.Nil(err)
+	assert.Equal(object.FileStats{{Name: "a.txt", Addition: 2}}, stats)
+}
+
+func TestCLIEngineBatchStats(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	assert := assert.New(t)
+	repo := testutil.NewDiskRepo(t)
+	defer repo.Remove()
+	root := repo.CommitFiles("root", map[string]string{"a.txt": "1\n", "sp ace.txt": "x\n"})
+	repo.Branch("feature").CommitFiles("feature", map[string]string{"a.txt": "1\n2\n"})
+	repo.Checkout("master").CommitFiles("master", map[string]string{"sp ace.txt": "y\nz\n"})
+	merge := repo.Merge("feature", "merge")
+	last := repo.CommitFiles("last", map[string]string{"a.txt": "2\n"})
+
+	assert.Nil(CLIEngine{}.BatchStats(repo.Repository, root.Hash, last.Hash, time.Time{}))
+	dir, err := repositoryDir(repo.Repository)
+	assert.Nil(err)
+	// The merge and the start of the range are left out
+	assert.Len(cliStats.stats[dir], 3)
+	_, ok := cliStats.stats[dir][merge.Hash]
+	assert.False(ok)
+
+	commits, err := CommitsBetween(repo.Repository, plumbing.ZeroHash, last.Hash)
+	assert.Nil(err)
+	for _, commit := range commits {
+		expected, err := GoGitEngine{}.CommitStats(repo.Repository, commit)
+		assert.Nil(err)
+		stats, err := CLIEngine{}.CommitStats(repo.Repository, commit)
+		assert.Nil(err)
+		assert.Equal(expected, stats, commit.Message)
+	}
+	assert.Empty(cliStats.stats[dir])
+}
+
+func TestCLICloneOptionURL(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	assert := assert.New(t)
+	repo := testutil.NewRepo(t)
+	repo.CommitFiles("first", map[string]string{"a.txt": "1\n"})
+	marker := filepath.Join(os.TempDir(), fmt.Sprintf("git-churn-injected-%d", time.Now().UnixNano()))
+	url := "--upload-pack=touch " + marker
+	_, err := repo.CreateRemote(&config.RemoteConfig{Name: git.DefaultRemoteName, URLs: []string{url}})
+	assert.Nil(err)
+
+	// The URL is cloned as a repository, not run as an option of git clone
+	_, err = repositoryDir(repo.Repository)
+	if assert.NotNil(err) {
+		assert.Contains(err.Error(), "repository '"+url+"' does not exist")
+	}
+	_, err = os.Stat(marker)
+	assert.True(os.IsNotExist(err))
+}
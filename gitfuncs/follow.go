@@ -0,0 +1,271 @@
+package gitfuncs
+
+import (
+	"sort"
+
+	"gopkg.in/src-d/go-git.v4"
+	"gopkg.in/src-d/go-git.v4/plumbing"
+	"gopkg.in/src-d/go-git.v4/plumbing/filemode"
+	"gopkg.in/src-d/go-git.v4/plumbing/object"
+	"gopkg.in/src-d/go-git.v4/utils/merkletrie"
+)
+
+// DefaultRenameSimilarity is the share of its lines a file deleted by a commit must have in common with a
+// file the commit added for the change to be a rename, like the 50% similarity index of git
+const DefaultRenameSimilarity = 0.5
+
+// FileRevision is a commit changing a followed file, from the file before the commit, with no From entry
+// when the commit added it, to the file after the commit
+type FileRevision struct {
+	Commit *object.Commit
+	Change *object.Change
+	// Share of the lines of the file in common with the file it was renamed from, 1 when not renamed
+	Similarity float64
+}
+
+// Path returns the path of the file after the commit
+func (r *FileRevision) Path() string {
+	return r.Change.To.Name
+}
+
+// Renamed tells whether the commit renamed the file, from Change.From.Name
+func (r *FileRevision) Renamed() bool {
+	return r.Change.From.Name != "" && r.Change.From.Name != r.Change.To.Name
+}
+
+// FollowFile returns the commits changing the file at the path in the revision, newest first, going back
+// its history like git log --follow across the commits that renamed it. Like git log, a commit leaving the
+// file the same as one of its parents (TREESAME) only has that parent followed, so that the changes made on
+// a merged branch are credited to the commits of the branch rather than to the merge. The merges changing
+// the file against every parent are followed through all of them and are not reported, their changes being
+// those of the branches they merged. Only the first parents are followed when FirstParent is set. A file
+// added by a commit deleting a file of the same content, or having at least `similarity` of its lines in
+// common, is taken as renamed from the deleted file most alike. The history ends with the commit that added
+// the file. It returns object.ErrFileNotFound when the revision has no file at the path. Renames are
+// detected over the whole trees, whatever PathScope.
+func FollowFile(repo *git.Repository, revision, path string, similarity float64) ([]*FileRevision, error) {
+	hash, err := ResolveRef(repo, revision)
+	if err != nil {
+		return nil, err
+	}
+	commit, err := repo.CommitObject(*hash)
+	if err != nil {
+		return nil, err
+	}
+	tree, err := commit.Tree()
+	if err != nil {
+		return nil, err
+	}
+	entry, err := fileEntry(tree, path)
+	if err != nil {
+		return nil, err
+	}
+	if entry == nil {
+		return nil, object.ErrFileNotFound
+	}
+
+	var revisions []*FileRevision
+	queue := []*followedFile{{commit, tree, path, entry}}
+	seen := make(map[followedKey]bool)
+	for len(queue) > 0 {
+		file := queue[0]
+		queue = queue[1:]
+		key := followedKey{file.commit.Hash, file.path}
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+
+		parents, err := file.parents()
+		if err != nil {
+			return nil, err
+		}
+		treesame := -1
+		for i, parent := range parents {
+			if parent.entry != nil && parent.entry.Hash == file.entry.Hash && parent.entry.Mode == file.entry.Mode {
+				treesame = i
+				break
+			}
+		}
+		switch {
+		case treesame >= 0:
+			queue = append(queue, parents[treesame])
+		case len(parents) == 1 && parents[0].entry != nil:
+			revisions = append(revisions, &FileRevision{Commit: file.commit, Change: file.change(parents[0]), Similarity: 1})
+			queue = append(queue, parents[0])
+		default:
+			// Added or renamed by the commit, or changed by every branch it merged
+			added := true
+			for _, parent := range parents {
+				if parent.entry == nil {
+					from, score, err := renamedFrom(parent.tree, file.tree, file.changeEntry(), similarity)
+					if err != nil {
+						return nil, err
+					}
+					if from == nil {
+						continue
+					}
+					if len(parents) == 1 {
+						revisions = append(revisions, &FileRevision{Commit: file.commit, Change: &object.Change{From: *from, To: file.changeEntry()}, Similarity: score})
+					}
+					parent.path, parent.entry = from.Name, &from.TreeEntry
+				}
+				added = false
+				queue = append(queue, parent)
+			}
+			if added {
+				revisions = append(revisions, &FileRevision{Commit: file.commit, Change: &object.Change{To: file.changeEntry()}, Similarity: 1})
+			}
+		}
+	}
+	sort.SliceStable(revisions, func(i, j int) bool {
+		return revisions[i].Commit.Committer.When.After(revisions[j].Commit.Committer.When)
+	})
+	return revisions, nil
+}
+
+// followedFile is the file followed at a commit, under the path it had then
+type followedFile struct {
+	commit *object.Commit
+	tree   *object.Tree
+	path   string
+	// Nil when the commit has no file at the path
+	entry *object.TreeEntry
+}
+
+type followedKey struct {
+	commit plumbing.Hash
+	path   string
+}
+
+// parents returns the file at the same path in the parents of the commit, the first one only when
+// FirstParent is set
+func (f *followedFile) parents() ([]*followedFile, error) {
+	var parents []*followedFile
+	for i := range f.commit.ParentHashes {
+		if FirstParent && i > 0 {
+			break
+		}
+		commit, err := f.commit.Parent(i)
+		if err != nil {
+			return nil, err
+		}
+		tree, err := commit.Tree()
+		if err != nil {
+			return nil, err
+		}
+		entry, err := fileEntry(tree, f.path)
+		if err != nil {
+			return nil, err
+		}
+		parents = append(parents, &followedFile{commit, tree, f.path, entry})
+	}
+	return parents, nil
+}
+
+func (f *followedFile) changeEntry() object.ChangeEntry {
+	return object.ChangeEntry{Name: f.path, Tree: f.tree, TreeEntry: *f.entry}
+}
+
+// change returns the change of the file from the parent to the commit
+func (f *followedFile) change(parent *followedFile) *object.Change {
+	return &object.Change{From: parent.changeEntry(), To: f.changeEntry()}
+}
+
+// fileEntry returns the entry of the file at the path in the tree, nil when there is none, the path being
+// missing, a directory or a submodule
+func fileEntry(tree *object.Tree, path string) (*object.TreeEntry, error) {
+	entry, err := tree.FindEntry(path)
+	if err == object.ErrEntryNotFound || err == object.ErrDirectoryNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if entry.Mode == filemode.Dir || entry.Mode == filemode.Submodule {
+		return nil, nil
+	}
+	return entry, nil
+}
+
+// renamedFrom returns the file deleted between the trees the added file was renamed from, and the share of
+// their lines in common, or nil when the added file is new
+func renamedFrom(from, to *object.Tree, added object.ChangeEntry, similarity float64) (*object.ChangeEntry, float64, error) {
+	changes, err := object.DiffTree(from, to)
+	if err != nil {
+		return nil, 0, err
+	}
+	var deleted []object.ChangeEntry
+	for _, change := range changes {
+		action, err := change.Action()
+		if err != nil {
+			return nil, 0, err
+		}
+		if action != merkletrie.Delete || change.From.TreeEntry.Mode == filemode.Submodule {
+			continue
+		}
+		if change.From.TreeEntry.Hash == added.TreeEntry.Hash {
+			return &change.From, 1, nil
+		}
+		deleted = append(deleted, change.From)
+	}
+	if len(deleted) == 0 {
+		return nil, 0, nil
+	}
+	addedLines, err := entryLines(added)
+	if err != nil {
+		return nil, 0, err
+	}
+	var best *object.ChangeEntry
+	bestScore := 0.0
+	for i := range deleted {
+		lines, err := entryLines(deleted[i])
+		if err != nil {
+			return nil, 0, err
+		}
+		score := lineSimilarity(lines, addedLines)
+		if score >= similarity && score > bestScore {
+			best, bestScore = &deleted[i], score
+		}
+	}
+	return best, bestScore, nil
+}
+
+// entryLines returns the lines of the file of the change entry, none for a binary file
+func entryLines(entry object.ChangeEntry) ([]string, error) {
+	f, err := entry.Tree.TreeEntryFile(&entry.TreeEntry)
+	if err != nil {
+		return nil, err
+	}
+	if binary, err := isBinary(f); err != nil || binary {
+		return nil, err
+	}
+	text, err := fileText(f)
+	if err != nil {
+		return nil, err
+	}
+	return textLines(text), nil
+}
+
+// lineSimilarity returns the share of the lines of the larger of the two files that the other one has too
+func lineSimilarity(a, b []string) float64 {
+	larger := len(a)
+	if len(b) > larger {
+		larger = len(b)
+	}
+	if larger == 0 {
+		return 0
+	}
+	counts := make(map[string]int, len(a))
+	for _, line := range a {
+		counts[line] += 1
+	}
+	common := 0
+	for _, line := range b {
+		if counts[line] > 0 {
+			counts[line] -= 1
+			common += 1
+		}
+	}
+	return float64(common) / float64(larger)
+}
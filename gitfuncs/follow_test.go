@@ -0,0 +1,90 @@
+package gitfuncs
+
+import (
+	"testing"
+
+	"github.com/andymeneely/git-churn/testutil"
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/src-d/go-git.v4/plumbing/object"
+)
+
+func TestFollowFile(t *testing.T) {
+	assert := assert.New(t)
+	repo := testutil.NewRepo(t)
+	root := repo.CommitFiles("root", map[string]string{"a.go": "1\n2\n3\n4\n", "other.go": "x\n"})
+	change := repo.CommitFiles("change", map[string]string{"a.go": "1\n2\n3\n4\n5\n"})
+	move := repo.Rename("a.go", "pkg/b.go").Commit("move")
+	edit := repo.Rename("pkg/b.go", "pkg/c.go").WriteFiles(map[string]string{"pkg/c.go": "1\n2\n3\n4\nfive\n"}).Commit("rename and edit")
+	repo.CommitFiles("unrelated", map[string]string{"other.go": "y\n"})
+	last := repo.CommitFiles("last", map[string]string{"pkg/c.go": "1\n2\n3\n4\nfive\n6\n"})
+
+	revisions, err := FollowFile(repo.Repository, "HEAD", "pkg/c.go", DefaultRenameSimilarity)
+	assert.Nil(err)
+	var hashes []string
+	var paths []string
+	var renamedFrom []string
+	for _, revision := range revisions {
+		hashes = append(hashes, revision.Commit.Hash.String())
+		paths = append(paths, revision.Path())
+		if revision.Renamed() {
+			renamedFrom = append(renamedFrom, revision.Change.From.Name)
+		}
+	}
+	assert.Equal([]string{last.Hash.String(), edit.Hash.String(), move.Hash.String(), change.Hash.String(), root.Hash.String()}, hashes)
+	assert.Equal([]string{"pkg/c.go", "pkg/c.go", "pkg/b.go", "a.go", "a.go"}, paths)
+	assert.Equal([]string{"pkg/b.go", "a.go"}, renamedFrom)
+	assert.Equal(0.8, revisions[1].Similarity)
+	assert.Equal(1.0, revisions[2].Similarity)
+	assert.Empty(revisions[4].Change.From.Name)
+
+	// Too different to be a rename
+	revisions, err = FollowFile(repo.Repository, "HEAD", "pkg/c.go", 0.9)
+	assert.Nil(err)
+	assert.Len(revisions, 2)
+	assert.False(revisions[1].Renamed())
+
+	_, err = FollowFile(repo.Repository, "HEAD", "a.go", DefaultRenameSimilarity)
+	assert.Equal(object.ErrFileNotFound, err)
+	_, err = FollowFile(repo.Repository, "HEAD", "pkg", DefaultRenameSimilarity)
+	assert.Equal(object.ErrFileNotFound, err)
+}
+
+func TestFollowFileMerge(t *testing.T) {
+	assert := assert.New(t)
+	repo := testutil.NewRepo(t)
+	root := repo.As("alice").CommitFiles("root", map[string]string{"a.go": "1\n2\n3\n4\n", "other.go": "x\n"})
+	change := repo.Branch("feature").As("bob").CommitFiles("change", map[string]string{"a.go": "1\n2\n3\n4\n5\n"})
+	move := repo.Rename("a.go", "b.go").Commit("move")
+	repo.Checkout("master").As("alice").CommitFiles("unrelated", map[string]string{"other.go": "y\n"})
+	merge := repo.Merge("feature", "merge feature")
+
+	hashes := func(revisions []*FileRevision) []string {
+		var hashes []string
+		for _, revision := range revisions {
+			hashes = append(hashes, revision.Commit.Hash.String())
+		}
+		return hashes
+	}
+	// The merge leaves b.go as on the branch, whose commits are credited
+	revisions, err := FollowFile(repo.Repository, "HEAD", "b.go", DefaultRenameSimilarity)
+	assert.Nil(err)
+	assert.Equal([]string{move.Hash.String(), change.Hash.String(), root.Hash.String()}, hashes(revisions))
+	assert.Equal("a.go", revisions[0].Change.From.Name)
+	assert.Equal("bob@example.com", revisions[1].Commit.Author.Email)
+
+	// The merge stands for the branch along the first parents
+	FirstParent = true
+	defer func() { FirstParent = false }()
+	revisions, err = FollowFile(repo.Repository, "HEAD", "b.go", DefaultRenameSimilarity)
+	assert.Nil(err)
+	assert.Equal([]string{merge.Hash.String(), root.Hash.String()}, hashes(revisions))
+	assert.True(revisions[0].Renamed())
+}
+
+func TestLineSimilarity(t *testing.T) {
+	assert := assert.New(t)
+	assert.Equal(1.0, lineSimilarity([]string{"a", "b"}, []string{"b", "a"}))
+	assert.Equal(0.5, lineSimilarity([]string{"a", "a"}, []string{"a", "b"}))
+	assert.Equal(0.25, lineSimilarity([]string{"a"}, []string{"a", "b", "c", "d"}))
+	assert.Equal(0.0, lineSimilarity(nil, nil))
+}
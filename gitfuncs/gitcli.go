@@ -0,0 +1,189 @@
+package gitfuncs
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os/exec"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	. "github.com/andymeneely/git-churn/print"
+	"gopkg.in/src-d/go-git.v4"
+	"gopkg.in/src-d/go-git.v4/plumbing"
+	"gopkg.in/src-d/go-git.v4/storage/filesystem"
+)
+
+// Bare clones made for the git command line, by repository URL
+var cliClones = struct {
+	sync.Mutex
+	dirs map[string]string
+}{dirs: make(map[string]string)}
+
+// repositoryDir returns a directory the git command line can work on for the repository: its own
+// directory when stored on disk, or else a bare clone of its origin remote made on first use
+func repositoryDir(repo *git.Repository) (string, error) {
+	if storage, ok := repo.Storer.(*filesystem.Storage); ok {
+		return storage.Filesystem().Root(), nil
+	}
+	remote, err := repo.Remote(git.DefaultRemoteName)
+	if err != nil {
+		return "", err
+	}
+	url := remote.Config().URLs[0]
+
+	cliClones.Lock()
+	defer cliClones.Unlock()
+	if dir, ok := cliClones.dirs[url]; ok {
+		return dir, nil
+	}
+	dir, err := ioutil.TempDir("", "git-churn-")
+	if err != nil {
+		return "", err
+	}
+	Info("git clone --bare %s %s", url, dir)
+	// The URL is never taken for an option, e.g. --upload-pack running a command
+	if _, err := runGit("", "clone", "--bare", "--quiet", "--", url, dir); err != nil {
+		removeDirs([]string{dir})
+		return "", err
+	}
+	cliClones.dirs[url] = dir
+	return dir, nil
+}
+
+// releaseCLIClone removes the clone of the repository made for the git command line, if any, along with
+// the stats batched on it
+func releaseCLIClone(repo *git.Repository) {
+	if _, ok := repo.Storer.(*filesystem.Storage); ok {
+		return
+	}
+	remote, err := repo.Remote(git.DefaultRemoteName)
+	if err != nil {
+		return
+	}
+	url := remote.Config().URLs[0]
+	cliClones.Lock()
+	dir, ok := cliClones.dirs[url]
+	delete(cliClones.dirs, url)
+	cliClones.Unlock()
+	if ok {
+		forgetStats(dir)
+		removeDirs([]string{dir})
+	}
+}
+
+// runGit runs the git command line in the given directory and returns its output
+func runGit(dir string, args ...string) ([]byte, error) {
+	if dir != "" {
+		args = append([]string{"-C", dir}, args...)
+	}
+	var stderr bytes.Buffer
+	cmd := exec.Command("git", args...)
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("git %s: %v: %s", strings.Join(args, " "), err, strings.TrimSpace(stderr.String()))
+	}
+	return out, nil
+}
+
+// cliBlame blames the file at the given commit with git blame --porcelain
+func cliBlame(repo *git.Repository, hash plumbing.Hash, path string, opts BlameOptions) (*git.BlameResult, error) {
+	dir, err := repositoryDir(repo)
+	if err != nil {
+		return nil, err
+	}
+	args := []string{"blame", "--porcelain"}
+	if opts.IgnoreWhitespace {
+		args = append(args, "-w")
+	}
+	for rev := range opts.IgnoreRevs {
+		args = append(args, "--ignore-rev", rev.String())
+	}
+	if opts.StartLine > 0 || opts.EndLine > 0 {
+		lines := "1,"
+		if opts.StartLine > 0 {
+			lines = strconv.Itoa(opts.StartLine) + ","
+		}
+		if opts.EndLine > 0 {
+			lines += strconv.Itoa(opts.EndLine)
+		}
+		args = append(args, "-L", lines)
+	}
+	out, err := runGit(dir, append(args, hash.String(), "--", path)...)
+	if err != nil {
+		return nil, err
+	}
+	lines, err := parseBlamePorcelain(bytes.NewReader(out))
+	if err != nil {
+		return nil, err
+	}
+	return &git.BlameResult{Path: path, Rev: hash, Lines: lines}, nil
+}
+
+// parseBlamePorcelain parses the output of git blame --porcelain. The author of a commit is only given
+// the first time one of its lines shows up.
+func parseBlamePorcelain(r io.Reader) ([]*git.Line, error) {
+	type commitInfo struct {
+		email string
+		time  int64
+		tz    string
+	}
+	commits := make(map[string]*commitInfo)
+	var lines []*git.Line
+	var current *commitInfo
+	var currentHash string
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.HasPrefix(line, "\t") {
+			if current == nil {
+				return nil, errors.New("unexpected line content in git blame output")
+			}
+			date := time.Unix(current.time, 0).In(parseTimezone(current.tz))
+			lines = append(lines, &git.Line{Author: current.email, Text: line[1:], Date: date, Hash: plumbing.NewHash(currentHash)})
+			continue
+		}
+		fields := strings.SplitN(line, " ", 2)
+		value := ""
+		if len(fields) == 2 {
+			value = fields[1]
+		}
+		if current == nil && strings.HasPrefix(fields[0], "author-") {
+			return nil, fmt.Errorf("unexpected %s before any commit in git blame output", fields[0])
+		}
+		switch fields[0] {
+		case "author-mail":
+			current.email = strings.TrimSuffix(strings.TrimPrefix(value, "<"), ">")
+		case "author-time":
+			current.time, _ = strconv.ParseInt(value, 10, 64)
+		case "author-tz":
+			current.tz = value
+		default:
+			if len(fields[0]) == 40 && plumbing.NewHash(fields[0]).String() == fields[0] {
+				currentHash = fields[0]
+				if commits[currentHash] == nil {
+					commits[currentHash] = &commitInfo{}
+				}
+				current = commits[currentHash]
+			}
+		}
+	}
+	return lines, scanner.Err()
+}
+
+// parseTimezone parses a timezone offset like +0200
+func parseTimezone(tz string) *time.Location {
+	offset, err := strconv.Atoi(tz)
+	if err != nil || len(tz) != 5 {
+		return time.UTC
+	}
+	seconds := (offset/100*60 + offset%100) * 60
+	return time.FixedZone(tz, seconds)
+}
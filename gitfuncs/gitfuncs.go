@@ -1,17 +1,17 @@
 package gitfuncs
 
 import (
+	"fmt"
 	"github.com/andymeneely/git-churn/helper"
-	"gopkg.in/src-d/go-git.v4/plumbing/revlist"
 	"sort"
 	"strings"
 
 	. "github.com/andymeneely/git-churn/print"
-	"gopkg.in/src-d/go-billy.v4/memfs"
 	"gopkg.in/src-d/go-git.v4"
+	"gopkg.in/src-d/go-git.v4/config"
 	"gopkg.in/src-d/go-git.v4/plumbing"
+	"gopkg.in/src-d/go-git.v4/plumbing/filemode"
 	"gopkg.in/src-d/go-git.v4/plumbing/object"
-	"gopkg.in/src-d/go-git.v4/storage/memory"
 	//"github.com/go-git/go-git/v5"
 )
 
@@ -20,7 +20,7 @@ func LastCommit(repoUrl string) string {
 	// branches and fetching the objects, exactly as:
 	Info("git clone " + repoUrl)
 
-	r, err := git.Clone(memory.NewStorage(), nil, &git.CloneOptions{
+	r, err := CloneWithOptions(&git.CloneOptions{
 		URL: repoUrl,
 	})
 
@@ -38,151 +38,332 @@ func LastCommit(repoUrl string) string {
 	return commit.Message
 }
 
-func Branches(repoUrl string) []string {
-	// Clones the given repository in memory, creating the remote, the local
-	// branches and fetching the objects, exactly as:
-	Info("git clone " + repoUrl)
-
-	r, err := git.Clone(memory.NewStorage(), nil, &git.CloneOptions{
-		URL: repoUrl,
-	})
+// Branch is a local or remote-tracking branch along with the commit at its tip
+type Branch struct {
+	Name   string
+	Hash   string
+	Remote bool
+}
 
+// Branches lists the local and the remote-tracking branches of the given repository.
+// An in-memory clone only creates a local branch for HEAD, so the branches advertised by
+// the remote are listed as well in case they were not fetched as remote-tracking branches.
+func Branches(repoUrl string) []Branch {
+	r := Clone(repoUrl)
+	branches, err := ListBranches(r)
 	CheckIfError(err)
+	return branches
+}
 
-	branchIttr, _ := r.Branches()
+// ListBranches lists the local and remote-tracking branches of an already cloned repository
+func ListBranches(r *git.Repository) ([]Branch, error) {
+	seen := make(map[string]bool)
+	var branches []Branch
+	addBranch := func(ref *plumbing.Reference, remote bool) {
+		if ref.Type() != plumbing.HashReference || seen[ref.Name().String()] {
+			return
+		}
+		seen[ref.Name().String()] = true
+		branches = append(branches, Branch{Name: ref.Name().String(), Hash: ref.Hash().String(), Remote: remote})
+	}
 
-	//fmt.Println(branchIttr)
-	var branches []string
-	//TODO: Check why it is only getting the master branch
-	err = branchIttr.ForEach(func(ref *plumbing.Reference) error {
-		//fmt.Println(ref.Name().String())
-		branches = append(branches, ref.Name().String())
+	refs, err := r.References()
+	if err != nil {
+		return nil, err
+	}
+	err = refs.ForEach(func(ref *plumbing.Reference) error {
+		if ref.Name().IsBranch() {
+			addBranch(ref, false)
+		} else if ref.Name().IsRemote() {
+			addBranch(ref, true)
+		}
 		return nil
 	})
+	if err != nil {
+		return nil, err
+	}
 
-	return branches
+	remotes, err := r.Remotes()
+	if err != nil {
+		return nil, err
+	}
+	for _, remote := range remotes {
+		advertised, err := remote.List(&git.ListOptions{})
+		if err != nil {
+			// The remote-tracking branches are still useful if the remote went away
+			Warning("git ls-remote %s: %s", remote.Config().Name, err)
+			continue
+		}
+		for _, ref := range advertised {
+			if ref.Name().IsBranch() {
+				name := plumbing.NewRemoteReferenceName(remote.Config().Name, ref.Name().Short())
+				addBranch(plumbing.NewHashReference(name, ref.Hash()), true)
+			}
+		}
+	}
+
+	sort.Slice(branches, func(i, j int) bool { return branches[i].Name < branches[j].Name })
+	return branches, nil
 }
 
 func Tags(repoUrl string) []*plumbing.Reference {
 
 	Info("git clone " + repoUrl)
 
-	r, err := git.Clone(memory.NewStorage(), nil, &git.CloneOptions{
+	r, err := CloneWithOptions(&git.CloneOptions{
 		URL: repoUrl,
 	})
 
 	CheckIfError(err)
 	// List all tag references, both lightweight tags and annotated tags
 	Info("git show-ref --tag")
+	tagsArr, err := ListTags(r)
+	CheckIfError(err)
+
+	return tagsArr
+
+}
+
+// ListTags lists the lightweight and annotated tag references of an already cloned repository
+func ListTags(r *git.Repository) ([]*plumbing.Reference, error) {
 	var tagsArr []*plumbing.Reference
 
 	tagrefs, err := r.Tags()
-	CheckIfError(err)
+	if err != nil {
+		return nil, err
+	}
 	err = tagrefs.ForEach(func(t *plumbing.Reference) error {
 		tagsArr = append(tagsArr, t)
 		return nil
 	})
-	CheckIfError(err)
+	return tagsArr, err
+}
 
-	return tagsArr
+// Checkout clones the given repository and points HEAD at the given commit hash
+func Checkout(repoUrl, hash string) *git.Repository {
+	r := Clone(repoUrl)
 
+	// ... checking out to commit
+	Info("git checkout %s", hash)
+	CheckIfError(SetHead(r, plumbing.NewHash(hash)))
+	return r
 }
 
-func Checkout(repoUrl, hash string) *git.Repository {
+// Clone clones the given repository into the Storage backend without checking out any particular commit
+func Clone(repoUrl string) *git.Repository {
+	r, err := CloneRepository(repoUrl)
+	CheckIfError(err)
+	return r
+}
+
+// CloneRepository clones the repository like Clone, returning the error instead of exiting
+func CloneRepository(repoUrl string) (*git.Repository, error) {
 	Info("git clone " + repoUrl)
 
-	r, err := git.Clone(memory.NewStorage(), memfs.New(), &git.CloneOptions{
+	return CloneWithOptions(&git.CloneOptions{
 		URL: repoUrl,
 	})
+}
 
-	CheckIfError(err)
+// CheckoutRef clones the given repository and points HEAD at the given branch, tag or revision.
+// Branch names are looked up among the remote-tracking branches as well, so any branch of
+// the remote can be analysed and not only the default one.
+func CheckoutRef(repoUrl, ref string) *git.Repository {
+	r := Clone(repoUrl)
 
-	w, err := r.Worktree()
+	hash, err := ResolveRef(r, ref)
 	CheckIfError(err)
 
 	// ... checking out to commit
-	Info("git checkout %s", hash)
-	err = w.Checkout(&git.CheckoutOptions{
-		Hash: plumbing.NewHash(hash),
-	})
-	CheckIfError(err)
+	Info("git checkout %s", ref)
+	CheckIfError(SetHead(r, *hash))
 	return r
 }
 
+// FetchRefs fetches the given refspecs from the origin remote of an already cloned repository,
+// e.g. "+refs/pull/1/head:refs/remotes/origin/pull/1" for refs that are not branches nor tags
+func FetchRefs(r *git.Repository, refspecs ...string) error {
+	_, err := FetchNewRefs(r, refspecs...)
+	return err
+}
+
+// FetchNewRefs fetches like FetchRefs and tells whether the fetch updated any ref, so that what was
+// computed on the previous refs can be dropped
+func FetchNewRefs(r *git.Repository, refspecs ...string) (bool, error) {
+	specs, err := parseRefSpecs(refspecs)
+	if err != nil {
+		return false, err
+	}
+	Info("git fetch %s %s", git.DefaultRemoteName, strings.Join(refspecs, " "))
+	err = r.Fetch(&git.FetchOptions{RemoteName: git.DefaultRemoteName, RefSpecs: specs})
+	if err == git.NoErrAlreadyUpToDate {
+		return false, nil
+	}
+	if err == nil {
+		// The clone the CLIEngine made lacks the new commits, it is cloned again on its next use
+		releaseCLIClone(r)
+	}
+	return err == nil, err
+}
+
+// FetchRefsFrom fetches the given refspecs into an already cloned repository like FetchRefs, but from
+// another repository than its origin, e.g. the fork a pull request comes from
+func FetchRefsFrom(r *git.Repository, repoUrl string, refspecs ...string) error {
+	specs, err := parseRefSpecs(refspecs)
+	if err != nil {
+		return err
+	}
+	Info("git fetch %s %s", repoUrl, strings.Join(refspecs, " "))
+	remote := git.NewRemote(r.Storer, &config.RemoteConfig{Name: "fetched", URLs: []string{repoUrl}})
+	err = remote.Fetch(&git.FetchOptions{RefSpecs: specs})
+	if err == git.NoErrAlreadyUpToDate {
+		return nil
+	}
+	return err
+}
+
+func parseRefSpecs(refspecs []string) ([]config.RefSpec, error) {
+	specs := make([]config.RefSpec, len(refspecs))
+	for i, refspec := range refspecs {
+		specs[i] = config.RefSpec(refspec)
+		if err := specs[i].Validate(); err != nil {
+			return nil, err
+		}
+	}
+	return specs, nil
+}
+
+// SetHead detaches HEAD at the given commit of an already cloned repository, the way a checkout does but
+// without a worktree to update, the metrics of HEAD reading its tree from the objects
+func SetHead(r *git.Repository, hash plumbing.Hash) error {
+	if _, err := r.CommitObject(hash); err != nil {
+		return fmt.Errorf("unable to check out %s: %v", hash, err)
+	}
+	return r.Storer.SetReference(plumbing.NewHashReference(plumbing.HEAD, hash))
+}
+
+// ResolveRef resolves a commit hash, local branch, remote-tracking branch, tag or any
+// revision understood by ResolveRevision (HEAD~1, v1.0^, ...) to a commit hash
+func ResolveRef(r *git.Repository, ref string) (*plumbing.Hash, error) {
+	hash, err := r.ResolveRevision(plumbing.Revision(ref))
+	if err == nil {
+		return hash, nil
+	}
+	// An in-memory clone only has a local branch for HEAD, the rest are remote-tracking branches
+	if remoteHash, remoteErr := r.ResolveRevision(plumbing.Revision(git.DefaultRemoteName + "/" + ref)); remoteErr == nil {
+		return remoteHash, nil
+	}
+	return nil, fmt.Errorf("unable to resolve %s to a commit: %s", ref, err)
+}
+
+// ResolveFetchedRef resolves the ref like ResolveRef, but looks branch names up among the remote-tracking
+// branches first. Those are the ones FetchRefs updates, the local branches of a clone never move.
+func ResolveFetchedRef(r *git.Repository, ref string) (*plumbing.Hash, error) {
+	if hash, err := r.ResolveRevision(plumbing.Revision(git.DefaultRemoteName + "/" + ref)); err == nil {
+		return hash, nil
+	}
+	return ResolveRef(r, ref)
+}
+
+// DefaultBranch returns the name of the default branch of the repository: the branch the HEAD of its origin
+// remote points to, or else the branch its HEAD points to, which for a clone is the default branch of the
+// remote it was cloned from
+func DefaultBranch(r *git.Repository) (string, error) {
+	remoteHead := plumbing.NewRemoteHEADReferenceName(git.DefaultRemoteName)
+	if ref, err := r.Reference(remoteHead, false); err == nil && ref.Type() == plumbing.SymbolicReference {
+		return strings.TrimPrefix(ref.Target().String(), "refs/remotes/"+git.DefaultRemoteName+"/"), nil
+	}
+	head, err := r.Reference(plumbing.HEAD, false)
+	if err != nil {
+		return "", err
+	}
+	if head.Type() != plumbing.SymbolicReference {
+		return "", fmt.Errorf("unable to tell the default branch, HEAD is detached at %s", head.Hash())
+	}
+	return head.Target().Short(), nil
+}
+
 func FileLOC(repoUrl, filePath string) int {
 	loc := 0
 	// ... get the files iterator and print the file
 	FilesIttr(repoUrl).ForEach(func(f *object.File) error {
 		if f.Name == filePath {
-			lines, _ := f.Lines()
-			loc = len(lines)
+			loc, _, _ = blobLines(f, true)
 		}
 		return nil
 	})
 	return loc
 }
 
-//Gets the total number of lines of code in a given file in the specified commit tree
-//Whitespace included
-func FileLOCFromTree(tree *object.Tree, filePath string) int {
+// EmptyTreeHash is the hash of the tree with no entries, the parent tree of the root commits
+var EmptyTreeHash = plumbing.NewHash("4b825dc642cb6eb9a060e54bf8d69288fbee4904")
+
+// TreeLOC returns the total lines of code of the files of the tree within PathScope but the ignored ones,
+// and their names. Blank lines are counted only if whitespace is true. A nil tree, e.g. the parent tree of a
+// root commit, and a tree with no files, e.g. one of submodules only, have no lines and no files.
+func TreeLOC(tree *object.Tree, ignore *Ignore, whitespace bool) (int, []string, error) {
 	loc := 0
-	tree.Files().ForEach(func(f *object.File) error {
-		if f.Name == filePath {
-			lines, _ := f.Lines()
-			loc = len(lines)
+	var files []string
+	err := ForEachFile(tree, func(f *object.File) error {
+		if ignore.Match(f.Name) {
+			return nil
 		}
+		loc += BlobLOC(f, whitespace)
+		files = append(files, f.Name)
 		return nil
 	})
+	if err != nil {
+		return 0, nil, err
+	}
+	return loc, files, nil
+}
+
+// TreeFileLOC returns the lines of code of the file at the path in the tree. Blank lines are counted only if
+// whitespace is true. It returns object.ErrFileNotFound when the tree is nil or the path is missing, a
+// directory or a submodule.
+func TreeFileLOC(tree *object.Tree, filePath string, whitespace bool) (int, error) {
+	if tree == nil {
+		return 0, object.ErrFileNotFound
+	}
+	entry, err := tree.FindEntry(filePath)
+	if err != nil {
+		return 0, object.ErrFileNotFound
+	}
+	if entry.Mode == filemode.Dir || entry.Mode == filemode.Submodule {
+		return 0, object.ErrFileNotFound
+	}
+	f, err := tree.File(filePath)
+	if err != nil {
+		return 0, err
+	}
+	return BlobLOC(f, whitespace), nil
+}
+
+//Gets the total number of lines of code in a given file in the specified commit tree, 0 when the file is not in it
+//Whitespace included
+func FileLOCFromTree(tree *object.Tree, filePath string) int {
+	loc, _ := TreeFileLOC(tree, filePath, true)
 	return loc
 }
 
-//Returns the total lines of code from all the files in the given commit tree and list of fine names
+//Returns the total lines of code from all the files in the given commit tree and list of fine names, see TreeLOC
 // Whitespace included
-func LOCFilesFromTree(tree *object.Tree, c chan func() (int, []string)) {
-	loc := 0
-	var files []string
-	tree.Files().ForEach(func(f *object.File) error {
-		lines, _ := f.Lines()
-		loc += len(lines)
-		files = append(files, f.Name)
-		return nil
-	})
+func LOCFilesFromTree(tree *object.Tree, ignore *Ignore, c chan func() (int, []string)) {
+	loc, files, _ := TreeLOC(tree, ignore, true)
 	c <- func() (int, []string) { return loc, files }
 }
 
-//Gets the total number of lines of code in a given file in the specified commit tree
+//Gets the total number of lines of code in a given file in the specified commit tree, 0 when the file is not in it
 //Whitespace excluded
 func FileLOCFromTreeWhitespaceExcluded(tree *object.Tree, filePath string) int {
-	loc := 0
-	tree.Files().ForEach(func(f *object.File) error {
-		if f.Name == filePath {
-			lines, _ := f.Lines()
-			for _, line := range lines {
-				if line != "" {
-					loc += 1
-				}
-			}
-		}
-		return nil
-	})
+	loc, _ := TreeFileLOC(tree, filePath, false)
 	return loc
 }
 
-//Returns the total lines of code from all the files in the given commit tree and list of fine names
+//Returns the total lines of code from all the files in the given commit tree and list of fine names, see TreeLOC
 //Whitespace excluded
-func LOCFilesFromTreeWhitespaceExcluded(tree *object.Tree) (int, []string) {
-	loc := 0
-	var files []string
-	tree.Files().ForEach(func(f *object.File) error {
-		lines, _ := f.Lines()
-		for _, line := range lines {
-			if line != "" {
-				loc += 1
-			}
-		}
-		files = append(files, f.Name)
-		return nil
-	})
+func LOCFilesFromTreeWhitespaceExcluded(tree *object.Tree, ignore *Ignore) (int, []string) {
+	loc, files, _ := TreeLOC(tree, ignore, false)
 	return loc, files
 }
 
@@ -192,7 +373,7 @@ func FilesIttr(repoUrl string) *object.FileIter {
 	//branches and fetching the objects, exactly as:
 	Info("git clone " + repoUrl)
 
-	r, err := git.Clone(memory.NewStorage(), nil, &git.CloneOptions{
+	r, err := CloneWithOptions(&git.CloneOptions{
 		URL: repoUrl,
 	})
 
@@ -215,7 +396,8 @@ func FilesIttr(repoUrl string) *object.FileIter {
 	return tree.Files()
 }
 
-// Returns the changes b/n the commit and it's parent, the tree corresponding to the commit and it's parent tree
+// Returns the changes b/n the commit and it's parent, the files of the moved directories being paired when
+// DetectDirMoves is set, the tree corresponding to the commit and it's parent tree, nil for a root commit
 func CommitDiff(repo *git.Repository) (*object.Changes, *object.Tree, *object.Tree) {
 
 	head, err := repo.Head()
@@ -228,9 +410,6 @@ func CommitDiff(repo *git.Repository) (*object.Changes, *object.Tree, *object.Tr
 	//fmt.Println(commitObj.Author.When)
 	//fmt.Println(commitObj.Author.String())
 
-	parentCommitObj, err := commitObj.Parent(0)
-	CheckIfError(err)
-
 	// List the tree from HEAD
 	Info("git ls-tree -repo HEAD")
 
@@ -238,9 +417,15 @@ func CommitDiff(repo *git.Repository) (*object.Changes, *object.Tree, *object.Tr
 	tree, err := commitObj.Tree()
 	CheckIfError(err)
 
-	parentTree, err := parentCommitObj.Tree()
-	CheckIfError(err)
-	changes, err := parentTree.Diff(tree)
+	// A root commit, e.g. the first one of an orphan branch, adds all its files to no parent tree
+	var parentTree *object.Tree
+	if commitObj.NumParents() > 0 {
+		parentCommitObj, err := commitObj.Parent(0)
+		CheckIfError(err)
+		parentTree, err = parentCommitObj.Tree()
+		CheckIfError(err)
+	}
+	changes, err := diffTreeMoves(parentTree, tree)
 	CheckIfError(err)
 
 	//fmt.Println(changes)
@@ -249,89 +434,81 @@ func CommitDiff(repo *git.Repository) (*object.Changes, *object.Tree, *object.Tr
 	return &changes, tree, parentTree
 }
 
-func DeletedLineNumbers(repo *git.Repository) (map[string][]int, string) {
-	changes, _, parentTree := CommitDiff(repo)
-	patch, _ := changes.Patch()
-	fileDeletedLinesMap := make(map[string][]int)
-	for _, patch := range patch.FilePatches() {
-		//fmt.Println(patch)
-		lineCounter := 0
-		var deletedLines []int
-		for _, chunk := range patch.Chunks() {
-			if chunk.Type() == 0 {
-				if chunk.Content()[len(chunk.Content())-1] == '\n' {
-					lineCounter += len(strings.Split(chunk.Content(), "\n")) - 1
-				} else {
-					lineCounter += len(strings.Split(chunk.Content(), "\n"))
-				}
-				//lineCounter += len(strings.Split(chunk.Content(), "\n")) - 1
-			}
-			if chunk.Type() == 2 {
-				var patchLen int
-				if chunk.Content()[len(chunk.Content())-1] == '\n' {
-					patchLen = len(strings.Split(chunk.Content(), "\n")) - 1
-				} else {
-					patchLen = len(strings.Split(chunk.Content(), "\n"))
-				}
-				for i := 1; i <= patchLen; i++ {
-					deletedLines = append(deletedLines, lineCounter+i)
-
-				}
-				lineCounter += patchLen
-			}
-		}
-		fromFile, toFile := patch.Files()
-		if nil == fromFile {
-			fileDeletedLinesMap[toFile.Path()] = deletedLines
-		} else {
-			fileDeletedLinesMap[fromFile.Path()] = deletedLines
+// TreeDiff returns the changes b/n the trees of any two revisions, from the first to the second, the tree of the
+// second and the tree of the first, whatever the commits in between. The files of the moved directories are
+// paired when DetectDirMoves is set.
+func TreeDiff(repo *git.Repository, from, to string) (*object.Changes, *object.Tree, *object.Tree, error) {
+	fromTree, err := revisionTree(repo, from)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	toTree, err := revisionTree(repo, to)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	changes, err := fromTree.Diff(toTree)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	if DetectDirMoves {
+		if changes, _, err = pairDirMoves(fromTree, toTree, changes); err != nil {
+			return nil, nil, nil, err
 		}
-		//fmt.Println(deletedLines)
 	}
-	return fileDeletedLinesMap, parentTree.Hash.String()
+	return &changes, toTree, fromTree, nil
+}
+
+func revisionTree(repo *git.Repository, revision string) (*object.Tree, error) {
+	hash, err := ResolveRef(repo, revision)
+	if err != nil {
+		return nil, err
+	}
+	commit, err := repo.CommitObject(*hash)
+	if err != nil {
+		return nil, err
+	}
+	return commit.Tree()
 }
 
+// DeletedLineNumbers returns the line numbers, in the parent of HEAD, of the lines deleted per file by
+// HEAD, see LineChanges, and the hash of the parent tree. A root commit deletes no lines and has the empty
+// tree as parent tree.
+func DeletedLineNumbers(repo *git.Repository) (map[string][]int, string) {
+	return deletedLineNumbers(repo, WhitespaceIncluded)
+}
+
+// DeletedLineNumbersWhitespaceExcluded is DeletedLineNumbers leaving the blank lines out
 func DeletedLineNumbersWhitespaceExcluded(repo *git.Repository) (map[string][]int, string) {
-	changes, _, parentTree := CommitDiff(repo)
-	patch, _ := changes.Patch()
-	fileDeletedLinesMap := make(map[string][]int)
-	for _, patch := range patch.FilePatches() {
-		//fmt.Println(patch)
-		lineCounter := 0
-		var deletedLines []int
-		for _, chunk := range patch.Chunks() {
-			if chunk.Type() == 0 {
-				if chunk.Content()[len(chunk.Content())-1] == '\n' {
-					lineCounter += len(strings.Split(chunk.Content(), "\n")) - 1
-				} else {
-					lineCounter += len(strings.Split(chunk.Content(), "\n"))
-				}
-			}
-			if chunk.Type() == 2 {
-				deletedPatch := strings.Split(chunk.Content(), "\n")
-				var patchLen int
-				if chunk.Content()[len(chunk.Content())-1] == '\n' {
-					patchLen = len(deletedPatch) - 1
-				} else {
-					patchLen = len(deletedPatch)
-				}
-				for i := 1; i <= patchLen; i++ {
-					if deletedPatch[i-1] != "" {
-						deletedLines = append(deletedLines, lineCounter+i)
-					}
-				}
-				lineCounter += patchLen
-			}
-		}
-		fromFile, toFile := patch.Files()
-		if nil == fromFile {
-			fileDeletedLinesMap[toFile.Path()] = deletedLines
-		} else {
-			fileDeletedLinesMap[fromFile.Path()] = deletedLines
-		}
-		//fmt.Println(deletedLines)
+	return deletedLineNumbers(repo, WhitespaceExcluded)
+}
+
+func deletedLineNumbers(repo *git.Repository, whitespace WhitespaceMode) (map[string][]int, string) {
+	changes, err := LineChanges(repo, "HEAD", LineChangeOptions{Whitespace: whitespace})
+	CheckIfError(err)
+	head, err := repo.Head()
+	CheckIfError(err)
+	commit, err := repo.CommitObject(head.Hash())
+	CheckIfError(err)
+	if commit.NumParents() == 0 {
+		return deletedLines(changes), EmptyTreeHash.String()
+	}
+	parent, err := commit.Parent(0)
+	CheckIfError(err)
+	return deletedLines(changes), parent.TreeHash.String()
+}
+
+// DeletedLinesBetween returns the line numbers, in the `from` tree, of the lines deleted per file
+// between the two trees. Blank lines are included only if whitespace is true.
+func DeletedLinesBetween(from, to *object.Tree, whitespace bool) (map[string][]int, error) {
+	opts := LineChangeOptions{Whitespace: WhitespaceExcluded}
+	if whitespace {
+		opts.Whitespace = WhitespaceIncluded
+	}
+	changes, err := LineChangesBetween(from, to, opts)
+	if err != nil {
+		return nil, err
 	}
-	return fileDeletedLinesMap, parentTree.Hash.String()
+	return deletedLines(changes), nil
 }
 
 func RevisionCommits(r *git.Repository, revision string) *plumbing.Hash {
@@ -348,34 +525,54 @@ func RevisionCommits(r *git.Repository, revision string) *plumbing.Hash {
 	return h
 }
 
-// RevList is native implementation of git rev-list command
+// RevList is native implementation of git rev-list command. It lists the commits reachable from beginCommit,
+// the tip of the range, and not from endCommit, the older boundary, like git rev-list endCommit..beginCommit:
+// beginCommit is included and endCommit excluded. An empty endCommit lists the whole history of beginCommit.
+// The commits are listed once each, newest committer time first.
 func RevList(r *git.Repository, beginCommit, endCommit string) ([]*object.Commit, error) {
-	//TODO: should I reverse the begin and end?
+	return RevListWithOptions(r, beginCommit, endCommit, RevListOptions{})
+}
 
-	commits := make([]*object.Commit, 0)
-	ref1hist, err := revlist.Objects(r.Storer, []plumbing.Hash{plumbing.NewHash(endCommit)}, nil)
+// RevListWithOptions is RevList ordering the commits and bounding the range as told by the options
+func RevListWithOptions(r *git.Repository, beginCommit, endCommit string, opts RevListOptions) ([]*object.Commit, error) {
+	begin, err := ResolveRef(r, beginCommit)
 	if err != nil {
 		return nil, err
 	}
-	ref2hist, err := revlist.Objects(r.Storer, []plumbing.Hash{plumbing.NewHash(beginCommit)}, ref1hist)
+	var end plumbing.Hash
+	if endCommit != "" {
+		hash, err := ResolveRef(r, endCommit)
+		if err != nil {
+			return nil, err
+		}
+		end = *hash
+	}
+
+	var commits []*object.Commit
+	err = walkCommitsBetween(r, end, *begin, func(c *object.Commit) error {
+		commits = append(commits, c)
+		return nil
+	})
 	if err != nil {
 		return nil, err
 	}
-
-	for _, h := range ref2hist {
-		c, err := r.CommitObject(h)
+	if opts.IncludeEnd && !end.IsZero() {
+		endCommitObj, err := r.CommitObject(end)
 		if err != nil {
-			continue
+			return nil, err
 		}
-		commits = append(commits, c)
+		commits = append(commits, endCommitObj)
 	}
-	//  sorts by datetime
-	sort.Slice(commits, func(i, j int) bool { return commits[i].Committer.When.Unix() > commits[j].Committer.When.Unix() })
-	//fmt.Println(commits)
-
-	return commits, err
+	sortCommits(commits, opts.Order)
+	return commits, nil
 }
 
+// IsBot tells the commits of bots, whose authors GetDistinctAuthorsEMailIds leaves out. The metrics set it
+// to their bot filter.
+var IsBot func(commit *object.Commit) bool
+
+// GetDistinctAuthorsEMailIds returns the authors of the commits of the range whose tree has the file, but
+// the bots
 func GetDistinctAuthorsEMailIds(r *git.Repository, beginCommit, endCommit, filePath string) ([]string, error) {
 
 	commits, err := RevList(r, beginCommit, endCommit)
@@ -393,33 +590,22 @@ func GetDistinctAuthorsEMailIds(r *git.Repository, beginCommit, endCommit, fileP
 		if err != nil {
 			continue
 		}
-		authors = append(authors, commit.Author.Email)
+		if IsBot != nil && IsBot(commit) {
+			continue
+		}
+		_, email := ResolveAuthor(r, commit.Author)
+		authors = append(authors, email)
 	}
 	authors = helper.UniqueElements(authors)
 	return authors, err
 
 }
 
+// Blame blames the file at the given commit with the active engine
 func Blame(repo *git.Repository, hash *plumbing.Hash, path string) (*git.BlameResult, error) {
-
-	//TODO: It does not support the options mentioned in https://git-scm.com/docs/git-blame
 	commitObj, err := repo.CommitObject(*hash)
 	CheckIfError(err)
 
-	// This is because the Blame throws error if the previous commit is a merge PR commit
-	//if strings.Contains(commitObj.Message, "Merge pull request") {
-	//	hash := RevisionCommits(repo, "HEAD^^2")
-	//	commitObj, err = repo.CommitObject(*hash)
-	//	fmt.Println(commitObj.Message)
-	//	CheckIfError(err)
-	//}
-
 	//TODO: issue: https://github.com/src-d/go-git/issues/725
-	blameResult, err := git.Blame(commitObj, path)
-
-	//fmt.Println(blameResult)
-	//fmt.Println(blameResult.Lines)
-
-	return blameResult, err
-
+	return ActiveEngine.Blame(repo, commitObj, path)
 }
@@ -11,6 +11,14 @@ func TestBranches(t *testing.T) {
 	branches := Branches("https://github.com/andymeneely/git-churn")
 	assert := assert.New(t)
 	assert.NotEqual(0, len(branches))
+	remotes := 0
+	for _, branch := range branches {
+		assert.Equal(40, len(branch.Hash))
+		if branch.Remote {
+			remotes += 1
+		}
+	}
+	assert.NotEqual(0, remotes)
 }
 
 func TestRevList(t *testing.T) {
@@ -0,0 +1,156 @@
+package gitfuncs
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"strings"
+
+	"github.com/sergi/go-diff/diffmatchpatch"
+	"gopkg.in/src-d/go-git.v4"
+	"gopkg.in/src-d/go-git.v4/plumbing/object"
+)
+
+// Hunk is a run of lines of a file replaced by other lines, without context lines. The start lines count
+// from 1 and, like in unified diffs, are the line before the hunk when it has no lines on that side.
+type Hunk struct {
+	File     string
+	OldStart int
+	NewStart int
+	Added    int `units:"lines"`
+	Deleted  int `units:"lines"`
+	// SHA-1 of the lines deleted and added, line breaks included, to recognize the same lines elsewhere
+	DeletedHash string `json:",omitempty"`
+	AddedHash   string `json:",omitempty"`
+}
+
+// CommitHunks returns the hunks of the diff of the commit against its first parent, leaving out the files
+// the repository ignores. Binary files have no hunks.
+func CommitHunks(repo *git.Repository, commit *object.Commit) ([]Hunk, error) {
+	tree, err := commit.Tree()
+	if err != nil {
+		return nil, err
+	}
+	var parentTree *object.Tree
+	if commit.NumParents() != 0 {
+		parent, err := commit.Parent(0)
+		if err != nil {
+			return nil, err
+		}
+		if parentTree, err = parent.Tree(); err != nil {
+			return nil, err
+		}
+	}
+	hunks, err := TreeDiffHunks(parentTree, tree)
+	if err != nil {
+		return nil, err
+	}
+	ignore := RepoIgnore(repo)
+	kept := hunks[:0]
+	for _, hunk := range hunks {
+		if !ignore.Match(hunk.File) {
+			kept = append(kept, hunk)
+		}
+	}
+	return kept, nil
+}
+
+// TreeDiffHunks returns the hunks of the text files changed between the two trees, by path then line.
+// A nil `from` tree stands for the empty tree.
+func TreeDiffHunks(from, to *object.Tree) ([]Hunk, error) {
+	changes, err := diffTree(from, to)
+	if err != nil {
+		return nil, err
+	}
+	var hunks []Hunk
+	for _, change := range changes {
+		fromFile, toFile, err := change.Files()
+		if err != nil {
+			return nil, err
+		}
+		if fromFile == nil && toFile == nil {
+			// Submodules
+			continue
+		}
+		if binary, err := isBinary(fromFile, toFile); err != nil {
+			return nil, err
+		} else if binary {
+			continue
+		}
+		if fromFile != nil && toFile != nil && fromFile.Hash == toFile.Hash {
+			// Only the mode changed
+			continue
+		}
+		var fromContent, toContent string
+		if fromFile != nil {
+			if fromContent, err = fileText(fromFile); err != nil {
+				return nil, err
+			}
+		}
+		if toFile != nil {
+			if toContent, err = fileText(toFile); err != nil {
+				return nil, err
+			}
+		}
+		hunks = append(hunks, diffHunks(changePath(change), fromContent, toContent)...)
+	}
+	return hunks, nil
+}
+
+// diffHunks finds the hunks between two versions of the file at the path, with the same line diff as
+// diffLineCounts
+func diffHunks(path, from, to string) []Hunk {
+	trimmedFrom, trimmedTo, prefix := trimCommonLines(from, to)
+	start := strings.Count(from[:prefix], "\n")
+	fromLines, toLines := textLines(trimmedFrom), textLines(trimmedTo)
+	diffs := diffLines(fromLines, toLines)
+
+	var hunks []Hunk
+	// Lines of the hunk being built, and its first line on both sides counting from 0
+	var deleted, added []string
+	oldStart, newStart := 0, 0
+	flush := func() {
+		if len(deleted) == 0 && len(added) == 0 {
+			return
+		}
+		hunk := Hunk{File: path, OldStart: start + oldStart, NewStart: start + newStart, Deleted: len(deleted), Added: len(added)}
+		if len(deleted) > 0 {
+			hunk.OldStart += 1
+			hunk.DeletedHash = linesHash(deleted)
+		}
+		if len(added) > 0 {
+			hunk.NewStart += 1
+			hunk.AddedHash = linesHash(added)
+		}
+		hunks = append(hunks, hunk)
+		deleted, added = nil, nil
+	}
+	oldLine, newLine := 0, 0
+	for _, d := range diffs {
+		n := len([]rune(d.Text))
+		if d.Type != diffmatchpatch.DiffEqual && len(deleted) == 0 && len(added) == 0 {
+			oldStart, newStart = oldLine, newLine
+		}
+		switch d.Type {
+		case diffmatchpatch.DiffEqual:
+			flush()
+			oldLine += n
+			newLine += n
+		case diffmatchpatch.DiffDelete:
+			deleted = append(deleted, fromLines[oldLine:oldLine+n]...)
+			oldLine += n
+		case diffmatchpatch.DiffInsert:
+			added = append(added, toLines[newLine:newLine+n]...)
+			newLine += n
+		}
+	}
+	flush()
+	return hunks
+}
+
+func linesHash(lines []string) string {
+	h := sha1.New()
+	for _, line := range lines {
+		h.Write([]byte(line))
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
@@ -0,0 +1,50 @@
+package gitfuncs
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDiffHunks(t *testing.T) {
+	assert := assert.New(t)
+	hunks := diffHunks("a.txt", "1\n2\n3\n4\n5\n6\n", "1\n3\n4\nnew\n5\n7\n8\n")
+	assert.Equal([]Hunk{
+		{File: "a.txt", OldStart: 2, NewStart: 1, Deleted: 1, DeletedHash: linesHash([]string{"2\n"})},
+		{File: "a.txt", OldStart: 4, NewStart: 4, Added: 1, AddedHash: linesHash([]string{"new\n"})},
+		{File: "a.txt", OldStart: 6, NewStart: 6, Added: 2, Deleted: 1,
+			DeletedHash: linesHash([]string{"6\n"}), AddedHash: linesHash([]string{"7\n", "8\n"})},
+	}, hunks)
+
+	assert.Equal([]Hunk{{File: "a.txt", NewStart: 1, Added: 2, AddedHash: linesHash([]string{"a\n", "b\n"})}},
+		diffHunks("a.txt", "", "a\nb\n"))
+	assert.Empty(diffHunks("a.txt", "a\n", "a\n"))
+
+	// The hunks add up to the line counts of the stats
+	texts := []string{"", "a", "a\n", "a\nb", "a\nb\n", "b\n", "xa\nb\n", "a\nxb\n", "a\na\na\n", "a\nb\na\nb\nc"}
+	for _, from := range texts {
+		for _, to := range texts {
+			added, deleted := 0, 0
+			for _, hunk := range diffHunks("a.txt", from, to) {
+				added += hunk.Added
+				deleted += hunk.Deleted
+			}
+			a, d := diffLineCounts(from, to)
+			assert.Equal([]int{a, d}, []int{added, deleted}, "%q to %q", from, to)
+		}
+	}
+}
+
+func TestCommitHunks(t *testing.T) {
+	assert := assert.New(t)
+	repo, commits := commitContents(t, []string{"alice", "bob"}, "1\n2\n", "1\n3\n")
+
+	hunks, err := CommitHunks(repo, commits[0])
+	assert.Nil(err)
+	assert.Equal([]Hunk{{File: "a.txt", NewStart: 1, Added: 2, AddedHash: linesHash([]string{"1\n", "2\n"})}}, hunks)
+
+	hunks, err = CommitHunks(repo, commits[1])
+	assert.Nil(err)
+	assert.Equal([]Hunk{{File: "a.txt", OldStart: 2, NewStart: 2, Added: 1, Deleted: 1,
+		DeletedHash: linesHash([]string{"2\n"}), AddedHash: linesHash([]string{"3\n"})}}, hunks)
+}
@@ -0,0 +1,115 @@
+package gitfuncs
+
+import (
+	"gopkg.in/src-d/go-git.v4"
+	"gopkg.in/src-d/go-git.v4/plumbing/object"
+)
+
+// WhitespaceMode tells whether the blank lines, empty or only made of whitespace, count as changed lines
+type WhitespaceMode int
+
+const (
+	WhitespaceIncluded WhitespaceMode = iota
+	WhitespaceExcluded
+)
+
+// LineChangeOptions tell LineChanges which lines to report
+type LineChangeOptions struct {
+	Whitespace WhitespaceMode
+}
+
+// FileLineChanges are the numbers, counting from 1, of the lines added to a file, in its new version,
+// and of the lines deleted from it, in its old version
+type FileLineChanges struct {
+	// Paths of the old and the new version of the file, empty when added and deleted respectively
+	OldPath string `json:",omitempty"`
+	NewPath string `json:",omitempty"`
+	Added   []int
+	Deleted []int
+}
+
+// LineChanges returns the lines added and deleted per file by the commit against its first parent, the
+// files of a root commit being all added. The files are keyed by their old path, or by their new path
+// when added.
+func LineChanges(repo *git.Repository, commitHash string, opts LineChangeOptions) (map[string]*FileLineChanges, error) {
+	hash, err := ResolveRef(repo, commitHash)
+	if err != nil {
+		return nil, err
+	}
+	commit, err := repo.CommitObject(*hash)
+	if err != nil {
+		return nil, err
+	}
+	tree, err := commit.Tree()
+	if err != nil {
+		return nil, err
+	}
+	var parentTree *object.Tree
+	if commit.NumParents() > 0 {
+		parent, err := commit.Parent(0)
+		if err != nil {
+			return nil, err
+		}
+		if parentTree, err = parent.Tree(); err != nil {
+			return nil, err
+		}
+	}
+	return LineChangesBetween(parentTree, tree, opts)
+}
+
+// LineChangesBetween returns the lines added and deleted per file between the two trees like LineChanges,
+// a nil `from` tree being empty
+func LineChangesBetween(from, to *object.Tree, opts LineChangeOptions) (map[string]*FileLineChanges, error) {
+	patch, err := ChurnPatchBetween(from, to)
+	if err != nil {
+		return nil, err
+	}
+	whitespace := opts.Whitespace == WhitespaceIncluded
+	lineChanges := make(map[string]*FileLineChanges)
+	for _, file := range patch.Files {
+		fileChanges := &FileLineChanges{
+			OldPath: file.OldPath,
+			NewPath: file.NewPath,
+			Added:   file.LineNumbers(LineAdded, whitespace),
+			Deleted: file.LineNumbers(LineDeleted, whitespace),
+		}
+		if file.OldPath == "" {
+			lineChanges[file.NewPath] = fileChanges
+		} else {
+			lineChanges[file.OldPath] = fileChanges
+		}
+	}
+	return lineChanges, nil
+}
+
+// AddedLineNumbers returns the line numbers, in the new version of the files, of the lines added per file
+// by the commit against its first parent, keyed by the new path of the files, e.g. to tell the new code of
+// a commit to coverage or static analysis tools
+func AddedLineNumbers(repo *git.Repository, commitHash string, whitespace WhitespaceMode) (map[string][]int, error) {
+	changes, err := LineChanges(repo, commitHash, LineChangeOptions{Whitespace: whitespace})
+	if err != nil {
+		return nil, err
+	}
+	return addedLines(changes), nil
+}
+
+// addedLines keeps the added lines of the line changes, by the new path of the files, the deleted files
+// having none
+func addedLines(changes map[string]*FileLineChanges) map[string][]int {
+	added := make(map[string][]int, len(changes))
+	for _, fileChanges := range changes {
+		if fileChanges.NewPath != "" {
+			added[fileChanges.NewPath] = fileChanges.Added
+		}
+	}
+	return added
+}
+
+// deletedLines keeps the deleted lines of the line changes
+func deletedLines(changes map[string]*FileLineChanges) map[string][]int {
+	deleted := make(map[string][]int, len(changes))
+	for path, fileChanges := range changes {
+		deleted[path] = fileChanges.Deleted
+	}
+	return deleted
+}
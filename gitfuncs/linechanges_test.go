@@ -0,0 +1,61 @@
+package gitfuncs
+
+import (
+	"testing"
+
+	"github.com/andymeneely/git-churn/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLineChanges(t *testing.T) {
+	assert := assert.New(t)
+	repo, commits := commitContents(t, []string{"alice", "bob", "carol"},
+		"1\n2\n3",
+		"1\n\n  \n3\n4",
+		"1\n3\n4\n",
+	)
+
+	changes, err := LineChanges(repo, commits[0].Hash.String(), LineChangeOptions{})
+	assert.Nil(err)
+	assert.Equal(&FileLineChanges{NewPath: "a.txt", Added: []int{1, 2, 3}}, changes["a.txt"])
+
+	// The last line without a line break is changed when one is added after it
+	changes, err = LineChanges(repo, commits[1].Hash.String(), LineChangeOptions{})
+	assert.Nil(err)
+	assert.Equal(&FileLineChanges{OldPath: "a.txt", NewPath: "a.txt", Added: []int{2, 3, 4, 5}, Deleted: []int{2, 3}}, changes["a.txt"])
+	changes, err = LineChanges(repo, commits[1].Hash.String(), LineChangeOptions{Whitespace: WhitespaceExcluded})
+	assert.Nil(err)
+	assert.Equal(&FileLineChanges{OldPath: "a.txt", NewPath: "a.txt", Added: []int{4, 5}, Deleted: []int{2, 3}}, changes["a.txt"])
+
+	changes, err = LineChanges(repo, "HEAD", LineChangeOptions{Whitespace: WhitespaceExcluded})
+	assert.Nil(err)
+	assert.Equal(&FileLineChanges{OldPath: "a.txt", NewPath: "a.txt", Added: []int{3}, Deleted: []int{5}}, changes["a.txt"])
+	changes, err = LineChanges(repo, "HEAD", LineChangeOptions{})
+	assert.Nil(err)
+	assert.Equal(&FileLineChanges{OldPath: "a.txt", NewPath: "a.txt", Added: []int{3}, Deleted: []int{2, 3, 5}}, changes["a.txt"])
+
+	deleted, parentTree := DeletedLineNumbersWhitespaceExcluded(repo)
+	assert.Equal(map[string][]int{"a.txt": {5}}, deleted)
+	assert.Equal(commits[1].TreeHash.String(), parentTree)
+
+	added, err := AddedLineNumbers(repo, "HEAD~1", WhitespaceExcluded)
+	assert.Nil(err)
+	assert.Equal(map[string][]int{"a.txt": {4, 5}}, added)
+
+	_, err = LineChanges(repo, "nothing", LineChangeOptions{})
+	assert.NotNil(err)
+}
+
+func TestAddedLineNumbersDeletedFile(t *testing.T) {
+	assert := assert.New(t)
+	repo := testutil.NewRepo(t)
+	repo.CommitFiles("add", map[string]string{"a.txt": "a\n", "b.txt": "b\n"})
+	repo.Delete("a.txt").Write("b.txt", "b\nc\n").Commit("delete")
+
+	changes, err := LineChanges(repo.Repository, "HEAD", LineChangeOptions{})
+	assert.Nil(err)
+	assert.Equal(&FileLineChanges{OldPath: "a.txt", Deleted: []int{1}}, changes["a.txt"])
+	added, err := AddedLineNumbers(repo.Repository, "HEAD", WhitespaceIncluded)
+	assert.Nil(err)
+	assert.Equal(map[string][]int{"b.txt": {2}}, added)
+}
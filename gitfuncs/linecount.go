@@ -0,0 +1,95 @@
+package gitfuncs
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"strings"
+
+	"gopkg.in/src-d/go-git.v4/plumbing/object"
+)
+
+// sniffLen is the number of bytes looked at for a NUL byte to tell a binary file, like git
+const sniffLen = 8000
+
+// CountLines counts the lines read from r, splitting them like object.File's Lines without holding
+// the content: the last line may lack its line break. Blank lines are counted only if whitespace is
+// true, a CR before the line break making no line blank or not. UTF-16 with a byte order mark is
+// decoded first. Other content with a NUL byte in its first 8000 bytes is binary and has no lines.
+func CountLines(r io.Reader, whitespace bool) (lines int, binary bool, err error) {
+	r = decodeText(r)
+	buf := make([]byte, 32*1024)
+	read := 0
+	// Whether the line being read has no byte yet, and no byte but CRs
+	empty, blank := true, true
+	for {
+		n, readErr := r.Read(buf)
+		chunk := buf[:n]
+		if read < sniffLen {
+			sniff := chunk
+			if len(sniff) > sniffLen-read {
+				sniff = sniff[:sniffLen-read]
+			}
+			if bytes.IndexByte(sniff, 0) >= 0 {
+				return 0, true, nil
+			}
+		}
+		read += n
+		for len(chunk) > 0 {
+			i := bytes.IndexByte(chunk, '\n')
+			if i < 0 {
+				empty = false
+				blank = blank && len(bytes.Trim(chunk, "\r")) == 0
+				break
+			}
+			if whitespace || !blank || len(bytes.Trim(chunk[:i], "\r")) > 0 {
+				lines += 1
+			}
+			empty, blank = true, true
+			chunk = chunk[i+1:]
+		}
+		if readErr == io.EOF {
+			break
+		}
+		if readErr != nil {
+			return 0, false, readErr
+		}
+	}
+	if (whitespace && !empty) || !blank {
+		lines += 1
+	}
+	return lines, false, nil
+}
+
+// blobLines counts the lines of the file like CountLines, streaming its blob
+func blobLines(f *object.File, whitespace bool) (int, bool, error) {
+	reader, err := f.Reader()
+	if err != nil {
+		return 0, false, err
+	}
+	defer reader.Close()
+	return CountLines(reader, whitespace)
+}
+
+// forEachLine passes the lines of the file, decoded like CountLines, to fn one at a time without their
+// line ending, split like object.File's Lines
+func forEachLine(f *object.File, fn func(line string)) error {
+	reader, err := f.Reader()
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+	buffered := bufio.NewReader(decodeText(reader))
+	for {
+		line, err := buffered.ReadString('\n')
+		if line != "" {
+			fn(strings.TrimSuffix(strings.TrimSuffix(line, "\n"), "\r"))
+		}
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
@@ -0,0 +1,62 @@
+package gitfuncs
+
+import (
+	"strings"
+	"testing"
+	"testing/iotest"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCountLines(t *testing.T) {
+	assert := assert.New(t)
+	cases := map[string][2]int{
+		"":               {0, 0},
+		"\n":             {1, 0},
+		"a":              {1, 1},
+		"a\n":            {1, 1},
+		"a\n\nb":         {3, 2},
+		"a\n\n\n":        {3, 1},
+		"  \n\r\n\tb\n":  {3, 2},
+		"a\nb\nc\n\nd\n": {5, 4},
+		// CRLF line endings count the same as LF
+		"a\r\n\r\nb\r\n": {3, 2},
+		"a\r\n\r":        {2, 1},
+		// Byte order marks are no content
+		"\xEF\xBB\xBFa\n": {1, 1},
+		"\xEF\xBB\xBF":    {0, 0},
+	}
+	for content, expected := range cases {
+		for whitespace, want := range map[bool]int{true: expected[0], false: expected[1]} {
+			// One byte at a time, the lines span the reads
+			lines, binary, err := CountLines(iotest.OneByteReader(strings.NewReader(content)), whitespace)
+			assert.Nil(err)
+			assert.False(binary)
+			assert.Equal(want, lines, "%q whitespace %v", content, whitespace)
+		}
+	}
+
+	long := strings.Repeat("line\n", 20000)
+	lines, _, err := CountLines(strings.NewReader(long), true)
+	assert.Nil(err)
+	assert.Equal(20000, lines)
+
+	// UTF-16 is decoded rather than binary
+	utf16LE := "\xFF\xFEa\x00\r\x00\n\x00\r\x00\n\x00=\xD8\x00\xDE\n\x00"
+	lines, binary, err := CountLines(strings.NewReader(utf16LE), false)
+	assert.Nil(err)
+	assert.False(binary)
+	assert.Equal(2, lines)
+	lines, _, err = CountLines(iotest.OneByteReader(strings.NewReader("\xFE\xFF\x00a\x00\n\x00\n\x00b")), true)
+	assert.Nil(err)
+	assert.Equal(3, lines)
+
+	_, binary, err = CountLines(strings.NewReader("PNG\x00\n\n"), true)
+	assert.Nil(err)
+	assert.True(binary)
+	// Like git, only the start of a file tells whether it is binary
+	lines, binary, err = CountLines(strings.NewReader(long+"\x00"), true)
+	assert.Nil(err)
+	assert.False(binary)
+	assert.Equal(20001, lines)
+}
@@ -0,0 +1,78 @@
+package gitfuncs
+
+import (
+	"sync"
+
+	"github.com/andymeneely/git-churn/lang"
+	"gopkg.in/src-d/go-git.v4/plumbing"
+	"gopkg.in/src-d/go-git.v4/plumbing/filemode"
+	"gopkg.in/src-d/go-git.v4/plumbing/object"
+)
+
+type locKey struct {
+	blob       plumbing.Hash
+	whitespace bool
+	// Language the lines of code are counted for, empty when counting all the lines
+	language string
+}
+
+// The same blob is shared by many trees of a history, so its line count is computed only once
+var locCache = struct {
+	sync.RWMutex
+	counts map[locKey]int
+}{counts: make(map[locKey]int)}
+
+// BlobLOC returns the number of lines in the given file. Blank lines are counted only if
+// whitespace is true, binary files and symbolic links have none. Counts are cached by blob hash
+// for the lifetime of the process.
+func BlobLOC(f *object.File, whitespace bool) int {
+	if f.Mode == filemode.Symlink {
+		return 0
+	}
+	key := locKey{blob: f.Hash, whitespace: whitespace}
+	locCache.RLock()
+	loc, ok := locCache.counts[key]
+	locCache.RUnlock()
+	if ok {
+		return loc
+	}
+
+	// Huge files are counted without being held in memory
+	loc, _, _ = blobLines(f, whitespace)
+
+	locCache.Lock()
+	locCache.counts[key] = loc
+	locCache.Unlock()
+	return loc
+}
+
+// BlobCodeLOC returns the number of lines of code in the given file, comments and blank lines excluded,
+// based on the language detected from its name. Binary files and symbolic links have none. Counts are
+// cached like BlobLOC.
+func BlobCodeLOC(f *object.File) int {
+	if f.Mode == filemode.Symlink {
+		return 0
+	}
+	language := lang.Detect(f.Name)
+	key := locKey{blob: f.Hash, language: language}
+	locCache.RLock()
+	loc, ok := locCache.counts[key]
+	locCache.RUnlock()
+	if ok {
+		return loc
+	}
+
+	if binary, _ := isTextBinary(f); !binary {
+		tracker := lang.NewCodeTracker(language)
+		forEachLine(f, func(line string) {
+			if tracker.IsCode(line) {
+				loc += 1
+			}
+		})
+	}
+
+	locCache.Lock()
+	locCache.counts[key] = loc
+	locCache.Unlock()
+	return loc
+}
@@ -0,0 +1,232 @@
+package gitfuncs
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+
+	. "github.com/andymeneely/git-churn/print"
+	"gopkg.in/src-d/go-git.v4"
+	"gopkg.in/src-d/go-git.v4/plumbing"
+	"gopkg.in/src-d/go-git.v4/plumbing/object"
+)
+
+// Mailmap maps the names and emails authors committed with to their canonical identity, in the format of
+// git's .mailmap (see gitmailmap(5)). Emails are matched case insensitively. A nil Mailmap maps every
+// identity to itself.
+type Mailmap struct {
+	// Canonical identities by commit email, and by commit email and name for the entries giving both
+	byEmail map[string]mailmapEntry
+	byName  map[mailmapKey]mailmapEntry
+}
+
+// MailmapOverride, when set, maps the authors of every repository instead of their own .mailmap
+var MailmapOverride *Mailmap
+
+// The mailmap of the repository last looked at, so that it is read once per analysis, and the canonical
+// emails of the authors of its commits resolved with it
+var repoMailmap = struct {
+	sync.Mutex
+	repo    *git.Repository
+	mailmap *Mailmap
+	// Emails by commit, and the mailmap they were resolved with
+	authors        map[plumbing.Hash]string
+	authorsMailmap *Mailmap
+}{}
+
+type mailmapKey struct {
+	email, name string
+}
+
+// mailmapEntry is a canonical identity, an empty field keeping the one committed with
+type mailmapEntry struct {
+	name, email string
+}
+
+// ParseMailmap parses a mailmap, made of lines in any of the forms
+//
+//	Proper Name <commit@email>
+//	<proper@email> <commit@email>
+//	Proper Name <proper@email> <commit@email>
+//	Proper Name <proper@email> Commit Name <commit@email>
+//
+// Blank lines and comments starting with # are ignored.
+func ParseMailmap(r io.Reader) (*Mailmap, error) {
+	m := &Mailmap{byEmail: make(map[string]mailmapEntry), byName: make(map[mailmapKey]mailmapEntry)}
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if i := strings.Index(line, "#"); i >= 0 {
+			line = line[:i]
+		}
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		var names, emails []string
+		for rest := line; ; {
+			open := strings.Index(rest, "<")
+			if open < 0 {
+				if strings.TrimSpace(rest) != "" {
+					return nil, fmt.Errorf("invalid mailmap line %q", line)
+				}
+				break
+			}
+			end := strings.Index(rest[open:], ">")
+			if end < 0 {
+				return nil, fmt.Errorf("invalid mailmap line %q", line)
+			}
+			names = append(names, strings.TrimSpace(rest[:open]))
+			emails = append(emails, strings.TrimSpace(rest[open+1:open+end]))
+			rest = rest[open+end+1:]
+		}
+		switch len(emails) {
+		case 1:
+			m.byEmail[strings.ToLower(emails[0])] = mailmapEntry{name: names[0]}
+		case 2:
+			entry := mailmapEntry{name: names[0], email: emails[0]}
+			if names[1] != "" {
+				m.byName[mailmapKey{strings.ToLower(emails[1]), names[1]}] = entry
+			} else {
+				m.byEmail[strings.ToLower(emails[1])] = entry
+			}
+		default:
+			return nil, fmt.Errorf("invalid mailmap line %q", line)
+		}
+	}
+	return m, scanner.Err()
+}
+
+// ReadMailmapFile reads the mailmap file at the given path, see ParseMailmap
+func ReadMailmapFile(path string) (*Mailmap, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return ParseMailmap(f)
+}
+
+// Resolve returns the canonical name and email of the identity an author committed with
+func (m *Mailmap) Resolve(name, email string) (string, string) {
+	if m == nil {
+		return name, email
+	}
+	entry, ok := m.byName[mailmapKey{strings.ToLower(email), name}]
+	if !ok {
+		entry, ok = m.byEmail[strings.ToLower(email)]
+	}
+	if !ok {
+		return name, email
+	}
+	if entry.name != "" {
+		name = entry.name
+	}
+	if entry.email != "" {
+		email = entry.email
+	}
+	return name, email
+}
+
+// RepoMailmap returns the mailmap the authors of the repository are resolved with: MailmapOverride if set,
+// or else the .mailmap at the root of its HEAD, nil if it has none
+func RepoMailmap(repo *git.Repository) *Mailmap {
+	if MailmapOverride != nil {
+		return MailmapOverride
+	}
+	repoMailmap.Lock()
+	defer repoMailmap.Unlock()
+	return loadRepoMailmap(repo)
+}
+
+// loadRepoMailmap reads the mailmap of the repository unless it is the one last looked at, repoMailmap
+// being locked
+func loadRepoMailmap(repo *git.Repository) *Mailmap {
+	if repoMailmap.repo != repo {
+		repoMailmap.repo, repoMailmap.mailmap = repo, readRepoMailmap(repo)
+		repoMailmap.authors = nil
+	}
+	return repoMailmap.mailmap
+}
+
+// ForgetMailmap drops the mailmap read for the repository, so that it is read again from its new HEAD,
+// e.g. after a fetch of the long-lived clones of the server
+func ForgetMailmap(repo *git.Repository) {
+	repoMailmap.Lock()
+	defer repoMailmap.Unlock()
+	if repoMailmap.repo == repo {
+		repoMailmap.repo, repoMailmap.mailmap, repoMailmap.authors = nil, nil, nil
+	}
+}
+
+func readRepoMailmap(repo *git.Repository) *Mailmap {
+	head, err := repo.Head()
+	if err != nil {
+		return nil
+	}
+	commit, err := repo.CommitObject(head.Hash())
+	if err != nil {
+		return nil
+	}
+	file, err := commit.File(".mailmap")
+	if err != nil {
+		return nil
+	}
+	reader, err := file.Reader()
+	if err != nil {
+		return nil
+	}
+	defer reader.Close()
+	mailmap, err := ParseMailmap(reader)
+	if err != nil {
+		Warning("ignoring the .mailmap of the repository: %v", err)
+		return nil
+	}
+	return mailmap
+}
+
+// ResolveAuthor returns the canonical name and email of the author of a commit of the repository
+func ResolveAuthor(repo *git.Repository, author object.Signature) (string, string) {
+	return RepoMailmap(repo).Resolve(author.Name, author.Email)
+}
+
+// ResolveEmail returns the canonical email of an author of the repository known by email only. The mailmap
+// entries that also match on the name do not apply, ResolveAuthor and ResolveLineAuthor should be preferred
+// when the commit is known.
+func ResolveEmail(repo *git.Repository, email string) string {
+	_, email = RepoMailmap(repo).Resolve("", email)
+	return email
+}
+
+// ResolveLineAuthor returns the canonical email of the author of a blamed line, resolved with their name and
+// email from the commit that wrote the line like ResolveAuthor, so that the metrics keyed by the authors of
+// blamed lines and those keyed by the authors of commits agree. The emails are cached per commit.
+func ResolveLineAuthor(repo *git.Repository, line *git.Line) string {
+	repoMailmap.Lock()
+	mailmap := loadRepoMailmap(repo)
+	if MailmapOverride != nil {
+		mailmap = MailmapOverride
+	}
+	if repoMailmap.authors == nil || repoMailmap.authorsMailmap != mailmap {
+		repoMailmap.authors, repoMailmap.authorsMailmap = make(map[plumbing.Hash]string), mailmap
+	}
+	email, ok := repoMailmap.authors[line.Hash]
+	repoMailmap.Unlock()
+	if ok {
+		return email
+	}
+	commit, err := repo.CommitObject(line.Hash)
+	if err != nil {
+		_, email = mailmap.Resolve("", line.Author)
+		return email
+	}
+	_, email = mailmap.Resolve(commit.Author.Name, commit.Author.Email)
+	repoMailmap.Lock()
+	if repoMailmap.repo == repo && repoMailmap.authorsMailmap == mailmap && repoMailmap.authors != nil {
+		repoMailmap.authors[line.Hash] = email
+	}
+	repoMailmap.Unlock()
+	return email
+}
@@ -0,0 +1,90 @@
+package gitfuncs
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/andymeneely/git-churn/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMailmap(t *testing.T) {
+	assert := assert.New(t)
+	mailmap, err := ParseMailmap(strings.NewReader(`
+# Proper name only
+Alice Doe <alice@example.com>
+<bob@example.com> <bob@laptop.local>
+Carol Roe <carol@example.com> <CRoe@Old.example.com>
+Dan Poe <dan@example.com> dan <shared@example.com>   # only dan's commits with the shared email
+`))
+	assert.Nil(err)
+
+	cases := [][4]string{
+		{"alice", "alice@example.com", "Alice Doe", "alice@example.com"},
+		{"Bob", "bob@laptop.local", "Bob", "bob@example.com"},
+		{"carol", "croe@old.example.com", "Carol Roe", "carol@example.com"},
+		{"dan", "shared@example.com", "Dan Poe", "dan@example.com"},
+		{"erin", "shared@example.com", "erin", "shared@example.com"},
+		{"frank", "frank@example.com", "frank", "frank@example.com"},
+	}
+	for _, c := range cases {
+		name, email := mailmap.Resolve(c[0], c[1])
+		assert.Equal([2]string{c[2], c[3]}, [2]string{name, email}, c[1])
+	}
+
+	var none *Mailmap
+	name, email := none.Resolve("alice", "alice@example.com")
+	assert.Equal("alice", name)
+	assert.Equal("alice@example.com", email)
+
+	_, err = ParseMailmap(strings.NewReader("Alice <alice@example.com"))
+	assert.NotNil(err)
+	_, err = ParseMailmap(strings.NewReader("Alice"))
+	assert.NotNil(err)
+}
+
+func TestRepoMailmap(t *testing.T) {
+	assert := assert.New(t)
+	builder := testutil.NewRepo(t)
+	builder.CommitFiles("commit", map[string]string{"a.txt": "1\n"})
+	bob := builder.As("bob").CommitFiles("commit", map[string]string{"a.txt": "2\n"})
+	builder.CommitFiles("mailmap", map[string]string{".mailmap": "Bob Roe <bob@example.org> <bob@example.com>\n"})
+	repo := builder.Repository
+
+	name, email := ResolveAuthor(repo, bob.Author)
+	assert.Equal("Bob Roe", name)
+	assert.Equal("bob@example.org", email)
+	assert.Equal("bob@example.org", ResolveEmail(repo, "bob@example.com"))
+	assert.Equal("alice@example.com", ResolveEmail(repo, "alice@example.com"))
+
+	override, err := ParseMailmap(strings.NewReader("<alice@example.org> <alice@example.com>"))
+	assert.Nil(err)
+	MailmapOverride = override
+	defer func() { MailmapOverride = nil }()
+	assert.Equal("alice@example.org", ResolveEmail(repo, "alice@example.com"))
+	assert.Equal("bob@example.com", ResolveEmail(repo, "bob@example.com"))
+}
+
+// TestResolveLineAuthor checks that the authors of blamed lines are resolved with their name, like the
+// authors of commits, and that the mailmap is read again once forgotten
+func TestResolveLineAuthor(t *testing.T) {
+	assert := assert.New(t)
+	builder := testutil.NewRepo(t)
+	bob := builder.As("bob").CommitFiles("commit", map[string]string{"a.txt": "1\n"})
+	builder.CommitFiles("mailmap", map[string]string{".mailmap": "Bob Roe <bob@example.org> bob <bob@example.com>\n"})
+	repo := builder.Repository
+
+	hash := builder.Head().Hash
+	blame, err := Blame(repo, &hash, "a.txt")
+	assert.Nil(err)
+	_, email := ResolveAuthor(repo, bob.Author)
+	assert.Equal("bob@example.org", email)
+	assert.Equal(email, ResolveLineAuthor(repo, blame.Lines[0]))
+	// Known by email only, the entry matching on the name does not apply
+	assert.Equal("bob@example.com", ResolveEmail(repo, "bob@example.com"))
+
+	builder.CommitFiles("mailmap", map[string]string{".mailmap": "Bob Doe <bob@example.net> bob <bob@example.com>\n"})
+	assert.Equal("bob@example.org", ResolveLineAuthor(repo, blame.Lines[0]))
+	ForgetMailmap(repo)
+	assert.Equal("bob@example.net", ResolveLineAuthor(repo, blame.Lines[0]))
+}
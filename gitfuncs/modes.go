@@ -0,0 +1,67 @@
+package gitfuncs
+
+import (
+	"gopkg.in/src-d/go-git.v4/plumbing/filemode"
+	"gopkg.in/src-d/go-git.v4/plumbing/object"
+)
+
+// ModeChange is a change to the mode of a file, e.g. made executable, or a change to a symbolic link,
+// whose content is the path it points at rather than lines
+type ModeChange struct {
+	Path string
+	// Modes of the file before and after, filemode.Empty when added and deleted respectively
+	OldMode filemode.FileMode
+	NewMode filemode.FileMode
+	// Paths the symbolic link pointed at before and after, empty when not a link
+	OldTarget string
+	NewTarget string
+}
+
+// Symlink tells whether the file is a symbolic link before or after the change
+func (c ModeChange) Symlink() bool {
+	return c.OldMode == filemode.Symlink || c.NewMode == filemode.Symlink
+}
+
+// ModeChanged tells whether the mode of a file present before and after the change changed
+func (c ModeChange) ModeChanged() bool {
+	return c.OldMode != filemode.Empty && c.NewMode != filemode.Empty && c.OldMode != c.NewMode
+}
+
+// ModeChanges returns the changes to symbolic links and to the mode of the files, by path. A file
+// whose content changed along with its mode is in both the patch and the mode changes.
+func ModeChanges(changes object.Changes) (map[string]ModeChange, error) {
+	modes := make(map[string]ModeChange)
+	for _, change := range changes {
+		modeChange := ModeChange{Path: changePath(change), OldMode: change.From.TreeEntry.Mode, NewMode: change.To.TreeEntry.Mode}
+		if !modeChange.Symlink() && !modeChange.ModeChanged() {
+			continue
+		}
+		from, to, err := change.Files()
+		if err != nil {
+			return nil, err
+		}
+		if modeChange.OldMode == filemode.Symlink && from != nil {
+			if modeChange.OldTarget, err = from.Contents(); err != nil {
+				return nil, err
+			}
+		}
+		if modeChange.NewMode == filemode.Symlink && to != nil {
+			if modeChange.NewTarget, err = to.Contents(); err != nil {
+				return nil, err
+			}
+		}
+		modes[modeChange.Path] = modeChange
+	}
+	return modes, nil
+}
+
+// WithoutSymlinks drops the changes to symbolic links, which have no lines to churn
+func WithoutSymlinks(changes object.Changes) object.Changes {
+	var kept object.Changes
+	for _, change := range changes {
+		if change.From.TreeEntry.Mode != filemode.Symlink && change.To.TreeEntry.Mode != filemode.Symlink {
+			kept = append(kept, change)
+		}
+	}
+	return kept
+}
@@ -0,0 +1,46 @@
+package gitfuncs
+
+import (
+	"testing"
+
+	"github.com/andymeneely/git-churn/testutil"
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/src-d/go-git.v4/plumbing/filemode"
+	"gopkg.in/src-d/go-git.v4/plumbing/object"
+)
+
+func TestModeChanges(t *testing.T) {
+	assert := assert.New(t)
+	repo := testutil.NewRepo(t)
+	repo.CommitFiles("first", map[string]string{"run.sh": "echo 1\n", "a.txt": "1\n"})
+	repo.Symlink("latest", "a.txt").Commit("link")
+	from := repo.Head()
+	to := repo.WriteMode("run.sh", "echo 2\n", 0755).Symlink("latest", "run.sh").Commit("executable")
+
+	fromTree, err := from.Tree()
+	assert.Nil(err)
+	toTree, err := to.Tree()
+	assert.Nil(err)
+	changes, err := object.DiffTree(fromTree, toTree)
+	assert.Nil(err)
+	modes, err := ModeChanges(changes)
+	assert.Nil(err)
+	assert.Equal(map[string]ModeChange{
+		"run.sh": {Path: "run.sh", OldMode: filemode.Regular, NewMode: filemode.Executable},
+		"latest": {Path: "latest", OldMode: filemode.Symlink, NewMode: filemode.Symlink, OldTarget: "a.txt", NewTarget: "run.sh"},
+	}, modes)
+	assert.True(modes["run.sh"].ModeChanged())
+	assert.False(modes["latest"].ModeChanged())
+	assert.True(modes["latest"].Symlink())
+	assert.Equal(1, len(WithoutSymlinks(changes)))
+
+	// The content of a symbolic link is no lines
+	patch, err := CommitChurnPatch(to)
+	assert.Nil(err)
+	assert.True(patch.File("latest").Symlink)
+	assert.Nil(patch.File("latest").Hunks)
+	assert.Equal(1, patch.Count(LineAdded, true))
+	stats, err := CommitStats(to)
+	assert.Nil(err)
+	assert.Equal(1, len(stats))
+}
@@ -0,0 +1,214 @@
+package gitfuncs
+
+import (
+	"path"
+	"sort"
+	"strings"
+
+	"gopkg.in/src-d/go-git.v4/plumbing/filemode"
+	"gopkg.in/src-d/go-git.v4/plumbing/object"
+	"gopkg.in/src-d/go-git.v4/utils/merkletrie"
+)
+
+// DetectDirMoves pairs the files of the directories moved as a whole, e.g. src/a to src/b, so that only the
+// lines edited along with the move are churn rather than all the lines of the directory being deleted and
+// added. It applies to the line counts of the commits and to their patches.
+var DetectDirMoves bool
+
+// DirMove is a directory moved as a whole, the directory it moved from being gone and the directory it
+// moved to being new
+type DirMove struct {
+	From string
+	To   string
+	// Files moved along, to the same path in the new directory
+	Files int
+}
+
+func (m DirMove) String() string {
+	return "moved " + m.From + " → " + m.To
+}
+
+// DirMoves returns the directories moved between the two trees, see pairDirMoves, a nil tree standing for
+// the empty tree
+func DirMoves(from, to *object.Tree) ([]DirMove, error) {
+	changes, err := diffTree(from, to)
+	if err != nil {
+		return nil, err
+	}
+	_, moves, err := pairDirMoves(from, to, changes)
+	return moves, err
+}
+
+// CommitDirMoves returns the directories the commit moved, against its first parent
+func CommitDirMoves(commit *object.Commit) ([]DirMove, error) {
+	if commit.NumParents() == 0 {
+		return nil, nil
+	}
+	parent, err := commit.Parent(0)
+	if err != nil {
+		return nil, err
+	}
+	parentTree, err := parent.Tree()
+	if err != nil {
+		return nil, err
+	}
+	tree, err := commit.Tree()
+	if err != nil {
+		return nil, err
+	}
+	return DirMoves(parentTree, tree)
+}
+
+// diffTreeMoves is diffTree pairing the files of the moved directories when DetectDirMoves is set
+func diffTreeMoves(from, to *object.Tree) (object.Changes, error) {
+	changes, err := diffTree(from, to)
+	if err != nil || !DetectDirMoves {
+		return changes, err
+	}
+	changes, _, err = pairDirMoves(from, to, changes)
+	return changes, err
+}
+
+// pairDirMoves finds the directories moved between the trees, those of the `from` tree gone from the `to`
+// tree whose files were added under a directory new in the `to` tree at the same relative path, the
+// longest relative path winning, and makes every file deleted and added along a single change from its old
+// path to its new one
+func pairDirMoves(from, to *object.Tree, changes object.Changes) (object.Changes, []DirMove, error) {
+	if from == nil || to == nil {
+		return changes, nil, nil
+	}
+	deleted := make(map[string]*object.Change)
+	byName := make(map[string][]string)
+	var added []*object.Change
+	for _, change := range changes {
+		action, err := change.Action()
+		if err != nil {
+			return nil, nil, err
+		}
+		switch {
+		case action == merkletrie.Delete && change.From.TreeEntry.Mode != filemode.Submodule:
+			deleted[change.From.Name] = change
+			name := path.Base(change.From.Name)
+			byName[name] = append(byName[name], change.From.Name)
+		case action == merkletrie.Insert && change.To.TreeEntry.Mode != filemode.Submodule:
+			added = append(added, change)
+		}
+	}
+	if len(deleted) == 0 || len(added) == 0 {
+		return changes, nil, nil
+	}
+
+	checked := make(map[[2]string]bool)
+	isMove := func(fromDir, toDir string) (bool, error) {
+		m := [2]string{fromDir, toDir}
+		if ok, seen := checked[m]; seen {
+			return ok, nil
+		}
+		ok, err := movedDir(from, to, fromDir, toDir)
+		checked[m] = ok
+		return ok, err
+	}
+	// The files deleted each added file may have moved from, the moves supported by the most files winning,
+	// e.g. over a file of the same name deleted from an unrelated directory
+	type candidate struct {
+		oldPath string
+		move    [2]string
+	}
+	candidates := make(map[*object.Change][]candidate)
+	votes := make(map[[2]string]int)
+	for _, change := range added {
+		newPath := change.To.Name
+		for _, oldPath := range byName[path.Base(newPath)] {
+			m, found, err := commonMove(oldPath, newPath, isMove)
+			if err != nil {
+				return nil, nil, err
+			}
+			if found {
+				candidates[change] = append(candidates[change], candidate{oldPath, m})
+				votes[m] += 1
+			}
+		}
+	}
+	moved := make(map[[2]string]int)
+	pairs := make(map[*object.Change]*object.Change)
+	paired := make(map[string]bool)
+	for _, change := range added {
+		var best *candidate
+		for i, c := range candidates[change] {
+			if !paired[c.oldPath] && (best == nil || votes[c.move] > votes[best.move]) {
+				best = &candidates[change][i]
+			}
+		}
+		if best == nil {
+			continue
+		}
+		moved[best.move] += 1
+		pairs[change] = deleted[best.oldPath]
+		paired[best.oldPath] = true
+	}
+	if len(pairs) == 0 {
+		return changes, nil, nil
+	}
+
+	var kept object.Changes
+	for _, change := range changes {
+		if change.To.Name == "" && paired[change.From.Name] {
+			continue
+		}
+		if deletion, ok := pairs[change]; ok {
+			change = &object.Change{From: deletion.From, To: change.To}
+		}
+		kept = append(kept, change)
+	}
+	var moves []DirMove
+	for m, files := range moved {
+		moves = append(moves, DirMove{From: m[0], To: m[1], Files: files})
+	}
+	sort.Slice(moves, func(i, j int) bool {
+		return moves[i].From < moves[j].From
+	})
+	return kept, moves, nil
+}
+
+// commonMove returns the directories the file at the old path and the file at the new path are in below
+// their common relative path, trying the longest relative path first, when isMove tells they are a moved directory
+func commonMove(oldPath, newPath string, isMove func(fromDir, toDir string) (bool, error)) ([2]string, bool, error) {
+	oldParts, newParts := strings.Split(oldPath, "/"), strings.Split(newPath, "/")
+	common := 0
+	for common < len(oldParts) && common < len(newParts) &&
+		oldParts[len(oldParts)-1-common] == newParts[len(newParts)-1-common] {
+		common++
+	}
+	for suffix := common; suffix >= 1; suffix-- {
+		fromDir := strings.Join(oldParts[:len(oldParts)-suffix], "/")
+		toDir := strings.Join(newParts[:len(newParts)-suffix], "/")
+		if fromDir == "" || toDir == "" {
+			continue
+		}
+		ok, err := isMove(fromDir, toDir)
+		if err != nil || ok {
+			return [2]string{fromDir, toDir}, ok, err
+		}
+	}
+	return [2]string{}, false, nil
+}
+
+// movedDir tells whether the directory at `fromDir` in the `from` tree is gone from the `to` tree, and the
+// directory at `toDir` in the `to` tree is new
+func movedDir(from, to *object.Tree, fromDir, toDir string) (bool, error) {
+	for _, check := range []struct {
+		tree *object.Tree
+		path string
+		dir  bool
+	}{{from, fromDir, true}, {to, fromDir, false}, {from, toDir, false}, {to, toDir, true}} {
+		entry, err := check.tree.FindEntry(check.path)
+		if err != nil && err != object.ErrEntryNotFound && err != object.ErrDirectoryNotFound {
+			return false, err
+		}
+		isDir := err == nil && entry.Mode == filemode.Dir
+		if check.dir != isDir || (!check.dir && err == nil) {
+			return false, nil
+		}
+	}
+	return true, nil
+}
@@ -0,0 +1,55 @@
+package gitfuncs
+
+import (
+	"testing"
+
+	"github.com/andymeneely/git-churn/testutil"
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/src-d/go-git.v4/plumbing/object"
+)
+
+func TestDirMoves(t *testing.T) {
+	assert := assert.New(t)
+	repo := testutil.NewRepo(t)
+	repo.CommitFiles("root", map[string]string{
+		"src/a/x.go": "1\n2\n3\n", "src/a/sub/y.go": "1\n2\n", "src/keep.go": "k\n", "other/x.go": "o\n",
+		"lib/one.go": "1\n", "lib/two.go": "2\n",
+	})
+	move := repo.Rename("src/a/x.go", "src/b/x.go").Rename("src/a/sub/y.go", "src/b/sub/y.go").
+		WriteFiles(map[string]string{"src/b/x.go": "1\n2\nthree\n", "src/b/new.go": "n\n"}).
+		Delete("other/x.go").
+		// Not a directory move, lib is still there
+		Rename("lib/one.go", "pkg/one.go").
+		Commit("move")
+
+	moves, err := CommitDirMoves(move)
+	assert.Nil(err)
+	assert.Equal([]DirMove{{From: "src/a", To: "src/b", Files: 2}}, moves)
+	assert.Equal("moved src/a → src/b", moves[0].String())
+
+	stats, err := CommitStats(move)
+	assert.Nil(err)
+	assert.Len(stats, 8)
+
+	DetectDirMoves = true
+	defer func() { DetectDirMoves = false }()
+	stats, err = CommitStats(move)
+	assert.Nil(err)
+	assert.ElementsMatch(object.FileStats{
+		{Name: "src/b/x.go", Addition: 1, Deletion: 1},
+		{Name: "src/b/sub/y.go"},
+		{Name: "src/b/new.go", Addition: 1},
+		{Name: "other/x.go", Deletion: 1},
+		{Name: "lib/one.go", Deletion: 1},
+		{Name: "pkg/one.go", Addition: 1},
+	}, stats)
+
+	patch, err := CommitChurnPatch(move)
+	assert.Nil(err)
+	file := patch.File("src/b/x.go")
+	assert.Equal("src/a/x.go", file.OldPath)
+	assert.Equal([]int{3}, file.LineNumbers(LineDeleted, true))
+
+	changes, _, _ := CommitDiff(repo.Repository)
+	assert.Len(*changes, 6)
+}
@@ -0,0 +1,96 @@
+package gitfuncs
+
+import (
+	. "github.com/andymeneely/git-churn/print"
+	"gopkg.in/src-d/go-git.v4"
+	"gopkg.in/src-d/go-git.v4/plumbing"
+	"gopkg.in/src-d/go-git.v4/plumbing/cache"
+	"gopkg.in/src-d/go-git.v4/plumbing/object"
+	"gopkg.in/src-d/go-git.v4/storage/filesystem"
+)
+
+// ObjectCacheSize is the size in bytes of the cache of the objects the clones on disk read from their
+// packfiles, so that the objects looked up again are not read and their deltas resolved again: go-git's
+// default of 96MB if 0, bounded by MaxMemory
+var ObjectCacheSize int64
+
+// Prefetch makes the range walks resolve the objects of the range into the object cache first, see
+// PrefetchRange
+var Prefetch bool
+
+// objectCache returns the cache of the objects of a clone on disk, sized by ObjectCacheSize and MaxMemory
+func objectCache() *cache.ObjectLRU {
+	size := cache.DefaultMaxSize
+	if ObjectCacheSize > 0 {
+		size = cache.FileSize(ObjectCacheSize)
+	}
+	if MaxMemory > 0 && cache.FileSize(MaxMemory/memoryPerPackByte) < size {
+		size = cache.FileSize(MaxMemory / memoryPerPackByte)
+	}
+	return cache.NewObjectLRU(size)
+}
+
+// PrefetchStats counts the objects PrefetchRange resolved
+type PrefetchStats struct {
+	Commits int
+	// Files changed by the commits against their first parent, whose trees were resolved
+	Files int
+	// Versions of the files changed, and their size in bytes
+	Blobs     int
+	BlobBytes int64
+}
+
+// PrefetchRange resolves the objects an upcoming walk of the commits reachable from `to` but not from
+// `from` reads into the object cache of a clone on disk, in a single pass: the commits, the trees they
+// changed against their first parent and, with blobs, both versions of the files they changed, which
+// the churn diffs read. The objects stay cached as long as the cache holds them, so ObjectCacheSize
+// should be sized to the range. The clones in memory hold every object already and are left alone.
+func PrefetchRange(r *git.Repository, from, to plumbing.Hash, blobs bool) (PrefetchStats, error) {
+	var stats PrefetchStats
+	if _, ok := r.Storer.(*filesystem.Storage); !ok {
+		return stats, nil
+	}
+	err := ForEachCommitBetween(r, from, to, func(commit *object.Commit) error {
+		stats.Commits += 1
+		tree, err := commit.Tree()
+		if err != nil {
+			return err
+		}
+		var parentTree *object.Tree
+		if commit.NumParents() > 0 {
+			parent, err := commit.Parent(0)
+			if err != nil {
+				return err
+			}
+			if parentTree, err = parent.Tree(); err != nil {
+				return err
+			}
+		}
+		changes, err := diffTree(parentTree, tree)
+		if err != nil {
+			return err
+		}
+		stats.Files += len(changes)
+		if !blobs {
+			return nil
+		}
+		for _, change := range changes {
+			for _, entry := range []object.ChangeEntry{change.From, change.To} {
+				if entry.Name == "" || !entry.TreeEntry.Mode.IsFile() {
+					continue
+				}
+				blob, err := r.Storer.EncodedObject(plumbing.BlobObject, entry.TreeEntry.Hash)
+				if err != nil {
+					return err
+				}
+				stats.Blobs += 1
+				stats.BlobBytes += blob.Size()
+			}
+		}
+		return nil
+	})
+	if err == nil {
+		Debug("prefetched %d commits, %d files changed and %d blobs (%d bytes)", stats.Commits, stats.Files, stats.Blobs, stats.BlobBytes)
+	}
+	return stats, err
+}
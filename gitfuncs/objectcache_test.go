@@ -0,0 +1,52 @@
+package gitfuncs
+
+import (
+	"testing"
+
+	"github.com/andymeneely/git-churn/testutil"
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/src-d/go-git.v4/plumbing"
+	"gopkg.in/src-d/go-git.v4/plumbing/cache"
+)
+
+func TestObjectCache(t *testing.T) {
+	assert := assert.New(t)
+	defer func() { ObjectCacheSize, MaxMemory = 0, 0 }()
+
+	assert.Equal(cache.DefaultMaxSize, objectCache().MaxSize)
+	ObjectCacheSize = 512 << 20
+	assert.Equal(512*cache.MiByte, objectCache().MaxSize)
+	// Bounded by the memory the analysis stays under
+	MaxMemory = 64 * memoryPerPackByte << 20
+	assert.Equal(64*cache.MiByte, objectCache().MaxSize)
+}
+
+func TestPrefetchRange(t *testing.T) {
+	assert := assert.New(t)
+	origin := testutil.NewDiskRepo(t)
+	defer origin.Remove()
+	origin.CommitFiles("first", map[string]string{"a.txt": "1\n", "b.txt": "1\n"})
+	first := origin.Head().Hash
+	origin.CommitFiles("second", map[string]string{"a.txt": "1\n2\n"})
+	origin.CommitFiles("third", map[string]string{"c.txt": "1\n"})
+	defer func() { Storage = StorageMemory }()
+
+	// The clones in memory hold every object already
+	repo, err := CloneRepository(origin.Dir)
+	assert.Nil(err)
+	stats, err := PrefetchRange(repo, plumbing.ZeroHash, origin.Head().Hash, true)
+	assert.Nil(err)
+	assert.Equal(PrefetchStats{}, stats)
+
+	Storage = StorageDisk
+	defer RemoveTempClones()
+	repo, err = CloneRepository(origin.Dir)
+	assert.Nil(err)
+	stats, err = PrefetchRange(repo, first, origin.Head().Hash, true)
+	assert.Nil(err)
+	// a.txt before and after, and the new c.txt
+	assert.Equal(PrefetchStats{Commits: 2, Files: 2, Blobs: 3, BlobBytes: 8}, stats)
+	stats, err = PrefetchRange(repo, plumbing.ZeroHash, origin.Head().Hash, false)
+	assert.Nil(err)
+	assert.Equal(PrefetchStats{Commits: 3, Files: 4}, stats)
+}
@@ -0,0 +1,253 @@
+package gitfuncs
+
+import (
+	"container/heap"
+	"errors"
+	"io"
+	"sort"
+
+	"github.com/andymeneely/git-churn/helper"
+	"gopkg.in/src-d/go-git.v4"
+	"gopkg.in/src-d/go-git.v4/plumbing"
+	"gopkg.in/src-d/go-git.v4/plumbing/object"
+	"gopkg.in/src-d/go-git.v4/plumbing/storer"
+)
+
+// FirstParent makes the walks of the history follow only the first parent of the merges, like
+// git log --first-parent, so that a merged branch counts once by the diff of its merge commit
+// instead of commit by commit
+var FirstParent bool
+
+// CommitOrder is the order commits are listed in
+type CommitOrder int
+
+const (
+	// OrderCommitterTime lists the newest committer time first, like git rev-list --date-order without
+	// the guarantee that children come before their parents when the clocks of the committers are skewed
+	OrderCommitterTime CommitOrder = iota
+	// OrderTopological lists no parent before all its children, newest committer time first otherwise,
+	// like git rev-list --topo-order
+	OrderTopological
+)
+
+// RevListOptions tell RevListWithOptions how to order the commits and bound the range
+type RevListOptions struct {
+	Order CommitOrder
+	// IncludeEnd lists the end commit too, which bounds the range exclusively otherwise
+	IncludeEnd bool
+}
+
+// MergeBase returns the best common ancestor of the two commits, like git merge-base
+func MergeBase(r *git.Repository, a, b plumbing.Hash) (*object.Commit, error) {
+	commitA, err := r.CommitObject(a)
+	if err != nil {
+		return nil, err
+	}
+	commitB, err := r.CommitObject(b)
+	if err != nil {
+		return nil, err
+	}
+	bases, err := commitA.MergeBase(commitB)
+	if err != nil {
+		return nil, err
+	}
+	if len(bases) == 0 {
+		return nil, errors.New("the commits " + a.String() + " and " + b.String() + " have no common ancestor")
+	}
+	return bases[0], nil
+}
+
+// CommitsBetween returns the commits reachable from `to` but not from `from`, like git log from..to,
+// newest first. A zero `from` hash returns the whole history of `to`.
+func CommitsBetween(r *git.Repository, from, to plumbing.Hash) ([]*object.Commit, error) {
+	var commits []*object.Commit
+	err := walkCommitsBetween(r, from, to, func(c *object.Commit) error {
+		commits = append(commits, c)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.SliceStable(commits, func(i, j int) bool { return commits[i].Committer.When.After(commits[j].Committer.When) })
+	return commits, nil
+}
+
+// ForEachCommitBetween calls fn on the commits of CommitsBetween one at a time, in committer time order,
+// without loading them all first. Returning storer.ErrStop from fn stops the walk without error. When
+// helper.OnProgress is set, the commits are counted first to report the progress of the walk.
+func ForEachCommitBetween(r *git.Repository, from, to plumbing.Hash, fn func(*object.Commit) error) error {
+	if helper.OnProgress == nil {
+		return walkCommitsBetween(r, from, to, fn)
+	}
+	total := 0
+	err := walkCommitsBetween(r, from, to, func(*object.Commit) error {
+		total += 1
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	progress := helper.TrackProgress("commits", total)
+	defer progress.Finish()
+	return walkCommitsBetween(r, from, to, func(c *object.Commit) error {
+		defer progress.Step()
+		return fn(c)
+	})
+}
+
+// walkCommitsBetween is ForEachCommitBetween without the progress
+func walkCommitsBetween(r *git.Repository, from, to plumbing.Hash, fn func(*object.Commit) error) error {
+	// Only the hashes of the excluded history are kept in memory
+	excluded := make(map[plumbing.Hash]bool)
+	if !from.IsZero() {
+		fromCommit, err := r.CommitObject(from)
+		if err != nil {
+			return err
+		}
+		err = object.NewCommitPreorderIter(fromCommit, nil, nil).ForEach(func(c *object.Commit) error {
+			excluded[c.Hash] = true
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	toCommit, err := r.CommitObject(to)
+	if err != nil {
+		return err
+	}
+	if FirstParent {
+		return newFirstParentIter(toCommit, excluded).ForEach(fn)
+	}
+	// The excluded commits are passed as already seen so that their history is not walked again
+	return object.NewCommitIterCTime(toCommit, excluded, nil).ForEach(fn)
+}
+
+// LogCommits iterates over the history of the commit, newest first in committer time order, or along the
+// first parents when FirstParent is set
+func LogCommits(r *git.Repository, from plumbing.Hash) (object.CommitIter, error) {
+	if !FirstParent {
+		return r.Log(&git.LogOptions{From: from, Order: git.LogOrderCommitterTime})
+	}
+	commit, err := r.CommitObject(from)
+	if err != nil {
+		return nil, err
+	}
+	return newFirstParentIter(commit, nil), nil
+}
+
+// firstParentIter walks from a commit to its first parent until the root or a commit already seen
+type firstParentIter struct {
+	next *object.Commit
+	seen map[plumbing.Hash]bool
+}
+
+func newFirstParentIter(commit *object.Commit, seen map[plumbing.Hash]bool) *firstParentIter {
+	return &firstParentIter{next: commit, seen: seen}
+}
+
+func (it *firstParentIter) Next() (*object.Commit, error) {
+	commit := it.next
+	if commit == nil || it.seen[commit.Hash] {
+		return nil, io.EOF
+	}
+	it.next = nil
+	if commit.NumParents() > 0 {
+		parent, err := commit.Parent(0)
+		if err != nil {
+			return nil, err
+		}
+		it.next = parent
+	}
+	return commit, nil
+}
+
+func (it *firstParentIter) ForEach(fn func(*object.Commit) error) error {
+	for {
+		commit, err := it.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if err := fn(commit); err == storer.ErrStop {
+			return nil
+		} else if err != nil {
+			return err
+		}
+	}
+}
+
+func (it *firstParentIter) Close() {
+	it.next = nil
+}
+
+// sortCommits sorts the commits in the order, the ties broken by hash so that the order is stable
+func sortCommits(commits []*object.Commit, order CommitOrder) {
+	sort.Slice(commits, func(i, j int) bool { return newerCommit(commits[i], commits[j]) })
+	if order == OrderTopological {
+		sortTopological(commits)
+	}
+}
+
+func newerCommit(a, b *object.Commit) bool {
+	if !a.Committer.When.Equal(b.Committer.When) {
+		return a.Committer.When.After(b.Committer.When)
+	}
+	return a.Hash.String() < b.Hash.String()
+}
+
+// sortTopological reorders the commits, sorted by committer time, so that no parent comes before any of
+// its children among them. Of the commits whose children are all listed, the newest comes next.
+func sortTopological(commits []*object.Commit) {
+	children := make(map[plumbing.Hash]int, len(commits))
+	listed := make(map[plumbing.Hash]bool, len(commits))
+	for _, c := range commits {
+		listed[c.Hash] = true
+	}
+	for _, c := range commits {
+		for _, parent := range c.ParentHashes {
+			if listed[parent] {
+				children[parent] += 1
+			}
+		}
+	}
+	byHash := make(map[plumbing.Hash]*object.Commit, len(commits))
+	ready := &commitHeap{}
+	for _, c := range commits {
+		byHash[c.Hash] = c
+		if children[c.Hash] == 0 {
+			heap.Push(ready, c)
+		}
+	}
+	sorted := commits[:0]
+	for ready.Len() > 0 {
+		c := heap.Pop(ready).(*object.Commit)
+		sorted = append(sorted, c)
+		for _, parent := range c.ParentHashes {
+			if !listed[parent] {
+				continue
+			}
+			children[parent] -= 1
+			if children[parent] == 0 {
+				heap.Push(ready, byHash[parent])
+			}
+		}
+	}
+}
+
+// commitHeap pops the newest commit first
+type commitHeap []*object.Commit
+
+func (h commitHeap) Len() int            { return len(h) }
+func (h commitHeap) Less(i, j int) bool  { return newerCommit(h[i], h[j]) }
+func (h commitHeap) Swap(i, j int)       { h[i], h[j] = h[j], h[i] }
+func (h *commitHeap) Push(x interface{}) { *h = append(*h, x.(*object.Commit)) }
+func (h *commitHeap) Pop() interface{} {
+	old := *h
+	c := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return c
+}
@@ -0,0 +1,139 @@
+package gitfuncs
+
+import (
+	"testing"
+
+	"github.com/andymeneely/git-churn/testutil"
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/src-d/go-git.v4/plumbing"
+	"gopkg.in/src-d/go-git.v4/plumbing/object"
+)
+
+func TestRevListDivergentHistory(t *testing.T) {
+	assert := assert.New(t)
+	repo := testutil.NewRepo(t)
+	root := repo.CommitFiles("root", map[string]string{"a.txt": "a\n"})
+	repo.Branch("feature").CommitFiles("feature 1", map[string]string{"b.txt": "1\n"})
+	// The clock of the feature author is a year behind
+	repo.At(testutil.Start.AddDate(-1, 0, 0)).CommitFiles("feature 2", map[string]string{"b.txt": "2\n"})
+	master := repo.Checkout("master").At(testutil.Start.AddDate(0, 0, 5)).CommitFiles("master", map[string]string{"a.txt": "b\n"})
+	repo.Merge("feature", "merge")
+
+	// The root is reachable through both parents of the merge but listed once
+	commits, err := RevList(repo.Repository, "HEAD", "")
+	assert.Nil(err)
+	assert.Equal([]string{"merge", "master", "feature 1", "root", "feature 2"}, messages(commits))
+
+	commits, err = RevListWithOptions(repo.Repository, "HEAD", "", RevListOptions{Order: OrderTopological})
+	assert.Nil(err)
+	assert.Equal([]string{"merge", "master", "feature 2", "feature 1", "root"}, messages(commits))
+
+	// The end boundary is exclusive unless told otherwise, the begin one inclusive
+	commits, err = RevList(repo.Repository, "feature", root.Hash.String())
+	assert.Nil(err)
+	assert.Equal([]string{"feature 1", "feature 2"}, messages(commits))
+	commits, err = RevListWithOptions(repo.Repository, "feature", root.Hash.String(), RevListOptions{Order: OrderTopological, IncludeEnd: true})
+	assert.Nil(err)
+	assert.Equal([]string{"feature 2", "feature 1", "root"}, messages(commits))
+
+	commits, err = RevList(repo.Repository, "HEAD", master.Hash.String())
+	assert.Nil(err)
+	assert.Equal([]string{"merge", "feature 1", "feature 2"}, messages(commits))
+
+	_, err = RevList(repo.Repository, "HEAD", "nothing")
+	assert.NotNil(err)
+}
+
+func TestMergeBase(t *testing.T) {
+	assert := assert.New(t)
+	repo := testutil.NewDivergedRepo(t)
+	master := repo.Head()
+	feature := branchTip(t, repo, "feature")
+
+	base, err := MergeBase(repo.Repository, master.Hash, feature)
+	assert.Nil(err)
+	assert.Equal("root", base.Message)
+	// Either way round
+	base, err = MergeBase(repo.Repository, feature, master.Hash)
+	assert.Nil(err)
+	assert.Equal("root", base.Message)
+	// An ancestor is its own merge base with its descendants
+	base, err = MergeBase(repo.Repository, base.Hash, feature)
+	assert.Nil(err)
+	assert.Equal("root", base.Message)
+
+	merge := repo.Merge("feature", "merge")
+	base, err = MergeBase(repo.Repository, merge.Hash, feature)
+	assert.Nil(err)
+	assert.Equal(feature, base.Hash)
+
+	_, err = MergeBase(repo.Repository, master.Hash, plumbing.NewHash("0123456789012345678901234567890123456789"))
+	assert.NotNil(err)
+}
+
+func TestCommitsBetween(t *testing.T) {
+	assert := assert.New(t)
+	repo := testutil.NewDivergedRepo(t)
+	master := repo.Head()
+	feature := branchTip(t, repo, "feature")
+
+	commits, err := CommitsBetween(repo.Repository, master.Hash, feature)
+	assert.Nil(err)
+	assert.Equal([]string{"feature 2", "feature 1"}, messages(commits))
+	commits, err = CommitsBetween(repo.Repository, feature, master.Hash)
+	assert.Nil(err)
+	assert.Equal([]string{"master"}, messages(commits))
+	// The whole history from a zero hash
+	commits, err = CommitsBetween(repo.Repository, plumbing.ZeroHash, feature)
+	assert.Nil(err)
+	assert.Equal([]string{"feature 2", "feature 1", "root"}, messages(commits))
+	commits, err = CommitsBetween(repo.Repository, feature, feature)
+	assert.Nil(err)
+	assert.Empty(commits)
+
+	// The merge brings the commits of the branch along, but only itself along the first parents
+	merge := repo.Merge("feature", "merge")
+	commits, err = CommitsBetween(repo.Repository, master.Hash, merge.Hash)
+	assert.Nil(err)
+	assert.Equal([]string{"merge", "feature 2", "feature 1"}, messages(commits))
+	FirstParent = true
+	defer func() { FirstParent = false }()
+	commits, err = CommitsBetween(repo.Repository, master.Hash, merge.Hash)
+	assert.Nil(err)
+	assert.Equal([]string{"merge"}, messages(commits))
+}
+
+// branchTip returns the hash of the last commit of the branch
+func branchTip(t *testing.T, repo *testutil.Repo, branch string) plumbing.Hash {
+	ref, err := repo.Reference(plumbing.NewBranchReferenceName(branch), true)
+	assert.Nil(t, err)
+	return ref.Hash()
+}
+
+func messages(commits []*object.Commit) []string {
+	var messages []string
+	for _, c := range commits {
+		messages = append(messages, c.Message)
+	}
+	return messages
+}
+
+func TestDefaultBranch(t *testing.T) {
+	assert := assert.New(t)
+	repo := testutil.NewRepo(t)
+	repo.CommitFiles("root", map[string]string{"a.txt": "1\n"})
+	repo.Branch("feature")
+	// HEAD when there is no origin remote
+	branch, err := DefaultBranch(repo.Repository)
+	assert.Nil(err)
+	assert.Equal("feature", branch)
+
+	repo.SetRef("refs/remotes/origin/main")
+	assert.Nil(repo.Storer.SetReference(plumbing.NewSymbolicReference("refs/remotes/origin/HEAD", "refs/remotes/origin/main")))
+	branch, err = DefaultBranch(repo.Repository)
+	assert.Nil(err)
+	assert.Equal("main", branch)
+	hash, err := ResolveRef(repo.Repository, branch)
+	assert.Nil(err)
+	assert.Equal(repo.Head().Hash, *hash)
+}
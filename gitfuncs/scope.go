@@ -0,0 +1,129 @@
+package gitfuncs
+
+import (
+	"path"
+	"strings"
+
+	"gopkg.in/src-d/go-git.v4/plumbing/filemode"
+	"gopkg.in/src-d/go-git.v4/plumbing/object"
+)
+
+// PathScope restricts the whole analysis to the subtree of the repositories at this path, e.g. a service
+// of a monorepo: the files outside of it are ignored like those of RepoIgnore, and the trees walked and
+// diffed are the subtrees at PathScope, so that the rest of the repository is not even read. Empty for the
+// whole repository. Set with SetPathScope.
+var PathScope string
+
+// SetPathScope sets PathScope to the given path, relative to the root of the repositories
+func SetPathScope(scope string) {
+	scope = path.Clean("/" + strings.TrimSpace(scope))
+	PathScope = strings.Trim(scope, "/")
+}
+
+// InScope tells whether the file at the given path is within PathScope
+func InScope(file string) bool {
+	return PathScope == "" || file == PathScope || strings.HasPrefix(file, PathScope+"/")
+}
+
+// ForEachFile passes the files of the tree within PathScope to fn, with their path from the root of the
+// tree, walking only the subtree at PathScope. A nil tree has no files.
+func ForEachFile(tree *object.Tree, fn func(*object.File) error) error {
+	if tree == nil {
+		return nil
+	}
+	if PathScope == "" {
+		return tree.Files().ForEach(fn)
+	}
+	subtree, isDir, err := scopeTree(tree)
+	if err != nil {
+		return err
+	}
+	if !isDir {
+		f, err := tree.File(PathScope)
+		if err != nil {
+			// Not there, or a submodule
+			return nil
+		}
+		return fn(f)
+	}
+	if subtree == nil {
+		return nil
+	}
+	return subtree.Files().ForEach(func(f *object.File) error {
+		f.Name = PathScope + "/" + f.Name
+		return fn(f)
+	})
+}
+
+// diffTree returns the changes between the two trees within PathScope, a nil tree standing for the empty
+// tree. Only the subtrees at PathScope are diffed when it is a directory, the changes keeping the paths
+// from the root of the trees.
+func diffTree(from, to *object.Tree) (object.Changes, error) {
+	if PathScope == "" {
+		return object.DiffTree(from, to)
+	}
+	fromSubtree, fromDir, err := scopeTree(from)
+	if err != nil {
+		return nil, err
+	}
+	toSubtree, toDir, err := scopeTree(to)
+	if err != nil {
+		return nil, err
+	}
+	if !fromDir || !toDir {
+		// A file on either side, the whole trees are diffed and the changes filtered
+		changes, err := object.DiffTree(from, to)
+		if err != nil {
+			return nil, err
+		}
+		var kept object.Changes
+		for _, change := range changes {
+			if InScope(changePath(change)) {
+				kept = append(kept, change)
+			}
+		}
+		return kept, nil
+	}
+	if fromSubtree == nil && toSubtree == nil {
+		return nil, nil
+	}
+	if fromSubtree != nil && toSubtree != nil && fromSubtree.Hash == toSubtree.Hash {
+		return nil, nil
+	}
+	changes, err := object.DiffTree(fromSubtree, toSubtree)
+	if err != nil {
+		return nil, err
+	}
+	for _, change := range changes {
+		for _, entry := range []*object.ChangeEntry{&change.From, &change.To} {
+			if entry.Name != "" {
+				entry.Name = PathScope + "/" + entry.Name
+			}
+		}
+	}
+	return changes, nil
+}
+
+// scopeTree returns the subtree of the tree at PathScope and true when it is a directory. A nil subtree
+// and true are returned when the tree is nil or has nothing at PathScope, a nil subtree and false when
+// PathScope is a file of the tree.
+func scopeTree(tree *object.Tree) (*object.Tree, bool, error) {
+	if tree == nil {
+		return nil, true, nil
+	}
+	entry, err := tree.FindEntry(PathScope)
+	if err == object.ErrEntryNotFound || err == object.ErrDirectoryNotFound {
+		return nil, true, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	if entry.Mode != filemode.Dir {
+		return nil, false, nil
+	}
+	subtree, err := tree.Tree(PathScope)
+	if err != nil {
+		return nil, false, err
+	}
+	return subtree, true, nil
+}
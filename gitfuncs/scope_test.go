@@ -0,0 +1,68 @@
+package gitfuncs
+
+import (
+	"sort"
+	"testing"
+
+	"github.com/andymeneely/git-churn/testutil"
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/src-d/go-git.v4/plumbing/object"
+)
+
+func TestPathScope(t *testing.T) {
+	assert := assert.New(t)
+	repo := testutil.NewRepo(t)
+	root := repo.CommitFiles("root", map[string]string{"README": "1\n", "services/billing/a.go": "1\n", "services/billing.go": "1\n"})
+	change := repo.CommitFiles("change", map[string]string{"README": "1\n2\n", "services/billing/a.go": "1\n2\n", "services/billing/b/c.go": "1\n"})
+	outside := repo.CommitFiles("outside", map[string]string{"README": "1\n2\n3\n"})
+
+	SetPathScope("./services/billing/")
+	defer SetPathScope("")
+	assert.Equal("services/billing", PathScope)
+	assert.True(InScope("services/billing/a.go"))
+	assert.False(InScope("services/billing.go"))
+	assert.False(InScope("README"))
+	var none *Ignore
+	assert.True(none.Match("README"))
+	assert.False(none.Match("services/billing/b/c.go"))
+
+	var files []string
+	tree, _ := change.Tree()
+	assert.Nil(ForEachFile(tree, func(f *object.File) error {
+		files = append(files, f.Name)
+		return nil
+	}))
+	sort.Strings(files)
+	assert.Equal([]string{"services/billing/a.go", "services/billing/b/c.go"}, files)
+
+	stats, err := CommitStats(change)
+	assert.Nil(err)
+	assert.Equal(object.FileStats{{Name: "services/billing/a.go", Addition: 1}, {Name: "services/billing/b/c.go", Addition: 1}}, stats)
+	stats, err = CommitStats(root)
+	assert.Nil(err)
+	assert.Equal(object.FileStats{{Name: "services/billing/a.go", Addition: 1}}, stats)
+	stats, err = CommitStats(outside)
+	assert.Nil(err)
+	assert.Empty(stats)
+
+	// A file
+	SetPathScope("README")
+	stats, err = CommitStats(outside)
+	assert.Nil(err)
+	assert.Equal(object.FileStats{{Name: "README", Addition: 1}}, stats)
+	files = nil
+	assert.Nil(ForEachFile(tree, func(f *object.File) error {
+		files = append(files, f.Name)
+		return nil
+	}))
+	assert.Equal([]string{"README"}, files)
+
+	SetPathScope("missing")
+	stats, err = CommitStats(change)
+	assert.Nil(err)
+	assert.Empty(stats)
+	assert.Nil(ForEachFile(tree, func(f *object.File) error {
+		t.Errorf("unexpected file %s", f.Name)
+		return nil
+	}))
+}
@@ -0,0 +1,123 @@
+package gitfuncs
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/sergi/go-diff/diffmatchpatch"
+	"gopkg.in/src-d/go-git.v4/plumbing/filemode"
+	"gopkg.in/src-d/go-git.v4/plumbing/object"
+)
+
+// CommitDiffMatches tells whether the commit adds or deletes, against its first parent, a line of a text
+// file matching the regular expression, like git log -G
+func CommitDiffMatches(commit *object.Commit, re *regexp.Regexp) (bool, error) {
+	return anyTextChange(commit, func(path, from, to string) bool {
+		from, to, _ = trimCommonLines(from, to)
+		added, deleted := diffLineTexts(textLines(from), textLines(to))
+		for _, line := range append(added, deleted...) {
+			if re.MatchString(strings.TrimSuffix(line, "\n")) {
+				return true
+			}
+		}
+		return false
+	})
+}
+
+// CommitChangesOccurrences tells whether the commit changes, against its first parent, the number of
+// occurrences of the string in a text file, like git log -S
+func CommitChangesOccurrences(commit *object.Commit, s string) (bool, error) {
+	return anyTextChange(commit, func(path, from, to string) bool {
+		return strings.Count(from, s) != strings.Count(to, s)
+	})
+}
+
+// OccurrenceChange counts the occurrences of a string in a text file before and after a commit
+type OccurrenceChange struct {
+	Path   string
+	Before int
+	After  int
+}
+
+// CommitOccurrenceChanges counts the occurrences of the string in the text files the commit changes
+// against its first parent, leaving out the files whose count did not change
+func CommitOccurrenceChanges(commit *object.Commit, s string) ([]OccurrenceChange, error) {
+	var changes []OccurrenceChange
+	_, err := anyTextChange(commit, func(path, from, to string) bool {
+		if before, after := strings.Count(from, s), strings.Count(to, s); before != after {
+			changes = append(changes, OccurrenceChange{Path: path, Before: before, After: after})
+		}
+		return false
+	})
+	return changes, err
+}
+
+// FileOccurrences counts the occurrences of the string in the file, none in binary files and symbolic links
+func FileOccurrences(f *object.File, s string) (int, error) {
+	if f.Mode == filemode.Symlink {
+		return 0, nil
+	}
+	if binary, err := isBinary(f); err != nil || binary {
+		return 0, err
+	}
+	text, err := fileText(f)
+	if err != nil {
+		return 0, err
+	}
+	return strings.Count(text, s), nil
+}
+
+// anyTextChange tells whether the texts before and after one of the text files changed by the commit
+// satisfy the condition, a file added or deleted having an empty text. Binary files and symbolic links
+// are left out.
+func anyTextChange(commit *object.Commit, condition func(path, from, to string) bool) (bool, error) {
+	changes, err := commitChanges(commit)
+	if err != nil {
+		return false, err
+	}
+	for _, change := range WithoutSymlinks(changes) {
+		from, to, err := change.Files()
+		if err != nil {
+			return false, err
+		}
+		if binary, err := isBinary(from, to); err != nil {
+			return false, err
+		} else if binary {
+			continue
+		}
+		var fromText, toText string
+		if from != nil {
+			if fromText, err = fileText(from); err != nil {
+				return false, err
+			}
+		}
+		if to != nil {
+			if toText, err = fileText(to); err != nil {
+				return false, err
+			}
+		}
+		if condition(changePath(change), fromText, toText) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// diffLineTexts returns the lines added and deleted between two lists of lines, diffed like diffLines
+func diffLineTexts(from, to []string) (added []string, deleted []string) {
+	i, j := 0, 0
+	for _, d := range diffLines(from, to) {
+		n := len([]rune(d.Text))
+		switch d.Type {
+		case diffmatchpatch.DiffEqual:
+			i, j = i+n, j+n
+		case diffmatchpatch.DiffDelete:
+			deleted = append(deleted, from[i:i+n]...)
+			i += n
+		case diffmatchpatch.DiffInsert:
+			added = append(added, to[j:j+n]...)
+			j += n
+		}
+	}
+	return added, deleted
+}
@@ -0,0 +1,34 @@
+package gitfuncs
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCommitSearch(t *testing.T) {
+	assert := assert.New(t)
+	_, commits := commitContents(t, []string{"alice", "bob", "carol"},
+		"// TODO: parse\nparse(x)\n",
+		"// TODO: parse\nparse(y)\nparse(x)\n",
+		"parse(y)\nparse(x)\n// TODO: parse\n",
+	)
+	todo := regexp.MustCompile(`TODO`)
+	for i, expected := range []struct{ todoLines, todoOccurrences, parseOccurrences bool }{
+		{true, true, true},
+		{false, false, true},
+		// Moving the marker adds and deletes it, but does not change its occurrences
+		{true, false, false},
+	} {
+		match, err := CommitDiffMatches(commits[i], todo)
+		assert.Nil(err)
+		assert.Equal(expected.todoLines, match, "commit %d", i)
+		match, err = CommitChangesOccurrences(commits[i], "TODO")
+		assert.Nil(err)
+		assert.Equal(expected.todoOccurrences, match, "commit %d", i)
+		match, err = CommitChangesOccurrences(commits[i], "parse(")
+		assert.Nil(err)
+		assert.Equal(expected.parseOccurrences, match, "commit %d", i)
+	}
+}
@@ -0,0 +1,182 @@
+package gitfuncs
+
+import (
+	"strings"
+	"time"
+
+	"github.com/sergi/go-diff/diffmatchpatch"
+	"gopkg.in/src-d/go-git.v4/plumbing/object"
+)
+
+// CommitStats returns the lines added and deleted per file by the commit against its first parent, the
+// same as go-git's Commit.Stats, without building the patches of the commit: only the files whose content
+// changed are diffed, the lines of added and deleted files are counted and cached like BlobLOC. Binary files
+// and symbolic links have no lines and are left out, UTF-16 files are decoded and line endings ignored like
+// ChangesChurnPatch. The files of the moved directories are paired when DetectDirMoves is set.
+func CommitStats(commit *object.Commit) (object.FileStats, error) {
+	tree, err := commit.Tree()
+	if err != nil {
+		return nil, err
+	}
+	var parentTree *object.Tree
+	if commit.NumParents() != 0 {
+		parent, err := commit.Parent(0)
+		if err != nil {
+			return nil, err
+		}
+		if parentTree, err = parent.Tree(); err != nil {
+			return nil, err
+		}
+	}
+	return TreeDiffStats(parentTree, tree)
+}
+
+// TreeDiffStats returns the lines added and deleted per file between the two trees like CommitStats.
+// A nil `from` tree stands for the empty tree.
+func TreeDiffStats(from, to *object.Tree) (object.FileStats, error) {
+	changes, err := diffTreeMoves(from, to)
+	if err != nil {
+		return nil, err
+	}
+	var stats object.FileStats
+	for _, change := range WithoutSymlinks(changes) {
+		fromFile, toFile, err := change.Files()
+		if err != nil {
+			return nil, err
+		}
+		if fromFile == nil && toFile == nil {
+			// Submodules
+			continue
+		}
+		if binary, err := isBinary(fromFile, toFile); err != nil {
+			return nil, err
+		} else if binary {
+			continue
+		}
+		stat, changed, err := fileStat(changePath(change), fromFile, toFile)
+		if err != nil {
+			return nil, err
+		}
+		if changed {
+			stats = append(stats, stat)
+		}
+	}
+	return stats, nil
+}
+
+// fileStat counts the lines added and deleted between two versions of a text file at the path, either being nil if
+// the file was added or deleted. Like go-git, empty files added or deleted are no change.
+func fileStat(path string, from, to *object.File) (object.FileStat, bool, error) {
+	switch {
+	case from == nil:
+		stat := object.FileStat{Name: path, Addition: BlobLOC(to, true)}
+		return stat, stat.Addition > 0, nil
+	case to == nil:
+		stat := object.FileStat{Name: path, Deletion: BlobLOC(from, true)}
+		return stat, stat.Deletion > 0, nil
+	case from.Hash == to.Hash:
+		// Only the mode changed
+		return object.FileStat{Name: path}, from.Size > 0, nil
+	}
+	fromContent, err := fileText(from)
+	if err != nil {
+		return object.FileStat{}, false, err
+	}
+	toContent, err := fileText(to)
+	if err != nil {
+		return object.FileStat{}, false, err
+	}
+	stat := object.FileStat{Name: path}
+	stat.Addition, stat.Deletion = diffLineCounts(fromContent, toContent)
+	// Only the line endings changed when they are ignored
+	return stat, stat.Addition+stat.Deletion > 0, nil
+}
+
+// diffLineCounts counts the lines added and deleted between two texts. It finds the same line diff as
+// go-git's patches, without going back and forth between the lines and their text.
+func diffLineCounts(from, to string) (int, int) {
+	// The lines both texts start and end with are no change, only the lines in between are diffed
+	from, to, _ = trimCommonLines(from, to)
+	added, deleted := 0, 0
+	for _, d := range diffLines(textLines(from), textLines(to)) {
+		switch d.Type {
+		case diffmatchpatch.DiffInsert:
+			added += len([]rune(d.Text))
+		case diffmatchpatch.DiffDelete:
+			deleted += len([]rune(d.Text))
+		}
+	}
+	return added, deleted
+}
+
+// trimCommonLines strips the texts of the whole lines they both start and end with, and returns the
+// length in bytes of the lines stripped from their start
+func trimCommonLines(from, to string) (string, string, int) {
+	prefix := 0
+	for prefix < len(from) && prefix < len(to) && from[prefix] == to[prefix] {
+		prefix++
+	}
+	prefix = strings.LastIndexByte(from[:prefix], '\n') + 1
+	from, to = from[prefix:], to[prefix:]
+
+	suffix := 0
+	for suffix < len(from) && suffix < len(to) && from[len(from)-1-suffix] == to[len(to)-1-suffix] {
+		suffix++
+	}
+	common := from[len(from)-suffix:]
+	if !lineStart(from, len(from)-suffix) || !lineStart(to, len(to)-suffix) {
+		// Only the lines after the first line break are whole
+		if i := strings.IndexByte(common, '\n'); i >= 0 {
+			common = common[i+1:]
+		} else {
+			common = ""
+		}
+	}
+	return from[:len(from)-len(common)], to[:len(to)-len(common)], prefix
+}
+
+func lineStart(text string, i int) bool {
+	return i == 0 || text[i-1] == '\n'
+}
+
+// diffLines diffs two lists of lines, every rune of the diffs standing for a line. Lines differing only
+// in whitespace are the same when IgnoreAllSpace is set.
+func diffLines(from, to []string) []diffmatchpatch.Diff {
+	return diffLinesBy(from, to, lineKey)
+}
+
+// diffLinesBy diffs two lists of lines like diffLines, the lines with the same key being the same
+func diffLinesBy(from, to []string, key func(line string) string) []diffmatchpatch.Diff {
+	ids := make(map[string]rune, len(from)+len(to))
+	dmp := diffmatchpatch.New()
+	// Like go-git, never settle for a suboptimal diff
+	dmp.DiffTimeout = time.Hour
+	return dmp.DiffMainRunes(linesRunes(from, ids, key), linesRunes(to, ids, key), false)
+}
+
+// textLines splits the text into lines, line breaks included
+func textLines(text string) []string {
+	if text == "" {
+		return nil
+	}
+	lines := strings.SplitAfter(text, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+// linesRunes turns every line into a rune identifying its key
+func linesRunes(lines []string, ids map[string]rune, key func(line string) string) []rune {
+	runes := make([]rune, len(lines))
+	for i, line := range lines {
+		k := key(line)
+		id, ok := ids[k]
+		if !ok {
+			id = rune(len(ids) + 1)
+			ids[k] = id
+		}
+		runes[i] = id
+	}
+	return runes
+}
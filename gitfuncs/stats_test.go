@@ -0,0 +1,60 @@
+package gitfuncs
+
+import (
+	"testing"
+
+	"github.com/andymeneely/git-churn/testutil"
+	"github.com/sergi/go-diff/diffmatchpatch"
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/src-d/go-git.v4/utils/diff"
+)
+
+func TestCommitStats(t *testing.T) {
+	assert := assert.New(t)
+	repo := testutil.NewRepo(t)
+	// Contents by path per commit, nil deleting the file
+	snapshots := []map[string]*string{
+		{"a.txt": str("1\n2\n3\n"), "empty.txt": str(""), "bin.dat": str("\x00\x01\x02"), "dir/gone.txt": str("x\ny")},
+		{"a.txt": str("1\n3\n4\n5"), "empty.txt": str("now\n"), "bin.dat": str("\x00\x03"), "dir/gone.txt": nil},
+		{"a.txt": str("1\n3\n4\n5\n"), "dir/b.txt": str(""), "dir/c.txt": str("c\n"), "empty.txt": nil},
+	}
+	for i, files := range snapshots {
+		for path, content := range files {
+			if content == nil {
+				repo.Delete(path)
+			} else {
+				repo.Write(path, *content)
+			}
+		}
+		commit := repo.Commit("commit")
+
+		expected, err := commit.Stats()
+		assert.Nil(err)
+		stats, err := CommitStats(commit)
+		assert.Nil(err)
+		assert.Equal(expected, stats, "commit %d", i)
+	}
+}
+
+func TestDiffLineCounts(t *testing.T) {
+	texts := []string{"", "a", "a\n", "a\nb", "a\nb\n", "b\n", "xa\nb\n", "a\nxb\n", "a\na\na\n", "a\nb\na\nb\nc"}
+	for _, from := range texts {
+		for _, to := range texts {
+			added, deleted := 0, 0
+			for _, d := range diff.Do(from, to) {
+				switch d.Type {
+				case diffmatchpatch.DiffInsert:
+					added += chunkLines(d.Text)
+				case diffmatchpatch.DiffDelete:
+					deleted += chunkLines(d.Text)
+				}
+			}
+			a, d := diffLineCounts(from, to)
+			assert.Equal(t, []int{added, deleted}, []int{a, d}, "%q to %q", from, to)
+		}
+	}
+}
+
+func str(s string) *string {
+	return &s
+}
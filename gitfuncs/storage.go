@@ -0,0 +1,162 @@
+package gitfuncs
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+
+	. "github.com/andymeneely/git-churn/print"
+	"gopkg.in/src-d/go-billy.v4/osfs"
+	"gopkg.in/src-d/go-git.v4"
+	"gopkg.in/src-d/go-git.v4/storage"
+	"gopkg.in/src-d/go-git.v4/storage/filesystem"
+	"gopkg.in/src-d/go-git.v4/storage/memory"
+)
+
+// Backends the repositories are cloned into
+const (
+	// StorageMemory keeps the objects of the clones in memory, the fastest
+	StorageMemory = "memory"
+	// StorageDisk clones into temporary directories removed by RemoveTempClones, reading the objects
+	// from disk as the trees are walked so that big repositories fit in memory
+	StorageDisk = "disk"
+	// StorageAuto clones in memory the repositories that fit in MaxMemory and on disk the others
+	StorageAuto = "auto"
+)
+
+// Storage is the backend the repositories are cloned into
+var Storage = StorageMemory
+
+// MaxMemory is a hint of the memory in bytes the analysis should stay under, 0 for no limit. The auto
+// storage picks the backend with it and the disk storage bounds its cache of objects with it.
+var MaxMemory int64
+
+// Objects take several times the size of their packfile once decompressed in memory
+const memoryPerPackByte = 4
+
+var tempClones = struct {
+	sync.Mutex
+	dirs []string
+}{}
+
+// ParseStorage checks the name of a storage backend: memory, disk or auto
+func ParseStorage(name string) (string, error) {
+	switch name {
+	case StorageMemory, StorageDisk, StorageAuto:
+		return name, nil
+	}
+	return "", fmt.Errorf("unknown storage %q, expected memory, disk or auto", name)
+}
+
+// CloneWithOptions clones the repository into the Storage backend. The clones are bare: the metrics
+// read the trees of the commits from the objects, so no worktree is ever checked out.
+func CloneWithOptions(options *git.CloneOptions) (*git.Repository, error) {
+	storer, err := newCloneStorage(options.URL)
+	if err != nil {
+		return nil, err
+	}
+	return git.Clone(storer, nil, options)
+}
+
+// newCloneStorage returns the storage to clone the repository into
+func newCloneStorage(repoUrl string) (storage.Storer, error) {
+	backend := Storage
+	if backend == StorageAuto {
+		backend = autoStorage(repoUrl)
+	}
+	if backend != StorageDisk {
+		return memory.NewStorage(), nil
+	}
+
+	dir, err := ioutil.TempDir("", "git-churn-")
+	if err != nil {
+		return nil, err
+	}
+	tempClones.Lock()
+	tempClones.dirs = append(tempClones.dirs, dir)
+	tempClones.Unlock()
+	Debug("cloning %s into %s", repoUrl, dir)
+
+	// The temporary clone is only written by git-churn, whose fetches go through the storage
+	return filesystem.NewStorageWithOptions(osfs.New(dir), objectCache(), filesystem.Options{ExclusiveAccess: true}), nil
+}
+
+// autoStorage picks the disk for the repositories that may not fit in MaxMemory: the local ones bigger
+// than it once decompressed and the remote ones, whose size is unknown
+func autoStorage(repoUrl string) string {
+	if MaxMemory <= 0 {
+		return StorageMemory
+	}
+	size, err := dirSize(repoUrl)
+	if err != nil || size*memoryPerPackByte > MaxMemory {
+		return StorageDisk
+	}
+	return StorageMemory
+}
+
+func dirSize(dir string) (int64, error) {
+	var size int64
+	err := filepath.Walk(dir, func(_ string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		size += info.Size()
+		return nil
+	})
+	return size, err
+}
+
+// RemoveTempClones removes the temporary directories of the clones on disk, of StorageDisk and of
+// the CLIEngine, once the analysis is over
+func RemoveTempClones() {
+	tempClones.Lock()
+	dirs := tempClones.dirs
+	tempClones.dirs = nil
+	tempClones.Unlock()
+	cliClones.Lock()
+	for url, dir := range cliClones.dirs {
+		dirs = append(dirs, dir)
+		delete(cliClones.dirs, url)
+	}
+	cliClones.Unlock()
+	for _, dir := range dirs {
+		forgetStats(dir)
+	}
+	removeDirs(dirs)
+}
+
+// ReleaseRepository removes the temporary directories of the repository once its caller is done with it,
+// like RemoveTempClones does for all of them: its clone on disk, of StorageDisk, or else the clone the
+// CLIEngine made of it. The repository is not to be used afterwards. The servers and the libraries
+// analyzing many repositories release them one by one rather than holding them to the exit.
+func ReleaseRepository(repo *git.Repository) {
+	ForgetMailmap(repo)
+	storage, ok := repo.Storer.(*filesystem.Storage)
+	if !ok {
+		releaseCLIClone(repo)
+		return
+	}
+	root := storage.Filesystem().Root()
+	forgetStats(root)
+	tempClones.Lock()
+	var dirs []string
+	for i, dir := range tempClones.dirs {
+		if dir == root {
+			tempClones.dirs = append(tempClones.dirs[:i], tempClones.dirs[i+1:]...)
+			dirs = append(dirs, dir)
+			break
+		}
+	}
+	tempClones.Unlock()
+	removeDirs(dirs)
+}
+
+func removeDirs(dirs []string) {
+	for _, dir := range dirs {
+		if err := os.RemoveAll(dir); err != nil {
+			Warning("could not remove %s: %v", dir, err)
+		}
+	}
+}
@@ -0,0 +1,112 @@
+package gitfuncs
+
+import (
+	"os"
+	"os/exec"
+	"testing"
+
+	"github.com/andymeneely/git-churn/testutil"
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/src-d/go-git.v4"
+	"gopkg.in/src-d/go-git.v4/plumbing"
+	"gopkg.in/src-d/go-git.v4/storage/filesystem"
+	"gopkg.in/src-d/go-git.v4/storage/memory"
+)
+
+func TestCloneStorage(t *testing.T) {
+	assert := assert.New(t)
+	origin := testutil.NewDiskRepo(t)
+	defer origin.Remove()
+	origin.CommitFiles("commit", map[string]string{"a.txt": "1\n2\n"})
+	defer func() { Storage, MaxMemory = StorageMemory, 0 }()
+
+	repo, err := CloneRepository(origin.Dir)
+	assert.Nil(err)
+	assert.IsType(&memory.Storage{}, repo.Storer)
+	// The metrics read the trees from the objects, there is no worktree to check out
+	_, err = repo.Worktree()
+	assert.Equal(git.ErrIsBareRepository, err)
+	first := origin.Head().Hash
+	origin.CommitFiles("second", map[string]string{"a.txt": "1\n"})
+	repo, err = CloneRepository(origin.Dir)
+	assert.Nil(err)
+	assert.Nil(SetHead(repo, first))
+	head, err := repo.Head()
+	assert.Nil(err)
+	assert.Equal(first, head.Hash())
+	assert.NotNil(SetHead(repo, plumbing.NewHash("0123456789012345678901234567890123456789")))
+
+	Storage = StorageDisk
+	repo, err = CloneRepository(origin.Dir)
+	assert.Nil(err)
+	disk, ok := repo.Storer.(*filesystem.Storage)
+	assert.True(ok)
+	root := disk.Filesystem().Root()
+	head, err = repo.Head()
+	assert.Nil(err)
+	assert.Equal(origin.Head().Hash, head.Hash())
+	commit, err := repo.CommitObject(head.Hash())
+	assert.Nil(err)
+	tree, err := commit.Tree()
+	assert.Nil(err)
+	assert.Equal(1, FileLOCFromTree(tree, "a.txt"))
+	RemoveTempClones()
+	_, err = os.Stat(root)
+	assert.True(os.IsNotExist(err))
+
+	// The auto storage clones on disk what may not fit in the memory
+	Storage, MaxMemory = StorageAuto, 1<<30
+	repo, err = CloneRepository(origin.Dir)
+	assert.Nil(err)
+	assert.IsType(&memory.Storage{}, repo.Storer)
+	MaxMemory = 1 << 10
+	repo, err = CloneRepository(origin.Dir)
+	assert.Nil(err)
+	assert.IsType(&filesystem.Storage{}, repo.Storer)
+	RemoveTempClones()
+
+	_, err = ParseStorage("cloud")
+	assert.NotNil(err)
+}
+
+func TestReleaseRepository(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	assert := assert.New(t)
+	origin := testutil.NewDiskRepo(t)
+	defer origin.Remove()
+	origin.CommitFiles("commit", map[string]string{"a.txt": "1\n2\n"})
+	defer func() { Storage = StorageMemory }()
+
+	Storage = StorageDisk
+	disk, err := CloneRepository(origin.Dir)
+	assert.Nil(err)
+	root := disk.Storer.(*filesystem.Storage).Filesystem().Root()
+	Storage = StorageMemory
+	inMemory, err := CloneRepository(origin.Dir)
+	assert.Nil(err)
+	cli, err := repositoryDir(inMemory)
+	assert.Nil(err)
+
+	ReleaseRepository(disk)
+	_, err = os.Stat(root)
+	assert.True(os.IsNotExist(err))
+	_, err = os.Stat(cli)
+	assert.Nil(err)
+	ReleaseRepository(inMemory)
+	_, err = os.Stat(cli)
+	assert.True(os.IsNotExist(err))
+	assert.Empty(tempClones.dirs)
+	assert.Empty(cliClones.dirs)
+
+	// A fetch bringing new commits drops the clone of the git command line, lacking them
+	cli, err = repositoryDir(inMemory)
+	assert.Nil(err)
+	origin.CommitFiles("second", map[string]string{"a.txt": "1\n"})
+	updated, err := FetchNewRefs(inMemory, "+refs/heads/*:refs/remotes/origin/*")
+	assert.Nil(err)
+	assert.True(updated)
+	_, err = os.Stat(cli)
+	assert.True(os.IsNotExist(err))
+}
@@ -0,0 +1,94 @@
+package gitfuncs
+
+import (
+	"github.com/andymeneely/git-churn/lang"
+	"github.com/sergi/go-diff/diffmatchpatch"
+	"gopkg.in/src-d/go-git.v4/plumbing/object"
+)
+
+// TokenChange counts the tokens of code, as split by lang.Tokenize, added to and deleted from a text file
+type TokenChange struct {
+	Path       string
+	Insertions int
+	Deletions  int
+}
+
+// TokenChanges diffs the tokens of the changed text files, decoded like ChangesChurnPatch. Unlike the
+// lines, the tokens do not change when code is re-wrapped or re-indented. Binary files, symbolic links
+// and the files whose tokens did not change are left out.
+func TokenChanges(changes object.Changes) ([]TokenChange, error) {
+	var tokenChanges []TokenChange
+	for _, change := range WithoutSymlinks(changes) {
+		from, to, err := change.Files()
+		if err != nil {
+			return nil, err
+		}
+		if binary, err := isBinary(from, to); err != nil {
+			return nil, err
+		} else if binary {
+			continue
+		}
+		var fromText, toText string
+		if from != nil {
+			if fromText, err = fileText(from); err != nil {
+				return nil, err
+			}
+		}
+		if to != nil {
+			if toText, err = fileText(to); err != nil {
+				return nil, err
+			}
+		}
+		tokenChange := TokenChange{Path: changePath(change)}
+		tokenChange.Insertions, tokenChange.Deletions = diffTokenCounts(fromText, toText)
+		if tokenChange.Insertions+tokenChange.Deletions > 0 {
+			tokenChanges = append(tokenChanges, tokenChange)
+		}
+	}
+	return tokenChanges, nil
+}
+
+// CommitTokenChanges diffs the tokens of the files changed by the commit against its first parent like
+// TokenChanges, the files of a root commit being all added
+func CommitTokenChanges(commit *object.Commit) ([]TokenChange, error) {
+	changes, err := commitChanges(commit)
+	if err != nil {
+		return nil, err
+	}
+	return TokenChanges(changes)
+}
+
+// commitChanges returns the changes of the commit against its first parent, the files of a root commit
+// being all added
+func commitChanges(commit *object.Commit) (object.Changes, error) {
+	tree, err := commit.Tree()
+	if err != nil {
+		return nil, err
+	}
+	var parentTree *object.Tree
+	if commit.NumParents() != 0 {
+		parent, err := commit.Parent(0)
+		if err != nil {
+			return nil, err
+		}
+		if parentTree, err = parent.Tree(); err != nil {
+			return nil, err
+		}
+	}
+	return diffTree(parentTree, tree)
+}
+
+// diffTokenCounts counts the tokens added and deleted between two texts, diffing the tokens the way
+// diffLines diffs the lines
+func diffTokenCounts(from, to string) (int, int) {
+	added, deleted := 0, 0
+	for _, d := range diffLinesBy(lang.Tokenize(from), lang.Tokenize(to), sameLine) {
+		switch d.Type {
+		case diffmatchpatch.DiffInsert:
+			added += len([]rune(d.Text))
+		case diffmatchpatch.DiffDelete:
+			deleted += len([]rune(d.Text))
+		}
+	}
+	return added, deleted
+}
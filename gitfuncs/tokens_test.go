@@ -0,0 +1,28 @@
+package gitfuncs
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCommitTokenChanges(t *testing.T) {
+	assert := assert.New(t)
+	_, commits := commitContents(t, []string{"alice", "bob", "carol"},
+		"x := f(a, b)\n",
+		"x := f(\n\ta,\n\tb,\n)\n",
+		"y := f(\n\ta,\n\tb,\n)\n",
+	)
+
+	changes, err := CommitTokenChanges(commits[0])
+	assert.Nil(err)
+	assert.Equal([]TokenChange{{Path: "a.txt", Insertions: 9}}, changes)
+
+	changes, err = CommitTokenChanges(commits[1])
+	assert.Nil(err)
+	assert.Equal([]TokenChange{{Path: "a.txt", Insertions: 1}}, changes)
+
+	changes, err = CommitTokenChanges(commits[2])
+	assert.Nil(err)
+	assert.Equal([]TokenChange{{Path: "a.txt", Insertions: 1, Deletions: 1}}, changes)
+}
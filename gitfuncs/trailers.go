@@ -0,0 +1,68 @@
+package gitfuncs
+
+import (
+	"strings"
+)
+
+// Trailer is a "Key: value" line of the trailer block closing a commit message, like Reviewed-by or
+// Signed-off-by, see git interpret-trailers
+type Trailer struct {
+	Key   string
+	Value string
+}
+
+// CommitTrailers parses the trailers of the commit message: the lines of its last paragraph when they are
+// all trailers, or continuation lines of a trailer starting with whitespace. A message made of a single
+// paragraph has no trailers, that paragraph being its subject.
+func CommitTrailers(message string) []Trailer {
+	paragraphs := strings.Split(strings.TrimSpace(strings.Replace(message, "\r\n", "\n", -1)), "\n\n")
+	if len(paragraphs) < 2 {
+		return nil
+	}
+	var trailers []Trailer
+	for _, line := range strings.Split(strings.Trim(paragraphs[len(paragraphs)-1], "\n"), "\n") {
+		if len(trailers) > 0 && (strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t")) {
+			trailers[len(trailers)-1].Value += " " + strings.TrimSpace(line)
+			continue
+		}
+		colon := strings.Index(line, ":")
+		if colon <= 0 || !isTrailerKey(line[:colon]) {
+			return nil
+		}
+		trailers = append(trailers, Trailer{Key: line[:colon], Value: strings.TrimSpace(line[colon+1:])})
+	}
+	return trailers
+}
+
+// TrailerValues returns the values of the trailers of the commit message with the key, compared case
+// insensitively
+func TrailerValues(message, key string) []string {
+	var values []string
+	for _, trailer := range CommitTrailers(message) {
+		if strings.EqualFold(trailer.Key, key) {
+			values = append(values, trailer.Value)
+		}
+	}
+	return values
+}
+
+// ParseIdentity splits an identity like "Name <email>" into its name and email, the email being empty when
+// there is none
+func ParseIdentity(identity string) (string, string) {
+	open := strings.Index(identity, "<")
+	end := strings.LastIndex(identity, ">")
+	if open < 0 || end < open {
+		return strings.TrimSpace(identity), ""
+	}
+	return strings.TrimSpace(identity[:open]), strings.TrimSpace(identity[open+1 : end])
+}
+
+// isTrailerKey tells whether the token is a trailer key, made of alphanumerics and dashes
+func isTrailerKey(token string) bool {
+	for _, r := range token {
+		if !(r == '-' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9') {
+			return false
+		}
+	}
+	return token != ""
+}
@@ -0,0 +1,28 @@
+package gitfuncs
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCommitTrailers(t *testing.T) {
+	assert := assert.New(t)
+	message := "Fix the parser\n\nIt choked on: colons.\n\nReviewed-by: Carol <carol@example.com>\nSigned-off-by: Bob\n  Builder <bob@example.com>\n"
+	assert.Equal([]Trailer{
+		{Key: "Reviewed-by", Value: "Carol <carol@example.com>"},
+		{Key: "Signed-off-by", Value: "Bob Builder <bob@example.com>"},
+	}, CommitTrailers(message))
+	assert.Equal([]string{"Carol <carol@example.com>"}, TrailerValues(message, "reviewed-by"))
+
+	assert.Nil(CommitTrailers("Reviewed-by: Carol <carol@example.com>\n"))
+	assert.Nil(CommitTrailers("Fix the parser\n\nIt choked on: colons.\nReviewed-by: Carol\n"))
+	assert.Nil(CommitTrailers("Fix the parser\n\nSee http://example.com\n"))
+
+	name, email := ParseIdentity(" Carol Doe <carol@example.com> ")
+	assert.Equal("Carol Doe", name)
+	assert.Equal("carol@example.com", email)
+	name, email = ParseIdentity("Carol")
+	assert.Equal("Carol", name)
+	assert.Equal("", email)
+}
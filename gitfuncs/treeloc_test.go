@@ -0,0 +1,94 @@
+package gitfuncs
+
+import (
+	"testing"
+
+	"github.com/andymeneely/git-churn/testutil"
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/src-d/go-git.v4/plumbing"
+	"gopkg.in/src-d/go-git.v4/plumbing/filemode"
+	"gopkg.in/src-d/go-git.v4/plumbing/object"
+)
+
+// storeTree stores a tree of the given entries in the repository
+func storeTree(t *testing.T, repo *testutil.Repo, entries ...object.TreeEntry) *object.Tree {
+	tree := &object.Tree{Entries: entries}
+	obj := repo.Storer.NewEncodedObject()
+	if err := tree.Encode(obj); err != nil {
+		t.Fatal(err)
+	}
+	hash, err := repo.Storer.SetEncodedObject(obj)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tree, err = repo.TreeObject(hash)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return tree
+}
+
+func TestTreeLOC(t *testing.T) {
+	assert := assert.New(t)
+	repo := testutil.NewRepo(t)
+	commit := repo.CommitFiles("root", map[string]string{"a.go": "1\n\n2\n", "dir/b.go": "1\n"})
+	tree, _ := commit.Tree()
+
+	loc, files, err := TreeLOC(tree, nil, true)
+	assert.Nil(err)
+	assert.Equal(4, loc)
+	assert.Equal([]string{"a.go", "dir/b.go"}, files)
+	loc, _, err = TreeLOC(tree, nil, false)
+	assert.Nil(err)
+	assert.Equal(3, loc)
+
+	loc, err = TreeFileLOC(tree, "a.go", true)
+	assert.Nil(err)
+	assert.Equal(3, loc)
+	for _, path := range []string{"missing.go", "dir", "dir/missing.go"} {
+		_, err = TreeFileLOC(tree, path, true)
+		assert.Equal(object.ErrFileNotFound, err, path)
+		assert.Equal(0, FileLOCFromTree(tree, path), path)
+	}
+
+	// The parent tree of a root commit
+	loc, files, err = TreeLOC(nil, nil, true)
+	assert.Nil(err)
+	assert.Equal(0, loc)
+	assert.Empty(files)
+	_, err = TreeFileLOC(nil, "a.go", true)
+	assert.Equal(object.ErrFileNotFound, err)
+	assert.Equal(0, FileLOCFromTreeWhitespaceExcluded(nil, "a.go"))
+
+	empty := storeTree(t, repo)
+	assert.Equal(EmptyTreeHash, empty.Hash)
+	loc, files, err = TreeLOC(empty, nil, true)
+	assert.Nil(err)
+	assert.Equal(0, loc)
+	assert.Empty(files)
+
+	// Submodules are no files
+	submodules := storeTree(t, repo, object.TreeEntry{Name: "lib", Mode: filemode.Submodule, Hash: plumbing.NewHash("0123456789012345678901234567890123456789")})
+	loc, files, err = TreeLOC(submodules, nil, true)
+	assert.Nil(err)
+	assert.Equal(0, loc)
+	assert.Empty(files)
+	_, err = TreeFileLOC(submodules, "lib", true)
+	assert.Equal(object.ErrFileNotFound, err)
+}
+
+func TestRootCommitDiff(t *testing.T) {
+	assert := assert.New(t)
+	repo := testutil.NewRepo(t)
+	repo.CommitFiles("root", map[string]string{"a.go": "1\n2\n"})
+
+	changes, tree, parentTree := CommitDiff(repo.Repository)
+	assert.Nil(parentTree)
+	assert.NotNil(tree)
+	assert.Len(*changes, 1)
+	assert.Equal("a.go", (*changes)[0].To.Name)
+
+	deleted, parentTreeHash := DeletedLineNumbers(repo.Repository)
+	assert.Empty(deleted["a.go"])
+	assert.Equal(EmptyTreeHash.String(), parentTreeHash)
+}
@@ -0,0 +1,82 @@
+package gitfuncs
+
+import (
+	"strings"
+	"unicode"
+
+	"github.com/sergi/go-diff/diffmatchpatch"
+	"gopkg.in/src-d/go-git.v4/plumbing/object"
+)
+
+// IgnoreAllSpace makes the lines that only changed in whitespace no change, like git diff -w, so that
+// reformatting churns nothing
+var IgnoreAllSpace bool
+
+// lineKey is what a line is compared by in the diffs: the line itself, or the line without any
+// whitespace when IgnoreAllSpace is set
+func lineKey(line string) string {
+	if !IgnoreAllSpace {
+		return line
+	}
+	return spaceless(line)
+}
+
+func spaceless(line string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsSpace(r) {
+			return -1
+		}
+		return r
+	}, line)
+}
+
+func sameLine(line string) string {
+	return line
+}
+
+// CommitSpaceChanges counts the lines the commit added and deleted in its text files against its first
+// parent, whatever IgnoreAllSpace, and among them the lines that changed other than in whitespace. A
+// reformat commit changes many lines but next to none of their tokens.
+func CommitSpaceChanges(commit *object.Commit) (lines int, tokenLines int, err error) {
+	changes, err := commitChanges(commit)
+	if err != nil {
+		return 0, 0, err
+	}
+	for _, change := range WithoutSymlinks(changes) {
+		from, to, err := change.Files()
+		if err != nil {
+			return 0, 0, err
+		}
+		if binary, err := isBinary(from, to); err != nil {
+			return 0, 0, err
+		} else if binary {
+			continue
+		}
+		var fromText, toText string
+		if from != nil {
+			if fromText, err = fileText(from); err != nil {
+				return 0, 0, err
+			}
+		}
+		if to != nil {
+			if toText, err = fileText(to); err != nil {
+				return 0, 0, err
+			}
+		}
+		fromLines, toLines := textLines(fromText), textLines(toText)
+		lines += changedLines(diffLinesBy(fromLines, toLines, sameLine))
+		tokenLines += changedLines(diffLinesBy(fromLines, toLines, spaceless))
+	}
+	return lines, tokenLines, nil
+}
+
+// changedLines counts the lines added and deleted by the diffs of diffLines
+func changedLines(diffs []diffmatchpatch.Diff) int {
+	n := 0
+	for _, d := range diffs {
+		if d.Type != diffmatchpatch.DiffEqual {
+			n += len([]rune(d.Text))
+		}
+	}
+	return n
+}
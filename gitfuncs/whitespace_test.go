@@ -0,0 +1,51 @@
+package gitfuncs
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIgnoreAllSpace(t *testing.T) {
+	assert := assert.New(t)
+	repo, commits := commitContents(t, []string{"alice", "bob"},
+		"func f() {\nreturn 1\n}\n",
+		"func f()  {\n\treturn 1\n}\n\n// g\n",
+	)
+	defer func() { IgnoreAllSpace = false }()
+
+	patch, err := CommitChurnPatch(commits[1])
+	assert.Nil(err)
+	assert.Equal(4, patch.Count(LineAdded, true))
+	assert.Equal(2, patch.Count(LineDeleted, true))
+
+	IgnoreAllSpace = true
+	patch, err = CommitChurnPatch(commits[1])
+	assert.Nil(err)
+	assert.Equal([]int{4, 5}, patch.File("a.txt").LineNumbers(LineAdded, true))
+	assert.Equal("// g", patch.File("a.txt").Lines(LineAdded, true)[1].Text)
+	assert.Nil(patch.File("a.txt").LineNumbers(LineDeleted, true))
+	stats, err := CommitStats(commits[1])
+	assert.Nil(err)
+	assert.Equal(2, stats[0].Addition)
+	assert.Equal(0, stats[0].Deletion)
+	hunks, err := CommitHunks(repo, commits[1])
+	assert.Nil(err)
+	assert.Equal(1, len(hunks))
+	assert.Equal(4, hunks[0].NewStart)
+}
+
+func TestCommitSpaceChanges(t *testing.T) {
+	assert := assert.New(t)
+	_, commits := commitContents(t, []string{"alice", "bob"},
+		"func f() {\nreturn 1\n}\n",
+		"func f()  {\n\treturn 1\n}\n\n// g\n",
+	)
+	IgnoreAllSpace = true
+	defer func() { IgnoreAllSpace = false }()
+
+	lines, tokenLines, err := CommitSpaceChanges(commits[1])
+	assert.Nil(err)
+	assert.Equal(6, lines)
+	assert.Equal(2, tokenLines)
+}
@@ -0,0 +1,47 @@
+package helper
+
+// Progress is the progress of a long running task
+type Progress struct {
+	Task string
+	// Steps done so far out of the total, zero when unknown
+	Done  int
+	Total int
+	// Whether the task is over
+	Finished bool
+}
+
+// OnProgress, when set, is told of the progress of the walks over the commits of the metrics
+var OnProgress func(Progress)
+
+// ProgressTracker reports the progress of a task to OnProgress
+type ProgressTracker struct {
+	Progress
+}
+
+// TrackProgress starts tracking a task of the given number of steps, zero if unknown
+func TrackProgress(task string, total int) *ProgressTracker {
+	tracker := &ProgressTracker{Progress{Task: task, Total: total}}
+	tracker.report()
+	return tracker
+}
+
+// Step marks one more step done
+func (t *ProgressTracker) Step() {
+	t.Done += 1
+	t.report()
+}
+
+// Finish marks the task over, done or not
+func (t *ProgressTracker) Finish() {
+	if t.Finished {
+		return
+	}
+	t.Finished = true
+	t.report()
+}
+
+func (t *ProgressTracker) report() {
+	if OnProgress != nil {
+		OnProgress(t.Progress)
+	}
+}
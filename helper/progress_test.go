@@ -0,0 +1,23 @@
+package helper
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProgressTracker(t *testing.T) {
+	var reported []Progress
+	OnProgress = func(progress Progress) { reported = append(reported, progress) }
+	defer func() { OnProgress = nil }()
+
+	tracker := TrackProgress("commits", 2)
+	tracker.Step()
+	tracker.Finish()
+	tracker.Finish()
+	assert.Equal(t, []Progress{
+		{Task: "commits", Total: 2},
+		{Task: "commits", Done: 1, Total: 2},
+		{Task: "commits", Done: 1, Total: 2, Finished: true},
+	}, reported)
+}
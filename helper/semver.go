@@ -0,0 +1,59 @@
+package helper
+
+import (
+	"strconv"
+	"strings"
+)
+
+// Semver is a parsed semantic version, e.g. v1.2.3-rc.1
+type Semver struct {
+	Major      int
+	Minor      int
+	Patch      int
+	Prerelease string
+}
+
+// ParseSemver parses tags like v1.2.3, 1.2.3 or v1.2.3-rc.1, build metadata is ignored.
+// A missing patch (v1.2) is read as zero.
+func ParseSemver(tag string) (Semver, bool) {
+	var version Semver
+	tag = strings.TrimPrefix(tag, "v")
+	if i := strings.Index(tag, "+"); i >= 0 {
+		tag = tag[:i]
+	}
+	if i := strings.Index(tag, "-"); i >= 0 {
+		version.Prerelease = tag[i+1:]
+		tag = tag[:i]
+	}
+	parts := strings.Split(tag, ".")
+	if len(parts) < 2 || len(parts) > 3 {
+		return version, false
+	}
+	numbers := make([]int, 3)
+	for i, part := range parts {
+		number, err := strconv.Atoi(part)
+		if err != nil || number < 0 {
+			return version, false
+		}
+		numbers[i] = number
+	}
+	version.Major, version.Minor, version.Patch = numbers[0], numbers[1], numbers[2]
+	return version, true
+}
+
+// Less tells whether the version precedes the other one. Pre-releases precede the release.
+func (v Semver) Less(other Semver) bool {
+	if v.Major != other.Major {
+		return v.Major < other.Major
+	}
+	if v.Minor != other.Minor {
+		return v.Minor < other.Minor
+	}
+	if v.Patch != other.Patch {
+		return v.Patch < other.Patch
+	}
+	if v.Prerelease == "" || other.Prerelease == "" {
+		return v.Prerelease != "" && other.Prerelease == ""
+	}
+	return v.Prerelease < other.Prerelease
+}
@@ -0,0 +1,34 @@
+package helper
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestParseSemver(t *testing.T) {
+	assert := assert.New(t)
+	version, ok := ParseSemver("v1.2.3")
+	assert.True(ok)
+	assert.Equal(Semver{1, 2, 3, ""}, version)
+
+	version, ok = ParseSemver("2.0-rc.1+build.5")
+	assert.True(ok)
+	assert.Equal(Semver{2, 0, 0, "rc.1"}, version)
+
+	_, ok = ParseSemver("release-2020")
+	assert.False(ok)
+	_, ok = ParseSemver("v1")
+	assert.False(ok)
+}
+
+func TestSemverLess(t *testing.T) {
+	assert := assert.New(t)
+	parse := func(tag string) Semver {
+		version, _ := ParseSemver(tag)
+		return version
+	}
+	assert.True(parse("v1.2.3").Less(parse("v1.10.0")))
+	assert.True(parse("v1.2.3-rc.1").Less(parse("v1.2.3")))
+	assert.False(parse("v1.2.3").Less(parse("v1.2.3-rc.1")))
+	assert.False(parse("v2.0.0").Less(parse("v2.0.0")))
+}
@@ -0,0 +1,50 @@
+package helper
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ParseSince parses the start of a time window the way git log --since does for the common cases: a
+// relative period like 6.months, 2.weeks.ago or "3 days ago", or a date like 2020-01-31 or an RFC 3339
+// timestamp. Relative periods are counted back from now.
+func ParseSince(since string, now time.Time) (time.Time, error) {
+	since = strings.TrimSpace(since)
+	if t, err := time.Parse(time.RFC3339, since); err == nil {
+		return t, nil
+	}
+	if t, err := time.ParseInLocation("2006-01-02", since, now.Location()); err == nil {
+		return t, nil
+	}
+
+	fields := strings.FieldsFunc(strings.ToLower(since), func(r rune) bool { return r == '.' || r == ' ' || r == '_' })
+	if len(fields) == 3 && fields[2] == "ago" {
+		fields = fields[:2]
+	}
+	if len(fields) != 2 {
+		return time.Time{}, fmt.Errorf("invalid since %q, expected e.g. 6.months or 2020-01-31", since)
+	}
+	n, err := strconv.Atoi(fields[0])
+	if err != nil || n < 0 {
+		return time.Time{}, fmt.Errorf("invalid since %q, expected e.g. 6.months or 2020-01-31", since)
+	}
+	switch strings.TrimSuffix(fields[1], "s") {
+	case "second":
+		return now.Add(-time.Duration(n) * time.Second), nil
+	case "minute":
+		return now.Add(-time.Duration(n) * time.Minute), nil
+	case "hour":
+		return now.Add(-time.Duration(n) * time.Hour), nil
+	case "day":
+		return now.AddDate(0, 0, -n), nil
+	case "week":
+		return now.AddDate(0, 0, -7*n), nil
+	case "month":
+		return now.AddDate(0, -n, 0), nil
+	case "year":
+		return now.AddDate(-n, 0, 0), nil
+	}
+	return time.Time{}, fmt.Errorf("invalid since %q, unknown unit %q", since, fields[1])
+}
@@ -0,0 +1,31 @@
+package helper
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseSince(t *testing.T) {
+	assert := assert.New(t)
+	now := time.Date(2020, 8, 31, 12, 0, 0, 0, time.UTC)
+	cases := map[string]time.Time{
+		"6.months":             time.Date(2020, 3, 2, 12, 0, 0, 0, time.UTC),
+		"2.weeks.ago":          time.Date(2020, 8, 17, 12, 0, 0, 0, time.UTC),
+		"1 day ago":            time.Date(2020, 8, 30, 12, 0, 0, 0, time.UTC),
+		"3.hours":              time.Date(2020, 8, 31, 9, 0, 0, 0, time.UTC),
+		"1.year":               time.Date(2019, 8, 31, 12, 0, 0, 0, time.UTC),
+		"2020-01-31":           time.Date(2020, 1, 31, 0, 0, 0, 0, time.UTC),
+		"2020-01-31T10:00:00Z": time.Date(2020, 1, 31, 10, 0, 0, 0, time.UTC),
+	}
+	for since, expected := range cases {
+		parsed, err := ParseSince(since, now)
+		assert.Nil(err, since)
+		assert.True(expected.Equal(parsed), "%s: %v", since, parsed)
+	}
+	for _, since := range []string{"", "months", "6.fortnights", "x.days"} {
+		_, err := ParseSince(since, now)
+		assert.NotNil(err, since)
+	}
+}
@@ -0,0 +1,30 @@
+package helper
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+var sizeUnits = map[string]int64{
+	"":   1,
+	"b":  1,
+	"k":  1 << 10,
+	"kb": 1 << 10,
+	"m":  1 << 20,
+	"mb": 1 << 20,
+	"g":  1 << 30,
+	"gb": 1 << 30,
+}
+
+// ParseSize parses a size in bytes like 512MB, 2GB or 2g, the units being powers of 1024
+func ParseSize(size string) (int64, error) {
+	size = strings.ToLower(strings.TrimSpace(size))
+	digits := strings.TrimRightFunc(size, func(r rune) bool { return r < '0' || r > '9' })
+	unit, ok := sizeUnits[strings.TrimSpace(strings.TrimSuffix(strings.TrimPrefix(size, digits), "ib"))]
+	n, err := strconv.ParseInt(digits, 10, 64)
+	if !ok || err != nil {
+		return 0, fmt.Errorf("invalid size %q, expected e.g. 512MB or 2GB", size)
+	}
+	return n * unit, nil
+}
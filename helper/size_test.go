@@ -0,0 +1,27 @@
+package helper
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseSize(t *testing.T) {
+	assert := assert.New(t)
+	cases := map[string]int64{
+		"1024":   1024,
+		"512MB":  512 << 20,
+		"2GB":    2 << 30,
+		"2g":     2 << 30,
+		"64 KiB": 64 << 10,
+	}
+	for size, expected := range cases {
+		parsed, err := ParseSize(size)
+		assert.Nil(err, size)
+		assert.Equal(expected, parsed, size)
+	}
+	for _, size := range []string{"", "GB", "2TB", "2.5GB"} {
+		_, err := ParseSize(size)
+		assert.NotNil(err, size)
+	}
+}
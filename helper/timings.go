@@ -1,14 +1,16 @@
 package helper
 
 import (
-	"log"
 	"time"
+
+	"github.com/andymeneely/git-churn/print"
 )
 
 func Track(msg string) (string, time.Time) {
 	return msg, time.Now()
 }
 
+// Duration logs the time elapsed since the start of the tracked step, at the debug level
 func Duration(msg string, start time.Time) {
-	log.Printf("%v: %v\n", msg, time.Since(start))
+	print.Log.Debug(msg, "duration", time.Since(start))
 }
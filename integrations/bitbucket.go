@@ -0,0 +1,104 @@
+package integrations
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const BitbucketAPI = "https://api.bitbucket.org/2.0"
+
+// BitbucketClient is a minimal client of the Bitbucket Cloud REST API
+type BitbucketClient struct {
+	BaseURL string
+	// Access token, or app password when Username is set, optional for public repositories unless posting
+	// comments
+	Token      string
+	Username   string
+	HTTPClient *http.Client
+}
+
+// NewBitbucketClient returns a client of the Bitbucket Cloud API authenticating with the given access
+// token, if any
+func NewBitbucketClient(token string) *BitbucketClient {
+	return &BitbucketClient{
+		BaseURL:    BitbucketAPI,
+		Token:      token,
+		HTTPClient: &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+// GetPullRequest looks up the base and head commits of a pull request. The owner is the workspace of
+// the repository. Bitbucket only gives abbreviated hashes.
+func (c *BitbucketClient) GetPullRequest(owner, repo string, number int) (*PullRequest, error) {
+	type endpoint struct {
+		Branch struct {
+			Name string `json:"name"`
+		} `json:"branch"`
+		Commit struct {
+			Hash string `json:"hash"`
+		} `json:"commit"`
+		Repository struct {
+			FullName string `json:"full_name"`
+		} `json:"repository"`
+	}
+	var body struct {
+		Title string `json:"title"`
+		Links struct {
+			HTML struct {
+				Href string `json:"href"`
+			} `json:"html"`
+		} `json:"links"`
+		Source      endpoint `json:"source"`
+		Destination endpoint `json:"destination"`
+	}
+	if err := c.do(http.MethodGet, fmt.Sprintf("/repositories/%s/%s/pullrequests/%d", owner, repo, number), nil, &body); err != nil {
+		return nil, err
+	}
+	site := "https://bitbucket.org"
+	if u, err := url.Parse(body.Links.HTML.Href); err == nil && u.Host != "" {
+		site = u.Scheme + "://" + u.Host
+	}
+	pr := &PullRequest{
+		Owner:    owner,
+		Repo:     repo,
+		Number:   number,
+		Title:    body.Title,
+		URL:      body.Links.HTML.Href,
+		CloneURL: site + "/" + body.Destination.Repository.FullName + ".git",
+		BaseRef:  body.Destination.Branch.Name,
+		BaseSHA:  body.Destination.Commit.Hash,
+		HeadRef:  body.Source.Branch.Name,
+		HeadSHA:  body.Source.Commit.Hash,
+		// Bitbucket keeps no ref for pull requests, the source branch is fetched instead
+		HeadFetchRef: "refs/heads/" + body.Source.Branch.Name,
+	}
+	if !strings.EqualFold(body.Source.Repository.FullName, body.Destination.Repository.FullName) {
+		pr.HeadCloneURL = site + "/" + body.Source.Repository.FullName + ".git"
+	}
+	return pr, nil
+}
+
+// PostComment adds a comment with the given markdown body to the pull request
+func (c *BitbucketClient) PostComment(owner, repo string, number int, comment string) error {
+	if c.Token == "" {
+		return errors.New("a Bitbucket token is needed to comment on pull requests")
+	}
+	request := map[string]interface{}{"content": map[string]string{"raw": comment}}
+	return c.do(http.MethodPost, fmt.Sprintf("/repositories/%s/%s/pullrequests/%d/comments", owner, repo, number), request, nil)
+}
+
+// do sends a request to the API, encoding `in` and decoding the response into `out` as JSON when not nil
+func (c *BitbucketClient) do(method, path string, in, out interface{}) error {
+	header := http.Header{}
+	if c.Token != "" && c.Username != "" {
+		header.Set("Authorization", "Basic "+base64.StdEncoding.EncodeToString([]byte(c.Username+":"+c.Token)))
+	} else if c.Token != "" {
+		header.Set("Authorization", "Bearer "+c.Token)
+	}
+	return doJSON(c.HTTPClient, "Bitbucket", method, strings.TrimSuffix(c.BaseURL, "/")+path, header, in, out)
+}
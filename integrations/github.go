@@ -0,0 +1,167 @@
+// Package integrations connects the churn metrics to code hosting services
+package integrations
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const GitHubAPI = "https://api.github.com"
+
+// PullRequest is a GitHub pull request, a GitLab merge request or a Bitbucket pull request
+type PullRequest struct {
+	Owner  string
+	Repo   string
+	Number int
+	Title  string
+	URL    string
+	// Clone URL of the repository the pull request is opened against
+	CloneURL string
+	BaseRef  string
+	BaseSHA  string
+	HeadRef  string
+	HeadSHA  string
+	// Ref the head is fetched from, e.g. refs/pull/42/head on GitHub
+	HeadFetchRef string
+	// Clone URL of the fork the head is fetched from, when the repository itself does not have it
+	HeadCloneURL string `json:",omitempty"`
+}
+
+// GitHubClient is a minimal client of the GitHub REST API
+type GitHubClient struct {
+	// Root of the API, GitHubAPI unless using GitHub Enterprise
+	BaseURL string
+	// Personal access token, optional for public repositories unless posting comments
+	Token      string
+	HTTPClient *http.Client
+}
+
+// NewGitHubClient returns a client of the public GitHub API authenticating with the given token, if any
+func NewGitHubClient(token string) *GitHubClient {
+	return &GitHubClient{
+		BaseURL:    GitHubAPI,
+		Token:      token,
+		HTTPClient: &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+// ParsePullRequestURL extracts the owner, the repository and the number from a pull request URL
+// like https://github.com/owner/repo/pull/42
+func ParsePullRequestURL(prURL string) (string, string, int, error) {
+	u, err := url.Parse(prURL)
+	if err != nil {
+		return "", "", 0, err
+	}
+	parts := strings.Split(strings.Trim(u.Path, "/"), "/")
+	if len(parts) < 4 || parts[2] != "pull" {
+		return "", "", 0, fmt.Errorf("%s is not a pull request URL, expected https://github.com/<owner>/<repo>/pull/<number>", prURL)
+	}
+	number, err := strconv.Atoi(parts[3])
+	if err != nil || number <= 0 {
+		return "", "", 0, fmt.Errorf("invalid pull request number %q in %s", parts[3], prURL)
+	}
+	return parts[0], parts[1], number, nil
+}
+
+// GetPullRequest looks up the base and head commits of a pull request
+func (c *GitHubClient) GetPullRequest(owner, repo string, number int) (*PullRequest, error) {
+	var body struct {
+		Title   string `json:"title"`
+		HTMLURL string `json:"html_url"`
+		Base    struct {
+			Ref  string `json:"ref"`
+			SHA  string `json:"sha"`
+			Repo struct {
+				CloneURL string `json:"clone_url"`
+			} `json:"repo"`
+		} `json:"base"`
+		Head struct {
+			Ref string `json:"ref"`
+			SHA string `json:"sha"`
+		} `json:"head"`
+	}
+	if err := c.do(http.MethodGet, fmt.Sprintf("/repos/%s/%s/pulls/%d", owner, repo, number), nil, &body); err != nil {
+		return nil, err
+	}
+	return &PullRequest{
+		Owner:    owner,
+		Repo:     repo,
+		Number:   number,
+		Title:    body.Title,
+		URL:      body.HTMLURL,
+		CloneURL: body.Base.Repo.CloneURL,
+		BaseRef:  body.Base.Ref,
+		BaseSHA:  body.Base.SHA,
+		HeadRef:  body.Head.Ref,
+		HeadSHA:  body.Head.SHA,
+		// GitHub keeps the head of every pull request, from a fork or not, in the base repository
+		HeadFetchRef: fmt.Sprintf("refs/pull/%d/head", number),
+	}, nil
+}
+
+// PostComment adds a comment with the given markdown body to the pull request
+func (c *GitHubClient) PostComment(owner, repo string, number int, comment string) error {
+	if c.Token == "" {
+		return errors.New("a GitHub token is needed to comment on pull requests")
+	}
+	request := map[string]string{"body": comment}
+	// Pull request comments are issue comments in the GitHub API
+	return c.do(http.MethodPost, fmt.Sprintf("/repos/%s/%s/issues/%d/comments", owner, repo, number), request, nil)
+}
+
+// do sends a request to the API, encoding `in` and decoding the response into `out` as JSON when not nil
+func (c *GitHubClient) do(method, path string, in, out interface{}) error {
+	header := http.Header{"Accept": {"application/vnd.github.v3+json"}}
+	if c.Token != "" {
+		header.Set("Authorization", "token "+c.Token)
+	}
+	return doJSON(c.HTTPClient, "GitHub", method, strings.TrimSuffix(c.BaseURL, "/")+path, header, in, out)
+}
+
+// doJSON sends a request to the API of a service, encoding `in` and decoding the response into `out` as
+// JSON when not nil
+func doJSON(client *http.Client, service, method, endpoint string, header http.Header, in, out interface{}) error {
+	var reqBody io.Reader
+	if in != nil {
+		encoded, err := json.Marshal(in)
+		if err != nil {
+			return err
+		}
+		reqBody = bytes.NewReader(encoded)
+	}
+	req, err := http.NewRequest(method, endpoint, reqBody)
+	if err != nil {
+		return err
+	}
+	for name, values := range header {
+		req.Header[name] = values
+	}
+	if in != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		message, _ := ioutil.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("%s API %s %s: %s: %s", service, method, req.URL.Path, resp.Status, strings.TrimSpace(string(message)))
+	}
+	if out == nil {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
@@ -0,0 +1,74 @@
+package integrations
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	metrics "github.com/andymeneely/git-churn/matrics"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParsePullRequestURL(t *testing.T) {
+	assert := assert.New(t)
+	owner, repo, number, err := ParsePullRequestURL("https://github.com/andymeneely/git-churn/pull/42/files")
+	assert.Nil(err)
+	assert.Equal("andymeneely", owner)
+	assert.Equal("git-churn", repo)
+	assert.Equal(42, number)
+
+	_, _, _, err = ParsePullRequestURL("https://github.com/andymeneely/git-churn/issues/42")
+	assert.NotNil(err)
+	_, _, _, err = ParsePullRequestURL("https://github.com/andymeneely/git-churn/pull/abc")
+	assert.NotNil(err)
+}
+
+func TestGitHubClient(t *testing.T) {
+	assert := assert.New(t)
+	var comment map[string]string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal("token secret", r.Header.Get("Authorization"))
+		switch r.Method + " " + r.URL.Path {
+		case "GET /repos/octo/hello/pulls/7":
+			w.Write([]byte(`{"title": "Add hello", "html_url": "https://github.com/octo/hello/pull/7",
+				"base": {"ref": "master", "sha": "aaa", "repo": {"clone_url": "https://github.com/octo/hello.git"}},
+				"head": {"ref": "feature", "sha": "bbb"}}`))
+		case "POST /repos/octo/hello/issues/7/comments":
+			assert.Nil(json.NewDecoder(r.Body).Decode(&comment))
+			w.WriteHeader(http.StatusCreated)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	client := NewGitHubClient("secret")
+	client.BaseURL = server.URL
+	pr, err := client.GetPullRequest("octo", "hello", 7)
+	assert.Nil(err)
+	assert.Equal(&PullRequest{Owner: "octo", Repo: "hello", Number: 7, Title: "Add hello",
+		URL: "https://github.com/octo/hello/pull/7", CloneURL: "https://github.com/octo/hello.git",
+		BaseRef: "master", BaseSHA: "aaa", HeadRef: "feature", HeadSHA: "bbb", HeadFetchRef: "refs/pull/7/head"}, pr)
+
+	assert.Nil(client.PostComment("octo", "hello", 7, "churn"))
+	assert.Equal("churn", comment["body"])
+
+	_, err = client.GetPullRequest("octo", "hello", 8)
+	assert.NotNil(err)
+}
+
+func TestPullRequestReportMarkdown(t *testing.T) {
+	report := PullRequestReport{Number: 7, Base: "aaaaaaaaaa", Head: "bbbbbbbbbb", RangeSummary: metrics.RangeSummary{
+		Commits: 2, Insertions: 10, Deletions: 3, FilesChanged: 1,
+		TopFiles: []metrics.FileChurn{{File: "main.go", Insertions: 10, Deletions: 3}},
+		Authors:  []metrics.AuthorChurn{{Author: "alice@example.com", Commits: 2, Insertions: 10, Deletions: 3}},
+	}, Reviewers: []metrics.ReviewerSuggestion{{Reviewer: "bob@example.com"}, {Reviewer: "carol@example.com"}}}
+	markdown := report.Markdown()
+	assert := assert.New(t)
+	assert.Contains(markdown, "2 commits changed 1 files: **+10 / -3** lines.")
+	assert.Contains(markdown, "| `main.go` | 10 | 3 |")
+	assert.Contains(markdown, "| alice@example.com | 2 | 10 | 3 |")
+	assert.Contains(markdown, "Suggested reviewers: bob@example.com, carol@example.com")
+	assert.Contains(markdown, "aaaaaaa..bbbbbbb")
+}
@@ -0,0 +1,90 @@
+package integrations
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const GitLabAPI = "https://gitlab.com/api/v4"
+
+// GitLabClient is a minimal client of the GitLab REST API
+type GitLabClient struct {
+	// Root of the API, GitLabAPI unless self-hosted
+	BaseURL string
+	// Personal, project or group access token, optional for public projects unless posting comments
+	Token      string
+	HTTPClient *http.Client
+}
+
+// NewGitLabClient returns a client of the GitLab.com API authenticating with the given token, if any
+func NewGitLabClient(token string) *GitLabClient {
+	return &GitLabClient{
+		BaseURL:    GitLabAPI,
+		Token:      token,
+		HTTPClient: &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+// GetPullRequest looks up the base and head commits of a merge request. The owner is the full path of
+// the group of the project, subgroups included.
+func (c *GitLabClient) GetPullRequest(owner, repo string, number int) (*PullRequest, error) {
+	var body struct {
+		Title        string `json:"title"`
+		WebURL       string `json:"web_url"`
+		TargetBranch string `json:"target_branch"`
+		SourceBranch string `json:"source_branch"`
+		DiffRefs     struct {
+			BaseSHA string `json:"base_sha"`
+			HeadSHA string `json:"head_sha"`
+		} `json:"diff_refs"`
+	}
+	if err := c.do(http.MethodGet, fmt.Sprintf("%s/merge_requests/%d", projectPath(owner, repo), number), nil, &body); err != nil {
+		return nil, err
+	}
+	// The project is the part of the merge request URL before /-/
+	cloneURL := body.WebURL
+	if i := strings.Index(cloneURL, "/-/"); i >= 0 {
+		cloneURL = cloneURL[:i] + ".git"
+	}
+	return &PullRequest{
+		Owner:    owner,
+		Repo:     repo,
+		Number:   number,
+		Title:    body.Title,
+		URL:      body.WebURL,
+		CloneURL: cloneURL,
+		BaseRef:  body.TargetBranch,
+		BaseSHA:  body.DiffRefs.BaseSHA,
+		HeadRef:  body.SourceBranch,
+		HeadSHA:  body.DiffRefs.HeadSHA,
+		// GitLab keeps the head of every merge request, from a fork or not, in the target project
+		HeadFetchRef: fmt.Sprintf("refs/merge-requests/%d/head", number),
+	}, nil
+}
+
+// PostComment adds a note with the given markdown body to the merge request
+func (c *GitLabClient) PostComment(owner, repo string, number int, comment string) error {
+	if c.Token == "" {
+		return errors.New("a GitLab token is needed to comment on merge requests")
+	}
+	request := map[string]string{"body": comment}
+	return c.do(http.MethodPost, fmt.Sprintf("%s/merge_requests/%d/notes", projectPath(owner, repo), number), request, nil)
+}
+
+// projectPath is the path of the project in the API, which is identified by its URL encoded full path
+func projectPath(owner, repo string) string {
+	return "/projects/" + url.PathEscape(owner+"/"+repo)
+}
+
+// do sends a request to the API, encoding `in` and decoding the response into `out` as JSON when not nil
+func (c *GitLabClient) do(method, path string, in, out interface{}) error {
+	header := http.Header{}
+	if c.Token != "" {
+		header.Set("Private-Token", c.Token)
+	}
+	return doJSON(c.HTTPClient, "GitLab", method, strings.TrimSuffix(c.BaseURL, "/")+path, header, in, out)
+}
@@ -0,0 +1,111 @@
+package integrations
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// Code hosting services
+const (
+	GitHub    = "github"
+	GitLab    = "gitlab"
+	Bitbucket = "bitbucket"
+)
+
+// CodeHost looks up the pull requests, or merge requests, of a code hosting service and comments on them
+type CodeHost interface {
+	GetPullRequest(owner, repo string, number int) (*PullRequest, error)
+	PostComment(owner, repo string, number int, comment string) error
+}
+
+// ReviewURL locates a pull request or a merge request
+type ReviewURL struct {
+	Service string
+	// Scheme and host of the site, e.g. https://gitlab.example.com
+	Site   string
+	Owner  string
+	Repo   string
+	Number int
+}
+
+// ParseReviewURL tells the service of a pull request or merge request URL by its path, so that self-hosted
+// sites are recognized too, and extracts its owner, repository and number:
+//
+//	https://github.com/<owner>/<repo>/pull/<number>
+//	https://gitlab.com/<group>[/<subgroup>...]/<project>/-/merge_requests/<number>
+//	https://bitbucket.org/<workspace>/<repo>/pull-requests/<number>
+//
+// The owner of a GitLab project is the full path of its group.
+func ParseReviewURL(reviewURL string) (*ReviewURL, error) {
+	u, err := url.Parse(reviewURL)
+	if err != nil {
+		return nil, err
+	}
+	review := &ReviewURL{Site: u.Scheme + "://" + u.Host}
+	path := strings.Trim(u.Path, "/")
+	var number string
+	if i := strings.Index(path, "/-/merge_requests/"); i >= 0 {
+		review.Service = GitLab
+		project := path[:i]
+		slash := strings.LastIndex(project, "/")
+		if slash < 0 {
+			return nil, fmt.Errorf("%s is not a merge request URL, expected https://gitlab.com/<group>/<project>/-/merge_requests/<number>", reviewURL)
+		}
+		review.Owner, review.Repo = project[:slash], project[slash+1:]
+		number = strings.Split(path[i+len("/-/merge_requests/"):], "/")[0]
+	} else {
+		parts := strings.Split(path, "/")
+		if len(parts) < 4 || parts[2] != "pull" && parts[2] != "pull-requests" {
+			return nil, fmt.Errorf("%s is not a pull request nor a merge request URL", reviewURL)
+		}
+		review.Service = GitHub
+		if parts[2] == "pull-requests" {
+			review.Service = Bitbucket
+		}
+		review.Owner, review.Repo, number = parts[0], parts[1], parts[3]
+	}
+	review.Number, err = strconv.Atoi(number)
+	if err != nil || review.Number <= 0 {
+		return nil, fmt.Errorf("invalid pull request number %q in %s", number, reviewURL)
+	}
+	return review, nil
+}
+
+// API returns the root of the API of the service on the site: the public API of GitHub, GitLab and
+// Bitbucket Cloud, or the one of GitHub Enterprise or of a self-hosted GitLab
+func (r *ReviewURL) API() string {
+	switch {
+	case r.Service == GitHub && r.Site == "https://github.com":
+		return GitHubAPI
+	case r.Service == GitHub:
+		return r.Site + "/api/v3"
+	case r.Service == GitLab:
+		return r.Site + "/api/v4"
+	default:
+		return BitbucketAPI
+	}
+}
+
+// NewCodeHost returns a client of the API of the service rooted at api, authenticating with the token if
+// any. A Bitbucket token is an app password when the user name is given.
+func NewCodeHost(service, api, token, username string) (CodeHost, error) {
+	switch service {
+	case GitHub:
+		client := NewGitHubClient(token)
+		client.BaseURL = api
+		return client, nil
+	case GitLab:
+		client := NewGitLabClient(token)
+		client.BaseURL = api
+		return client, nil
+	case Bitbucket:
+		client := NewBitbucketClient(token)
+		client.BaseURL = api
+		client.Username = username
+		return client, nil
+	default:
+		return nil, fmt.Errorf("unknown code hosting service %q, expected %s, %s or %s", service, GitHub, GitLab, Bitbucket)
+	}
+}
@@ -0,0 +1,161 @@
+package integrations
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os/exec"
+	"testing"
+
+	metrics "github.com/andymeneely/git-churn/matrics"
+	"github.com/andymeneely/git-churn/testutil"
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/src-d/go-billy.v4/memfs"
+	"gopkg.in/src-d/go-git.v4"
+	"gopkg.in/src-d/go-git.v4/storage/memory"
+)
+
+func TestParseReviewURL(t *testing.T) {
+	assert := assert.New(t)
+	cases := map[string]ReviewURL{
+		"https://github.com/octo/hello/pull/7/files":                   {Service: GitHub, Site: "https://github.com", Owner: "octo", Repo: "hello", Number: 7},
+		"https://gitlab.com/group/sub/hello/-/merge_requests/8":        {Service: GitLab, Site: "https://gitlab.com", Owner: "group/sub", Repo: "hello", Number: 8},
+		"https://git.example.com/group/hello/-/merge_requests/9/diffs": {Service: GitLab, Site: "https://git.example.com", Owner: "group", Repo: "hello", Number: 9},
+		"https://bitbucket.org/team/hello/pull-requests/10":            {Service: Bitbucket, Site: "https://bitbucket.org", Owner: "team", Repo: "hello", Number: 10},
+	}
+	for reviewURL, expected := range cases {
+		review, err := ParseReviewURL(reviewURL)
+		assert.Nil(err, reviewURL)
+		assert.Equal(&expected, review, reviewURL)
+	}
+
+	for _, invalid := range []string{
+		"https://github.com/octo/hello/issues/7",
+		"https://github.com/octo/hello/pull/abc",
+		"https://gitlab.com/hello/-/merge_requests/8",
+		"https://bitbucket.org/team/hello/pull-requests/0",
+	} {
+		_, err := ParseReviewURL(invalid)
+		assert.NotNil(err, invalid)
+	}
+
+	assert.Equal(GitHubAPI, (&ReviewURL{Service: GitHub, Site: "https://github.com"}).API())
+	assert.Equal("https://ghe.example.com/api/v3", (&ReviewURL{Service: GitHub, Site: "https://ghe.example.com"}).API())
+	assert.Equal("https://git.example.com/api/v4", (&ReviewURL{Service: GitLab, Site: "https://git.example.com"}).API())
+	assert.Equal(BitbucketAPI, (&ReviewURL{Service: Bitbucket, Site: "https://bitbucket.org"}).API())
+}
+
+func TestGitLabClient(t *testing.T) {
+	assert := assert.New(t)
+	var note map[string]string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal("secret", r.Header.Get("Private-Token"))
+		switch r.Method + " " + r.URL.EscapedPath() {
+		case "GET /projects/group%2Fhello/merge_requests/8":
+			w.Write([]byte(`{"title": "Add hello", "web_url": "https://gitlab.com/group/hello/-/merge_requests/8",
+				"target_branch": "main", "source_branch": "feature",
+				"diff_refs": {"base_sha": "aaa", "head_sha": "bbb", "start_sha": "ccc"}}`))
+		case "POST /projects/group%2Fhello/merge_requests/8/notes":
+			assert.Nil(json.NewDecoder(r.Body).Decode(&note))
+			w.WriteHeader(http.StatusCreated)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	host, err := NewCodeHost(GitLab, server.URL, "secret", "")
+	assert.Nil(err)
+	pr, err := host.GetPullRequest("group", "hello", 8)
+	assert.Nil(err)
+	assert.Equal(&PullRequest{Owner: "group", Repo: "hello", Number: 8, Title: "Add hello",
+		URL: "https://gitlab.com/group/hello/-/merge_requests/8", CloneURL: "https://gitlab.com/group/hello.git",
+		BaseRef: "main", BaseSHA: "aaa", HeadRef: "feature", HeadSHA: "bbb", HeadFetchRef: "refs/merge-requests/8/head"}, pr)
+
+	assert.Nil(host.PostComment("group", "hello", 8, "churn"))
+	assert.Equal("churn", note["body"])
+
+	_, err = host.GetPullRequest("group", "hello", 9)
+	assert.NotNil(err)
+	assert.NotNil(NewGitLabClient("").PostComment("group", "hello", 8, "churn"))
+}
+
+func TestBitbucketClient(t *testing.T) {
+	assert := assert.New(t)
+	var comment struct {
+		Content struct {
+			Raw string `json:"raw"`
+		} `json:"content"`
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user, password, ok := r.BasicAuth()
+		assert.True(ok)
+		assert.Equal("alice", user)
+		assert.Equal("secret", password)
+		switch r.Method + " " + r.URL.Path {
+		case "GET /repositories/team/hello/pullrequests/10":
+			w.Write([]byte(`{"title": "Add hello", "links": {"html": {"href": "https://bitbucket.org/team/hello/pull-requests/10"}},
+				"source": {"branch": {"name": "feature"}, "commit": {"hash": "bbbbbbbbbbbb"}, "repository": {"full_name": "alice/hello"}},
+				"destination": {"branch": {"name": "main"}, "commit": {"hash": "aaaaaaaaaaaa"}, "repository": {"full_name": "team/hello"}}}`))
+		case "POST /repositories/team/hello/pullrequests/10/comments":
+			assert.Nil(json.NewDecoder(r.Body).Decode(&comment))
+			w.WriteHeader(http.StatusCreated)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	host, err := NewCodeHost(Bitbucket, server.URL, "secret", "alice")
+	assert.Nil(err)
+	pr, err := host.GetPullRequest("team", "hello", 10)
+	assert.Nil(err)
+	assert.Equal(&PullRequest{Owner: "team", Repo: "hello", Number: 10, Title: "Add hello",
+		URL: "https://bitbucket.org/team/hello/pull-requests/10", CloneURL: "https://bitbucket.org/team/hello.git",
+		BaseRef: "main", BaseSHA: "aaaaaaaaaaaa", HeadRef: "feature", HeadSHA: "bbbbbbbbbbbb",
+		HeadFetchRef: "refs/heads/feature", HeadCloneURL: "https://bitbucket.org/alice/hello.git"}, pr)
+
+	assert.Nil(host.PostComment("team", "hello", 10, "churn"))
+	assert.Equal("churn", comment.Content.Raw)
+
+	_, err = NewCodeHost("gitea", server.URL, "", "")
+	assert.NotNil(err)
+}
+
+func TestPullRequestChurnFromBranch(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	assert := assert.New(t)
+	origin := testutil.NewDiskRepo(t)
+	defer origin.Remove()
+	origin.CommitFiles("Add a", map[string]string{"a.txt": "1\n2\n"})
+	base := origin.Head()
+	origin.Branch("feature").As("bob").CommitFiles("Change a", map[string]string{"a.txt": "1\nb\nc\n"})
+	origin.Checkout("master")
+
+	repo, err := git.Clone(memory.NewStorage(), memfs.New(), &git.CloneOptions{URL: origin.Dir})
+	assert.Nil(err)
+	// Abbreviated hashes like Bitbucket's
+	pr := &PullRequest{Number: 10, BaseRef: "master", BaseSHA: base.Hash.String()[:12], HeadRef: "feature",
+		HeadSHA: "bbbbbbbbbbbb", HeadFetchRef: "refs/heads/feature"}
+	report, err := PullRequestChurn(repo, pr, 0)
+	assert.Nil(err)
+	assert.Equal(1, report.Commits)
+	assert.Equal(2, report.Insertions)
+	assert.Equal(1, report.Deletions)
+
+	// alice wrote the line bob changed
+	reviewers, err := PullRequestReviewers(repo, pr, metrics.ReviewerOptions{})
+	assert.Nil(err)
+	if assert.Len(reviewers, 1) {
+		assert.Equal("alice@example.com", reviewers[0].Reviewer)
+		assert.Equal(1, reviewers[0].OwnedLines)
+	}
+
+	// From the fork of the pull request
+	pr.Number, pr.HeadCloneURL = 11, origin.Dir
+	report, err = PullRequestChurn(repo, pr, 0)
+	assert.Nil(err)
+	assert.Equal(1, report.Commits)
+}
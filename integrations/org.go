@@ -0,0 +1,166 @@
+package integrations
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/andymeneely/git-churn/gitfuncs"
+	metrics "github.com/andymeneely/git-churn/matrics"
+	. "github.com/andymeneely/git-churn/print"
+	"github.com/andymeneely/git-churn/survey"
+	"gopkg.in/src-d/go-git.v4"
+	githttp "gopkg.in/src-d/go-git.v4/plumbing/transport/http"
+)
+
+// Repository is a repository of a GitHub organization
+type Repository struct {
+	Name          string
+	FullName      string
+	CloneURL      string
+	Language      string
+	DefaultBranch string
+	Archived      bool
+	Fork          bool
+}
+
+// Number of repositories per page of the listings, the most the API allows
+const reposPerPage = 100
+
+// ListOrgRepos lists all the repositories of the organization the token can see, page after page
+func (c *GitHubClient) ListOrgRepos(org string) ([]Repository, error) {
+	var repos []Repository
+	for page := 1; ; page++ {
+		var body []struct {
+			Name          string `json:"name"`
+			FullName      string `json:"full_name"`
+			CloneURL      string `json:"clone_url"`
+			Language      string `json:"language"`
+			DefaultBranch string `json:"default_branch"`
+			Archived      bool   `json:"archived"`
+			Fork          bool   `json:"fork"`
+		}
+		path := fmt.Sprintf("/orgs/%s/repos?type=all&per_page=%d&page=%d", org, reposPerPage, page)
+		if err := c.do(http.MethodGet, path, nil, &body); err != nil {
+			return nil, err
+		}
+		for _, r := range body {
+			repos = append(repos, Repository{
+				Name:          r.Name,
+				FullName:      r.FullName,
+				CloneURL:      r.CloneURL,
+				Language:      r.Language,
+				DefaultBranch: r.DefaultBranch,
+				Archived:      r.Archived,
+				Fork:          r.Fork,
+			})
+		}
+		if len(body) < reposPerPage {
+			return repos, nil
+		}
+	}
+}
+
+// Clone clones the repository like gitfuncs.CloneRepository, authenticating with the token if
+// any, e.g. for the private repositories of an organization
+func (c *GitHubClient) Clone(repoUrl string) (*git.Repository, error) {
+	Info("git clone " + repoUrl)
+	options := &git.CloneOptions{URL: repoUrl}
+	if c.Token != "" {
+		// Any user name goes along with a token
+		options.Auth = &githttp.BasicAuth{Username: "x-access-token", Password: c.Token}
+	}
+	return gitfuncs.CloneWithOptions(options)
+}
+
+// RepoFilter tells the repositories of an organization to analyze. Archived repositories and forks are
+// left out unless asked for.
+type RepoFilter struct {
+	// Main languages of the repositories to keep, case insensitive, all of them if empty
+	Languages []string
+	Archived  bool
+	Forks     bool
+}
+
+// Keep tells whether the repository passes the filter
+func (f RepoFilter) Keep(repo Repository) bool {
+	if repo.Archived && !f.Archived || repo.Fork && !f.Forks {
+		return false
+	}
+	if len(f.Languages) == 0 {
+		return true
+	}
+	for _, language := range f.Languages {
+		if strings.EqualFold(language, repo.Language) {
+			return true
+		}
+	}
+	return false
+}
+
+// OrgHotspot is a hotspot of a repository of an organization
+type OrgHotspot struct {
+	Repo string
+	metrics.Hotspot
+}
+
+// OrgReport is the churn of the repositories of an organization, keyed by clone URL, with the hottest
+// files across them
+type OrgReport struct {
+	Org string
+	// Repositories listed and left out by the filter
+	Listed  int
+	Skipped int
+	survey.Report
+	Hotspots []OrgHotspot
+}
+
+// OrgChurn surveys the repositories of the organization passing the filter and ranks the hotspots the
+// surveyor finds in each of them across the organization, returning the n hottest, all of them if n is
+// zero. The scores of the hotspots are normalized per repository, so they are ranked by commits times LOC
+// and normalized again for the hottest file of the organization to score 1.
+func OrgChurn(org string, repos []Repository, filter RepoFilter, s *survey.Surveyor, n int) *OrgReport {
+	report := &OrgReport{Org: org, Listed: len(repos)}
+	var urls []string
+	names := make(map[string]string)
+	for _, repo := range repos {
+		if !filter.Keep(repo) {
+			report.Skipped += 1
+			continue
+		}
+		urls = append(urls, repo.CloneURL)
+		names[repo.CloneURL] = repo.FullName
+	}
+	report.Report = *s.Run(urls)
+
+	maxScore := 0.0
+	for repoUrl, r := range report.Repos {
+		for _, hotspot := range r.Hotspots {
+			hotspot.Score = float64(hotspot.Commits * hotspot.LOC)
+			if hotspot.Score > maxScore {
+				maxScore = hotspot.Score
+			}
+			report.Hotspots = append(report.Hotspots, OrgHotspot{Repo: names[repoUrl], Hotspot: hotspot})
+		}
+	}
+	for i := range report.Hotspots {
+		if maxScore > 0 {
+			report.Hotspots[i].Score /= maxScore
+		}
+	}
+	sort.Slice(report.Hotspots, func(i, j int) bool {
+		a, b := report.Hotspots[i], report.Hotspots[j]
+		if a.Score != b.Score {
+			return a.Score > b.Score
+		}
+		if a.Repo != b.Repo {
+			return a.Repo < b.Repo
+		}
+		return a.File < b.File
+	})
+	if n > 0 && len(report.Hotspots) > n {
+		report.Hotspots = report.Hotspots[:n]
+	}
+	return report
+}
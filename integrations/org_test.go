@@ -0,0 +1,95 @@
+package integrations
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	metrics "github.com/andymeneely/git-churn/matrics"
+	"github.com/andymeneely/git-churn/survey"
+	"github.com/andymeneely/git-churn/testutil"
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/src-d/go-git.v4"
+)
+
+func TestListOrgRepos(t *testing.T) {
+	assert := assert.New(t)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/orgs/octo/repos" {
+			http.NotFound(w, r)
+			return
+		}
+		assert.Equal("100", r.URL.Query().Get("per_page"))
+		// A full first page and a partial second one
+		count := 100
+		if r.URL.Query().Get("page") == "2" {
+			count = 1
+		}
+		var repos []map[string]interface{}
+		for i := 0; i < count; i++ {
+			name := fmt.Sprintf("repo%s-%d", r.URL.Query().Get("page"), i)
+			repos = append(repos, map[string]interface{}{"name": name, "full_name": "octo/" + name,
+				"clone_url": "https://github.com/octo/" + name + ".git", "language": "Go",
+				"default_branch": "main", "archived": i == 0, "fork": false})
+		}
+		assert.Nil(json.NewEncoder(w).Encode(repos))
+	}))
+	defer server.Close()
+
+	client := NewGitHubClient("")
+	client.BaseURL = server.URL
+	repos, err := client.ListOrgRepos("octo")
+	assert.Nil(err)
+	assert.Len(repos, 101)
+	assert.Equal(Repository{Name: "repo1-0", FullName: "octo/repo1-0", CloneURL: "https://github.com/octo/repo1-0.git",
+		Language: "Go", DefaultBranch: "main", Archived: true}, repos[0])
+	assert.Equal("repo2-0", repos[100].Name)
+
+	_, err = client.ListOrgRepos("missing")
+	assert.NotNil(err)
+}
+
+func TestRepoFilter(t *testing.T) {
+	assert := assert.New(t)
+	goRepo := Repository{Name: "a", Language: "Go"}
+	assert.True(RepoFilter{}.Keep(goRepo))
+	assert.True(RepoFilter{Languages: []string{"python", "go"}}.Keep(goRepo))
+	assert.False(RepoFilter{Languages: []string{"Python"}}.Keep(goRepo))
+	assert.False(RepoFilter{}.Keep(Repository{Name: "b", Archived: true}))
+	assert.True(RepoFilter{Archived: true}.Keep(Repository{Name: "b", Archived: true}))
+	assert.False(RepoFilter{}.Keep(Repository{Name: "c", Fork: true}))
+	assert.True(RepoFilter{Forks: true}.Keep(Repository{Name: "c", Fork: true}))
+}
+
+func TestOrgChurn(t *testing.T) {
+	assert := assert.New(t)
+	s := survey.New(2, testutil.Start, 0)
+	s.Hotspots = 10
+	s.Clone = func(repoUrl string) (*git.Repository, error) {
+		repo := testutil.NewRepo(t)
+		repo.CommitFiles("Add a", map[string]string{"a.txt": "1\n2\n"})
+		if strings.HasSuffix(repoUrl, "/big.git") {
+			repo.CommitFiles("Add b", map[string]string{"b.txt": "1\n2\n3\n4\n"})
+			repo.CommitFiles("Change b", map[string]string{"b.txt": "1\n2\n3\n5\n"})
+		}
+		return repo.Repository, nil
+	}
+	repos := []Repository{
+		{FullName: "octo/small", CloneURL: "https://github.com/octo/small.git"},
+		{FullName: "octo/big", CloneURL: "https://github.com/octo/big.git"},
+		{FullName: "octo/old", CloneURL: "https://github.com/octo/old.git", Archived: true},
+	}
+	report := OrgChurn("octo", repos, RepoFilter{}, s, 2)
+	assert.Equal(3, report.Listed)
+	assert.Equal(1, report.Skipped)
+	assert.Equal(2, report.Analyzed)
+	assert.Len(report.Repos, 2)
+	assert.Equal(4, report.Commits)
+	assert.Equal([]OrgHotspot{
+		{Repo: "octo/big", Hotspot: metrics.Hotspot{File: "b.txt", Commits: 2, Churn: 6, LOC: 4, Score: 1}},
+		{Repo: "octo/big", Hotspot: metrics.Hotspot{File: "a.txt", Commits: 1, Churn: 2, LOC: 2, Score: 0.25}},
+	}, report.Hotspots)
+}
@@ -0,0 +1,106 @@
+package integrations
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/andymeneely/git-churn/gitfuncs"
+	metrics "github.com/andymeneely/git-churn/matrics"
+	"gopkg.in/src-d/go-git.v4"
+)
+
+type PullRequestReport struct {
+	URL    string
+	Number int
+	Title  string
+	Base   string
+	Head   string
+	metrics.RangeSummary
+	// Suggested by PullRequestReviewers, when asked for
+	Reviewers []metrics.ReviewerSuggestion `json:",omitempty"`
+}
+
+// PullRequestRef is where the head of a pull request is fetched to, as it may live in a fork
+func PullRequestRef(number int) string {
+	return fmt.Sprintf("refs/remotes/%s/pull/%d", git.DefaultRemoteName, number)
+}
+
+// PullRequestChurn fetches the head of the pull request into the cloned repository and summarizes the
+// churn of exactly its commits, those reachable from its head but not from its base
+func PullRequestChurn(repo *git.Repository, pr *PullRequest, topN int) (*PullRequestReport, error) {
+	refspec := fmt.Sprintf("+%s:%s", pr.HeadFetchRef, PullRequestRef(pr.Number))
+	var err error
+	if pr.HeadCloneURL != "" {
+		err = gitfuncs.FetchRefsFrom(repo, pr.HeadCloneURL, refspec)
+	} else {
+		err = gitfuncs.FetchRefs(repo, refspec)
+	}
+	if err != nil {
+		return nil, err
+	}
+	base, head := pullRequestRange(pr)
+	commits, err := metrics.RangeChurn(repo, base, head)
+	if err != nil {
+		return nil, err
+	}
+	return &PullRequestReport{
+		URL:          pr.URL,
+		Number:       pr.Number,
+		Title:        pr.Title,
+		Base:         pr.BaseSHA,
+		Head:         pr.HeadSHA,
+		RangeSummary: metrics.SummarizeRange(commits, topN),
+	}, nil
+}
+
+// PullRequestReviewers suggests reviewers for the pull request whose head PullRequestChurn fetched, see
+// metrics.SuggestReviewers
+func PullRequestReviewers(repo *git.Repository, pr *PullRequest, options metrics.ReviewerOptions) ([]metrics.ReviewerSuggestion, error) {
+	base, head := pullRequestRange(pr)
+	suggestions, err := metrics.SuggestReviewers(repo, base, head, options)
+	if err != nil {
+		return nil, err
+	}
+	return suggestions.Suggestions, nil
+}
+
+// pullRequestRange returns the revisions of the base and the head of the pull request once fetched
+func pullRequestRange(pr *PullRequest) (string, string) {
+	base, head := pr.BaseSHA, pr.HeadSHA
+	// Bitbucket only gives abbreviated hashes, which do not resolve, but the fetched refs do
+	if len(base) < 40 {
+		base = pr.BaseRef
+	}
+	if len(head) < 40 {
+		head = PullRequestRef(pr.Number)
+	}
+	return base, head
+}
+
+// Markdown renders the report as a pull request comment
+func (r *PullRequestReport) Markdown() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "### Churn of #%d\n\n", r.Number)
+	fmt.Fprintf(&b, "%d commits changed %d files: **+%d / -%d** lines.\n", r.Commits, r.FilesChanged, r.Insertions, r.Deletions)
+	if len(r.TopFiles) > 0 {
+		b.WriteString("\n| File | Insertions | Deletions |\n|---|---:|---:|\n")
+		for _, file := range r.TopFiles {
+			fmt.Fprintf(&b, "| `%s` | %d | %d |\n", file.File, file.Insertions, file.Deletions)
+		}
+	}
+	if len(r.Authors) > 0 {
+		b.WriteString("\n| Author | Commits | Insertions | Deletions |\n|---|---:|---:|---:|\n")
+		for _, author := range r.Authors {
+			fmt.Fprintf(&b, "| %s | %d | %d | %d |\n", author.Author, author.Commits, author.Insertions, author.Deletions)
+		}
+	}
+	if len(r.Reviewers) > 0 {
+		reviewers := make([]string, len(r.Reviewers))
+		for i, reviewer := range r.Reviewers {
+			reviewers[i] = reviewer.Reviewer
+		}
+		fmt.Fprintf(&b, "\nSuggested reviewers: %s\n", strings.Join(reviewers, ", "))
+	}
+	fmt.Fprintf(&b, "\n<sub>Generated by git-churn on %.7s..%.7s</sub>\n", r.Base, r.Head)
+	return b.String()
+}
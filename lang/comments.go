@@ -0,0 +1,142 @@
+package lang
+
+import "strings"
+
+// CommentSyntax is how comments are written in a language
+type CommentSyntax struct {
+	// Prefixes of the comments running to the end of the line
+	Line []string
+	// Delimiters of the comments that can span several lines, empty if the language has none
+	BlockStart string
+	BlockEnd   string
+}
+
+var (
+	cLike     = CommentSyntax{Line: []string{"//"}, BlockStart: "/*", BlockEnd: "*/"}
+	hashLine  = CommentSyntax{Line: []string{"#"}}
+	markup    = CommentSyntax{BlockStart: "<!--", BlockEnd: "-->"}
+	noComment = CommentSyntax{}
+)
+
+var commentSyntaxes = map[string]CommentSyntax{
+	"Go":               cLike,
+	"Java":             cLike,
+	"Kotlin":           cLike,
+	"Scala":            cLike,
+	"C":                cLike,
+	"C++":              cLike,
+	"C#":               cLike,
+	"Rust":             cLike,
+	"JavaScript":       cLike,
+	"TypeScript":       cLike,
+	"Swift":            cLike,
+	"Objective-C":      cLike,
+	"SCSS":             cLike,
+	"Protocol Buffers": cLike,
+	"Go Module":        cLike,
+	"PHP":              {Line: []string{"//", "#"}, BlockStart: "/*", BlockEnd: "*/"},
+	"CSS":              {BlockStart: "/*", BlockEnd: "*/"},
+	"SQL":              {Line: []string{"--"}, BlockStart: "/*", BlockEnd: "*/"},
+	"Lua":              {Line: []string{"--"}, BlockStart: "--[[", BlockEnd: "]]"},
+	"Python":           hashLine,
+	"Ruby":             hashLine,
+	"Shell":            hashLine,
+	"Perl":             hashLine,
+	"R":                hashLine,
+	"YAML":             hashLine,
+	"TOML":             hashLine,
+	"Makefile":         hashLine,
+	"Dockerfile":       hashLine,
+	"HTML":             markup,
+	"XML":              markup,
+	"Markdown":         markup,
+	"FreeMarker":       {BlockStart: "<#--", BlockEnd: "-->"},
+}
+
+// Comments returns the comment syntax of the language. Languages without comments, or unknown ones,
+// have an empty syntax, so only their blank lines are not code.
+func Comments(language string) CommentSyntax {
+	if syntax, ok := commentSyntaxes[language]; ok {
+		return syntax
+	}
+	return noComment
+}
+
+// CodeLines tells which of the lines of a file written in the language are code, that is neither blank
+// nor only made of comments. Like cloc, comment delimiters inside string literals are not told apart.
+func CodeLines(language string, lines []string) []bool {
+	tracker := NewCodeTracker(language)
+	code := make([]bool, len(lines))
+	for i, line := range lines {
+		code[i] = tracker.IsCode(line)
+	}
+	return code
+}
+
+// CodeTracker tells the code lines of a file like CodeLines, one line at a time, so that the lines of big
+// files can be streamed instead of being held at once
+type CodeTracker struct {
+	syntax  CommentSyntax
+	inBlock bool
+}
+
+// NewCodeTracker returns a tracker of the lines of a file written in the language, from its first line
+func NewCodeTracker(language string) *CodeTracker {
+	return &CodeTracker{syntax: Comments(language)}
+}
+
+// IsCode tells whether the next line of the file is code
+func (t *CodeTracker) IsCode(line string) bool {
+	return t.syntax.isCode(line, &t.inBlock)
+}
+
+// CodeLOC returns the number of lines of code, neither blank nor only comments, of a file written in the language
+func CodeLOC(language string, lines []string) int {
+	loc := 0
+	for _, code := range CodeLines(language, lines) {
+		if code {
+			loc += 1
+		}
+	}
+	return loc
+}
+
+// isCode tells whether the line has code outside comments. inBlock tracks whether a block comment is
+// open at the start of the line, and is updated for the next line.
+func (s CommentSyntax) isCode(line string, inBlock *bool) bool {
+	code := false
+	for line = strings.TrimSpace(line); line != ""; line = strings.TrimSpace(line) {
+		if *inBlock {
+			end := strings.Index(line, s.BlockEnd)
+			if end < 0 {
+				return code
+			}
+			line = line[end+len(s.BlockEnd):]
+			*inBlock = false
+			continue
+		}
+		// Block comments first, as they may start like line comments, e.g. --[[ and -- in Lua
+		if s.BlockStart != "" && strings.HasPrefix(line, s.BlockStart) {
+			line = line[len(s.BlockStart):]
+			*inBlock = true
+			continue
+		}
+		for _, prefix := range s.Line {
+			if strings.HasPrefix(line, prefix) {
+				return code
+			}
+		}
+
+		code = true
+		// The rest of the line only matters if it opens a block comment
+		if s.BlockStart == "" {
+			return true
+		}
+		start := strings.Index(line, s.BlockStart)
+		if start < 0 {
+			return true
+		}
+		line = line[start:]
+	}
+	return code
+}
@@ -0,0 +1,128 @@
+package lang
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// CtagsParser finds the functions, methods and classes of the languages universal-ctags supports by running
+// it with JSON output. Languages are only supported if ctags is installed.
+type CtagsParser struct {
+	// Command run, ctags on the PATH if empty
+	Command string
+}
+
+// Languages, as detected by Detect, handed to ctags
+var ctagsLanguages = map[string]bool{
+	"Java": true, "Kotlin": true, "Scala": true, "C": true, "C++": true, "C#": true, "Rust": true,
+	"Python": true, "Ruby": true, "PHP": true, "JavaScript": true, "TypeScript": true, "Swift": true,
+	"Objective-C": true, "Perl": true, "Lua": true, "Shell": true,
+}
+
+// Kinds of the ctags tags reported as symbols, by ctags kind name
+var ctagsKinds = map[string]string{
+	"function":        KindFunction,
+	"subroutine":      KindFunction,
+	"method":          KindMethod,
+	"singletonMethod": KindMethod,
+	"member":          KindMethod,
+	"constructor":     KindMethod,
+	"class":           KindClass,
+	"interface":       KindClass,
+	"struct":          KindClass,
+	"enum":            KindClass,
+	"trait":           KindClass,
+	"object":          KindClass,
+	"module":          KindClass,
+}
+
+var ctagsInstalled = struct {
+	sync.Once
+	ok bool
+}{}
+
+func (p CtagsParser) command() string {
+	if p.Command == "" {
+		return "ctags"
+	}
+	return p.Command
+}
+
+func (p CtagsParser) Supports(language string) bool {
+	if !ctagsLanguages[language] {
+		return false
+	}
+	if p.Command != "" {
+		return true
+	}
+	ctagsInstalled.Do(func() {
+		_, err := exec.LookPath("ctags")
+		ctagsInstalled.ok = err == nil
+	})
+	return ctagsInstalled.ok
+}
+
+// Symbols writes the source to a temporary file named like the file at the path, for ctags to detect its
+// language, and runs ctags on it
+func (p CtagsParser) Symbols(path string, src []byte) ([]Symbol, error) {
+	dir, err := ioutil.TempDir("", "git-churn-ctags")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(dir)
+	file := filepath.Join(dir, filepath.Base(path))
+	if err := ioutil.WriteFile(file, src, 0644); err != nil {
+		return nil, err
+	}
+	var stderr bytes.Buffer
+	cmd := exec.Command(p.command(), "--output-format=json", "--fields=+neKs", "-f", "-", file)
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("ctags %s: %v: %s", path, err, strings.TrimSpace(stderr.String()))
+	}
+	return parseCtagsJSON(bytes.NewReader(out))
+}
+
+// ctagsTag is a tag of the JSON output of universal-ctags
+type ctagsTag struct {
+	Type  string `json:"_type"`
+	Name  string `json:"name"`
+	Kind  string `json:"kind"`
+	Line  int    `json:"line"`
+	End   int    `json:"end"`
+	Scope string `json:"scope"`
+}
+
+// parseCtagsJSON reads the symbols of the tags of the given kinds with a known end line, named after their
+// scope, e.g. Parser.parse
+func parseCtagsJSON(r io.Reader) ([]Symbol, error) {
+	var symbols []Symbol
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		var tag ctagsTag
+		if err := json.Unmarshal(scanner.Bytes(), &tag); err != nil {
+			return nil, fmt.Errorf("invalid ctags output %q: %v", scanner.Text(), err)
+		}
+		kind, ok := ctagsKinds[tag.Kind]
+		if tag.Type != "tag" || !ok || tag.Line == 0 || tag.End < tag.Line {
+			continue
+		}
+		name := tag.Name
+		if tag.Scope != "" {
+			name = tag.Scope + "." + name
+		}
+		symbols = append(symbols, Symbol{Name: name, Kind: kind, StartLine: tag.Line, EndLine: tag.End})
+	}
+	return symbols, scanner.Err()
+}
@@ -0,0 +1,74 @@
+// Package lang maps file paths to the programming language they are written in.
+package lang
+
+import (
+	"path"
+	"strings"
+)
+
+// Unknown is reported for files whose language could not be detected
+const Unknown = "Other"
+
+var languagesByExt = map[string]string{
+	".go":    "Go",
+	".java":  "Java",
+	".kt":    "Kotlin",
+	".scala": "Scala",
+	".c":     "C",
+	".h":     "C",
+	".cc":    "C++",
+	".cpp":   "C++",
+	".cxx":   "C++",
+	".hpp":   "C++",
+	".cs":    "C#",
+	".rs":    "Rust",
+	".py":    "Python",
+	".rb":    "Ruby",
+	".php":   "PHP",
+	".js":    "JavaScript",
+	".jsx":   "JavaScript",
+	".mjs":   "JavaScript",
+	".ts":    "TypeScript",
+	".tsx":   "TypeScript",
+	".swift": "Swift",
+	".m":     "Objective-C",
+	".sh":    "Shell",
+	".bash":  "Shell",
+	".pl":    "Perl",
+	".lua":   "Lua",
+	".r":     "R",
+	".sql":   "SQL",
+	".html":  "HTML",
+	".htm":   "HTML",
+	".css":   "CSS",
+	".scss":  "SCSS",
+	".xml":   "XML",
+	".json":  "JSON",
+	".yml":   "YAML",
+	".yaml":  "YAML",
+	".toml":  "TOML",
+	".md":    "Markdown",
+	".proto": "Protocol Buffers",
+	".ftl":   "FreeMarker",
+}
+
+var languagesByName = map[string]string{
+	"Makefile":   "Makefile",
+	"Dockerfile": "Dockerfile",
+	"Rakefile":   "Ruby",
+	"Gemfile":    "Ruby",
+	"go.mod":     "Go Module",
+	"go.sum":     "Go Module",
+}
+
+// Detect returns the language of the file at the given path based on its name and extension
+func Detect(filePath string) string {
+	name := path.Base(filePath)
+	if language, ok := languagesByName[name]; ok {
+		return language
+	}
+	if language, ok := languagesByExt[strings.ToLower(path.Ext(name))]; ok {
+		return language
+	}
+	return Unknown
+}
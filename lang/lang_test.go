@@ -0,0 +1,121 @@
+package lang
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDetect(t *testing.T) {
+	assert := assert.New(t)
+	assert.Equal("Go", Detect("gitfuncs/gitfuncs.go"))
+	assert.Equal("Java", Detect("src/main/java/com/webcheckers/ui/WebServer.java"))
+	assert.Equal("YAML", Detect(".github/workflows/go.yml"))
+	assert.Equal("Makefile", Detect("Makefile"))
+	assert.Equal("C++", Detect("src/Main.CPP"))
+	assert.Equal(Unknown, Detect("LICENSE"))
+}
+
+func TestCodeLines(t *testing.T) {
+	assert := assert.New(t)
+	goFile := []string{
+		"// Package main",
+		"package main",
+		"",
+		"/* block",
+		"   comment */",
+		"func main() { /* inline */ }",
+		"	x := 1 /* opens",
+		"	closes */ y := 2",
+		"	/* a */ /* b */",
+		"	return // trailing",
+	}
+	assert.Equal([]bool{false, true, false, false, false, true, true, true, false, true}, CodeLines("Go", goFile))
+	assert.Equal(5, CodeLOC("Go", goFile))
+
+	assert.Equal([]bool{false, true, false}, CodeLines("Python", []string{"# comment", "x = 1  # one", "   "}))
+	assert.Equal([]bool{false, false, true}, CodeLines("Lua", []string{"--[[ long", "comment ]]", "print(1) -- hi"}))
+	assert.Equal([]bool{true, false}, CodeLines("JSON", []string{`{"a": "//"}`, ""}))
+	assert.Equal([]bool{true, false}, CodeLines(Unknown, []string{"# not a comment", " "}))
+}
+
+func TestIsTest(t *testing.T) {
+	assert := assert.New(t)
+	classifier := NewTestClassifier(nil)
+	assert.True(classifier.IsTest("matrics/churnmetrics_test.go"))
+	assert.True(classifier.IsTest("src/test/java/com/webcheckers/ui/WebServerTest.java"))
+	assert.True(classifier.IsTest("web/spec/models/user_spec.rb"))
+	assert.True(classifier.IsTest("app/__tests__/App.test.tsx"))
+	assert.False(classifier.IsTest("matrics/churnmetrics.go"))
+	assert.False(classifier.IsTest("src/main/java/com/webcheckers/ui/WebServer.java"))
+	assert.False(classifier.IsTest("contest/main.go"))
+
+	classifier = NewTestClassifier([]string{"qa/*.sh", "check_*"})
+	assert.True(classifier.IsTest("qa/run.sh"))
+	assert.True(classifier.IsTest("scripts/check_links.py"))
+	assert.False(classifier.IsTest("qa/sub/run.sh"))
+	assert.False(classifier.IsTest("main_test.go"))
+}
+
+func TestGoFunctions(t *testing.T) {
+	src := `package main
+
+// main runs
+func main() {
+	run()
+}
+
+type server struct{}
+
+func (s *server) serve() {}
+
+func (s server) String() string {
+	return ""
+}
+
+func broken( {
+`
+	assert.Equal(t, []Symbol{
+		{Name: "main", Kind: KindFunction, StartLine: 3, EndLine: 6},
+		{Name: "(*server).serve", Kind: KindMethod, StartLine: 10, EndLine: 10},
+		{Name: "server.String", Kind: KindMethod, StartLine: 12, EndLine: 14},
+	}, GoFunctions([]byte(src)))
+}
+
+func TestParseCtagsJSON(t *testing.T) {
+	assert := assert.New(t)
+	output := `{"_type": "ptag", "name": "JSON_OUTPUT_VERSION", "path": "0.0"}
+{"_type": "tag", "name": "Parser", "path": "p.py", "pattern": "/^class Parser:$/", "line": 1, "kind": "class", "end": 6}
+{"_type": "tag", "name": "parse", "path": "p.py", "pattern": "/^    def parse(self):$/", "line": 2, "kind": "member", "scope": "Parser", "scopeKind": "class", "end": 3}
+{"_type": "tag", "name": "VERSION", "path": "p.py", "pattern": "/^VERSION = 1$/", "line": 8, "kind": "variable"}
+{"_type": "tag", "name": "main", "path": "p.py", "pattern": "/^def main():$/", "line": 10, "kind": "function", "end": 11}
+{"_type": "tag", "name": "stub", "path": "p.py", "pattern": "/^def stub():$/", "line": 13, "kind": "function"}
+`
+	symbols, err := parseCtagsJSON(strings.NewReader(output))
+	assert.Nil(err)
+	assert.Equal([]Symbol{
+		{Name: "Parser", Kind: KindClass, StartLine: 1, EndLine: 6},
+		{Name: "Parser.parse", Kind: KindMethod, StartLine: 2, EndLine: 3},
+		{Name: "main", Kind: KindFunction, StartLine: 10, EndLine: 11},
+	}, symbols)
+
+	_, err = parseCtagsJSON(strings.NewReader("!_TAG_FILE_FORMAT\t2\n"))
+	assert.NotNil(err)
+}
+
+func TestParserFor(t *testing.T) {
+	assert := assert.New(t)
+	assert.Equal(GoParser{}, ParserFor("main.go"))
+	assert.Nil(ParserFor("README.md"))
+	assert.True(CtagsParser{Command: "ctags"}.Supports("Python"))
+	assert.False(CtagsParser{Command: "ctags"}.Supports("Markdown"))
+}
+
+func TestTokenize(t *testing.T) {
+	assert := assert.New(t)
+	assert.Equal([]string{"if", "x_1", "=", "=", "2", "{", "return", "f", "(", "é", ")", "}"},
+		Tokenize("if x_1 == 2 {\n\treturn f(é)\n}"))
+	assert.Equal(Tokenize("f(a,\n  b)"), Tokenize("f(a, b)"))
+	assert.Nil(Tokenize(" \n\t"))
+}
@@ -0,0 +1,108 @@
+package lang
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+)
+
+// Symbol is a function, method or type declared in a source file, spanning lines StartLine to EndLine
+// counting from 1
+type Symbol struct {
+	Name      string
+	Kind      string
+	StartLine int
+	EndLine   int
+}
+
+// Kinds of symbols
+const (
+	KindFunction = "function"
+	KindMethod   = "method"
+	KindClass    = "class"
+)
+
+// SymbolParser finds the symbols declared in the source files of some languages
+type SymbolParser interface {
+	// Supports tells whether the parser handles the language, as detected by Detect
+	Supports(language string) bool
+	// Symbols returns the symbols declared in the source of the file at the path
+	Symbols(path string, src []byte) ([]Symbol, error)
+}
+
+// SymbolParsers are the parsers ParserFor picks from, in order of preference
+var SymbolParsers = []SymbolParser{GoParser{}, CtagsParser{}}
+
+// ParserFor returns the first of SymbolParsers supporting the language of the file at the path, nil if none
+func ParserFor(path string) SymbolParser {
+	language := Detect(path)
+	for _, parser := range SymbolParsers {
+		if parser.Supports(language) {
+			return parser
+		}
+	}
+	return nil
+}
+
+// GoParser finds the functions and methods of Go files with go/parser, see GoFunctions
+type GoParser struct{}
+
+func (GoParser) Supports(language string) bool {
+	return language == "Go"
+}
+
+func (GoParser) Symbols(path string, src []byte) ([]Symbol, error) {
+	return GoFunctions(src), nil
+}
+
+// GoFunctions returns the functions and methods declared in the Go source, their doc comments included.
+// Methods are named after their receiver type like in stack traces, e.g. (*Repository).Head. Source that
+// does not parse yields the functions declared before the syntax error.
+func GoFunctions(src []byte) []Symbol {
+	fset := token.NewFileSet()
+	// A partial AST is returned along with syntax errors
+	file, _ := parser.ParseFile(fset, "", src, parser.ParseComments)
+	if file == nil {
+		return nil
+	}
+	var symbols []Symbol
+	for _, decl := range file.Decls {
+		fn, ok := decl.(*ast.FuncDecl)
+		if !ok || fn.Name == nil {
+			continue
+		}
+		symbol := Symbol{Name: fn.Name.Name, Kind: KindFunction}
+		if fn.Recv != nil && len(fn.Recv.List) > 0 {
+			symbol.Name = receiverName(fn.Recv.List[0].Type) + "." + symbol.Name
+			symbol.Kind = KindMethod
+		}
+		start := fn.Pos()
+		if fn.Doc != nil {
+			start = fn.Doc.Pos()
+		}
+		symbol.StartLine = fset.Position(start).Line
+		symbol.EndLine = fset.Position(fn.End()).Line
+		if symbol.EndLine < symbol.StartLine {
+			// Cut short by a syntax error
+			continue
+		}
+		symbols = append(symbols, symbol)
+	}
+	return symbols
+}
+
+// receiverName formats the type of a method receiver, e.g. T or (*T)
+func receiverName(expr ast.Expr) string {
+	switch t := expr.(type) {
+	case *ast.StarExpr:
+		return "(*" + receiverName(t.X) + ")"
+	case *ast.Ident:
+		return t.Name
+	case *ast.IndexExpr:
+		// Generic receivers, T[K]
+		return receiverName(t.X)
+	case *ast.ParenExpr:
+		return receiverName(t.X)
+	}
+	return "?"
+}
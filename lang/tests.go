@@ -0,0 +1,56 @@
+package lang
+
+import (
+	"path"
+	"strings"
+)
+
+// DefaultTestPatterns match the test files of the most common languages and layouts
+var DefaultTestPatterns = []string{
+	"*_test.go",
+	"test/", "tests/", "spec/", "__tests__/", "testdata/",
+	"*Test.java", "*Tests.java", "*Test.kt",
+	"test_*.py", "*_test.py",
+	"*_spec.rb", "*_test.rb",
+	"*.test.js", "*.spec.js", "*.test.ts", "*.spec.ts", "*.test.tsx", "*.spec.tsx",
+}
+
+// TestClassifier tells test files from production files by their path. A pattern ending with a slash
+// matches the files under a directory of that name at any depth, a pattern with a slash inside matches
+// the whole path, and any other pattern matches the file name, all with the syntax of path.Match.
+type TestClassifier struct {
+	Patterns []string
+}
+
+// NewTestClassifier returns a classifier of the given patterns, DefaultTestPatterns when there are none
+func NewTestClassifier(patterns []string) *TestClassifier {
+	if len(patterns) == 0 {
+		patterns = DefaultTestPatterns
+	}
+	return &TestClassifier{Patterns: patterns}
+}
+
+// IsTest tells whether the file at the given path is a test file
+func (c *TestClassifier) IsTest(filePath string) bool {
+	dirs := strings.Split(path.Dir(filePath), "/")
+	for _, pattern := range c.Patterns {
+		switch {
+		case strings.HasSuffix(pattern, "/"):
+			dirPattern := strings.TrimSuffix(pattern, "/")
+			for _, dir := range dirs {
+				if matched, _ := path.Match(dirPattern, dir); matched {
+					return true
+				}
+			}
+		case strings.Contains(pattern, "/"):
+			if matched, _ := path.Match(pattern, filePath); matched {
+				return true
+			}
+		default:
+			if matched, _ := path.Match(pattern, path.Base(filePath)); matched {
+				return true
+			}
+		}
+	}
+	return false
+}
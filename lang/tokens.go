@@ -0,0 +1,30 @@
+package lang
+
+import "unicode"
+
+// Tokenize splits source code into tokens: words of letters, digits and underscores, and every other
+// character but whitespace on its own. Whitespace and line breaks only separate the tokens, so that
+// re-wrapping or re-indenting code changes none of them.
+func Tokenize(text string) []string {
+	var tokens []string
+	start := -1
+	for i, r := range text {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_' {
+			if start < 0 {
+				start = i
+			}
+			continue
+		}
+		if start >= 0 {
+			tokens = append(tokens, text[start:i])
+			start = -1
+		}
+		if !unicode.IsSpace(r) {
+			tokens = append(tokens, string(r))
+		}
+	}
+	if start >= 0 {
+		tokens = append(tokens, text[start:])
+	}
+	return tokens
+}
@@ -0,0 +1,173 @@
+package metrics
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/andymeneely/git-churn/gitfuncs"
+	"github.com/andymeneely/git-churn/helper"
+	"gopkg.in/src-d/go-git.v4"
+	"gopkg.in/src-d/go-git.v4/plumbing/object"
+)
+
+// Attribution compares the lines of a revision last written by an author, or in a directory, with the lines
+// ever added by the author, or to the directory, in the history leading to the revision
+type Attribution struct {
+	// Lines of the revision, as blamed
+	SurvivingLines int `units:"lines"`
+	// Lines added by the commits leading to the revision
+	AddedLines int `units:"lines"`
+	// SurvivingLines divided by AddedLines, the share of the churn still in the codebase
+	SurvivalRate float64
+	// Share of the lines of the revision
+	Share float64
+}
+
+type AuthorAttribution struct {
+	Author string
+	Attribution
+}
+
+type DirAttribution struct {
+	Dir string
+	Attribution
+}
+
+type AttributionReport struct {
+	Revision string
+	Files    int
+	Attribution
+	// Most surviving lines first
+	Authors []AuthorAttribution
+	Dirs    []DirAttribution
+}
+
+func (a *Attribution) setRates(lines int) {
+	if a.AddedLines > 0 {
+		a.SurvivalRate = float64(a.SurvivingLines) / float64(a.AddedLines)
+	}
+	if lines > 0 {
+		a.Share = float64(a.SurvivingLines) / float64(lines)
+	}
+}
+
+// ChurnAttribution blames every file of the revision but the ignored and binary ones, and reports per
+// author and per directory, down to the given depth, the lines they own at the revision versus the lines
+// they added in the history leading to it, as counted by RangeChurn but for the merges of branches, whose
+// lines their authors added. Files that cannot be blamed are skipped like in GetFileOwnership.
+func ChurnAttribution(repo *git.Repository, revision string, depth int) (*AttributionReport, error) {
+	defer helper.Duration(helper.Track("ChurnAttribution"))
+	hash, err := gitfuncs.ResolveRef(repo, revision)
+	if err != nil {
+		return nil, err
+	}
+	commit, err := repo.CommitObject(*hash)
+	if err != nil {
+		return nil, err
+	}
+	tree, err := commit.Tree()
+	if err != nil {
+		return nil, err
+	}
+	report := &AttributionReport{Revision: revision}
+	authors := make(map[string]*AuthorAttribution)
+	dirs := make(map[string]*DirAttribution)
+	authorAttribution := func(email string) *AuthorAttribution {
+		author, ok := authors[email]
+		if !ok {
+			author = &AuthorAttribution{Author: email}
+			authors[email] = author
+		}
+		return author
+	}
+	dirAttribution := func(file string) *DirAttribution {
+		dir := dirAtDepth(file, depth)
+		attribution, ok := dirs[dir]
+		if !ok {
+			attribution = &DirAttribution{Dir: dir}
+			dirs[dir] = attribution
+		}
+		return attribution
+	}
+
+	progress := helper.TrackProgress("files", 0)
+	err = gitfuncs.ForEachFile(tree, func(f *object.File) error {
+		defer progress.Step()
+		if gitfuncs.IsIgnored(repo, f.Name) {
+			return nil
+		}
+		if binary, err := f.IsBinary(); err != nil || binary {
+			return err
+		}
+		owners, err := LineOwnership(repo, *hash, f.Name, nil)
+		if err != nil {
+			return nil
+		}
+		report.Files += 1
+		dir := dirAttribution(f.Name)
+		for author, lines := range owners {
+			report.SurvivingLines += lines
+			dir.SurvivingLines += lines
+			authorAttribution(author).SurvivingLines += lines
+		}
+		return nil
+	})
+	progress.Finish()
+	if err != nil {
+		return nil, err
+	}
+
+	commits, err := RangeChurn(repo, "", revision)
+	if err != nil {
+		return nil, err
+	}
+	for _, commit := range commits {
+		if commit.mergedBranch() {
+			continue
+		}
+		report.AddedLines += commit.Insertions
+		authorAttribution(commit.Author).AddedLines += commit.Insertions
+		for _, file := range commit.Files {
+			dirAttribution(file.File).AddedLines += file.Insertions
+		}
+	}
+
+	report.setRates(report.SurvivingLines)
+	for _, author := range authors {
+		author.setRates(report.SurvivingLines)
+		report.Authors = append(report.Authors, *author)
+	}
+	sort.Slice(report.Authors, func(i, j int) bool {
+		a, b := report.Authors[i], report.Authors[j]
+		if a.SurvivingLines != b.SurvivingLines {
+			return a.SurvivingLines > b.SurvivingLines
+		}
+		return a.Author < b.Author
+	})
+	for _, dir := range dirs {
+		dir.setRates(report.SurvivingLines)
+		report.Dirs = append(report.Dirs, *dir)
+	}
+	sort.Slice(report.Dirs, func(i, j int) bool {
+		a, b := report.Dirs[i], report.Dirs[j]
+		if a.SurvivingLines != b.SurvivingLines {
+			return a.SurvivingLines > b.SurvivingLines
+		}
+		return a.Dir < b.Dir
+	})
+	return report, nil
+}
+
+// dirAtDepth returns the directory of the file cut to its first depth directories, or "." for the files
+// in the repository root
+func dirAtDepth(file string, depth int) string {
+	parts := strings.Split(file, "/")
+	parts = parts[:len(parts)-1]
+	if len(parts) > depth {
+		parts = parts[:depth]
+	}
+	if len(parts) == 0 {
+		return "."
+	}
+	return strings.Join(parts, "/")
+}
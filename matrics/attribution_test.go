@@ -0,0 +1,52 @@
+package metrics
+
+import (
+	"testing"
+
+	"github.com/andymeneely/git-churn/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestChurnAttribution(t *testing.T) {
+	assert := assert.New(t)
+	repo := testutil.NewRepo(t)
+	repo.As("alice").CommitFiles("root", map[string]string{"README": "1\n2\n", "api/v1/a.go": "1\n2\n3\n4\n"})
+	repo.As("bob").CommitFiles("rewrite", map[string]string{"api/v1/a.go": "1\nb\nb\n", "logo.png": "\x89PNG\x00\x00"})
+
+	report, err := ChurnAttribution(repo.Repository, "HEAD", 1)
+	assert.Nil(err)
+	assert.Equal(2, report.Files)
+	assert.Equal(Attribution{SurvivingLines: 5, AddedLines: 8, SurvivalRate: 5.0 / 8, Share: 1}, report.Attribution)
+	assert.Equal([]AuthorAttribution{
+		{Author: "alice@example.com", Attribution: Attribution{SurvivingLines: 3, AddedLines: 6, SurvivalRate: 0.5, Share: 0.6}},
+		{Author: "bob@example.com", Attribution: Attribution{SurvivingLines: 2, AddedLines: 2, SurvivalRate: 1, Share: 0.4}},
+	}, report.Authors)
+	assert.Equal([]DirAttribution{
+		{Dir: "api", Attribution: Attribution{SurvivingLines: 3, AddedLines: 6, SurvivalRate: 0.5, Share: 0.6}},
+		{Dir: ".", Attribution: Attribution{SurvivingLines: 2, AddedLines: 2, SurvivalRate: 1, Share: 0.4}},
+	}, report.Dirs)
+
+	report, err = ChurnAttribution(repo.Repository, "HEAD", 2)
+	assert.Nil(err)
+	assert.Equal("api/v1", report.Dirs[0].Dir)
+}
+
+// TestChurnAttributionMerge checks that the lines of a merged branch are added by their authors rather than
+// by the merge, and that blame and the added lines resolve the authors alike
+func TestChurnAttributionMerge(t *testing.T) {
+	assert := assert.New(t)
+	repo := testutil.NewRepo(t)
+	repo.As("alice").CommitFiles("root", map[string]string{"a.txt": "1\n"})
+	repo.Branch("feature").As("bob").CommitFiles("feature", map[string]string{"b.txt": "1\n2\n"})
+	repo.Checkout("master").As("alice").CommitFiles("master", map[string]string{"c.txt": "1\n"})
+	repo.CommitFiles("mailmap", map[string]string{".mailmap": "Bob Roe <bob@example.org> bob <bob@example.com>\n"})
+	repo.Merge("feature", "merge feature")
+
+	report, err := ChurnAttribution(repo.Repository, "HEAD", 1)
+	assert.Nil(err)
+	assert.Equal(Attribution{SurvivingLines: 5, AddedLines: 5, SurvivalRate: 1, Share: 1}, report.Attribution)
+	assert.Equal([]AuthorAttribution{
+		{Author: "alice@example.com", Attribution: Attribution{SurvivingLines: 3, AddedLines: 3, SurvivalRate: 1, Share: 0.6}},
+		{Author: "bob@example.org", Attribution: Attribution{SurvivingLines: 2, AddedLines: 2, SurvivalRate: 1, Share: 0.4}},
+	}, report.Authors)
+}
@@ -0,0 +1,125 @@
+package metrics
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/andymeneely/git-churn/gitfuncs"
+	"gopkg.in/src-d/go-git.v4"
+	"gopkg.in/src-d/go-git.v4/plumbing"
+	"gopkg.in/src-d/go-git.v4/plumbing/object"
+)
+
+// DefaultBotAuthors match the dependency update bots and CI accounts committing on GitHub and GitLab
+var DefaultBotAuthors = []string{
+	`(?i)\[bot\]`,
+	`(?i)^(dependabot|renovate|greenkeeper|snyk-bot|github-actions|pyup-bot|imgbot)\b`,
+	`(?i)<(dependabot|renovate|greenkeeper)[^>]*@`,
+}
+
+// DependencyUpdateMessages match the subjects of the commits dependabot and renovate make. Messages being
+// no proof of the author, a human commit can match them too, so they are only used when given to
+// NewBotFilter, e.g. for the updates the bots made under the name of a human account.
+var DependencyUpdateMessages = []string{
+	`^(chore|build|fix)\(deps(-dev)?\)`,
+	`^Bump \S+ from \S+ to \S+`,
+	`^(chore\(deps\): )?[Uu]pdate dependency \S+ to `,
+	`^(chore\(deps\): )?[Pp]in dependencies`,
+}
+
+// BotFilter tells the automated commits to leave out of the churn and author metrics: the commits whose
+// author, given as "Name <email>", matches one of the author patterns, and, when enabled, the commits with
+// a co-author of a Co-authored-by trailer matching them or whose message matches one of the message
+// patterns. The patterns are regular expressions. A nil BotFilter keeps every commit.
+type BotFilter struct {
+	Authors  []*regexp.Regexp
+	Messages []*regexp.Regexp
+	// Whether the co-authors are matched too, a human commit having a bot as a co-author otherwise
+	CoAuthors bool
+}
+
+// Bots are left out of the churn and author metrics
+var Bots, _ = NewBotFilter(nil, nil, false)
+
+func init() {
+	// The authors gitfuncs lists leave out the Bots of the time
+	gitfuncs.IsBot = func(commit *object.Commit) bool {
+		return Bots.IsBot(commit)
+	}
+}
+
+// NewBotFilter compiles the given patterns, DefaultBotAuthors when there are no author patterns. The
+// commits are only matched by their message when message patterns are given, and by their co-authors
+// when coAuthors is set.
+func NewBotFilter(authors, messages []string, coAuthors bool) (*BotFilter, error) {
+	if len(authors) == 0 {
+		authors = DefaultBotAuthors
+	}
+	filter := &BotFilter{CoAuthors: coAuthors}
+	for _, pattern := range authors {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid bot author pattern %q: %v", pattern, err)
+		}
+		filter.Authors = append(filter.Authors, re)
+	}
+	for _, pattern := range messages {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid bot message pattern %q: %v", pattern, err)
+		}
+		filter.Messages = append(filter.Messages, re)
+	}
+	return filter, nil
+}
+
+// IsBot tells whether the commit was made by a bot
+func (f *BotFilter) IsBot(commit *object.Commit) bool {
+	if f == nil {
+		return false
+	}
+	if f.isBotAuthor(fmt.Sprintf("%s <%s>", commit.Author.Name, commit.Author.Email)) {
+		return true
+	}
+	if f.CoAuthors {
+		for _, line := range strings.Split(commit.Message, "\n") {
+			const trailer = "co-authored-by:"
+			line = strings.TrimSpace(line)
+			if len(line) > len(trailer) && strings.EqualFold(line[:len(trailer)], trailer) &&
+				f.isBotAuthor(strings.TrimSpace(line[len(trailer):])) {
+				return true
+			}
+		}
+	}
+	for _, re := range f.Messages {
+		if re.MatchString(commit.Message) {
+			return true
+		}
+	}
+	return false
+}
+
+func (f *BotFilter) isBotAuthor(author string) bool {
+	for _, re := range f.Authors {
+		if re.MatchString(author) {
+			return true
+		}
+	}
+	return false
+}
+
+// botLine tells whether the blamed line was last changed by a commit of Bots, keeping the commits looked up
+// in bots
+func botLine(repo *git.Repository, line *git.Line, bots map[plumbing.Hash]bool) bool {
+	if Bots == nil {
+		return false
+	}
+	bot, ok := bots[line.Hash]
+	if !ok {
+		commit, err := repo.CommitObject(line.Hash)
+		bot = err == nil && Bots.IsBot(commit)
+		bots[line.Hash] = bot
+	}
+	return bot
+}
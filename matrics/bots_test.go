@@ -0,0 +1,66 @@
+package metrics
+
+import (
+	"testing"
+
+	"github.com/andymeneely/git-churn/gitfuncs"
+	"github.com/andymeneely/git-churn/testutil"
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/src-d/go-git.v4/plumbing/object"
+)
+
+func TestBotFilter(t *testing.T) {
+	assert := assert.New(t)
+	commit := func(name, email, message string) *object.Commit {
+		return &object.Commit{Author: object.Signature{Name: name, Email: email}, Message: message}
+	}
+	bots, err := NewBotFilter(nil, nil, false)
+	assert.Nil(err)
+	assert.True(bots.IsBot(commit("dependabot[bot]", "49699333+dependabot[bot]@users.noreply.github.com", "Bump lodash from 4.17.15 to 4.17.19\n")))
+	assert.True(bots.IsBot(commit("Renovate Bot", "bot@renovateapp.com", "Update dependency eslint to v7\n")))
+	// Only the authors are matched by default, the humans writing like bots or with bots being kept
+	assert.False(bots.IsBot(commit("alice", "alice@example.com", "chore(deps): update golang.org/x/net\n")))
+	assert.False(bots.IsBot(commit("alice", "alice@example.com", "Bump lodash from 4.17.15 to 4.17.19\n")))
+	lint := commit("alice", "alice@example.com", "Fix lint\n\nCo-authored-by: github-actions[bot] <41898282+github-actions[bot]@users.noreply.github.com>\n")
+	assert.False(bots.IsBot(lint))
+
+	bots, err = NewBotFilter(nil, DependencyUpdateMessages, true)
+	assert.Nil(err)
+	assert.True(bots.IsBot(commit("alice", "alice@example.com", "chore(deps): update golang.org/x/net\n")))
+	assert.True(bots.IsBot(lint))
+	assert.False(bots.IsBot(commit("alice", "alice@example.com", "Fix the parser\n\nCo-authored-by: Bob <bob@example.com>\n")))
+	assert.False(bots.IsBot(commit("alice", "alice@example.com", "Explain why we bump lodash from 4 to 5\n")))
+
+	bots, err = NewBotFilter([]string{`@ci\.example\.com>$`}, []string{`^\[release\]`}, false)
+	assert.Nil(err)
+	assert.True(bots.IsBot(commit("builder", "builder@ci.example.com", "Build\n")))
+	assert.True(bots.IsBot(commit("alice", "alice@example.com", "[release] 1.2.0\n")))
+	assert.False(bots.IsBot(commit("dependabot[bot]", "dependabot@example.com", "Update\n")))
+
+	var none *BotFilter
+	assert.False(none.IsBot(commit("dependabot[bot]", "dependabot@example.com", "Bump a from 1 to 2\n")))
+
+	_, err = NewBotFilter([]string{"("}, nil, false)
+	assert.NotNil(err)
+}
+
+func TestBotsLeftOutOfAuthors(t *testing.T) {
+	assert := assert.New(t)
+	repo := testutil.NewRepo(t)
+	repo.As("dependabot[bot]").CommitFiles("Bump", map[string]string{"a.txt": "1\n2\n"})
+	repo.As("alice").CommitFiles("add", map[string]string{"a.txt": "1\n2\n3\n"})
+	repo.As("bob").CommitFiles("delete", map[string]string{"a.txt": "1\n"})
+
+	authors, err := gitfuncs.GetDistinctAuthorsEMailIds(repo.Repository, "HEAD", "", "a.txt")
+	assert.Nil(err)
+	assert.ElementsMatch([]string{"alice@example.com", "bob@example.com"}, authors)
+
+	// The line of the bot is deleted but churns nobody
+	churn, err := GetChurnMetricsWithWhitespace(repo.Repository, "a.txt")
+	assert.Nil(err)
+	assert.Equal(2, churn.DeletedLinesCount)
+	assert.Equal(1, churn.InteractiveChurnCount)
+	assert.Equal(0, churn.SelfChurnCount)
+	aggregated := AggrChurnMetricsWithWhitespace(repo.Repository)
+	assert.Equal(1, aggregated.InteractiveChurnCount)
+}
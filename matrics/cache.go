@@ -0,0 +1,144 @@
+package metrics
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/andymeneely/git-churn/gitfuncs"
+)
+
+// DefaultResultTTL is how long the long-running modes keep a computed result
+const DefaultResultTTL = time.Hour
+
+// ResultCache keeps the metrics computed by the long-running modes (serve, export) so that the same
+// queries do not walk the history again. The results are kept per repository under content-addressed
+// keys, see ResultKey, for TTL at most, and all the results of a repository are dropped when a fetch
+// brings new commits. The cached results are shared and must not be modified.
+type ResultCache struct {
+	// How long a result is kept, 0 for as long as its repository is not invalidated
+	TTL time.Duration
+
+	mu        sync.Mutex
+	repos     map[string]map[string]cachedResult
+	lastSweep time.Time
+	hits      int
+	misses    int
+	now       func() time.Time
+}
+
+type cachedResult struct {
+	value   interface{}
+	expires time.Time
+}
+
+// NewResultCache returns a cache keeping the results for ttl
+func NewResultCache(ttl time.Duration) *ResultCache {
+	return &ResultCache{TTL: ttl, repos: make(map[string]map[string]cachedResult), now: time.Now}
+}
+
+// ResultKey identifies a result by the given parts, which should be commit hashes rather than refs so
+// that the key addresses the content the result is computed on, and by OptionsHash
+func ResultKey(parts ...interface{}) string {
+	h := sha1.New()
+	fmt.Fprint(h, OptionsHash())
+	for _, part := range parts {
+		fmt.Fprintf(h, "\x00%v", part)
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// OptionsHash hashes the options changing the metrics: the churn definition, the commits searched, left
+// out or weighted, the files ignored and their paths rewritten, the history followed and the way lines are
+// diffed and blamed
+func OptionsHash() string {
+	h := sha1.New()
+	fmt.Fprintf(h, "%+v\x00%+v\x00%+v\x00%+v\x00%+v\x00%v\x00%+v\x00%+v\x00%+v\x00", Churn, Search, Bots, Reformats, Fixes, CountTokens, Teams, TestFiles, PathRewrites)
+	fmt.Fprintf(h, "%v\x00%v\x00%v\x00%v\x00%v\x00%+v\x00%T\x00%v\x00%v", gitfuncs.IgnorePatterns, gitfuncs.IgnoreDisabled, gitfuncs.FirstParent,
+		gitfuncs.IgnoreEOL, gitfuncs.IgnoreAllSpace, gitfuncs.BlameOpts, gitfuncs.ActiveEngine, gitfuncs.PathScope, gitfuncs.DetectDirMoves)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// Load returns the result of the repository cached under the key, computing it with compute when missing
+// or expired. Failed computations are not cached.
+func (c *ResultCache) Load(repo, key string, compute func() (interface{}, error)) (interface{}, error) {
+	if value, ok := c.get(repo, key); ok {
+		return value, nil
+	}
+	value, err := compute()
+	if err != nil {
+		return nil, err
+	}
+	c.put(repo, key, value)
+	return value, nil
+}
+
+// Invalidate drops the results of the repository, e.g. when new commits were fetched
+func (c *ResultCache) Invalidate(repo string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.repos, repo)
+}
+
+// Stats returns the number of results served from the cache and the number of results computed
+func (c *ResultCache) Stats() (hits int, misses int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.hits, c.misses
+}
+
+func (c *ResultCache) get(repo, key string) (interface{}, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	result, ok := c.repos[repo][key]
+	if ok && c.expired(result) {
+		delete(c.repos[repo], key)
+		ok = false
+	}
+	if !ok {
+		c.misses += 1
+		return nil, false
+	}
+	c.hits += 1
+	return result.value, true
+}
+
+func (c *ResultCache) put(repo, key string, value interface{}) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	now := c.now()
+	if c.TTL > 0 && now.Sub(c.lastSweep) > c.TTL {
+		c.sweep()
+		c.lastSweep = now
+	}
+	results, ok := c.repos[repo]
+	if !ok {
+		results = make(map[string]cachedResult)
+		c.repos[repo] = results
+	}
+	result := cachedResult{value: value}
+	if c.TTL > 0 {
+		result.expires = now.Add(c.TTL)
+	}
+	results[key] = result
+}
+
+// sweep drops the expired results, which are otherwise only dropped when asked for again
+func (c *ResultCache) sweep() {
+	for repo, results := range c.repos {
+		for key, result := range results {
+			if c.expired(result) {
+				delete(results, key)
+			}
+		}
+		if len(results) == 0 {
+			delete(c.repos, repo)
+		}
+	}
+}
+
+func (c *ResultCache) expired(result cachedResult) bool {
+	return !result.expires.IsZero() && !c.now().Before(result.expires)
+}
@@ -0,0 +1,58 @@
+package metrics
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestResultCache(t *testing.T) {
+	assert := assert.New(t)
+	now := time.Date(2020, 5, 1, 0, 0, 0, 0, time.UTC)
+	cache := NewResultCache(time.Hour)
+	cache.now = func() time.Time { return now }
+	computed := 0
+	compute := func() (interface{}, error) {
+		computed++
+		return computed, nil
+	}
+
+	key := ResultKey("range", "abc", "def")
+	value, err := cache.Load("repo", key, compute)
+	assert.Nil(err)
+	assert.Equal(1, value)
+	value, _ = cache.Load("repo", key, compute)
+	assert.Equal(1, value)
+	value, _ = cache.Load("other", key, compute)
+	assert.Equal(2, value)
+
+	_, err = cache.Load("repo", ResultKey("failing"), func() (interface{}, error) { return nil, errors.New("failed") })
+	assert.NotNil(err)
+
+	now = now.Add(time.Hour)
+	value, _ = cache.Load("repo", key, compute)
+	assert.Equal(3, value)
+
+	cache.Invalidate("repo")
+	value, _ = cache.Load("repo", key, compute)
+	assert.Equal(4, value)
+	value, _ = cache.Load("other", key, compute)
+	assert.Equal(5, value, "the results of the other repository expired too")
+
+	hits, misses := cache.Stats()
+	assert.Equal(1, hits)
+	assert.Equal(6, misses)
+}
+
+func TestResultKey(t *testing.T) {
+	assert := assert.New(t)
+	key := ResultKey("range", "abc", "def")
+	assert.Equal(key, ResultKey("range", "abc", "def"))
+	assert.NotEqual(key, ResultKey("range", "abcdef", ""))
+
+	defer func(churn ChurnDefinition) { Churn = churn }(Churn)
+	Churn.Mode = ChurnAdded
+	assert.NotEqual(key, ResultKey("range", "abc", "def"))
+}
@@ -0,0 +1,108 @@
+package metrics
+
+import (
+	"errors"
+	"sort"
+	"time"
+
+	"github.com/andymeneely/git-churn/helper"
+	"gopkg.in/src-d/go-git.v4"
+)
+
+// ReleaseInterval is the churn between a release and the previous one
+type ReleaseInterval struct {
+	From string
+	To   string
+	// Commit date of the release
+	Date time.Time
+	// Days since the previous release
+	Days         float64
+	Commits      int
+	Insertions   int `units:"lines"`
+	Deletions    int `units:"lines"`
+	FilesChanged int
+	// Churn according to the churn definition
+	Churn int `units:"lines"`
+	// Churn above ReleaseCadence.LargeChurn
+	Large bool
+}
+
+// ReleaseCadence is the velocity of the releases of a repository
+type ReleaseCadence struct {
+	Releases   int
+	MeanDays   float64
+	MedianDays float64
+	// Median churn of the releases, and the churn above which a release is unusually large: the upper
+	// Tukey fence, the third quartile plus 1.5 times the interquartile range
+	MedianChurn int `units:"lines"`
+	LargeChurn  int `units:"lines"`
+	// Oldest first
+	Intervals []ReleaseInterval
+}
+
+// GetReleaseCadence pairs the consecutive semantic version tags of the repository and reports the days
+// between the releases and the churn of each, for the last n releases, all of them if n is zero, flagging
+// the unusually large releases
+func GetReleaseCadence(repo *git.Repository, n int) (*ReleaseCadence, error) {
+	defer helper.Duration(helper.Track("GetReleaseCadence"))
+	tags, err := SemverTags(repo)
+	if err != nil {
+		return nil, err
+	}
+	if len(tags) < 2 {
+		return nil, errors.New("at least two semantic version tags are needed to compute the release cadence")
+	}
+	if n > 0 && len(tags) > n+1 {
+		tags = tags[len(tags)-n-1:]
+	}
+
+	cadence := &ReleaseCadence{Intervals: []ReleaseInterval{}}
+	var days []float64
+	var churns []int
+	for i := 1; i < len(tags); i++ {
+		fromHash, toHash, err := resolveRange(repo, tags[i-1], tags[i])
+		if err != nil {
+			return nil, err
+		}
+		fromCommit, err := repo.CommitObject(fromHash)
+		if err != nil {
+			return nil, err
+		}
+		toCommit, err := repo.CommitObject(toHash)
+		if err != nil {
+			return nil, err
+		}
+		commits, err := RangeChurn(repo, tags[i-1], tags[i])
+		if err != nil {
+			return nil, err
+		}
+		summary := SummarizeRange(commits, 0)
+		interval := ReleaseInterval{
+			From:         tags[i-1],
+			To:           tags[i],
+			Date:         toCommit.Committer.When,
+			Days:         toCommit.Committer.When.Sub(fromCommit.Committer.When).Hours() / 24,
+			Commits:      summary.Commits,
+			Insertions:   summary.Insertions,
+			Deletions:    summary.Deletions,
+			FilesChanged: summary.FilesChanged,
+			Churn:        summary.Churn,
+		}
+		cadence.Intervals = append(cadence.Intervals, interval)
+		cadence.MeanDays += interval.Days
+		days = append(days, interval.Days)
+		churns = append(churns, interval.Churn)
+	}
+	cadence.Releases = len(cadence.Intervals)
+	cadence.MeanDays /= float64(cadence.Releases)
+	cadence.MedianDays = median(days)
+
+	sort.Ints(churns)
+	cadence.MedianChurn = percentile(churns, 50)
+	q1, q3 := percentile(churns, 25), percentile(churns, 75)
+	cadence.LargeChurn = q3 + (q3-q1)*3/2
+	for i := range cadence.Intervals {
+		cadence.Intervals[i].Large = cadence.Intervals[i].Churn > cadence.LargeChurn
+	}
+	return cadence, nil
+}
@@ -0,0 +1,47 @@
+package metrics
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/andymeneely/git-churn/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetReleaseCadence(t *testing.T) {
+	assert := assert.New(t)
+	repo := testutil.NewRepo(t)
+	repo.CommitFiles("root", map[string]string{"a.txt": "x\n"})
+	repo.Tag("v0.1.0")
+	repo.CommitFiles("a", map[string]string{"a.txt": "x\ny\n"})
+	repo.Tag("v0.2.0")
+	repo.CommitFiles("b", map[string]string{"b.txt": "1\n"})
+	repo.CommitFiles("b", map[string]string{"b.txt": "1\n2\n"})
+	repo.Tag("v0.3.0").Tag("nightly")
+	repo.CommitFiles("c", map[string]string{"c.txt": "1\n"})
+	repo.Tag("v0.4.0")
+	repo.CommitFiles("d", map[string]string{"d.txt": strings.Repeat("line\n", 100)})
+	repo.Tag("v0.5.0")
+
+	cadence, err := GetReleaseCadence(repo.Repository, 0)
+	assert.Nil(err)
+	assert.Equal(4, cadence.Releases)
+	assert.Equal(1.25, cadence.MeanDays)
+	assert.Equal(1.0, cadence.MedianDays)
+	assert.Equal(1, cadence.MedianChurn)
+	assert.Equal(3, cadence.LargeChurn)
+	var large []string
+	for _, interval := range cadence.Intervals {
+		if interval.Large {
+			large = append(large, interval.From+".."+interval.To)
+		}
+	}
+	assert.Equal([]string{"v0.4.0..v0.5.0"}, large)
+	assert.Equal(2, cadence.Intervals[1].Commits)
+	assert.Equal(2.0, cadence.Intervals[1].Days)
+
+	cadence, err = GetReleaseCadence(repo.Repository, 2)
+	assert.Nil(err)
+	assert.Equal(2, cadence.Releases)
+	assert.Equal("v0.3.0", cadence.Intervals[0].From)
+}
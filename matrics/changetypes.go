@@ -0,0 +1,129 @@
+package metrics
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Types of change the commits are classified into, the ones of the conventional commits
+// (https://www.conventionalcommits.org) and the others
+const (
+	ChangeFeature  = "feat"
+	ChangeFix      = "fix"
+	ChangeRefactor = "refactor"
+	ChangeChore    = "chore"
+	ChangeDocs     = "docs"
+	ChangeOther    = "other"
+)
+
+// ChangeTypes are the types of the conventional commits recognized, any other type counting as other
+var ChangeTypes = []string{ChangeFeature, ChangeFix, ChangeRefactor, "perf", "test", "style", "build", "ci", ChangeChore, ChangeDocs, "revert"}
+
+// ConventionalCommit is the header of a commit message following the conventional commits, e.g.
+// "feat(parser)!: accept arrays"
+type ConventionalCommit struct {
+	Type        string
+	Scope       string `json:",omitempty"`
+	Breaking    bool
+	Description string
+}
+
+var (
+	conventionalHeader = regexp.MustCompile(`^(\w+)(?:\(([^()]*)\))?(!)?: +(.*)$`)
+	breakingFooter     = regexp.MustCompile(`(?m)^BREAKING[ -]CHANGE: `)
+)
+
+// ParseConventionalCommit parses the first line of the commit message as the header of a conventional
+// commit, its type lowercased. A "BREAKING CHANGE:" footer makes the change breaking too.
+func ParseConventionalCommit(message string) (ConventionalCommit, bool) {
+	header := strings.TrimSpace(strings.SplitN(message, "\n", 2)[0])
+	match := conventionalHeader.FindStringSubmatch(header)
+	if match == nil {
+		return ConventionalCommit{}, false
+	}
+	return ConventionalCommit{
+		Type:        strings.ToLower(match[1]),
+		Scope:       match[2],
+		Breaking:    match[3] != "" || breakingFooter.MatchString(message),
+		Description: match[4],
+	}, true
+}
+
+// ChangeType classifies the commit message by its conventional commit type when one of ChangeTypes.
+// The other messages are fixes when Fixes tells so, and of the other type otherwise.
+func ChangeType(message string) string {
+	if commit, ok := ParseConventionalCommit(message); ok {
+		for _, changeType := range ChangeTypes {
+			if commit.Type == changeType {
+				return changeType
+			}
+		}
+		return ChangeOther
+	}
+	if Fixes.IsFix(message) {
+		return ChangeFix
+	}
+	return ChangeOther
+}
+
+// ChangeTypeChurn totals the commits of a type of change
+type ChangeTypeChurn struct {
+	Type       string
+	Commits    int
+	Insertions int `units:"lines"`
+	Deletions  int `units:"lines"`
+	// Churn according to the churn definition
+	Churn int `units:"lines"`
+	// Share of the churn of every commit
+	ChurnShare float64
+}
+
+// ChangeTypeReport splits the churn of a range by type of change
+type ChangeTypeReport struct {
+	Commits int
+	Churn   int `units:"lines"`
+	// Commits following the conventional commits
+	Conventional int
+	// Most churned types first
+	Types []ChangeTypeChurn
+}
+
+// ChurnByChangeType classifies the given commits with ChangeType and totals their churn per type, to
+// tell how much of it goes to features rather than maintenance
+func ChurnByChangeType(commits []*CommitChurn) *ChangeTypeReport {
+	report := &ChangeTypeReport{}
+	byType := make(map[string]*ChangeTypeChurn)
+	for _, commit := range countedCommits(commits) {
+		changeType := ChangeType(commit.Message)
+		if _, ok := ParseConventionalCommit(commit.Message); ok {
+			report.Conventional += 1
+		}
+		total, ok := byType[changeType]
+		if !ok {
+			total = &ChangeTypeChurn{Type: changeType}
+			byType[changeType] = total
+		}
+		total.Commits += 1
+		total.Insertions += commit.Insertions
+		total.Deletions += commit.Deletions
+		total.Churn += commit.Churn()
+		report.Commits += 1
+		report.Churn += commit.Churn()
+	}
+
+	report.Types = make([]ChangeTypeChurn, 0, len(byType))
+	for _, total := range byType {
+		if report.Churn != 0 {
+			total.ChurnShare = float64(total.Churn) / float64(report.Churn)
+		}
+		report.Types = append(report.Types, *total)
+	}
+	sort.Slice(report.Types, func(i, j int) bool {
+		if report.Types[i].Churn != report.Types[j].Churn {
+			return report.Types[i].Churn > report.Types[j].Churn
+		}
+		return report.Types[i].Type < report.Types[j].Type
+	})
+	return report
+}
@@ -0,0 +1,46 @@
+package metrics
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseConventionalCommit(t *testing.T) {
+	assert := assert.New(t)
+	commit, ok := ParseConventionalCommit("feat(parser)!: accept arrays\n\nbody")
+	assert.True(ok)
+	assert.Equal(ConventionalCommit{Type: "feat", Scope: "parser", Breaking: true, Description: "accept arrays"}, commit)
+
+	commit, ok = ParseConventionalCommit("Fix: typo\n\nBREAKING CHANGE: renamed the flag")
+	assert.True(ok)
+	assert.Equal(ConventionalCommit{Type: "fix", Breaking: true, Description: "typo"}, commit)
+
+	_, ok = ParseConventionalCommit("Merge branch 'master': conflicts")
+	assert.False(ok)
+}
+
+func TestChurnByChangeType(t *testing.T) {
+	assert := assert.New(t)
+	commits := []*CommitChurn{
+		{Message: "feat: export to csv", Insertions: 50, Deletions: 10},
+		{Message: "feat(cli): add --tokens", Insertions: 20},
+		{Message: "Fixed the crash on empty repos", Insertions: 5, Deletions: 5},
+		{Message: "chore(deps): bump cobra", Insertions: 10, Deletions: 10},
+		{Message: "wip: try things", Insertions: 1},
+		{Message: "Update README.md", Insertions: 2, Deletions: 2},
+	}
+	assert.Equal(ChangeFix, ChangeType(commits[2].Message))
+	assert.Equal(ChangeOther, ChangeType(commits[4].Message))
+
+	report := ChurnByChangeType(commits)
+	assert.Equal(6, report.Commits)
+	assert.Equal(115, report.Churn)
+	assert.Equal(4, report.Conventional)
+	assert.Equal([]ChangeTypeChurn{
+		{Type: "feat", Commits: 2, Insertions: 70, Deletions: 10, Churn: 80, ChurnShare: 80.0 / 115},
+		{Type: "chore", Commits: 1, Insertions: 10, Deletions: 10, Churn: 20, ChurnShare: 20.0 / 115},
+		{Type: "fix", Commits: 1, Insertions: 5, Deletions: 5, Churn: 10, ChurnShare: 10.0 / 115},
+		{Type: "other", Commits: 2, Insertions: 3, Deletions: 2, Churn: 5, ChurnShare: 5.0 / 115},
+	}, report.Types)
+}
@@ -0,0 +1,77 @@
+package metrics
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/andymeneely/git-churn/gitfuncs"
+)
+
+// NoLimit disables a threshold
+const NoLimit = -1
+
+// Thresholds are the most a range of commits may change, NoLimit disabling a threshold
+type Thresholds struct {
+	// Lines added and deleted
+	MaxLines int `units:"lines"`
+	// Distinct files changed
+	MaxFiles int
+	// Churn according to the churn definition
+	MaxChurn int `units:"lines"`
+	// Files to be changed sparingly, in the syntax of .gitignore, e.g. migrations/ or *.lock
+	ProtectedPaths []string
+	// Churn in the protected files, according to the churn definition
+	MaxProtectedChurn int `units:"lines"`
+}
+
+// CheckResult is how a range of commits compares with thresholds
+type CheckResult struct {
+	Commits      int
+	LinesChanged int `units:"lines"`
+	FilesChanged int
+	Churn        int `units:"lines"`
+	// Churn of the protected files and the protected files changed
+	ProtectedChurn int `units:"lines"`
+	ProtectedFiles []string
+	// Thresholds exceeded, none if the range passes
+	Violations []string
+	Passed     bool
+}
+
+// CheckThresholds totals the changes of the commits, but the merges of branches counted on their own, and
+// checks them against the thresholds
+func CheckThresholds(commits []*CommitChurn, thresholds Thresholds) CheckResult {
+	commits = countedCommits(commits)
+	result := CheckResult{Commits: len(commits)}
+	protected := gitfuncs.NewIgnore(thresholds.ProtectedPaths)
+	files := make(map[string]bool)
+	protectedFiles := make(map[string]bool)
+	for _, commit := range commits {
+		result.LinesChanged += commit.Insertions + commit.Deletions
+		result.Churn += commit.Churn()
+		for _, file := range commit.Files {
+			files[file.File] = true
+			if len(thresholds.ProtectedPaths) > 0 && protected.Match(file.File) {
+				protectedFiles[file.File] = true
+				result.ProtectedChurn += file.Churn()
+			}
+		}
+	}
+	result.FilesChanged = len(files)
+	for file := range protectedFiles {
+		result.ProtectedFiles = append(result.ProtectedFiles, file)
+	}
+	sort.Strings(result.ProtectedFiles)
+
+	check := func(name string, value, max int) {
+		if max != NoLimit && value > max {
+			result.Violations = append(result.Violations, fmt.Sprintf("%s %d exceeds %d", name, value, max))
+		}
+	}
+	check("lines changed", result.LinesChanged, thresholds.MaxLines)
+	check("files changed", result.FilesChanged, thresholds.MaxFiles)
+	check("churn", result.Churn, thresholds.MaxChurn)
+	check("churn in protected paths", result.ProtectedChurn, thresholds.MaxProtectedChurn)
+	result.Passed = len(result.Violations) == 0
+	return result
+}
@@ -0,0 +1,52 @@
+package metrics
+
+import (
+	"testing"
+
+	"github.com/andymeneely/git-churn/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheckThresholds(t *testing.T) {
+	assert := assert.New(t)
+	commits := []*CommitChurn{
+		{Insertions: 12, Deletions: 2, Files: []FileChurn{{File: "main.go", Insertions: 10, Deletions: 2}, {File: "db/migrations/001.sql", Insertions: 2}}},
+		{Insertions: 5, Deletions: 5, Files: []FileChurn{{File: "main.go", Insertions: 5, Deletions: 5}}},
+	}
+	noLimits := Thresholds{MaxLines: NoLimit, MaxFiles: NoLimit, MaxChurn: NoLimit, MaxProtectedChurn: NoLimit}
+	result := CheckThresholds(commits, noLimits)
+	assert.Equal(CheckResult{Commits: 2, LinesChanged: 24, FilesChanged: 2, Churn: 24, Passed: true}, result)
+
+	thresholds := Thresholds{MaxLines: 20, MaxFiles: 2, MaxChurn: NoLimit, ProtectedPaths: []string{"migrations/", "*.lock"}, MaxProtectedChurn: 0}
+	result = CheckThresholds(commits, thresholds)
+	assert.False(result.Passed)
+	assert.Equal(2, result.ProtectedChurn)
+	assert.Equal([]string{"db/migrations/001.sql"}, result.ProtectedFiles)
+	assert.Equal([]string{"lines changed 24 exceeds 20", "churn in protected paths 2 exceeds 0"}, result.Violations)
+
+	thresholds.MaxLines, thresholds.MaxProtectedChurn = 24, 2
+	assert.True(CheckThresholds(commits, thresholds).Passed)
+}
+
+func TestCheckThresholdsMerge(t *testing.T) {
+	assert := assert.New(t)
+	repo := testutil.NewDivergedRepo(t)
+	repo.Merge("feature", "merge")
+	commits, err := RangeChurn(repo.Repository, "", "HEAD")
+	assert.Nil(err)
+
+	// The lines the merge brought from the branch are counted once, by the commits of the branch
+	result := CheckThresholds(commits, Thresholds{MaxLines: 10, MaxFiles: NoLimit, MaxChurn: 10, MaxProtectedChurn: 2,
+		ProtectedPaths: []string{"c.txt"}})
+	assert.Equal(4, result.Commits)
+	assert.Equal(10, result.LinesChanged)
+	assert.Equal(10, result.Churn)
+	assert.Equal(2, result.ProtectedChurn)
+	assert.Equal(3, result.FilesChanged)
+	assert.True(result.Passed, result.Violations)
+
+	// So are they by the other totals of the range
+	assert.Equal(10, SummarizeRange(commits, 0).Churn)
+	assert.Equal(10, ChurnByChangeType(commits).Churn)
+	assert.Equal(10, ChurnTree(commits, 0).Churn)
+}
@@ -6,12 +6,14 @@ import (
 	"github.com/andymeneely/git-churn/helper"
 	. "github.com/andymeneely/git-churn/print"
 	"gopkg.in/src-d/go-git.v4"
+	"gopkg.in/src-d/go-git.v4/plumbing"
 )
 
 type ChurnMetrics struct {
-	DeletedLinesCount     int
-	SelfChurnCount        int
-	InteractiveChurnCount int
+	DeletedLinesCount int `units:"lines"`
+	// Deleted lines last changed by the author of the commit and by other authors, those of Bots left out
+	SelfChurnCount        int `units:"lines"`
+	InteractiveChurnCount int `units:"lines"`
 	CommitAuthor          string
 }
 
@@ -49,25 +51,32 @@ func GetChurnMetricsWhitespaceExcluded(repo *git.Repository, filePath string) (*
 
 func calculateChurnMetrics(fileDeletedLinesMap map[string][]int, repo *git.Repository, filePath string, churnMetrics *FileChurnMetrics) error {
 	deletedLines := fileDeletedLinesMap[filePath]
-	//REF: https://git-scm.com/docs/gitrevisions
-	parentCommitHash := gitfuncs.RevisionCommits(repo, "HEAD~1")
+	head, _ := repo.Head()
+	commitObj, err := repo.CommitObject(head.Hash())
+	CheckIfError(err)
+	// A root commit, e.g. the first one of an orphan branch, only adds new files
+	if commitObj.NumParents() == 0 {
+		return errors.New("The specified file was a new file added in this commit. Hence, churn can't be calculated.")
+	}
+	parentCommitHash := commitObj.ParentHashes[0]
 
-	blame, err := gitfuncs.Blame(repo, parentCommitHash, filePath)
+	blame, err := gitfuncs.Blame(repo, &parentCommitHash, filePath)
 	if err != nil {
 		return errors.New("The specified file was a new file added in this commit. Hence, churn can't be calculated.")
 	}
 	lines := blame.Lines
 
-	head, _ := repo.Head()
-	commitObj, err := repo.CommitObject(head.Hash())
-	CheckIfError(err)
-	commitAuthor := commitObj.Author.Email
+	_, commitAuthor := gitfuncs.ResolveAuthor(repo, commitObj.Author)
 
 	churnDetails := make(map[string]string)
 	selfChurnCount := 0
 	interactiveChurnCount := 0
+	bots := make(map[plumbing.Hash]bool)
 	for _, deletedLine := range deletedLines {
-		churnAuthor := lines[deletedLine-1].Author
+		if botLine(repo, lines[deletedLine-1], bots) {
+			continue
+		}
+		churnAuthor := gitfuncs.ResolveLineAuthor(repo, lines[deletedLine-1])
 		if churnAuthor == commitAuthor {
 			selfChurnCount += 1
 		} else {
@@ -106,21 +115,34 @@ func AggrChurnMetricsWhitespaceExcluded(repo *git.Repository) *AggrChurMetrics {
 }
 
 func calculateAggrChurnMetrics(fileDeletedLinesMap map[string][]int, repo *git.Repository, churnMetrics *AggrChurMetrics) {
-	parentCommitHash := gitfuncs.RevisionCommits(repo, "HEAD~1")
 	head, _ := repo.Head()
 	commitObj, err := repo.CommitObject(head.Hash())
 	CheckIfError(err)
-	commitAuthor := commitObj.Author.Email
+	// A root commit deletes no lines, there is no parent to blame
+	var parentCommitHash *plumbing.Hash
+	if commitObj.NumParents() > 0 {
+		parentCommitHash = &commitObj.ParentHashes[0]
+	} else {
+		fileDeletedLinesMap = nil
+	}
+	_, commitAuthor := gitfuncs.ResolveAuthor(repo, commitObj.Author)
 	totalDeletedLines := 0
 	totalSelfChurnCount := 0
 	totalInteractiveChurnCount := 0
+	bots := make(map[plumbing.Hash]bool)
 	for filePath, deletedLines := range fileDeletedLinesMap {
+		if gitfuncs.IsIgnored(repo, filePath) {
+			continue
+		}
 		blame, err := gitfuncs.Blame(repo, parentCommitHash, filePath)
 		if err == nil && blame != nil {
 			lines := blame.Lines
 
 			for _, deletedLine := range deletedLines {
-				churnAuthor := lines[deletedLine-1].Author
+				if botLine(repo, lines[deletedLine-1], bots) {
+					continue
+				}
+				churnAuthor := gitfuncs.ResolveLineAuthor(repo, lines[deletedLine-1])
 				if churnAuthor == commitAuthor {
 					totalSelfChurnCount += 1
 				} else {
@@ -0,0 +1,132 @@
+package metrics
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/andymeneely/git-churn/gitfuncs"
+	"gopkg.in/src-d/go-git.v4"
+	"gopkg.in/src-d/go-git.v4/plumbing/object"
+)
+
+// ChurnMode is how lines added and deleted add up to churn
+type ChurnMode string
+
+const (
+	// Lines added
+	ChurnAdded ChurnMode = "added"
+	// Lines added plus lines deleted
+	ChurnTotal ChurnMode = "total"
+	// Lines added minus lines deleted
+	ChurnNet ChurnMode = "net"
+	// Lines deleted within the window of being added
+	ChurnRecent ChurnMode = "recent"
+)
+
+// ChurnModes are the supported churn modes
+var ChurnModes = []ChurnMode{ChurnAdded, ChurnTotal, ChurnNet, ChurnRecent}
+
+// ParseChurnMode returns the churn mode of the given name
+func ParseChurnMode(name string) (ChurnMode, error) {
+	for _, mode := range ChurnModes {
+		if string(mode) == name {
+			return mode, nil
+		}
+	}
+	return "", fmt.Errorf("unknown churn mode %q, expected one of %v", name, ChurnModes)
+}
+
+type ChurnDefinition struct {
+	Mode ChurnMode
+	// How young a deleted line has to be to count as churn in the ChurnRecent mode
+	Window time.Duration
+}
+
+// Churn is the definition of churn used by all the metrics, the lines added plus deleted by default
+var Churn = ChurnDefinition{Mode: ChurnTotal, Window: 21 * 24 * time.Hour}
+
+// Lines returns the churn of a change, given the lines it added and deleted and, in the ChurnRecent
+// mode, how many of the deleted lines were added within the window
+func (d ChurnDefinition) Lines(insertions, deletions, recentDeletions int) int {
+	switch d.Mode {
+	case ChurnAdded:
+		return insertions
+	case ChurnNet:
+		return insertions - deletions
+	case ChurnRecent:
+		return recentDeletions
+	default:
+		return insertions + deletions
+	}
+}
+
+// blameDeletedLines blames in the first parent of the commit the lines the commit deletes and passes them
+// to fn along with their file, blank lines included only if whitespace is true
+func blameDeletedLines(repo *git.Repository, commit *object.Commit, whitespace bool, fn func(path string, line *git.Line)) error {
+	if commit.NumParents() == 0 {
+		return nil
+	}
+	parent, err := commit.Parent(0)
+	if err != nil {
+		return err
+	}
+	patch, err := gitfuncs.CommitChurnPatch(commit)
+	if err != nil {
+		return err
+	}
+	for _, file := range patch.Files {
+		lines := file.Lines(gitfuncs.LineDeleted, whitespace)
+		if len(lines) == 0 || gitfuncs.IsIgnored(repo, file.OldPath) {
+			continue
+		}
+		blame, err := gitfuncs.ActiveEngine.Blame(repo, parent, file.OldPath)
+		if err != nil {
+			return err
+		}
+		for _, line := range lines {
+			if line.Number <= len(blame.Lines) {
+				fn(file.OldPath, blame.Lines[line.Number-1])
+			}
+		}
+	}
+	return nil
+}
+
+// commitRecentDeletions counts per file the lines the commit deletes within the window of the churn
+// definition, blank lines included only if whitespace is true
+func commitRecentDeletions(repo *git.Repository, commit *object.Commit, whitespace bool) (map[string]int, error) {
+	counts := make(map[string]int)
+	err := blameDeletedLines(repo, commit, whitespace, func(path string, line *git.Line) {
+		if commit.Author.When.Sub(line.Date) <= Churn.Window {
+			counts[path] += 1
+		}
+	})
+	return counts, err
+}
+
+// headChurn returns the churn of the commit checked out in the repository, for the given file or all of
+// them when empty, given the lines it added and deleted
+func headChurn(repo *git.Repository, file string, whitespace bool, diff DiffMetrics) (int, error) {
+	if Churn.Mode != ChurnRecent {
+		return Churn.Lines(diff.Insertions, diff.Deletions, 0), nil
+	}
+	head, err := repo.Head()
+	if err != nil {
+		return 0, err
+	}
+	commit, err := repo.CommitObject(head.Hash())
+	if err != nil {
+		return 0, err
+	}
+	counts, err := commitRecentDeletions(repo, commit, whitespace)
+	if err != nil {
+		return 0, err
+	}
+	recent := 0
+	for path, count := range counts {
+		if file == "" || path == file {
+			recent += count
+		}
+	}
+	return Churn.Lines(diff.Insertions, diff.Deletions, recent), nil
+}
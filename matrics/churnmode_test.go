@@ -0,0 +1,49 @@
+package metrics
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestChurnDefinitionLines(t *testing.T) {
+	assert := assert.New(t)
+	assert.Equal(5, ChurnDefinition{Mode: ChurnAdded}.Lines(5, 3, 1))
+	assert.Equal(8, ChurnDefinition{Mode: ChurnTotal}.Lines(5, 3, 1))
+	assert.Equal(2, ChurnDefinition{Mode: ChurnNet}.Lines(5, 3, 1))
+	assert.Equal(1, ChurnDefinition{Mode: ChurnRecent}.Lines(5, 3, 1))
+
+	mode, err := ParseChurnMode("net")
+	assert.Nil(err)
+	assert.Equal(ChurnNet, mode)
+	_, err = ParseChurnMode("lines")
+	assert.NotNil(err)
+}
+
+func TestRecentChurn(t *testing.T) {
+	defer func(churn ChurnDefinition) { Churn = churn }(Churn)
+	Churn = ChurnDefinition{Mode: ChurnRecent, Window: 36 * time.Hour}
+	assert := assert.New(t)
+	// The last commit deletes 2, added two days before, and 4, added the day before
+	repo := snapshotRepo(t,
+		map[string]string{"a.txt": "1\n2\n3\n", "b.txt": "x\n"},
+		map[string]string{"a.txt": "1\n2\n3\n4\n"},
+		map[string]string{"a.txt": "1\n3\n", "b.txt": "x\ny\n"},
+	)
+
+	churns, err := RangeChurn(repo, "", "HEAD")
+	assert.Nil(err)
+	assert.Equal(1, churns[0].RecentDeletions)
+	assert.Equal(1, churns[0].Churn())
+	assert.Equal([]FileChurn{
+		{File: "a.txt", Deletions: 2, RecentDeletions: 1},
+		{File: "b.txt", Insertions: 1},
+	}, churns[0].Files)
+	assert.Equal(1, SummarizeRange(churns, 0).Churn)
+
+	assert.Equal(1, AggrDiffMetricsWithWhitespace(repo).Churn)
+	fileMetrics, err := CalculateDiffMetricsWhitespaceExcluded(repo, "b.txt")
+	assert.Nil(err)
+	assert.Equal(0, fileMetrics.Churn)
+}
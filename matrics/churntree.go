@@ -0,0 +1,123 @@
+package metrics
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/andymeneely/git-churn/print"
+)
+
+// DirChurn is the churn of a directory of the repository rolled up from its files and subdirectories, or
+// the churn of a file
+type DirChurn struct {
+	// Name of the file or of the directory, ending with a slash, "." for the root
+	Name string
+	Path string
+	// Commits changing any file under the directory
+	Commits    int
+	Insertions int `units:"lines"`
+	Deletions  int `units:"lines"`
+	// Churn according to the churn definition
+	Churn int `units:"lines"`
+	// Share of the churn of the whole repository
+	Share float64
+	// Subdirectories then files, most churned first
+	Children []*DirChurn `json:",omitempty"`
+
+	commits  map[string]bool
+	children map[string]*DirChurn
+}
+
+// ChurnTree rolls the churn of the files changed by the given commits up their directories, like du
+// totals the sizes of the files. The tree is cut at the given depth below the root, the deeper files
+// being totalled in their directory at that depth, unless depth is zero.
+func ChurnTree(commits []*CommitChurn, depth int) *DirChurn {
+	root := &DirChurn{Name: ".", commits: make(map[string]bool), children: make(map[string]*DirChurn)}
+	for _, commit := range countedCommits(commits) {
+		for _, file := range commit.Files {
+			parts := strings.Split(file.File, "/")
+			if depth > 0 && len(parts) > depth {
+				parts = parts[:depth]
+				parts[depth-1] += "/"
+			}
+			root.add(commit.Hash, file)
+			node := root
+			for i, part := range parts {
+				if i < len(parts)-1 {
+					part += "/"
+				}
+				node = node.child(part)
+				node.add(commit.Hash, file)
+			}
+		}
+	}
+	root.finish(root.Churn)
+	return root
+}
+
+func (d *DirChurn) add(hash string, file FileChurn) {
+	d.commits[hash] = true
+	d.Insertions += file.Insertions
+	d.Deletions += file.Deletions
+	d.Churn += file.Churn()
+}
+
+func (d *DirChurn) child(name string) *DirChurn {
+	child, ok := d.children[name]
+	if !ok {
+		child = &DirChurn{Name: name, Path: d.Path + name, commits: make(map[string]bool), children: make(map[string]*DirChurn)}
+		d.children[name] = child
+	}
+	return child
+}
+
+// finish counts the commits, sets the shares and sorts the children of the subtree
+func (d *DirChurn) finish(total int) {
+	d.Commits = len(d.commits)
+	d.Share = ratio(float64(d.Churn), float64(total))
+	for _, child := range d.children {
+		child.finish(total)
+		d.Children = append(d.Children, child)
+	}
+	sort.Slice(d.Children, func(i, j int) bool {
+		a, b := d.Children[i], d.Children[j]
+		if a.isDir() != b.isDir() {
+			return a.isDir()
+		}
+		if a.Churn != b.Churn {
+			return a.Churn > b.Churn
+		}
+		return a.Name < b.Name
+	})
+}
+
+func (d *DirChurn) isDir() bool {
+	return strings.HasSuffix(d.Name, "/")
+}
+
+func (d *DirChurn) TreeHeader() []string {
+	return []string{"Churn", "Added", "Deleted", "Commits", "Share"}
+}
+
+func (d *DirChurn) TreeColumns(f print.TextFormat) []string {
+	return []string{
+		f.FormatLines(d.Churn),
+		"+" + f.FormatLines(d.Insertions),
+		"-" + f.FormatLines(d.Deletions),
+		strconv.Itoa(d.Commits),
+		strconv.FormatFloat(100*d.Share, 'f', f.Precision, 64) + "%",
+	}
+}
+
+func (d *DirChurn) TreeName() string {
+	return d.Name
+}
+
+func (d *DirChurn) TreeChildren() []print.TreeNode {
+	children := make([]print.TreeNode, len(d.Children))
+	for i, child := range d.Children {
+		children[i] = child
+	}
+	return children
+}
@@ -0,0 +1,43 @@
+package metrics
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/andymeneely/git-churn/print"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestChurnTree(t *testing.T) {
+	assert := assert.New(t)
+	commits := []*CommitChurn{
+		{Hash: "1", Files: []FileChurn{{File: "cmd/root.go", Insertions: 30, Deletions: 10}, {File: "main.go", Insertions: 10}}},
+		{Hash: "2", Files: []FileChurn{{File: "cmd/sub/a.go", Insertions: 20}, {File: "cmd/root.go", Insertions: 5, Deletions: 5}}},
+	}
+
+	root := ChurnTree(commits, 0)
+	assert.Equal(2, root.Commits)
+	assert.Equal(80, root.Churn)
+	assert.Equal(1.0, root.Share)
+	assert.Equal([]string{"cmd/", "main.go"}, []string{root.Children[0].Name, root.Children[1].Name})
+	cmd := root.Children[0]
+	assert.Equal(2, cmd.Commits)
+	assert.Equal(55, cmd.Insertions)
+	assert.Equal(15, cmd.Deletions)
+	assert.Equal(0.875, cmd.Share)
+	assert.Equal("cmd/sub/", cmd.Children[0].Path)
+	assert.Equal("cmd/root.go", cmd.Children[1].Path)
+	assert.Equal("cmd/sub/a.go", cmd.Children[0].Children[0].Path)
+
+	root = ChurnTree(commits, 1)
+	assert.Equal(2, len(root.Children))
+	assert.Nil(root.Children[0].Children)
+
+	var out bytes.Buffer
+	assert.Nil(print.TextFormat{Units: print.UnitsLines, Precision: 1}.WriteTree(&out, root))
+	assert.Equal(`Churn  Added  Deleted  Commits   Share
+   80    +65      -15        2  100.0%  .
+   70    +55      -15        2   87.5%  ├── cmd/
+   10    +10       -0        1   12.5%  └── main.go
+`, out.String())
+}
@@ -0,0 +1,175 @@
+package metrics
+
+import (
+	"strings"
+	"time"
+
+	"github.com/andymeneely/git-churn/gitfuncs"
+	"github.com/andymeneely/git-churn/helper"
+	"gopkg.in/src-d/go-git.v4"
+	"gopkg.in/src-d/go-git.v4/plumbing/object"
+)
+
+// DefaultCodeownersMinChurn is the churn under which the declared owners of paths did not contribute
+// meaningfully to them
+const DefaultCodeownersMinChurn = 10
+
+// CodeownersEntry compares the owners a line of CODEOWNERS declares with the authors of the files it is
+// the last matching line of
+type CodeownersEntry struct {
+	Pattern string
+	Owners  []string
+	Files   int
+	// Lines of the files at the revision, those the declared owners wrote last according to blame, and
+	// their share
+	Lines       int `units:"lines"`
+	OwnersLines int `units:"lines"`
+	OwnersShare float64
+	// Author who wrote most of the lines
+	TopAuthor      string `json:",omitempty"`
+	TopAuthorLines int    `units:"lines"`
+	// Churn of the files over the window and the part of it by the declared owners, according to the
+	// churn definition
+	Churn           int        `units:"lines"`
+	OwnersChurn     int        `units:"lines"`
+	LastOwnerCommit *time.Time `json:",omitempty"`
+	// Whether the declared owners churned less than the minimum over the window
+	Stale bool
+}
+
+// CodeownersReport cross-checks the owners declared in a CODEOWNERS with the actual authors of the files
+type CodeownersReport struct {
+	Since time.Time
+	// Files of the revision no line of CODEOWNERS matches, or whose last matching line declares no owner
+	UnownedFiles int
+	StaleEntries int
+	// Entries in the order of CODEOWNERS
+	Entries []CodeownersEntry
+}
+
+// CrossCheckCodeowners blames the files of the revision and totals the churn of the commits leading to it
+// since `since`, for every line of codeowners matching them, to tell the lines whose declared owners
+// churned less than minChurn, i.e. did not meaningfully contribute to the files over the window. The
+// ignored and binary files are left out. An owner is either an author email, a team the authors are
+// mapped to by Teams, or a GitHub user matched against the local part of the email of the authors,
+// GitHub's noreply emails included.
+func CrossCheckCodeowners(repo *git.Repository, codeowners *TeamMap, revision string, since time.Time, minChurn int) (*CodeownersReport, error) {
+	defer helper.Duration(helper.Track("CrossCheckCodeowners"))
+	hash, err := gitfuncs.ResolveRef(repo, revision)
+	if err != nil {
+		return nil, err
+	}
+	commit, err := repo.CommitObject(*hash)
+	if err != nil {
+		return nil, err
+	}
+	tree, err := commit.Tree()
+	if err != nil {
+		return nil, err
+	}
+	report := &CodeownersReport{Since: since, Entries: make([]CodeownersEntry, len(codeowners.paths))}
+	authorLines := make([]map[string]int, len(codeowners.paths))
+	for i, path := range codeowners.paths {
+		report.Entries[i] = CodeownersEntry{Pattern: path.line, Owners: path.owners}
+		authorLines[i] = make(map[string]int)
+	}
+
+	err = gitfuncs.ForEachFile(tree, func(f *object.File) error {
+		if gitfuncs.IsIgnored(repo, f.Name) {
+			return nil
+		}
+		i := codeowners.pathEntry(f.Name)
+		if i < 0 || len(codeowners.paths[i].owners) == 0 {
+			report.UnownedFiles += 1
+			return nil
+		}
+		if binary, err := f.IsBinary(); err != nil || binary {
+			return err
+		}
+		entry := &report.Entries[i]
+		entry.Files += 1
+		owners, err := LineOwnership(repo, *hash, f.Name, nil)
+		if err != nil {
+			// Not blamed, like in GetFileOwnership
+			return nil
+		}
+		for author, lines := range owners {
+			entry.Lines += lines
+			authorLines[i][author] += lines
+			if isDeclaredOwner(entry.Owners, author) {
+				entry.OwnersLines += lines
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	commits, err := ChurnSince(repo, revision, since)
+	if err != nil {
+		return nil, err
+	}
+	for _, commit := range commits {
+		for _, file := range commit.Files {
+			i := codeowners.pathEntry(file.File)
+			if i < 0 || len(codeowners.paths[i].owners) == 0 {
+				continue
+			}
+			entry := &report.Entries[i]
+			entry.Churn += file.Churn()
+			if !isDeclaredOwner(entry.Owners, commit.Author) {
+				continue
+			}
+			entry.OwnersChurn += file.Churn()
+			if entry.LastOwnerCommit == nil || commit.When.After(*entry.LastOwnerCommit) {
+				when := commit.When
+				entry.LastOwnerCommit = &when
+			}
+		}
+	}
+
+	for i := range report.Entries {
+		entry := &report.Entries[i]
+		if entry.Lines > 0 {
+			entry.OwnersShare = float64(entry.OwnersLines) / float64(entry.Lines)
+		}
+		for author, lines := range authorLines[i] {
+			if lines > entry.TopAuthorLines || (lines == entry.TopAuthorLines && author < entry.TopAuthor) {
+				entry.TopAuthor = author
+				entry.TopAuthorLines = lines
+			}
+		}
+		entry.Stale = entry.Files > 0 && entry.OwnersChurn < minChurn
+		if entry.Stale {
+			report.StaleEntries += 1
+		}
+	}
+	return report, nil
+}
+
+// isDeclaredOwner tells whether the author of the given email is one of the owners declared in CODEOWNERS
+func isDeclaredOwner(owners []string, email string) bool {
+	email = strings.ToLower(email)
+	user := strings.SplitN(email, "@", 2)[0]
+	if strings.HasSuffix(email, "@users.noreply.github.com") {
+		// 12345+user@users.noreply.github.com
+		user = user[strings.Index(user, "+")+1:]
+	}
+	for _, owner := range owners {
+		owner = strings.ToLower(owner)
+		switch {
+		case strings.Contains(owner, "@"):
+			if owner == email {
+				return true
+			}
+		case strings.Contains(owner, "/"):
+			if strings.ToLower(Teams.AuthorTeam(email)) == owner {
+				return true
+			}
+		case owner == user:
+			return true
+		}
+	}
+	return false
+}
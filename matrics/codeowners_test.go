@@ -0,0 +1,74 @@
+package metrics
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/andymeneely/git-churn/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCrossCheckCodeowners(t *testing.T) {
+	assert := assert.New(t)
+	now := time.Now()
+	repo := testutil.NewRepo(t)
+	repo.As("carol").At(now.AddDate(-2, 0, 0)).CommitFiles("old", map[string]string{
+		"legacy/x.go": strings.Repeat("x\n", 10),
+		"docs/a.md":   strings.Repeat("a\n", 5),
+	})
+	repo.As("alice").At(now.AddDate(0, -1, 0)).CommitFiles("code", map[string]string{"a.go": strings.Repeat("a\n", 12)})
+	repo.As("bob").CommitFiles("docs", map[string]string{"docs/b.md": "b\nb\nb\n"})
+	repo.As("erin").CommitFiles("rewrite", map[string]string{"docs/a.md": strings.Repeat("z\n", 20)})
+	codeowners, err := ParseTeamMap(strings.NewReader("*.go @alice\n/docs/ docs@example.com @org/writers\n/legacy/ @carol\n/gone/ @dave\n/docs/b.md\n"))
+	assert.Nil(err)
+	Teams, err = ParseTeamMap(strings.NewReader("erin@example.com @org/writers\n"))
+	assert.Nil(err)
+	defer func() { Teams = nil }()
+
+	report, err := CrossCheckCodeowners(repo.Repository, codeowners, "HEAD", now.AddDate(0, -6, 0), 10)
+	assert.Nil(err)
+	assert.Equal(1, report.StaleEntries)
+	assert.Equal(1, report.UnownedFiles)
+	assert.Equal(5, len(report.Entries))
+
+	code := report.Entries[0]
+	assert.Equal("*.go", code.Pattern)
+	assert.Equal([]string{"alice"}, code.Owners)
+	assert.Equal(1, code.Files)
+	assert.Equal(12, code.OwnersLines)
+	assert.Equal(12, code.OwnersChurn)
+	assert.NotNil(code.LastOwnerCommit)
+	assert.False(code.Stale)
+
+	// docs/b.md is left unowned by the last line
+	docs := report.Entries[1]
+	assert.Equal(1, docs.Files)
+	assert.Equal(20, docs.Lines)
+	assert.Equal(20, docs.OwnersLines)
+	assert.Equal("erin@example.com", docs.TopAuthor)
+	assert.Equal(25, docs.Churn)
+	assert.Equal(25, docs.OwnersChurn)
+	assert.False(docs.Stale)
+
+	legacy := report.Entries[2]
+	assert.Equal(10, legacy.OwnersLines)
+	assert.Equal(1.0, legacy.OwnersShare)
+	assert.Equal(0, legacy.Churn)
+	assert.Nil(legacy.LastOwnerCommit)
+	assert.True(legacy.Stale)
+
+	assert.Equal(0, report.Entries[3].Files)
+	assert.False(report.Entries[3].Stale)
+	assert.Empty(report.Entries[4].Owners)
+	assert.Equal(0, report.Entries[4].Churn)
+	assert.False(report.Entries[4].Stale)
+}
+
+func TestIsDeclaredOwner(t *testing.T) {
+	assert := assert.New(t)
+	assert.True(isDeclaredOwner([]string{"Alice"}, "alice@example.com"))
+	assert.True(isDeclaredOwner([]string{"alice"}, "1234+alice@users.noreply.github.com"))
+	assert.True(isDeclaredOwner([]string{"bob", "alice@example.com"}, "Alice@example.com"))
+	assert.False(isDeclaredOwner([]string{"org/writers"}, "alice@example.com"))
+}
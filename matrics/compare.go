@@ -0,0 +1,130 @@
+package metrics
+
+import (
+	"sort"
+
+	"github.com/andymeneely/git-churn/gitfuncs"
+	"github.com/andymeneely/git-churn/helper"
+	"gopkg.in/src-d/go-git.v4"
+	"gopkg.in/src-d/go-git.v4/plumbing"
+	"gopkg.in/src-d/go-git.v4/plumbing/object"
+)
+
+type FileChurn struct {
+	File       string
+	Insertions int `units:"lines"`
+	Deletions  int `units:"lines"`
+
+	// Deleted lines that were added within the window of the ChurnRecent mode
+	RecentDeletions int `json:",omitempty" units:"lines"`
+}
+
+// Churn returns the churn of the file according to the churn definition
+func (f FileChurn) Churn() int {
+	return Churn.Lines(f.Insertions, f.Deletions, f.RecentDeletions)
+}
+
+type BranchChurn struct {
+	Branch       string
+	Commit       string
+	CommitsAhead int
+	Insertions   int `units:"lines"`
+	Deletions    int `units:"lines"`
+	FilesChanged int
+	Files        []FileChurn
+}
+
+type BranchComparison struct {
+	MergeBase string
+	Base      BranchChurn
+	Head      BranchChurn
+	// Files changed on both branches since they diverged
+	OverlappingFiles []string
+}
+
+// CompareBranches computes the churn each of the two branches accumulated since their merge base,
+// e.g. a long lived feature branch (head) against main (base), both in aggregate and per file
+func CompareBranches(repo *git.Repository, base, head string) (*BranchComparison, error) {
+	defer helper.Duration(helper.Track("CompareBranches"))
+	baseHash, err := gitfuncs.ResolveRef(repo, base)
+	if err != nil {
+		return nil, err
+	}
+	headHash, err := gitfuncs.ResolveRef(repo, head)
+	if err != nil {
+		return nil, err
+	}
+	mergeBase, err := gitfuncs.MergeBase(repo, *baseHash, *headHash)
+	if err != nil {
+		return nil, err
+	}
+
+	comparison := &BranchComparison{MergeBase: mergeBase.Hash.String()}
+	comparison.Base, err = branchChurnSince(repo, mergeBase, base, *baseHash)
+	if err != nil {
+		return nil, err
+	}
+	comparison.Head, err = branchChurnSince(repo, mergeBase, head, *headHash)
+	if err != nil {
+		return nil, err
+	}
+
+	baseFiles := make(map[string]bool)
+	for _, file := range comparison.Base.Files {
+		baseFiles[file.File] = true
+	}
+	for _, file := range comparison.Head.Files {
+		if baseFiles[file.File] {
+			comparison.OverlappingFiles = append(comparison.OverlappingFiles, file.File)
+		}
+	}
+	return comparison, nil
+}
+
+// branchChurnSince computes the churn between the merge base and the tip of the branch
+func branchChurnSince(repo *git.Repository, mergeBase *object.Commit, branch string, tip plumbing.Hash) (BranchChurn, error) {
+	churn := BranchChurn{Branch: branch, Commit: tip.String()}
+	commits, err := gitfuncs.CommitsBetween(repo, mergeBase.Hash, tip)
+	if err != nil {
+		return churn, err
+	}
+	churn.CommitsAhead = len(commits)
+
+	tipCommit, err := repo.CommitObject(tip)
+	if err != nil {
+		return churn, err
+	}
+	fromTree, err := mergeBase.Tree()
+	if err != nil {
+		return churn, err
+	}
+	toTree, err := tipCommit.Tree()
+	if err != nil {
+		return churn, err
+	}
+	stats, err := gitfuncs.TreeDiffStats(fromTree, toTree)
+	if err != nil {
+		return churn, err
+	}
+	churn.Files = PathRewrites.rewriteFiles(fileChurnFromStats(repo, stats))
+	for _, file := range churn.Files {
+		churn.Insertions += file.Insertions
+		churn.Deletions += file.Deletions
+	}
+	churn.FilesChanged = len(churn.Files)
+	return churn, nil
+}
+
+// fileChurnFromStats converts go-git file stats into FileChurn sorted by path, leaving out the files the
+// repository ignores
+func fileChurnFromStats(repo *git.Repository, stats object.FileStats) []FileChurn {
+	files := make([]FileChurn, 0, len(stats))
+	for _, stat := range stats {
+		if gitfuncs.IsIgnored(repo, stat.Name) {
+			continue
+		}
+		files = append(files, FileChurn{File: stat.Name, Insertions: stat.Addition, Deletions: stat.Deletion})
+	}
+	sort.Slice(files, func(i, j int) bool { return files[i].File < files[j].File })
+	return files
+}
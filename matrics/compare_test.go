@@ -0,0 +1,44 @@
+package metrics
+
+import (
+	"testing"
+
+	"github.com/andymeneely/git-churn/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCompareBranches(t *testing.T) {
+	assert := assert.New(t)
+	repo := testutil.NewDivergedRepo(t)
+	master := repo.Head()
+
+	comparison, err := CompareBranches(repo.Repository, "master", "feature")
+	assert.Nil(err)
+	root, err := master.Parent(0)
+	assert.Nil(err)
+	assert.Equal(root.Hash.String(), comparison.MergeBase)
+	assert.Equal(BranchChurn{Branch: "master", Commit: master.Hash.String(), CommitsAhead: 1, Insertions: 2, Deletions: 1, FilesChanged: 2,
+		Files: []FileChurn{{File: "a.txt", Insertions: 1, Deletions: 1}, {File: "b.txt", Insertions: 1}}}, comparison.Base)
+	head := comparison.Head
+	assert.Equal("feature", head.Branch)
+	assert.Equal(2, head.CommitsAhead)
+	assert.Equal(3, head.Insertions)
+	assert.Equal(0, head.Deletions)
+	// c.txt changed twice on the branch counts once, by the diff since the merge base
+	assert.Equal([]FileChurn{{File: "a.txt", Insertions: 1}, {File: "c.txt", Insertions: 2}}, head.Files)
+	assert.Equal([]string{"a.txt"}, comparison.OverlappingFiles)
+
+	// Once merged, the feature branch has nothing left ahead of master
+	repo.Merge("feature", "merge")
+	comparison, err = CompareBranches(repo.Repository, "master", "feature")
+	assert.Nil(err)
+	assert.Equal(head.Commit, comparison.MergeBase)
+	assert.Equal(0, comparison.Head.CommitsAhead)
+	assert.Empty(comparison.Head.Files)
+	// The master commit and the merge
+	assert.Equal(2, comparison.Base.CommitsAhead)
+	assert.Empty(comparison.OverlappingFiles)
+
+	_, err = CompareBranches(repo.Repository, "master", "nothing")
+	assert.NotNil(err)
+}
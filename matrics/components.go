@@ -0,0 +1,238 @@
+package metrics
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/andymeneely/git-churn/gitfuncs"
+	"github.com/andymeneely/git-churn/helper"
+	"gopkg.in/src-d/go-git.v4"
+	"gopkg.in/src-d/go-git.v4/plumbing/object"
+)
+
+// NoComponent is the component of the files under no component path
+const NoComponent = "other"
+
+// Component is a part of a monorepo, e.g. a service, made of the files under its path
+type Component struct {
+	Name string
+	Path string
+}
+
+// ComponentMap maps the files of a repository to components. A nil ComponentMap maps nothing.
+type ComponentMap struct {
+	// Longest path first
+	components []Component
+}
+
+// Components maps the files to the components the component metrics aggregate them into
+var Components *ComponentMap
+
+// ParseComponents parses components given as name=path, or as a path naming the component, e.g.
+// billing=services/billing or web/. A file belongs to the component of the longest path it is under.
+func ParseComponents(specs []string) (*ComponentMap, error) {
+	m := &ComponentMap{}
+	names := make(map[string]bool)
+	for _, spec := range specs {
+		name, componentPath := "", spec
+		if i := strings.Index(spec, "="); i >= 0 {
+			name, componentPath = strings.TrimSpace(spec[:i]), spec[i+1:]
+		}
+		componentPath = strings.Trim(strings.TrimSpace(componentPath), "/")
+		if componentPath == "" {
+			return nil, fmt.Errorf("component %q has no path", spec)
+		}
+		if name == "" {
+			name = componentPath
+		}
+		if names[name] || name == NoComponent {
+			return nil, fmt.Errorf("component %q defined twice", name)
+		}
+		names[name] = true
+		m.components = append(m.components, Component{Name: name, Path: componentPath})
+	}
+	sort.SliceStable(m.components, func(i, j int) bool {
+		return len(m.components[i].Path) > len(m.components[j].Path)
+	})
+	return m, nil
+}
+
+// PathComponent returns the component of the file at the path, or NoComponent
+func (m *ComponentMap) PathComponent(path string) string {
+	if m == nil {
+		return NoComponent
+	}
+	for _, component := range m.components {
+		if path == component.Path || strings.HasPrefix(path, component.Path+"/") {
+			return component.Name
+		}
+	}
+	return NoComponent
+}
+
+// componentPath returns the path of the component, empty for NoComponent
+func (m *ComponentMap) componentPath(name string) string {
+	if m == nil {
+		return ""
+	}
+	for _, component := range m.components {
+		if component.Name == name {
+			return component.Path
+		}
+	}
+	return ""
+}
+
+// ComponentChurn totals the commits changing the files of a component
+type ComponentChurn struct {
+	Component string
+	Path      string `json:",omitempty"`
+	// Commits changing files of the component, their authors and the lines they changed in it
+	Commits    int
+	Authors    int
+	Insertions int `units:"lines"`
+	Deletions  int `units:"lines"`
+	// Churn according to the churn definition
+	Churn        int `units:"lines"`
+	FilesChanged int
+	// Files and lines of the component at the revision
+	Files int
+	LOC   int `units:"lines"`
+	// Commits also changing files of other components
+	CrossCommits int
+}
+
+// ComponentCoupling is how often two components change in the same commits
+type ComponentCoupling struct {
+	Component        string
+	CoupledComponent string
+	// Commits changing files of both components
+	CoChanges int
+	// CoChanges divided by the commits of the component changing least, from 0 to 1
+	Coupling float64
+}
+
+// ComponentReport is the churn of every component of a range and how they change together
+type ComponentReport struct {
+	Revision string
+	// Commits changing files of more than one component
+	CrossCommits int
+	// Most churning first
+	Components []ComponentChurn
+	// Most coupled first
+	Coupling []ComponentCoupling
+}
+
+// ChurnByComponent totals the given commits per component of the files they change according to
+// Components, counts the files and lines of every component at the revision, and the commits spanning
+// several components. The files under no component path make NoComponent, left out of the coupling.
+func ChurnByComponent(repo *git.Repository, commits []*CommitChurn, revision string) (*ComponentReport, error) {
+	defer helper.Duration(helper.Track("ChurnByComponent"))
+	report := &ComponentReport{Revision: revision}
+	byComponent := make(map[string]*ComponentChurn)
+	authors := make(map[string]map[string]bool)
+	files := make(map[string]map[string]bool)
+	componentChurn := func(component string) *ComponentChurn {
+		churn, ok := byComponent[component]
+		if !ok {
+			churn = &ComponentChurn{Component: component, Path: Components.componentPath(component)}
+			byComponent[component] = churn
+			authors[component] = make(map[string]bool)
+			files[component] = make(map[string]bool)
+		}
+		return churn
+	}
+	type pair struct{ a, b string }
+	coChanges := make(map[pair]int)
+	for _, commit := range countedCommits(commits) {
+		touched := make(map[string]bool)
+		for _, file := range commit.Files {
+			component := Components.PathComponent(file.File)
+			churn := componentChurn(component)
+			if !touched[component] {
+				touched[component] = true
+				churn.Commits += 1
+				authors[component][commit.Author] = true
+			}
+			files[component][file.File] = true
+			churn.Insertions += file.Insertions
+			churn.Deletions += file.Deletions
+			churn.Churn += file.Churn()
+		}
+		var spanned []string
+		for component := range touched {
+			if component != NoComponent {
+				spanned = append(spanned, component)
+			}
+		}
+		if len(spanned) < 2 {
+			continue
+		}
+		report.CrossCommits += 1
+		sort.Strings(spanned)
+		for i, component := range spanned {
+			byComponent[component].CrossCommits += 1
+			for _, other := range spanned[i+1:] {
+				coChanges[pair{component, other}] += 1
+			}
+		}
+	}
+
+	tree, err := revisionTree(repo, revision)
+	if err != nil {
+		return nil, err
+	}
+	ignore := gitfuncs.RepoIgnore(repo)
+	err = gitfuncs.ForEachFile(tree, func(f *object.File) error {
+		if ignore.Match(f.Name) {
+			return nil
+		}
+		churn := componentChurn(Components.PathComponent(f.Name))
+		churn.Files += 1
+		churn.LOC += gitfuncs.BlobLOC(f, true)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for component, churn := range byComponent {
+		churn.Authors = len(authors[component])
+		churn.FilesChanged = len(files[component])
+		report.Components = append(report.Components, *churn)
+	}
+	sort.Slice(report.Components, func(i, j int) bool {
+		a, b := report.Components[i], report.Components[j]
+		if a.Churn != b.Churn {
+			return a.Churn > b.Churn
+		}
+		return a.Component < b.Component
+	})
+	for p, count := range coChanges {
+		least := byComponent[p.a].Commits
+		if byComponent[p.b].Commits < least {
+			least = byComponent[p.b].Commits
+		}
+		report.Coupling = append(report.Coupling, ComponentCoupling{
+			Component:        p.a,
+			CoupledComponent: p.b,
+			CoChanges:        count,
+			Coupling:         float64(count) / float64(least),
+		})
+	}
+	sort.Slice(report.Coupling, func(i, j int) bool {
+		a, b := report.Coupling[i], report.Coupling[j]
+		if a.CoChanges != b.CoChanges {
+			return a.CoChanges > b.CoChanges
+		}
+		if a.Coupling != b.Coupling {
+			return a.Coupling > b.Coupling
+		}
+		if a.Component != b.Component {
+			return a.Component < b.Component
+		}
+		return a.CoupledComponent < b.CoupledComponent
+	})
+	return report, nil
+}
@@ -0,0 +1,54 @@
+package metrics
+
+import (
+	"testing"
+
+	"github.com/andymeneely/git-churn/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseComponents(t *testing.T) {
+	assert := assert.New(t)
+	components, err := ParseComponents([]string{"billing=services/billing/", "services", "web"})
+	assert.Nil(err)
+	assert.Equal("billing", components.PathComponent("services/billing/api.go"))
+	assert.Equal("services", components.PathComponent("services/billing.go"))
+	assert.Equal("web", components.PathComponent("web"))
+	assert.Equal(NoComponent, components.PathComponent("website/index.html"))
+	var none *ComponentMap
+	assert.Equal(NoComponent, none.PathComponent("web/index.html"))
+
+	_, err = ParseComponents([]string{"web", "web=client"})
+	assert.EqualError(err, `component "web" defined twice`)
+	_, err = ParseComponents([]string{"web="})
+	assert.EqualError(err, `component "web=" has no path`)
+}
+
+func TestChurnByComponent(t *testing.T) {
+	assert := assert.New(t)
+	repo := testutil.NewRepo(t)
+	repo.As("alice").CommitFiles("root", map[string]string{"README": "1\n", "api/a.go": "1\n2\n", "web/app.js": "1\n"})
+	repo.As("bob").CommitFiles("api", map[string]string{"api/a.go": "1\n2\n3\n"})
+	repo.As("bob").CommitFiles("both", map[string]string{"api/b.go": "1\n", "web/app.js": "1\n2\n", "README": "1\n2\n"})
+
+	var err error
+	Components, err = ParseComponents([]string{"api", "frontend=web"})
+	assert.Nil(err)
+	defer func() { Components = nil }()
+	commits, err := RangeChurn(repo.Repository, "", "HEAD")
+	assert.Nil(err)
+	report, err := ChurnByComponent(repo.Repository, commits, "HEAD")
+	assert.Nil(err)
+
+	assert.Equal(2, report.CrossCommits)
+	assert.Equal([]ComponentChurn{
+		{Component: "api", Path: "api", Commits: 3, Authors: 2, Insertions: 4, Churn: 4, FilesChanged: 2, Files: 2, LOC: 4, CrossCommits: 2},
+		{Component: "frontend", Path: "web", Commits: 2, Authors: 2, Insertions: 2, Churn: 2, FilesChanged: 1, Files: 1, LOC: 2, CrossCommits: 2},
+		{Component: NoComponent, Commits: 2, Authors: 2, Insertions: 2, Churn: 2, FilesChanged: 1, Files: 1, LOC: 2},
+	}, report.Components)
+	assert.Equal([]ComponentCoupling{{Component: "api", CoupledComponent: "frontend", CoChanges: 2, Coupling: 1}}, report.Coupling)
+
+	snapshot, err := GetLOCSnapshot(repo.Repository, "HEAD", GroupByComponent, true, false)
+	assert.Nil(err)
+	assert.Equal([]LOCGroup{{Name: "api", Files: 2, LOC: 4}, {Name: "frontend", Files: 1, LOC: 2}, {Name: NoComponent, Files: 1, LOC: 2}}, snapshot.Groups)
+}
@@ -0,0 +1,107 @@
+package metrics
+
+import (
+	"sort"
+
+	"github.com/andymeneely/git-churn/gitfuncs"
+	"github.com/andymeneely/git-churn/helper"
+	"gopkg.in/src-d/go-git.v4"
+)
+
+// NearbyLines is the distance in lines under which changes made on both sides of a merge, though merging
+// cleanly, may still conflict, their hunks aligning differently once diffed, or clash in meaning
+const NearbyLines = 3
+
+// Likelihood of a file conflicting when merged
+const (
+	// git will stop on a conflict
+	ConflictHigh = "high"
+	// The changes of both sides are within NearbyLines lines of each other
+	ConflictMedium = "medium"
+	// The changes of both sides are further apart
+	ConflictLow = "low"
+)
+
+// FileConflict is a file changed on both sides of a merge and how likely it is to conflict
+type FileConflict struct {
+	gitfuncs.MergeFile
+	Likelihood string
+}
+
+// ConflictPrediction is the likelihood that merging head into base conflicts, per file changed on both
+// since their merge base
+type ConflictPrediction struct {
+	Base      string
+	Head      string
+	MergeBase string
+	// Files changed differently on both branches
+	FilesChangedOnBoth int
+	// Files git will stop on
+	Conflicts int
+	// Most likely to conflict first, then nearest changes first, then by path
+	Files []FileConflict
+}
+
+// PredictConflicts predicts, before merging head into base, the files both changed since their merge base
+// and the runs of lines of the merge base both changed, which git will stop on, and tells how likely the
+// other files changed on both are to conflict by how close their changes are. The files the repository
+// ignores are left out.
+func PredictConflicts(repo *git.Repository, base, head string) (*ConflictPrediction, error) {
+	defer helper.Duration(helper.Track("PredictConflicts"))
+	baseHash, err := gitfuncs.ResolveRef(repo, base)
+	if err != nil {
+		return nil, err
+	}
+	headHash, err := gitfuncs.ResolveRef(repo, head)
+	if err != nil {
+		return nil, err
+	}
+	mergeBase, err := gitfuncs.MergeBase(repo, *baseHash, *headHash)
+	if err != nil {
+		return nil, err
+	}
+	mergeBaseTree, err := mergeBase.Tree()
+	if err != nil {
+		return nil, err
+	}
+	baseTree, err := revisionTree(repo, baseHash.String())
+	if err != nil {
+		return nil, err
+	}
+	headTree, err := revisionTree(repo, headHash.String())
+	if err != nil {
+		return nil, err
+	}
+	files, err := gitfuncs.MergeFiles(mergeBaseTree, baseTree, headTree)
+	if err != nil {
+		return nil, err
+	}
+
+	prediction := &ConflictPrediction{Base: base, Head: head, MergeBase: mergeBase.Hash.String(), Files: []FileConflict{}}
+	ignore := gitfuncs.RepoIgnore(repo)
+	for _, file := range files {
+		if ignore.Match(file.File) {
+			continue
+		}
+		conflict := FileConflict{MergeFile: file, Likelihood: ConflictLow}
+		if file.Conflict != "" {
+			conflict.Likelihood = ConflictHigh
+			prediction.Conflicts += 1
+		} else if file.Distance <= NearbyLines {
+			conflict.Likelihood = ConflictMedium
+		}
+		prediction.Files = append(prediction.Files, conflict)
+	}
+	prediction.FilesChangedOnBoth = len(prediction.Files)
+	sort.Slice(prediction.Files, func(i, j int) bool {
+		a, b := prediction.Files[i], prediction.Files[j]
+		if (a.Conflict != "") != (b.Conflict != "") {
+			return a.Conflict != ""
+		}
+		if a.Distance != b.Distance {
+			return a.Distance < b.Distance
+		}
+		return a.File < b.File
+	})
+	return prediction, nil
+}
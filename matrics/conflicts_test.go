@@ -0,0 +1,27 @@
+package metrics
+
+import (
+	"testing"
+
+	"github.com/andymeneely/git-churn/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPredictConflicts(t *testing.T) {
+	assert := assert.New(t)
+	repo := testutil.NewRepo(t)
+	repo.CommitFiles("root", map[string]string{"a.txt": "1\n2\n3\n4\n5\n6\n7\n8\n9\n", "b.txt": "1\n2\n3\n4\n5\n6\n7\n8\n9\n", "c.txt": "1\n2\n3\n4\n5\n6\n7\n8\n9\n"})
+	repo.Branch("feature").CommitFiles("feature", map[string]string{"a.txt": "1\nfeature\n3\n4\n5\n6\n7\n8\n9\n", "b.txt": "1\nfeature\n3\n4\n5\n6\n7\n8\n9\n", "c.txt": "feature\n2\n3\n4\n5\n6\n7\n8\n9\n"})
+	repo.Checkout("master").CommitFiles("master", map[string]string{"a.txt": "1\nmaster\n3\n4\n5\n6\n7\n8\n9\n", "b.txt": "1\n2\n3\n4\nmaster\n6\n7\n8\n9\n", "c.txt": "1\n2\n3\n4\n5\n6\n7\n8\nmaster\n"})
+
+	prediction, err := PredictConflicts(repo.Repository, "master", "feature")
+	assert.Nil(err)
+	assert.Equal(3, prediction.FilesChangedOnBoth)
+	assert.Equal(1, prediction.Conflicts)
+	var likelihoods []string
+	for _, file := range prediction.Files {
+		likelihoods = append(likelihoods, file.File+" "+file.Likelihood)
+	}
+	assert.Equal([]string{"a.txt high", "b.txt medium", "c.txt low"}, likelihoods)
+	assert.Equal(2, prediction.Files[1].Distance)
+}
@@ -0,0 +1,101 @@
+package metrics
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+// Contributor totals the commits of an author
+type Contributor struct {
+	Author     string
+	Name       string
+	Commits    int
+	Insertions int `units:"lines"`
+	Deletions  int `units:"lines"`
+	// Churn according to the churn definition
+	Churn int `units:"lines"`
+	// Distinct files changed by the author
+	FilesTouched int
+	FirstCommit  time.Time
+	LastCommit   time.Time
+	// Team of the author, when Teams are mapped
+	Team string `json:",omitempty"`
+}
+
+// ContributorSortKeys are the keys Contributors can rank the authors by
+var ContributorSortKeys = []string{"commits", "insertions", "deletions", "churn", "files", "first", "last"}
+
+// Contributors totals the given commits per author and ranks the authors first by the given key: commits,
+// insertions, deletions, churn, files, first (the earliest first commit first) or last (the latest last
+// commit first). Ties are ranked by email. The merges of branches whose commits are counted are left out.
+func Contributors(commits []*CommitChurn, sortBy string) ([]Contributor, error) {
+	var greater func(a, b *Contributor) bool
+	switch sortBy {
+	case "", "commits":
+		greater = func(a, b *Contributor) bool { return a.Commits > b.Commits }
+	case "insertions":
+		greater = func(a, b *Contributor) bool { return a.Insertions > b.Insertions }
+	case "deletions":
+		greater = func(a, b *Contributor) bool { return a.Deletions > b.Deletions }
+	case "churn":
+		greater = func(a, b *Contributor) bool { return a.Churn > b.Churn }
+	case "files":
+		greater = func(a, b *Contributor) bool { return a.FilesTouched > b.FilesTouched }
+	case "first":
+		greater = func(a, b *Contributor) bool { return a.FirstCommit.Before(b.FirstCommit) }
+	case "last":
+		greater = func(a, b *Contributor) bool { return a.LastCommit.After(b.LastCommit) }
+	default:
+		return nil, fmt.Errorf("unknown sort key %q, expected one of %v", sortBy, ContributorSortKeys)
+	}
+
+	byAuthor := make(map[string]*Contributor)
+	files := make(map[string]map[string]bool)
+	for _, commit := range commits {
+		if commit.mergedBranch() {
+			continue
+		}
+		name, email := commit.AuthorName, commit.Author
+		contributor, ok := byAuthor[email]
+		if !ok {
+			contributor = &Contributor{Author: email, FirstCommit: commit.When, LastCommit: commit.When}
+			byAuthor[email] = contributor
+			files[email] = make(map[string]bool)
+		}
+		contributor.Commits += 1
+		contributor.Insertions += commit.Insertions
+		contributor.Deletions += commit.Deletions
+		contributor.Churn += commit.Churn()
+		if commit.When.Before(contributor.FirstCommit) {
+			contributor.FirstCommit = commit.When
+		}
+		// The name of the latest commit, authors tend to fix their name rather than break it
+		if !commit.When.Before(contributor.LastCommit) {
+			contributor.LastCommit = commit.When
+			contributor.Name = name
+		}
+		for _, file := range commit.Files {
+			files[email][file.File] = true
+		}
+	}
+
+	contributors := make([]Contributor, 0, len(byAuthor))
+	for email, contributor := range byAuthor {
+		contributor.FilesTouched = len(files[email])
+		if Teams != nil {
+			contributor.Team = Teams.AuthorTeam(email)
+		}
+		contributors = append(contributors, *contributor)
+	}
+	sort.Slice(contributors, func(i, j int) bool {
+		if greater(&contributors[i], &contributors[j]) {
+			return true
+		}
+		if greater(&contributors[j], &contributors[i]) {
+			return false
+		}
+		return contributors[i].Author < contributors[j].Author
+	})
+	return contributors, nil
+}
@@ -0,0 +1,86 @@
+package metrics
+
+import (
+	"testing"
+
+	"github.com/andymeneely/git-churn/gitfuncs"
+	"github.com/andymeneely/git-churn/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestContributors(t *testing.T) {
+	assert := assert.New(t)
+	commits := []*CommitChurn{
+		churnAt("alice@laptop.local", "2020-03-01", 4, 1),
+		churnAt("bob@example.com", "2020-02-10", 10, 10),
+		churnAt("alice@example.com", "2020-01-10", 1, 0),
+	}
+	commits[0].AuthorName = "Alice Doe"
+	commits[1].AuthorName = "bob"
+	commits[2].AuthorName = "alice"
+	commits[0].Files = []FileChurn{{File: "a.go", Insertions: 4, Deletions: 1}}
+	commits[1].Files = []FileChurn{{File: "b.go", Insertions: 10, Deletions: 10}}
+	commits[2].Files = []FileChurn{{File: "a.go", Insertions: 1}}
+
+	contributors, err := Contributors(commits, "commits")
+	assert.Nil(err)
+	assert.Equal([]string{"alice@example.com", "alice@laptop.local", "bob@example.com"}, contributorEmails(contributors))
+
+	// As resolved by the mailmap
+	commits[0].Author = "alice@example.com"
+	contributors, err = Contributors(commits, "commits")
+	assert.Nil(err)
+	assert.Equal([]Contributor{
+		{Author: "alice@example.com", Name: "Alice Doe", Commits: 2, Insertions: 5, Deletions: 1, Churn: 6,
+			FilesTouched: 1, FirstCommit: commits[2].When, LastCommit: commits[0].When},
+		{Author: "bob@example.com", Name: "bob", Commits: 1, Insertions: 10, Deletions: 10, Churn: 20,
+			FilesTouched: 1, FirstCommit: commits[1].When, LastCommit: commits[1].When},
+	}, contributors)
+
+	contributors, err = Contributors(commits, "churn")
+	assert.Nil(err)
+	assert.Equal([]string{"bob@example.com", "alice@example.com"}, contributorEmails(contributors))
+
+	contributors, err = Contributors(commits, "first")
+	assert.Nil(err)
+	assert.Equal([]string{"alice@example.com", "bob@example.com"}, contributorEmails(contributors))
+
+	_, err = Contributors(commits, "name")
+	assert.NotNil(err)
+}
+
+func TestContributorsMerge(t *testing.T) {
+	assert := assert.New(t)
+	repo := testutil.NewDivergedRepo(t)
+	repo.Merge("feature", "merge")
+	commits, err := RangeChurn(repo.Repository, "", "HEAD")
+	assert.Nil(err)
+
+	// The merge by alice is not credited with the lines bob added on the branch
+	contributors, err := Contributors(commits, "commits")
+	assert.Nil(err)
+	assert.Equal([]string{"alice@example.com", "bob@example.com"}, contributorEmails(contributors))
+	assert.Equal(2, contributors[0].Commits)
+	assert.Equal(6, contributors[0].Insertions)
+	assert.Equal(1, contributors[0].Deletions)
+	assert.Equal(2, contributors[1].Commits)
+	assert.Equal(3, contributors[1].Insertions)
+
+	// The merge stands for the commits of the branch when only the first parents are walked
+	gitfuncs.FirstParent = true
+	defer func() { gitfuncs.FirstParent = false }()
+	commits, err = RangeChurn(repo.Repository, "", "HEAD")
+	assert.Nil(err)
+	contributors, err = Contributors(commits, "commits")
+	assert.Nil(err)
+	assert.Equal([]string{"alice@example.com"}, contributorEmails(contributors))
+	assert.Equal(3, contributors[0].Commits)
+}
+
+func contributorEmails(contributors []Contributor) []string {
+	var emails []string
+	for _, contributor := range contributors {
+		emails = append(emails, contributor.Author)
+	}
+	return emails
+}
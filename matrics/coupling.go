@@ -0,0 +1,81 @@
+package metrics
+
+import "sort"
+
+// FileCoupling is how often two files change together, the logical coupling of Gall et al. ("Detection of
+// logical coupling based on product release history", ICSM 1998)
+type FileCoupling struct {
+	File        string
+	CoupledFile string
+	// Commits changing both files
+	CoChanges int
+	// CoChanges divided by the commits of the file changing least, from 0 to 1 when one never changes
+	// without the other
+	Coupling float64
+}
+
+// DefaultMinCoChanges is the number of commits two files have to change together in to be reported coupled
+const DefaultMinCoChanges = 2
+
+// MaxCouplingFiles is the number of files over which a commit, like a bulk rename or reformat, tells
+// nothing about the coupling of its files and is left out
+const MaxCouplingFiles = 50
+
+// ChangeCoupling counts the commits changing every pair of files together and returns the n pairs changing
+// together most (all of them if n is zero), in at least minCoChanges commits. Merges and the commits
+// changing more than MaxCouplingFiles files are left out. Ties are ranked by coupling, then by file names.
+func ChangeCoupling(commits []*CommitChurn, minCoChanges, n int) []FileCoupling {
+	type pair struct{ a, b string }
+	fileCommits := make(map[string]int)
+	coChanges := make(map[pair]int)
+	for _, commit := range commits {
+		if commit.Parents > 1 || len(commit.Files) > MaxCouplingFiles {
+			continue
+		}
+		files := make([]string, len(commit.Files))
+		for i, file := range commit.Files {
+			files[i] = file.File
+			fileCommits[file.File] += 1
+		}
+		sort.Strings(files)
+		for i := range files {
+			for j := i + 1; j < len(files); j++ {
+				coChanges[pair{files[i], files[j]}] += 1
+			}
+		}
+	}
+
+	var couplings []FileCoupling
+	for p, count := range coChanges {
+		if count < minCoChanges {
+			continue
+		}
+		least := fileCommits[p.a]
+		if fileCommits[p.b] < least {
+			least = fileCommits[p.b]
+		}
+		couplings = append(couplings, FileCoupling{
+			File:        p.a,
+			CoupledFile: p.b,
+			CoChanges:   count,
+			Coupling:    float64(count) / float64(least),
+		})
+	}
+	sort.Slice(couplings, func(i, j int) bool {
+		a, b := couplings[i], couplings[j]
+		if a.CoChanges != b.CoChanges {
+			return a.CoChanges > b.CoChanges
+		}
+		if a.Coupling != b.Coupling {
+			return a.Coupling > b.Coupling
+		}
+		if a.File != b.File {
+			return a.File < b.File
+		}
+		return a.CoupledFile < b.CoupledFile
+	})
+	if n > 0 && len(couplings) > n {
+		couplings = couplings[:n]
+	}
+	return couplings
+}
@@ -0,0 +1,41 @@
+package metrics
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestChangeCoupling(t *testing.T) {
+	assert := assert.New(t)
+	commit := func(parents int, files ...string) *CommitChurn {
+		c := &CommitChurn{Parents: parents}
+		for _, file := range files {
+			c.Files = append(c.Files, FileChurn{File: file, Insertions: 1})
+		}
+		return c
+	}
+	var bulk []string
+	for i := 0; i <= MaxCouplingFiles; i++ {
+		bulk = append(bulk, fmt.Sprintf("f%d.go", i))
+	}
+	commits := []*CommitChurn{
+		commit(1, "b.go", "a.go"),
+		commit(1, "a.go", "b.go", "c.go"),
+		commit(1, "a.go", "c.go"),
+		commit(1, "a.go"),
+		commit(2, "a.go", "b.go"),
+		commit(1, append(bulk, "a.go", "b.go")...),
+	}
+
+	couplings := ChangeCoupling(commits, 1, 0)
+	assert.Equal([]FileCoupling{
+		{File: "a.go", CoupledFile: "b.go", CoChanges: 2, Coupling: 1},
+		{File: "a.go", CoupledFile: "c.go", CoChanges: 2, Coupling: 1},
+		{File: "b.go", CoupledFile: "c.go", CoChanges: 1, Coupling: 0.5},
+	}, couplings)
+	assert.Len(ChangeCoupling(commits, DefaultMinCoChanges, 0), 2)
+	assert.Len(ChangeCoupling(commits, 1, 1), 1)
+	assert.Nil(ChangeCoupling(nil, 1, 0))
+}
@@ -4,27 +4,78 @@ import (
 	"errors"
 	"github.com/andymeneely/git-churn/gitfuncs"
 	"github.com/andymeneely/git-churn/helper"
+	"github.com/andymeneely/git-churn/lang"
 	"gopkg.in/src-d/go-git.v4"
-	"strings"
+	"gopkg.in/src-d/go-git.v4/plumbing/filemode"
+	"gopkg.in/src-d/go-git.v4/plumbing/object"
+	"sort"
 )
 
 type DiffMetrics struct {
-	Insertions  int
-	Deletions   int
-	LinesBefore int
-	LinesAfter  int
+	Insertions  int `units:"lines"`
+	Deletions   int `units:"lines"`
+	LinesBefore int `units:"lines"`
+	LinesAfter  int `units:"lines"`
+	// Churn according to the churn definition
+	Churn int `units:"lines"`
+	// Tokens of code added and deleted, counted only when CountTokens is set
+	TokenInsertions int `json:",omitempty"`
+	TokenDeletions  int `json:",omitempty"`
 }
 type FileDiffMetrics struct {
 	DiffMetrics
 	File       string
 	NewFile    bool
 	DeleteFile bool
+
+	// Binary files have no lines, their change is measured in bytes
+	Binary          bool
+	BinarySizeDelta int64
+	// Symbolic links have no lines either, the path they point at is reported instead
+	Symlink       bool
+	SymlinkTarget string `json:",omitempty"`
+	// Modes of the file before and after, e.g. 0100755 once made executable, when its mode changed
+	OldMode string `json:",omitempty"`
+	NewMode string `json:",omitempty"`
 }
 type AggrDiffMetrics struct {
 	DiffMetrics
 	FilesCount   int
 	NewFiles     int
 	DeletedFiles int
+
+	// Binary files have no lines, their changes are counted and measured in bytes apart
+	BinaryFilesChanged int
+	BinarySizeDelta    int64
+	// Files whose mode changed, e.g. made executable, and symbolic links changed, counted apart from the lines
+	ModeChanges    int
+	SymlinkChanges int
+	// Changes of the text files per language
+	Languages []LanguageDiffMetrics `json:",omitempty"`
+	// Lines changed in the test and in the production files, told apart by TestFiles
+	TestChurn LineChurn
+	ProdChurn LineChurn
+	// Directories moved as a whole, listed when gitfuncs.DetectDirMoves is set
+	Moves []gitfuncs.DirMove `json:",omitempty"`
+}
+
+type LineChurn struct {
+	Insertions int `units:"lines"`
+	Deletions  int `units:"lines"`
+}
+
+// TestFiles tells the test files from the production ones in the churn metrics
+var TestFiles = lang.NewTestClassifier(nil)
+
+// LanguageDiffMetrics are the changes to the files of a language, counting all the lines and only the
+// lines of code, that is neither blank nor only comments
+type LanguageDiffMetrics struct {
+	Language       string
+	Files          int
+	Insertions     int `units:"lines"`
+	Deletions      int `units:"lines"`
+	CodeInsertions int `units:"lines"`
+	CodeDeletions  int `units:"lines"`
 }
 
 func CalculateDiffMetricsWithWhitespace(repo *git.Repository, filePath string) *FileDiffMetrics {
@@ -32,18 +83,12 @@ func CalculateDiffMetricsWithWhitespace(repo *git.Repository, filePath string) *
 	diffMetrics := new(FileDiffMetrics)
 	diffMetrics.File = filePath
 	changes, tree, parentTree := gitfuncs.CommitDiff(repo)
-	patch, _ := changes.Patch()
-	//fmt.Println(changes)
-	//fmt.Println(patch)
-	diffStats := patch.Stats()
-	//fmt.Println(diffStats)
+	patch, err := gitfuncs.ChangesChurnPatch(changesOf(*changes, filePath))
 
 	//TODO: Throw error if file not exists in this commit
-	for _, value := range diffStats {
-		if value.Name == filePath {
-			diffMetrics.Insertions = value.Addition
-			diffMetrics.Deletions = value.Deletion
-		}
+	if err == nil && patch.File(filePath) != nil {
+		diffMetrics.Insertions = patch.File(filePath).Count(gitfuncs.LineAdded, true)
+		diffMetrics.Deletions = patch.File(filePath).Count(gitfuncs.LineDeleted, true)
 	}
 
 	diffMetrics.LinesBefore = gitfuncs.FileLOCFromTree(parentTree, filePath)
@@ -56,6 +101,12 @@ func CalculateDiffMetricsWithWhitespace(repo *git.Repository, filePath string) *
 	if diffMetrics.LinesBefore != 0 && diffMetrics.LinesAfter == 0 {
 		diffMetrics.DeleteFile = true
 	}
+	binaries, _ := gitfuncs.BinaryChanges(*changes)
+	setFileBinaryChange(binaries, diffMetrics)
+	modes, _ := gitfuncs.ModeChanges(*changes)
+	setFileModeChange(modes, diffMetrics)
+	setTokenChanges(changesOf(*changes, filePath), &diffMetrics.DiffMetrics)
+	diffMetrics.Churn, _ = headChurn(repo, filePath, true, diffMetrics.DiffMetrics)
 
 	return diffMetrics
 
@@ -66,32 +117,37 @@ func CalculateDiffMetricsWhitespaceExcluded(repo *git.Repository, filePath strin
 	diffMetrics := new(FileDiffMetrics)
 	diffMetrics.File = filePath
 	changes, tree, parentTree := gitfuncs.CommitDiff(repo)
-	patch, _ := changes.Patch()
-
-	fileDiffTexts := strings.Split(patch.String(), "diff --git a/"+filePath)
-	if len(fileDiffTexts) < 2 {
+	fileChanges := changesOf(*changes, filePath)
+	modes, err := gitfuncs.ModeChanges(fileChanges)
+	if err != nil {
+		return nil, err
+	}
+	setFileModeChange(modes, diffMetrics)
+	binaries, err := gitfuncs.BinaryChanges(*changes)
+	if err != nil {
+		return nil, err
+	}
+	if setFileBinaryChange(binaries, diffMetrics) {
+		// The patch of a binary file has no lines to count
+		return diffMetrics, nil
+	}
+	patch, err := gitfuncs.ChangesChurnPatch(fileChanges)
+	if err != nil {
+		return nil, err
+	}
+	file := patch.File(filePath)
+	if file == nil {
 		return nil, errors.New("File: " + filePath + " not found in the given commitHash")
 	}
-	fileDiff := strings.Split(fileDiffTexts[1], "+++")[1]
-	fileDiff = strings.Split(fileDiff, "diff --git")[0]
-	lines := strings.Split(fileDiff, "\n")
-
-	insertions := 0
-	deletions := 0
-
-	for _, line := range lines {
-		line = strings.TrimSpace(line)
-
-		if strings.HasPrefix(line, "+") && line != "+" {
-			insertions += 1
-		}
-		if strings.HasPrefix(line, "-") && line != "-" {
-			deletions += 1
-		}
+	diffMetrics.Insertions = file.Count(gitfuncs.LineAdded, false)
+	diffMetrics.Deletions = file.Count(gitfuncs.LineDeleted, false)
+	if err := setTokenChanges(fileChanges, &diffMetrics.DiffMetrics); err != nil {
+		return nil, err
+	}
+	diffMetrics.Churn, err = headChurn(repo, filePath, false, diffMetrics.DiffMetrics)
+	if err != nil {
+		return nil, err
 	}
-
-	diffMetrics.Insertions = insertions
-	diffMetrics.Deletions = deletions
 
 	diffMetrics.LinesBefore = gitfuncs.FileLOCFromTreeWhitespaceExcluded(parentTree, filePath)
 	diffMetrics.LinesAfter = gitfuncs.FileLOCFromTreeWhitespaceExcluded(tree, filePath)
@@ -107,38 +163,102 @@ func CalculateDiffMetricsWhitespaceExcluded(repo *git.Repository, filePath strin
 	return diffMetrics, nil
 }
 
+// CalculateDiffMetricsBetween gets the DiffMetrics of the file b/n any two commits, e.g. last week's deploy and
+// today's HEAD, diffing the tree of commitA against the tree of commitB whatever the commits in between.
+// It includes the whitespaces while counting the changes. The recent churn mode, which looks at the
+// deleted lines of a single commit, counts no churn b/n two commits.
+func CalculateDiffMetricsBetween(repo *git.Repository, commitA, commitB, filePath string) (*FileDiffMetrics, error) {
+	defer helper.Duration(helper.Track("CalculateDiffMetricsBetween"))
+	return diffMetricsBetween(repo, commitA, commitB, filePath, true)
+}
+
+// CalculateDiffMetricsBetweenWhitespaceExcluded is CalculateDiffMetricsBetween neglecting the whitespaces
+func CalculateDiffMetricsBetweenWhitespaceExcluded(repo *git.Repository, commitA, commitB, filePath string) (*FileDiffMetrics, error) {
+	defer helper.Duration(helper.Track("CalculateDiffMetricsBetweenWhitespaceExcluded"))
+	return diffMetricsBetween(repo, commitA, commitB, filePath, false)
+}
+
+func diffMetricsBetween(repo *git.Repository, commitA, commitB, filePath string, whitespace bool) (*FileDiffMetrics, error) {
+	changes, tree, baseTree, err := gitfuncs.TreeDiff(repo, commitA, commitB)
+	if err != nil {
+		return nil, err
+	}
+	diffMetrics := new(FileDiffMetrics)
+	diffMetrics.File = filePath
+	before, beforeErr := baseTree.File(filePath)
+	after, afterErr := tree.File(filePath)
+	if beforeErr != nil && afterErr != nil {
+		return nil, errors.New("File: " + filePath + " not found in " + commitA + " nor in " + commitB)
+	}
+
+	fileChanges := changesOf(*changes, filePath)
+	modes, err := gitfuncs.ModeChanges(fileChanges)
+	if err != nil {
+		return nil, err
+	}
+	setFileModeChange(modes, diffMetrics)
+	binaries, err := gitfuncs.BinaryChanges(fileChanges)
+	if err != nil {
+		return nil, err
+	}
+	if setFileBinaryChange(binaries, diffMetrics) {
+		return diffMetrics, nil
+	}
+	patch, err := gitfuncs.ChangesChurnPatch(fileChanges)
+	if err != nil {
+		return nil, err
+	}
+	diffMetrics.Insertions = patch.Count(gitfuncs.LineAdded, whitespace)
+	diffMetrics.Deletions = patch.Count(gitfuncs.LineDeleted, whitespace)
+	if err := setTokenChanges(fileChanges, &diffMetrics.DiffMetrics); err != nil {
+		return nil, err
+	}
+	diffMetrics.Churn = Churn.Lines(diffMetrics.Insertions, diffMetrics.Deletions, 0)
+
+	if beforeErr == nil {
+		diffMetrics.LinesBefore = gitfuncs.BlobLOC(before, whitespace)
+	}
+	if afterErr == nil {
+		diffMetrics.LinesAfter = gitfuncs.BlobLOC(after, whitespace)
+	}
+	diffMetrics.NewFile = beforeErr != nil
+	diffMetrics.DeleteFile = afterErr != nil
+	return diffMetrics, nil
+}
+
 //Gets the aggregated DiffMetrics for all the files in the given repo for the specified commit hash.
 //It includes the whitespaces while counting the changes.
 func AggrDiffMetricsWithWhitespace(repo *git.Repository) *AggrDiffMetrics {
 	defer helper.Duration(helper.Track("AggrDiffMetricsWithWhitespace"))
 	diffMetrics := new(AggrDiffMetrics)
 	changes, tree, parentTree := gitfuncs.CommitDiff(repo)
-	patch, _ := changes.Patch()
-	//fmt.Println(changes)
-	//fmt.Println(patch)
-	diffStats := patch.Stats()
-	//fmt.Println(diffStats)
-
-	additions := 0
-	deletions := 0
-	for _, value := range diffStats {
-		additions += value.Addition
-		deletions += value.Deletion
+	*changes = gitfuncs.WithoutIgnored(repo, *changes)
+	if patch, err := gitfuncs.ChangesChurnPatch(*changes); err == nil {
+		diffMetrics.Insertions = patch.Count(gitfuncs.LineAdded, true)
+		diffMetrics.Deletions = patch.Count(gitfuncs.LineDeleted, true)
 	}
-	diffMetrics.Insertions = additions
-	diffMetrics.Deletions = deletions
+	setTokenChanges(*changes, &diffMetrics.DiffMetrics)
+	diffMetrics.Churn, _ = headChurn(repo, "", true, diffMetrics.DiffMetrics)
 
 	var beforeFiles []string
 	var afterFiles []string
 	beforeCh := make(chan func() (int, []string))
-	go gitfuncs.LOCFilesFromTree(parentTree, beforeCh)
+	go gitfuncs.LOCFilesFromTree(parentTree, gitfuncs.RepoIgnore(repo), beforeCh)
 
 	afterCh := make(chan func() (int, []string))
-	go gitfuncs.LOCFilesFromTree(tree, afterCh)
+	go gitfuncs.LOCFilesFromTree(tree, gitfuncs.RepoIgnore(repo), afterCh)
 	diffMetrics.LinesBefore, beforeFiles = (<-beforeCh)()
 	diffMetrics.LinesAfter, afterFiles = (<-afterCh)()
 
 	setFilesCounts(beforeFiles, afterFiles, diffMetrics)
+	binaries, _ := gitfuncs.BinaryChanges(*changes)
+	setBinaryChanges(binaries, diffMetrics)
+	modes, _ := gitfuncs.ModeChanges(*changes)
+	setModeChanges(modes, diffMetrics)
+	setTextChanges(*changes, true, diffMetrics)
+	if gitfuncs.DetectDirMoves {
+		diffMetrics.Moves, _ = gitfuncs.DirMoves(parentTree, tree)
+	}
 	return diffMetrics
 }
 
@@ -194,39 +314,160 @@ func AggrDiffMetricsWhitespaceExcluded(repo *git.Repository) (*AggrDiffMetrics,
 	defer helper.Duration(helper.Track("AggrDiffMetricsWhitespaceExcluded"))
 	diffMetrics := new(AggrDiffMetrics)
 	changes, tree, parentTree := gitfuncs.CommitDiff(repo)
-	patch, _ := changes.Patch()
-
-	fileDiffTexts := strings.Split(patch.String(), "diff --git a/")
-	insertions := 0
-	deletions := 0
-	for index, _ := range fileDiffTexts {
-		if index == 0 {
-			continue
-		}
-		fileDiff := strings.Split(fileDiffTexts[index], "+++")[1]
-		fileDiff = strings.Split(fileDiff, "diff --git")[0]
-		lines := strings.Split(fileDiff, "\n")
-
-		for _, line := range lines {
-			line = strings.TrimSpace(line)
-
-			if strings.HasPrefix(line, "+") && line != "+" {
-				insertions += 1
-			}
-			if strings.HasPrefix(line, "-") && line != "-" {
-				deletions += 1
-			}
-		}
+	*changes = gitfuncs.WithoutIgnored(repo, *changes)
+	patch, err := gitfuncs.ChangesChurnPatch(*changes)
+	if err != nil {
+		return nil, err
+	}
+	diffMetrics.Insertions = patch.Count(gitfuncs.LineAdded, false)
+	diffMetrics.Deletions = patch.Count(gitfuncs.LineDeleted, false)
+	if err := setTokenChanges(*changes, &diffMetrics.DiffMetrics); err != nil {
+		return nil, err
+	}
+	diffMetrics.Churn, err = headChurn(repo, "", false, diffMetrics.DiffMetrics)
+	if err != nil {
+		return nil, err
+	}
+	binaries, err := gitfuncs.BinaryChanges(*changes)
+	if err != nil {
+		return nil, err
+	}
+	setBinaryChanges(binaries, diffMetrics)
+	modes, err := gitfuncs.ModeChanges(*changes)
+	if err != nil {
+		return nil, err
+	}
+	setModeChanges(modes, diffMetrics)
+	if err := setTextChanges(*changes, false, diffMetrics); err != nil {
+		return nil, err
 	}
-
-	diffMetrics.Insertions = insertions
-	diffMetrics.Deletions = deletions
 
 	var beforeFiles []string
 	var afterFiles []string
-	diffMetrics.LinesBefore, beforeFiles = gitfuncs.LOCFilesFromTreeWhitespaceExcluded(parentTree)
-	diffMetrics.LinesAfter, afterFiles = gitfuncs.LOCFilesFromTreeWhitespaceExcluded(tree)
+	diffMetrics.LinesBefore, beforeFiles, err = gitfuncs.TreeLOC(parentTree, gitfuncs.RepoIgnore(repo), false)
+	if err != nil {
+		return nil, err
+	}
+	diffMetrics.LinesAfter, afterFiles, err = gitfuncs.TreeLOC(tree, gitfuncs.RepoIgnore(repo), false)
+	if err != nil {
+		return nil, err
+	}
 
 	setFilesCounts(beforeFiles, afterFiles, diffMetrics)
+	if gitfuncs.DetectDirMoves {
+		if diffMetrics.Moves, err = gitfuncs.DirMoves(parentTree, tree); err != nil {
+			return nil, err
+		}
+	}
 	return diffMetrics, nil
 }
+
+// changesOf keeps the changes to the file, the only ones worth a patch
+func changesOf(changes object.Changes, filePath string) object.Changes {
+	var fileChanges object.Changes
+	for _, change := range changes {
+		if change.From.Name == filePath || change.To.Name == filePath {
+			fileChanges = append(fileChanges, change)
+		}
+	}
+	return fileChanges
+}
+
+// setFileBinaryChange flags the file as binary, measuring its change in bytes, and tells whether it is one.
+// Binary files have no lines, so whether they are new or deleted cannot be told from their LOC.
+func setFileBinaryChange(binaries map[string]gitfuncs.BinaryChange, diffMetrics *FileDiffMetrics) bool {
+	change, ok := binaries[diffMetrics.File]
+	if !ok {
+		return false
+	}
+	diffMetrics.Binary = true
+	diffMetrics.BinarySizeDelta = change.SizeDelta()
+	diffMetrics.NewFile = change.New
+	diffMetrics.DeleteFile = change.Deleted
+	return true
+}
+
+// setBinaryChanges counts the binary files changed and sums their size change in bytes
+func setBinaryChanges(binaries map[string]gitfuncs.BinaryChange, diffMetrics *AggrDiffMetrics) {
+	diffMetrics.BinaryFilesChanged = len(binaries)
+	for _, change := range binaries {
+		diffMetrics.BinarySizeDelta += change.SizeDelta()
+	}
+}
+
+// setFileModeChange reports the change to the mode of the file and the path the symbolic link points at.
+// Symbolic links have no lines, so whether they are new or deleted cannot be told from their LOC.
+func setFileModeChange(modes map[string]gitfuncs.ModeChange, diffMetrics *FileDiffMetrics) {
+	change, ok := modes[diffMetrics.File]
+	if !ok {
+		return
+	}
+	if change.ModeChanged() {
+		diffMetrics.OldMode = change.OldMode.String()
+		diffMetrics.NewMode = change.NewMode.String()
+	}
+	if change.Symlink() {
+		diffMetrics.Symlink = true
+		diffMetrics.SymlinkTarget = change.NewTarget
+		if change.NewMode == filemode.Empty {
+			diffMetrics.SymlinkTarget = change.OldTarget
+		}
+		diffMetrics.NewFile = change.OldMode == filemode.Empty
+		diffMetrics.DeleteFile = change.NewMode == filemode.Empty
+	}
+}
+
+// setModeChanges counts the files whose mode changed and the symbolic links changed
+func setModeChanges(modes map[string]gitfuncs.ModeChange, diffMetrics *AggrDiffMetrics) {
+	diffMetrics.ModeChanges, diffMetrics.SymlinkChanges = 0, 0
+	for _, change := range modes {
+		if change.ModeChanged() {
+			diffMetrics.ModeChanges += 1
+		}
+		if change.Symlink() {
+			diffMetrics.SymlinkChanges += 1
+		}
+	}
+}
+
+// setTextChanges breaks the changes to the text files down by language, most changed first, and
+// splits them between test and production files
+func setTextChanges(changes object.Changes, whitespace bool, diffMetrics *AggrDiffMetrics) error {
+	codeChanges, err := gitfuncs.CodeChanges(changes, whitespace)
+	if err != nil {
+		return err
+	}
+	diffMetrics.TestChurn, diffMetrics.ProdChurn = LineChurn{}, LineChurn{}
+	byLanguage := make(map[string]*LanguageDiffMetrics)
+	for _, change := range codeChanges {
+		split := &diffMetrics.ProdChurn
+		if TestFiles.IsTest(change.Path) {
+			split = &diffMetrics.TestChurn
+		}
+		split.Insertions += change.Insertions
+		split.Deletions += change.Deletions
+
+		language, ok := byLanguage[change.Language]
+		if !ok {
+			language = &LanguageDiffMetrics{Language: change.Language}
+			byLanguage[change.Language] = language
+		}
+		language.Files += 1
+		language.Insertions += change.Insertions
+		language.Deletions += change.Deletions
+		language.CodeInsertions += change.CodeInsertions
+		language.CodeDeletions += change.CodeDeletions
+	}
+	diffMetrics.Languages = nil
+	for _, language := range byLanguage {
+		diffMetrics.Languages = append(diffMetrics.Languages, *language)
+	}
+	sort.Slice(diffMetrics.Languages, func(i, j int) bool {
+		a, b := diffMetrics.Languages[i], diffMetrics.Languages[j]
+		if a.Insertions+a.Deletions != b.Insertions+b.Deletions {
+			return a.Insertions+a.Deletions > b.Insertions+b.Deletions
+		}
+		return a.Language < b.Language
+	})
+	return nil
+}
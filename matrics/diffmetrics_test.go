@@ -2,6 +2,7 @@ package metrics
 
 import (
 	"github.com/andymeneely/git-churn/gitfuncs"
+	"github.com/andymeneely/git-churn/testutil"
 	"github.com/stretchr/testify/assert"
 	"runtime"
 	"testing"
@@ -201,3 +202,146 @@ func TestAggrFileDeleteWhitespaceExcluded(t *testing.T) {
 	assert.Equal(0, diffmetrics.NewFiles)
 	assert.Equal(5, diffmetrics.DeletedFiles)
 }
+
+func TestBinaryFiles(t *testing.T) {
+	repo := snapshotRepo(t,
+		map[string]string{"a.txt": "1\n", "img/logo.png": "\x89PNG\x00\x01\x02"},
+		map[string]string{"a.txt": "1\n2\n", "img/logo.png": "\x89PNG\x00\x01\x02\x03\x04", "icon.bin": "\x00\x00"},
+	)
+	assert := assert.New(t)
+
+	aggregated := AggrDiffMetricsWithWhitespace(repo)
+	assert.Equal(1, aggregated.Insertions)
+	assert.Equal(0, aggregated.Deletions)
+	assert.Equal(1, aggregated.LinesBefore)
+	assert.Equal(2, aggregated.LinesAfter)
+	assert.Equal(2, aggregated.BinaryFilesChanged)
+	assert.Equal(int64(4), aggregated.BinarySizeDelta)
+
+	aggregated, err := AggrDiffMetricsWhitespaceExcluded(repo)
+	assert.Nil(err)
+	assert.Equal(1, aggregated.Insertions)
+	assert.Equal(2, aggregated.BinaryFilesChanged)
+
+	file := CalculateDiffMetricsWithWhitespace(repo, "icon.bin")
+	assert.True(file.Binary)
+	assert.True(file.NewFile)
+	assert.Equal(int64(2), file.BinarySizeDelta)
+	assert.Equal(0, file.LinesAfter)
+
+	file, err = CalculateDiffMetricsWhitespaceExcluded(repo, "img/logo.png")
+	assert.Nil(err)
+	assert.True(file.Binary)
+	assert.False(file.NewFile)
+	assert.Equal(int64(2), file.BinarySizeDelta)
+
+	file = CalculateDiffMetricsWithWhitespace(repo, "a.txt")
+	assert.False(file.Binary)
+	assert.Equal(1, file.Insertions)
+}
+
+func TestLanguageBreakdown(t *testing.T) {
+	repo := snapshotRepo(t,
+		map[string]string{"main.go": "package main\n\nfunc main() {}\n", "ci.yml": "on: push\n"},
+		map[string]string{"main.go": "// Package main runs\npackage main\n\n/*\nusage\n*/\nfunc main() { run() }\n",
+			"ci.yml": "# CI\non: push\n\njobs: {}\n"},
+	)
+	assert := assert.New(t)
+	aggregated := AggrDiffMetricsWithWhitespace(repo)
+	assert.Equal([]LanguageDiffMetrics{
+		{Language: "Go", Files: 1, Insertions: 5, Deletions: 1, CodeInsertions: 1, CodeDeletions: 1},
+		{Language: "YAML", Files: 1, Insertions: 3, Deletions: 0, CodeInsertions: 1, CodeDeletions: 0},
+	}, aggregated.Languages)
+
+	aggregated, err := AggrDiffMetricsWhitespaceExcluded(repo)
+	assert.Nil(err)
+	assert.Equal(2, aggregated.Languages[1].Insertions)
+}
+
+func TestTestChurnSplit(t *testing.T) {
+	repo := snapshotRepo(t,
+		map[string]string{"main.go": "package main\n", "main_test.go": "package main\n", "testdata/in.txt": "1\n"},
+		map[string]string{"main.go": "package main\n\nfunc f() {}\n", "main_test.go": "package main\n\nfunc TestF() {}\n", "testdata/in.txt": "2\n"},
+	)
+	aggregated := AggrDiffMetricsWithWhitespace(repo)
+	assert := assert.New(t)
+	assert.Equal(LineChurn{Insertions: 3, Deletions: 1}, aggregated.TestChurn)
+	assert.Equal(LineChurn{Insertions: 2}, aggregated.ProdChurn)
+}
+
+func TestDiffMetricsBetween(t *testing.T) {
+	repo := snapshotRepo(t,
+		map[string]string{"a.txt": "1\n2\n3\n", "b.txt": "b\n"},
+		map[string]string{"a.txt": "1\n2\n3\n4\n5\n", "b.txt": "b\n"},
+		map[string]string{"a.txt": "1\n3\n4\n  5\n6\n\n", "c.txt": "c\n"},
+	)
+	assert := assert.New(t)
+
+	diff, err := CalculateDiffMetricsBetween(repo, "HEAD~2", "HEAD", "a.txt")
+	assert.Nil(err)
+	assert.Equal(DiffMetrics{Insertions: 4, Deletions: 1, LinesBefore: 3, LinesAfter: 6, Churn: 5}, diff.DiffMetrics)
+	assert.False(diff.NewFile)
+
+	diff, err = CalculateDiffMetricsBetweenWhitespaceExcluded(repo, "HEAD~2", "HEAD", "a.txt")
+	assert.Nil(err)
+	assert.Equal(DiffMetrics{Insertions: 3, Deletions: 1, LinesBefore: 3, LinesAfter: 5, Churn: 4}, diff.DiffMetrics)
+
+	diff, err = CalculateDiffMetricsBetween(repo, "HEAD~2", "HEAD~1", "b.txt")
+	assert.Nil(err)
+	assert.Equal(DiffMetrics{LinesBefore: 1, LinesAfter: 1}, diff.DiffMetrics)
+
+	diff, err = CalculateDiffMetricsBetween(repo, "HEAD~2", "HEAD", "c.txt")
+	assert.Nil(err)
+	assert.True(diff.NewFile)
+	assert.Equal(1, diff.Insertions)
+	diff, err = CalculateDiffMetricsBetween(repo, "HEAD", "HEAD~2", "c.txt")
+	assert.Nil(err)
+	assert.True(diff.DeleteFile)
+	assert.Equal(1, diff.Deletions)
+
+	_, err = CalculateDiffMetricsBetween(repo, "HEAD~2", "HEAD", "d.txt")
+	assert.NotNil(err)
+}
+
+func TestModeAndSymlinkChanges(t *testing.T) {
+	repo := testutil.NewRepo(t)
+	repo.CommitFiles("first", map[string]string{"run.sh": "echo 1\n", "a.txt": "1\n2\n"})
+	repo.Symlink("latest", "a.txt").Commit("link")
+	repo.WriteMode("run.sh", "echo 1\n", 0755).Symlink("latest", "run.sh").Write("a.txt", "1\n2\n3\n").Commit("executable")
+	assert := assert.New(t)
+
+	aggregated := AggrDiffMetricsWithWhitespace(repo.Repository)
+	assert.Equal(1, aggregated.Insertions)
+	assert.Equal(0, aggregated.Deletions)
+	assert.Equal(3, aggregated.LinesBefore)
+	assert.Equal(4, aggregated.LinesAfter)
+	assert.Equal(1, aggregated.ModeChanges)
+	assert.Equal(1, aggregated.SymlinkChanges)
+
+	aggregated, err := AggrDiffMetricsWhitespaceExcluded(repo.Repository)
+	assert.Nil(err)
+	assert.Equal(1, aggregated.Insertions)
+	assert.Equal(0, aggregated.Deletions)
+	assert.Equal(1, aggregated.ModeChanges)
+	assert.Equal(1, aggregated.SymlinkChanges)
+
+	file := CalculateDiffMetricsWithWhitespace(repo.Repository, "run.sh")
+	assert.Equal(0, file.Insertions+file.Deletions)
+	assert.Equal("0100644", file.OldMode)
+	assert.Equal("0100755", file.NewMode)
+	assert.False(file.Symlink)
+
+	file, err = CalculateDiffMetricsWhitespaceExcluded(repo.Repository, "latest")
+	assert.Nil(err)
+	assert.True(file.Symlink)
+	assert.Equal("run.sh", file.SymlinkTarget)
+	assert.Equal(0, file.Insertions+file.Deletions)
+	assert.Equal(0, file.LinesAfter)
+	assert.False(file.NewFile)
+
+	file, err = CalculateDiffMetricsBetween(repo.Repository, "HEAD~2", "HEAD", "latest")
+	assert.Nil(err)
+	assert.True(file.Symlink)
+	assert.True(file.NewFile)
+	assert.Equal(0, file.Insertions)
+}
@@ -0,0 +1,218 @@
+package metrics
+
+import (
+	"sort"
+	"time"
+
+	"github.com/andymeneely/git-churn/gitfuncs"
+	"github.com/andymeneely/git-churn/helper"
+	"gopkg.in/src-d/go-git.v4"
+	"gopkg.in/src-d/go-git.v4/plumbing"
+)
+
+// DivergenceOptions tell DivergentBranches which branches to report
+type DivergenceOptions struct {
+	// Branches last committed to before are left out, none if zero
+	Since time.Time
+	// Branches last committed to before are flagged as stale, none if zero
+	StaleBefore time.Time
+}
+
+// BranchDivergence is the churn a branch accumulated since it diverged from the base branch
+type BranchDivergence struct {
+	Branch     string
+	Commit     string
+	LastCommit time.Time
+	// Author of the last commit
+	LastAuthor     string
+	LastAuthorName string
+	// Last committed to before DivergenceOptions.StaleBefore, likely abandoned
+	Stale         bool
+	MergeBase     string
+	CommitsAhead  int
+	CommitsBehind int
+	Insertions    int `units:"lines"`
+	Deletions     int `units:"lines"`
+	FilesChanged  int
+	// Churn of the files changed according to the churn definition
+	Churn int `units:"lines"`
+	// Files the branch changed that the base branch or another branch, but a stale one, changed too
+	OverlappingFiles []string
+}
+
+// ConflictHotspot is a file changed by several branches, stale ones aside, since they diverged, likely to
+// conflict when they are merged
+type ConflictHotspot struct {
+	File string
+	// Branches changing the file, the base branch included when it changed the file since one of them
+	// diverged from it
+	Branches []string
+	// Churn of the file on the branches but the base one
+	Churn int `units:"lines"`
+}
+
+// DivergenceReport is the divergence of the active branches from the base branch
+type DivergenceReport struct {
+	Base   string
+	Commit string
+	Stale  int
+	// Most churn first, then by name
+	Branches []BranchDivergence
+	// Files changed by the most branches first
+	Hotspots []ConflictHotspot
+}
+
+// DivergentBranches reports, for every local and remote-tracking branch committed to since options.Since
+// and not merged into the base branch, its last commit, whether it is stale, the commits it is ahead and
+// behind and the churn it accumulated since their merge base, and the files several of the branches but
+// the stale ones changed, the base branch included: the likely merge conflict hotspots. The branches at
+// the same commit are reported once. An empty base is the default branch of the repository.
+func DivergentBranches(repo *git.Repository, base string, options DivergenceOptions) (*DivergenceReport, error) {
+	defer helper.Duration(helper.Track("DivergentBranches"))
+	if base == "" {
+		defaultBranch, err := gitfuncs.DefaultBranch(repo)
+		if err != nil {
+			return nil, err
+		}
+		base = defaultBranch
+	}
+	baseHash, err := gitfuncs.ResolveRef(repo, base)
+	if err != nil {
+		return nil, err
+	}
+	branches, err := gitfuncs.ListBranches(repo)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &DivergenceReport{Base: base, Commit: baseHash.String(), Branches: []BranchDivergence{}, Hotspots: []ConflictHotspot{}}
+	seen := map[plumbing.Hash]bool{*baseHash: true}
+	// Branches changing every file, and the churn of the file on each of them
+	changedBy := make(map[string]map[string]int)
+	change := func(file, branch string, churn int) {
+		if changedBy[file] == nil {
+			changedBy[file] = make(map[string]int)
+		}
+		changedBy[file][branch] += churn
+	}
+	var branchFiles [][]FileChurn
+	var branchBaseFiles []map[string]bool
+	// The branches diverging at the same commit are behind the base branch by the same commits
+	behindSince := make(map[plumbing.Hash]BranchChurn)
+	for _, branch := range branches {
+		hash := plumbing.NewHash(branch.Hash)
+		if seen[hash] {
+			continue
+		}
+		seen[hash] = true
+		tip, err := repo.CommitObject(hash)
+		if err == plumbing.ErrObjectNotFound {
+			// Advertised by the remote but not fetched
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+		if tip.Committer.When.Before(options.Since) {
+			continue
+		}
+		mergeBase, err := gitfuncs.MergeBase(repo, *baseHash, hash)
+		if err != nil {
+			return nil, err
+		}
+		name := plumbing.ReferenceName(branch.Name).Short()
+		ahead, err := branchChurnSince(repo, mergeBase, name, hash)
+		if err != nil {
+			return nil, err
+		}
+		if ahead.CommitsAhead == 0 {
+			continue
+		}
+		behind, ok := behindSince[mergeBase.Hash]
+		if !ok {
+			behind, err = branchChurnSince(repo, mergeBase, base, *baseHash)
+			if err != nil {
+				return nil, err
+			}
+			behindSince[mergeBase.Hash] = behind
+		}
+		lastAuthorName, lastAuthor := gitfuncs.ResolveAuthor(repo, tip.Author)
+		stale := tip.Committer.When.Before(options.StaleBefore)
+		if stale {
+			report.Stale += 1
+		}
+		report.Branches = append(report.Branches, BranchDivergence{
+			Branch:         name,
+			Commit:         branch.Hash,
+			LastCommit:     tip.Committer.When,
+			LastAuthor:     lastAuthor,
+			LastAuthorName: lastAuthorName,
+			Stale:          stale,
+			MergeBase:      mergeBase.Hash.String(),
+			CommitsAhead:   ahead.CommitsAhead,
+			CommitsBehind:  behind.CommitsAhead,
+			Insertions:     ahead.Insertions,
+			Deletions:      ahead.Deletions,
+			FilesChanged:   ahead.FilesChanged,
+		})
+		baseFiles := make(map[string]bool, len(behind.Files))
+		for _, file := range behind.Files {
+			baseFiles[file.File] = true
+		}
+		branchFiles = append(branchFiles, ahead.Files)
+		branchBaseFiles = append(branchBaseFiles, baseFiles)
+		divergence := &report.Branches[len(report.Branches)-1]
+		for _, file := range ahead.Files {
+			divergence.Churn += file.Churn()
+			if stale {
+				continue
+			}
+			change(file.File, name, file.Churn())
+			if baseFiles[file.File] {
+				change(file.File, base, 0)
+			}
+		}
+	}
+
+	for i := range report.Branches {
+		branch := &report.Branches[i]
+		branch.OverlappingFiles = []string{}
+		for _, file := range branchFiles[i] {
+			// The stale branches overlap the others without making up a hotspot
+			churns := changedBy[file.File]
+			if len(churns) > 1 || branch.Stale && (len(churns) > 0 || branchBaseFiles[i][file.File]) {
+				branch.OverlappingFiles = append(branch.OverlappingFiles, file.File)
+			}
+		}
+	}
+	for file, churns := range changedBy {
+		if len(churns) < 2 {
+			continue
+		}
+		hotspot := ConflictHotspot{File: file}
+		for branch, churn := range churns {
+			hotspot.Branches = append(hotspot.Branches, branch)
+			hotspot.Churn += churn
+		}
+		sort.Strings(hotspot.Branches)
+		report.Hotspots = append(report.Hotspots, hotspot)
+	}
+	sort.Slice(report.Hotspots, func(i, j int) bool {
+		a, b := report.Hotspots[i], report.Hotspots[j]
+		if len(a.Branches) != len(b.Branches) {
+			return len(a.Branches) > len(b.Branches)
+		}
+		if a.Churn != b.Churn {
+			return a.Churn > b.Churn
+		}
+		return a.File < b.File
+	})
+	// Sorted last, the overlapping files being found by index
+	sort.Slice(report.Branches, func(i, j int) bool {
+		a, b := report.Branches[i], report.Branches[j]
+		if a.Churn != b.Churn {
+			return a.Churn > b.Churn
+		}
+		return a.Branch < b.Branch
+	})
+	return report, nil
+}
@@ -0,0 +1,73 @@
+package metrics
+
+import (
+	"testing"
+	"time"
+
+	"github.com/andymeneely/git-churn/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDivergentBranches(t *testing.T) {
+	assert := assert.New(t)
+	repo := testutil.NewRepo(t)
+	repo.CommitFiles("root", map[string]string{"a.txt": "1\n", "b.txt": "1\n"})
+	repo.Branch("stale").CommitFiles("stale", map[string]string{"s.txt": "1\n"})
+	repo.Checkout("master").Branch("f1").CommitFiles("f1", map[string]string{"a.txt": "1\n2\n"})
+	repo.Checkout("master").Branch("f2").CommitFiles("f2", map[string]string{"a.txt": "0\n1\n", "c.txt": "1\n"})
+	repo.Checkout("master").Branch("f3").CommitFiles("f3", map[string]string{"b.txt": "1\nx\n"})
+	repo.Checkout("master").Branch("merged").Checkout("master")
+	repo.CommitFiles("master", map[string]string{"b.txt": "1\n2\n"})
+
+	report, err := DivergentBranches(repo.Repository, "master", DivergenceOptions{Since: testutil.Start.AddDate(0, 0, 1).Add(time.Hour)})
+	assert.Nil(err)
+	assert.Equal(repo.Head().Hash.String(), report.Commit)
+	var names []string
+	for _, branch := range report.Branches {
+		names = append(names, branch.Branch)
+	}
+	assert.Equal([]string{"f2", "f1", "f3"}, names)
+	f2 := report.Branches[0]
+	assert.Equal(1, f2.CommitsAhead)
+	assert.Equal(1, f2.CommitsBehind)
+	assert.Equal(2, f2.Churn)
+	assert.Equal(2, f2.FilesChanged)
+	assert.Equal([]string{"a.txt"}, f2.OverlappingFiles)
+	assert.Equal([]string{"b.txt"}, report.Branches[2].OverlappingFiles)
+	// Diverged at the same commit, behind by the same commits
+	for _, branch := range report.Branches {
+		assert.Equal(1, branch.CommitsBehind)
+	}
+
+	assert.Equal([]ConflictHotspot{
+		{File: "a.txt", Branches: []string{"f1", "f2"}, Churn: 2},
+		{File: "b.txt", Branches: []string{"f3", "master"}, Churn: 1},
+	}, report.Hotspots)
+
+	// f1 stale, left out of the hotspots
+	report, err = DivergentBranches(repo.Repository, "master", DivergenceOptions{StaleBefore: testutil.Start.AddDate(0, 0, 2).Add(time.Hour)})
+	assert.Nil(err)
+	assert.Equal(2, report.Stale)
+	stale := make(map[string]bool)
+	for _, branch := range report.Branches {
+		stale[branch.Branch] = branch.Stale
+		if branch.Branch == "f1" {
+			assert.Equal([]string{"a.txt"}, branch.OverlappingFiles)
+			assert.Contains(branch.LastAuthor, "@example.com")
+		}
+	}
+	assert.Equal(map[string]bool{"stale": true, "f1": true, "f2": false, "f3": false}, stale)
+	assert.Equal([]ConflictHotspot{{File: "b.txt", Branches: []string{"f3", "master"}, Churn: 1}}, report.Hotspots)
+
+	// The default branch, the one checked out, when no base is given
+	repo.Branch("late").CommitFiles("late", map[string]string{"c.txt": "1\n"})
+	repo.Checkout("master")
+	report, err = DivergentBranches(repo.Repository, "", DivergenceOptions{Since: testutil.Start.AddDate(0, 0, 1).Add(time.Hour)})
+	assert.Nil(err)
+	assert.Equal("master", report.Base)
+	behind := make(map[string]int)
+	for _, branch := range report.Branches {
+		behind[branch.Branch] = branch.CommitsBehind
+	}
+	assert.Equal(map[string]int{"f1": 1, "f2": 1, "f3": 1, "late": 0}, behind)
+}
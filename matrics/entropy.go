@@ -0,0 +1,68 @@
+package metrics
+
+import (
+	"strings"
+	"time"
+)
+
+// CommitEntropy is how scattered the changes of a commit are across its files, after Hassan's change
+// entropy ("Predicting faults using the complexity of code changes", ICSE 2009)
+type CommitEntropy struct {
+	Hash    string
+	Author  string
+	When    time.Time
+	Message string
+	Files   int
+	// Churn according to the churn definition
+	Churn int `units:"lines"`
+	// Shannon entropy in bits of the lines changed across the files, 0 for a single file and log2(Files)
+	// for an even spread
+	Entropy float64
+	// Entropy divided by its maximum, from 0 for a single file to 1 for an even spread
+	NormalizedEntropy float64
+}
+
+// EntropyReport is the change entropy of the commits of a range and its mean
+type EntropyReport struct {
+	Commits               int
+	MeanEntropy           float64
+	MeanNormalizedEntropy float64
+	// Newest first
+	Entropies []CommitEntropy
+}
+
+// ChangeEntropy computes the change entropy of every commit given, newest first, from the lines added plus
+// deleted in the files it changed, whatever the churn definition, the net churn of a file going negative,
+// and averages it over the commits. Merges are left out, like in CommitRisks, and so are the commits
+// changing no line.
+func ChangeEntropy(commits []*CommitChurn) *EntropyReport {
+	report := &EntropyReport{}
+	for _, commit := range commits {
+		if commit.Parents > 1 || commit.Insertions+commit.Deletions == 0 {
+			continue
+		}
+		changed := make([]int, len(commit.Files))
+		for i, file := range commit.Files {
+			changed[i] = file.Insertions + file.Deletions
+		}
+		entropy := CommitEntropy{
+			Hash:              commit.Hash,
+			Author:            commit.Author,
+			When:              commit.When,
+			Message:           strings.SplitN(strings.TrimSpace(commit.Message), "\n", 2)[0],
+			Files:             len(commit.Files),
+			Churn:             commit.Churn(),
+			Entropy:           shannonEntropy(changed),
+			NormalizedEntropy: normalizedEntropy(changed),
+		}
+		report.Entropies = append(report.Entropies, entropy)
+		report.MeanEntropy += entropy.Entropy
+		report.MeanNormalizedEntropy += entropy.NormalizedEntropy
+	}
+	report.Commits = len(report.Entropies)
+	if report.Commits > 0 {
+		report.MeanEntropy /= float64(report.Commits)
+		report.MeanNormalizedEntropy /= float64(report.Commits)
+	}
+	return report
+}
@@ -0,0 +1,47 @@
+package metrics
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestChangeEntropy(t *testing.T) {
+	assert := assert.New(t)
+	commits := []*CommitChurn{
+		{Hash: "scattered", Message: "touch everything\n\nbody", Insertions: 8, Files: []FileChurn{
+			{File: "a.go", Insertions: 2}, {File: "b.go", Insertions: 2}, {File: "c.go", Insertions: 2}, {File: "d.go", Insertions: 2}}},
+		{Hash: "merge", Parents: 2, Insertions: 2, Files: []FileChurn{{File: "a.go", Insertions: 1}, {File: "b.go", Insertions: 1}}},
+		{Hash: "uneven", Insertions: 3, Deletions: 1, Files: []FileChurn{{File: "a.go", Insertions: 3}, {File: "b.go", Deletions: 1}}},
+		{Hash: "focused", Insertions: 5, Files: []FileChurn{{File: "a.go", Insertions: 5}}},
+		{Hash: "empty"},
+	}
+
+	report := ChangeEntropy(commits)
+	assert.Equal(3, report.Commits)
+	assert.Equal([]string{"scattered", "uneven", "focused"}, []string{report.Entropies[0].Hash, report.Entropies[1].Hash, report.Entropies[2].Hash})
+	assert.Equal("touch everything", report.Entropies[0].Message)
+	assert.Equal(2.0, report.Entropies[0].Entropy)
+	assert.Equal(1.0, report.Entropies[0].NormalizedEntropy)
+	assert.InDelta(0.811, report.Entropies[1].Entropy, 0.001)
+	assert.Equal(0.0, report.Entropies[2].Entropy)
+	assert.InDelta((2+0.811)/3, report.MeanEntropy, 0.001)
+	assert.InDelta((1+0.811)/3, report.MeanNormalizedEntropy, 0.001)
+}
+
+func TestChangeEntropyNetChurn(t *testing.T) {
+	defer func(churn ChurnDefinition) { Churn = churn }(Churn)
+	Churn = ChurnDefinition{Mode: ChurnNet}
+	assert := assert.New(t)
+	// A net churn of 0 and of -2, the lines changed spreading evenly
+	commits := []*CommitChurn{
+		{Hash: "cleanup", Insertions: 1, Deletions: 3, Files: []FileChurn{
+			{File: "a.go", Insertions: 1, Deletions: 1}, {File: "b.go", Deletions: 2}}},
+	}
+
+	report := ChangeEntropy(commits)
+	assert.Equal(1, report.Commits)
+	assert.Equal(-2, report.Entropies[0].Churn)
+	assert.Equal(1.0, report.Entropies[0].Entropy)
+	assert.Equal(1.0, report.Entropies[0].NormalizedEntropy)
+}
@@ -0,0 +1,184 @@
+package metrics
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+
+	"github.com/andymeneely/git-churn/helper"
+)
+
+// DefaultFixPatterns match the messages of the commits fixing bugs: fix, bug, defect, hotfix and the
+// closing or referencing of an issue
+var DefaultFixPatterns = []string{
+	`(?i)\b(fix(e[sd]|ing)?|bug(s|fix(es)?)?|defects?|hotfix(es)?)\b`,
+	`(?i)\b(close[sd]?|resolve[sd]?)\s+#\d+`,
+	`(?i)\bissue\s*#?\d+`,
+}
+
+// FixDetector tells the commits fixing bugs by their message, matched against regular expressions. A nil
+// FixDetector finds no fixes.
+type FixDetector struct {
+	Messages []*regexp.Regexp
+}
+
+// Fixes detects the commits fixing bugs in the defect metrics
+var Fixes, _ = NewFixDetector(nil)
+
+// NewFixDetector compiles the given patterns, DefaultFixPatterns when there are none
+func NewFixDetector(patterns []string) (*FixDetector, error) {
+	if len(patterns) == 0 {
+		patterns = DefaultFixPatterns
+	}
+	detector := &FixDetector{}
+	for _, pattern := range patterns {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid fix pattern %q: %v", pattern, err)
+		}
+		detector.Messages = append(detector.Messages, re)
+	}
+	return detector, nil
+}
+
+// IsFix tells whether the commit message is the one of a bug fix
+func (d *FixDetector) IsFix(message string) bool {
+	if d == nil {
+		return false
+	}
+	for _, re := range d.Messages {
+		if re.MatchString(message) {
+			return true
+		}
+	}
+	return false
+}
+
+// FileDefects is the churn and the bug fixes of a file over a window of commits
+type FileDefects struct {
+	File    string
+	Commits int
+	Fixes   int
+	// Churn of every commit, fixes included, according to the churn definition
+	Churn int `units:"lines"`
+	// Fixes per commit
+	FixRatio float64
+	// Percentile of the churn of the file times percentile of its fixes, both among every file changed
+	Score float64
+}
+
+// DefectReport correlates the churn of the files with the bug fixes made to them
+type DefectReport struct {
+	Files   int
+	Commits int
+	Fixes   int
+	// Spearman rank correlation between the churn and the fixes of the files, 0 when undefined
+	Correlation float64
+	// Fixed files ranking highest by Score, where high churn coincides with frequent fixes
+	Hotspots []FileDefects
+}
+
+// DefectDensity counts per file the commits and the fixes among them, as told by Fixes, and reports the
+// n fixed files where high churn most coincides with frequent fixes, all of them if n is zero
+func DefectDensity(commits []*CommitChurn, n int) DefectReport {
+	defer helper.Duration(helper.Track("DefectDensity"))
+	var report DefectReport
+	byFile := make(map[string]*FileDefects)
+	for _, commit := range countedCommits(commits) {
+		fix := Fixes.IsFix(commit.Message)
+		report.Commits += 1
+		if fix {
+			report.Fixes += 1
+		}
+		for _, file := range commit.Files {
+			defects, ok := byFile[file.File]
+			if !ok {
+				defects = &FileDefects{File: file.File}
+				byFile[file.File] = defects
+			}
+			defects.Commits += 1
+			defects.Churn += file.Churn()
+			if fix {
+				defects.Fixes += 1
+			}
+		}
+	}
+
+	files := make([]FileDefects, 0, len(byFile))
+	for _, defects := range byFile {
+		defects.FixRatio = float64(defects.Fixes) / float64(defects.Commits)
+		files = append(files, *defects)
+	}
+	report.Files = len(files)
+	churns := make([]float64, len(files))
+	fixes := make([]float64, len(files))
+	for i, file := range files {
+		churns[i], fixes[i] = float64(file.Churn), float64(file.Fixes)
+	}
+	churnRanks, fixRanks := ranks(churns), ranks(fixes)
+	report.Correlation = pearson(churnRanks, fixRanks)
+	for i, file := range files {
+		if file.Fixes > 0 {
+			file.Score = churnRanks[i] / float64(len(files)) * fixRanks[i] / float64(len(files))
+			report.Hotspots = append(report.Hotspots, file)
+		}
+	}
+	sort.Slice(report.Hotspots, func(i, j int) bool {
+		a, b := report.Hotspots[i], report.Hotspots[j]
+		if a.Score != b.Score {
+			return a.Score > b.Score
+		}
+		return a.File < b.File
+	})
+	if n > 0 && len(report.Hotspots) > n {
+		report.Hotspots = report.Hotspots[:n]
+	}
+	return report
+}
+
+// ranks returns the rank of every value from 1 for the smallest, tied values sharing their mean rank
+func ranks(values []float64) []float64 {
+	order := make([]int, len(values))
+	for i := range order {
+		order[i] = i
+	}
+	sort.Slice(order, func(i, j int) bool { return values[order[i]] < values[order[j]] })
+	ranked := make([]float64, len(values))
+	for i := 0; i < len(order); {
+		j := i
+		for j+1 < len(order) && values[order[j+1]] == values[order[i]] {
+			j++
+		}
+		rank := float64(i+j)/2 + 1
+		for k := i; k <= j; k++ {
+			ranked[order[k]] = rank
+		}
+		i = j + 1
+	}
+	return ranked
+}
+
+// pearson returns the Pearson correlation coefficient of the two series, 0 when either is constant
+func pearson(x, y []float64) float64 {
+	if len(x) < 2 {
+		return 0
+	}
+	meanX, meanY := 0.0, 0.0
+	for i := range x {
+		meanX += x[i]
+		meanY += y[i]
+	}
+	meanX /= float64(len(x))
+	meanY /= float64(len(y))
+	cov, varX, varY := 0.0, 0.0, 0.0
+	for i := range x {
+		cov += (x[i] - meanX) * (y[i] - meanY)
+		varX += (x[i] - meanX) * (x[i] - meanX)
+		varY += (y[i] - meanY) * (y[i] - meanY)
+	}
+	if varX == 0 || varY == 0 {
+		return 0
+	}
+	return cov / math.Sqrt(varX*varY)
+}
@@ -0,0 +1,75 @@
+package metrics
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFixDetector(t *testing.T) {
+	assert := assert.New(t)
+	assert.True(Fixes.IsFix("Fix crash in the parser"))
+	assert.True(Fixes.IsFix("fix: typo"))
+	assert.True(Fixes.IsFix("Handle empty files\n\nCloses #12"))
+	assert.True(Fixes.IsFix("Bugfix for issue 42"))
+	assert.False(Fixes.IsFix("Add prefix option"))
+	assert.False(Fixes.IsFix("Debug logging"))
+
+	detector, err := NewFixDetector([]string{`^JIRA-\d+`})
+	assert.Nil(err)
+	assert.True(detector.IsFix("JIRA-12 wrong total"))
+	assert.False(detector.IsFix("fix: typo"))
+	_, err = NewFixDetector([]string{"("})
+	assert.NotNil(err)
+	assert.False((*FixDetector)(nil).IsFix("fix"))
+}
+
+func TestDefectDensity(t *testing.T) {
+	assert := assert.New(t)
+	commits := []*CommitChurn{
+		{Message: "fix: typo", Files: []FileChurn{{File: "a.go", Insertions: 5, Deletions: 5}, {File: "b.go", Insertions: 3, Deletions: 2}}},
+		{Message: "Fix crash in the parser (closes #12)", Files: []FileChurn{{File: "a.go", Insertions: 5, Deletions: 5}}},
+		{Message: "Add the parser", Files: []FileChurn{{File: "a.go", Insertions: 10}, {File: "b.go", Insertions: 5}, {File: "c.go", Insertions: 2}}},
+	}
+	report := DefectDensity(commits, 0)
+	assert.Equal(3, report.Files)
+	assert.Equal(3, report.Commits)
+	assert.Equal(2, report.Fixes)
+	assert.InDelta(1.0, report.Correlation, 1e-9)
+	assert.Len(report.Hotspots, 2)
+	assert.Equal(FileDefects{File: "a.go", Commits: 3, Fixes: 2, Churn: 30, FixRatio: 2.0 / 3, Score: 1}, report.Hotspots[0])
+	assert.Equal("b.go", report.Hotspots[1].File)
+	assert.InDelta(4.0/9, report.Hotspots[1].Score, 1e-9)
+
+	assert.Len(DefectDensity(commits, 1).Hotspots, 1)
+	assert.Equal(0.0, DefectDensity(nil, 0).Correlation)
+}
+
+func TestDefectDensityOrder(t *testing.T) {
+	assert := assert.New(t)
+	// Many files, some tied, whose order and correlation must not depend on the iteration of a map
+	var commits []*CommitChurn
+	for i := 0; i < 12; i++ {
+		commit := &CommitChurn{Message: "Add the parser"}
+		if i%3 == 0 {
+			commit.Message = "fix: typo"
+		}
+		for j := i; j < 40; j += 1 + i%4 {
+			commit.Files = append(commit.Files, FileChurn{File: fmt.Sprintf("f%02d.go", 39-j), Insertions: j % 7, Deletions: i % 5})
+		}
+		commits = append(commits, commit)
+	}
+	report := DefectDensity(commits, 0)
+	for i := 0; i < 20; i++ {
+		assert.Equal(report, DefectDensity(commits, 0))
+	}
+	for i := 1; i < len(report.Hotspots); i++ {
+		a, b := report.Hotspots[i-1], report.Hotspots[i]
+		assert.True(a.Score > b.Score || a.Score == b.Score && a.File < b.File)
+	}
+}
+
+func TestRanks(t *testing.T) {
+	assert.Equal(t, []float64{3, 1.5, 4, 1.5}, ranks([]float64{5, 2, 7, 2}))
+}
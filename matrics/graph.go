@@ -0,0 +1,99 @@
+package metrics
+
+import (
+	"strings"
+	"time"
+
+	"github.com/andymeneely/git-churn/gitfuncs"
+	"github.com/andymeneely/git-churn/helper"
+	"gopkg.in/src-d/go-git.v4"
+	"gopkg.in/src-d/go-git.v4/plumbing"
+)
+
+// GraphCommit is a commit of a commit graph, annotated with its churn
+type GraphCommit struct {
+	Hash    string
+	Author  string
+	When    time.Time
+	Subject string
+	// Parents of the commit in the range, the edges of the graph
+	Parents    []string
+	Insertions int `units:"lines"`
+	Deletions  int `units:"lines"`
+	// Churn according to the churn definition
+	Churn int `units:"lines"`
+	// Left out of the churn metrics, e.g. a commit of a bot, and counted as no churn
+	Excluded bool `json:",omitempty"`
+}
+
+// CommitGraph is the commit DAG of a range
+type CommitGraph struct {
+	From string
+	To   string
+	// Newest first
+	Commits []GraphCommit
+}
+
+// MaxChurn returns the churn of the most churning commit of the graph
+func (g *CommitGraph) MaxChurn() int {
+	max := 0
+	for _, commit := range g.Commits {
+		if commit.Churn > max {
+			max = commit.Churn
+		}
+	}
+	return max
+}
+
+// RangeCommitGraph returns the commits between from and to (git log from..to), every one with its churn as
+// computed by RangeChurn and its parents within the range, so that the structure of the history can be
+// drawn along with its churn. The commits RangeChurn leaves out are kept in the graph, as excluded, not to
+// break it up. Only the first parents are followed when gitfuncs.FirstParent is set.
+func RangeCommitGraph(repo *git.Repository, from, to string) (*CommitGraph, error) {
+	defer helper.Duration(helper.Track("RangeCommitGraph"))
+	fromHash, toHash, err := resolveRange(repo, from, to)
+	if err != nil {
+		return nil, err
+	}
+	commits, err := gitfuncs.CommitsBetween(repo, fromHash, toHash)
+	if err != nil {
+		return nil, err
+	}
+	inRange := make(map[plumbing.Hash]bool, len(commits))
+	for _, commit := range commits {
+		inRange[commit.Hash] = true
+	}
+	graph := &CommitGraph{From: from, To: to}
+	progress := helper.TrackProgress("commits", len(commits))
+	defer progress.Finish()
+	for _, commit := range commits {
+		progress.Step()
+		node := GraphCommit{
+			Hash:    commit.Hash.String(),
+			When:    commit.Author.When,
+			Subject: strings.SplitN(strings.TrimSpace(commit.Message), "\n", 2)[0],
+		}
+		_, node.Author = gitfuncs.ResolveAuthor(repo, commit.Author)
+		for i, parent := range commit.ParentHashes {
+			if gitfuncs.FirstParent && i > 0 {
+				break
+			}
+			if inRange[parent] {
+				node.Parents = append(node.Parents, parent.String())
+			}
+		}
+		churn, err := rangeCommitChurn(repo, commit)
+		if err != nil {
+			return nil, err
+		}
+		if churn == nil {
+			node.Excluded = true
+		} else {
+			node.Insertions = churn.Insertions
+			node.Deletions = churn.Deletions
+			node.Churn = churn.Churn()
+		}
+		graph.Commits = append(graph.Commits, node)
+	}
+	return graph, nil
+}
@@ -0,0 +1,56 @@
+package metrics
+
+import (
+	"testing"
+
+	"github.com/andymeneely/git-churn/gitfuncs"
+	"github.com/andymeneely/git-churn/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRangeCommitGraph(t *testing.T) {
+	assert := assert.New(t)
+	repo := testutil.NewRepo(t)
+	root := repo.CommitFiles("root", map[string]string{"a.go": "1\n"})
+	repo.Branch("feature")
+	feature := repo.As("bob").CommitFiles("feature\n\nbody", map[string]string{"b.go": "1\n2\n"})
+	repo.Checkout("master")
+	main := repo.As("alice").CommitFiles("main", map[string]string{"a.go": "1\n2\n3\n"})
+	merge := repo.Merge("feature", "merge")
+	bot := repo.As("dependabot[bot]").CommitFiles("bump", map[string]string{"a.go": "1\n2\n3\n4\n"})
+
+	graph, err := RangeCommitGraph(repo.Repository, "", "HEAD")
+	assert.Nil(err)
+	assert.Len(graph.Commits, 5)
+	byHash := make(map[string]GraphCommit)
+	for _, commit := range graph.Commits {
+		byHash[commit.Hash] = commit
+	}
+	assert.Equal([]string{main.Hash.String(), feature.Hash.String()}, byHash[merge.Hash.String()].Parents)
+	assert.Empty(byHash[root.Hash.String()].Parents)
+	f := byHash[feature.Hash.String()]
+	assert.Equal("feature", f.Subject)
+	assert.Equal("bob@example.com", f.Author)
+	assert.Equal(2, f.Insertions)
+	assert.Equal(2, f.Churn)
+	assert.True(byHash[bot.Hash.String()].Excluded)
+	assert.Equal(0, byHash[bot.Hash.String()].Churn)
+	assert.Equal(2, graph.MaxChurn())
+
+	// The range cuts the edges to the commits before it
+	graph, err = RangeCommitGraph(repo.Repository, root.Hash.String(), "HEAD")
+	assert.Nil(err)
+	assert.Len(graph.Commits, 4)
+	for _, commit := range graph.Commits {
+		assert.NotContains(commit.Parents, root.Hash.String())
+	}
+
+	gitfuncs.FirstParent = true
+	defer func() { gitfuncs.FirstParent = false }()
+	graph, err = RangeCommitGraph(repo.Repository, "", "HEAD")
+	assert.Nil(err)
+	assert.Len(graph.Commits, 4)
+	for _, commit := range graph.Commits {
+		assert.True(len(commit.Parents) <= 1)
+	}
+}
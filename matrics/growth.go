@@ -0,0 +1,90 @@
+package metrics
+
+import (
+	"errors"
+	"time"
+
+	"github.com/andymeneely/git-churn/gitfuncs"
+	"github.com/andymeneely/git-churn/helper"
+	"gopkg.in/src-d/go-git.v4"
+	"gopkg.in/src-d/go-git.v4/plumbing/object"
+)
+
+type GrowthPoint struct {
+	Commit string
+	Date   time.Time
+	Files  int
+	LOC    int `units:"lines"`
+}
+
+// GrowthCurve computes the total lines of code of the repository at sampled points of the history
+// leading to the given revision, along the first parents when gitfuncs.FirstParent is set: every Nth
+// commit, or the last commit of every month if monthly is set. The revision itself is always the last
+// point of the curve. Blob line counts are cached, so unchanged files are only counted once no matter
+// how many samples contain them.
+func GrowthCurve(repo *git.Repository, revision string, every int, monthly bool, whitespace bool) ([]GrowthPoint, error) {
+	defer helper.Duration(helper.Track("GrowthCurve"))
+	if every < 1 && !monthly {
+		return nil, errors.New("the sampling interval has to be at least one commit")
+	}
+	hash, err := gitfuncs.ResolveRef(repo, revision)
+	if err != nil {
+		return nil, err
+	}
+	commitIter, err := gitfuncs.LogCommits(repo, *hash)
+	if err != nil {
+		return nil, err
+	}
+	var commits []*object.Commit
+	err = commitIter.ForEach(func(c *object.Commit) error {
+		commits = append(commits, c)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	// Oldest first
+	for i, j := 0, len(commits)-1; i < j; i, j = i+1, j-1 {
+		commits[i], commits[j] = commits[j], commits[i]
+	}
+
+	var points []GrowthPoint
+	for i, commit := range commits {
+		last := i == len(commits)-1
+		if monthly {
+			if !last && sameMonth(commit.Committer.When, commits[i+1].Committer.When) {
+				continue
+			}
+		} else if !last && (i+1)%every != 0 {
+			continue
+		}
+		point, err := growthPoint(commit, gitfuncs.RepoIgnore(repo), whitespace)
+		if err != nil {
+			return nil, err
+		}
+		points = append(points, point)
+	}
+	return points, nil
+}
+
+func growthPoint(commit *object.Commit, ignore *gitfuncs.Ignore, whitespace bool) (GrowthPoint, error) {
+	point := GrowthPoint{Commit: commit.Hash.String(), Date: commit.Committer.When}
+	tree, err := commit.Tree()
+	if err != nil {
+		return point, err
+	}
+	err = gitfuncs.ForEachFile(tree, func(f *object.File) error {
+		if ignore.Match(f.Name) {
+			return nil
+		}
+		point.Files += 1
+		point.LOC += gitfuncs.BlobLOC(f, whitespace)
+		return nil
+	})
+	return point, err
+}
+
+func sameMonth(a, b time.Time) bool {
+	a, b = a.UTC(), b.UTC()
+	return a.Year() == b.Year() && a.Month() == b.Month()
+}
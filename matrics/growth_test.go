@@ -0,0 +1,65 @@
+package metrics
+
+import (
+	"testing"
+	"time"
+
+	"github.com/andymeneely/git-churn/gitfuncs"
+	"github.com/andymeneely/git-churn/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGrowthCurve(t *testing.T) {
+	assert := assert.New(t)
+	repo := testutil.NewRepo(t)
+	date := func(month time.Month, day int) time.Time { return time.Date(2020, month, day, 0, 0, 0, 0, time.UTC) }
+	repo.At(date(time.January, 10)).CommitFiles("first", map[string]string{"a.txt": "1\n"})
+	second := repo.At(date(time.January, 20)).CommitFiles("second", map[string]string{"a.txt": "1\n\n2\n"})
+	third := repo.At(date(time.February, 5)).CommitFiles("third", map[string]string{"b.txt": "b\n"})
+	fourth := repo.At(date(time.March, 1)).CommitFiles("fourth", map[string]string{"a.txt": "1\n2\n3\n"})
+	fifth := repo.At(date(time.March, 15)).CommitFiles("fifth", map[string]string{"c.txt": "c\n"})
+
+	// Every second commit, the revision last
+	points, err := GrowthCurve(repo.Repository, "HEAD", 2, false, true)
+	assert.Nil(err)
+	assert.Equal([]GrowthPoint{
+		{Commit: second.Hash.String(), Date: second.Committer.When, Files: 1, LOC: 3},
+		{Commit: fourth.Hash.String(), Date: fourth.Committer.When, Files: 2, LOC: 4},
+		{Commit: fifth.Hash.String(), Date: fifth.Committer.When, Files: 3, LOC: 5},
+	}, points)
+
+	// The last commit of every month, the blank lines left out
+	points, err = GrowthCurve(repo.Repository, "HEAD", 0, true, false)
+	assert.Nil(err)
+	assert.Equal([]string{second.Hash.String(), third.Hash.String(), fifth.Hash.String()}, growthCommits(points))
+	assert.Equal([]int{2, 3, 5}, []int{points[0].LOC, points[1].LOC, points[2].LOC})
+
+	_, err = GrowthCurve(repo.Repository, "HEAD", 0, false, true)
+	assert.NotNil(err)
+}
+
+func TestGrowthCurveFirstParent(t *testing.T) {
+	assert := assert.New(t)
+	repo := testutil.NewDivergedRepo(t)
+	repo.Merge("feature", "merge")
+
+	points, err := GrowthCurve(repo.Repository, "HEAD", 1, false, true)
+	assert.Nil(err)
+	assert.Len(points, 5)
+
+	// The commits of the merged branch are not sampled
+	gitfuncs.FirstParent = true
+	defer func() { gitfuncs.FirstParent = false }()
+	points, err = GrowthCurve(repo.Repository, "HEAD", 1, false, true)
+	assert.Nil(err)
+	assert.Len(points, 3)
+	assert.Equal(repo.Head().Hash.String(), points[2].Commit)
+}
+
+func growthCommits(points []GrowthPoint) []string {
+	var commits []string
+	for _, point := range points {
+		commits = append(commits, point.Commit)
+	}
+	return commits
+}
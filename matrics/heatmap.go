@@ -0,0 +1,154 @@
+package metrics
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+// Columns of the churn heatmaps
+const (
+	// The hours of the day, from 00 to 23
+	HeatmapHours = "hour"
+	// The ISO weeks of the year, from W01 to W53, whatever the year
+	HeatmapWeeks = "week"
+)
+
+// Working hours of the weekdays, from WorkdayStart to WorkdayEnd exclusive, the churn outside of which is
+// after hours
+var (
+	WorkdayStart = 9
+	WorkdayEnd   = 18
+)
+
+// heatmapDays are the rows of the heatmaps, Monday first
+var heatmapDays = []time.Weekday{time.Monday, time.Tuesday, time.Wednesday, time.Thursday, time.Friday, time.Saturday, time.Sunday}
+
+// Heatmap is the commits and the churn of a range by day of the week, the rows, and by hour of the day or
+// week of the year, the columns
+type Heatmap struct {
+	// Empty for all the authors
+	Author string `json:",omitempty"`
+	// Monday to Sunday
+	Rows    []string
+	Columns []string
+	Commits [][]int
+	// Churn according to the churn definition
+	Churn      [][]int `units:"lines"`
+	TotalChurn int     `units:"lines"`
+	// Shares of the churn on the weekdays outside of the working hours, and on the weekends, from 0 to 1
+	AfterHoursRate float64
+	WeekendRate    float64
+
+	afterHours int
+	weekend    int
+}
+
+// HeatmapReport is the heatmap of a range, overall and optionally per author
+type HeatmapReport struct {
+	// Columns of the heatmaps, HeatmapHours or HeatmapWeeks
+	By string
+	// Time zone the commits are placed in, empty for the time zone of every author
+	TimeZone string `json:",omitempty"`
+	Heatmap
+	// Most churning first
+	Authors []Heatmap `json:",omitempty"`
+}
+
+func newHeatmap(author, columns string) *Heatmap {
+	heatmap := &Heatmap{Author: author}
+	for _, day := range heatmapDays {
+		heatmap.Rows = append(heatmap.Rows, day.String())
+	}
+	switch columns {
+	case HeatmapHours:
+		for hour := 0; hour < 24; hour++ {
+			heatmap.Columns = append(heatmap.Columns, fmt.Sprintf("%02d", hour))
+		}
+	case HeatmapWeeks:
+		for week := 1; week <= 53; week++ {
+			heatmap.Columns = append(heatmap.Columns, fmt.Sprintf("W%02d", week))
+		}
+	}
+	heatmap.Commits = make([][]int, len(heatmap.Rows))
+	heatmap.Churn = make([][]int, len(heatmap.Rows))
+	for i := range heatmap.Rows {
+		heatmap.Commits[i] = make([]int, len(heatmap.Columns))
+		heatmap.Churn[i] = make([]int, len(heatmap.Columns))
+	}
+	return heatmap
+}
+
+func (h *Heatmap) add(when time.Time, column string, churn int) {
+	row := (int(when.Weekday()) + 6) % 7
+	col := when.Hour()
+	if column == HeatmapWeeks {
+		_, week := when.ISOWeek()
+		col = week - 1
+	}
+	h.Commits[row][col] += 1
+	h.Churn[row][col] += churn
+	h.TotalChurn += churn
+	switch {
+	case when.Weekday() == time.Saturday || when.Weekday() == time.Sunday:
+		h.weekend += churn
+	case when.Hour() < WorkdayStart || when.Hour() >= WorkdayEnd:
+		h.afterHours += churn
+	}
+}
+
+func (h *Heatmap) setRates() {
+	if h.TotalChurn > 0 {
+		h.AfterHoursRate = float64(h.afterHours) / float64(h.TotalChurn)
+		h.WeekendRate = float64(h.weekend) / float64(h.TotalChurn)
+	}
+}
+
+// ChurnHeatmap totals the commits and the churn of the given commits by day of the week and by hour of the
+// day or week of the year, HeatmapHours or HeatmapWeeks, overall and per author if perAuthor is set, to spot
+// crunches and the work after hours and on weekends. The commits are placed in the given time zone, or in
+// the time zone of their author, their local time, if nil. The merges of branches whose commits are among
+// the given ones are left out, their churn being that of the commits over again.
+func ChurnHeatmap(commits []*CommitChurn, columns string, zone *time.Location, perAuthor bool) (*HeatmapReport, error) {
+	if columns != HeatmapHours && columns != HeatmapWeeks {
+		return nil, fmt.Errorf("unknown heatmap columns %q, expected %s or %s", columns, HeatmapHours, HeatmapWeeks)
+	}
+	report := &HeatmapReport{By: columns, Heatmap: *newHeatmap("", columns)}
+	if zone != nil {
+		report.TimeZone = zone.String()
+	}
+	authors := make(map[string]*Heatmap)
+	for _, commit := range commits {
+		if commit.mergedBranch() {
+			continue
+		}
+		when := commit.When
+		if zone != nil {
+			when = when.In(zone)
+		}
+		churn := commit.Churn()
+		report.add(when, columns, churn)
+		if !perAuthor {
+			continue
+		}
+		author, ok := authors[commit.Author]
+		if !ok {
+			author = newHeatmap(commit.Author, columns)
+			authors[commit.Author] = author
+		}
+		author.add(when, columns, churn)
+	}
+	report.setRates()
+	for _, author := range authors {
+		author.setRates()
+		report.Authors = append(report.Authors, *author)
+	}
+	sort.Slice(report.Authors, func(i, j int) bool {
+		a, b := report.Authors[i], report.Authors[j]
+		if a.TotalChurn != b.TotalChurn {
+			return a.TotalChurn > b.TotalChurn
+		}
+		return a.Author < b.Author
+	})
+	return report, nil
+}
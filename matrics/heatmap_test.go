@@ -0,0 +1,69 @@
+package metrics
+
+import (
+	"testing"
+	"time"
+
+	"github.com/andymeneely/git-churn/gitfuncs"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestChurnHeatmap(t *testing.T) {
+	assert := assert.New(t)
+	paris := time.FixedZone("CET", 3600)
+	commits := []*CommitChurn{
+		// Monday 10:00, in the working hours
+		{Author: "alice@example.com", When: time.Date(2020, 1, 6, 10, 0, 0, 0, paris), Insertions: 6},
+		// Tuesday 22:30, after hours
+		{Author: "alice@example.com", When: time.Date(2020, 1, 7, 22, 30, 0, 0, paris), Insertions: 3, Deletions: 1},
+		// Sunday 10:00
+		{Author: "bob@example.com", When: time.Date(2020, 1, 12, 10, 0, 0, 0, time.UTC), Insertions: 10},
+		// Merge of the branch of the commit of Tuesday, left out
+		{Author: "bob@example.com", When: time.Date(2020, 1, 8, 9, 0, 0, 0, paris), Parents: 2, Insertions: 3, Deletions: 1},
+	}
+
+	report, err := ChurnHeatmap(commits, HeatmapHours, nil, true)
+	assert.Nil(err)
+	assert.Equal([]string{"Monday", "Tuesday", "Wednesday", "Thursday", "Friday", "Saturday", "Sunday"}, report.Rows)
+	assert.Len(report.Columns, 24)
+	assert.Equal("22", report.Columns[22])
+	assert.Equal(6, report.Churn[0][10])
+	assert.Equal(1, report.Commits[0][10])
+	assert.Equal(4, report.Churn[1][22])
+	assert.Equal(10, report.Churn[6][10])
+	assert.Equal(20, report.TotalChurn)
+	assert.Equal(0.2, report.AfterHoursRate)
+	assert.Equal(0.5, report.WeekendRate)
+	assert.Empty(report.TimeZone)
+	assert.Len(report.Authors, 2)
+	// Tied, by email
+	assert.Equal("alice@example.com", report.Authors[0].Author)
+	assert.Equal(0.4, report.Authors[0].AfterHoursRate)
+	assert.Equal(1.0, report.Authors[1].WeekendRate)
+
+	// In UTC, the commit of Tuesday 22:30 CET is at 21:30
+	report, err = ChurnHeatmap(commits, HeatmapHours, time.UTC, false)
+	assert.Nil(err)
+	assert.Equal("UTC", report.TimeZone)
+	assert.Equal(4, report.Churn[1][21])
+	assert.Equal(6, report.Churn[0][9])
+	assert.Empty(report.Authors)
+
+	report, err = ChurnHeatmap(commits, HeatmapWeeks, nil, false)
+	assert.Nil(err)
+	assert.Len(report.Columns, 53)
+	assert.Equal("W02", report.Columns[1])
+	assert.Equal(6, report.Churn[0][1])
+	assert.Equal(10, report.Churn[6][1])
+
+	// The merges stand for the commits of their branch when only the first parents are walked
+	gitfuncs.FirstParent = true
+	defer func() { gitfuncs.FirstParent = false }()
+	report, err = ChurnHeatmap(commits, HeatmapHours, nil, false)
+	assert.Nil(err)
+	assert.Equal(24, report.TotalChurn)
+	assert.Equal(4, report.Churn[2][9])
+
+	_, err = ChurnHeatmap(commits, "month", nil, false)
+	assert.NotNil(err)
+}
@@ -0,0 +1,236 @@
+package metrics
+
+import (
+	"sort"
+
+	"github.com/andymeneely/git-churn/gitfuncs"
+	"github.com/andymeneely/git-churn/helper"
+	"gopkg.in/src-d/go-git.v4"
+	"gopkg.in/src-d/go-git.v4/plumbing/object"
+)
+
+// Hotspot is a file that changes often and is big, where defects and maintenance effort concentrate
+type Hotspot struct {
+	File    string
+	Commits int
+	Churn   int `units:"lines"`
+	LOC     int `units:"lines"`
+	// Commits times LOC, normalized so that the hottest file scores 1
+	Score float64
+}
+
+type FileOwnership struct {
+	File       string
+	Owner      string
+	OwnerLines int `units:"lines"`
+	TotalLines int `units:"lines"`
+	Authors    int
+	// When Teams are mapped, the team owning the path of the file and the team whose authors wrote most
+	// of its lines
+	Team           string `json:",omitempty"`
+	OwnerTeam      string `json:",omitempty"`
+	OwnerTeamLines int    `json:",omitempty" units:"lines"`
+}
+
+type PeriodChurn struct {
+	Period     string
+	Commits    int
+	Insertions int `units:"lines"`
+	Deletions  int `units:"lines"`
+	// Churn according to the churn definition
+	Churn int `units:"lines"`
+
+	// Distinct files changed and authors committing in the period
+	FilesChanged int
+	Authors      int
+}
+
+// Hotspots ranks the files still present at the given revision by how often they changed in the given
+// commits times their size, and returns the n hottest ones (all of them if n is zero)
+func Hotspots(repo *git.Repository, commits []*CommitChurn, revision string, n int) ([]Hotspot, error) {
+	defer helper.Duration(helper.Track("Hotspots"))
+	tree, err := revisionTree(repo, revision)
+	if err != nil {
+		return nil, err
+	}
+	byFile := make(map[string]*Hotspot)
+	for _, commit := range countedCommits(commits) {
+		for _, file := range commit.Files {
+			hotspot, ok := byFile[file.File]
+			if !ok {
+				hotspot = &Hotspot{File: file.File}
+				byFile[file.File] = hotspot
+			}
+			hotspot.Commits += 1
+			hotspot.Churn += file.Churn()
+		}
+	}
+
+	var hotspots []Hotspot
+	maxScore := 0.0
+	for _, hotspot := range byFile {
+		f, err := tree.File(hotspot.File)
+		if err != nil {
+			// Deleted since
+			continue
+		}
+		hotspot.LOC = gitfuncs.BlobLOC(f, true)
+		hotspot.Score = float64(hotspot.Commits * hotspot.LOC)
+		if hotspot.Score > maxScore {
+			maxScore = hotspot.Score
+		}
+		hotspots = append(hotspots, *hotspot)
+	}
+	for i := range hotspots {
+		if maxScore > 0 {
+			hotspots[i].Score /= maxScore
+		}
+	}
+	sort.Slice(hotspots, func(i, j int) bool {
+		if hotspots[i].Score != hotspots[j].Score {
+			return hotspots[i].Score > hotspots[j].Score
+		}
+		return hotspots[i].File < hotspots[j].File
+	})
+	if n > 0 && len(hotspots) > n {
+		hotspots = hotspots[:n]
+	}
+	return hotspots, nil
+}
+
+// FileHeat is the size of a file and how much it churned
+type FileHeat struct {
+	File    string
+	LOC     int `units:"lines"`
+	Commits int
+	// Churn according to the churn definition
+	Churn int `units:"lines"`
+}
+
+// FileHeats returns every file of the revision but the ignored ones, in path order, with its lines and
+// the commits and churn of the given commits on it, e.g. to draw a treemap
+func FileHeats(repo *git.Repository, commits []*CommitChurn, revision string) ([]FileHeat, error) {
+	defer helper.Duration(helper.Track("FileHeats"))
+	tree, err := revisionTree(repo, revision)
+	if err != nil {
+		return nil, err
+	}
+	byFile := make(map[string]*FileHeat)
+	for _, commit := range countedCommits(commits) {
+		for _, file := range commit.Files {
+			heat, ok := byFile[file.File]
+			if !ok {
+				heat = &FileHeat{File: file.File}
+				byFile[file.File] = heat
+			}
+			heat.Commits += 1
+			heat.Churn += file.Churn()
+		}
+	}
+
+	var heats []FileHeat
+	ignore := gitfuncs.RepoIgnore(repo)
+	err = gitfuncs.ForEachFile(tree, func(f *object.File) error {
+		if ignore.Match(f.Name) {
+			return nil
+		}
+		heat := FileHeat{File: f.Name}
+		if churned, ok := byFile[f.Name]; ok {
+			heat = *churned
+		}
+		heat.LOC = gitfuncs.BlobLOC(f, true)
+		heats = append(heats, heat)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(heats, func(i, j int) bool { return heats[i].File < heats[j].File })
+	return heats, nil
+}
+
+// GetFileOwnership blames the given files at the revision and reports their main owner, i.e. the author
+// who wrote most of their current lines. Files that cannot be blamed are skipped.
+func GetFileOwnership(repo *git.Repository, revision string, files []string) ([]FileOwnership, error) {
+	defer helper.Duration(helper.Track("GetFileOwnership"))
+	hash, err := gitfuncs.ResolveRef(repo, revision)
+	if err != nil {
+		return nil, err
+	}
+	var ownership []FileOwnership
+	for _, file := range files {
+		owners, err := LineOwnership(repo, *hash, file, nil)
+		if err != nil {
+			continue
+		}
+		fileOwnership := FileOwnership{File: file, Authors: len(owners)}
+		for owner, lines := range owners {
+			fileOwnership.TotalLines += lines
+			if lines > fileOwnership.OwnerLines || (lines == fileOwnership.OwnerLines && owner < fileOwnership.Owner) {
+				fileOwnership.Owner = owner
+				fileOwnership.OwnerLines = lines
+			}
+		}
+		if Teams != nil {
+			setTeamOwnership(&fileOwnership, owners)
+		}
+		ownership = append(ownership, fileOwnership)
+	}
+	return ownership, nil
+}
+
+// ChurnPerMonth totals the churn of the given commits per calendar month, oldest month first
+func ChurnPerMonth(commits []*CommitChurn) []PeriodChurn {
+	return churnPerPeriod(commits, "2006-01")
+}
+
+// ChurnPerDay totals the churn of the given commits per UTC day, oldest day first
+func ChurnPerDay(commits []*CommitChurn) []PeriodChurn {
+	return churnPerPeriod(commits, "2006-01-02")
+}
+
+// churnPerPeriod totals the churn of the commits per period, the periods being the author dates formatted
+// with the layout
+func churnPerPeriod(commits []*CommitChurn, layout string) []PeriodChurn {
+	byPeriod := make(map[string]*PeriodChurn)
+	files := make(map[string]map[string]bool)
+	authors := make(map[string]map[string]bool)
+	for _, commit := range countedCommits(commits) {
+		key := commit.When.UTC().Format(layout)
+		period, ok := byPeriod[key]
+		if !ok {
+			period = &PeriodChurn{Period: key}
+			byPeriod[key] = period
+			files[key] = make(map[string]bool)
+			authors[key] = make(map[string]bool)
+		}
+		period.Commits += 1
+		period.Insertions += commit.Insertions
+		period.Deletions += commit.Deletions
+		period.Churn += commit.Churn()
+		for _, file := range commit.Files {
+			files[key][file.File] = true
+		}
+		authors[key][commit.Author] = true
+	}
+	periods := make([]PeriodChurn, 0, len(byPeriod))
+	for key, period := range byPeriod {
+		period.FilesChanged = len(files[key])
+		period.Authors = len(authors[key])
+		periods = append(periods, *period)
+	}
+	sort.Slice(periods, func(i, j int) bool { return periods[i].Period < periods[j].Period })
+	return periods
+}
+
+func revisionTree(repo *git.Repository, revision string) (*object.Tree, error) {
+	hash, err := gitfuncs.ResolveRef(repo, revision)
+	if err != nil {
+		return nil, err
+	}
+	commit, err := repo.CommitObject(*hash)
+	if err != nil {
+		return nil, err
+	}
+	return commit.Tree()
+}
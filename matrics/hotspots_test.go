@@ -0,0 +1,48 @@
+package metrics
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestChurnPerMonth(t *testing.T) {
+	commits := []*CommitChurn{
+		churnAt("alice@example.com", "2020-02-10", 1, 1),
+		churnAt("bob@example.com", "2020-01-10", 5, 0),
+		churnAt("alice@example.com", "2020-02-01", 2, 3),
+	}
+	assert.Equal(t, []PeriodChurn{
+		{Period: "2020-01", Commits: 1, Insertions: 5, Deletions: 0, Churn: 5, Authors: 1},
+		{Period: "2020-02", Commits: 2, Insertions: 3, Deletions: 4, Churn: 7, Authors: 1},
+	}, ChurnPerMonth(commits))
+}
+
+func TestChurnPerDay(t *testing.T) {
+	commits := []*CommitChurn{
+		churnAt("alice@example.com", "2020-02-10", 1, 1),
+		churnAt("bob@example.com", "2020-02-10", 5, 0),
+		churnAt("alice@example.com", "2020-02-11", 2, 3),
+	}
+	commits[0].Files = []FileChurn{{File: "a.go", Insertions: 1, Deletions: 1}}
+	commits[1].Files = []FileChurn{{File: "a.go", Insertions: 3}, {File: "b.go", Insertions: 2}}
+	assert.Equal(t, []PeriodChurn{
+		{Period: "2020-02-10", Commits: 2, Insertions: 6, Deletions: 1, Churn: 7, FilesChanged: 2, Authors: 2},
+		{Period: "2020-02-11", Commits: 1, Insertions: 2, Deletions: 3, Churn: 5, Authors: 1},
+	}, ChurnPerDay(commits))
+}
+
+func TestFileHeats(t *testing.T) {
+	repo := snapshotRepo(t,
+		map[string]string{"a.go": "1\n2\n", "c.go": "1\n", "vendor/v.go": "v\n"},
+		map[string]string{"a.go": "1\n2\n3\n", "b.go": "b\n"},
+	)
+	commits, err := RangeChurn(repo, "", "HEAD")
+	assert.Nil(t, err)
+	heats, err := FileHeats(repo, commits, "HEAD")
+	assert.Nil(t, err)
+	assert.Equal(t, []FileHeat{
+		{File: "a.go", LOC: 3, Commits: 2, Churn: 3},
+		{File: "b.go", LOC: 1, Commits: 1, Churn: 1},
+		{File: "c.go", LOC: 1, Commits: 1, Churn: 1},
+	}, heats)
+}
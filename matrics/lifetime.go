@@ -0,0 +1,154 @@
+package metrics
+
+import (
+	"sort"
+	"time"
+
+	"github.com/andymeneely/git-churn/gitfuncs"
+	"github.com/andymeneely/git-churn/helper"
+	"gopkg.in/src-d/go-git.v4"
+	"gopkg.in/src-d/go-git.v4/plumbing/object"
+)
+
+// FileRename is a commit renaming a file
+type FileRename struct {
+	Hash string
+	When time.Time
+	From string
+	To   string
+	// Share of the lines the two files have in common
+	Similarity float64
+}
+
+// FileCommit is a commit changing a file, under the path the file had then
+type FileCommit struct {
+	Hash       string
+	Author     string
+	When       time.Time
+	Path       string
+	Insertions int `units:"lines"`
+	Deletions  int `units:"lines"`
+}
+
+// FileAuthor is an author of a file, with the first and the last of their commits changing it
+type FileAuthor struct {
+	Author     string
+	Commits    int
+	Insertions int `units:"lines"`
+	Deletions  int `units:"lines"`
+	First      time.Time
+	Last       time.Time
+}
+
+// FileLifetime is the whole history of a file, across its renames
+type FileLifetime struct {
+	File     string
+	Revision string
+	// Path the file was added under, and when
+	OriginalPath string
+	Created      time.Time
+	Commits      int
+	Insertions   int `units:"lines"`
+	Deletions    int `units:"lines"`
+	// Churn according to the churn definition
+	Churn int `units:"lines"`
+	LOC   int `units:"lines"`
+	// Oldest first
+	Renames []FileRename
+	// By first commit
+	Authors []FileAuthor
+	// Newest first, like git log
+	History []FileCommit
+}
+
+// GetFileLifetime follows the file at the path in the revision back to the commit that added it across its
+// renames, see gitfuncs.FollowFile, and totals the lines every commit changing it added and deleted, per
+// author with the span of their commits, along with the chain of its renames. The lines of a renamed file
+// are diffed against the file it was renamed from. Blank lines are counted only if whitespace is true.
+func GetFileLifetime(repo *git.Repository, revision, path string, similarity float64, whitespace bool) (*FileLifetime, error) {
+	defer helper.Duration(helper.Track("GetFileLifetime"))
+	revisions, err := gitfuncs.FollowFile(repo, revision, path, similarity)
+	if err != nil {
+		return nil, err
+	}
+	lifetime := &FileLifetime{File: path, Revision: revision}
+	authors := make(map[string]*FileAuthor)
+	for _, rev := range revisions {
+		patch, err := gitfuncs.ChangesChurnPatch([]*object.Change{rev.Change})
+		if err != nil {
+			return nil, err
+		}
+		commit := FileCommit{
+			Hash: rev.Commit.Hash.String(),
+			When: rev.Commit.Author.When,
+			Path: rev.Path(),
+		}
+		_, commit.Author = gitfuncs.ResolveAuthor(repo, rev.Commit.Author)
+		commit.Insertions = patch.Count(gitfuncs.LineAdded, whitespace)
+		commit.Deletions = patch.Count(gitfuncs.LineDeleted, whitespace)
+		lifetime.History = append(lifetime.History, commit)
+		lifetime.Commits += 1
+		lifetime.Insertions += commit.Insertions
+		lifetime.Deletions += commit.Deletions
+
+		author, ok := authors[commit.Author]
+		if !ok {
+			author = &FileAuthor{Author: commit.Author, First: commit.When, Last: commit.When}
+			authors[commit.Author] = author
+		}
+		author.Commits += 1
+		author.Insertions += commit.Insertions
+		author.Deletions += commit.Deletions
+		if commit.When.Before(author.First) {
+			author.First = commit.When
+		}
+		if commit.When.After(author.Last) {
+			author.Last = commit.When
+		}
+
+		if rev.Renamed() {
+			lifetime.Renames = append(lifetime.Renames, FileRename{
+				Hash:       commit.Hash,
+				When:       commit.When,
+				From:       rev.Change.From.Name,
+				To:         rev.Path(),
+				Similarity: rev.Similarity,
+			})
+		}
+	}
+	added := revisions[len(revisions)-1]
+	lifetime.OriginalPath = added.Path()
+	lifetime.Created = added.Commit.Author.When
+	lifetime.Churn = Churn.Lines(lifetime.Insertions, lifetime.Deletions, 0)
+	// The newest commit changing the file may be on a branch merged since
+	hash, err := gitfuncs.ResolveRef(repo, revision)
+	if err != nil {
+		return nil, err
+	}
+	commit, err := repo.CommitObject(*hash)
+	if err != nil {
+		return nil, err
+	}
+	tree, err := commit.Tree()
+	if err != nil {
+		return nil, err
+	}
+	if lifetime.LOC, err = gitfuncs.TreeFileLOC(tree, path, whitespace); err != nil {
+		return nil, err
+	}
+
+	for i, j := 0, len(lifetime.Renames)-1; i < j; i, j = i+1, j-1 {
+		lifetime.Renames[i], lifetime.Renames[j] = lifetime.Renames[j], lifetime.Renames[i]
+	}
+	for _, author := range authors {
+		lifetime.Authors = append(lifetime.Authors, *author)
+	}
+	sort.Slice(lifetime.Authors, func(i, j int) bool {
+		a, b := lifetime.Authors[i], lifetime.Authors[j]
+		if !a.First.Equal(b.First) {
+			return a.First.Before(b.First)
+		}
+		return a.Author < b.Author
+	})
+	return lifetime, nil
+}
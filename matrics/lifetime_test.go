@@ -0,0 +1,64 @@
+package metrics
+
+import (
+	"testing"
+
+	"github.com/andymeneely/git-churn/gitfuncs"
+	"github.com/andymeneely/git-churn/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetFileLifetime(t *testing.T) {
+	assert := assert.New(t)
+	repo := testutil.NewRepo(t)
+	repo.As("alice").CommitFiles("root", map[string]string{"a.go": "1\n2\n3\n4\n"})
+	repo.As("bob").CommitFiles("change", map[string]string{"a.go": "1\n2\n3\n4\n5\n"})
+	repo.As("bob").Rename("a.go", "b.go").WriteFiles(map[string]string{"b.go": "1\n2\n3\n4\nfive\n"}).Commit("rename")
+	repo.As("alice").CommitFiles("last", map[string]string{"b.go": "1\n2\n3\n4\nfive\n\n6\n"})
+
+	lifetime, err := GetFileLifetime(repo.Repository, "HEAD", "b.go", gitfuncs.DefaultRenameSimilarity, true)
+	assert.Nil(err)
+	assert.Equal("a.go", lifetime.OriginalPath)
+	assert.True(testutil.Start.Equal(lifetime.Created))
+	assert.Equal(4, lifetime.Commits)
+	assert.Equal(4+1+1+2, lifetime.Insertions)
+	assert.Equal(1, lifetime.Deletions)
+	assert.Equal(9, lifetime.Churn)
+	assert.Equal(7, lifetime.LOC)
+	assert.Len(lifetime.Renames, 1)
+	rename := lifetime.Renames[0]
+	assert.Equal(FileRename{Hash: lifetime.History[1].Hash, From: "a.go", To: "b.go", Similarity: 0.8}, FileRename{Hash: rename.Hash, From: rename.From, To: rename.To, Similarity: rename.Similarity})
+	assert.True(testutil.Start.AddDate(0, 0, 2).Equal(rename.When))
+	assert.Len(lifetime.Authors, 2)
+	alice, bob := lifetime.Authors[0], lifetime.Authors[1]
+	assert.Equal([]interface{}{"alice@example.com", 2, 6, 0}, []interface{}{alice.Author, alice.Commits, alice.Insertions, alice.Deletions})
+	assert.True(testutil.Start.Equal(alice.First))
+	assert.True(testutil.Start.AddDate(0, 0, 3).Equal(alice.Last))
+	assert.Equal([]interface{}{"bob@example.com", 2, 2, 1}, []interface{}{bob.Author, bob.Commits, bob.Insertions, bob.Deletions})
+	assert.True(testutil.Start.AddDate(0, 0, 1).Equal(bob.First))
+	assert.True(testutil.Start.AddDate(0, 0, 2).Equal(bob.Last))
+	assert.Equal([]string{"b.go", "b.go", "a.go", "a.go"}, []string{lifetime.History[0].Path, lifetime.History[1].Path, lifetime.History[2].Path, lifetime.History[3].Path})
+
+	lifetime, err = GetFileLifetime(repo.Repository, "HEAD", "b.go", gitfuncs.DefaultRenameSimilarity, false)
+	assert.Nil(err)
+	assert.Equal(6, lifetime.LOC)
+	assert.Equal(4+1+1+1, lifetime.Insertions)
+}
+
+func TestGetFileLifetimeMerge(t *testing.T) {
+	assert := assert.New(t)
+	repo := testutil.NewRepo(t)
+	repo.As("alice").CommitFiles("root", map[string]string{"a.go": "1\n2\n", "other.go": "x\n"})
+	repo.Branch("feature").As("bob").CommitFiles("change", map[string]string{"a.go": "1\n2\n3\n"})
+	repo.Checkout("master").As("alice").CommitFiles("unrelated", map[string]string{"other.go": "y\n"})
+	repo.Merge("feature", "merge feature")
+
+	lifetime, err := GetFileLifetime(repo.Repository, "HEAD", "a.go", gitfuncs.DefaultRenameSimilarity, true)
+	assert.Nil(err)
+	assert.Equal(2, lifetime.Commits)
+	assert.Equal(3, lifetime.Insertions)
+	assert.Equal(3, lifetime.LOC)
+	assert.Len(lifetime.Authors, 2)
+	bob := lifetime.Authors[1]
+	assert.Equal([]interface{}{"bob@example.com", 1, 1, 0}, []interface{}{bob.Author, bob.Commits, bob.Insertions, bob.Deletions})
+}
@@ -0,0 +1,133 @@
+package metrics
+
+import (
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/andymeneely/git-churn/gitfuncs"
+	"github.com/andymeneely/git-churn/helper"
+	"github.com/andymeneely/git-churn/lang"
+	"gopkg.in/src-d/go-git.v4"
+	"gopkg.in/src-d/go-git.v4/plumbing/object"
+)
+
+// Groupings supported by LOCSnapshot
+const (
+	GroupByDir  = "dir"
+	GroupByExt  = "ext"
+	GroupByLang = "lang"
+	// The components of Components
+	GroupByComponent = "component"
+)
+
+type LOCGroup struct {
+	Name  string
+	Files int
+	LOC   int `units:"lines"`
+}
+
+type LOCSnapshot struct {
+	Commit  string
+	Files   int
+	LOC     int        `units:"lines"`
+	GroupBy string     `json:",omitempty"`
+	Groups  []LOCGroup `json:",omitempty"`
+}
+
+// GetLOCSnapshot counts the lines of code of every file in the tree of the given revision, like cloc
+// does for a working copy. When groupBy is set, the counts are also broken down by top level
+// directory, file extension, language or component. Blank lines are counted only if whitespace is true, and
+// neither blank lines nor comments if code is true.
+func GetLOCSnapshot(repo *git.Repository, revision, groupBy string, whitespace, code bool) (*LOCSnapshot, error) {
+	defer helper.Duration(helper.Track("GetLOCSnapshot"))
+	groupKey, err := locGroupKey(groupBy)
+	if err != nil {
+		return nil, err
+	}
+	hash, err := gitfuncs.ResolveRef(repo, revision)
+	if err != nil {
+		return nil, err
+	}
+	commit, err := repo.CommitObject(*hash)
+	if err != nil {
+		return nil, err
+	}
+	tree, err := commit.Tree()
+	if err != nil {
+		return nil, err
+	}
+
+	snapshot := &LOCSnapshot{Commit: hash.String(), GroupBy: groupBy}
+	groups := make(map[string]*LOCGroup)
+	ignore := gitfuncs.RepoIgnore(repo)
+	err = gitfuncs.ForEachFile(tree, func(f *object.File) error {
+		if ignore.Match(f.Name) {
+			return nil
+		}
+		var loc int
+		if code {
+			loc = gitfuncs.BlobCodeLOC(f)
+		} else {
+			loc = gitfuncs.BlobLOC(f, whitespace)
+		}
+		snapshot.Files += 1
+		snapshot.LOC += loc
+		if groupKey != nil {
+			name := groupKey(f.Name)
+			group, ok := groups[name]
+			if !ok {
+				group = &LOCGroup{Name: name}
+				groups[name] = group
+			}
+			group.Files += 1
+			group.LOC += loc
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, group := range groups {
+		snapshot.Groups = append(snapshot.Groups, *group)
+	}
+	// Largest groups first, like cloc
+	sort.Slice(snapshot.Groups, func(i, j int) bool {
+		if snapshot.Groups[i].LOC != snapshot.Groups[j].LOC {
+			return snapshot.Groups[i].LOC > snapshot.Groups[j].LOC
+		}
+		return snapshot.Groups[i].Name < snapshot.Groups[j].Name
+	})
+	return snapshot, nil
+}
+
+func locGroupKey(groupBy string) (func(string) string, error) {
+	switch groupBy {
+	case "":
+		return nil, nil
+	case GroupByDir:
+		return topLevelDir, nil
+	case GroupByExt:
+		return func(filePath string) string {
+			if ext := path.Ext(filePath); ext != "" {
+				return ext
+			}
+			return "(none)"
+		}, nil
+	case GroupByLang:
+		return lang.Detect, nil
+	case GroupByComponent:
+		return Components.PathComponent, nil
+	}
+	return nil, fmt.Errorf("unknown grouping %q, expected one of %s, %s, %s or %s", groupBy, GroupByDir, GroupByExt, GroupByLang, GroupByComponent)
+}
+
+// topLevelDir returns the first directory of the path, or "." for files in the repository root
+func topLevelDir(filePath string) string {
+	if i := strings.Index(filePath, "/"); i >= 0 {
+		return filePath[:i]
+	}
+	return "."
+}
@@ -0,0 +1,203 @@
+package metrics
+
+import (
+	"fmt"
+	"plugin"
+	"sort"
+	"strings"
+
+	"github.com/andymeneely/git-churn/helper"
+	"gopkg.in/src-d/go-git.v4"
+	"gopkg.in/src-d/go-git.v4/plumbing/object"
+)
+
+// Metric is a metric computed over the commits of a range in the single walk of RunMetrics, so that custom
+// metrics can be added without walking the history again. A metric is created anew for every run.
+type Metric interface {
+	// Name of the metric, the key of its result
+	Name() string
+	// Init prepares the metric to analyse the repository, before the first commit
+	Init(repo *git.Repository) error
+	// ProcessCommit analyses a commit of the range along with its churn. The commits come newest first,
+	// leaving out those of Bots.
+	ProcessCommit(commit *object.Commit, churn *CommitChurn) error
+	// Result returns the result of the metric once every commit has been processed, serialized like the
+	// results of the commands
+	Result() (interface{}, error)
+}
+
+// RangeMetric is a Metric needing the revisions bounding the range it analyses, e.g. to read the files at
+// its end. RunMetrics sets them before Init.
+type RangeMetric interface {
+	Metric
+	SetRange(from, to string)
+}
+
+// Metrics are the constructors of the metrics RunMetrics can compute, by name, the compiled-in ones and
+// those registered by the plugins
+var Metrics = map[string]func() Metric{
+	"churn": newRangeMetric("churn", func(_ *git.Repository, _ string, commits []*CommitChurn) (interface{}, error) {
+		return TopFiles(commits, "churn", 0)
+	}),
+	"components": newRangeMetric("components", func(repo *git.Repository, to string, commits []*CommitChurn) (interface{}, error) {
+		return ChurnByComponent(repo, commits, to)
+	}),
+	"contributors": newRangeMetric("contributors", func(_ *git.Repository, _ string, commits []*CommitChurn) (interface{}, error) {
+		return Contributors(commits, "commits")
+	}),
+	"coupling": newRangeMetric("coupling", func(_ *git.Repository, _ string, commits []*CommitChurn) (interface{}, error) {
+		return ChangeCoupling(commits, DefaultMinCoChanges, 0), nil
+	}),
+	"entropy": newRangeMetric("entropy", func(_ *git.Repository, _ string, commits []*CommitChurn) (interface{}, error) {
+		return ChangeEntropy(commits), nil
+	}),
+	"heatmap": newRangeMetric("heatmap", func(_ *git.Repository, _ string, commits []*CommitChurn) (interface{}, error) {
+		return ChurnHeatmap(commits, HeatmapHours, nil, false)
+	}),
+	"hotspots": newRangeMetric("hotspots", func(repo *git.Repository, to string, commits []*CommitChurn) (interface{}, error) {
+		return Hotspots(repo, commits, to, 0)
+	}),
+	"ownership": newRangeMetric("ownership", func(repo *git.Repository, to string, commits []*CommitChurn) (interface{}, error) {
+		return GetFileOwnership(repo, to, changedFiles(commits))
+	}),
+	"stats": newRangeMetric("stats", func(_ *git.Repository, _ string, commits []*CommitChurn) (interface{}, error) {
+		return CommitSizes(commits, false), nil
+	}),
+	"tenure": newRangeMetric("tenure", func(_ *git.Repository, _ string, commits []*CommitChurn) (interface{}, error) {
+		return AuthorTenures(commits, DefaultRampMonths, DefaultRampShare)
+	}),
+	"types": newRangeMetric("types", func(_ *git.Repository, _ string, commits []*CommitChurn) (interface{}, error) {
+		return ChurnByChangeType(commits), nil
+	}),
+}
+
+// DefaultMetrics are the metrics computed when none is requested, the cheap ones, leaving out ownership
+// which blames every file changed, and components which needs Components
+var DefaultMetrics = []string{"churn", "contributors", "coupling", "entropy", "heatmap", "hotspots", "stats", "tenure", "types"}
+
+// RegisterMetric adds a metric to Metrics, the constructor being called for every run. It is meant to be
+// called from the init function of the package defining the metric, compiled in or loaded as a plugin.
+func RegisterMetric(name string, constructor func() Metric) {
+	if _, ok := Metrics[name]; ok {
+		panic(fmt.Sprintf("metric %q registered twice", name))
+	}
+	Metrics[name] = constructor
+}
+
+// MetricNames returns the names of the registered metrics, sorted
+func MetricNames() []string {
+	names := make([]string, 0, len(Metrics))
+	for name := range Metrics {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// NewMetric creates the registered metric of the given name
+func NewMetric(name string) (Metric, error) {
+	constructor, ok := Metrics[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown metric %q, expected one of %s", name, strings.Join(MetricNames(), ", "))
+	}
+	return constructor(), nil
+}
+
+// LoadMetricPlugin opens a Go plugin (go build -buildmode=plugin) registering metrics with RegisterMetric
+// from its init functions. The plugin has to be built with the same version of Go and of git-churn, and
+// plugins are only supported on Linux, FreeBSD and macOS.
+func LoadMetricPlugin(path string) error {
+	if _, err := plugin.Open(path); err != nil {
+		return fmt.Errorf("loading the metric plugin %s: %v", path, err)
+	}
+	return nil
+}
+
+// RunMetrics walks the commits of the range from..to once, like ForEachCommitMetrics, feeding every commit
+// and its churn to all the metrics, and returns their results by name. Every commit is diffed once, however
+// many metrics read its churn.
+func RunMetrics(repo *git.Repository, from, to string, metrics []Metric) (map[string]interface{}, error) {
+	defer helper.Duration(helper.Track("RunMetrics"))
+	for _, metric := range metrics {
+		if rangeMetric, ok := metric.(RangeMetric); ok {
+			rangeMetric.SetRange(from, to)
+		}
+		if err := metric.Init(repo); err != nil {
+			return nil, fmt.Errorf("metric %s: %v", metric.Name(), err)
+		}
+	}
+	err := forEachRangeCommit(repo, from, to, func(commit *object.Commit, churn *CommitChurn) error {
+		for _, metric := range metrics {
+			if err := metric.ProcessCommit(commit, churn); err != nil {
+				return fmt.Errorf("metric %s: %v", metric.Name(), err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	results := make(map[string]interface{}, len(metrics))
+	for _, metric := range metrics {
+		result, err := metric.Result()
+		if err != nil {
+			return nil, fmt.Errorf("metric %s: %v", metric.Name(), err)
+		}
+		results[metric.Name()] = result
+	}
+	return results, nil
+}
+
+// rangeMetric adapts the reports computed from the churn of a whole range into a Metric, the churn of the
+// commits being shared by all of them
+type rangeMetric struct {
+	name    string
+	report  func(repo *git.Repository, to string, commits []*CommitChurn) (interface{}, error)
+	repo    *git.Repository
+	to      string
+	commits []*CommitChurn
+}
+
+func newRangeMetric(name string, report func(*git.Repository, string, []*CommitChurn) (interface{}, error)) func() Metric {
+	return func() Metric {
+		return &rangeMetric{name: name, report: report}
+	}
+}
+
+func (m *rangeMetric) Name() string {
+	return m.name
+}
+
+func (m *rangeMetric) SetRange(_, to string) {
+	m.to = to
+}
+
+func (m *rangeMetric) Init(repo *git.Repository) error {
+	m.repo = repo
+	return nil
+}
+
+func (m *rangeMetric) ProcessCommit(_ *object.Commit, churn *CommitChurn) error {
+	m.commits = append(m.commits, churn)
+	return nil
+}
+
+func (m *rangeMetric) Result() (interface{}, error) {
+	return m.report(m.repo, m.to, m.commits)
+}
+
+// changedFiles returns the files changed by the commits, sorted
+func changedFiles(commits []*CommitChurn) []string {
+	changed := make(map[string]bool)
+	for _, commit := range commits {
+		for _, file := range commit.Files {
+			changed[file.File] = true
+		}
+	}
+	files := make([]string, 0, len(changed))
+	for file := range changed {
+		files = append(files, file)
+	}
+	sort.Strings(files)
+	return files
+}
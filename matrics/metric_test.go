@@ -0,0 +1,105 @@
+package metrics
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/andymeneely/git-churn/testutil"
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/src-d/go-git.v4"
+	"gopkg.in/src-d/go-git.v4/plumbing/object"
+)
+
+// linesPerAuthor is a custom metric totalling the lines added per author
+type linesPerAuthor struct {
+	lines map[string]int
+	fail  bool
+}
+
+func (m *linesPerAuthor) Name() string {
+	return "lines-per-author"
+}
+
+func (m *linesPerAuthor) Init(*git.Repository) error {
+	m.lines = make(map[string]int)
+	return nil
+}
+
+func (m *linesPerAuthor) ProcessCommit(commit *object.Commit, churn *CommitChurn) error {
+	if m.fail {
+		return errors.New("failed")
+	}
+	m.lines[commit.Author.Email] += churn.Insertions
+	return nil
+}
+
+func (m *linesPerAuthor) Result() (interface{}, error) {
+	return m.lines, nil
+}
+
+func TestRunMetrics(t *testing.T) {
+	assert := assert.New(t)
+	repo := testutil.NewRepo(t)
+	repo.As("alice").CommitFiles("feat: parser", map[string]string{"a.go": "1\n2\n", "b.go": "1\n"})
+	repo.As("bob").CommitFiles("fix: lexer", map[string]string{"a.go": "1\n2\n3\n"})
+
+	RegisterMetric("lines-per-author", func() Metric { return &linesPerAuthor{} })
+	defer delete(Metrics, "lines-per-author")
+	assert.Panics(func() { RegisterMetric("lines-per-author", func() Metric { return &linesPerAuthor{} }) })
+	assert.Equal([]string{"churn", "components", "contributors", "coupling", "entropy", "heatmap", "hotspots", "lines-per-author", "ownership", "stats", "tenure", "types"}, MetricNames())
+
+	var requested []Metric
+	for _, name := range []string{"lines-per-author", "stats"} {
+		metric, err := NewMetric(name)
+		assert.Nil(err)
+		requested = append(requested, metric)
+	}
+	results, err := RunMetrics(repo.Repository, "", "HEAD", requested)
+	assert.Nil(err)
+	assert.Len(results, 2)
+	assert.Equal(map[string]int{"alice@example.com": 3, "bob@example.com": 1}, results["lines-per-author"])
+	assert.Equal(2, results["stats"].(*CommitSizeStats).Commits)
+
+	_, err = NewMetric("unknown")
+	assert.EqualError(err, `unknown metric "unknown", expected one of churn, components, contributors, coupling, entropy, heatmap, hotspots, lines-per-author, ownership, stats, tenure, types`)
+	_, err = RunMetrics(repo.Repository, "", "HEAD", []Metric{&linesPerAuthor{fail: true}})
+	assert.EqualError(err, "metric lines-per-author: failed")
+	assert.NotNil(LoadMetricPlugin("missing.so"))
+}
+
+// TestRunMetricsSinglePass checks that the metrics computed in a single walk match their commands, which
+// walk the range each
+func TestRunMetricsSinglePass(t *testing.T) {
+	assert := assert.New(t)
+	repo := testutil.NewRepo(t)
+	repo.As("alice").CommitFiles("feat: parser", map[string]string{"a.go": "1\n2\n", "b.go": "1\n"})
+	repo.As("bob").CommitFiles("fix: lexer", map[string]string{"a.go": "1\n2\n3\n", "b.go": "2\n"})
+	repo.As("alice").CommitFiles("refactor: gone", map[string]string{"c.go": "1\n"})
+	repo.Delete("c.go").Commit("chore: drop c")
+
+	var requested []Metric
+	for _, name := range append(DefaultMetrics, "ownership") {
+		metric, err := NewMetric(name)
+		assert.Nil(err)
+		requested = append(requested, metric)
+	}
+	results, err := RunMetrics(repo.Repository, "", "HEAD", requested)
+	assert.Nil(err)
+	assert.Len(results, len(DefaultMetrics)+1)
+
+	commits, err := RangeChurn(repo.Repository, "", "HEAD")
+	assert.Nil(err)
+	top, _ := TopFiles(commits, "churn", 0)
+	assert.Equal(top, results["churn"])
+	contributors, _ := Contributors(commits, "commits")
+	assert.Equal(contributors, results["contributors"])
+	hotspots, _ := Hotspots(repo.Repository, commits, "HEAD", 0)
+	assert.Equal(hotspots, results["hotspots"])
+	assert.Equal(ChangeCoupling(commits, DefaultMinCoChanges, 0), results["coupling"])
+	assert.Equal([]FileCoupling{{File: "a.go", CoupledFile: "b.go", CoChanges: 2, Coupling: 1}}, results["coupling"])
+	ownership := results["ownership"].([]FileOwnership)
+	assert.Len(ownership, 2)
+	assert.Equal("a.go", ownership[0].File)
+	assert.Equal("alice@example.com", ownership[0].Owner)
+	assert.Equal("bob@example.com", ownership[1].Owner)
+}
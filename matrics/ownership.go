@@ -0,0 +1,30 @@
+package metrics
+
+import (
+	"github.com/andymeneely/git-churn/gitfuncs"
+	"gopkg.in/src-d/go-git.v4"
+	"gopkg.in/src-d/go-git.v4/plumbing"
+)
+
+// LineOwnership blames the file at the given commit and counts, per author email, how many of the
+// given lines (1-based) they wrote last. All the lines of the file are counted if lines is nil.
+func LineOwnership(repo *git.Repository, commit plumbing.Hash, filePath string, lines []int) (map[string]int, error) {
+	blame, err := gitfuncs.Blame(repo, &commit, filePath)
+	if err != nil {
+		return nil, err
+	}
+	owners := make(map[string]int)
+	if lines == nil {
+		for _, line := range blame.Lines {
+			owners[gitfuncs.ResolveLineAuthor(repo, line)] += 1
+		}
+		return owners, nil
+	}
+	for _, lineNumber := range lines {
+		if lineNumber < 1 || lineNumber > len(blame.Lines) {
+			continue
+		}
+		owners[gitfuncs.ResolveLineAuthor(repo, blame.Lines[lineNumber-1])] += 1
+	}
+	return owners, nil
+}
@@ -0,0 +1,83 @@
+package metrics
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// PathRewrite rewrites the paths matching a regular expression, the replacement expanding $1 and the other
+// submatches like regexp.ReplaceAllString
+type PathRewrite struct {
+	Pattern     *regexp.Regexp
+	Replacement string
+}
+
+// PathRewriter aliases the paths of the files, e.g. the paths of a directory before it was restructured to
+// its current ones, so that the churn of a file is aggregated across its moves. The first rule matching a
+// path rewrites it. A nil PathRewriter keeps every path.
+type PathRewriter struct {
+	Rules []PathRewrite
+}
+
+// PathRewrites rewrite the paths of the files changed by the commits before the range metrics aggregate them
+var PathRewrites *PathRewriter
+
+// ParsePathRewrites parses rules given as pattern=replacement, e.g. ^pkg/=internal/, the pattern being a
+// regular expression and the replacement the text of the first = on. It returns nil when there are none.
+func ParsePathRewrites(specs []string) (*PathRewriter, error) {
+	if len(specs) == 0 {
+		return nil, nil
+	}
+	rewriter := &PathRewriter{}
+	for _, spec := range specs {
+		i := strings.Index(spec, "=")
+		if i <= 0 {
+			return nil, fmt.Errorf("invalid path rewrite %q, expected pattern=replacement", spec)
+		}
+		re, err := regexp.Compile(spec[:i])
+		if err != nil {
+			return nil, fmt.Errorf("invalid path rewrite pattern %q: %v", spec[:i], err)
+		}
+		rewriter.Rules = append(rewriter.Rules, PathRewrite{Pattern: re, Replacement: spec[i+1:]})
+	}
+	return rewriter, nil
+}
+
+// Rewrite returns the path as rewritten by the first rule matching it, or the path itself
+func (r *PathRewriter) Rewrite(path string) string {
+	if r == nil {
+		return path
+	}
+	for _, rule := range r.Rules {
+		if rule.Pattern.MatchString(path) {
+			return rule.Pattern.ReplaceAllString(path, rule.Replacement)
+		}
+	}
+	return path
+}
+
+// rewriteFiles rewrites the paths of the files, merging the files rewritten to the same path, e.g. both
+// sides of a move, and sorts them by path
+func (r *PathRewriter) rewriteFiles(files []FileChurn) []FileChurn {
+	if r == nil {
+		return files
+	}
+	byPath := make(map[string]int, len(files))
+	rewritten := make([]FileChurn, 0, len(files))
+	for _, file := range files {
+		file.File = r.Rewrite(file.File)
+		i, ok := byPath[file.File]
+		if !ok {
+			byPath[file.File] = len(rewritten)
+			rewritten = append(rewritten, file)
+			continue
+		}
+		rewritten[i].Insertions += file.Insertions
+		rewritten[i].Deletions += file.Deletions
+		rewritten[i].RecentDeletions += file.RecentDeletions
+	}
+	sort.Slice(rewritten, func(i, j int) bool { return rewritten[i].File < rewritten[j].File })
+	return rewritten
+}
@@ -0,0 +1,56 @@
+package metrics
+
+import (
+	"testing"
+
+	"github.com/andymeneely/git-churn/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParsePathRewrites(t *testing.T) {
+	assert := assert.New(t)
+	rewriter, err := ParsePathRewrites(nil)
+	assert.Nil(err)
+	assert.Nil(rewriter)
+	assert.Equal("pkg/a.go", rewriter.Rewrite("pkg/a.go"))
+
+	rewriter, err = ParsePathRewrites([]string{`^pkg/=internal/`, `^src/(\w+)/lib/=$1/`, `^(old)/=new/`})
+	assert.Nil(err)
+	assert.Equal("internal/a.go", rewriter.Rewrite("pkg/a.go"))
+	assert.Equal("web/b.go", rewriter.Rewrite("src/web/lib/b.go"))
+	assert.Equal("docs/pkg/c.md", rewriter.Rewrite("docs/pkg/c.md"))
+	// The first rule matching wins
+	rewriter, err = ParsePathRewrites([]string{`\.go$=.golang`, `^pkg/=internal/`})
+	assert.Nil(err)
+	assert.Equal("pkg/a.golang", rewriter.Rewrite("pkg/a.go"))
+
+	_, err = ParsePathRewrites([]string{"pkg/"})
+	assert.EqualError(err, `invalid path rewrite "pkg/", expected pattern=replacement`)
+	_, err = ParsePathRewrites([]string{"=internal/"})
+	assert.EqualError(err, `invalid path rewrite "=internal/", expected pattern=replacement`)
+	_, err = ParsePathRewrites([]string{"(pkg=internal/"})
+	assert.EqualError(err, "invalid path rewrite pattern \"(pkg\": error parsing regexp: missing closing ): `(pkg`")
+}
+
+func TestRangeChurnPathRewrites(t *testing.T) {
+	assert := assert.New(t)
+	repo := testutil.NewRepo(t)
+	repo.CommitFiles("add", map[string]string{"pkg/a.go": "1\n2\n", "pkg/b.go": "1\n"})
+	repo.Delete("pkg/a.go").Delete("pkg/b.go").WriteFiles(map[string]string{"internal/a.go": "1\n2\n3\n", "internal/b.go": "1\n"}).Commit("restructure")
+	repo.CommitFiles("edit", map[string]string{"internal/a.go": "1\n"})
+
+	PathRewrites, _ = ParsePathRewrites([]string{"^pkg/=internal/"})
+	defer func() { PathRewrites = nil }()
+	commits, err := RangeChurn(repo.Repository, "", "HEAD")
+	assert.Nil(err)
+	// Both sides of the move are the same file
+	assert.Equal([]FileChurn{{File: "internal/a.go", Insertions: 3, Deletions: 2}, {File: "internal/b.go", Insertions: 1, Deletions: 1}}, commits[1].Files)
+	top, err := TopFiles(commits, "churn", 0)
+	assert.Nil(err)
+	assert.Len(top, 2)
+	assert.Equal("internal/a.go", top[0].File)
+	assert.Equal(3, top[0].Commits)
+	assert.Equal(9, top[0].Churn)
+	assert.Equal("internal/b.go", top[1].File)
+	assert.Equal(2, top[1].Commits)
+}
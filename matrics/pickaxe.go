@@ -0,0 +1,150 @@
+package metrics
+
+import (
+	"errors"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/andymeneely/git-churn/gitfuncs"
+	"github.com/andymeneely/git-churn/helper"
+	"gopkg.in/src-d/go-git.v4"
+	"gopkg.in/src-d/go-git.v4/plumbing/object"
+)
+
+// PickaxeCommit is a commit changing the number of occurrences of the string searched, e.g. adopting or
+// removing the calls of an API
+type PickaxeCommit struct {
+	Hash       string
+	Author     string
+	AuthorName string
+	When       time.Time
+	Subject    string
+	// Occurrences added to and removed from the files, netted per file
+	Added   int
+	Removed int
+	Files   []gitfuncs.OccurrenceChange
+}
+
+// PickaxePeriod totals the occurrences added and removed by the commits of a month
+type PickaxePeriod struct {
+	Period  string
+	Commits int
+	Added   int
+	Removed int
+	// Occurrences in the tree at the end of the month
+	Occurrences int
+}
+
+// PickaxeReport is the trend of the occurrences of a string over a range of commits
+type PickaxeReport struct {
+	String string
+	// Last commit of the range and the occurrences in its tree
+	Commit      string
+	Occurrences int
+	Added       int
+	Removed     int
+	// Commits changing the occurrences, newest first, and their totals per month, oldest first
+	Commits []PickaxeCommit
+	Months  []PickaxePeriod
+}
+
+// PickaxeTrend finds the commits of the range changing the number of occurrences of the string, like git log
+// -S, and reports how many occurrences each added and removed, per month, and how many are left in the
+// tree of every month. The files left out of the churn, the commits of Bots and the merges, but with
+// gitfuncs.FirstParent, are left out. The occurrences of the past months are counted back from the last
+// commit, so that the history does not have to be walked from its start.
+func PickaxeTrend(repo *git.Repository, from, to, s string) (*PickaxeReport, error) {
+	defer helper.Duration(helper.Track("PickaxeTrend"))
+	if s == "" {
+		return nil, errors.New("the string to search is empty")
+	}
+	fromHash, toHash, err := resolveRange(repo, from, to)
+	if err != nil {
+		return nil, err
+	}
+	report := &PickaxeReport{String: s, Commit: toHash.String(), Commits: []PickaxeCommit{}}
+	ignore := gitfuncs.RepoIgnore(repo)
+	err = gitfuncs.ForEachCommitBetween(repo, fromHash, toHash, func(commit *object.Commit) error {
+		if (commit.NumParents() > 1 && !gitfuncs.FirstParent) || Bots.IsBot(commit) {
+			return nil
+		}
+		changes, err := gitfuncs.CommitOccurrenceChanges(commit, s)
+		if err != nil {
+			return err
+		}
+		name, email := gitfuncs.ResolveAuthor(repo, commit.Author)
+		pickaxe := PickaxeCommit{
+			Hash:       commit.Hash.String(),
+			Author:     email,
+			AuthorName: name,
+			When:       commit.Author.When,
+			Subject:    strings.SplitN(strings.TrimSpace(commit.Message), "\n", 2)[0],
+		}
+		for _, change := range changes {
+			if ignore.Match(change.Path) {
+				continue
+			}
+			if change.After > change.Before {
+				pickaxe.Added += change.After - change.Before
+			} else {
+				pickaxe.Removed += change.Before - change.After
+			}
+			pickaxe.Files = append(pickaxe.Files, change)
+		}
+		if len(pickaxe.Files) > 0 {
+			report.Commits = append(report.Commits, pickaxe)
+			report.Added += pickaxe.Added
+			report.Removed += pickaxe.Removed
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	tree, err := revisionTree(repo, toHash.String())
+	if err != nil {
+		return nil, err
+	}
+	err = gitfuncs.ForEachFile(tree, func(f *object.File) error {
+		if ignore.Match(f.Name) {
+			return nil
+		}
+		occurrences, err := gitfuncs.FileOccurrences(f, s)
+		report.Occurrences += occurrences
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	report.Months = pickaxePerMonth(report.Commits, report.Occurrences)
+	return report, nil
+}
+
+// pickaxePerMonth totals the commits per month of their author date, oldest first, the occurrences left at
+// the end of every month being counted back from the occurrences at the end of the range
+func pickaxePerMonth(commits []PickaxeCommit, occurrences int) []PickaxePeriod {
+	byMonth := make(map[string]*PickaxePeriod)
+	for _, commit := range commits {
+		key := commit.When.UTC().Format("2006-01")
+		month, ok := byMonth[key]
+		if !ok {
+			month = &PickaxePeriod{Period: key}
+			byMonth[key] = month
+		}
+		month.Commits += 1
+		month.Added += commit.Added
+		month.Removed += commit.Removed
+	}
+	months := make([]PickaxePeriod, 0, len(byMonth))
+	for _, month := range byMonth {
+		months = append(months, *month)
+	}
+	sort.Slice(months, func(i, j int) bool { return months[i].Period < months[j].Period })
+	for i := len(months) - 1; i >= 0; i-- {
+		months[i].Occurrences = occurrences
+		occurrences -= months[i].Added - months[i].Removed
+	}
+	return months
+}
@@ -0,0 +1,40 @@
+package metrics
+
+import (
+	"testing"
+
+	"github.com/andymeneely/git-churn/gitfuncs"
+	"github.com/andymeneely/git-churn/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPickaxeTrend(t *testing.T) {
+	assert := assert.New(t)
+	repo := testutil.NewRepo(t)
+	repo.As("alice").CommitFiles("Add the client\n", map[string]string{"client.go": "old.Get(a)\nold.Get(b)\n", "main.go": "old.Get(c)\n"})
+	repo.As("bob").CommitFiles("Document the client\n", map[string]string{"README.md": "Call the client\n"})
+	repo.At(testutil.Start.AddDate(0, 1, 0)).As("alice").CommitFiles("Move to the new API\n", map[string]string{"client.go": "api.Get(a)\nold.Get(b)\n"})
+	repo.As("bob").Delete("main.go").Commit("Drop main\n")
+
+	report, err := PickaxeTrend(repo.Repository, "", "HEAD", "old.Get(")
+	assert.Nil(err)
+	assert.Equal(1, report.Occurrences)
+	assert.Equal(3, report.Added)
+	assert.Equal(2, report.Removed)
+
+	var subjects []string
+	for _, commit := range report.Commits {
+		subjects = append(subjects, commit.Subject)
+	}
+	assert.Equal([]string{"Drop main", "Move to the new API", "Add the client"}, subjects)
+	assert.Equal([]gitfuncs.OccurrenceChange{{Path: "client.go", Before: 2, After: 1}}, report.Commits[1].Files)
+	assert.Equal("bob@example.com", report.Commits[0].Author)
+
+	assert.Equal([]PickaxePeriod{
+		{Period: "2020-01", Commits: 1, Added: 3, Occurrences: 3},
+		{Period: "2020-02", Commits: 2, Removed: 2, Occurrences: 1},
+	}, report.Months)
+
+	_, err = PickaxeTrend(repo.Repository, "", "HEAD", "")
+	assert.NotNil(err)
+}
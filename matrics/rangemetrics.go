@@ -0,0 +1,236 @@
+package metrics
+
+import (
+	"time"
+
+	"github.com/andymeneely/git-churn/gitfuncs"
+	"github.com/andymeneely/git-churn/helper"
+	"gopkg.in/src-d/go-git.v4"
+	"gopkg.in/src-d/go-git.v4/plumbing"
+	"gopkg.in/src-d/go-git.v4/plumbing/object"
+	"gopkg.in/src-d/go-git.v4/plumbing/storer"
+)
+
+type CommitChurn struct {
+	Hash       string
+	Author     string
+	AuthorName string
+	When       time.Time
+	Message    string
+	Parents    int
+	Insertions int `units:"lines"`
+	Deletions  int `units:"lines"`
+	Files      []FileChurn
+
+	// Deleted lines that were added within the window of the ChurnRecent mode
+	RecentDeletions int `json:",omitempty" units:"lines"`
+	// Tokens of code added and deleted, counted only when CountTokens is set
+	TokenInsertions int `json:",omitempty"`
+	TokenDeletions  int `json:",omitempty"`
+	// Mass reformat, whose lines are weighted by Reformats
+	Reformat bool `json:",omitempty"`
+	// Directories moved as a whole, listed when gitfuncs.DetectDirMoves is set
+	Moves []gitfuncs.DirMove `json:",omitempty"`
+}
+
+// Churn returns the churn of the commit according to the churn definition, the lines added plus the lines
+// deleted by default
+func (c *CommitChurn) Churn() int {
+	return Churn.Lines(c.Insertions, c.Deletions, c.RecentDeletions)
+}
+
+// mergedBranch tells whether the commit merged a branch whose commits are counted on their own, its churn
+// against its first parent being theirs over again. With gitfuncs.FirstParent the commits of the branches
+// are not walked, and the merges stand for them.
+func (c *CommitChurn) mergedBranch() bool {
+	return c.Parents > 1 && !gitfuncs.FirstParent
+}
+
+// countedCommits returns the commits but the merges of branches whose commits are counted on their own, see
+// mergedBranch, the ones the metrics totalling the churn of a range count
+func countedCommits(commits []*CommitChurn) []*CommitChurn {
+	counted := make([]*CommitChurn, 0, len(commits))
+	for _, commit := range commits {
+		if !commit.mergedBranch() {
+			counted = append(counted, commit)
+		}
+	}
+	return counted
+}
+
+// GetCommitChurn computes the lines added and deleted per file by the commit against its first parent, the
+// paths being rewritten by PathRewrites, and the directories it moved when gitfuncs.DetectDirMoves is set
+func GetCommitChurn(repo *git.Repository, commit *object.Commit) (*CommitChurn, error) {
+	stats, err := gitfuncs.ActiveEngine.CommitStats(repo, commit)
+	if err != nil {
+		return nil, err
+	}
+	name, email := gitfuncs.ResolveAuthor(repo, commit.Author)
+	churn := &CommitChurn{
+		Hash:       commit.Hash.String(),
+		Author:     email,
+		AuthorName: name,
+		When:       commit.Author.When,
+		Message:    commit.Message,
+		Parents:    commit.NumParents(),
+		Files:      fileChurnFromStats(repo, stats),
+	}
+	var recent map[string]int
+	if Churn.Mode == ChurnRecent {
+		if recent, err = commitRecentDeletions(repo, commit, true); err != nil {
+			return nil, err
+		}
+	}
+	for i, file := range churn.Files {
+		churn.Files[i].RecentDeletions = recent[file.File]
+		churn.Insertions += file.Insertions
+		churn.Deletions += file.Deletions
+		churn.RecentDeletions += recent[file.File]
+	}
+	churn.Files = PathRewrites.rewriteFiles(churn.Files)
+	if err := setCommitTokenChanges(repo, commit, churn); err != nil {
+		return nil, err
+	}
+	if gitfuncs.DetectDirMoves {
+		if churn.Moves, err = gitfuncs.CommitDirMoves(commit); err != nil {
+			return nil, err
+		}
+	}
+	return churn, nil
+}
+
+// RangeChurn computes the churn of every commit reachable from `to` but not from `from` (git log from..to),
+// newest first, but for the commits of Bots, the churn of the reformats being weighted by Reformats. An
+// empty `from` covers the whole history leading to `to`.
+func RangeChurn(repo *git.Repository, from, to string) ([]*CommitChurn, error) {
+	defer helper.Duration(helper.Track("RangeChurn"))
+	fromHash, toHash, err := resolveRange(repo, from, to)
+	if err != nil {
+		return nil, err
+	}
+	if err := prefetchRange(repo, fromHash, toHash); err != nil {
+		return nil, err
+	}
+	commits, err := gitfuncs.CommitsBetween(repo, fromHash, toHash)
+	if err != nil {
+		return nil, err
+	}
+	churns := make([]*CommitChurn, 0, len(commits))
+	progress := helper.TrackProgress("commits", len(commits))
+	defer progress.Finish()
+	for _, commit := range commits {
+		churn, err := rangeCommitChurn(repo, commit)
+		if err != nil {
+			return nil, err
+		}
+		if churn != nil {
+			churns = append(churns, churn)
+		}
+		progress.Step()
+	}
+	return churns, nil
+}
+
+// ForEachCommitMetrics computes the churn of the commits of RangeChurn one at a time and passes it to fn,
+// so that the churn of huge histories can be processed without holding it all in memory. The commits come
+// in committer time order, newest first. Returning storer.ErrStop from fn stops the walk without error.
+func ForEachCommitMetrics(repo *git.Repository, from, to string, fn func(*CommitChurn) error) error {
+	defer helper.Duration(helper.Track("ForEachCommitMetrics"))
+	return forEachRangeCommit(repo, from, to, func(_ *object.Commit, churn *CommitChurn) error {
+		return fn(churn)
+	})
+}
+
+// forEachRangeCommit is ForEachCommitMetrics passing the commit along with its churn
+func forEachRangeCommit(repo *git.Repository, from, to string, fn func(*object.Commit, *CommitChurn) error) error {
+	fromHash, toHash, err := resolveRange(repo, from, to)
+	if err != nil {
+		return err
+	}
+	if err := prefetchRange(repo, fromHash, toHash); err != nil {
+		return err
+	}
+	return gitfuncs.ForEachCommitBetween(repo, fromHash, toHash, func(commit *object.Commit) error {
+		churn, err := rangeCommitChurn(repo, commit)
+		if err != nil || churn == nil {
+			return err
+		}
+		return fn(commit, churn)
+	})
+}
+
+// ChurnSince computes the churn of the commits leading to the revision that were committed after `since`,
+// newest first, leaving out the commits of Bots and weighting the reformats like RangeChurn
+func ChurnSince(repo *git.Repository, revision string, since time.Time) ([]*CommitChurn, error) {
+	defer helper.Duration(helper.Track("ChurnSince"))
+	hash, err := gitfuncs.ResolveRef(repo, revision)
+	if err != nil {
+		return nil, err
+	}
+	if err := gitfuncs.BatchStats(repo, plumbing.ZeroHash, *hash, since); err != nil {
+		return nil, err
+	}
+	var churns []*CommitChurn
+	err = forEachCommitSince(repo, revision, since, func(c *object.Commit) error {
+		churn, err := rangeCommitChurn(repo, c)
+		if err != nil || churn == nil {
+			return err
+		}
+		churns = append(churns, churn)
+		return nil
+	})
+	return churns, err
+}
+
+// forEachCommitSince passes the commits leading to the revision that were committed after `since` to fn,
+// newest first
+func forEachCommitSince(repo *git.Repository, revision string, since time.Time, fn func(*object.Commit) error) error {
+	hash, err := gitfuncs.ResolveRef(repo, revision)
+	if err != nil {
+		return err
+	}
+	commitIter, err := gitfuncs.LogCommits(repo, *hash)
+	if err != nil {
+		return err
+	}
+	// How far back `since` goes in commits is not known beforehand
+	progress := helper.TrackProgress("commits", 0)
+	defer progress.Finish()
+	return commitIter.ForEach(func(c *object.Commit) error {
+		if c.Committer.When.Before(since) {
+			return storer.ErrStop
+		}
+		defer progress.Step()
+		return fn(c)
+	})
+}
+
+// resolveRange resolves the revisions bounding a range. An empty `from` resolves to the zero hash.
+func resolveRange(repo *git.Repository, from, to string) (plumbing.Hash, plumbing.Hash, error) {
+	var fromHash plumbing.Hash
+	if from != "" {
+		hash, err := gitfuncs.ResolveRef(repo, from)
+		if err != nil {
+			return plumbing.ZeroHash, plumbing.ZeroHash, err
+		}
+		fromHash = *hash
+	}
+	toHash, err := gitfuncs.ResolveRef(repo, to)
+	if err != nil {
+		return plumbing.ZeroHash, plumbing.ZeroHash, err
+	}
+	return fromHash, *toHash, nil
+}
+
+// prefetchRange has the engine compute the stats of the commits of the range at once, and resolves the
+// objects the churn of the range reads into the object cache first when gitfuncs.Prefetch is set
+func prefetchRange(repo *git.Repository, from, to plumbing.Hash) error {
+	if err := gitfuncs.BatchStats(repo, from, to, time.Time{}); err != nil {
+		return err
+	}
+	if !gitfuncs.Prefetch {
+		return nil
+	}
+	_, err := gitfuncs.PrefetchRange(repo, from, to, true)
+	return err
+}
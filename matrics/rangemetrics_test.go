@@ -0,0 +1,190 @@
+package metrics
+
+import (
+	"testing"
+
+	"github.com/andymeneely/git-churn/gitfuncs"
+	"github.com/andymeneely/git-churn/helper"
+	"github.com/andymeneely/git-churn/testutil"
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/src-d/go-git.v4"
+	"gopkg.in/src-d/go-git.v4/plumbing/storer"
+)
+
+// linearRepo commits the successive contents of a.txt one day apart
+func linearRepo(t *testing.T, contents ...string) *git.Repository {
+	snapshots := make([]map[string]string, len(contents))
+	for i, content := range contents {
+		snapshots[i] = map[string]string{"a.txt": content}
+	}
+	return snapshotRepo(t, snapshots...)
+}
+
+// snapshotRepo commits the successive snapshots of files by path one day apart, leaving the last one
+// checked out. The files missing from a snapshot are kept as they were.
+func snapshotRepo(t *testing.T, snapshots ...map[string]string) *git.Repository {
+	commits := make([]testCommit, len(snapshots))
+	for i, files := range snapshots {
+		commits[i] = testCommit{author: "alice", message: "commit", files: files}
+	}
+	return historyRepo(t, commits...)
+}
+
+// testCommit is a commit of historyRepo, authored by author@example.com
+type testCommit struct {
+	author  string
+	message string
+	files   map[string]string
+}
+
+// historyRepo makes the commits one day apart like snapshotRepo
+func historyRepo(t *testing.T, commits ...testCommit) *git.Repository {
+	repo := testutil.NewRepo(t)
+	for _, commit := range commits {
+		repo.As(commit.author).CommitFiles(commit.message, commit.files)
+	}
+	return repo.Repository
+}
+
+func TestForEachCommitMetrics(t *testing.T) {
+	assert := assert.New(t)
+	repo := linearRepo(t, "1\n", "1\n2\n", "2\n3\n4\n")
+
+	var streamed []*CommitChurn
+	assert.Nil(ForEachCommitMetrics(repo, "", "HEAD", func(churn *CommitChurn) error {
+		streamed = append(streamed, churn)
+		return nil
+	}))
+	collected, err := RangeChurn(repo, "", "HEAD")
+	assert.Nil(err)
+	assert.Equal(collected, streamed)
+	assert.Equal(3, len(streamed))
+	assert.Equal(2, streamed[0].Insertions)
+	assert.Equal(1, streamed[0].Deletions)
+
+	count := 0
+	assert.Nil(ForEachCommitMetrics(repo, "HEAD~2", "HEAD", func(churn *CommitChurn) error {
+		count++
+		return storer.ErrStop
+	}))
+	assert.Equal(1, count)
+}
+
+func TestRangeChurnProgress(t *testing.T) {
+	assert := assert.New(t)
+	repo := linearRepo(t, "1\n", "1\n2\n", "2\n3\n4\n")
+	var reported []helper.Progress
+	helper.OnProgress = func(progress helper.Progress) { reported = append(reported, progress) }
+	defer func() { helper.OnProgress = nil }()
+
+	_, err := RangeChurn(repo, "HEAD~2", "HEAD")
+	assert.Nil(err)
+	assert.Equal(helper.Progress{Task: "commits", Total: 2}, reported[0])
+	assert.Equal(helper.Progress{Task: "commits", Done: 2, Total: 2, Finished: true}, reported[len(reported)-1])
+
+	reported = nil
+	assert.Nil(ForEachCommitMetrics(repo, "", "HEAD", func(*CommitChurn) error { return nil }))
+	assert.Equal(helper.Progress{Task: "commits", Done: 3, Total: 3, Finished: true}, reported[len(reported)-1])
+	assert.Len(reported, 5)
+}
+
+func TestRangeChurnIgnoresVendoredFiles(t *testing.T) {
+	assert := assert.New(t)
+	repo := snapshotRepo(t,
+		map[string]string{"main.go": "1\n", "vendor/lib/lib.go": "1\n2\n3\n"},
+		map[string]string{"main.go": "1\n2\n", "api.pb.go": "1\n2\n", ".churnignore": "!vendor/\n"},
+	)
+
+	commits, err := RangeChurn(repo, "", "HEAD")
+	assert.Nil(err)
+	assert.Equal([]FileChurn{{File: ".churnignore", Insertions: 1}, {File: "main.go", Insertions: 1}}, commits[0].Files)
+	assert.Equal([]FileChurn{{File: "main.go", Insertions: 1}, {File: "vendor/lib/lib.go", Insertions: 3}}, commits[1].Files)
+
+	snapshot, err := GetLOCSnapshot(repo, "HEAD", "", true, false)
+	assert.Nil(err)
+	assert.Equal(3, snapshot.Files)
+	assert.Equal(6, snapshot.LOC)
+}
+
+func TestRangeChurnPathScope(t *testing.T) {
+	assert := assert.New(t)
+	repo := snapshotRepo(t,
+		map[string]string{"main.go": "1\n", "api/api.go": "1\n", "api/vendor/lib.go": "1\n"},
+		map[string]string{"main.go": "1\n2\n"},
+		map[string]string{"api/api.go": "1\n2\n3\n"},
+	)
+
+	gitfuncs.SetPathScope("api")
+	defer gitfuncs.SetPathScope("")
+	commits, err := RangeChurn(repo, "", "HEAD")
+	assert.Nil(err)
+	assert.Len(commits, 2)
+	assert.Equal([]FileChurn{{File: "api/api.go", Insertions: 2}}, commits[0].Files)
+	assert.Equal([]FileChurn{{File: "api/api.go", Insertions: 1}}, commits[1].Files)
+
+	snapshot, err := GetLOCSnapshot(repo, "HEAD", "", true, false)
+	assert.Nil(err)
+	assert.Equal(1, snapshot.Files)
+	assert.Equal(3, snapshot.LOC)
+}
+
+func TestRangeChurnFirstParent(t *testing.T) {
+	assert := assert.New(t)
+	repo := testutil.NewRepo(t)
+	repo.CommitFiles("root", map[string]string{"a.txt": "a\n"})
+	repo.Branch("feature").CommitFiles("feature 1", map[string]string{"b.txt": "1\n"})
+	repo.CommitFiles("feature 2", map[string]string{"b.txt": "2\n3\n"})
+	repo.Checkout("master").CommitFiles("master", map[string]string{"a.txt": "a\nb\n"})
+	merge := repo.Merge("feature", "merge feature")
+
+	all, err := RangeChurn(repo.Repository, "", "HEAD")
+	assert.Nil(err)
+	assert.Equal(5, len(all))
+
+	gitfuncs.FirstParent = true
+	defer func() { gitfuncs.FirstParent = false }()
+	mainline, err := RangeChurn(repo.Repository, "", "HEAD")
+	assert.Nil(err)
+	assert.Equal([]string{"merge feature", "master", "root"}, commitMessages(mainline))
+	assert.Equal(merge.Hash.String(), mainline[0].Hash)
+	assert.Equal(2, mainline[0].Insertions)
+	assert.Equal(0, mainline[0].Deletions)
+
+	since, err := ChurnSince(repo.Repository, "HEAD", testutil.Start)
+	assert.Nil(err)
+	assert.Equal(commitMessages(mainline), commitMessages(since))
+
+	// The range excludes the whole history of `from`, the feature branch included
+	mainline, err = RangeChurn(repo.Repository, "feature", "HEAD")
+	assert.Nil(err)
+	assert.Equal([]string{"merge feature", "master"}, commitMessages(mainline))
+}
+
+func commitMessages(churns []*CommitChurn) []string {
+	var messages []string
+	for _, churn := range churns {
+		messages = append(messages, churn.Message)
+	}
+	return messages
+}
+
+func TestRangeChurnDirMoves(t *testing.T) {
+	assert := assert.New(t)
+	repo := testutil.NewRepo(t)
+	repo.CommitFiles("root", map[string]string{"src/a/x.go": "1\n2\n3\n", "src/a/y.go": "1\n2\n"})
+	repo.Rename("src/a/x.go", "src/b/x.go").Rename("src/a/y.go", "src/b/y.go").
+		WriteFiles(map[string]string{"src/b/x.go": "1\n2\nthree\n"}).Commit("move")
+
+	churns, err := RangeChurn(repo.Repository, "HEAD~1", "HEAD")
+	assert.Nil(err)
+	assert.Equal(5, churns[0].Insertions)
+	assert.Empty(churns[0].Moves)
+
+	gitfuncs.DetectDirMoves = true
+	defer func() { gitfuncs.DetectDirMoves = false }()
+	churns, err = RangeChurn(repo.Repository, "HEAD~1", "HEAD")
+	assert.Nil(err)
+	assert.Equal(1, churns[0].Insertions)
+	assert.Equal(1, churns[0].Deletions)
+	assert.Equal([]gitfuncs.DirMove{{From: "src/a", To: "src/b", Files: 2}}, churns[0].Moves)
+}
@@ -0,0 +1,116 @@
+package metrics
+
+import (
+	"math"
+
+	"github.com/andymeneely/git-churn/gitfuncs"
+	"gopkg.in/src-d/go-git.v4"
+	"gopkg.in/src-d/go-git.v4/plumbing"
+	"gopkg.in/src-d/go-git.v4/plumbing/object"
+)
+
+// Defaults of the reformat detection: a reformat changes hundreds of lines, barely any of their tokens
+const (
+	DefaultReformatMinLines      = 200
+	DefaultReformatMaxTokenShare = 0.05
+)
+
+// ReformatFilter tells the mass reformat commits, e.g. running a formatter over the code base, whose churn
+// says nothing of the work done: the commits listed in Revs or in the .git-blame-ignore-revs of the
+// repository, and the commits changing at least MinLines lines of which at most MaxTokenShare changed other
+// than in whitespace. Merges are no reformats. A nil ReformatFilter finds none.
+type ReformatFilter struct {
+	MinLines      int `units:"lines"`
+	MaxTokenShare float64
+	Revs          map[plumbing.Hash]bool
+	// Weight of the churn of the reformats in the range metrics, 0 leaving them out and 1 counting them
+	// like any other commit. The metrics that cannot weight a commit only leave them out at 0.
+	Weight float64
+}
+
+// Reformats are weighted in the range metrics, none being looked for by default
+var Reformats *ReformatFilter
+
+// NewReformatFilter returns a filter with the default thresholds weighting the reformats with weight
+func NewReformatFilter(weight float64) *ReformatFilter {
+	return &ReformatFilter{MinLines: DefaultReformatMinLines, MaxTokenShare: DefaultReformatMaxTokenShare, Weight: weight}
+}
+
+// IsReformat tells whether the commit is a reformat
+func (f *ReformatFilter) IsReformat(repo *git.Repository, commit *object.Commit) (bool, error) {
+	return f.isReformat(repo, commit, -1)
+}
+
+// Excludes tells whether the commit is a reformat left out of the metrics
+func (f *ReformatFilter) Excludes(repo *git.Repository, commit *object.Commit) (bool, error) {
+	if f == nil || f.Weight > 0 {
+		return false, nil
+	}
+	return f.IsReformat(repo, commit)
+}
+
+// isReformat tells whether the commit is a reformat, lines being the number of lines it changed when known,
+// -1 otherwise, so that the commits too small to be reformats are not diffed again
+func (f *ReformatFilter) isReformat(repo *git.Repository, commit *object.Commit, lines int) (bool, error) {
+	if f == nil || commit.NumParents() > 1 {
+		return false, nil
+	}
+	if f.Revs[commit.Hash] || gitfuncs.RepoIgnoreRevs(repo)[commit.Hash] {
+		return true, nil
+	}
+	if lines >= 0 && lines < f.MinLines {
+		return false, nil
+	}
+	lines, tokenLines, err := gitfuncs.CommitSpaceChanges(commit)
+	if err != nil {
+		return false, err
+	}
+	return lines >= f.MinLines && float64(tokenLines) <= f.MaxTokenShare*float64(lines), nil
+}
+
+// rangeCommitChurn computes the churn of the commit for the range metrics: nil for the commits of Bots,
+// those changing nothing within gitfuncs.PathScope and the reformats left out, the churn of the other
+// reformats being weighted by Reformats
+func rangeCommitChurn(repo *git.Repository, commit *object.Commit) (*CommitChurn, error) {
+	if Bots.IsBot(commit) {
+		return nil, nil
+	}
+	if match, err := Search.Matches(commit); err != nil || !match {
+		return nil, err
+	}
+	churn, err := GetCommitChurn(repo, commit)
+	if err != nil {
+		return nil, err
+	}
+	if gitfuncs.PathScope != "" && len(churn.Files) == 0 {
+		// Out of the scope, like git log -- <path>
+		return nil, nil
+	}
+	reformat, err := Reformats.isReformat(repo, commit, churn.Insertions+churn.Deletions)
+	if err != nil || !reformat {
+		return churn, err
+	}
+	if Reformats.Weight <= 0 {
+		return nil, nil
+	}
+	churn.Reformat = true
+	churn.weigh(Reformats.Weight)
+	return churn, nil
+}
+
+// weigh scales the lines changed by the commit and by each of its files, and the tokens changed
+func (c *CommitChurn) weigh(weight float64) {
+	scale := func(lines int) int {
+		return int(math.Round(float64(lines) * weight))
+	}
+	c.TokenInsertions, c.TokenDeletions = scale(c.TokenInsertions), scale(c.TokenDeletions)
+	c.Insertions, c.Deletions, c.RecentDeletions = 0, 0, 0
+	for i, file := range c.Files {
+		file.Insertions, file.Deletions, file.RecentDeletions =
+			scale(file.Insertions), scale(file.Deletions), scale(file.RecentDeletions)
+		c.Files[i] = file
+		c.Insertions += file.Insertions
+		c.Deletions += file.Deletions
+		c.RecentDeletions += file.RecentDeletions
+	}
+}
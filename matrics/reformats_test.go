@@ -0,0 +1,55 @@
+package metrics
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/andymeneely/git-churn/testutil"
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/src-d/go-git.v4/plumbing"
+)
+
+func TestReformats(t *testing.T) {
+	assert := assert.New(t)
+	code := strings.Repeat("if x {\nreturn y\n}\n", 100)
+	repo := testutil.NewRepo(t)
+	add := repo.CommitFiles("add", map[string]string{"a.go": code})
+	reformat := repo.CommitFiles("gofmt", map[string]string{"a.go": strings.Replace(code, "return", "\treturn", -1)})
+	change := repo.CommitFiles("change", map[string]string{"a.go": strings.Replace(code, "return y", "\treturn z", -1)})
+	listed := repo.CommitFiles("small", map[string]string{"a.go": strings.Replace(code, "return y", "\treturn z", 99) + "\n"})
+	defer func() { Reformats = nil }()
+
+	commits, err := RangeChurn(repo.Repository, "", "HEAD")
+	assert.Nil(err)
+	assert.Equal(4, len(commits))
+	assert.Equal(200, commits[2].Insertions+commits[2].Deletions)
+	assert.False(commits[2].Reformat)
+
+	Reformats = NewReformatFilter(0.5)
+	Reformats.Revs = map[plumbing.Hash]bool{listed.Hash: true}
+	for commit, expected := range map[plumbing.Hash]bool{reformat.Hash: true, change.Hash: false, listed.Hash: true} {
+		c, err := repo.CommitObject(commit)
+		assert.Nil(err)
+		isReformat, err := Reformats.IsReformat(repo.Repository, c)
+		assert.Nil(err)
+		assert.Equal(expected, isReformat, commit.String())
+	}
+	commits, err = RangeChurn(repo.Repository, "", "HEAD")
+	assert.Nil(err)
+	assert.Equal(4, len(commits))
+	assert.True(commits[2].Reformat)
+	assert.Equal(50, commits[2].Insertions)
+	assert.Equal(50, commits[2].Files[0].Deletions)
+	assert.False(commits[1].Reformat)
+	assert.Equal(200, commits[1].Insertions+commits[1].Deletions)
+
+	Reformats.Weight = 0
+	commits, err = RangeChurn(repo.Repository, "", "HEAD")
+	assert.Nil(err)
+	assert.Equal(2, len(commits))
+	assert.Equal(change.Hash.String(), commits[0].Hash)
+	assert.Equal(add.Hash.String(), commits[1].Hash)
+	report, err := ReworkRate(repo.Repository, "", "HEAD", 0)
+	assert.Nil(err)
+	assert.Equal(2, report.Commits)
+}
@@ -0,0 +1,176 @@
+package metrics
+
+import (
+	"errors"
+	"sort"
+
+	"github.com/andymeneely/git-churn/gitfuncs"
+	"github.com/andymeneely/git-churn/helper"
+	"gopkg.in/src-d/go-git.v4"
+)
+
+type AuthorChurn struct {
+	Author     string
+	Commits    int
+	Insertions int `units:"lines"`
+	Deletions  int `units:"lines"`
+	// Churn according to the churn definition
+	Churn int `units:"lines"`
+}
+
+type RangeSummary struct {
+	Commits      int
+	Insertions   int `units:"lines"`
+	Deletions    int `units:"lines"`
+	FilesChanged int
+	// Churn according to the churn definition
+	Churn int `units:"lines"`
+	// Most churned files first
+	TopFiles []FileChurn
+	// Most churning authors first
+	Authors []AuthorChurn
+	// Lines changed in the test and in the production files, told apart by TestFiles
+	TestChurn LineChurn
+	ProdChurn LineChurn
+}
+
+type ReleaseReport struct {
+	From       string
+	To         string
+	FromCommit string
+	ToCommit   string
+	RangeSummary
+}
+
+// SummarizeRange totals the churn of the given commits and breaks it down by file and author.
+// Only the topN most churned files are kept, all of them if topN is zero.
+func SummarizeRange(commits []*CommitChurn, topN int) RangeSummary {
+	commits = countedCommits(commits)
+	summary := RangeSummary{Commits: len(commits)}
+	files := make(map[string]*FileChurn)
+	authors := make(map[string]*AuthorChurn)
+	for _, commit := range commits {
+		summary.Insertions += commit.Insertions
+		summary.Deletions += commit.Deletions
+		summary.Churn += commit.Churn()
+		author, ok := authors[commit.Author]
+		if !ok {
+			author = &AuthorChurn{Author: commit.Author}
+			authors[commit.Author] = author
+		}
+		author.Commits += 1
+		author.Insertions += commit.Insertions
+		author.Deletions += commit.Deletions
+		author.Churn += commit.Churn()
+		for _, fileChurn := range commit.Files {
+			split := &summary.ProdChurn
+			if TestFiles.IsTest(fileChurn.File) {
+				split = &summary.TestChurn
+			}
+			split.Insertions += fileChurn.Insertions
+			split.Deletions += fileChurn.Deletions
+			file, ok := files[fileChurn.File]
+			if !ok {
+				file = &FileChurn{File: fileChurn.File}
+				files[fileChurn.File] = file
+			}
+			file.Insertions += fileChurn.Insertions
+			file.Deletions += fileChurn.Deletions
+			file.RecentDeletions += fileChurn.RecentDeletions
+		}
+	}
+
+	summary.FilesChanged = len(files)
+	for _, file := range files {
+		summary.TopFiles = append(summary.TopFiles, *file)
+	}
+	sort.Slice(summary.TopFiles, func(i, j int) bool {
+		a, b := summary.TopFiles[i], summary.TopFiles[j]
+		if a.Churn() != b.Churn() {
+			return a.Churn() > b.Churn()
+		}
+		return a.File < b.File
+	})
+	if topN > 0 && len(summary.TopFiles) > topN {
+		summary.TopFiles = summary.TopFiles[:topN]
+	}
+
+	for _, author := range authors {
+		summary.Authors = append(summary.Authors, *author)
+	}
+	sort.Slice(summary.Authors, func(i, j int) bool {
+		a, b := summary.Authors[i], summary.Authors[j]
+		if a.Churn != b.Churn {
+			return a.Churn > b.Churn
+		}
+		return a.Author < b.Author
+	})
+	return summary
+}
+
+// GetReleaseReport summarizes the churn between two tags: totals, the topN most changed files and the
+// churn per author. When both tags are empty, the two latest semantic version tags are compared.
+func GetReleaseReport(repo *git.Repository, fromTag, toTag string, topN int) (*ReleaseReport, error) {
+	defer helper.Duration(helper.Track("GetReleaseReport"))
+	if fromTag == "" && toTag == "" {
+		var err error
+		fromTag, toTag, err = LatestReleaseTags(repo)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if fromTag == "" || toTag == "" {
+		return nil, errors.New("both tags of the release have to be given, or none of them")
+	}
+	fromHash, toHash, err := resolveRange(repo, fromTag, toTag)
+	if err != nil {
+		return nil, err
+	}
+	commits, err := RangeChurn(repo, fromTag, toTag)
+	if err != nil {
+		return nil, err
+	}
+	return &ReleaseReport{
+		From:         fromTag,
+		To:           toTag,
+		FromCommit:   fromHash.String(),
+		ToCommit:     toHash.String(),
+		RangeSummary: SummarizeRange(commits, topN),
+	}, nil
+}
+
+// SemverTags returns the names of the tags that are semantic versions, oldest version first
+func SemverTags(repo *git.Repository) ([]string, error) {
+	tags, err := gitfuncs.ListTags(repo)
+	if err != nil {
+		return nil, err
+	}
+	type versionTag struct {
+		name    string
+		version helper.Semver
+	}
+	var versions []versionTag
+	for _, tag := range tags {
+		if version, ok := helper.ParseSemver(tag.Name().Short()); ok {
+			versions = append(versions, versionTag{tag.Name().Short(), version})
+		}
+	}
+	sort.SliceStable(versions, func(i, j int) bool { return versions[i].version.Less(versions[j].version) })
+	names := make([]string, len(versions))
+	for i, version := range versions {
+		names[i] = version.name
+	}
+	return names, nil
+}
+
+// LatestReleaseTags returns the two latest semantic version tags of the repository
+func LatestReleaseTags(repo *git.Repository) (string, string, error) {
+	tags, err := SemverTags(repo)
+	if err != nil {
+		return "", "", err
+	}
+	if len(tags) < 2 {
+		return "", "", errors.New("at least two semantic version tags are needed to detect the latest release")
+	}
+	return tags[len(tags)-2], tags[len(tags)-1], nil
+}
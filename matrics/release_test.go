@@ -0,0 +1,34 @@
+package metrics
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestSummarizeRange(t *testing.T) {
+	commits := []*CommitChurn{
+		{Author: "alice@example.com", Insertions: 5, Deletions: 1, Files: []FileChurn{{File: "a.go", Insertions: 4, Deletions: 1}, {File: "b.go", Insertions: 1, Deletions: 0}}},
+		{Author: "bob@example.com", Insertions: 2, Deletions: 2, Files: []FileChurn{{File: "b.go", Insertions: 2, Deletions: 2}}},
+		{Author: "alice@example.com", Insertions: 1, Deletions: 0, Files: []FileChurn{{File: "c.go", Insertions: 1, Deletions: 0}}},
+	}
+	summary := SummarizeRange(commits, 2)
+	assert := assert.New(t)
+	assert.Equal(3, summary.Commits)
+	assert.Equal(8, summary.Insertions)
+	assert.Equal(3, summary.Deletions)
+	assert.Equal(3, summary.FilesChanged)
+	assert.Equal(11, summary.Churn)
+	assert.Equal([]FileChurn{{File: "a.go", Insertions: 4, Deletions: 1}, {File: "b.go", Insertions: 3, Deletions: 2}}, summary.TopFiles)
+	assert.Equal([]AuthorChurn{{"alice@example.com", 2, 6, 1, 7}, {"bob@example.com", 1, 2, 2, 4}}, summary.Authors)
+}
+
+func TestSummarizeRangeTestChurn(t *testing.T) {
+	commits := []*CommitChurn{
+		{Author: "alice@example.com", Insertions: 9, Deletions: 1, Files: []FileChurn{{File: "a.go", Insertions: 4, Deletions: 1}, {File: "a_test.go", Insertions: 5, Deletions: 0}}},
+		{Author: "bob@example.com", Insertions: 3, Deletions: 2, Files: []FileChurn{{File: "test/fixture.json", Insertions: 3, Deletions: 2}}},
+	}
+	summary := SummarizeRange(commits, 0)
+	assert := assert.New(t)
+	assert.Equal(LineChurn{8, 2}, summary.TestChurn)
+	assert.Equal(LineChurn{4, 1}, summary.ProdChurn)
+}
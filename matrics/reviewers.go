@@ -0,0 +1,215 @@
+package metrics
+
+import (
+	"sort"
+	"time"
+
+	"github.com/andymeneely/git-churn/gitfuncs"
+	"github.com/andymeneely/git-churn/helper"
+	"gopkg.in/src-d/go-git.v4"
+	"gopkg.in/src-d/go-git.v4/plumbing/object"
+	"gopkg.in/src-d/go-git.v4/plumbing/storer"
+)
+
+// Weights of the ownership and the recent activity in the reviewer score
+const (
+	ownershipWeight = 0.6
+	activityWeight  = 0.4
+)
+
+type ReviewerOptions struct {
+	// Recent activity is counted over this window before the merge base
+	ActivityWindow time.Duration
+	// Open review suggestions per reviewer email, each one lowers the score of the reviewer
+	Load map[string]int
+	// Maximum number of suggestions, all of them if zero
+	Max int
+}
+
+type ReviewerSuggestion struct {
+	Reviewer string
+	Score    float64
+	// Touched lines last written by the reviewer
+	OwnedLines int `units:"lines"`
+	// Commits by the reviewer touching the changed files within the activity window
+	RecentCommits int
+	Load          int
+}
+
+type ReviewerSuggestions struct {
+	Base         string
+	Head         string
+	MergeBase    string
+	ChangedFiles []string
+	TouchedLines int `units:"lines"`
+	// Authors of the changeset, never suggested as reviewers
+	Authors     []string
+	Suggestions []ReviewerSuggestion
+}
+
+// SuggestReviewers suggests reviewers for the changeset of head against base (e.g. a pull request or a
+// local branch) by combining the ownership of the lines it touches, the recent activity on the files it
+// changes and the current review load of every candidate
+func SuggestReviewers(repo *git.Repository, base, head string, options ReviewerOptions) (*ReviewerSuggestions, error) {
+	defer helper.Duration(helper.Track("SuggestReviewers"))
+	baseHash, err := gitfuncs.ResolveRef(repo, base)
+	if err != nil {
+		return nil, err
+	}
+	headHash, err := gitfuncs.ResolveRef(repo, head)
+	if err != nil {
+		return nil, err
+	}
+	mergeBase, err := gitfuncs.MergeBase(repo, *baseHash, *headHash)
+	if err != nil {
+		return nil, err
+	}
+	headCommit, err := repo.CommitObject(*headHash)
+	if err != nil {
+		return nil, err
+	}
+	suggestions := &ReviewerSuggestions{Base: baseHash.String(), Head: headHash.String(), MergeBase: mergeBase.Hash.String()}
+
+	changeset, err := gitfuncs.CommitsBetween(repo, mergeBase.Hash, *headHash)
+	if err != nil {
+		return nil, err
+	}
+	authors := make(map[string]bool)
+	for _, commit := range changeset {
+		_, email := gitfuncs.ResolveAuthor(repo, commit.Author)
+		authors[email] = true
+	}
+	for author := range authors {
+		suggestions.Authors = append(suggestions.Authors, author)
+	}
+	sort.Strings(suggestions.Authors)
+
+	baseTree, err := mergeBase.Tree()
+	if err != nil {
+		return nil, err
+	}
+	headTree, err := headCommit.Tree()
+	if err != nil {
+		return nil, err
+	}
+	stats, err := gitfuncs.TreeDiffStats(baseTree, headTree)
+	if err != nil {
+		return nil, err
+	}
+	changedFiles := make(map[string]bool)
+	for _, stat := range stats {
+		changedFiles[stat.Name] = true
+		suggestions.ChangedFiles = append(suggestions.ChangedFiles, stat.Name)
+	}
+	sort.Strings(suggestions.ChangedFiles)
+
+	// Ownership of the lines the changeset modifies or deletes, as of the merge base
+	touchedLines, err := gitfuncs.DeletedLinesBetween(baseTree, headTree, false)
+	if err != nil {
+		return nil, err
+	}
+	ownedLines := make(map[string]int)
+	for filePath, lines := range touchedLines {
+		if len(lines) == 0 {
+			continue
+		}
+		owners, err := LineOwnership(repo, mergeBase.Hash, filePath, lines)
+		if err != nil {
+			// New files have no previous owners
+			continue
+		}
+		for owner, count := range owners {
+			ownedLines[owner] += count
+		}
+		suggestions.TouchedLines += len(lines)
+	}
+
+	recentCommits, err := recentActivity(repo, mergeBase, changedFiles, options.ActivityWindow)
+	if err != nil {
+		return nil, err
+	}
+
+	candidates := make(map[string]bool)
+	for candidate := range ownedLines {
+		candidates[candidate] = true
+	}
+	for candidate := range recentCommits {
+		candidates[candidate] = true
+	}
+	totalRecent := 0
+	for _, count := range recentCommits {
+		totalRecent += count
+	}
+	for candidate := range candidates {
+		if authors[candidate] {
+			continue
+		}
+		suggestion := ReviewerSuggestion{
+			Reviewer:      candidate,
+			OwnedLines:    ownedLines[candidate],
+			RecentCommits: recentCommits[candidate],
+			Load:          options.Load[candidate],
+		}
+		suggestion.Score = reviewerScore(suggestion, suggestions.TouchedLines, totalRecent)
+		suggestions.Suggestions = append(suggestions.Suggestions, suggestion)
+	}
+	sort.Slice(suggestions.Suggestions, func(i, j int) bool {
+		a, b := suggestions.Suggestions[i], suggestions.Suggestions[j]
+		if a.Score != b.Score {
+			return a.Score > b.Score
+		}
+		return a.Reviewer < b.Reviewer
+	})
+	if options.Max > 0 && len(suggestions.Suggestions) > options.Max {
+		suggestions.Suggestions = suggestions.Suggestions[:options.Max]
+	}
+	return suggestions, nil
+}
+
+// reviewerScore combines the share of the touched lines owned by the reviewer and the share of the recent
+// commits on the changed files made by the reviewer, and divides it by the review load of the reviewer
+func reviewerScore(suggestion ReviewerSuggestion, touchedLines, recentCommits int) float64 {
+	score := 0.0
+	if touchedLines > 0 {
+		score += ownershipWeight * float64(suggestion.OwnedLines) / float64(touchedLines)
+	}
+	if recentCommits > 0 {
+		score += activityWeight * float64(suggestion.RecentCommits) / float64(recentCommits)
+	}
+	return score / float64(1+suggestion.Load)
+}
+
+// recentActivity counts per author the commits touching any of the given files within the window
+// preceding the given commit, the ignored files and the ones out of gitfuncs.PathScope aside
+func recentActivity(repo *git.Repository, since *object.Commit, files map[string]bool, window time.Duration) (map[string]int, error) {
+	activity := make(map[string]int)
+	if window <= 0 {
+		return activity, nil
+	}
+	oldest := since.Committer.When.Add(-window)
+	commitIter, err := repo.Log(&git.LogOptions{From: since.Hash, Order: git.LogOrderCommitterTime})
+	if err != nil {
+		return nil, err
+	}
+	err = commitIter.ForEach(func(c *object.Commit) error {
+		if c.Committer.When.Before(oldest) {
+			return storer.ErrStop
+		}
+		if Bots.IsBot(c) {
+			return nil
+		}
+		stats, err := gitfuncs.ActiveEngine.CommitStats(repo, c)
+		if err != nil {
+			return err
+		}
+		for _, file := range fileChurnFromStats(repo, stats) {
+			if files[file.File] {
+				_, email := gitfuncs.ResolveAuthor(repo, c.Author)
+				activity[email] += 1
+				break
+			}
+		}
+		return nil
+	})
+	return activity, err
+}
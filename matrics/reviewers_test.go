@@ -0,0 +1,95 @@
+package metrics
+
+import (
+	"testing"
+	"time"
+
+	"github.com/andymeneely/git-churn/gitfuncs"
+	"github.com/andymeneely/git-churn/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReviewerScore(t *testing.T) {
+	assert := assert.New(t)
+	owner := ReviewerSuggestion{Reviewer: "alice@example.com", OwnedLines: 10, RecentCommits: 1}
+	assert.InDelta(0.6*10/10+0.4*1/4, reviewerScore(owner, 10, 4), 1e-9)
+
+	owner.Load = 1
+	assert.InDelta((0.6*10/10+0.4*1/4)/2, reviewerScore(owner, 10, 4), 1e-9)
+
+	assert.Equal(0.0, reviewerScore(ReviewerSuggestion{}, 0, 0))
+}
+
+func TestSuggestReviewers(t *testing.T) {
+	assert := assert.New(t)
+	repo := testutil.NewRepo(t)
+	repo.CommitFiles("add a", map[string]string{"a.txt": "1\n2\n3\n4\n"})
+	repo.As("bob").CommitFiles("add b", map[string]string{"a.txt": "1\n2\n3\nfour\n", "b.txt": "x\ny\n"})
+	repo.As("carol").CommitFiles("add c", map[string]string{"c.txt": "c\n"})
+	mergeBase := repo.As("dave").CommitFiles("edit b", map[string]string{"b.txt": "x\nY\n"})
+	repo.Branch("feature").As("erin").CommitFiles("edit a", map[string]string{"a.txt": "one\n2\n3\nFOUR\n"})
+	repo.As("alice").CommitFiles("edit b, add d", map[string]string{"b.txt": "X\nY\n", "d.txt": "d\n"})
+	repo.Checkout("master")
+
+	// The window reaches back to the commit of bob, not to the first one
+	options := ReviewerOptions{ActivityWindow: 60 * time.Hour}
+	suggestions, err := SuggestReviewers(repo.Repository, "master", "feature", options)
+	assert.Nil(err)
+	assert.Equal(mergeBase.Hash.String(), suggestions.MergeBase)
+	assert.Equal([]string{"a.txt", "b.txt", "d.txt"}, suggestions.ChangedFiles)
+	// Lines 1 and 4 of a.txt, and line 1 of b.txt
+	assert.Equal(3, suggestions.TouchedLines)
+	assert.Equal([]string{"alice@example.com", "erin@example.com"}, suggestions.Authors)
+	// alice owns line 1 of a.txt but authored the changeset, carol did not touch the changed files
+	assert.Len(suggestions.Suggestions, 2)
+	bob, dave := suggestions.Suggestions[0], suggestions.Suggestions[1]
+	assert.Equal("bob@example.com", bob.Reviewer)
+	assert.Equal(2, bob.OwnedLines)
+	assert.Equal(1, bob.RecentCommits)
+	assert.InDelta(0.6*2/3+0.4*1/2, bob.Score, 1e-9)
+	assert.Equal("dave@example.com", dave.Reviewer)
+	assert.Equal(0, dave.OwnedLines)
+	assert.Equal(1, dave.RecentCommits)
+	assert.InDelta(0.4*1/2, dave.Score, 1e-9)
+
+	// Loaded with reviews, bob comes after dave
+	options.Load = map[string]int{"bob@example.com": 5}
+	suggestions, err = SuggestReviewers(repo.Repository, "master", "feature", options)
+	assert.Nil(err)
+	assert.Equal("dave@example.com", suggestions.Suggestions[0].Reviewer)
+	assert.Equal(5, suggestions.Suggestions[1].Load)
+	options.Max = 1
+	suggestions, err = SuggestReviewers(repo.Repository, "master", "feature", options)
+	assert.Nil(err)
+	assert.Len(suggestions.Suggestions, 1)
+
+	// Without recent activity, the owners only
+	suggestions, err = SuggestReviewers(repo.Repository, "master", "feature", ReviewerOptions{})
+	assert.Nil(err)
+	assert.Len(suggestions.Suggestions, 1)
+	assert.Equal("bob@example.com", suggestions.Suggestions[0].Reviewer)
+	assert.InDelta(0.6*2/3, suggestions.Suggestions[0].Score, 1e-9)
+
+	_, err = SuggestReviewers(repo.Repository, "master", "nothing", options)
+	assert.NotNil(err)
+}
+
+func TestRecentActivityPathScope(t *testing.T) {
+	assert := assert.New(t)
+	repo := testutil.NewRepo(t)
+	repo.CommitFiles("add", map[string]string{"api/a.go": "a\n", "docs/a.md": "a\n"})
+	repo.As("bob").CommitFiles("edit docs", map[string]string{"docs/a.md": "b\n"})
+	head := repo.As("carol").CommitFiles("edit api", map[string]string{"api/a.go": "c\n"})
+	files := map[string]bool{"api/a.go": true, "docs/a.md": true}
+
+	activity, err := recentActivity(repo.Repository, head, files, 30*24*time.Hour)
+	assert.Nil(err)
+	assert.Equal(map[string]int{"alice@example.com": 1, "bob@example.com": 1, "carol@example.com": 1}, activity)
+
+	// The commits only changing files out of the scope are no activity on the changed files
+	gitfuncs.SetPathScope("api")
+	defer gitfuncs.SetPathScope("")
+	activity, err = recentActivity(repo.Repository, head, files, 30*24*time.Hour)
+	assert.Nil(err)
+	assert.Equal(map[string]int{"alice@example.com": 1, "carol@example.com": 1}, activity)
+}
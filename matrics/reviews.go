@@ -0,0 +1,244 @@
+package metrics
+
+import (
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/andymeneely/git-churn/gitfuncs"
+	"github.com/andymeneely/git-churn/helper"
+	"gopkg.in/src-d/go-git.v4"
+	"gopkg.in/src-d/go-git.v4/plumbing"
+	"gopkg.in/src-d/go-git.v4/plumbing/object"
+)
+
+// ReviewerChurn is the churn a reviewer reviewed over a range
+type ReviewerChurn struct {
+	Reviewer string
+	Name     string
+	Commits  int
+	// Churn of the commits reviewed according to the churn definition
+	Churn int `units:"lines"`
+	// Median time from the authoring of the commits reviewed to their landing
+	MedianLatencyHours float64
+}
+
+// UnreviewedCommit is a commit that landed without review
+type UnreviewedCommit struct {
+	Hash    string
+	Author  string
+	When    time.Time
+	Subject string
+	Churn   int `units:"lines"`
+}
+
+// ReviewReport is the share of the churn of a range that was reviewed, the churn every reviewer reviewed
+// and how long the reviewed commits took to land
+type ReviewReport struct {
+	Commits            int
+	ReviewedCommits    int
+	Churn              int `units:"lines"`
+	ReviewedChurn      int `units:"lines"`
+	UnreviewedChurn    int `units:"lines"`
+	UnreviewedShare    float64
+	MedianLatencyHours float64
+	// Spearman rank correlation between the churn and the latency of the reviewed commits, 0 when undefined
+	LatencyCorrelation float64
+	// Most churn reviewed first
+	Reviewers []ReviewerChurn
+	// Newest first
+	Unreviewed []UnreviewedCommit
+}
+
+// reviewNode is a commit of the range, as needed to find how it landed
+type reviewNode struct {
+	parents    []plumbing.Hash
+	author     string
+	authorName string
+	committed  time.Time
+	message    string
+	churn      *CommitChurn
+	// Merge of the mainline that brought the commit in, nil for the commits of the mainline
+	landedBy *reviewNode
+}
+
+// ReviewCoverage reports which commits of the range were reviewed before landing and the churn every
+// reviewer reviewed. A commit is reviewed by the people of its Reviewed-by trailers, of its Signed-off-by
+// trailers but its author, and, when it landed through a merge of the mainline (the first parents of `to`),
+// by the author of the merge and the people of its Reviewed-by trailers. Nobody reviews their own commits.
+// The latency of a reviewed commit runs from its authoring to the commit of the merge it landed through, or
+// to its own commit otherwise. The commits of Bots and the commits not matching Search are left out, and
+// so are the merges but with gitfuncs.FirstParent, when they stand for the branches they merge.
+func ReviewCoverage(repo *git.Repository, from, to string) (*ReviewReport, error) {
+	defer helper.Duration(helper.Track("ReviewCoverage"))
+	fromHash, toHash, err := resolveRange(repo, from, to)
+	if err != nil {
+		return nil, err
+	}
+	nodes := make(map[plumbing.Hash]*reviewNode)
+	var order []plumbing.Hash
+	err = gitfuncs.ForEachCommitBetween(repo, fromHash, toHash, func(commit *object.Commit) error {
+		churn, err := rangeCommitChurn(repo, commit)
+		if err != nil {
+			return err
+		}
+		name, author := gitfuncs.ResolveAuthor(repo, commit.Author)
+		nodes[commit.Hash] = &reviewNode{
+			parents:    commit.ParentHashes,
+			author:     author,
+			authorName: name,
+			committed:  commit.Committer.When,
+			message:    commit.Message,
+			churn:      churn,
+		}
+		order = append(order, commit.Hash)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	landMergedCommits(nodes, toHash)
+
+	report := &ReviewReport{Reviewers: []ReviewerChurn{}, Unreviewed: []UnreviewedCommit{}}
+	byReviewer := make(map[string]*ReviewerChurn)
+	reviewerLatencies := make(map[string][]float64)
+	var churns, latencies []float64
+	for _, hash := range order {
+		node := nodes[hash]
+		if node.churn == nil || (node.churn.Parents > 1 && !gitfuncs.FirstParent) {
+			continue
+		}
+		churn := node.churn.Churn()
+		report.Commits += 1
+		report.Churn += churn
+		reviewers := commitReviewers(repo, node)
+		if len(reviewers) == 0 {
+			report.UnreviewedChurn += churn
+			report.Unreviewed = append(report.Unreviewed, UnreviewedCommit{
+				Hash:    node.churn.Hash,
+				Author:  node.author,
+				When:    node.churn.When,
+				Subject: strings.SplitN(strings.TrimSpace(node.message), "\n", 2)[0],
+				Churn:   churn,
+			})
+			continue
+		}
+		landed := node.committed
+		if node.landedBy != nil {
+			landed = node.landedBy.committed
+		}
+		latency := landed.Sub(node.churn.When).Hours()
+		if latency < 0 {
+			latency = 0
+		}
+		report.ReviewedCommits += 1
+		report.ReviewedChurn += churn
+		churns = append(churns, float64(churn))
+		latencies = append(latencies, latency)
+		for email, name := range reviewers {
+			reviewer, ok := byReviewer[email]
+			if !ok {
+				reviewer = &ReviewerChurn{Reviewer: email, Name: name}
+				byReviewer[email] = reviewer
+			}
+			reviewer.Commits += 1
+			reviewer.Churn += churn
+			reviewerLatencies[email] = append(reviewerLatencies[email], latency)
+		}
+	}
+	if report.Churn > 0 {
+		report.UnreviewedShare = float64(report.UnreviewedChurn) / float64(report.Churn)
+	}
+	report.MedianLatencyHours = median(latencies)
+	report.LatencyCorrelation = pearson(ranks(churns), ranks(latencies))
+	for email, reviewer := range byReviewer {
+		reviewer.MedianLatencyHours = median(reviewerLatencies[email])
+		report.Reviewers = append(report.Reviewers, *reviewer)
+	}
+	sort.Slice(report.Reviewers, func(i, j int) bool {
+		if report.Reviewers[i].Churn != report.Reviewers[j].Churn {
+			return report.Reviewers[i].Churn > report.Reviewers[j].Churn
+		}
+		return report.Reviewers[i].Reviewer < report.Reviewers[j].Reviewer
+	})
+	return report, nil
+}
+
+// landMergedCommits sets the merge of the mainline of `to` that brought in every commit off the mainline,
+// going up the mainline from its oldest commit in the range so that every commit is walked once
+func landMergedCommits(nodes map[plumbing.Hash]*reviewNode, to plumbing.Hash) {
+	var mainline []*reviewNode
+	for hash := to; ; {
+		node, ok := nodes[hash]
+		if !ok {
+			break
+		}
+		mainline = append(mainline, node)
+		if len(node.parents) == 0 {
+			break
+		}
+		hash = node.parents[0]
+	}
+	landed := make(map[*reviewNode]bool, len(nodes))
+	for _, node := range mainline {
+		landed[node] = true
+	}
+	for i := len(mainline) - 1; i >= 0; i-- {
+		merge := mainline[i]
+		if len(merge.parents) < 2 {
+			continue
+		}
+		pending := append([]plumbing.Hash(nil), merge.parents[1:]...)
+		for len(pending) > 0 {
+			node, ok := nodes[pending[len(pending)-1]]
+			pending = pending[:len(pending)-1]
+			if !ok || landed[node] {
+				continue
+			}
+			landed[node] = true
+			node.landedBy = merge
+			pending = append(pending, node.parents...)
+		}
+	}
+}
+
+// commitReviewers returns the names of the reviewers of the commit by email, or by name for the reviewers
+// known by name only
+func commitReviewers(repo *git.Repository, node *reviewNode) map[string]string {
+	reviewers := make(map[string]string)
+	add := func(name, email string) {
+		name, email = gitfuncs.RepoMailmap(repo).Resolve(name, email)
+		if email == "" {
+			email = name
+		}
+		if email != "" && !strings.EqualFold(email, node.author) {
+			reviewers[email] = name
+		}
+	}
+	for _, trailer := range gitfuncs.CommitTrailers(node.message) {
+		if strings.EqualFold(trailer.Key, "Reviewed-by") || strings.EqualFold(trailer.Key, "Signed-off-by") {
+			add(gitfuncs.ParseIdentity(trailer.Value))
+		}
+	}
+	if merge := node.landedBy; merge != nil {
+		add(merge.authorName, merge.author)
+		for _, value := range gitfuncs.TrailerValues(merge.message, "Reviewed-by") {
+			add(gitfuncs.ParseIdentity(value))
+		}
+	}
+	return reviewers
+}
+
+// median returns the median of the values, 0 when there are none
+func median(values []float64) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+	sorted := append([]float64(nil), values...)
+	sort.Float64s(sorted)
+	middle := len(sorted) / 2
+	if len(sorted)%2 == 0 {
+		return (sorted[middle-1] + sorted[middle]) / 2
+	}
+	return sorted[middle]
+}
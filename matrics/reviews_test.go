@@ -0,0 +1,39 @@
+package metrics
+
+import (
+	"testing"
+
+	"github.com/andymeneely/git-churn/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReviewCoverage(t *testing.T) {
+	assert := assert.New(t)
+	repo := testutil.NewRepo(t)
+	repo.As("alice").CommitFiles("Add a\n", map[string]string{"a.txt": "1\n"})
+	repo.As("bob").CommitFiles("Add b\n\nSigned-off-by: Bob <bob@example.com>\nReviewed-by: Carol <carol@example.com>\n",
+		map[string]string{"b.txt": "1\n2\n"})
+	repo.Branch("feature").As("bob").CommitFiles("Add c\n", map[string]string{"c.txt": "1\n2\n3\n"})
+	repo.Checkout("master").As("alice").Merge("feature", "Merge feature\n")
+	repo.As("alice").CommitFiles("Add d\n\nSigned-off-by: Alice <alice@example.com>\n", map[string]string{"d.txt": "1\n"})
+
+	report, err := ReviewCoverage(repo.Repository, "", "HEAD")
+	assert.Nil(err)
+	assert.Equal(4, report.Commits)
+	assert.Equal(2, report.ReviewedCommits)
+	assert.Equal(7, report.Churn)
+	assert.Equal(5, report.ReviewedChurn)
+	assert.Equal(2, report.UnreviewedChurn)
+	assert.InDelta(2.0/7, report.UnreviewedShare, 1e-9)
+	assert.Equal(12.0, report.MedianLatencyHours)
+	assert.Equal(1.0, report.LatencyCorrelation)
+	assert.Equal([]ReviewerChurn{
+		{Reviewer: "alice@example.com", Name: "alice", Commits: 1, Churn: 3, MedianLatencyHours: 24},
+		{Reviewer: "carol@example.com", Name: "Carol", Commits: 1, Churn: 2},
+	}, report.Reviewers)
+	var unreviewed []string
+	for _, commit := range report.Unreviewed {
+		unreviewed = append(unreviewed, commit.Subject)
+	}
+	assert.Equal([]string{"Add d", "Add a"}, unreviewed)
+}
@@ -0,0 +1,144 @@
+package metrics
+
+import (
+	"sort"
+	"time"
+
+	"github.com/andymeneely/git-churn/gitfuncs"
+	"github.com/andymeneely/git-churn/helper"
+	"gopkg.in/src-d/go-git.v4"
+	"gopkg.in/src-d/go-git.v4/plumbing/object"
+)
+
+// Rework counts the lines added and the lines reworked, i.e. deleted within the window of being added.
+// Rate is the share of the added lines that got reworked.
+type Rework struct {
+	Insertions int `units:"lines"`
+	Reworked   int `units:"lines"`
+	Rate       float64
+}
+
+type FileRework struct {
+	File string
+	Rework
+}
+
+// AuthorRework attributes the reworked lines to the author who added them
+type AuthorRework struct {
+	Author string
+	Rework
+}
+
+type ReworkReport struct {
+	WindowDays int
+	Commits    int
+	Rework
+	// Most reworked files and authors first
+	Files   []FileRework
+	Authors []AuthorRework
+}
+
+func (r *Rework) add(insertions, reworked int) {
+	r.Insertions += insertions
+	r.Reworked += reworked
+}
+
+func (r *Rework) setRate() {
+	if r.Insertions > 0 {
+		r.Rate = float64(r.Reworked) / float64(r.Insertions)
+	}
+}
+
+// ReworkRate finds the lines deleted within the window of being added by the commits between from and to
+// (git log from..to), blaming every deleted line in the parent of the deleting commit, and reports the
+// rework rate overall, per file and per author. Lines added before the range but reworked in it count
+// as reworked too, so the rate of a short range can exceed one. Merge commits are skipped, the lines
+// they delete are counted at the commits of the merged branch. The commits of Bots and the reformats
+// Reformats excludes are skipped too.
+func ReworkRate(repo *git.Repository, from, to string, window time.Duration) (*ReworkReport, error) {
+	defer helper.Duration(helper.Track("ReworkRate"))
+	fromHash, toHash, err := resolveRange(repo, from, to)
+	if err != nil {
+		return nil, err
+	}
+	if err := prefetchRange(repo, fromHash, toHash); err != nil {
+		return nil, err
+	}
+	report := &ReworkReport{WindowDays: int(window.Hours() / 24)}
+	files := make(map[string]*FileRework)
+	authors := make(map[string]*AuthorRework)
+	fileRework := func(path string) *FileRework {
+		file, ok := files[path]
+		if !ok {
+			file = &FileRework{File: path}
+			files[path] = file
+		}
+		return file
+	}
+	authorRework := func(email string) *AuthorRework {
+		author, ok := authors[email]
+		if !ok {
+			author = &AuthorRework{Author: email}
+			authors[email] = author
+		}
+		return author
+	}
+
+	err = gitfuncs.ForEachCommitBetween(repo, fromHash, toHash, func(commit *object.Commit) error {
+		if commit.NumParents() > 1 || Bots.IsBot(commit) {
+			return nil
+		}
+		if reformat, err := Reformats.Excludes(repo, commit); err != nil || reformat {
+			return err
+		}
+		report.Commits += 1
+		stats, err := gitfuncs.ActiveEngine.CommitStats(repo, commit)
+		if err != nil {
+			return err
+		}
+		_, author := gitfuncs.ResolveAuthor(repo, commit.Author)
+		for _, stat := range stats {
+			if gitfuncs.IsIgnored(repo, stat.Name) {
+				continue
+			}
+			report.add(stat.Addition, 0)
+			fileRework(stat.Name).add(stat.Addition, 0)
+			authorRework(author).add(stat.Addition, 0)
+		}
+		return blameDeletedLines(repo, commit, true, func(path string, line *git.Line) {
+			if commit.Author.When.Sub(line.Date) <= window {
+				report.add(0, 1)
+				fileRework(path).add(0, 1)
+				authorRework(gitfuncs.ResolveLineAuthor(repo, line)).add(0, 1)
+			}
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	report.setRate()
+	for _, file := range files {
+		file.setRate()
+		report.Files = append(report.Files, *file)
+	}
+	sort.Slice(report.Files, func(i, j int) bool {
+		a, b := report.Files[i], report.Files[j]
+		if a.Reworked != b.Reworked {
+			return a.Reworked > b.Reworked
+		}
+		return a.File < b.File
+	})
+	for _, author := range authors {
+		author.setRate()
+		report.Authors = append(report.Authors, *author)
+	}
+	sort.Slice(report.Authors, func(i, j int) bool {
+		a, b := report.Authors[i], report.Authors[j]
+		if a.Reworked != b.Reworked {
+			return a.Reworked > b.Reworked
+		}
+		return a.Author < b.Author
+	})
+	return report, nil
+}
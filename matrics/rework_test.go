@@ -0,0 +1,33 @@
+package metrics
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReworkRate(t *testing.T) {
+	assert := assert.New(t)
+	// The last commit deletes 2, added two days before, and 4, added the day before
+	repo := snapshotRepo(t,
+		map[string]string{"a.txt": "1\n2\n3\n", "b.txt": "x\n"},
+		map[string]string{"a.txt": "1\n2\n3\n4\n5\n"},
+		map[string]string{"a.txt": "1\n3\n5\n", "b.txt": "x\ny\n"},
+	)
+
+	report, err := ReworkRate(repo, "", "HEAD", 36*time.Hour)
+	assert.Nil(err)
+	assert.Equal(1, report.WindowDays)
+	assert.Equal(3, report.Commits)
+	assert.Equal(Rework{Insertions: 7, Reworked: 1, Rate: 1.0 / 7}, report.Rework)
+	assert.Equal([]FileRework{
+		{File: "a.txt", Rework: Rework{Insertions: 5, Reworked: 1, Rate: 0.2}},
+		{File: "b.txt", Rework: Rework{Insertions: 2}},
+	}, report.Files)
+	assert.Equal([]AuthorRework{{Author: "alice@example.com", Rework: report.Rework}}, report.Authors)
+
+	report, err = ReworkRate(repo, "", "HEAD", 72*time.Hour)
+	assert.Nil(err)
+	assert.Equal(2, report.Reworked)
+}
@@ -0,0 +1,222 @@
+package metrics
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/andymeneely/git-churn/helper"
+	"gopkg.in/src-d/go-git.v4"
+)
+
+// CommitRisk is how risky a commit is to review or to ship, from the size and spread of its changes, the
+// hotspots it touches and how experienced its author is with the files it touches
+type CommitRisk struct {
+	Hash    string
+	Author  string
+	When    time.Time
+	Message string
+	// Churn according to the churn definition
+	Churn int `units:"lines"`
+	Files int
+	// Shannon entropy of the lines changed across the files, normalized to 0 for a single file and 1 for an
+	// even spread
+	Entropy float64
+	// Files touched among the hottest tenth of the hotspots of the commits
+	HotspotHits int
+	// Mean number of earlier commits of the author on the files touched
+	AuthorExperience float64
+	// Weighted mean of the risk factors normalized to 0..1, 1 being the riskiest
+	Score float64
+}
+
+// RiskWeights are the weights of the risk factors in the score of CommitRisks
+type RiskWeights struct {
+	Churn      float64
+	Files      float64
+	Entropy    float64
+	Hotspots   float64
+	Experience float64
+}
+
+// DefaultRiskWeights weigh every risk factor the same
+var DefaultRiskWeights = RiskWeights{Churn: 1, Files: 1, Entropy: 1, Hotspots: 1, Experience: 1}
+
+// ParseRiskWeights parses weights like "churn=2,entropy=0.5", the factors left out keeping their default
+// weight
+func ParseRiskWeights(s string) (RiskWeights, error) {
+	weights := DefaultRiskWeights
+	for _, field := range strings.Split(s, ",") {
+		field = strings.TrimSpace(field)
+		if field == "" {
+			continue
+		}
+		parts := strings.SplitN(field, "=", 2)
+		if len(parts) != 2 {
+			return weights, fmt.Errorf("invalid risk weight %q, expected factor=weight", field)
+		}
+		weight, err := strconv.ParseFloat(parts[1], 64)
+		if err != nil || weight < 0 {
+			return weights, fmt.Errorf("invalid risk weight %q, expected a positive number", field)
+		}
+		switch parts[0] {
+		case "churn":
+			weights.Churn = weight
+		case "files":
+			weights.Files = weight
+		case "entropy":
+			weights.Entropy = weight
+		case "hotspots":
+			weights.Hotspots = weight
+		case "experience":
+			weights.Experience = weight
+		default:
+			return weights, fmt.Errorf("unknown risk factor %q, expected churn, files, entropy, hotspots or experience", parts[0])
+		}
+	}
+	return weights, nil
+}
+
+// CommitRisks scores the given commits, merges left out, and returns the n riskiest ones, all of them if n
+// is zero. Every factor is normalized to 0..1 over the commits: the lines changed, added plus deleted
+// whatever the churn definition, and the number of files on a log scale relative to the biggest commit,
+// the entropy as is, the share of the files touched that are hotspots, and the inexperience of the author
+// as 1/(1+experience). The hotspots are those of the commits at the revision. The experience of an author
+// only counts the commits given, so the range should reach far enough back for it to be meaningful.
+func CommitRisks(repo *git.Repository, commits []*CommitChurn, revision string, weights RiskWeights, n int) ([]CommitRisk, error) {
+	defer helper.Duration(helper.Track("CommitRisks"))
+	hot, err := hottestFiles(repo, commits, revision)
+	if err != nil {
+		return nil, err
+	}
+
+	type authorFile struct {
+		author, file string
+	}
+	experience := make(map[authorFile]int)
+	var risks []CommitRisk
+	// The net churn of a commit deleting more than it adds is negative, the lines it changes are not
+	var lines []int
+	maxLines, maxFiles := 0.0, 0.0
+	// Oldest first, for the experience to only count the earlier commits
+	for i := len(commits) - 1; i >= 0; i-- {
+		commit := commits[i]
+		if commit.Parents > 1 {
+			continue
+		}
+		risk := CommitRisk{
+			Hash:    commit.Hash,
+			Author:  commit.Author,
+			When:    commit.When,
+			Message: strings.SplitN(strings.TrimSpace(commit.Message), "\n", 2)[0],
+			Churn:   commit.Churn(),
+			Files:   len(commit.Files),
+		}
+		changed := make([]int, len(commit.Files))
+		earlier := 0
+		for j, file := range commit.Files {
+			changed[j] = file.Insertions + file.Deletions
+			if hot[file.File] {
+				risk.HotspotHits += 1
+			}
+			key := authorFile{commit.Author, file.File}
+			earlier += experience[key]
+			experience[key] += 1
+		}
+		if risk.Files > 0 {
+			risk.AuthorExperience = float64(earlier) / float64(risk.Files)
+		}
+		risk.Entropy = normalizedEntropy(changed)
+		lines = append(lines, commit.Insertions+commit.Deletions)
+		maxLines = math.Max(maxLines, math.Log1p(float64(commit.Insertions+commit.Deletions)))
+		maxFiles = math.Max(maxFiles, math.Log1p(float64(risk.Files)))
+		risks = append(risks, risk)
+	}
+
+	total := weights.Churn + weights.Files + weights.Entropy + weights.Hotspots + weights.Experience
+	for i := range risks {
+		risk := &risks[i]
+		if total == 0 {
+			continue
+		}
+		hotspots := 0.0
+		if risk.Files > 0 {
+			hotspots = float64(risk.HotspotHits) / float64(risk.Files)
+		}
+		risk.Score = (weights.Churn*ratio(math.Log1p(float64(lines[i])), maxLines) +
+			weights.Files*ratio(math.Log1p(float64(risk.Files)), maxFiles) +
+			weights.Entropy*risk.Entropy +
+			weights.Hotspots*hotspots +
+			weights.Experience/(1+risk.AuthorExperience)) / total
+	}
+	sort.Slice(risks, func(i, j int) bool {
+		if risks[i].Score != risks[j].Score {
+			return risks[i].Score > risks[j].Score
+		}
+		if !risks[i].When.Equal(risks[j].When) {
+			return risks[i].When.After(risks[j].When)
+		}
+		return risks[i].Hash < risks[j].Hash
+	})
+	if n > 0 && len(risks) > n {
+		risks = risks[:n]
+	}
+	return risks, nil
+}
+
+// hottestFiles returns the hottest tenth of the hotspots of the commits, at least one file if any
+func hottestFiles(repo *git.Repository, commits []*CommitChurn, revision string) (map[string]bool, error) {
+	hotspots, err := Hotspots(repo, commits, revision, 0)
+	if err != nil {
+		return nil, err
+	}
+	n := (len(hotspots) + 9) / 10
+	hot := make(map[string]bool, n)
+	for _, hotspot := range hotspots[:n] {
+		hot[hotspot.File] = true
+	}
+	return hot, nil
+}
+
+// normalizedEntropy returns the Shannon entropy of the distribution of the values divided by its maximum,
+// 0 when a single value is non zero and 1 when all are equal
+func normalizedEntropy(values []int) float64 {
+	entropy := shannonEntropy(values)
+	if entropy == 0 {
+		return 0
+	}
+	return entropy / math.Log2(float64(len(values)))
+}
+
+// shannonEntropy returns the Shannon entropy in bits of the distribution of the values, 0 when a single
+// value is non zero
+func shannonEntropy(values []int) float64 {
+	total, nonZero := 0, 0
+	for _, value := range values {
+		total += value
+		if value > 0 {
+			nonZero += 1
+		}
+	}
+	if nonZero < 2 {
+		return 0
+	}
+	entropy := 0.0
+	for _, value := range values {
+		if value > 0 {
+			p := float64(value) / float64(total)
+			entropy -= p * math.Log2(p)
+		}
+	}
+	return entropy
+}
+
+func ratio(value, max float64) float64 {
+	if max == 0 {
+		return 0
+	}
+	return value / max
+}
@@ -0,0 +1,85 @@
+package metrics
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCommitRisks(t *testing.T) {
+	assert := assert.New(t)
+	repo := snapshotRepo(t,
+		map[string]string{"a.go": "1\n2\n3\n4\n", "b.go": "1\n"},
+		map[string]string{"a.go": "1\n2\n3\n5\n"},
+		map[string]string{"a.go": "1\n2\n3\n6\n", "b.go": "2\n", "c.go": "1\n"},
+	)
+	commits, err := RangeChurn(repo, "", "HEAD")
+	assert.Nil(err)
+
+	risks, err := CommitRisks(repo, commits, "HEAD", DefaultRiskWeights, 0)
+	assert.Nil(err)
+	assert.Len(risks, 3)
+	// The first commit, on files new to its author, is the riskiest; the second, a one line fix of the
+	// hotspot by its author, the least risky
+	assert.Equal(commits[2].Hash, risks[0].Hash)
+	assert.Equal(commits[1].Hash, risks[2].Hash)
+	assert.Equal(3, risks[1].Files)
+	assert.Equal(1.0, risks[1].AuthorExperience)
+	assert.Equal(1.0, risks[2].AuthorExperience)
+	assert.Equal(1, risks[2].HotspotHits)
+	assert.Equal(0.0, risks[2].Entropy)
+	for _, risk := range risks {
+		assert.True(risk.Score >= 0 && risk.Score <= 1)
+	}
+
+	risks, err = CommitRisks(repo, commits, "HEAD", RiskWeights{Files: 1}, 1)
+	assert.Nil(err)
+	assert.Equal([]string{commits[0].Hash}, []string{risks[0].Hash})
+	assert.Equal(1.0, risks[0].Score)
+}
+
+func TestCommitRisksNetChurn(t *testing.T) {
+	defer func(churn ChurnDefinition) { Churn = churn }(Churn)
+	Churn = ChurnDefinition{Mode: ChurnNet}
+	assert := assert.New(t)
+	repo := snapshotRepo(t,
+		map[string]string{"a.go": "1\n2\n3\n4\n5\n6\n7\n8\n", "b.go": "1\n"},
+		map[string]string{"a.go": "1\n", "b.go": "2\n"},
+	)
+	commits, err := RangeChurn(repo, "", "HEAD")
+	assert.Nil(err)
+
+	risks, err := CommitRisks(repo, commits, "HEAD", RiskWeights{Churn: 1}, 0)
+	assert.Nil(err)
+	assert.Len(risks, 2)
+	// The 9 lines deleted or changed weigh as much as the 9 added, though the net churn is negative
+	assert.Equal(commits[0].Hash, risks[0].Hash)
+	assert.Equal(-7, risks[0].Churn)
+	assert.Equal(1.0, risks[0].Score)
+	assert.Equal(1.0, risks[1].Score)
+	assert.True(risks[0].Entropy > 0)
+}
+
+func TestParseRiskWeights(t *testing.T) {
+	assert := assert.New(t)
+	weights, err := ParseRiskWeights("churn=2, entropy=0.5")
+	assert.Nil(err)
+	assert.Equal(RiskWeights{Churn: 2, Files: 1, Entropy: 0.5, Hotspots: 1, Experience: 1}, weights)
+	weights, err = ParseRiskWeights("")
+	assert.Nil(err)
+	assert.Equal(DefaultRiskWeights, weights)
+	_, err = ParseRiskWeights("size=1")
+	assert.NotNil(err)
+	_, err = ParseRiskWeights("churn")
+	assert.NotNil(err)
+	_, err = ParseRiskWeights("churn=-1")
+	assert.NotNil(err)
+}
+
+func TestNormalizedEntropy(t *testing.T) {
+	assert := assert.New(t)
+	assert.Equal(0.0, normalizedEntropy(nil))
+	assert.Equal(0.0, normalizedEntropy([]int{5, 0}))
+	assert.Equal(1.0, normalizedEntropy([]int{3, 3, 3, 3}))
+	assert.InDelta(0.811, normalizedEntropy([]int{3, 1}), 0.001)
+}
@@ -0,0 +1,62 @@
+package metrics
+
+import (
+	"fmt"
+	"regexp"
+
+	"github.com/andymeneely/git-churn/gitfuncs"
+	"gopkg.in/src-d/go-git.v4/plumbing/object"
+)
+
+// CommitSearch scopes the range metrics to the commits matching every criterion given, like the
+// commit limiting options of git log: the commits whose message matches Message (--grep), whose diff
+// adds or deletes a line matching Lines (-G), and whose diff changes the number of occurrences of Pickaxe
+// (-S), e.g. the commits touching TODO markers or the calls of an API. A nil CommitSearch keeps every commit.
+type CommitSearch struct {
+	Message *regexp.Regexp
+	Lines   *regexp.Regexp
+	Pickaxe string
+}
+
+// Search scopes the range metrics to the commits it matches
+var Search *CommitSearch
+
+// NewCommitSearch compiles the given regular expressions, returning nil when no criterion is given
+func NewCommitSearch(message, lines, pickaxe string) (*CommitSearch, error) {
+	if message == "" && lines == "" && pickaxe == "" {
+		return nil, nil
+	}
+	search := &CommitSearch{Pickaxe: pickaxe}
+	var err error
+	if message != "" {
+		if search.Message, err = regexp.Compile(message); err != nil {
+			return nil, fmt.Errorf("invalid message pattern %q: %v", message, err)
+		}
+	}
+	if lines != "" {
+		if search.Lines, err = regexp.Compile(lines); err != nil {
+			return nil, fmt.Errorf("invalid line pattern %q: %v", lines, err)
+		}
+	}
+	return search, nil
+}
+
+// Matches tells whether the commit matches the search, diffing it against its first parent only when its
+// message matched
+func (s *CommitSearch) Matches(commit *object.Commit) (bool, error) {
+	if s == nil {
+		return true, nil
+	}
+	if s.Message != nil && !s.Message.MatchString(commit.Message) {
+		return false, nil
+	}
+	if s.Pickaxe != "" {
+		if match, err := gitfuncs.CommitChangesOccurrences(commit, s.Pickaxe); err != nil || !match {
+			return false, err
+		}
+	}
+	if s.Lines != nil {
+		return gitfuncs.CommitDiffMatches(commit, s.Lines)
+	}
+	return true, nil
+}
@@ -0,0 +1,45 @@
+package metrics
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCommitSearch(t *testing.T) {
+	assert := assert.New(t)
+	repo := historyRepo(t,
+		testCommit{"alice", "Add the client\n", map[string]string{"client.go": "api.Get(url)\n"}},
+		testCommit{"bob", "Retry the requests\n", map[string]string{"client.go": "// TODO: backoff\napi.Get(url)\napi.Get(url)\n"}},
+		testCommit{"alice", "Document the client\n", map[string]string{"README.md": "Call api.Get\n"}},
+	)
+	defer func() { Search = nil }()
+	messages := func(commits []*CommitChurn) []string {
+		var messages []string
+		for _, commit := range commits {
+			messages = append(messages, commit.Message)
+		}
+		return messages
+	}
+
+	var err error
+	for _, c := range []struct {
+		message, lines, pickaxe string
+		expected                []string
+	}{
+		{"", "", "", []string{"Document the client\n", "Retry the requests\n", "Add the client\n"}},
+		{"(?i)client", "", "", []string{"Document the client\n", "Add the client\n"}},
+		{"", "TODO", "", []string{"Retry the requests\n"}},
+		{"", "", "api.Get(", []string{"Retry the requests\n", "Add the client\n"}},
+		{"client", "", "api.Get(", []string{"Add the client\n"}},
+	} {
+		Search, err = NewCommitSearch(c.message, c.lines, c.pickaxe)
+		assert.Nil(err)
+		commits, err := RangeChurn(repo, "", "HEAD")
+		assert.Nil(err)
+		assert.Equal(c.expected, messages(commits), "%+v", c)
+	}
+
+	_, err = NewCommitSearch("(", "", "")
+	assert.NotNil(err)
+}
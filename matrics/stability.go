@@ -0,0 +1,115 @@
+package metrics
+
+import (
+	"math"
+	"sort"
+	"time"
+
+	"github.com/andymeneely/git-churn/gitfuncs"
+	"github.com/andymeneely/git-churn/helper"
+	"gopkg.in/src-d/go-git.v4"
+)
+
+// DefaultHalfLife is the age at which the churn of a commit weighs half as much as the churn of a commit
+// made at the revision in the stability scores
+const DefaultHalfLife = 90 * 24 * time.Hour
+
+// FileStability is how much a file, or a group of files, churned lately
+type FileStability struct {
+	// Path of the file, or name of the group
+	Name    string
+	Commits int
+	// Churn according to the churn definition
+	Churn       int `units:"lines"`
+	LastTouched time.Time
+	// Lines added plus deleted by every commit, whatever the churn definition, the net churn going
+	// negative, weighted by 2^(-age/half-life), the age being counted back from the revision
+	DecayedChurn float64
+	// 1 minus the decayed churn relative to the highest one, from 0 for the least stable to 1 for the
+	// files not churned lately
+	Stability float64
+}
+
+// StabilityRanking scores the stability of the files of the revision changed by the given commits with
+// an exponentially decayed churn, so that recent changes weigh more than old ones, and ranks them most
+// stable first, e.g. to tell the modules safe to build on. When groupBy is set, the files are totalled
+// by top level directory, file extension or language like in GetLOCSnapshot. The n first are returned,
+// all of them if n is zero.
+func StabilityRanking(repo *git.Repository, commits []*CommitChurn, revision string, halfLife time.Duration, groupBy string, n int) ([]FileStability, error) {
+	defer helper.Duration(helper.Track("StabilityRanking"))
+	groupKey, err := locGroupKey(groupBy)
+	if err != nil {
+		return nil, err
+	}
+	hash, err := gitfuncs.ResolveRef(repo, revision)
+	if err != nil {
+		return nil, err
+	}
+	commit, err := repo.CommitObject(*hash)
+	if err != nil {
+		return nil, err
+	}
+	tree, err := commit.Tree()
+	if err != nil {
+		return nil, err
+	}
+	now := commit.Committer.When
+
+	byName := make(map[string]*FileStability)
+	exists := make(map[string]bool)
+	for _, churn := range commits {
+		weight := math.Exp2(-now.Sub(churn.When).Hours() / halfLife.Hours())
+		// A commit changing several files of a group counts once for it
+		touched := make(map[string]bool)
+		for _, file := range churn.Files {
+			present, ok := exists[file.File]
+			if !ok {
+				_, err := tree.File(file.File)
+				present = err == nil
+				exists[file.File] = present
+			}
+			if !present {
+				// Deleted since
+				continue
+			}
+			name := file.File
+			if groupKey != nil {
+				name = groupKey(file.File)
+			}
+			stability, ok := byName[name]
+			if !ok {
+				stability = &FileStability{Name: name}
+				byName[name] = stability
+			}
+			if !touched[name] {
+				touched[name] = true
+				stability.Commits += 1
+			}
+			stability.Churn += file.Churn()
+			stability.DecayedChurn += weight * float64(file.Insertions+file.Deletions)
+			if churn.When.After(stability.LastTouched) {
+				stability.LastTouched = churn.When
+			}
+		}
+	}
+
+	stabilities := make([]FileStability, 0, len(byName))
+	maxDecayed := 0.0
+	for _, stability := range byName {
+		maxDecayed = math.Max(maxDecayed, stability.DecayedChurn)
+		stabilities = append(stabilities, *stability)
+	}
+	for i := range stabilities {
+		stabilities[i].Stability = 1 - ratio(stabilities[i].DecayedChurn, maxDecayed)
+	}
+	sort.Slice(stabilities, func(i, j int) bool {
+		if stabilities[i].DecayedChurn != stabilities[j].DecayedChurn {
+			return stabilities[i].DecayedChurn < stabilities[j].DecayedChurn
+		}
+		return stabilities[i].Name < stabilities[j].Name
+	})
+	if n > 0 && len(stabilities) > n {
+		stabilities = stabilities[:n]
+	}
+	return stabilities, nil
+}
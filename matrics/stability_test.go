@@ -0,0 +1,80 @@
+package metrics
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/andymeneely/git-churn/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStabilityRanking(t *testing.T) {
+	assert := assert.New(t)
+	start := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	lines := strings.Repeat("line\n", 10)
+	repo := testutil.NewRepo(t)
+	repo.At(start).CommitFiles("add", map[string]string{"a.go": lines, "lib/b.go": lines, "gone.go": lines})
+	repo.At(start.Add(2*DefaultHalfLife)).Write("a.go", strings.Replace(lines, "line", "changed", 2)).Delete("gone.go").Commit("change")
+	commits, err := RangeChurn(repo.Repository, "", "HEAD")
+	assert.Nil(err)
+
+	files, err := StabilityRanking(repo.Repository, commits, "HEAD", DefaultHalfLife, "", 0)
+	assert.Nil(err)
+	assert.Equal(2, len(files))
+	assert.Equal("lib/b.go", files[0].Name)
+	assert.InDelta(2.5, files[0].DecayedChurn, 0.001)
+	assert.InDelta(1-2.5/6.5, files[0].Stability, 0.001)
+	assert.Equal("a.go", files[1].Name)
+	assert.Equal(2, files[1].Commits)
+	assert.Equal(14, files[1].Churn)
+	assert.InDelta(6.5, files[1].DecayedChurn, 0.001)
+	assert.Equal(0.0, files[1].Stability)
+	assert.Equal(start.Add(2*DefaultHalfLife), files[1].LastTouched.UTC())
+
+	dirs, err := StabilityRanking(repo.Repository, commits, "HEAD", DefaultHalfLife, GroupByDir, 1)
+	assert.Nil(err)
+	assert.Equal(1, len(dirs))
+	assert.Equal("lib", dirs[0].Name)
+
+	_, err = StabilityRanking(repo.Repository, commits, "HEAD", DefaultHalfLife, "owner", 0)
+	assert.NotNil(err)
+}
+
+func TestStabilityRankingGroupCommits(t *testing.T) {
+	assert := assert.New(t)
+	repo := testutil.NewRepo(t)
+	repo.CommitFiles("add", map[string]string{"lib/a.go": "a\n", "lib/b.go": "b\n", "main.go": "main\n"})
+	repo.CommitFiles("change", map[string]string{"lib/a.go": "a\nA\n", "lib/b.go": "b\nB\n"})
+	commits, err := RangeChurn(repo.Repository, "", "HEAD")
+	assert.Nil(err)
+
+	// The commits changing both files of lib count once for it
+	dirs, err := StabilityRanking(repo.Repository, commits, "HEAD", DefaultHalfLife, GroupByDir, 0)
+	assert.Nil(err)
+	assert.Equal(2, len(dirs))
+	assert.Equal("lib", dirs[1].Name)
+	assert.Equal(2, dirs[1].Commits)
+	assert.Equal(4, dirs[1].Churn)
+}
+
+func TestStabilityRankingNetChurn(t *testing.T) {
+	defer func(churn ChurnDefinition) { Churn = churn }(Churn)
+	Churn = ChurnDefinition{Mode: ChurnNet}
+	assert := assert.New(t)
+	lines := strings.Repeat("line\n", 10)
+	repo := testutil.NewRepo(t)
+	repo.CommitFiles("add", map[string]string{"a.go": lines, "b.go": "line\n"})
+	repo.CommitFiles("shrink", map[string]string{"a.go": "line\n"})
+	commits, err := RangeChurn(repo.Repository, "", "HEAD")
+	assert.Nil(err)
+
+	// a.go shrinking back to a line churned more than b.go, though its net churn is the same
+	files, err := StabilityRanking(repo.Repository, commits, "HEAD", DefaultHalfLife, "", 0)
+	assert.Nil(err)
+	assert.Equal([]string{"b.go", "a.go"}, []string{files[0].Name, files[1].Name})
+	assert.Equal(1, files[1].Churn)
+	assert.InDelta(10+9, files[1].DecayedChurn, 0.1)
+	assert.Equal(0.0, files[1].Stability)
+	assert.True(files[0].Stability > 0.9)
+}
@@ -0,0 +1,133 @@
+package metrics
+
+import (
+	"fmt"
+	"math"
+	"sort"
+)
+
+// Upper bounds of the histogram buckets of the commit sizes, the last bucket having none
+var (
+	lineBuckets = []int{0, 10, 50, 100, 500, 1000}
+	fileBuckets = []int{0, 1, 5, 10, 20, 50}
+)
+
+// SizeDistribution summarizes the sizes of commits. The percentiles are nearest-rank ones, so that they are
+// sizes of actual commits.
+type SizeDistribution struct {
+	Mean      float64
+	Median    int
+	P90       int
+	Max       int
+	Histogram []SizeBucket
+}
+
+// SizeBucket counts the commits whose size is in the range of the bucket, e.g. "11-50" or "1001+"
+type SizeBucket struct {
+	Bucket  string
+	Commits int
+}
+
+// CommitSizeStats is the distribution of the sizes of the commits of a range, in lines according to the
+// churn definition and in files changed
+type CommitSizeStats struct {
+	Commits int
+	Lines   SizeDistribution
+	Files   SizeDistribution
+	// Most committing authors first, only when asked for
+	Authors []AuthorSizeStats `json:",omitempty"`
+}
+
+// AuthorSizeStats is the distribution of the sizes of the commits of an author
+type AuthorSizeStats struct {
+	Author string
+	Name   string
+	CommitSizeStats
+}
+
+// CommitSizes summarizes the distribution of the sizes of the commits given, overall and, if perAuthor,
+// per author. Merges are left out, like in ChangeEntropy, their diff against the first parent being the
+// size of the branch merged rather than of a commit.
+func CommitSizes(commits []*CommitChurn, perAuthor bool) *CommitSizeStats {
+	var lines, files []int
+	authorLines := make(map[string][]int)
+	authorFiles := make(map[string][]int)
+	names := make(map[string]string)
+	for _, commit := range commits {
+		if commit.Parents > 1 {
+			continue
+		}
+		lines = append(lines, commit.Churn())
+		files = append(files, len(commit.Files))
+		if perAuthor {
+			authorLines[commit.Author] = append(authorLines[commit.Author], commit.Churn())
+			authorFiles[commit.Author] = append(authorFiles[commit.Author], len(commit.Files))
+			if _, ok := names[commit.Author]; !ok {
+				names[commit.Author] = commit.AuthorName
+			}
+		}
+	}
+	stats := sizeStats(lines, files)
+	for author := range authorLines {
+		stats.Authors = append(stats.Authors, AuthorSizeStats{
+			Author:          author,
+			Name:            names[author],
+			CommitSizeStats: *sizeStats(authorLines[author], authorFiles[author]),
+		})
+	}
+	sort.Slice(stats.Authors, func(i, j int) bool {
+		if stats.Authors[i].Commits != stats.Authors[j].Commits {
+			return stats.Authors[i].Commits > stats.Authors[j].Commits
+		}
+		return stats.Authors[i].Author < stats.Authors[j].Author
+	})
+	return stats
+}
+
+func sizeStats(lines, files []int) *CommitSizeStats {
+	return &CommitSizeStats{
+		Commits: len(lines),
+		Lines:   sizeDistribution(lines, lineBuckets),
+		Files:   sizeDistribution(files, fileBuckets),
+	}
+}
+
+// sizeDistribution summarizes the sizes, counting them in the buckets of the given upper bounds
+func sizeDistribution(sizes []int, bounds []int) SizeDistribution {
+	distribution := SizeDistribution{Histogram: make([]SizeBucket, len(bounds)+1)}
+	lower := 0
+	for i, upper := range bounds {
+		if lower == upper {
+			distribution.Histogram[i].Bucket = fmt.Sprint(upper)
+		} else {
+			distribution.Histogram[i].Bucket = fmt.Sprintf("%d-%d", lower, upper)
+		}
+		lower = upper + 1
+	}
+	distribution.Histogram[len(bounds)].Bucket = fmt.Sprintf("%d+", lower)
+	if len(sizes) == 0 {
+		return distribution
+	}
+
+	sorted := append([]int(nil), sizes...)
+	sort.Ints(sorted)
+	total := 0
+	for _, size := range sorted {
+		total += size
+		distribution.Histogram[sort.SearchInts(bounds, size)].Commits += 1
+	}
+	distribution.Mean = float64(total) / float64(len(sorted))
+	distribution.Median = percentile(sorted, 50)
+	distribution.P90 = percentile(sorted, 90)
+	distribution.Max = sorted[len(sorted)-1]
+	return distribution
+}
+
+// percentile returns the nearest-rank percentile of the sorted sizes
+func percentile(sorted []int, p float64) int {
+	rank := int(math.Ceil(p / 100 * float64(len(sorted))))
+	if rank < 1 {
+		rank = 1
+	}
+	return sorted[rank-1]
+}
@@ -0,0 +1,50 @@
+package metrics
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCommitSizes(t *testing.T) {
+	assert := assert.New(t)
+	commit := func(author string, parents int, lines ...int) *CommitChurn {
+		c := &CommitChurn{Author: author + "@example.com", AuthorName: author, Parents: parents}
+		for _, n := range lines {
+			c.Insertions += n
+			c.Files = append(c.Files, FileChurn{Insertions: n})
+		}
+		return c
+	}
+	commits := []*CommitChurn{
+		commit("alice", 1, 5),
+		commit("alice", 1, 20, 10),
+		commit("bob", 1, 2000, 1, 1, 1, 1, 1),
+		commit("alice", 2, 100000),
+		commit("alice", 1, 3),
+	}
+
+	stats := CommitSizes(commits, false)
+	assert.Equal(4, stats.Commits)
+	assert.Equal(510.75, stats.Lines.Mean)
+	assert.Equal(5, stats.Lines.Median)
+	assert.Equal(2005, stats.Lines.P90)
+	assert.Equal(2005, stats.Lines.Max)
+	assert.Equal([]SizeBucket{{"0", 0}, {"1-10", 2}, {"11-50", 1}, {"51-100", 0}, {"101-500", 0}, {"501-1000", 0}, {"1001+", 1}}, stats.Lines.Histogram)
+	assert.Equal(1, stats.Files.Median)
+	assert.Equal([]SizeBucket{{"0", 0}, {"1", 2}, {"2-5", 1}, {"6-10", 1}, {"11-20", 0}, {"21-50", 0}, {"51+", 0}}, stats.Files.Histogram)
+	assert.Nil(stats.Authors)
+
+	stats = CommitSizes(commits, true)
+	assert.Len(stats.Authors, 2)
+	assert.Equal("alice@example.com", stats.Authors[0].Author)
+	assert.Equal("alice", stats.Authors[0].Name)
+	assert.Equal(3, stats.Authors[0].Commits)
+	assert.Equal(5, stats.Authors[0].Lines.Median)
+	assert.Equal(2, stats.Authors[0].Files.Max)
+	assert.Equal(1, stats.Authors[1].Commits)
+
+	empty := CommitSizes(nil, true)
+	assert.Equal(0, empty.Commits)
+	assert.Len(empty.Lines.Histogram, 7)
+}
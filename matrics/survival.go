@@ -0,0 +1,198 @@
+package metrics
+
+import (
+	"sort"
+	"time"
+
+	"github.com/andymeneely/git-churn/gitfuncs"
+	"github.com/andymeneely/git-churn/helper"
+	"gopkg.in/src-d/go-git.v4"
+	"gopkg.in/src-d/go-git.v4/plumbing"
+	"gopkg.in/src-d/go-git.v4/plumbing/object"
+)
+
+// Survival counts the lines added and how many of them survived, or got deleted, early or not. Early
+// deletions are wasted churn.
+type Survival struct {
+	Added int `units:"lines"`
+	// Added lines deleted since, and those deleted within the window of being added
+	Deleted      int `units:"lines"`
+	DeletedEarly int `units:"lines"`
+	Survived     int `units:"lines"`
+	// Shares of the added lines that survived and that got deleted early
+	SurvivalRate      float64
+	EarlyDeletionRate float64
+	// Mean age in days of the added lines when deleted
+	MeanDaysToDeletion float64
+
+	daysToDeletion float64
+}
+
+type FileSurvival struct {
+	File string
+	Survival
+}
+
+// AuthorSurvival attributes the lines to the author who added them
+type AuthorSurvival struct {
+	Author string
+	Survival
+}
+
+type SurvivalReport struct {
+	// Window under which a deleted line counts as deleted early, in days or, if set, in commits
+	WindowDays    int
+	WindowCommits int `json:",omitempty"`
+	Commits       int
+	Survival
+	// Files and authors whose lines got deleted early most first
+	Files   []FileSurvival
+	Authors []AuthorSurvival
+}
+
+func (s *Survival) add(added int) {
+	s.Added += added
+}
+
+func (s *Survival) delete(days float64, early bool) {
+	s.Deleted += 1
+	s.daysToDeletion += days
+	if early {
+		s.DeletedEarly += 1
+	}
+}
+
+func (s *Survival) setRates() {
+	s.Survived = s.Added - s.Deleted
+	if s.Added > 0 {
+		s.SurvivalRate = float64(s.Survived) / float64(s.Added)
+		s.EarlyDeletionRate = float64(s.DeletedEarly) / float64(s.Added)
+	}
+	if s.Deleted > 0 {
+		s.MeanDaysToDeletion = s.daysToDeletion / float64(s.Deleted)
+	}
+}
+
+// LineSurvival follows the lines added by the commits between from and to (git log from..to) up to `to`,
+// blaming the lines deleted by every commit of the range in the parent of the deleting commit, and reports
+// the share of them that survived, and that got deleted within the window of being added, overall, per file
+// and per author who added them. The window is in commits, the commits of the range in between the adding
+// and the deleting one, when windowCommits is set, in time otherwise. The deleted lines are counted in the
+// file they were deleted from. Merges, the commits of Bots and the reformats Reformats excludes are skipped
+// like in ReworkRate.
+func LineSurvival(repo *git.Repository, from, to string, window time.Duration, windowCommits int) (*SurvivalReport, error) {
+	defer helper.Duration(helper.Track("LineSurvival"))
+	fromHash, toHash, err := resolveRange(repo, from, to)
+	if err != nil {
+		return nil, err
+	}
+	if err := prefetchRange(repo, fromHash, toHash); err != nil {
+		return nil, err
+	}
+	commits, err := gitfuncs.CommitsBetween(repo, fromHash, toHash)
+	if err != nil {
+		return nil, err
+	}
+	report := &SurvivalReport{WindowDays: int(window.Hours() / 24), WindowCommits: windowCommits}
+	if windowCommits > 0 {
+		report.WindowDays = 0
+	}
+	files := make(map[string]*FileSurvival)
+	authors := make(map[string]*AuthorSurvival)
+	fileSurvival := func(path string) *FileSurvival {
+		file, ok := files[path]
+		if !ok {
+			file = &FileSurvival{File: path}
+			files[path] = file
+		}
+		return file
+	}
+	authorSurvival := func(email string) *AuthorSurvival {
+		author, ok := authors[email]
+		if !ok {
+			author = &AuthorSurvival{Author: email}
+			authors[email] = author
+		}
+		return author
+	}
+
+	// Position of the commits counted, in the range, the newest first, for the lines deleted to be told from
+	// those added by other commits and their age in commits
+	positions := make(map[plumbing.Hash]int, len(commits))
+	var counted []*object.Commit
+	for i, commit := range commits {
+		if commit.NumParents() > 1 || Bots.IsBot(commit) {
+			continue
+		}
+		if reformat, err := Reformats.Excludes(repo, commit); err != nil {
+			return nil, err
+		} else if reformat {
+			continue
+		}
+		positions[commit.Hash] = i
+		counted = append(counted, commit)
+	}
+	progress := helper.TrackProgress("commits", len(counted))
+	defer progress.Finish()
+	for _, commit := range counted {
+		progress.Step()
+		report.Commits += 1
+		stats, err := gitfuncs.ActiveEngine.CommitStats(repo, commit)
+		if err != nil {
+			return nil, err
+		}
+		_, author := gitfuncs.ResolveAuthor(repo, commit.Author)
+		for _, stat := range stats {
+			if gitfuncs.IsIgnored(repo, stat.Name) {
+				continue
+			}
+			report.add(stat.Addition)
+			fileSurvival(stat.Name).add(stat.Addition)
+			authorSurvival(author).add(stat.Addition)
+		}
+		err = blameDeletedLines(repo, commit, true, func(path string, line *git.Line) {
+			added, ok := positions[line.Hash]
+			if !ok {
+				// Added before the range, or by a commit not counted
+				return
+			}
+			age := commit.Author.When.Sub(line.Date)
+			early := age <= window
+			if windowCommits > 0 {
+				early = added-positions[commit.Hash] <= windowCommits
+			}
+			days := age.Hours() / 24
+			report.delete(days, early)
+			fileSurvival(path).delete(days, early)
+			authorSurvival(gitfuncs.ResolveLineAuthor(repo, line)).delete(days, early)
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	report.setRates()
+	for _, file := range files {
+		file.setRates()
+		report.Files = append(report.Files, *file)
+	}
+	sort.Slice(report.Files, func(i, j int) bool {
+		a, b := report.Files[i], report.Files[j]
+		if a.DeletedEarly != b.DeletedEarly {
+			return a.DeletedEarly > b.DeletedEarly
+		}
+		return a.File < b.File
+	})
+	for _, author := range authors {
+		author.setRates()
+		report.Authors = append(report.Authors, *author)
+	}
+	sort.Slice(report.Authors, func(i, j int) bool {
+		a, b := report.Authors[i], report.Authors[j]
+		if a.DeletedEarly != b.DeletedEarly {
+			return a.DeletedEarly > b.DeletedEarly
+		}
+		return a.Author < b.Author
+	})
+	return report, nil
+}
@@ -0,0 +1,49 @@
+package metrics
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLineSurvival(t *testing.T) {
+	assert := assert.New(t)
+	// The last commit deletes 2, added two days before, and 4, added the day before, 1 being added before
+	// the range
+	repo := snapshotRepo(t,
+		map[string]string{"a.txt": "1\n"},
+		map[string]string{"a.txt": "1\n2\n3\n", "b.txt": "x\n"},
+		map[string]string{"a.txt": "1\n2\n3\n4\n5\n"},
+		map[string]string{"a.txt": "3\n5\n", "b.txt": "x\ny\n"},
+	)
+
+	report, err := LineSurvival(repo, "HEAD~3", "HEAD", 36*time.Hour, 0)
+	assert.Nil(err)
+	assert.Equal(1, report.WindowDays)
+	assert.Equal(3, report.Commits)
+	assert.Equal(6, report.Added)
+	assert.Equal(2, report.Deleted)
+	assert.Equal(1, report.DeletedEarly)
+	assert.Equal(4, report.Survived)
+	assert.Equal(4.0/6, report.SurvivalRate)
+	assert.Equal(1.0/6, report.EarlyDeletionRate)
+	assert.Equal(1.5, report.MeanDaysToDeletion)
+	assert.Equal("a.txt", report.Files[0].File)
+	assert.Equal(4, report.Files[0].Added)
+	assert.Equal(2, report.Files[0].Survived)
+	assert.Equal("b.txt", report.Files[1].File)
+	assert.Equal(1.0, report.Files[1].SurvivalRate)
+	assert.Len(report.Authors, 1)
+	assert.Equal("alice@example.com", report.Authors[0].Author)
+
+	// 2 is deleted two commits after being added
+	report, err = LineSurvival(repo, "HEAD~3", "HEAD", 0, 2)
+	assert.Nil(err)
+	assert.Equal(0, report.WindowDays)
+	assert.Equal(2, report.WindowCommits)
+	assert.Equal(2, report.DeletedEarly)
+	report, err = LineSurvival(repo, "HEAD~3", "HEAD", 0, 1)
+	assert.Nil(err)
+	assert.Equal(1, report.DeletedEarly)
+}
@@ -0,0 +1,231 @@
+package metrics
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/andymeneely/git-churn/gitfuncs"
+	"github.com/andymeneely/git-churn/helper"
+	"github.com/andymeneely/git-churn/lang"
+	. "github.com/andymeneely/git-churn/print"
+	"gopkg.in/src-d/go-git.v4"
+	"gopkg.in/src-d/go-git.v4/plumbing/object"
+)
+
+// SymbolChurn is the churn of a symbol, i.e. a function, method or class, over a range of commits
+type SymbolChurn struct {
+	File       string
+	Symbol     string
+	Kind       string
+	Commits    int
+	Insertions int `units:"lines"`
+	Deletions  int `units:"lines"`
+	// Churn according to the churn definition, the recent mode counting every deleted line
+	Churn       int `units:"lines"`
+	LastTouched time.Time
+}
+
+// RangeSymbolChurn attributes the lines added and deleted by the commits of the range, merges and the
+// commits of Bots left out, to the symbols they fall in, in the version of the file before the commit for
+// the deleted lines and after it for the added lines. The symbols are found by the parser lang.ParserFor
+// picks for the file, files no parser supports being left out. It returns the n most churned symbols, all
+// of them if n is zero. Symbols are told apart by file and name, so a symbol moved to another file or
+// renamed starts afresh.
+func RangeSymbolChurn(repo *git.Repository, from, to string, n int) ([]SymbolChurn, error) {
+	defer helper.Duration(helper.Track("RangeSymbolChurn"))
+	fromHash, toHash, err := resolveRange(repo, from, to)
+	if err != nil {
+		return nil, err
+	}
+	symbols := newSymbolChurns()
+	err = gitfuncs.ForEachCommitBetween(repo, fromHash, toHash, func(commit *object.Commit) error {
+		return symbols.add(repo, commit)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return TopSymbols(symbols.list(), "churn", n)
+}
+
+// SymbolChurnSince attributes the lines changed by the commits leading to the revision that were committed
+// after `since` to the symbols they fall in, like RangeSymbolChurn, and returns the churn of every symbol
+func SymbolChurnSince(repo *git.Repository, revision string, since time.Time) ([]SymbolChurn, error) {
+	defer helper.Duration(helper.Track("SymbolChurnSince"))
+	symbols := newSymbolChurns()
+	err := forEachCommitSince(repo, revision, since, func(commit *object.Commit) error {
+		return symbols.add(repo, commit)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return symbols.list(), nil
+}
+
+// TopSymbols returns the n symbols ranking first by the given key of TopSortKeys, all of them if n is zero.
+// Ties are broken by file and symbol name.
+func TopSymbols(symbols []SymbolChurn, sortBy string, n int) ([]SymbolChurn, error) {
+	var greater func(a, b *SymbolChurn) bool
+	switch sortBy {
+	case "", "churn":
+		greater = func(a, b *SymbolChurn) bool { return a.Churn > b.Churn }
+	case "commits":
+		greater = func(a, b *SymbolChurn) bool { return a.Commits > b.Commits }
+	case "insertions":
+		greater = func(a, b *SymbolChurn) bool { return a.Insertions > b.Insertions }
+	case "deletions":
+		greater = func(a, b *SymbolChurn) bool { return a.Deletions > b.Deletions }
+	case "last":
+		greater = func(a, b *SymbolChurn) bool { return a.LastTouched.After(b.LastTouched) }
+	default:
+		return nil, fmt.Errorf("unknown sort key %q, expected one of %v", sortBy, TopSortKeys)
+	}
+	sort.Slice(symbols, func(i, j int) bool {
+		a, b := &symbols[i], &symbols[j]
+		if greater(a, b) {
+			return true
+		}
+		if greater(b, a) {
+			return false
+		}
+		if a.File != b.File {
+			return a.File < b.File
+		}
+		return a.Symbol < b.Symbol
+	})
+	if n > 0 && len(symbols) > n {
+		symbols = symbols[:n]
+	}
+	return symbols, nil
+}
+
+type symbolKey struct {
+	file, name string
+}
+
+// symbolChurns accumulates the churn of the symbols commit after commit
+type symbolChurns map[symbolKey]*SymbolChurn
+
+func newSymbolChurns() symbolChurns {
+	return make(symbolChurns)
+}
+
+// add attributes the lines changed by the commit to the symbols they fall in. Merges, the commits of Bots
+// and the reformats Reformats excludes are left out.
+func (s symbolChurns) add(repo *git.Repository, commit *object.Commit) error {
+	if commit.NumParents() > 1 || Bots.IsBot(commit) {
+		return nil
+	}
+	if reformat, err := Reformats.Excludes(repo, commit); err != nil || reformat {
+		return err
+	}
+	hunks, err := gitfuncs.CommitHunks(repo, commit)
+	if err != nil {
+		return err
+	}
+	touched := make(map[symbolKey]bool)
+	byFile := make(map[string][]gitfuncs.Hunk)
+	for _, hunk := range hunks {
+		byFile[hunk.File] = append(byFile[hunk.File], hunk)
+	}
+	for file, fileHunks := range byFile {
+		parser := lang.ParserFor(file)
+		if parser == nil {
+			continue
+		}
+		before, after, err := commitSymbols(parser, commit, file)
+		if err != nil {
+			return err
+		}
+		symbolChurn := func(symbol *lang.Symbol) *SymbolChurn {
+			key := symbolKey{file, symbol.Name}
+			churn, ok := s[key]
+			if !ok {
+				churn = &SymbolChurn{File: file, Symbol: symbol.Name, Kind: symbol.Kind}
+				s[key] = churn
+			}
+			if !touched[key] {
+				touched[key] = true
+				churn.Commits += 1
+				if commit.Committer.When.After(churn.LastTouched) {
+					churn.LastTouched = commit.Committer.When
+				}
+			}
+			return churn
+		}
+		for _, hunk := range fileHunks {
+			for line := hunk.OldStart; line < hunk.OldStart+hunk.Deleted; line++ {
+				if symbol := symbolAt(before, line); symbol != nil {
+					symbolChurn(symbol).Deletions += 1
+				}
+			}
+			for line := hunk.NewStart; line < hunk.NewStart+hunk.Added; line++ {
+				if symbol := symbolAt(after, line); symbol != nil {
+					symbolChurn(symbol).Insertions += 1
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// list returns the churn of every symbol, in no particular order
+func (s symbolChurns) list() []SymbolChurn {
+	symbols := make([]SymbolChurn, 0, len(s))
+	for _, symbol := range s {
+		symbol.Churn = Churn.Lines(symbol.Insertions, symbol.Deletions, symbol.Deletions)
+		symbols = append(symbols, *symbol)
+	}
+	return symbols
+}
+
+// commitSymbols returns the symbols of the file before and after the commit
+func commitSymbols(parser lang.SymbolParser, commit *object.Commit, path string) ([]lang.Symbol, []lang.Symbol, error) {
+	var before, after []lang.Symbol
+	if commit.NumParents() > 0 {
+		parent, err := commit.Parent(0)
+		if err != nil {
+			return nil, nil, err
+		}
+		if before, err = fileSymbols(parser, parent, path); err != nil {
+			return nil, nil, err
+		}
+	}
+	after, err := fileSymbols(parser, commit, path)
+	return before, after, err
+}
+
+// fileSymbols returns the symbols of the file at the commit, none if it has no such file. A file the
+// parser fails on is warned about and has no symbols.
+func fileSymbols(parser lang.SymbolParser, commit *object.Commit, path string) ([]lang.Symbol, error) {
+	file, err := commit.File(path)
+	if err == object.ErrFileNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	contents, err := file.Contents()
+	if err != nil {
+		return nil, err
+	}
+	symbols, err := parser.Symbols(path, []byte(contents))
+	if err != nil {
+		Warning("could not find the symbols of %s at %s: %v", path, commit.Hash, err)
+		return nil, nil
+	}
+	return symbols, nil
+}
+
+// symbolAt returns the innermost symbol spanning the line, nil if none
+func symbolAt(symbols []lang.Symbol, line int) *lang.Symbol {
+	var found *lang.Symbol
+	for i := range symbols {
+		symbol := &symbols[i]
+		if symbol.StartLine <= line && line <= symbol.EndLine &&
+			(found == nil || symbol.EndLine-symbol.StartLine < found.EndLine-found.StartLine) {
+			found = symbol
+		}
+	}
+	return found
+}
@@ -0,0 +1,76 @@
+package metrics
+
+import (
+	"testing"
+	"time"
+
+	"github.com/andymeneely/git-churn/lang"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRangeSymbolChurn(t *testing.T) {
+	assert := assert.New(t)
+	repo := snapshotRepo(t,
+		map[string]string{"main.go": "package main\n\nfunc a() {\n\tx := 1\n}\n\nfunc b() {}\n", "README.md": "a\n"},
+		map[string]string{"main.go": "package main\n\nfunc a() {\n\tx := 2\n\ty := 3\n}\n\nfunc b() {}\n", "README.md": "b\n"},
+		map[string]string{"main.go": "package main\n\nfunc a() {\n\tx := 2\n\ty := 3\n}\n\ntype t struct{}\n\nfunc (*t) c() {\n}\n"},
+	)
+	day := func(d int) time.Time { return time.Date(2020, 1, d, 0, 0, 0, 0, time.UTC) }
+
+	symbols, err := RangeSymbolChurn(repo, "", "HEAD", 0)
+	assert.Nil(err)
+	for i := range symbols {
+		symbols[i].LastTouched = symbols[i].LastTouched.UTC()
+	}
+	assert.Equal([]SymbolChurn{
+		{File: "main.go", Symbol: "a", Kind: "function", Commits: 2, Insertions: 5, Deletions: 1, Churn: 6, LastTouched: day(2)},
+		{File: "main.go", Symbol: "(*t).c", Kind: "method", Commits: 1, Insertions: 2, Churn: 2, LastTouched: day(3)},
+		{File: "main.go", Symbol: "b", Kind: "function", Commits: 2, Insertions: 1, Deletions: 1, Churn: 2, LastTouched: day(3)},
+	}, symbols)
+
+	symbols, err = RangeSymbolChurn(repo, "HEAD~1", "HEAD", 1)
+	assert.Nil(err)
+	assert.Len(symbols, 1)
+	assert.Equal("(*t).c", symbols[0].Symbol)
+
+	symbols, err = SymbolChurnSince(repo, "HEAD", day(3))
+	assert.Nil(err)
+	symbols, err = TopSymbols(symbols, "deletions", 0)
+	assert.Nil(err)
+	assert.Equal([]string{"b", "(*t).c"}, []string{symbols[0].Symbol, symbols[1].Symbol})
+	_, err = TopSymbols(symbols, "size", 0)
+	assert.NotNil(err)
+}
+
+// pythonParser finds a single function f spanning the whole of the Python files
+type pythonParser struct{}
+
+func (pythonParser) Supports(language string) bool {
+	return language == "Python"
+}
+
+func (pythonParser) Symbols(path string, src []byte) ([]lang.Symbol, error) {
+	return []lang.Symbol{{Name: "f", Kind: lang.KindFunction, StartLine: 1, EndLine: 100}}, nil
+}
+
+func TestRangeSymbolChurnParsers(t *testing.T) {
+	assert := assert.New(t)
+	repo := snapshotRepo(t,
+		map[string]string{"f.py": "def f():\n    pass\n"},
+		map[string]string{"f.py": "def f():\n    return 1\n"},
+	)
+	parsers := lang.SymbolParsers
+	defer func() { lang.SymbolParsers = parsers }()
+
+	lang.SymbolParsers = nil
+	symbols, err := RangeSymbolChurn(repo, "", "HEAD", 0)
+	assert.Nil(err)
+	assert.Empty(symbols)
+
+	lang.SymbolParsers = []lang.SymbolParser{pythonParser{}}
+	symbols, err = RangeSymbolChurn(repo, "", "HEAD", 0)
+	assert.Nil(err)
+	assert.Len(symbols, 1)
+	assert.Equal(SymbolChurn{File: "f.py", Symbol: "f", Kind: "function", Commits: 2, Insertions: 3, Deletions: 1, Churn: 4,
+		LastTouched: symbols[0].LastTouched}, symbols[0])
+}
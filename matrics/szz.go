@@ -0,0 +1,168 @@
+package metrics
+
+import (
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/andymeneely/git-churn/gitfuncs"
+	"github.com/andymeneely/git-churn/helper"
+	"gopkg.in/src-d/go-git.v4"
+	"gopkg.in/src-d/go-git.v4/plumbing"
+	"gopkg.in/src-d/go-git.v4/plumbing/object"
+)
+
+// BugIntroducingCommit is a commit that added lines later deleted by bug fixes
+type BugIntroducingCommit struct {
+	Hash    string
+	Author  string
+	When    time.Time
+	Message string
+	// Lines of the commit deleted by the fixes
+	BuggyLines int `units:"lines"`
+	// Churn of the whole commit according to the churn definition
+	Churn int `units:"lines"`
+	// Fixes deleting its lines
+	FixedBy []string
+}
+
+// AuthorBugs is the churn of the bug-introducing commits of an author
+type AuthorBugs struct {
+	Author                string
+	BugIntroducingCommits int
+	BuggyLines            int `units:"lines"`
+	BugIntroducingChurn   int `units:"lines"`
+}
+
+// FileBugs is the churn bug-introducing commits made to a file, counting the files with buggy lines only
+type FileBugs struct {
+	File                  string
+	BugIntroducingCommits int
+	BuggyLines            int `units:"lines"`
+	BugIntroducingChurn   int `units:"lines"`
+}
+
+// SZZReport lists the bug-introducing commits of the fixes of a range
+type SZZReport struct {
+	Fixes                 int
+	BugIntroducingCommits []BugIntroducingCommit
+	Authors               []AuthorBugs
+	Files                 []FileBugs
+}
+
+// SZZ identifies the commits that introduced the bugs fixed in the range with the SZZ algorithm: the
+// non-blank lines deleted by the fixes of the range, as told by Fixes, are blamed in the parent of the fix
+// and the commits that added them are deemed bug-introducing, wherever they are in the history. Merges and
+// the commits of Bots are not looked at as fixes. The bug-introducing commits are totalled per author and
+// per file, most buggy lines first.
+func SZZ(repo *git.Repository, from, to string) (*SZZReport, error) {
+	defer helper.Duration(helper.Track("SZZ"))
+	fromHash, toHash, err := resolveRange(repo, from, to)
+	if err != nil {
+		return nil, err
+	}
+	report := &SZZReport{}
+	type bug struct {
+		lines   int
+		byFile  map[string]int
+		fixedBy []string
+	}
+	bugs := make(map[plumbing.Hash]*bug)
+	err = gitfuncs.ForEachCommitBetween(repo, fromHash, toHash, func(commit *object.Commit) error {
+		if commit.NumParents() > 1 || Bots.IsBot(commit) || !Fixes.IsFix(commit.Message) {
+			return nil
+		}
+		report.Fixes += 1
+		fix := commit.Hash.String()
+		return blameDeletedLines(repo, commit, false, func(path string, line *git.Line) {
+			b, ok := bugs[line.Hash]
+			if !ok {
+				b = &bug{byFile: make(map[string]int)}
+				bugs[line.Hash] = b
+			}
+			b.lines += 1
+			b.byFile[path] += 1
+			if len(b.fixedBy) == 0 || b.fixedBy[len(b.fixedBy)-1] != fix {
+				b.fixedBy = append(b.fixedBy, fix)
+			}
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	authors := make(map[string]*AuthorBugs)
+	files := make(map[string]*FileBugs)
+	for hash, b := range bugs {
+		commit, err := repo.CommitObject(hash)
+		if err != nil {
+			return nil, err
+		}
+		churn, err := GetCommitChurn(repo, commit)
+		if err != nil {
+			return nil, err
+		}
+		report.BugIntroducingCommits = append(report.BugIntroducingCommits, BugIntroducingCommit{
+			Hash:       churn.Hash,
+			Author:     churn.Author,
+			When:       churn.When,
+			Message:    strings.SplitN(strings.TrimSpace(churn.Message), "\n", 2)[0],
+			BuggyLines: b.lines,
+			Churn:      churn.Churn(),
+			FixedBy:    b.fixedBy,
+		})
+
+		author, ok := authors[churn.Author]
+		if !ok {
+			author = &AuthorBugs{Author: churn.Author}
+			authors[churn.Author] = author
+		}
+		author.BugIntroducingCommits += 1
+		author.BuggyLines += b.lines
+		author.BugIntroducingChurn += churn.Churn()
+
+		fileChurn := make(map[string]int)
+		for _, file := range churn.Files {
+			fileChurn[file.File] = file.Churn()
+		}
+		for path, lines := range b.byFile {
+			file, ok := files[path]
+			if !ok {
+				file = &FileBugs{File: path}
+				files[path] = file
+			}
+			file.BugIntroducingCommits += 1
+			file.BuggyLines += lines
+			file.BugIntroducingChurn += fileChurn[path]
+		}
+	}
+
+	sort.Slice(report.BugIntroducingCommits, func(i, j int) bool {
+		a, b := report.BugIntroducingCommits[i], report.BugIntroducingCommits[j]
+		if a.BuggyLines != b.BuggyLines {
+			return a.BuggyLines > b.BuggyLines
+		}
+		return a.Hash < b.Hash
+	})
+	for _, author := range authors {
+		report.Authors = append(report.Authors, *author)
+	}
+	sort.Slice(report.Authors, func(i, j int) bool {
+		a, b := report.Authors[i], report.Authors[j]
+		if a.BuggyLines != b.BuggyLines {
+			return a.BuggyLines > b.BuggyLines
+		}
+		return a.Author < b.Author
+	})
+	for _, file := range files {
+		report.Files = append(report.Files, *file)
+	}
+	sort.Slice(report.Files, func(i, j int) bool {
+		a, b := report.Files[i], report.Files[j]
+		if a.BuggyLines != b.BuggyLines {
+			return a.BuggyLines > b.BuggyLines
+		}
+		return a.File < b.File
+	})
+	return report, nil
+}
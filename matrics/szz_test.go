@@ -0,0 +1,43 @@
+package metrics
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSZZ(t *testing.T) {
+	assert := assert.New(t)
+	repo := historyRepo(t,
+		testCommit{"alice", "Add the parser", map[string]string{"a.go": "1\n2\n3\n", "b.go": "x\n"}},
+		testCommit{"bob", "Handle comments", map[string]string{"a.go": "1\n2\n3\n4\n\n5\n", "b.go": "x\ny\n"}},
+		testCommit{"carol", "Fix the parser", map[string]string{"a.go": "1\n3\n4b\n5\n", "b.go": "x\nz\n"}},
+		testCommit{"carol", "Tidy up", map[string]string{"a.go": "1\n3\n"}},
+	)
+	commits, err := RangeChurn(repo, "", "HEAD")
+	assert.Nil(err)
+	add, handle, fix := commits[3], commits[2], commits[1]
+
+	report, err := SZZ(repo, "", "HEAD")
+	assert.Nil(err)
+	assert.Equal(1, report.Fixes)
+	// The fix deletes 2 added by alice, and 4 and y added by bob, the blank line it deletes not counting
+	assert.Len(report.BugIntroducingCommits, 2)
+	assert.Equal(BugIntroducingCommit{Hash: handle.Hash, Author: "bob@example.com", When: handle.When, Message: "Handle comments",
+		BuggyLines: 2, Churn: 4, FixedBy: []string{fix.Hash}}, report.BugIntroducingCommits[0])
+	assert.Equal(add.Hash, report.BugIntroducingCommits[1].Hash)
+	assert.Equal(1, report.BugIntroducingCommits[1].BuggyLines)
+	assert.Equal([]AuthorBugs{
+		{Author: "bob@example.com", BugIntroducingCommits: 1, BuggyLines: 2, BugIntroducingChurn: 4},
+		{Author: "alice@example.com", BugIntroducingCommits: 1, BuggyLines: 1, BugIntroducingChurn: 4},
+	}, report.Authors)
+	assert.Equal([]FileBugs{
+		{File: "a.go", BugIntroducingCommits: 2, BuggyLines: 2, BugIntroducingChurn: 6},
+		{File: "b.go", BugIntroducingCommits: 1, BuggyLines: 1, BugIntroducingChurn: 1},
+	}, report.Files)
+
+	report, err = SZZ(repo, "HEAD~1", "HEAD")
+	assert.Nil(err)
+	assert.Equal(0, report.Fixes)
+	assert.Empty(report.BugIntroducingCommits)
+}
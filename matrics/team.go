@@ -0,0 +1,110 @@
+package metrics
+
+import (
+	"fmt"
+	"time"
+)
+
+// QuarterRetention tells how the set of active authors of a quarter compares to the previous quarter.
+// Churn is counted according to the churn definition, lines added plus lines deleted by default.
+type QuarterRetention struct {
+	Quarter string
+	Authors int
+	// Authors committing for the first time in the analysed history
+	New int
+	// Authors also active in the previous quarter
+	Retained int
+	// Authors active before, but not in the previous quarter
+	Returning int
+	// Authors active in the previous quarter, but not in this one
+	Inactive       int
+	NewChurn       int `units:"lines"`
+	RetainedChurn  int `units:"lines"`
+	ReturningChurn int `units:"lines"`
+	// Churn the inactive authors contributed in the previous quarter
+	InactiveChurn int `units:"lines"`
+}
+
+type quarter struct {
+	year, number int
+}
+
+func quarterOf(t time.Time) quarter {
+	t = t.UTC()
+	return quarter{t.Year(), (int(t.Month())-1)/3 + 1}
+}
+
+func (q quarter) next() quarter {
+	if q.number == 4 {
+		return quarter{q.year + 1, 1}
+	}
+	return quarter{q.year, q.number + 1}
+}
+
+func (q quarter) before(other quarter) bool {
+	return q.year < other.year || (q.year == other.year && q.number < other.number)
+}
+
+func (q quarter) String() string {
+	return fmt.Sprintf("%d-Q%d", q.year, q.number)
+}
+
+// ContributorRetention buckets the commits by quarter and reports, for every quarter between the
+// first and the last commit, how many authors are new, retained, returning or inactive compared to
+// the previous quarter, along with the churn attributable to each group
+func ContributorRetention(commits []*CommitChurn) []QuarterRetention {
+	if len(commits) == 0 {
+		return nil
+	}
+	// churn per author per quarter
+	activity := make(map[quarter]map[string]int)
+	first, last := quarterOf(commits[0].When), quarterOf(commits[0].When)
+	for _, commit := range commits {
+		q := quarterOf(commit.When)
+		if q.before(first) {
+			first = q
+		}
+		if last.before(q) {
+			last = q
+		}
+		if activity[q] == nil {
+			activity[q] = make(map[string]int)
+		}
+		activity[q][commit.Author] += commit.Churn()
+	}
+
+	var retention []QuarterRetention
+	seen := make(map[string]bool)
+	previous := map[string]int{}
+	for q := first; !last.before(q); q = q.next() {
+		current := activity[q]
+		stats := QuarterRetention{Quarter: q.String(), Authors: len(current)}
+		for author, churn := range current {
+			if _, ok := previous[author]; ok {
+				stats.Retained += 1
+				stats.RetainedChurn += churn
+			} else if seen[author] {
+				stats.Returning += 1
+				stats.ReturningChurn += churn
+			} else {
+				stats.New += 1
+				stats.NewChurn += churn
+			}
+		}
+		for author, churn := range previous {
+			if _, ok := current[author]; !ok {
+				stats.Inactive += 1
+				stats.InactiveChurn += churn
+			}
+		}
+		for author := range current {
+			seen[author] = true
+		}
+		if current == nil {
+			current = map[string]int{}
+		}
+		previous = current
+		retention = append(retention, stats)
+	}
+	return retention
+}
@@ -0,0 +1,50 @@
+package metrics
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func churnAt(author string, date string, insertions, deletions int) *CommitChurn {
+	when, _ := time.Parse("2006-01-02", date)
+	return &CommitChurn{Author: author, When: when, Insertions: insertions, Deletions: deletions}
+}
+
+func TestContributorRetention(t *testing.T) {
+	commits := []*CommitChurn{
+		churnAt("alice@example.com", "2020-01-10", 10, 0),
+		churnAt("bob@example.com", "2020-02-10", 5, 5),
+		churnAt("alice@example.com", "2020-04-01", 3, 1),
+		churnAt("carol@example.com", "2020-05-01", 7, 0),
+		churnAt("bob@example.com", "2020-10-01", 2, 2),
+	}
+	retention := ContributorRetention(commits)
+	assert := assert.New(t)
+	assert.Equal(4, len(retention))
+
+	assert.Equal("2020-Q1", retention[0].Quarter)
+	assert.Equal(2, retention[0].New)
+	assert.Equal(20, retention[0].NewChurn)
+
+	assert.Equal("2020-Q2", retention[1].Quarter)
+	assert.Equal(1, retention[1].New)
+	assert.Equal(7, retention[1].NewChurn)
+	assert.Equal(1, retention[1].Retained)
+	assert.Equal(4, retention[1].RetainedChurn)
+	assert.Equal(1, retention[1].Inactive)
+	assert.Equal(10, retention[1].InactiveChurn)
+
+	assert.Equal("2020-Q3", retention[2].Quarter)
+	assert.Equal(0, retention[2].Authors)
+	assert.Equal(2, retention[2].Inactive)
+
+	assert.Equal("2020-Q4", retention[3].Quarter)
+	assert.Equal(1, retention[3].Returning)
+	assert.Equal(4, retention[3].ReturningChurn)
+	assert.Equal(0, retention[3].Inactive)
+}
+
+func TestContributorRetentionNoCommits(t *testing.T) {
+	assert.Nil(t, ContributorRetention(nil))
+}
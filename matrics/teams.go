@@ -0,0 +1,231 @@
+package metrics
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+
+	"gopkg.in/src-d/go-git.v4"
+	"gopkg.in/src-d/go-git.v4/plumbing/format/gitignore"
+)
+
+// NoTeam is the team of the authors and the files the TeamMap does not map
+const NoTeam = "unassigned"
+
+// TeamMap maps the authors and the files of a repository to teams. A nil TeamMap maps nothing.
+type TeamMap struct {
+	// Teams by lowercased author email
+	authors map[string]string
+	// Teams by path, the last matching pattern winning
+	paths []teamPath
+}
+
+type teamPath struct {
+	line    string
+	pattern gitignore.Pattern
+	// Owners declared for the paths, the first being the team, none for paths explicitly left unowned
+	owners []string
+}
+
+// Teams maps the authors and the files to the teams the team metrics aggregate them into
+var Teams *TeamMap
+
+var teamEmail = regexp.MustCompile(`^[^@\s/]+@[^@\s/]+$`)
+
+// ParseTeamMap parses a team mapping file in the format of GitHub's CODEOWNERS: lines of a path pattern, in
+// the syntax of .gitignore, followed by owners, the first of which is the team owning the matching files.
+// The last matching line wins, and a pattern without owners leaves the matching files unowned, mapping
+// them to NoTeam. Lines starting with an author email instead map the author to a team:
+//
+//	alice@example.com  @org/platform
+//	/api/              @org/api @alice
+//	*.js               @org/frontend
+//	/api/generated/
+//
+// The @ of the teams is optional. Blank lines and comments starting with # are ignored.
+func ParseTeamMap(r io.Reader) (*TeamMap, error) {
+	m := &TeamMap{authors: make(map[string]string)}
+	scanner := bufio.NewScanner(r)
+	for number := 1; scanner.Scan(); number++ {
+		line := scanner.Text()
+		if i := strings.Index(line, "#"); i >= 0 {
+			line = line[:i]
+		}
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+		owners := make([]string, len(fields)-1)
+		for i, owner := range fields[1:] {
+			owners[i] = strings.TrimPrefix(owner, "@")
+		}
+		if teamEmail.MatchString(fields[0]) {
+			if len(owners) == 0 {
+				return nil, fmt.Errorf("line %d: author %q has no team", number, fields[0])
+			}
+			m.authors[strings.ToLower(fields[0])] = owners[0]
+		} else {
+			m.paths = append(m.paths, teamPath{fields[0], gitignore.ParsePattern(fields[0], nil), owners})
+		}
+	}
+	return m, scanner.Err()
+}
+
+// ReadTeamMapFile parses the team mapping file at the path, see ParseTeamMap
+func ReadTeamMapFile(path string) (*TeamMap, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return ParseTeamMap(f)
+}
+
+// CodeownersPaths are where GitHub looks for the CODEOWNERS of a repository, in order
+var CodeownersPaths = []string{".github/CODEOWNERS", "CODEOWNERS", "docs/CODEOWNERS"}
+
+// ReadRepoCodeowners parses the CODEOWNERS of the repository at its HEAD as a TeamMap, nil if it has none
+func ReadRepoCodeowners(repo *git.Repository) (*TeamMap, error) {
+	head, err := repo.Head()
+	if err != nil {
+		return nil, err
+	}
+	commit, err := repo.CommitObject(head.Hash())
+	if err != nil {
+		return nil, err
+	}
+	for _, path := range CodeownersPaths {
+		file, err := commit.File(path)
+		if err != nil {
+			continue
+		}
+		reader, err := file.Reader()
+		if err != nil {
+			return nil, err
+		}
+		defer reader.Close()
+		return ParseTeamMap(reader)
+	}
+	return nil, nil
+}
+
+// AuthorTeam returns the team of the author, as resolved by the mailmap, or NoTeam
+func (m *TeamMap) AuthorTeam(email string) string {
+	if m == nil {
+		return NoTeam
+	}
+	if team, ok := m.authors[strings.ToLower(email)]; ok {
+		return team
+	}
+	return NoTeam
+}
+
+// PathTeam returns the team owning the file at the path, or NoTeam
+func (m *TeamMap) PathTeam(path string) string {
+	if entry := m.pathEntry(path); entry >= 0 && len(m.paths[entry].owners) > 0 {
+		return m.paths[entry].owners[0]
+	}
+	return NoTeam
+}
+
+// pathEntry returns the index of the last path pattern matching the path, -1 if none does
+func (m *TeamMap) pathEntry(path string) int {
+	if m == nil {
+		return -1
+	}
+	parts := strings.Split(path, "/")
+	for i := len(m.paths) - 1; i >= 0; i-- {
+		if m.paths[i].pattern.Match(parts, false) == gitignore.Exclude {
+			return i
+		}
+	}
+	return -1
+}
+
+// TeamChurn totals the commits of the authors of a team, and the churn of the files it owns
+type TeamChurn struct {
+	Team string
+	// Authors of the team who committed, their commits and the lines they changed
+	Authors    int
+	Commits    int
+	Insertions int `units:"lines"`
+	Deletions  int `units:"lines"`
+	// Churn according to the churn definition
+	Churn int `units:"lines"`
+	// Files owned by the team that were changed, their churn whoever changed them and the part of it by
+	// the authors of other teams
+	OwnedFiles   int
+	OwnedChurn   int `units:"lines"`
+	OutsideChurn int `units:"lines"`
+}
+
+// ChurnByTeam totals the given commits per team of their author and the churn of their files per team
+// owning them according to Teams, the most churning teams first. The files churn according to the churn
+// definition, like the commits.
+func ChurnByTeam(commits []*CommitChurn) []TeamChurn {
+	byTeam := make(map[string]*TeamChurn)
+	authors := make(map[string]map[string]bool)
+	files := make(map[string]map[string]bool)
+	teamChurn := func(team string) *TeamChurn {
+		churn, ok := byTeam[team]
+		if !ok {
+			churn = &TeamChurn{Team: team}
+			byTeam[team] = churn
+			authors[team] = make(map[string]bool)
+			files[team] = make(map[string]bool)
+		}
+		return churn
+	}
+	for _, commit := range countedCommits(commits) {
+		team := Teams.AuthorTeam(commit.Author)
+		churn := teamChurn(team)
+		authors[team][commit.Author] = true
+		churn.Commits += 1
+		churn.Insertions += commit.Insertions
+		churn.Deletions += commit.Deletions
+		churn.Churn += commit.Churn()
+		for _, file := range commit.Files {
+			owner := Teams.PathTeam(file.File)
+			owned := teamChurn(owner)
+			files[owner][file.File] = true
+			owned.OwnedChurn += file.Churn()
+			if owner != team {
+				owned.OutsideChurn += file.Churn()
+			}
+		}
+	}
+
+	teams := make([]TeamChurn, 0, len(byTeam))
+	for team, churn := range byTeam {
+		churn.Authors = len(authors[team])
+		churn.OwnedFiles = len(files[team])
+		teams = append(teams, *churn)
+	}
+	sort.Slice(teams, func(i, j int) bool {
+		if teams[i].Churn != teams[j].Churn {
+			return teams[i].Churn > teams[j].Churn
+		}
+		return teams[i].Team < teams[j].Team
+	})
+	return teams
+}
+
+// setTeamOwnership sets the team owning the path of the file and the team owning most of its lines,
+// given the lines owned per author
+func setTeamOwnership(ownership *FileOwnership, owners map[string]int) {
+	ownership.Team = Teams.PathTeam(ownership.File)
+	teamLines := make(map[string]int)
+	for owner, lines := range owners {
+		teamLines[Teams.AuthorTeam(owner)] += lines
+	}
+	for team, lines := range teamLines {
+		if lines > ownership.OwnerTeamLines || (lines == ownership.OwnerTeamLines && team < ownership.OwnerTeam) {
+			ownership.OwnerTeam = team
+			ownership.OwnerTeamLines = lines
+		}
+	}
+}
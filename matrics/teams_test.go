@@ -0,0 +1,78 @@
+package metrics
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/andymeneely/git-churn/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+const testTeams = `# authors
+Alice@Example.com  @org/platform
+bob@example.com    web
+
+# paths
+*                  @org/platform
+/web/              @web @carol
+*.md               docs # last match wins
+/web/vendor/
+`
+
+func TestParseTeamMap(t *testing.T) {
+	assert := assert.New(t)
+	teams, err := ParseTeamMap(strings.NewReader(testTeams))
+	assert.Nil(err)
+	assert.Equal("org/platform", teams.AuthorTeam("alice@example.com"))
+	assert.Equal("web", teams.AuthorTeam("bob@example.com"))
+	assert.Equal(NoTeam, teams.AuthorTeam("carol@example.com"))
+	assert.Equal("org/platform", teams.PathTeam("main.go"))
+	assert.Equal("web", teams.PathTeam("web/app.js"))
+	assert.Equal("docs", teams.PathTeam("web/README.md"))
+	assert.Equal(NoTeam, teams.PathTeam("web/vendor/lib.js"))
+
+	var none *TeamMap
+	assert.Equal(NoTeam, none.PathTeam("main.go"))
+	_, err = ParseTeamMap(strings.NewReader("alice@example.com\n"))
+	assert.EqualError(err, `line 1: author "alice@example.com" has no team`)
+}
+
+func TestChurnByTeam(t *testing.T) {
+	assert := assert.New(t)
+	var err error
+	Teams, err = ParseTeamMap(strings.NewReader(testTeams))
+	assert.Nil(err)
+	defer func() { Teams = nil }()
+	commits := []*CommitChurn{
+		{Author: "alice@example.com", Insertions: 10, Deletions: 2, Files: []FileChurn{
+			{File: "main.go", Insertions: 8, Deletions: 2}, {File: "web/app.js", Insertions: 2}}},
+		{Author: "bob@example.com", Insertions: 5, Files: []FileChurn{{File: "web/app.js", Insertions: 5}}},
+		{Author: "carol@example.com", Insertions: 1, Files: []FileChurn{{File: "README.md", Insertions: 1}}},
+	}
+
+	assert.Equal([]TeamChurn{
+		{Team: "org/platform", Authors: 1, Commits: 1, Insertions: 10, Deletions: 2, Churn: 12, OwnedFiles: 1, OwnedChurn: 10},
+		{Team: "web", Authors: 1, Commits: 1, Insertions: 5, Churn: 5, OwnedFiles: 1, OwnedChurn: 7, OutsideChurn: 2},
+		{Team: NoTeam, Authors: 1, Commits: 1, Insertions: 1, Churn: 1},
+		{Team: "docs", OwnedFiles: 1, OwnedChurn: 1, OutsideChurn: 1},
+	}, ChurnByTeam(commits))
+
+	contributors, err := Contributors(commits, "commits")
+	assert.Nil(err)
+	assert.Equal("org/platform", contributors[0].Team)
+	assert.Equal(NoTeam, contributors[2].Team)
+}
+
+func TestReadRepoCodeowners(t *testing.T) {
+	assert := assert.New(t)
+	repo := testutil.NewRepo(t)
+	repo.CommitFiles("add", map[string]string{"main.go": "package main\n"})
+	teams, err := ReadRepoCodeowners(repo.Repository)
+	assert.Nil(err)
+	assert.Nil(teams)
+
+	repo.CommitFiles("owners", map[string]string{".github/CODEOWNERS": "*.go @org/backend\n"})
+	teams, err = ReadRepoCodeowners(repo.Repository)
+	assert.Nil(err)
+	assert.Equal("org/backend", teams.PathTeam("cmd/main.go"))
+}
@@ -0,0 +1,189 @@
+package metrics
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+// DefaultRampMonths is the number of months after their first commit the ramp of the authors is followed for
+const DefaultRampMonths = 6
+
+// DefaultRampShare is the share of their busiest month of the ramp an author has to reach to be ramped up
+const DefaultRampShare = 0.5
+
+// RampMonth is the activity of an author in a month of their ramp, counted from their first commit
+type RampMonth struct {
+	// From 1, the month of the first commit
+	Month   int
+	Start   time.Time
+	Commits int
+	// Churn according to the churn definition
+	Churn       int `units:"lines"`
+	ActiveWeeks int
+	// Month the range ends within, whose activity is still incomplete
+	Partial bool `json:",omitempty"`
+}
+
+// AuthorTenure is the tenure of an author in the range and their churn over their first months
+type AuthorTenure struct {
+	Author string
+	Name   string
+	// First and last commit of the author in the range, the first one being their start when the range
+	// covers the whole history
+	FirstCommit time.Time
+	LastCommit  time.Time
+	TenureDays  int
+	// Distinct weeks the author committed in
+	ActiveWeeks int
+	Commits     int
+	// Churn according to the churn definition
+	Churn int `units:"lines"`
+	// Month of the ramp the author first reached the ramp share of their busiest month in, zero when the
+	// range ends before their ramp does
+	RampMonths int `json:",omitempty"`
+	// The months of the ramp up to the end of the range
+	Ramp []RampMonth
+	// Team of the author, when Teams are mapped
+	Team string `json:",omitempty"`
+}
+
+// TenureReport is the tenure and the onboarding ramp of the authors of a range
+type TenureReport struct {
+	// Length of the ramp in months
+	Months    int
+	RampShare float64
+	// Median of the RampMonths of the authors whose ramp the range covers
+	MedianRampMonths float64
+	// Earliest first commit first
+	Authors []AuthorTenure
+}
+
+type isoWeek struct {
+	year, week int
+}
+
+func isoWeekOf(t time.Time) isoWeek {
+	year, week := t.UTC().ISOWeek()
+	return isoWeek{year, week}
+}
+
+// monthsSince returns the number of whole months from start to t
+func monthsSince(start, t time.Time) int {
+	months := (t.Year()-start.Year())*12 + int(t.Month()) - int(start.Month())
+	if start.AddDate(0, months, 0).After(t) {
+		months--
+	}
+	return months
+}
+
+// AuthorTenures reports per author the first and last commit of the given commits, the weeks they were
+// active in, and their commits and churn month by month over the given number of months from their first
+// commit, to follow how fast new authors ramp up. An author is ramped up in the first month their churn
+// reaches rampShare of the churn of their busiest month of the ramp. The months are counted in UTC, and
+// those after the end of the range, its last commit, are left out. So are the merges of branches whose
+// commits are among the given ones, their churn being that of the commits over again.
+func AuthorTenures(commits []*CommitChurn, months int, rampShare float64) (*TenureReport, error) {
+	if months < 1 {
+		return nil, fmt.Errorf("the ramp has to last at least a month, got %d", months)
+	}
+	if rampShare <= 0 || rampShare > 1 {
+		return nil, fmt.Errorf("the ramp share has to be above 0 and at most 1, got %v", rampShare)
+	}
+	report := &TenureReport{Months: months, RampShare: rampShare, Authors: []AuthorTenure{}}
+	if len(commits) == 0 {
+		return report, nil
+	}
+
+	end := commits[0].When.UTC()
+	byAuthor := make(map[string]*AuthorTenure)
+	authorCommits := make(map[string][]*CommitChurn)
+	for _, commit := range commits {
+		if commit.mergedBranch() {
+			continue
+		}
+		when := commit.When.UTC()
+		if when.After(end) {
+			end = when
+		}
+		tenure, ok := byAuthor[commit.Author]
+		if !ok {
+			tenure = &AuthorTenure{Author: commit.Author, FirstCommit: when, LastCommit: when}
+			byAuthor[commit.Author] = tenure
+		}
+		tenure.Commits += 1
+		tenure.Churn += commit.Churn()
+		if when.Before(tenure.FirstCommit) {
+			tenure.FirstCommit = when
+		}
+		// The name of the latest commit, like Contributors
+		if !when.Before(tenure.LastCommit) {
+			tenure.LastCommit = when
+			tenure.Name = commit.AuthorName
+		}
+		authorCommits[commit.Author] = append(authorCommits[commit.Author], commit)
+	}
+
+	var ramps []float64
+	for email, tenure := range byAuthor {
+		tenure.TenureDays = int(tenure.LastCommit.Sub(tenure.FirstCommit).Hours() / 24)
+		for month := 0; month < months; month++ {
+			start := tenure.FirstCommit.AddDate(0, month, 0)
+			if start.After(end) {
+				break
+			}
+			tenure.Ramp = append(tenure.Ramp, RampMonth{
+				Month:   month + 1,
+				Start:   start,
+				Partial: tenure.FirstCommit.AddDate(0, month+1, 0).After(end),
+			})
+		}
+		weeks := make(map[isoWeek]bool)
+		monthWeeks := make([]map[isoWeek]bool, len(tenure.Ramp))
+		for _, commit := range authorCommits[email] {
+			when := commit.When.UTC()
+			weeks[isoWeekOf(when)] = true
+			month := monthsSince(tenure.FirstCommit, when)
+			if month >= len(tenure.Ramp) {
+				continue
+			}
+			tenure.Ramp[month].Commits += 1
+			tenure.Ramp[month].Churn += commit.Churn()
+			if monthWeeks[month] == nil {
+				monthWeeks[month] = make(map[isoWeek]bool)
+			}
+			monthWeeks[month][isoWeekOf(when)] = true
+		}
+		tenure.ActiveWeeks = len(weeks)
+		peak := 0
+		for i := range tenure.Ramp {
+			tenure.Ramp[i].ActiveWeeks = len(monthWeeks[i])
+			if tenure.Ramp[i].Churn > peak {
+				peak = tenure.Ramp[i].Churn
+			}
+		}
+		// The busiest month is only known once the range covers the whole ramp
+		if len(tenure.Ramp) == months && !tenure.Ramp[months-1].Partial && peak > 0 {
+			for _, month := range tenure.Ramp {
+				if float64(month.Churn) >= rampShare*float64(peak) {
+					tenure.RampMonths = month.Month
+					break
+				}
+			}
+			ramps = append(ramps, float64(tenure.RampMonths))
+		}
+		if Teams != nil {
+			tenure.Team = Teams.AuthorTeam(email)
+		}
+		report.Authors = append(report.Authors, *tenure)
+	}
+	report.MedianRampMonths = median(ramps)
+	sort.Slice(report.Authors, func(i, j int) bool {
+		a, b := report.Authors[i], report.Authors[j]
+		if !a.FirstCommit.Equal(b.FirstCommit) {
+			return a.FirstCommit.Before(b.FirstCommit)
+		}
+		return a.Author < b.Author
+	})
+	return report, nil
+}
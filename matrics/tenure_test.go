@@ -0,0 +1,77 @@
+package metrics
+
+import (
+	"testing"
+	"time"
+
+	"github.com/andymeneely/git-churn/gitfuncs"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAuthorTenures(t *testing.T) {
+	assert := assert.New(t)
+	merge := churnAt("dave@example.com", "2020-04-20", 43, 0)
+	merge.Parents = 2
+	commits := []*CommitChurn{
+		churnAt("carol@example.com", "2020-05-01", 3, 0),
+		// Merge of the branches of alice, left out
+		merge,
+		churnAt("alice@example.com", "2020-03-15", 40, 0),
+		churnAt("bob@example.com", "2020-03-01", 1, 1),
+		churnAt("alice@example.com", "2020-02-20", 20, 0),
+		churnAt("alice@example.com", "2020-01-11", 5, 0),
+		churnAt("alice@example.com", "2020-01-10", 5, 0),
+	}
+	report, err := AuthorTenures(commits, 3, 0.5)
+	assert.Nil(err)
+	assert.Equal(3, report.Months)
+	assert.Len(report.Authors, 3)
+
+	alice := report.Authors[0]
+	assert.Equal("alice@example.com", alice.Author)
+	assert.Equal(time.Date(2020, 1, 10, 0, 0, 0, 0, time.UTC), alice.FirstCommit)
+	assert.Equal(65, alice.TenureDays)
+	assert.Equal(3, alice.ActiveWeeks)
+	assert.Equal(4, alice.Commits)
+	assert.Equal(70, alice.Churn)
+	assert.Equal([]RampMonth{
+		{Month: 1, Start: time.Date(2020, 1, 10, 0, 0, 0, 0, time.UTC), Commits: 2, Churn: 10, ActiveWeeks: 1},
+		{Month: 2, Start: time.Date(2020, 2, 10, 0, 0, 0, 0, time.UTC), Commits: 1, Churn: 20, ActiveWeeks: 1},
+		{Month: 3, Start: time.Date(2020, 3, 10, 0, 0, 0, 0, time.UTC), Commits: 1, Churn: 40, ActiveWeeks: 1},
+	}, alice.Ramp)
+	// 20 is half of the 40 of the busiest month
+	assert.Equal(2, alice.RampMonths)
+
+	// The range ends within the third month of bob, and the first of carol
+	bob := report.Authors[1]
+	assert.Equal("bob@example.com", bob.Author)
+	assert.Len(bob.Ramp, 3)
+	assert.False(bob.Ramp[1].Partial)
+	assert.True(bob.Ramp[2].Partial)
+	assert.Equal(0, bob.RampMonths)
+	carol := report.Authors[2]
+	assert.Equal("carol@example.com", carol.Author)
+	assert.Equal([]RampMonth{{Month: 1, Start: time.Date(2020, 5, 1, 0, 0, 0, 0, time.UTC), Commits: 1, Churn: 3, ActiveWeeks: 1, Partial: true}}, carol.Ramp)
+
+	assert.Equal(2.0, report.MedianRampMonths)
+
+	// The merges stand for the commits of their branch when only the first parents are walked
+	gitfuncs.FirstParent = true
+	defer func() { gitfuncs.FirstParent = false }()
+	report, err = AuthorTenures(commits, 3, 0.5)
+	assert.Nil(err)
+	assert.Len(report.Authors, 4)
+	assert.Equal("dave@example.com", report.Authors[2].Author)
+	assert.Equal(43, report.Authors[2].Churn)
+}
+
+func TestAuthorTenuresInvalid(t *testing.T) {
+	assert := assert.New(t)
+	report, err := AuthorTenures(nil, DefaultRampMonths, DefaultRampShare)
+	assert.Nil(err)
+	assert.Empty(report.Authors)
+	_, err = AuthorTenures(nil, 0, DefaultRampShare)
+	assert.EqualError(err, "the ramp has to last at least a month, got 0")
+	_, err = AuthorTenures(nil, DefaultRampMonths, 1.5)
+	assert.EqualError(err, "the ramp share has to be above 0 and at most 1, got 1.5")
+}
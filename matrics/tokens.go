@@ -0,0 +1,47 @@
+package metrics
+
+import (
+	"github.com/andymeneely/git-churn/gitfuncs"
+	"gopkg.in/src-d/go-git.v4"
+	"gopkg.in/src-d/go-git.v4/plumbing/object"
+)
+
+// CountTokens makes the diff and range metrics count the tokens of code added and deleted along with the
+// lines, see gitfuncs.TokenChanges. It takes one more diff of every changed file.
+var CountTokens bool
+
+// setTokenChanges totals the tokens added and deleted by the changes when CountTokens is set
+func setTokenChanges(changes object.Changes, diffMetrics *DiffMetrics) error {
+	if !CountTokens {
+		return nil
+	}
+	tokenChanges, err := gitfuncs.TokenChanges(changes)
+	if err != nil {
+		return err
+	}
+	diffMetrics.TokenInsertions, diffMetrics.TokenDeletions = 0, 0
+	for _, change := range tokenChanges {
+		diffMetrics.TokenInsertions += change.Insertions
+		diffMetrics.TokenDeletions += change.Deletions
+	}
+	return nil
+}
+
+// setCommitTokenChanges totals the tokens the commit added and deleted in the files not ignored when
+// CountTokens is set
+func setCommitTokenChanges(repo *git.Repository, commit *object.Commit, churn *CommitChurn) error {
+	if !CountTokens {
+		return nil
+	}
+	tokenChanges, err := gitfuncs.CommitTokenChanges(commit)
+	if err != nil {
+		return err
+	}
+	for _, change := range tokenChanges {
+		if !gitfuncs.IsIgnored(repo, change.Path) {
+			churn.TokenInsertions += change.Insertions
+			churn.TokenDeletions += change.Deletions
+		}
+	}
+	return nil
+}
@@ -0,0 +1,29 @@
+package metrics
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCountTokens(t *testing.T) {
+	assert := assert.New(t)
+	repo := snapshotRepo(t,
+		map[string]string{"a.go": "return sum(a, b)\n", "vendor/v.go": "v := 1\n"},
+		map[string]string{"a.go": "return sum(\n\ta,\n\tb)\n", "vendor/v.go": "w := 2\n"},
+	)
+	defer func() { CountTokens = false }()
+
+	commits, err := RangeChurn(repo, "", "HEAD")
+	assert.Nil(err)
+	assert.Equal(0, commits[0].TokenInsertions)
+
+	CountTokens = true
+	commits, err = RangeChurn(repo, "", "HEAD")
+	assert.Nil(err)
+	assert.Equal(3, commits[0].Insertions)
+	assert.Equal(1, commits[0].Deletions)
+	assert.Equal(0, commits[0].TokenInsertions)
+	assert.Equal(0, commits[0].TokenDeletions)
+	assert.Equal(7, commits[1].TokenInsertions)
+}
@@ -0,0 +1,82 @@
+package metrics
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+// TopFile is the churn a file accumulated over a window of commits
+type TopFile struct {
+	File       string
+	Commits    int
+	Insertions int `units:"lines"`
+	Deletions  int `units:"lines"`
+	// Churn according to the churn definition
+	Churn       int `units:"lines"`
+	LastTouched time.Time
+}
+
+// TopSortKeys are the keys TopFiles can rank the files by
+var TopSortKeys = []string{"churn", "commits", "insertions", "deletions", "last"}
+
+// TopFiles totals the churn of every file changed by the given commits and returns the n files ranking
+// first by the given key (all of them if n is zero): churn, commits, insertions, deletions or last, the
+// most recently touched first. Ties are ranked by file name. The merges of branches whose commits are
+// counted are left out.
+func TopFiles(commits []*CommitChurn, sortBy string, n int) ([]TopFile, error) {
+	var greater func(a, b *TopFile) bool
+	switch sortBy {
+	case "", "churn":
+		greater = func(a, b *TopFile) bool { return a.Churn > b.Churn }
+	case "commits":
+		greater = func(a, b *TopFile) bool { return a.Commits > b.Commits }
+	case "insertions":
+		greater = func(a, b *TopFile) bool { return a.Insertions > b.Insertions }
+	case "deletions":
+		greater = func(a, b *TopFile) bool { return a.Deletions > b.Deletions }
+	case "last":
+		greater = func(a, b *TopFile) bool { return a.LastTouched.After(b.LastTouched) }
+	default:
+		return nil, fmt.Errorf("unknown sort key %q, expected one of %v", sortBy, TopSortKeys)
+	}
+
+	byFile := make(map[string]*TopFile)
+	for _, commit := range commits {
+		if commit.mergedBranch() {
+			continue
+		}
+		for _, file := range commit.Files {
+			top, ok := byFile[file.File]
+			if !ok {
+				top = &TopFile{File: file.File}
+				byFile[file.File] = top
+			}
+			top.Commits += 1
+			top.Insertions += file.Insertions
+			top.Deletions += file.Deletions
+			top.Churn += file.Churn()
+			if commit.When.After(top.LastTouched) {
+				top.LastTouched = commit.When
+			}
+		}
+	}
+
+	files := make([]TopFile, 0, len(byFile))
+	for _, top := range byFile {
+		files = append(files, *top)
+	}
+	sort.Slice(files, func(i, j int) bool {
+		if greater(&files[i], &files[j]) {
+			return true
+		}
+		if greater(&files[j], &files[i]) {
+			return false
+		}
+		return files[i].File < files[j].File
+	})
+	if n > 0 && len(files) > n {
+		files = files[:n]
+	}
+	return files, nil
+}
@@ -0,0 +1,71 @@
+package metrics
+
+import (
+	"testing"
+	"time"
+
+	"github.com/andymeneely/git-churn/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTopFiles(t *testing.T) {
+	assert := assert.New(t)
+	commits := []*CommitChurn{
+		churnAt("alice@example.com", "2020-02-10", 0, 0),
+		churnAt("bob@example.com", "2020-01-10", 0, 0),
+		churnAt("alice@example.com", "2020-02-01", 0, 0),
+	}
+	commits[0].Files = []FileChurn{{File: "a.go", Insertions: 1, Deletions: 1}}
+	commits[1].Files = []FileChurn{{File: "a.go", Insertions: 3}, {File: "b.go", Insertions: 10, Deletions: 2}}
+	commits[2].Files = []FileChurn{{File: "c.go", Insertions: 2, Deletions: 3}}
+
+	top, err := TopFiles(commits, "churn", 0)
+	assert.Nil(err)
+	assert.Equal([]TopFile{
+		{File: "b.go", Commits: 1, Insertions: 10, Deletions: 2, Churn: 12, LastTouched: commits[1].When},
+		{File: "a.go", Commits: 2, Insertions: 4, Deletions: 1, Churn: 5, LastTouched: commits[0].When},
+		{File: "c.go", Commits: 1, Insertions: 2, Deletions: 3, Churn: 5, LastTouched: commits[2].When},
+	}, top)
+
+	top, err = TopFiles(commits, "commits", 1)
+	assert.Nil(err)
+	assert.Equal([]string{"a.go"}, topNames(top))
+
+	top, err = TopFiles(commits, "deletions", 0)
+	assert.Nil(err)
+	assert.Equal([]string{"c.go", "b.go", "a.go"}, topNames(top))
+
+	top, err = TopFiles(commits, "last", 2)
+	assert.Nil(err)
+	assert.Equal([]string{"a.go", "c.go"}, topNames(top))
+	assert.True(top[0].LastTouched.Equal(time.Date(2020, 2, 10, 0, 0, 0, 0, time.UTC)))
+
+	_, err = TopFiles(commits, "size", 0)
+	assert.NotNil(err)
+}
+
+func TestTopFilesMerge(t *testing.T) {
+	assert := assert.New(t)
+	repo := testutil.NewDivergedRepo(t)
+	repo.Merge("feature", "merge")
+	commits, err := RangeChurn(repo.Repository, "", "HEAD")
+	assert.Nil(err)
+
+	// The files the merge brought from the branch are counted once, by the commits of the branch
+	files, err := TopFiles(commits, "commits", 0)
+	assert.Nil(err)
+	assert.Equal([]string{"a.txt", "b.txt", "c.txt"}, topNames(files))
+	assert.Equal(3, files[0].Commits)
+	assert.Equal(5, files[0].Insertions)
+	assert.Equal(1, files[0].Deletions)
+	assert.Equal(2, files[2].Commits)
+	assert.Equal(2, files[2].Insertions)
+}
+
+func topNames(files []TopFile) []string {
+	var names []string
+	for _, file := range files {
+		names = append(names, file.File)
+	}
+	return names
+}
@@ -0,0 +1,102 @@
+package metrics
+
+import (
+	"fmt"
+
+	"github.com/andymeneely/git-churn/gitfuncs"
+	"github.com/andymeneely/git-churn/helper"
+	"gopkg.in/src-d/go-git.v4"
+	"gopkg.in/src-d/go-git.v4/plumbing/object"
+	"gopkg.in/src-d/go-git.v4/plumbing/storer"
+)
+
+type InvariantViolation struct {
+	Commit    string
+	File      string `json:",omitempty"`
+	Invariant string
+	Detail    string
+}
+
+// VerifyInvariants runs the diff and churn metrics on the last n non merge commits leading to the
+// revision and checks the invariants every result must satisfy: counts are never negative, the lines
+// inserted minus the lines deleted equal the change in LOC, and every deleted line is either self or
+// interactive churn. The revision is left checked out.
+func VerifyInvariants(repo *git.Repository, revision string, n int) ([]InvariantViolation, error) {
+	defer helper.Duration(helper.Track("VerifyInvariants"))
+	hash, err := gitfuncs.ResolveRef(repo, revision)
+	if err != nil {
+		return nil, err
+	}
+	commitIter, err := repo.Log(&git.LogOptions{From: *hash})
+	if err != nil {
+		return nil, err
+	}
+	var commits []*object.Commit
+	err = commitIter.ForEach(func(c *object.Commit) error {
+		if len(commits) == n {
+			return storer.ErrStop
+		}
+		// The diff metrics compare a commit with its only parent
+		if c.NumParents() == 1 {
+			commits = append(commits, c)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var violations []InvariantViolation
+	for _, commit := range commits {
+		if err := gitfuncs.SetHead(repo, commit.Hash); err != nil {
+			return nil, err
+		}
+		violations = append(violations, verifyCommit(repo, commit)...)
+	}
+	return violations, gitfuncs.SetHead(repo, *hash)
+}
+
+// verifyCommit checks the invariants of the commit checked out at HEAD
+func verifyCommit(repo *git.Repository, commit *object.Commit) []InvariantViolation {
+	var violations []InvariantViolation
+	violated := func(file, invariant, format string, args ...interface{}) {
+		violations = append(violations, InvariantViolation{commit.Hash.String(), file, invariant, fmt.Sprintf(format, args...)})
+	}
+	checkDiff := func(file string, diff DiffMetrics) {
+		if diff.Insertions < 0 || diff.Deletions < 0 || diff.LinesBefore < 0 || diff.LinesAfter < 0 {
+			violated(file, "non-negative counts", "%+v", diff)
+		}
+		if diff.Insertions-diff.Deletions != diff.LinesAfter-diff.LinesBefore {
+			violated(file, "insertions - deletions = LOC delta", "%d - %d != %d - %d",
+				diff.Insertions, diff.Deletions, diff.LinesAfter, diff.LinesBefore)
+		}
+	}
+
+	aggregated := AggrDiffMetricsWithWhitespace(repo)
+	checkDiff("", aggregated.DiffMetrics)
+	if aggregated.NewFiles < 0 || aggregated.DeletedFiles < 0 || aggregated.FilesCount < 0 {
+		violated("", "non-negative counts", "%+v", aggregated)
+	}
+
+	stats, err := commit.Stats()
+	if err != nil {
+		violated("", "stats", "%s", err)
+		return violations
+	}
+	for _, stat := range stats {
+		checkDiff(stat.Name, CalculateDiffMetricsWithWhitespace(repo, stat.Name).DiffMetrics)
+		churn, err := GetChurnMetricsWithWhitespace(repo, stat.Name)
+		if err != nil {
+			// New files have no churn
+			continue
+		}
+		if churn.SelfChurnCount+churn.InteractiveChurnCount != churn.DeletedLinesCount {
+			violated(stat.Name, "self + interactive churn = deleted lines", "%d + %d != %d",
+				churn.SelfChurnCount, churn.InteractiveChurnCount, churn.DeletedLinesCount)
+		}
+		if churn.DeletedLinesCount != churn.FileDiffMetrics.Deletions {
+			violated(stat.Name, "deleted lines = deletions", "%d != %d", churn.DeletedLinesCount, churn.FileDiffMetrics.Deletions)
+		}
+	}
+	return violations
+}
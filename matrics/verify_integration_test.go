@@ -0,0 +1,19 @@
+//go:build integration
+// +build integration
+
+package metrics
+
+import (
+	"github.com/andymeneely/git-churn/gitfuncs"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+// Runs the whole pipeline against a small public repository, enable with go test -tags integration
+func TestVerifyInvariantsRemote(t *testing.T) {
+	repo := gitfuncs.Clone("https://github.com/andymeneely/git-churn")
+	violations, err := VerifyInvariants(repo, "HEAD", 10)
+	assert := assert.New(t)
+	assert.Nil(err)
+	assert.Empty(violations)
+}
@@ -0,0 +1,152 @@
+package print
+
+import (
+	"fmt"
+	"io"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Units line counts can be rendered in
+const (
+	UnitsLines = "lines"
+	UnitsKLOC  = "kloc"
+)
+
+// TextFormat renders results for humans. Line counts are shown in the chosen units and ratios and
+// scores are rounded to the chosen precision, while JSON output always keeps the raw values.
+type TextFormat struct {
+	Units     string
+	Precision int
+}
+
+// UnitsTag is the struct tag marking the integer fields holding line counts, and the lists and maps of
+// them, as in
+//
+//	Insertions int `units:"lines"`
+const UnitsTag = "units"
+
+// isLineCount tells by its tag whether a field holds a number of lines
+func isLineCount(field reflect.StructField) bool {
+	return field.Tag.Get(UnitsTag) == UnitsLines
+}
+
+// FormatLines renders a number of lines in the chosen units
+func (f TextFormat) FormatLines(lines int) string {
+	if f.Units == UnitsKLOC {
+		return strconv.FormatFloat(float64(lines)/1000, 'f', f.Precision, 64) + " kLOC"
+	}
+	return strconv.Itoa(lines)
+}
+
+// FormatRatio renders a ratio or a score rounded to the chosen precision
+func (f TextFormat) FormatRatio(ratio float64) string {
+	return strconv.FormatFloat(ratio, 'f', f.Precision, 64)
+}
+
+// Write renders the result as indented "Name: value" lines
+func (f TextFormat) Write(w io.Writer, result interface{}) error {
+	return f.write(w, reflect.ValueOf(result), "", "", "", false)
+}
+
+// write renders the value, as a line count in the chosen units when lines is set and it is an integer
+func (f TextFormat) write(w io.Writer, v reflect.Value, name, indent, prefix string, lines bool) error {
+	for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return f.scalar(w, name, indent, prefix, "null")
+		}
+		v = v.Elem()
+	}
+	if t, ok := v.Interface().(time.Time); ok {
+		return f.scalar(w, name, indent, prefix, t.Format(time.RFC3339))
+	}
+
+	switch v.Kind() {
+	case reflect.Struct:
+		return f.fields(w, v, name, indent, prefix)
+	case reflect.Map:
+		if err := f.header(w, name, indent, prefix); err != nil {
+			return err
+		}
+		keys := v.MapKeys()
+		sort.Slice(keys, func(i, j int) bool { return fmt.Sprint(keys[i]) < fmt.Sprint(keys[j]) })
+		for _, key := range keys {
+			if err := f.write(w, v.MapIndex(key), fmt.Sprint(key), f.childIndent(name, indent, prefix), "", lines); err != nil {
+				return err
+			}
+		}
+		return nil
+	case reflect.Slice, reflect.Array:
+		if err := f.header(w, name, indent, prefix); err != nil {
+			return err
+		}
+		for i := 0; i < v.Len(); i++ {
+			if err := f.write(w, v.Index(i), "", f.childIndent(name, indent, prefix), "- ", lines); err != nil {
+				return err
+			}
+		}
+		return nil
+	case reflect.Float32, reflect.Float64:
+		return f.scalar(w, name, indent, prefix, f.FormatRatio(v.Float()))
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if lines {
+			return f.scalar(w, name, indent, prefix, f.FormatLines(int(v.Int())))
+		}
+	}
+	return f.scalar(w, name, indent, prefix, fmt.Sprint(v.Interface()))
+}
+
+// fields writes the exported fields of a struct, flattening the embedded ones
+func (f TextFormat) fields(w io.Writer, v reflect.Value, name, indent, prefix string) error {
+	if err := f.header(w, name, indent, prefix); err != nil {
+		return err
+	}
+	childIndent := f.childIndent(name, indent, prefix)
+	if name == "" {
+		childIndent = indent
+	}
+	for i := 0; i < v.NumField(); i++ {
+		field := v.Type().Field(i)
+		if field.Anonymous && v.Field(i).Kind() == reflect.Struct {
+			if err := f.fields(w, v.Field(i), "", childIndent, prefix); err != nil {
+				return err
+			}
+		} else if field.PkgPath != "" {
+			continue
+		} else if err := f.write(w, v.Field(i), field.Name, childIndent, prefix, isLineCount(field)); err != nil {
+			return err
+		}
+		// Only the first field of a list item carries the dash
+		if prefix != "" {
+			childIndent, prefix = childIndent+strings.Repeat(" ", len(prefix)), ""
+		}
+	}
+	return nil
+}
+
+func (f TextFormat) childIndent(name, indent, prefix string) string {
+	if name == "" {
+		return indent + prefix
+	}
+	return indent + prefix + "  "
+}
+
+func (f TextFormat) header(w io.Writer, name, indent, prefix string) error {
+	if name == "" {
+		return nil
+	}
+	_, err := fmt.Fprintf(w, "%s%s%s:\n", indent, prefix, name)
+	return err
+}
+
+func (f TextFormat) scalar(w io.Writer, name, indent, prefix, value string) error {
+	if name == "" {
+		_, err := fmt.Fprintf(w, "%s%s%s\n", indent, prefix, value)
+		return err
+	}
+	_, err := fmt.Fprintf(w, "%s%s%s: %s\n", indent, prefix, name, value)
+	return err
+}
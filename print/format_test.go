@@ -0,0 +1,64 @@
+package print
+
+import (
+	"bytes"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+type innerResult struct {
+	Insertions int `units:"lines"`
+	Deletions  int `units:"lines"`
+	// Named like a line count, but not tagged as one
+	TokenInsertions int
+}
+
+type fileResult struct {
+	File  string
+	Score float64
+}
+
+type textResult struct {
+	innerResult
+	Commits int
+	Files   []fileResult
+	Authors map[string]int `units:"lines"`
+	Weeks   []int          `units:"lines"`
+}
+
+func TestFormatLines(t *testing.T) {
+	assert := assert.New(t)
+	assert.Equal("1234", TextFormat{Units: UnitsLines, Precision: 1}.FormatLines(1234))
+	assert.Equal("1.2 kLOC", TextFormat{Units: UnitsKLOC, Precision: 1}.FormatLines(1234))
+	assert.Equal("0.333", TextFormat{Precision: 3}.FormatRatio(1.0/3))
+}
+
+func TestTextFormatWrite(t *testing.T) {
+	result := textResult{
+		innerResult: innerResult{Insertions: 1500, Deletions: 20, TokenInsertions: 4000},
+		Commits:     3,
+		Files:       []fileResult{{"a.go", 0.123456}, {"b.go", 1}},
+		Authors:     map[string]int{"bob": 10, "alice": 2000},
+		Weeks:       []int{1000, 0},
+	}
+	var out bytes.Buffer
+	err := TextFormat{Units: UnitsKLOC, Precision: 2}.Write(&out, &result)
+	assert := assert.New(t)
+	assert.Nil(err)
+	assert.Equal(`Insertions: 1.50 kLOC
+Deletions: 0.02 kLOC
+TokenInsertions: 4000
+Commits: 3
+Files:
+  - File: a.go
+    Score: 0.12
+  - File: b.go
+    Score: 1.00
+Authors:
+  alice: 2.00 kLOC
+  bob: 0.01 kLOC
+Weeks:
+  - 1.00 kLOC
+  - 0.00 kLOC
+`, out.String())
+}
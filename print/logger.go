@@ -0,0 +1,70 @@
+package print
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// Logger receives the messages git-churn logs, structured as a message followed by alternating keys and
+// values. The method set is the one of log/slog's *slog.Logger, so that it can be injected as is, and of
+// zap's SugaredLogger through a thin adapter calling its Debugw, Infow, Warnw and Errorw methods.
+type Logger interface {
+	Debug(msg string, args ...interface{})
+	Info(msg string, args ...interface{})
+	Warn(msg string, args ...interface{})
+	Error(msg string, args ...interface{})
+}
+
+// Log receives the messages of git-churn. Applications embedding it can inject their own logger, or
+// Discard, to control where the messages go.
+var Log Logger = ConsoleLogger{Writer: os.Stderr}
+
+// Discard drops every message
+var Discard Logger = discardLogger{}
+
+// ConsoleLogger writes the messages of LogLevel and above to a terminal, colored by level, the
+// keys and values following the message as key=value
+type ConsoleLogger struct {
+	Writer io.Writer
+}
+
+func (l ConsoleLogger) Debug(msg string, args ...interface{}) {
+	l.write(LevelDebug, "\x1b[90m", msg, args)
+}
+
+func (l ConsoleLogger) Info(msg string, args ...interface{}) {
+	l.write(LevelInfo, "\x1b[34;1m", msg, args)
+}
+
+func (l ConsoleLogger) Warn(msg string, args ...interface{}) {
+	l.write(LevelWarning, "\x1b[36;1m", msg, args)
+}
+
+func (l ConsoleLogger) Error(msg string, args ...interface{}) {
+	l.write(LevelError, "\x1b[31;1m", "error: "+msg, args)
+}
+
+func (l ConsoleLogger) write(level Level, color, msg string, args []interface{}) {
+	if LogLevel < level {
+		return
+	}
+	var line strings.Builder
+	line.WriteString(msg)
+	for i := 0; i < len(args); i += 2 {
+		if i+1 < len(args) {
+			fmt.Fprintf(&line, " %v=%v", args[i], args[i+1])
+		} else {
+			fmt.Fprintf(&line, " %v", args[i])
+		}
+	}
+	fmt.Fprintf(l.Writer, "%s%s\x1b[0m\n", color, line.String())
+}
+
+type discardLogger struct{}
+
+func (discardLogger) Debug(msg string, args ...interface{}) {}
+func (discardLogger) Info(msg string, args ...interface{})  {}
+func (discardLogger) Warn(msg string, args ...interface{})  {}
+func (discardLogger) Error(msg string, args ...interface{}) {}
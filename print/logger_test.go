@@ -0,0 +1,52 @@
+package print
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// recordingLogger records the messages as "level msg args"
+type recordingLogger struct {
+	messages []string
+}
+
+func (l *recordingLogger) record(level, msg string, args []interface{}) {
+	l.messages = append(l.messages, fmt.Sprint(level, " ", msg, " ", args))
+}
+
+func (l *recordingLogger) Debug(msg string, args ...interface{}) { l.record("debug", msg, args) }
+func (l *recordingLogger) Info(msg string, args ...interface{})  { l.record("info", msg, args) }
+func (l *recordingLogger) Warn(msg string, args ...interface{})  { l.record("warn", msg, args) }
+func (l *recordingLogger) Error(msg string, args ...interface{}) { l.record("error", msg, args) }
+
+func TestInjectedLogger(t *testing.T) {
+	logger := &recordingLogger{}
+	defer func(log Logger) { Log = log }(Log)
+	Log = logger
+
+	Info("git clone %s", "repo")
+	Warning("could not blame %s", "a.go")
+	Debug("%d commits", 3)
+	assert.Equal(t, []string{"info git clone repo []", "warn could not blame a.go []", "debug 3 commits []"}, logger.messages)
+}
+
+func TestConsoleLogger(t *testing.T) {
+	assert := assert.New(t)
+	defer func(level Level) { LogLevel = level }(LogLevel)
+	var out bytes.Buffer
+	logger := ConsoleLogger{Writer: &out}
+
+	LogLevel = LevelInfo
+	logger.Info("RangeChurn", "duration", "2ms", "commits")
+	logger.Debug("hidden")
+	assert.Equal("\x1b[34;1mRangeChurn duration=2ms commits\x1b[0m\n", out.String())
+
+	out.Reset()
+	LogLevel = LevelError
+	logger.Warn("hidden")
+	logger.Error("failed")
+	assert.Equal("\x1b[31;1merror: failed\x1b[0m\n", out.String())
+}
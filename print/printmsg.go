@@ -7,31 +7,84 @@ import (
 	"strings"
 )
 
+// Level is the verbosity of the messages
+type Level int
+
+const (
+	LevelError Level = iota
+	LevelWarning
+	LevelInfo
+	LevelDebug
+)
+
+// Levels by name, as selected on the command line
+var levelNames = map[string]Level{
+	"error":   LevelError,
+	"warning": LevelWarning,
+	"warn":    LevelWarning,
+	"info":    LevelInfo,
+	"debug":   LevelDebug,
+}
+
+// LogLevel is the most verbose level of the messages ConsoleLogger shows, errors always being shown
+var LogLevel = LevelInfo
+
+// ParseLevel parses a level name: error, warning, info or debug
+func ParseLevel(name string) (Level, error) {
+	level, ok := levelNames[strings.ToLower(name)]
+	if !ok {
+		return LevelInfo, fmt.Errorf("unknown log level %q, expected error, warning, info or debug", name)
+	}
+	return level, nil
+}
+
 // CheckArgs should be used to ensure the right command line arguments are
 // passed before executing an example.
 func CheckArgs(arg ...string) {
 	if len(os.Args) < len(arg)+1 {
 		Warning("Usage: %s %s", os.Args[0], strings.Join(arg, " "))
-		os.Exit(1)
+		Exit(1)
 	}
 }
 
-// CheckIfError should be used to naively panics if an error is not nil.
+// CheckIfError should be used to naively exit if an error is not nil, after logging it
 func CheckIfError(err error) {
 	if err == nil {
 		return
 	}
 
-	fmt.Printf("\x1b[31;1m%s\x1b[0m\n", fmt.Sprintf("error: %s", err))
-	os.Exit(1)
+	Log.Error(err.Error())
+	Exit(1)
+}
+
+var exitHooks []func()
+
+// AtExit registers a function Exit calls before exiting, e.g. to remove temporary files
+func AtExit(fn func()) {
+	exitHooks = append(exitHooks, fn)
+}
+
+// Exit runs the functions registered with AtExit, the last registered first, and exits with the code
+func Exit(code int) {
+	hooks := exitHooks
+	exitHooks = nil
+	for i := len(hooks) - 1; i >= 0; i-- {
+		hooks[i]()
+	}
+	os.Exit(code)
 }
 
 // Info should be used to describe the example commands that are about to run.
 func Info(format string, args ...interface{}) {
-	fmt.Printf("\x1b[34;1m%s\x1b[0m\n", fmt.Sprintf(format, args...))
+	Log.Info(fmt.Sprintf(format, args...))
 }
 
 // Warning should be used to display a warning
 func Warning(format string, args ...interface{}) {
-	fmt.Printf("\x1b[36;1m%s\x1b[0m\n", fmt.Sprintf(format, args...))
+	Log.Warn(fmt.Sprintf(format, args...))
+}
+
+// Debug should be used to display the details of what is going on
+func Debug(format string, args ...interface{}) {
+	Log.Debug(fmt.Sprintf(format, args...))
 }
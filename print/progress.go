@@ -0,0 +1,68 @@
+package print
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+)
+
+// ProgressBar draws the progress of a task on a single line of a terminal, redrawn at most every
+// RefreshInterval but when the task is over
+type ProgressBar struct {
+	Writer io.Writer
+	// Width of the bar in characters
+	Width           int
+	RefreshInterval time.Duration
+
+	drawn     time.Time
+	lineWidth int
+}
+
+// NewProgressBar returns a progress bar drawing on w
+func NewProgressBar(w io.Writer) *ProgressBar {
+	return &ProgressBar{Writer: w, Width: 30, RefreshInterval: 100 * time.Millisecond}
+}
+
+// Draw draws the steps done of the task out of the total, a mere count when the total is zero, and ends
+// the line once the task is finished
+func (b *ProgressBar) Draw(task string, done, total int, finished bool) {
+	now := time.Now()
+	if !finished && done != 0 && done != total && now.Sub(b.drawn) < b.RefreshInterval {
+		return
+	}
+	b.drawn = now
+
+	var line string
+	if total > 0 {
+		filled := b.Width * done / total
+		if filled > b.Width {
+			filled = b.Width
+		}
+		bar := strings.Repeat("=", filled)
+		if filled < b.Width {
+			bar += ">" + strings.Repeat(" ", b.Width-filled-1)
+		}
+		line = fmt.Sprintf("%s [%s] %d/%d %3d%%", task, bar, done, total, 100*done/total)
+	} else {
+		line = fmt.Sprintf("%s %d", task, done)
+	}
+	// Blank out the rest of a longer previous line
+	padding := ""
+	if len(line) < b.lineWidth {
+		padding = strings.Repeat(" ", b.lineWidth-len(line))
+	}
+	b.lineWidth = len(line)
+	fmt.Fprintf(b.Writer, "\r%s%s", line, padding)
+	if finished {
+		fmt.Fprintln(b.Writer)
+		b.lineWidth = 0
+	}
+}
+
+// IsTerminal tells whether the file is a terminal rather than a pipe or a regular file
+func IsTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
@@ -0,0 +1,37 @@
+package print
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProgressBar(t *testing.T) {
+	assert := assert.New(t)
+	var out bytes.Buffer
+	bar := NewProgressBar(&out)
+	bar.Width = 4
+	bar.Draw("commits", 0, 2, false)
+	bar.Draw("commits", 1, 2, false)
+	assert.Equal("\rcommits [>   ] 0/2   0%", out.String())
+	bar.Draw("commits", 2, 2, true)
+	assert.Equal("\rcommits [>   ] 0/2   0%\rcommits [====] 2/2 100%\n", out.String())
+
+	out.Reset()
+	bar.Draw("commits", 0, 0, false)
+	bar.Draw("commits", 12, 0, true)
+	assert.Equal("\rcommits 0\rcommits 12\n", out.String())
+}
+
+func TestParseLevel(t *testing.T) {
+	assert := assert.New(t)
+	level, err := ParseLevel("DEBUG")
+	assert.Nil(err)
+	assert.Equal(LevelDebug, level)
+	level, err = ParseLevel("warn")
+	assert.Nil(err)
+	assert.Equal(LevelWarning, level)
+	_, err = ParseLevel("trace")
+	assert.NotNil(err)
+}
@@ -0,0 +1,61 @@
+package print
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"unicode/utf8"
+)
+
+// TreeNode is a result rendered as a tree by WriteTree, like du renders directories
+type TreeNode interface {
+	// TreeHeader names the columns, of the root only
+	TreeHeader() []string
+	// TreeColumns are the values shown before the name of the node
+	TreeColumns(f TextFormat) []string
+	TreeName() string
+	TreeChildren() []TreeNode
+}
+
+// WriteTree renders the tree as a line per node, its columns right aligned under the header of the root
+// then its name, indented under the name of its parent
+func (f TextFormat) WriteTree(w io.Writer, root TreeNode) error {
+	rows := [][]string{root.TreeHeader()}
+	names := []string{""}
+	var walk func(node TreeNode, prefix, childPrefix string)
+	walk = func(node TreeNode, prefix, childPrefix string) {
+		rows = append(rows, node.TreeColumns(f))
+		names = append(names, prefix+node.TreeName())
+		children := node.TreeChildren()
+		for i, child := range children {
+			if i == len(children)-1 {
+				walk(child, childPrefix+"└── ", childPrefix+"    ")
+			} else {
+				walk(child, childPrefix+"├── ", childPrefix+"│   ")
+			}
+		}
+	}
+	walk(root, "", "")
+
+	var widths []int
+	for _, row := range rows {
+		for i, column := range row {
+			if i == len(widths) {
+				widths = append(widths, 0)
+			}
+			if n := utf8.RuneCountInString(column); n > widths[i] {
+				widths[i] = n
+			}
+		}
+	}
+	for i, row := range rows {
+		var line strings.Builder
+		for j, column := range row {
+			fmt.Fprintf(&line, "%*s  ", widths[j], column)
+		}
+		if _, err := fmt.Fprintln(w, strings.TrimRight(line.String()+names[i], " ")); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,36 @@
+package print
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type sizeNode struct {
+	name     string
+	size     int
+	children []TreeNode
+}
+
+func (n sizeNode) TreeHeader() []string              { return []string{"Size"} }
+func (n sizeNode) TreeColumns(f TextFormat) []string { return []string{f.FormatLines(n.size)} }
+func (n sizeNode) TreeName() string                  { return n.name }
+func (n sizeNode) TreeChildren() []TreeNode          { return n.children }
+
+func TestWriteTree(t *testing.T) {
+	tree := sizeNode{".", 1200, []TreeNode{
+		sizeNode{"cmd/", 1000, []TreeNode{sizeNode{"root.go", 1000, nil}}},
+		sizeNode{"main.go", 200, nil},
+	}}
+	var out bytes.Buffer
+	err := TextFormat{Units: UnitsLines}.WriteTree(&out, tree)
+	assert := assert.New(t)
+	assert.Nil(err)
+	assert.Equal(`Size
+1200  .
+1000  ├── cmd/
+1000  │   └── root.go
+ 200  └── main.go
+`, out.String())
+}
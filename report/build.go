@@ -0,0 +1,43 @@
+package report
+
+import (
+	"time"
+
+	metrics "github.com/andymeneely/git-churn/matrics"
+	"github.com/andymeneely/git-churn/version"
+	"gopkg.in/src-d/go-git.v4"
+)
+
+// Build computes the data of a report on the commits from..to of the repository, where an empty
+// `from` covers the whole history. The top hotspots and most changed files are kept.
+func Build(repo *git.Repository, repository, from, to string, top int) (*Data, error) {
+	commits, err := metrics.RangeChurn(repo, from, to)
+	if err != nil {
+		return nil, err
+	}
+	data := &Data{
+		Repository:   repository,
+		Revision:     to,
+		Generated:    time.Now(),
+		Tool:         version.Get(),
+		Summary:      metrics.SummarizeRange(commits, top),
+		MonthlyChurn: metrics.ChurnPerMonth(commits),
+	}
+	data.Hotspots, err = metrics.Hotspots(repo, commits, to, top)
+	if err != nil {
+		return nil, err
+	}
+	files := make([]string, len(data.Hotspots))
+	for i, hotspot := range data.Hotspots {
+		files[i] = hotspot.File
+	}
+	data.Ownership, err = metrics.GetFileOwnership(repo, to, files)
+	if err != nil {
+		return nil, err
+	}
+	data.Growth, err = metrics.GrowthCurve(repo, to, 0, true, true)
+	if err != nil {
+		return nil, err
+	}
+	return data, nil
+}
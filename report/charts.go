@@ -0,0 +1,120 @@
+package report
+
+import (
+	"fmt"
+	"html"
+	"html/template"
+	"strings"
+)
+
+const (
+	chartWidth  = 720
+	chartHeight = 220
+	chartMargin = 30
+)
+
+// Series is a named list of values plotted against shared labels
+type Series struct {
+	Name   string
+	Color  string
+	Values []float64
+}
+
+// BarChart renders the series as grouped vertical bars in an inline SVG
+func BarChart(labels []string, series ...Series) template.HTML {
+	if len(labels) == 0 {
+		return template.HTML("<p class=\"empty\">No data</p>")
+	}
+	max := maxValue(series)
+	plotWidth := float64(chartWidth - 2*chartMargin)
+	plotHeight := float64(chartHeight - 2*chartMargin)
+	slot := plotWidth / float64(len(labels))
+	barWidth := slot * 0.8 / float64(len(series))
+
+	var svg strings.Builder
+	openChart(&svg)
+	for i, label := range labels {
+		x := float64(chartMargin) + slot*float64(i) + slot*0.1
+		for j, s := range series {
+			height := 0.0
+			if max > 0 {
+				height = s.Values[i] / max * plotHeight
+			}
+			fmt.Fprintf(&svg, `<rect x="%.1f" y="%.1f" width="%.1f" height="%.1f" fill="%s"><title>%s %s: %g</title></rect>`,
+				x+barWidth*float64(j), float64(chartHeight-chartMargin)-height, barWidth, height,
+				html.EscapeString(s.Color), html.EscapeString(label), html.EscapeString(s.Name), s.Values[i])
+		}
+		if showLabel(i, len(labels)) {
+			fmt.Fprintf(&svg, `<text x="%.1f" y="%d" class="label">%s</text>`, x, chartHeight-chartMargin+14, html.EscapeString(label))
+		}
+	}
+	closeChart(&svg, max, series)
+	return template.HTML(svg.String())
+}
+
+// LineChart renders the series as polylines in an inline SVG
+func LineChart(labels []string, series ...Series) template.HTML {
+	if len(labels) == 0 {
+		return template.HTML("<p class=\"empty\">No data</p>")
+	}
+	max := maxValue(series)
+	plotWidth := float64(chartWidth - 2*chartMargin)
+	plotHeight := float64(chartHeight - 2*chartMargin)
+	step := plotWidth
+	if len(labels) > 1 {
+		step = plotWidth / float64(len(labels)-1)
+	}
+
+	var svg strings.Builder
+	openChart(&svg)
+	for _, s := range series {
+		points := make([]string, len(s.Values))
+		for i, value := range s.Values {
+			y := float64(chartHeight - chartMargin)
+			if max > 0 {
+				y -= value / max * plotHeight
+			}
+			points[i] = fmt.Sprintf("%.1f,%.1f", float64(chartMargin)+step*float64(i), y)
+		}
+		fmt.Fprintf(&svg, `<polyline points="%s" fill="none" stroke="%s" stroke-width="2"/>`, strings.Join(points, " "), html.EscapeString(s.Color))
+	}
+	for i, label := range labels {
+		if showLabel(i, len(labels)) {
+			fmt.Fprintf(&svg, `<text x="%.1f" y="%d" class="label">%s</text>`, float64(chartMargin)+step*float64(i), chartHeight-chartMargin+14, html.EscapeString(label))
+		}
+	}
+	closeChart(&svg, max, series)
+	return template.HTML(svg.String())
+}
+
+func openChart(svg *strings.Builder) {
+	fmt.Fprintf(svg, `<svg xmlns="http://www.w3.org/2000/svg" viewBox="0 0 %d %d" class="chart">`, chartWidth, chartHeight)
+	fmt.Fprintf(svg, `<line x1="%d" y1="%d" x2="%d" y2="%d" class="axis"/>`, chartMargin, chartHeight-chartMargin, chartWidth-chartMargin, chartHeight-chartMargin)
+}
+
+func closeChart(svg *strings.Builder, max float64, series []Series) {
+	fmt.Fprintf(svg, `<text x="%d" y="%d" class="label">%g</text>`, 2, chartMargin-8, max)
+	for i, s := range series {
+		fmt.Fprintf(svg, `<rect x="%d" y="%d" width="10" height="10" fill="%s"/><text x="%d" y="%d" class="label">%s</text>`,
+			chartWidth-chartMargin-120, 4+14*i, html.EscapeString(s.Color), chartWidth-chartMargin-105, 13+14*i, html.EscapeString(s.Name))
+	}
+	svg.WriteString("</svg>")
+}
+
+// showLabel thins out the axis labels so that at most about a dozen of them are drawn
+func showLabel(i, count int) bool {
+	every := count/12 + 1
+	return i%every == 0
+}
+
+func maxValue(series []Series) float64 {
+	max := 0.0
+	for _, s := range series {
+		for _, value := range s.Values {
+			if value > max {
+				max = value
+			}
+		}
+	}
+	return max
+}
@@ -0,0 +1,83 @@
+package report
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/andymeneely/git-churn/integrations"
+	metrics "github.com/andymeneely/git-churn/matrics"
+	"github.com/andymeneely/git-churn/print"
+	"github.com/andymeneely/git-churn/testutil"
+	"github.com/andymeneely/git-churn/version"
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/src-d/go-git.v4"
+)
+
+// fixtureRepo is a small history of two authors over two months, spread across directories
+func fixtureRepo(t *testing.T) *git.Repository {
+	repo := testutil.NewRepo(t)
+	repo.CommitFiles("Add the parser", map[string]string{
+		"main.go":        "package main\n\nfunc main() {\n\tparse()\n}\n",
+		"parser/lex.go":  "package parser\n\n// Lex splits the input\nfunc Lex() {}\n",
+		"parser/tree.go": "package parser\n\ntype Tree struct{}\n",
+		"README.md":      "# fixture\n",
+	})
+	repo.As("bob").CommitFiles("Lex numbers", map[string]string{
+		"parser/lex.go": "package parser\n\n// Lex splits the input into tokens\nfunc Lex() {\n\tnumbers()\n}\n",
+	})
+	repo.At(testutil.Start.AddDate(0, 1, 0)).CommitFiles("fix: crash on empty input", map[string]string{
+		"parser/lex.go": "package parser\n\n// Lex splits the input into tokens\nfunc Lex() {\n\tif empty() {\n\t\treturn\n\t}\n\tnumbers()\n}\n",
+		"main.go":       "package main\n\nfunc main() {\n\tparse(args())\n}\n",
+	})
+	repo.As("alice").Delete("parser/tree.go").CommitFiles("Drop the tree", map[string]string{"docs/usage.md": "Run it\n"})
+	return repo.Repository
+}
+
+// TestGolden renders a report of the fixture repository in every output format and compares it to the
+// golden files. Run the tests with -update to rewrite them after changing a format on purpose.
+func TestGolden(t *testing.T) {
+	assert := assert.New(t)
+	repo := fixtureRepo(t)
+	data, err := Build(repo, "fixture", "", "HEAD", 3)
+	assert.Nil(err)
+	data.Generated = time.Date(2020, 3, 1, 12, 0, 0, 0, time.UTC)
+	data.Tool = version.Info{Version: "test"}
+
+	var html bytes.Buffer
+	assert.Nil(WriteHTML(&html, data))
+	testutil.Golden(t, "report.html", html.Bytes())
+
+	out, err := json.MarshalIndent(data, "", "  ")
+	assert.Nil(err)
+	testutil.Golden(t, "report.json", append(out, '\n'))
+
+	commits, err := metrics.RangeChurn(repo, "", "HEAD")
+	assert.Nil(err)
+	var jsonl bytes.Buffer
+	encoder := json.NewEncoder(&jsonl)
+	for _, commit := range commits {
+		assert.Nil(encoder.Encode(commit))
+	}
+	testutil.Golden(t, "commits.jsonl", jsonl.Bytes())
+
+	var text bytes.Buffer
+	assert.Nil(print.TextFormat{Units: print.UnitsLines, Precision: 2}.Write(&text, data.Summary))
+	testutil.Golden(t, "summary.txt", text.Bytes())
+
+	var tree bytes.Buffer
+	assert.Nil(print.TextFormat{Units: print.UnitsKLOC, Precision: 3}.WriteTree(&tree, metrics.ChurnTree(commits, 0)))
+	testutil.Golden(t, "tree.txt", tree.Bytes())
+
+	// The last two commits as a pull request
+	pr := integrations.PullRequestReport{Number: 42, Title: "Handle empty input", Base: commits[2].Hash, Head: commits[0].Hash,
+		RangeSummary: metrics.SummarizeRange(commits[:2], 3)}
+	testutil.Golden(t, "pullrequest.md", []byte(pr.Markdown()))
+
+	heats, err := metrics.FileHeats(repo, commits, "HEAD")
+	assert.Nil(err)
+	var svg bytes.Buffer
+	assert.Nil(WriteTreemap(&svg, "fixture at HEAD", heats))
+	testutil.Golden(t, "treemap.svg", svg.Bytes())
+}
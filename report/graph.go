@@ -0,0 +1,146 @@
+package report
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	metrics "github.com/andymeneely/git-churn/matrics"
+)
+
+// WriteDOT renders the commit graph for Graphviz, e.g. dot -Tsvg, every commit pointing to its parents and
+// labelled with its short hash, author and lines changed, its size growing and its color going from grey to
+// red with its churn like in WriteTreemap. The commits left out of the churn are dashed.
+func WriteDOT(w io.Writer, title string, graph *metrics.CommitGraph) error {
+	maxChurn := graph.MaxChurn()
+	var dot strings.Builder
+	fmt.Fprintf(&dot, "digraph %s {\n", dotQuote(title))
+	fmt.Fprintf(&dot, "  label=%s;\n  labelloc=t;\n  rankdir=RL;\n", dotQuote(title))
+	dot.WriteString("  node [shape=box, style=filled, fontname=\"sans-serif\", fontsize=10];\n")
+	for _, commit := range graph.Commits {
+		label := fmt.Sprintf("%s\n%s\n+%d -%d", shortHash(commit.Hash), commit.Author, commit.Insertions, commit.Deletions)
+		style := "filled"
+		if commit.Excluded {
+			style = "filled,dashed"
+		}
+		fmt.Fprintf(&dot, "  %s [label=%s, tooltip=%s, style=%q, fillcolor=%q, width=%.2f];\n", dotQuote(commit.Hash),
+			dotQuote(label), dotQuote(commit.Subject), style, heatColor(commit.Churn, maxChurn), graphNodeWidth(commit.Churn, maxChurn))
+	}
+	for _, commit := range graph.Commits {
+		for _, parent := range commit.Parents {
+			fmt.Fprintf(&dot, "  %s -> %s;\n", dotQuote(commit.Hash), dotQuote(parent))
+		}
+	}
+	dot.WriteString("}\n")
+	_, err := io.WriteString(w, dot.String())
+	return err
+}
+
+// graphNodeWidth grows the width of a commit in inches from 0.75 to 2.25 with its churn, on a log scale
+// relative to the most churning commit
+func graphNodeWidth(churn, maxChurn int) float64 {
+	if maxChurn <= 0 || churn <= 0 {
+		return 0.75
+	}
+	return 0.75 + 1.5*math.Log1p(float64(churn))/math.Log1p(float64(maxChurn))
+}
+
+// dotQuote quotes the string as a DOT identifier
+func dotQuote(s string) string {
+	s = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(s)
+	return `"` + s + `"`
+}
+
+func shortHash(hash string) string {
+	if len(hash) > 7 {
+		return hash[:7]
+	}
+	return hash
+}
+
+type graphML struct {
+	XMLName xml.Name     `xml:"graphml"`
+	XMLNS   string       `xml:"xmlns,attr"`
+	Keys    []graphMLKey `xml:"key"`
+	Graph   graphMLGraph `xml:"graph"`
+}
+
+type graphMLKey struct {
+	ID   string `xml:"id,attr"`
+	For  string `xml:"for,attr"`
+	Name string `xml:"attr.name,attr"`
+	Type string `xml:"attr.type,attr"`
+}
+
+type graphMLGraph struct {
+	ID          string        `xml:"id,attr"`
+	EdgeDefault string        `xml:"edgedefault,attr"`
+	Nodes       []graphMLNode `xml:"node"`
+	Edges       []graphMLEdge `xml:"edge"`
+}
+
+type graphMLNode struct {
+	ID   string        `xml:"id,attr"`
+	Data []graphMLData `xml:"data"`
+}
+
+type graphMLEdge struct {
+	Source string `xml:"source,attr"`
+	Target string `xml:"target,attr"`
+}
+
+type graphMLData struct {
+	Key   string `xml:"key,attr"`
+	Value string `xml:",chardata"`
+}
+
+// graphMLAttributes are the attributes of the commits in GraphML, with their type
+var graphMLAttributes = []graphMLKey{
+	{"label", "node", "label", "string"},
+	{"author", "node", "author", "string"},
+	{"when", "node", "when", "string"},
+	{"subject", "node", "subject", "string"},
+	{"insertions", "node", "insertions", "int"},
+	{"deletions", "node", "deletions", "int"},
+	{"churn", "node", "churn", "int"},
+	{"excluded", "node", "excluded", "boolean"},
+}
+
+// WriteGraphML renders the commit graph in GraphML, e.g. for Gephi, every commit pointing to its parents
+// and carrying its author, date, subject and lines changed as attributes
+func WriteGraphML(w io.Writer, graph *metrics.CommitGraph) error {
+	doc := graphML{
+		XMLNS: "http://graphml.graphdrawing.org/xmlns",
+		Keys:  graphMLAttributes,
+		Graph: graphMLGraph{ID: "commits", EdgeDefault: "directed"},
+	}
+	for _, commit := range graph.Commits {
+		doc.Graph.Nodes = append(doc.Graph.Nodes, graphMLNode{ID: commit.Hash, Data: []graphMLData{
+			{"label", shortHash(commit.Hash)},
+			{"author", commit.Author},
+			{"when", commit.When.Format(time.RFC3339)},
+			{"subject", commit.Subject},
+			{"insertions", strconv.Itoa(commit.Insertions)},
+			{"deletions", strconv.Itoa(commit.Deletions)},
+			{"churn", strconv.Itoa(commit.Churn)},
+			{"excluded", strconv.FormatBool(commit.Excluded)},
+		}})
+		for _, parent := range commit.Parents {
+			doc.Graph.Edges = append(doc.Graph.Edges, graphMLEdge{Source: commit.Hash, Target: parent})
+		}
+	}
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "  ")
+	if err := encoder.Encode(doc); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
@@ -0,0 +1,45 @@
+package report
+
+import (
+	"bytes"
+	"encoding/xml"
+	"testing"
+	"time"
+
+	metrics "github.com/andymeneely/git-churn/matrics"
+	"github.com/stretchr/testify/assert"
+)
+
+var testGraph = &metrics.CommitGraph{To: "HEAD", Commits: []metrics.GraphCommit{
+	{Hash: "bbbbbbbbbb", Author: "bob@example.com", When: time.Date(2020, 1, 2, 0, 0, 0, 0, time.UTC), Subject: `Say "hi"`,
+		Parents: []string{"aaaaaaaaaa"}, Insertions: 10, Deletions: 2, Churn: 12},
+	{Hash: "aaaaaaaaaa", Author: "dependabot[bot]@example.com", When: time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC), Subject: "bump <deps>",
+		Excluded: true},
+}}
+
+func TestWriteDOT(t *testing.T) {
+	assert := assert.New(t)
+	var out bytes.Buffer
+	assert.Nil(WriteDOT(&out, "git-churn at HEAD", testGraph))
+	dot := out.String()
+	assert.Contains(dot, `digraph "git-churn at HEAD" {`)
+	assert.Contains(dot, `"bbbbbbbbbb" [label="bbbbbbb\nbob@example.com\n+10 -2", tooltip="Say \"hi\"", style="filled", fillcolor="#d7301f", width=2.25];`)
+	assert.Contains(dot, `"aaaaaaaaaa" [label="aaaaaaa\ndependabot[bot]@example.com\n+0 -0", tooltip="bump <deps>", style="filled,dashed", fillcolor="#d9e1e8", width=0.75];`)
+	assert.Contains(dot, `"bbbbbbbbbb" -> "aaaaaaaaaa";`)
+}
+
+func TestWriteGraphML(t *testing.T) {
+	assert := assert.New(t)
+	var out bytes.Buffer
+	assert.Nil(WriteGraphML(&out, testGraph))
+	var doc graphML
+	assert.Nil(xml.Unmarshal(out.Bytes(), &doc))
+	assert.Len(doc.Keys, len(graphMLAttributes))
+	assert.Equal("directed", doc.Graph.EdgeDefault)
+	assert.Len(doc.Graph.Nodes, 2)
+	assert.Equal([]graphMLEdge{{Source: "bbbbbbbbbb", Target: "aaaaaaaaaa"}}, doc.Graph.Edges)
+	assert.Contains(doc.Graph.Nodes[0].Data, graphMLData{Key: "churn", Value: "12"})
+	assert.Contains(doc.Graph.Nodes[1].Data, graphMLData{Key: "subject", Value: "bump <deps>"})
+	assert.Contains(doc.Graph.Nodes[1].Data, graphMLData{Key: "excluded", Value: "true"})
+	assert.Contains(out.String(), "bump &lt;deps&gt;")
+}
@@ -0,0 +1,134 @@
+// Package report renders churn metrics into documents that can be shared with people who do not use the CLI.
+package report
+
+import (
+	"html/template"
+	"io"
+	"time"
+
+	metrics "github.com/andymeneely/git-churn/matrics"
+	"github.com/andymeneely/git-churn/version"
+)
+
+// Data is everything a report shows about a repository
+type Data struct {
+	Repository   string
+	Revision     string
+	Generated    time.Time
+	Tool         version.Info
+	Summary      metrics.RangeSummary
+	Hotspots     []metrics.Hotspot
+	Ownership    []metrics.FileOwnership
+	Growth       []metrics.GrowthPoint
+	MonthlyChurn []metrics.PeriodChurn
+}
+
+var htmlTemplate = template.Must(template.New("report").Funcs(template.FuncMap{
+	"churnChart":  churnChart,
+	"growthChart": growthChart,
+	"percent": func(part, total int) float64 {
+		if total == 0 {
+			return 0
+		}
+		return 100 * float64(part) / float64(total)
+	},
+	"scoreWidth": func(score float64) float64 { return 100 * score },
+	"date":       func(t time.Time) string { return t.Format("2006-01-02 15:04 MST") },
+}).Parse(htmlPage))
+
+// WriteHTML renders the report as a standalone HTML page with inline styles and SVG charts,
+// so the file can be shared and opened without any network access
+func WriteHTML(w io.Writer, data *Data) error {
+	return htmlTemplate.Execute(w, data)
+}
+
+func churnChart(periods []metrics.PeriodChurn) template.HTML {
+	labels := make([]string, len(periods))
+	insertions := Series{Name: "Insertions", Color: "#2da44e", Values: make([]float64, len(periods))}
+	deletions := Series{Name: "Deletions", Color: "#cf222e", Values: make([]float64, len(periods))}
+	for i, period := range periods {
+		labels[i] = period.Period
+		insertions.Values[i] = float64(period.Insertions)
+		deletions.Values[i] = float64(period.Deletions)
+	}
+	return BarChart(labels, insertions, deletions)
+}
+
+func growthChart(points []metrics.GrowthPoint) template.HTML {
+	labels := make([]string, len(points))
+	loc := Series{Name: "LOC", Color: "#0969da", Values: make([]float64, len(points))}
+	for i, point := range points {
+		labels[i] = point.Date.Format("2006-01")
+		loc.Values[i] = float64(point.LOC)
+	}
+	return LineChart(labels, loc)
+}
+
+const htmlPage = `<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>git-churn report - {{.Repository}}</title>
+<style>
+body { font-family: -apple-system, "Segoe UI", Helvetica, Arial, sans-serif; margin: 2em auto; max-width: 960px; color: #24292f; }
+h1 { font-size: 1.6em; margin-bottom: 0; }
+h2 { font-size: 1.2em; border-bottom: 1px solid #d0d7de; padding-bottom: .3em; margin-top: 2em; }
+.meta { color: #57606a; }
+table { border-collapse: collapse; width: 100%; }
+th, td { text-align: left; padding: .3em .6em; border-bottom: 1px solid #eaeef2; }
+td.num, th.num { text-align: right; font-variant-numeric: tabular-nums; }
+.tiles { display: flex; gap: 1em; flex-wrap: wrap; }
+.tile { border: 1px solid #d0d7de; border-radius: 6px; padding: .6em 1em; min-width: 8em; }
+.tile .value { font-size: 1.4em; font-weight: 600; }
+.bar { background: #fb8f44; height: .8em; border-radius: 2px; }
+.chart { width: 100%; height: auto; }
+.chart .axis { stroke: #8c959f; }
+.chart .label { font-size: 10px; fill: #57606a; }
+.empty { color: #8c959f; }
+</style>
+</head>
+<body>
+<h1>Churn report for {{.Repository}}</h1>
+<p class="meta">Revision {{.Revision}} &middot; generated {{date .Generated}} by git-churn {{.Tool.Version}}</p>
+
+<h2>Churn</h2>
+<div class="tiles">
+<div class="tile"><div class="value">{{.Summary.Commits}}</div>commits</div>
+<div class="tile"><div class="value">+{{.Summary.Insertions}}</div>lines added</div>
+<div class="tile"><div class="value">-{{.Summary.Deletions}}</div>lines deleted</div>
+<div class="tile"><div class="value">{{.Summary.FilesChanged}}</div>files changed</div>
+<div class="tile"><div class="value">{{len .Summary.Authors}}</div>authors</div>
+</div>
+
+<h2>Churn per month</h2>
+{{churnChart .MonthlyChurn}}
+
+<h2>Repository size</h2>
+{{growthChart .Growth}}
+
+<h2>Hotspots</h2>
+{{if .Hotspots}}<table>
+<tr><th>File</th><th class="num">Commits</th><th class="num">Churn</th><th class="num">LOC</th><th>Score</th></tr>
+{{range .Hotspots}}<tr><td>{{.File}}</td><td class="num">{{.Commits}}</td><td class="num">{{.Churn}}</td><td class="num">{{.LOC}}</td><td><div class="bar" style="width: {{printf "%.0f" (scoreWidth .Score)}}%"></div></td></tr>
+{{end}}</table>{{else}}<p class="empty">No data</p>{{end}}
+
+<h2>Ownership of the hotspots</h2>
+{{if .Ownership}}<table>
+<tr><th>File</th><th>Main owner</th><th class="num">Owned lines</th><th class="num">Authors</th></tr>
+{{range .Ownership}}<tr><td>{{.File}}</td><td>{{.Owner}}</td><td class="num">{{.OwnerLines}} / {{.TotalLines}} ({{printf "%.0f" (percent .OwnerLines .TotalLines)}}%)</td><td class="num">{{.Authors}}</td></tr>
+{{end}}</table>{{else}}<p class="empty">No data</p>{{end}}
+
+<h2>Authors</h2>
+{{if .Summary.Authors}}<table>
+<tr><th>Author</th><th class="num">Commits</th><th class="num">Insertions</th><th class="num">Deletions</th></tr>
+{{range .Summary.Authors}}<tr><td>{{.Author}}</td><td class="num">{{.Commits}}</td><td class="num">{{.Insertions}}</td><td class="num">{{.Deletions}}</td></tr>
+{{end}}</table>{{else}}<p class="empty">No data</p>{{end}}
+
+<h2>Most changed files</h2>
+{{if .Summary.TopFiles}}<table>
+<tr><th>File</th><th class="num">Insertions</th><th class="num">Deletions</th></tr>
+{{range .Summary.TopFiles}}<tr><td>{{.File}}</td><td class="num">{{.Insertions}}</td><td class="num">{{.Deletions}}</td></tr>
+{{end}}</table>{{else}}<p class="empty">No data</p>{{end}}
+</body>
+</html>
+`
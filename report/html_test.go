@@ -0,0 +1,39 @@
+package report
+
+import (
+	"bytes"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+
+	metrics "github.com/andymeneely/git-churn/matrics"
+)
+
+func TestWriteHTML(t *testing.T) {
+	data := &Data{
+		Repository: "https://github.com/andymeneely/git-churn",
+		Revision:   "master",
+		Generated:  time.Date(2020, 5, 1, 10, 0, 0, 0, time.UTC),
+		Summary: metrics.RangeSummary{
+			Commits:    2,
+			Insertions: 12,
+			Deletions:  3,
+			Authors:    []metrics.AuthorChurn{{Author: "<script>@example.com", Commits: 2, Insertions: 12, Deletions: 3}},
+		},
+		Hotspots:     []metrics.Hotspot{{File: "main.go", Commits: 2, Churn: 15, LOC: 40, Score: 1}},
+		MonthlyChurn: []metrics.PeriodChurn{{Period: "2020-04", Commits: 2, Insertions: 12, Deletions: 3}},
+	}
+	var out bytes.Buffer
+	assert := assert.New(t)
+	assert.Nil(WriteHTML(&out, data))
+	page := out.String()
+	assert.Contains(page, "Churn report for https://github.com/andymeneely/git-churn")
+	assert.Contains(page, "generated 2020-05-01 10:00 UTC")
+	assert.Contains(page, `<td>main.go</td><td class="num">2</td>`)
+	assert.Contains(page, `<div class="bar" style="width: 100%">`)
+	assert.Contains(page, "&lt;script&gt;@example.com")
+	assert.Contains(page, "<svg")
+	// Growth has no data
+	assert.Contains(page, `<p class="empty">No data</p>`)
+	assert.NotContains(page, "<script>")
+}
@@ -0,0 +1,194 @@
+package report
+
+import (
+	"fmt"
+	"html"
+	"io"
+	"math"
+	"sort"
+	"strings"
+
+	metrics "github.com/andymeneely/git-churn/matrics"
+)
+
+const (
+	treemapWidth  = 1200
+	treemapHeight = 800
+	// Height of the header naming a directory, and of a line of text
+	treemapLabel = 14
+	// Approximate width of a character of the labels
+	treemapCharWidth = 6.5
+)
+
+// treemapNode is a file, or a directory totalling the lines of its files
+type treemapNode struct {
+	name     string
+	file     *metrics.FileHeat
+	loc      int
+	children []*treemapNode
+}
+
+type rect struct {
+	x, y, w, h float64
+}
+
+// WriteTreemap renders the files as an SVG treemap nesting the directories, the area of every file being
+// proportional to its lines and its color going from grey to red as its churn grows, on a log scale
+// relative to the most churned file. The files without lines are left out.
+func WriteTreemap(w io.Writer, title string, files []metrics.FileHeat) error {
+	root := &treemapNode{}
+	maxChurn := 0
+	for i := range files {
+		file := &files[i]
+		if file.LOC == 0 {
+			continue
+		}
+		if file.Churn > maxChurn {
+			maxChurn = file.Churn
+		}
+		node := root
+		node.loc += file.LOC
+		parts := strings.Split(file.File, "/")
+		for _, part := range parts[:len(parts)-1] {
+			node = node.child(part + "/")
+			node.loc += file.LOC
+		}
+		node.children = append(node.children, &treemapNode{name: parts[len(parts)-1], file: file, loc: file.LOC})
+	}
+
+	var svg strings.Builder
+	fmt.Fprintf(&svg, `<svg xmlns="http://www.w3.org/2000/svg" viewBox="0 0 %d %d" width="%d" height="%d" font-family="sans-serif" font-size="10">`,
+		treemapWidth, treemapHeight+treemapLabel, treemapWidth, treemapHeight+treemapLabel)
+	fmt.Fprintf(&svg, `<title>%s</title><text x="2" y="11">%s</text>`, html.EscapeString(title), html.EscapeString(title))
+	if root.loc == 0 {
+		svg.WriteString(`<text x="2" y="30">No data</text>`)
+	} else {
+		root.draw(&svg, rect{0, treemapLabel, treemapWidth, treemapHeight}, maxChurn)
+	}
+	svg.WriteString("</svg>\n")
+	_, err := io.WriteString(w, svg.String())
+	return err
+}
+
+func (n *treemapNode) child(name string) *treemapNode {
+	for _, child := range n.children {
+		if child.name == name && child.file == nil {
+			return child
+		}
+	}
+	child := &treemapNode{name: name}
+	n.children = append(n.children, child)
+	return child
+}
+
+func (n *treemapNode) draw(svg *strings.Builder, r rect, maxChurn int) {
+	if n.file != nil {
+		fmt.Fprintf(svg, `<rect x="%.1f" y="%.1f" width="%.1f" height="%.1f" fill="%s" stroke="#fff" stroke-width="0.5"><title>%s: %d lines, %d commits, churn %d</title></rect>`,
+			r.x, r.y, r.w, r.h, heatColor(n.file.Churn, maxChurn), html.EscapeString(n.file.File), n.file.LOC, n.file.Commits, n.file.Churn)
+		label(svg, n.name, r)
+		return
+	}
+	if n.name != "" {
+		fmt.Fprintf(svg, `<rect x="%.1f" y="%.1f" width="%.1f" height="%.1f" fill="#ddd" stroke="#888" stroke-width="0.5"/>`, r.x, r.y, r.w, r.h)
+		if r.h > 2*treemapLabel && label(svg, n.name, r) {
+			r = rect{r.x + 1, r.y + treemapLabel, r.w - 2, r.h - treemapLabel - 1}
+		}
+	}
+	sort.Slice(n.children, func(i, j int) bool {
+		if n.children[i].loc != n.children[j].loc {
+			return n.children[i].loc > n.children[j].loc
+		}
+		return n.children[i].name < n.children[j].name
+	})
+	values := make([]float64, len(n.children))
+	for i, child := range n.children {
+		values[i] = float64(child.loc)
+	}
+	for i, childRect := range squarify(values, r) {
+		n.children[i].draw(svg, childRect, maxChurn)
+	}
+}
+
+// label writes the name in the top left corner of the rectangle if it fits
+func label(svg *strings.Builder, name string, r rect) bool {
+	if r.h < treemapLabel || float64(len(name))*treemapCharWidth > r.w-4 {
+		return false
+	}
+	fmt.Fprintf(svg, `<text x="%.1f" y="%.1f">%s</text>`, r.x+2, r.y+11, html.EscapeString(strings.TrimSuffix(name, "/")))
+	return true
+}
+
+// heatColor goes from grey for no churn to red for the highest churn
+func heatColor(churn, maxChurn int) string {
+	heat := 0.0
+	if maxChurn > 0 {
+		heat = math.Log1p(float64(churn)) / math.Log1p(float64(maxChurn))
+	}
+	mix := func(from, to float64) int {
+		return int(math.Round(from + (to-from)*heat))
+	}
+	return fmt.Sprintf("#%02x%02x%02x", mix(0xd9, 0xd7), mix(0xe1, 0x30), mix(0xe8, 0x1f))
+}
+
+// squarify splits the rectangle into rectangles of areas proportional to the values, sorted from the
+// biggest, keeping them as square as possible (Bruls, Huizing and van Wijk, "Squarified Treemaps")
+func squarify(values []float64, r rect) []rect {
+	rects := make([]rect, len(values))
+	total := 0.0
+	for _, value := range values {
+		total += value
+	}
+	if total == 0 {
+		return rects
+	}
+	scale := r.w * r.h / total
+	for i := 0; i < len(values); {
+		short := math.Min(r.w, r.h)
+		j, rowArea := i+1, values[i]*scale
+		for j < len(values) {
+			area := rowArea + values[j]*scale
+			if worstRatio(values[i:j+1], area, short, scale) > worstRatio(values[i:j], rowArea, short, scale) {
+				break
+			}
+			j, rowArea = j+1, area
+		}
+		thickness := 0.0
+		if short > 0 {
+			thickness = rowArea / short
+		}
+		offset := 0.0
+		for k := i; k < j; k++ {
+			length := 0.0
+			if thickness > 0 {
+				length = values[k] * scale / thickness
+			}
+			if r.w >= r.h {
+				rects[k] = rect{r.x, r.y + offset, thickness, length}
+			} else {
+				rects[k] = rect{r.x + offset, r.y, length, thickness}
+			}
+			offset += length
+		}
+		if r.w >= r.h {
+			r.x, r.w = r.x+thickness, r.w-thickness
+		} else {
+			r.y, r.h = r.y+thickness, r.h-thickness
+		}
+		i = j
+	}
+	return rects
+}
+
+// worstRatio returns the highest aspect ratio of the rectangles of a row of the values laid along a side
+func worstRatio(values []float64, rowArea, side, scale float64) float64 {
+	worst := 0.0
+	for _, value := range values {
+		area := value * scale
+		if area == 0 {
+			continue
+		}
+		ratio := math.Max(side*side*area/(rowArea*rowArea), rowArea*rowArea/(side*side*area))
+		worst = math.Max(worst, ratio)
+	}
+	return worst
+}
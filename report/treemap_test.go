@@ -0,0 +1,55 @@
+package report
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	metrics "github.com/andymeneely/git-churn/matrics"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSquarify(t *testing.T) {
+	assert := assert.New(t)
+	rects := squarify([]float64{6, 6, 4, 3, 2, 2, 1}, rect{0, 0, 6, 4})
+	assert.Equal(7, len(rects))
+	for i, value := range []float64{6, 6, 4, 3, 2, 2, 1} {
+		r := rects[i]
+		assert.InDelta(value, r.w*r.h, 1e-9)
+		assert.True(r.x >= 0 && r.y >= 0 && r.x+r.w <= 6+1e-9 && r.y+r.h <= 4+1e-9)
+	}
+	// The first row holds the two biggest, side by side along the short side
+	assert.Equal(rect{0, 0, 3, 2}, rects[0])
+	assert.Equal(rect{0, 2, 3, 2}, rects[1])
+}
+
+func TestWriteTreemap(t *testing.T) {
+	assert := assert.New(t)
+	files := []metrics.FileHeat{
+		{File: "cmd/root.go", LOC: 300, Commits: 4, Churn: 120},
+		{File: "cmd/<x>.go", LOC: 100},
+		{File: "main.go", LOC: 50, Commits: 1, Churn: 1},
+		{File: "logo.png"},
+	}
+	var out bytes.Buffer
+	assert.Nil(WriteTreemap(&out, "git-churn at HEAD", files))
+	svg := out.String()
+	assert.Contains(svg, `<svg xmlns="http://www.w3.org/2000/svg"`)
+	assert.Contains(svg, `fill="#d7301f" stroke="#fff" stroke-width="0.5"><title>cmd/root.go: 300 lines, 4 commits, churn 120</title>`)
+	assert.Contains(svg, `fill="#d9e1e8" stroke="#fff" stroke-width="0.5"><title>cmd/&lt;x&gt;.go: 100 lines, 0 commits, churn 0</title>`)
+	assert.Contains(svg, `>cmd</text>`)
+	assert.NotContains(svg, "logo.png")
+
+	// The files of the same size are laid out by name, whatever their order
+	out.Reset()
+	assert.Nil(WriteTreemap(&out, "ties", []metrics.FileHeat{{File: "b.go", LOC: 10}, {File: "a.go", LOC: 10}}))
+	tied := out.String()
+	out.Reset()
+	assert.Nil(WriteTreemap(&out, "ties", []metrics.FileHeat{{File: "a.go", LOC: 10}, {File: "b.go", LOC: 10}}))
+	assert.Equal(tied, out.String())
+	assert.Less(strings.Index(tied, "a.go"), strings.Index(tied, "b.go"))
+
+	out.Reset()
+	assert.Nil(WriteTreemap(&out, "empty", nil))
+	assert.Contains(out.String(), "No data")
+}
@@ -0,0 +1,279 @@
+// Package schema describes the JSON results of the commands as a JSON Schema, the contract the consumers of
+// git-churn validate its output against, and stamps the results with the version of that contract
+package schema
+
+import (
+	"bytes"
+	"encoding"
+	"encoding/json"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Version of the output contract, stamped on every JSON result as VersionField. The minor version is bumped
+// when fields are added, the major one when fields are removed or renamed or change meaning.
+const Version = "1.0"
+
+// VersionField is the field of every JSON result holding the version of the contract it follows
+const VersionField = "SchemaVersion"
+
+// ID identifies the schema of this version of the contract
+const ID = "https://github.com/andymeneely/git-churn/schema/v" + Version
+
+// Schema is a JSON Schema (draft 2020-12), as much of it as the results need
+type Schema struct {
+	Schema               string             `json:"$schema,omitempty"`
+	ID                   string             `json:"$id,omitempty"`
+	Ref                  string             `json:"$ref,omitempty"`
+	Title                string             `json:"title,omitempty"`
+	Description          string             `json:"description,omitempty"`
+	Type                 interface{}        `json:"type,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	Const                interface{}        `json:"const,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	AdditionalProperties *Schema            `json:"additionalProperties,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	OneOf                []*Schema          `json:"oneOf,omitempty"`
+	Defs                 map[string]*Schema `json:"$defs,omitempty"`
+}
+
+// Document returns the schema of the JSON results of the commands, given by command name along with
+// examples of the values they print, usually zero values of their types. The result of every command is
+// defined in $defs under the name of the command, the types making it up under their Go names, and the
+// document itself validates the result of any command.
+func Document(results map[string][]interface{}) *Schema {
+	g := &generator{defs: make(map[string]*Schema)}
+	commands := make([]string, 0, len(results))
+	for command := range results {
+		commands = append(commands, command)
+	}
+	sort.Strings(commands)
+
+	doc := &Schema{
+		Schema:      "https://json-schema.org/draft/2020-12/schema",
+		ID:          ID,
+		Title:       "git-churn results",
+		Description: "JSON results of the git-churn commands, version " + Version + " of the output contract",
+	}
+	for _, command := range commands {
+		var alternatives []*Schema
+		for _, result := range results[command] {
+			alternatives = append(alternatives, g.result(reflect.TypeOf(result)))
+		}
+		def := &Schema{OneOf: alternatives}
+		if len(alternatives) == 1 {
+			def = alternatives[0]
+		}
+		def.Title = command
+		g.defs[command] = def
+		doc.OneOf = append(doc.OneOf, &Schema{Ref: defRef(command)})
+	}
+	doc.Defs = g.defs
+	return doc
+}
+
+// For returns the schema of the result of a single command, the definitions of the other commands left
+// in $defs
+func For(results map[string][]interface{}, command string) (*Schema, bool) {
+	if _, ok := results[command]; !ok {
+		return nil, false
+	}
+	doc := Document(results)
+	doc.OneOf = nil
+	doc.Ref = defRef(command)
+	return doc, true
+}
+
+// Stamp adds VersionField to a JSON result, an object or an array of objects, stamping every object of
+// the array. Other values are returned as they are.
+func Stamp(result []byte) ([]byte, error) {
+	trimmed := bytes.TrimSpace(result)
+	if len(trimmed) == 0 {
+		return result, nil
+	}
+	switch trimmed[0] {
+	case '{':
+		return stampObject(trimmed), nil
+	case '[':
+		var items []json.RawMessage
+		if err := json.Unmarshal(trimmed, &items); err != nil {
+			return nil, err
+		}
+		for i, item := range items {
+			if len(item) > 0 && item[0] == '{' {
+				items[i] = stampObject(item)
+			}
+		}
+		return json.Marshal(items)
+	}
+	return result, nil
+}
+
+// stampObject adds VersionField first to the compact JSON object
+func stampObject(object []byte) []byte {
+	stamped := []byte(`{"` + VersionField + `":"` + Version + `"`)
+	rest := bytes.TrimSpace(object[1:])
+	if len(rest) > 0 && rest[0] != '}' {
+		stamped = append(stamped, ',')
+	}
+	return append(stamped, rest...)
+}
+
+var (
+	timeType          = reflect.TypeOf(time.Time{})
+	textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+	jsonMarshalerType = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+)
+
+// generator builds the schemas of Go types as encoding/json marshals them, the named structs being
+// defined once in defs
+type generator struct {
+	defs map[string]*Schema
+}
+
+// result returns the schema of the result of a command, its objects stamped with VersionField
+func (g *generator) result(t reflect.Type) *Schema {
+	stamp := func(s *Schema) *Schema {
+		s.Properties = map[string]*Schema{VersionField: {Type: "string", Const: Version}}
+		s.Required = []string{VersionField}
+		return s
+	}
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	switch {
+	case t.Kind() == reflect.Struct && t != timeType:
+		return stamp(g.schema(t))
+	case (t.Kind() == reflect.Slice || t.Kind() == reflect.Array) && t.Elem().Kind() != reflect.Uint8:
+		s := g.schema(t)
+		items := t.Elem()
+		for items.Kind() == reflect.Ptr {
+			items = items.Elem()
+		}
+		if items.Kind() == reflect.Struct && items != timeType {
+			s.Items = stamp(g.schema(items))
+		}
+		return s
+	}
+	return g.schema(t)
+}
+
+// schema returns the schema of the type, a reference for the named structs
+func (g *generator) schema(t reflect.Type) *Schema {
+	if t.Kind() == reflect.Ptr {
+		return nullable(g.schema(t.Elem()))
+	}
+	if t == timeType {
+		return &Schema{Type: "string", Format: "date-time"}
+	}
+	if t.Implements(jsonMarshalerType) {
+		// Anything
+		return &Schema{}
+	}
+	if t.Implements(textMarshalerType) || reflect.PtrTo(t).Implements(textMarshalerType) {
+		return &Schema{Type: "string"}
+	}
+	switch t.Kind() {
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return &Schema{Type: "integer"}
+	case reflect.Float32, reflect.Float64:
+		return &Schema{Type: "number"}
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Slice:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return &Schema{Type: []string{"string", "null"}, Format: "byte"}
+		}
+		return &Schema{Type: []string{"array", "null"}, Items: g.schema(t.Elem())}
+	case reflect.Array:
+		return &Schema{Type: "array", Items: g.schema(t.Elem())}
+	case reflect.Map:
+		return &Schema{Type: []string{"object", "null"}, AdditionalProperties: g.schema(t.Elem())}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return g.object(t)
+		}
+		name := t.String()
+		if _, ok := g.defs[name]; !ok {
+			// Defined before its fields so that the recursive types refer to it
+			g.defs[name] = &Schema{}
+			*g.defs[name] = *g.object(t)
+		}
+		return &Schema{Ref: defRef(name)}
+	}
+	// Interfaces, anything
+	return &Schema{}
+}
+
+// object returns the schema of the fields of the struct, the fields of its embedded structs included
+func (g *generator) object(t reflect.Type) *Schema {
+	s := &Schema{Type: "object", Properties: make(map[string]*Schema)}
+	g.fields(t, s)
+	sort.Strings(s.Required)
+	return s
+}
+
+func (g *generator) fields(t reflect.Type, s *Schema) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, options := tag, ""
+		if comma := strings.Index(tag, ","); comma >= 0 {
+			name, options = tag[:comma], tag[comma:]
+		}
+		fieldType := field.Type
+		if field.Anonymous && name == "" {
+			for fieldType.Kind() == reflect.Ptr {
+				fieldType = fieldType.Elem()
+			}
+			if fieldType.Kind() == reflect.Struct {
+				g.fields(fieldType, s)
+				continue
+			}
+		}
+		if field.PkgPath != "" {
+			// Unexported
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		property := g.schema(field.Type)
+		if strings.Contains(options, ",string") {
+			property = &Schema{Type: "string"}
+		}
+		s.Properties[name] = property
+		if !strings.Contains(options, ",omitempty") {
+			s.Required = append(s.Required, name)
+		}
+	}
+}
+
+// nullable lets the value be null too
+func nullable(s *Schema) *Schema {
+	switch t := s.Type.(type) {
+	case string:
+		s.Type = []string{t, "null"}
+		return s
+	case []string:
+		return s
+	}
+	if s.Ref != "" {
+		return &Schema{OneOf: []*Schema{s, {Type: "null"}}}
+	}
+	// Anything, null included
+	return s
+}
+
+func defRef(name string) string {
+	return "#/$defs/" + name
+}
@@ -0,0 +1,211 @@
+package schema
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	metrics "github.com/andymeneely/git-churn/matrics"
+	"github.com/andymeneely/git-churn/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+type inner struct {
+	Count int
+}
+
+type node struct {
+	inner
+	Name     string
+	Note     string `json:",omitempty"`
+	Renamed  bool   `json:"renamed"`
+	Skipped  string `json:"-"`
+	When     time.Time
+	Until    *time.Time `json:",omitempty"`
+	Children []*node
+	Tags     map[string]float64
+	Any      interface{}
+	private  int
+}
+
+func TestDocument(t *testing.T) {
+	assert := assert.New(t)
+	doc := Document(map[string][]interface{}{"nodes": {[]node{}}, "node": {node{}, inner{}}})
+	assert.Equal(ID, doc.ID)
+	assert.Equal([]*Schema{{Ref: "#/$defs/node"}, {Ref: "#/$defs/nodes"}}, doc.OneOf)
+
+	def := doc.Defs["schema.node"]
+	assert.Equal("object", def.Type)
+	assert.Equal([]string{"Any", "Children", "Count", "Name", "Tags", "When", "renamed"}, def.Required)
+	assert.Len(def.Properties, 9)
+	assert.Equal(&Schema{Type: "integer"}, def.Properties["Count"])
+	assert.Equal(&Schema{Type: "string", Format: "date-time"}, def.Properties["When"])
+	assert.Equal(&Schema{Type: []string{"string", "null"}, Format: "date-time"}, def.Properties["Until"])
+	// Recursive
+	assert.Equal(&Schema{Type: []string{"array", "null"}, Items: &Schema{OneOf: []*Schema{{Ref: "#/$defs/schema.node"}, {Type: "null"}}}}, def.Properties["Children"])
+	assert.Equal(&Schema{Type: []string{"object", "null"}, AdditionalProperties: &Schema{Type: "number"}}, def.Properties["Tags"])
+	assert.Equal(&Schema{}, def.Properties["Any"])
+
+	stamp := map[string]*Schema{VersionField: {Type: "string", Const: Version}}
+	nodes := doc.Defs["nodes"]
+	assert.Equal([]string{"array", "null"}, nodes.Type)
+	assert.Equal(&Schema{Ref: "#/$defs/schema.node", Properties: stamp, Required: []string{VersionField}}, nodes.Items)
+	assert.Len(doc.Defs["node"].OneOf, 2)
+	assert.Equal("#/$defs/schema.inner", doc.Defs["node"].OneOf[1].Ref)
+
+	single, ok := For(map[string][]interface{}{"nodes": {[]node{}}}, "nodes")
+	assert.True(ok)
+	assert.Equal("#/$defs/nodes", single.Ref)
+	assert.Nil(single.OneOf)
+	_, ok = For(map[string][]interface{}{}, "nodes")
+	assert.False(ok)
+}
+
+func TestStamp(t *testing.T) {
+	assert := assert.New(t)
+	for result, stamped := range map[string]string{
+		`{"A":1}`:        `{"SchemaVersion":"1.0","A":1}`,
+		`{}`:             `{"SchemaVersion":"1.0"}`,
+		`[{"A":1},{},2]`: `[{"SchemaVersion":"1.0","A":1},{"SchemaVersion":"1.0"},2]`,
+		`null`:           `null`,
+		`"text"`:         `"text"`,
+		"{\"A\":[{}]}\n": `{"SchemaVersion":"1.0","A":[{}]}`,
+		`[]`:             `[]`,
+	} {
+		out, err := Stamp([]byte(result))
+		assert.Nil(err)
+		assert.Equal(stamped, string(out), result)
+	}
+	_, err := Stamp([]byte(`[1,`))
+	assert.NotNil(err)
+}
+
+// TestResults validates real results, stamped, against their schema
+func TestResults(t *testing.T) {
+	assert := assert.New(t)
+	repo := testutil.NewRepo(t)
+	repo.CommitFiles("Add the parser", map[string]string{"main.go": "package main\n", "parser/lex.go": "package parser\n"})
+	repo.As("bob").CommitFiles("Lex", map[string]string{"parser/lex.go": "package parser\n\nfunc Lex() {}\n"})
+	commits, err := metrics.RangeChurn(repo.Repository, "", "HEAD")
+	assert.Nil(err)
+	hotspots, err := metrics.Hotspots(repo.Repository, commits, "HEAD", 0)
+	assert.Nil(err)
+
+	results := map[string]interface{}{
+		"commits":  commits,
+		"tree":     metrics.ChurnTree(commits, 0),
+		"stats":    metrics.CommitSizes(commits, true),
+		"hotspots": hotspots,
+		"empty":    []metrics.Hotspot(nil),
+	}
+	types := make(map[string][]interface{}, len(results))
+	for command, result := range results {
+		types[command] = []interface{}{result}
+	}
+	doc := Document(types)
+	for command, result := range results {
+		out, err := json.Marshal(result)
+		assert.Nil(err)
+		out, err = Stamp(out)
+		assert.Nil(err)
+		var value interface{}
+		assert.Nil(json.Unmarshal(out, &value))
+		assert.Nil(validate(doc, doc.Defs[command], value, command), command)
+	}
+	// Not stamped
+	assert.NotNil(validate(doc, doc.Defs["tree"], map[string]interface{}{}, "tree"))
+}
+
+// validate checks the value against the keywords of the schema Document generates
+func validate(doc, s *Schema, value interface{}, path string) error {
+	if s.Ref != "" {
+		if err := validate(doc, doc.Defs[strings.TrimPrefix(s.Ref, "#/$defs/")], value, path); err != nil {
+			return err
+		}
+	}
+	if s.Const != nil && s.Const != value {
+		return fmt.Errorf("%s: %v is not %v", path, value, s.Const)
+	}
+	if s.OneOf != nil {
+		matches := 0
+		for _, alternative := range s.OneOf {
+			if validate(doc, alternative, value, path) == nil {
+				matches += 1
+			}
+		}
+		if matches != 1 {
+			return fmt.Errorf("%s: %d alternatives match", path, matches)
+		}
+	}
+	if s.Type != nil {
+		types, ok := s.Type.([]string)
+		if !ok {
+			types = []string{s.Type.(string)}
+		}
+		if !hasType(types, value) {
+			return fmt.Errorf("%s: %v is not of type %v", path, value, types)
+		}
+	}
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for _, name := range s.Required {
+			if _, ok := v[name]; !ok {
+				return fmt.Errorf("%s: %s is missing", path, name)
+			}
+		}
+		for name, property := range v {
+			if p, ok := s.Properties[name]; ok {
+				if err := validate(doc, p, property, path+"."+name); err != nil {
+					return err
+				}
+			} else if s.AdditionalProperties != nil {
+				if err := validate(doc, s.AdditionalProperties, property, path+"."+name); err != nil {
+					return err
+				}
+			}
+		}
+	case []interface{}:
+		if s.Items != nil {
+			for i, item := range v {
+				if err := validate(doc, s.Items, item, fmt.Sprintf("%s[%d]", path, i)); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+func hasType(types []string, value interface{}) bool {
+	for _, t := range types {
+		switch v := value.(type) {
+		case nil:
+			if t == "null" {
+				return true
+			}
+		case bool:
+			if t == "boolean" {
+				return true
+			}
+		case float64:
+			if t == "number" || t == "integer" && v == float64(int64(v)) {
+				return true
+			}
+		case string:
+			if t == "string" {
+				return true
+			}
+		case []interface{}:
+			if t == "array" {
+				return true
+			}
+		case map[string]interface{}:
+			if t == "object" {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,947 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// The GraphQL endpoint implements the query language over a schema of objects whose fields are
+// resolved either by a resolver or, for the plain Go structs of the metrics, by the exported struct
+// field of the same name, case insensitively. Queries, variables, aliases, fragments and the @skip and
+// @include directives are supported, mutations, subscriptions and introspection (but __typename) are not.
+
+// object is a type of the schema, with the fields that take arguments or are computed
+type object struct {
+	name   string
+	fields map[string]*field
+}
+
+// field is a field of an object computed by a resolver
+type field struct {
+	// Arguments taken, with their default value, nil for none
+	args    map[string]interface{}
+	resolve func(source interface{}, args arguments) (interface{}, error)
+	// Object type of the value, or of its elements for lists. Nil for the scalars and the structs
+	// whose fields are all resolved by name.
+	typ *object
+}
+
+// arguments are the arguments of a field, coerced from the query literals and the JSON variables
+type arguments map[string]interface{}
+
+func (a arguments) string(name string) (string, error) {
+	switch value := a[name].(type) {
+	case nil:
+		return "", nil
+	case string:
+		return value, nil
+	}
+	return "", fmt.Errorf("argument %q: %v is not a string", name, a[name])
+}
+
+func (a arguments) int(name string) (int, error) {
+	switch value := a[name].(type) {
+	case nil:
+		return 0, nil
+	case int:
+		return value, nil
+	case float64:
+		if value == float64(int(value)) {
+			return int(value), nil
+		}
+	}
+	return 0, fmt.Errorf("argument %q: %v is not an int", name, a[name])
+}
+
+func (a arguments) bool(name string) (bool, error) {
+	switch value := a[name].(type) {
+	case nil:
+		return false, nil
+	case bool:
+		return value, nil
+	}
+	return false, fmt.Errorf("argument %q: %v is not a boolean", name, a[name])
+}
+
+// Nodes of the parsed documents
+
+type document struct {
+	operations []*operation
+	fragments  map[string]*fragment
+}
+
+type operation struct {
+	name       string
+	variables  []variableDefinition
+	selections []selection
+}
+
+type variableDefinition struct {
+	name         string
+	nonNull      bool
+	defaultValue interface{}
+}
+
+type fragment struct {
+	typeCondition string
+	selections    []selection
+}
+
+// selection is a field, a fragment spread or an inline fragment
+type selection struct {
+	alias, name string
+	arguments   map[string]interface{}
+	directives  []directive
+	selections  []selection
+	// Name of the spread fragment, or type condition of the inline fragment
+	fragment       string
+	inlineFragment bool
+}
+
+type directive struct {
+	name      string
+	arguments map[string]interface{}
+}
+
+// variable and enumValue are the values of the query that are not JSON literals
+type variable string
+type enumValue string
+
+// gqlError is an error reported in the errors of the response, with the path of the field causing it
+type gqlError struct {
+	Message string        `json:"message"`
+	Path    []interface{} `json:"path,omitempty"`
+}
+
+// gqlResponse is the result of a query
+type gqlResponse struct {
+	Data   interface{} `json:"data,omitempty"`
+	Errors []gqlError  `json:"errors,omitempty"`
+}
+
+// Lexer
+
+const (
+	tokenEOF = iota
+	tokenPunctuator
+	tokenName
+	tokenInt
+	tokenFloat
+	tokenString
+)
+
+type token struct {
+	kind  int
+	value string
+}
+
+// maxSelectionDepth is the deepest selection sets and fragments may nest, so that a query cannot exhaust
+// the stack of the server
+const maxSelectionDepth = 64
+
+type parser struct {
+	src   string
+	pos   int
+	token token
+	// Selection sets being parsed
+	depth int
+	// First error of the lexer, after which the tokens end
+	err error
+}
+
+func (p *parser) errorf(format string, args ...interface{}) error {
+	line := strings.Count(p.src[:p.pos], "\n") + 1
+	return fmt.Errorf("syntax error at line %d: %s", line, fmt.Sprintf(format, args...))
+}
+
+// next reads the next token. On error the input ends there, so that the parsing stops on an unexpected
+// end, and the error of the lexer is reported.
+func (p *parser) next() error {
+	if err := p.lex(); err != nil {
+		if p.err == nil {
+			p.err = err
+		}
+		p.token = token{kind: tokenEOF}
+		return err
+	}
+	return nil
+}
+
+// lex reads the next token, skipping the whitespace, the commas and the comments
+func (p *parser) lex() error {
+	for p.pos < len(p.src) {
+		c := p.src[p.pos]
+		if c == '#' {
+			for p.pos < len(p.src) && p.src[p.pos] != '\n' {
+				p.pos++
+			}
+		} else if c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == ',' {
+			p.pos++
+		} else {
+			break
+		}
+	}
+	if p.pos == len(p.src) {
+		p.token = token{kind: tokenEOF}
+		return nil
+	}
+	start := p.pos
+	c := p.src[p.pos]
+	switch {
+	case strings.HasPrefix(p.src[p.pos:], "..."):
+		p.pos += 3
+		p.token = token{tokenPunctuator, "..."}
+	case strings.IndexByte("!$():=@[]{}|", c) >= 0:
+		p.pos++
+		p.token = token{tokenPunctuator, string(c)}
+	case c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z':
+		for p.pos < len(p.src) && (p.src[p.pos] == '_' || isAlphaNumeric(p.src[p.pos])) {
+			p.pos++
+		}
+		p.token = token{tokenName, p.src[start:p.pos]}
+	case c == '-' || (c >= '0' && c <= '9'):
+		p.pos++
+		kind := tokenInt
+		for p.pos < len(p.src) && strings.IndexByte("0123456789.eE+-", p.src[p.pos]) >= 0 {
+			if strings.IndexByte(".eE", p.src[p.pos]) >= 0 {
+				kind = tokenFloat
+			}
+			p.pos++
+		}
+		p.token = token{kind, p.src[start:p.pos]}
+	case c == '"':
+		return p.readString()
+	default:
+		return p.errorf("unexpected character %q", c)
+	}
+	return nil
+}
+
+func isAlphaNumeric(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9'
+}
+
+// readString reads a string, or a block string between triple quotes
+func (p *parser) readString() error {
+	if strings.HasPrefix(p.src[p.pos:], `"""`) {
+		end := strings.Index(p.src[p.pos+3:], `"""`)
+		if end < 0 {
+			return p.errorf("unterminated string")
+		}
+		p.token = token{tokenString, strings.TrimSpace(p.src[p.pos+3 : p.pos+3+end])}
+		p.pos += end + 6
+		return nil
+	}
+	start := p.pos
+	for p.pos++; p.pos < len(p.src) && p.src[p.pos] != '"'; p.pos++ {
+		if p.src[p.pos] == '\\' {
+			p.pos++
+		} else if p.src[p.pos] == '\n' {
+			break
+		}
+	}
+	if p.pos >= len(p.src) || p.src[p.pos] != '"' {
+		return p.errorf("unterminated string")
+	}
+	p.pos++
+	// The escapes of GraphQL are the ones of JSON
+	var value string
+	if err := json.Unmarshal([]byte(p.src[start:p.pos]), &value); err != nil {
+		return p.errorf("invalid string %s", p.src[start:p.pos])
+	}
+	p.token = token{tokenString, value}
+	return nil
+}
+
+// peek tells whether the current token is the given punctuator
+func (p *parser) peek(punctuator string) bool {
+	return p.token.kind == tokenPunctuator && p.token.value == punctuator
+}
+
+// skip reads past the punctuator if it is the current token
+func (p *parser) skip(punctuator string) (bool, error) {
+	if !p.peek(punctuator) {
+		return false, nil
+	}
+	return true, p.next()
+}
+
+func (p *parser) expect(punctuator string) error {
+	if !p.peek(punctuator) {
+		return p.errorf("expected %q, found %q", punctuator, p.token.value)
+	}
+	return p.next()
+}
+
+func (p *parser) name() (string, error) {
+	if p.token.kind != tokenName {
+		return "", p.errorf("expected a name, found %q", p.token.value)
+	}
+	name := p.token.value
+	return name, p.next()
+}
+
+// Parser
+
+func parseQuery(query string) (*document, error) {
+	p := &parser{src: query}
+	doc, err := p.document()
+	if p.err != nil {
+		return nil, p.err
+	}
+	return doc, err
+}
+
+func (p *parser) document() (*document, error) {
+	if err := p.next(); err != nil {
+		return nil, err
+	}
+	doc := &document{fragments: make(map[string]*fragment)}
+	for p.token.kind != tokenEOF {
+		if p.peek("{") {
+			selections, err := p.selectionSet()
+			if err != nil {
+				return nil, err
+			}
+			doc.operations = append(doc.operations, &operation{selections: selections})
+			continue
+		}
+		keyword, err := p.name()
+		if err != nil {
+			return nil, err
+		}
+		switch keyword {
+		case "query":
+			op, err := p.operation()
+			if err != nil {
+				return nil, err
+			}
+			doc.operations = append(doc.operations, op)
+		case "fragment":
+			name, err := p.name()
+			if err != nil {
+				return nil, err
+			}
+			if on, err := p.name(); err != nil || on != "on" {
+				return nil, p.errorf("expected the type condition of fragment %s", name)
+			}
+			f := &fragment{}
+			if f.typeCondition, err = p.name(); err != nil {
+				return nil, err
+			}
+			if f.selections, err = p.selectionSet(); err != nil {
+				return nil, err
+			}
+			doc.fragments[name] = f
+		case "mutation", "subscription":
+			return nil, fmt.Errorf("%s operations are not supported", keyword)
+		default:
+			return nil, p.errorf("unexpected %q", keyword)
+		}
+	}
+	if len(doc.operations) == 0 {
+		return nil, errors.New("the document has no operation")
+	}
+	if err := checkFragmentCycles(doc.fragments); err != nil {
+		return nil, err
+	}
+	return doc, nil
+}
+
+// checkFragmentCycles rejects the fragments spreading themselves, directly or through other fragments,
+// as the NoFragmentCycles rule of the spec does
+func checkFragmentCycles(fragments map[string]*fragment) error {
+	names := make([]string, 0, len(fragments))
+	for name := range fragments {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	// Fragments whose spreads were all visited, and the ones being visited
+	done := make(map[string]bool)
+	var visit func(name string, path []string) error
+	visit = func(name string, path []string) error {
+		for i, visiting := range path {
+			if visiting == name {
+				return fmt.Errorf("cannot spread fragment %q within itself via %s", name, strings.Join(append(path[i:], name), " → "))
+			}
+		}
+		f, ok := fragments[name]
+		if !ok || done[name] {
+			return nil
+		}
+		path = append(path, name)
+		for _, spread := range fragmentSpreads(f.selections, nil) {
+			if err := visit(spread, path); err != nil {
+				return err
+			}
+		}
+		done[name] = true
+		return nil
+	}
+	for _, name := range names {
+		if err := visit(name, nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// fragmentSpreads appends the names of the fragments spread in the selections, at any depth
+func fragmentSpreads(selections []selection, spreads []string) []string {
+	for _, s := range selections {
+		if s.fragment != "" && !s.inlineFragment {
+			spreads = append(spreads, s.fragment)
+		}
+		spreads = fragmentSpreads(s.selections, spreads)
+	}
+	return spreads
+}
+
+func (p *parser) operation() (*operation, error) {
+	op := &operation{}
+	var err error
+	if p.token.kind == tokenName {
+		if op.name, err = p.name(); err != nil {
+			return nil, err
+		}
+	}
+	if ok, err := p.skip("("); err != nil {
+		return nil, err
+	} else if ok {
+		for !p.peek(")") {
+			definition, err := p.variableDefinition()
+			if err != nil {
+				return nil, err
+			}
+			op.variables = append(op.variables, definition)
+		}
+		if err := p.next(); err != nil {
+			return nil, err
+		}
+	}
+	if op.selections, err = p.selectionSet(); err != nil {
+		return nil, err
+	}
+	return op, nil
+}
+
+func (p *parser) variableDefinition() (variableDefinition, error) {
+	var definition variableDefinition
+	if err := p.expect("$"); err != nil {
+		return definition, err
+	}
+	var err error
+	if definition.name, err = p.name(); err != nil {
+		return definition, err
+	}
+	if err := p.expect(":"); err != nil {
+		return definition, err
+	}
+	if definition.nonNull, err = p.variableType(); err != nil {
+		return definition, err
+	}
+	if ok, err := p.skip("="); err != nil {
+		return definition, err
+	} else if ok {
+		if definition.defaultValue, err = p.value(true); err != nil {
+			return definition, err
+		}
+	}
+	return definition, nil
+}
+
+// variableType reads the type of a variable, returning whether it is non null. The types are not
+// checked beyond that, the arguments are coerced by the fields.
+func (p *parser) variableType() (bool, error) {
+	if ok, err := p.skip("["); err != nil {
+		return false, err
+	} else if ok {
+		if _, err := p.variableType(); err != nil {
+			return false, err
+		}
+		if err := p.expect("]"); err != nil {
+			return false, err
+		}
+	} else if _, err := p.name(); err != nil {
+		return false, err
+	}
+	return p.skip("!")
+}
+
+func (p *parser) selectionSet() ([]selection, error) {
+	if err := p.expect("{"); err != nil {
+		return nil, err
+	}
+	p.depth++
+	defer func() { p.depth-- }()
+	if p.depth > maxSelectionDepth {
+		return nil, p.errorf("selection sets nested deeper than %d", maxSelectionDepth)
+	}
+	var selections []selection
+	for !p.peek("}") {
+		if p.token.kind == tokenEOF {
+			return nil, p.errorf("unterminated selection set")
+		}
+		s, err := p.selection()
+		if err != nil {
+			return nil, err
+		}
+		selections = append(selections, s)
+	}
+	return selections, p.next()
+}
+
+func (p *parser) selection() (selection, error) {
+	var s selection
+	var err error
+	if ok, err := p.skip("..."); err != nil {
+		return s, err
+	} else if ok {
+		if p.token.kind == tokenName && p.token.value != "on" {
+			s.fragment, err = p.name()
+			if err != nil {
+				return s, err
+			}
+			s.directives, err = p.directives()
+			return s, err
+		}
+		s.inlineFragment = true
+		if p.token.kind == tokenName {
+			p.next()
+			if s.fragment, err = p.name(); err != nil {
+				return s, err
+			}
+		}
+		if s.directives, err = p.directives(); err != nil {
+			return s, err
+		}
+		s.selections, err = p.selectionSet()
+		return s, err
+	}
+
+	if s.name, err = p.name(); err != nil {
+		return s, err
+	}
+	s.alias = s.name
+	if ok, err := p.skip(":"); err != nil {
+		return s, err
+	} else if ok {
+		if s.name, err = p.name(); err != nil {
+			return s, err
+		}
+	}
+	if s.arguments, err = p.arguments(); err != nil {
+		return s, err
+	}
+	if s.directives, err = p.directives(); err != nil {
+		return s, err
+	}
+	if p.peek("{") {
+		s.selections, err = p.selectionSet()
+	}
+	return s, err
+}
+
+func (p *parser) arguments() (map[string]interface{}, error) {
+	args := make(map[string]interface{})
+	if ok, err := p.skip("("); err != nil || !ok {
+		return args, err
+	}
+	for !p.peek(")") {
+		name, err := p.name()
+		if err != nil {
+			return nil, err
+		}
+		if err := p.expect(":"); err != nil {
+			return nil, err
+		}
+		if args[name], err = p.value(false); err != nil {
+			return nil, err
+		}
+	}
+	return args, p.next()
+}
+
+func (p *parser) directives() ([]directive, error) {
+	var directives []directive
+	for p.peek("@") {
+		p.next()
+		var d directive
+		var err error
+		if d.name, err = p.name(); err != nil {
+			return nil, err
+		}
+		if d.arguments, err = p.arguments(); err != nil {
+			return nil, err
+		}
+		directives = append(directives, d)
+	}
+	return directives, nil
+}
+
+// value reads a value, which may not hold variables when constant
+func (p *parser) value(constant bool) (interface{}, error) {
+	t := p.token
+	switch {
+	case t.kind == tokenPunctuator && t.value == "$" && !constant:
+		p.next()
+		name, err := p.name()
+		return variable(name), err
+	case t.kind == tokenPunctuator && t.value == "[":
+		p.next()
+		list := []interface{}{}
+		for !p.peek("]") {
+			value, err := p.value(constant)
+			if err != nil {
+				return nil, err
+			}
+			list = append(list, value)
+		}
+		return list, p.next()
+	case t.kind == tokenPunctuator && t.value == "{":
+		p.next()
+		fields := make(map[string]interface{})
+		for !p.peek("}") {
+			name, err := p.name()
+			if err != nil {
+				return nil, err
+			}
+			if err := p.expect(":"); err != nil {
+				return nil, err
+			}
+			if fields[name], err = p.value(constant); err != nil {
+				return nil, err
+			}
+		}
+		return fields, p.next()
+	case t.kind == tokenInt:
+		value, err := strconv.Atoi(t.value)
+		if err != nil {
+			return nil, p.errorf("invalid int %s", t.value)
+		}
+		return value, p.next()
+	case t.kind == tokenFloat:
+		value, err := strconv.ParseFloat(t.value, 64)
+		if err != nil {
+			return nil, p.errorf("invalid float %s", t.value)
+		}
+		return value, p.next()
+	case t.kind == tokenString:
+		return t.value, p.next()
+	case t.kind == tokenName:
+		switch t.value {
+		case "true", "false":
+			return t.value == "true", p.next()
+		case "null":
+			return nil, p.next()
+		}
+		return enumValue(t.value), p.next()
+	}
+	return nil, p.errorf("unexpected %q", t.value)
+}
+
+// Executor
+
+type executor struct {
+	fragments map[string]*fragment
+	variables map[string]interface{}
+	errors    []gqlError
+}
+
+// execute runs the operation of the query with the given name, which may be empty when the query has
+// a single one, resolving its fields from the query object
+func execute(query *object, queryText, operationName string, variables map[string]interface{}) (*gqlResponse, error) {
+	doc, err := parseQuery(queryText)
+	if err != nil {
+		return nil, err
+	}
+	var op *operation
+	for _, o := range doc.operations {
+		if o.name == operationName || (operationName == "" && len(doc.operations) == 1) {
+			op = o
+		}
+	}
+	if op == nil {
+		if operationName == "" {
+			return nil, errors.New("the operationName is required to pick one of the operations of the document")
+		}
+		return nil, fmt.Errorf("unknown operation %q", operationName)
+	}
+
+	e := &executor{fragments: doc.fragments, variables: make(map[string]interface{})}
+	for _, definition := range op.variables {
+		value, ok := variables[definition.name]
+		if !ok {
+			value = definition.defaultValue
+		}
+		if value == nil && definition.nonNull {
+			return nil, fmt.Errorf("variable $%s is required", definition.name)
+		}
+		e.variables[definition.name] = value
+	}
+	data, err := e.selectFields(reflect.ValueOf(struct{}{}), query, op.selections, nil)
+	if err != nil {
+		return nil, err
+	}
+	return &gqlResponse{Data: data, Errors: e.errors}, nil
+}
+
+// fields are the fields of an object in the order of the query, encoded to JSON in that order
+type fields []fieldValue
+
+type fieldValue struct {
+	key   string
+	value interface{}
+}
+
+func (f fields) MarshalJSON() ([]byte, error) {
+	var b bytes.Buffer
+	b.WriteByte('{')
+	for i, field := range f {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		key, _ := json.Marshal(field.key)
+		value, err := json.Marshal(field.value)
+		if err != nil {
+			return nil, err
+		}
+		b.Write(key)
+		b.WriteByte(':')
+		b.Write(value)
+	}
+	b.WriteByte('}')
+	return b.Bytes(), nil
+}
+
+// collectFields groups the fields selected on an object by their response key, expanding the fragments
+// and leaving out the skipped fields. The selections of the fields of the same key are merged. A fragment
+// is expanded once per object, the visited ones being skipped like in the CollectFields of the spec.
+func (e *executor) collectFields(typeName string, selections []selection, keys *[]string, byKey map[string]*selection, visited map[string]bool, depth int) error {
+	if depth > maxSelectionDepth {
+		return fmt.Errorf("fragments nested deeper than %d", maxSelectionDepth)
+	}
+	for i := range selections {
+		s := selections[i]
+		if include, err := e.include(s.directives); err != nil {
+			return err
+		} else if !include {
+			continue
+		}
+		switch {
+		case s.inlineFragment:
+			if s.fragment == "" || s.fragment == typeName {
+				if err := e.collectFields(typeName, s.selections, keys, byKey, visited, depth+1); err != nil {
+					return err
+				}
+			}
+		case s.fragment != "":
+			f, ok := e.fragments[s.fragment]
+			if !ok {
+				return fmt.Errorf("unknown fragment %q", s.fragment)
+			}
+			if visited[s.fragment] {
+				continue
+			}
+			visited[s.fragment] = true
+			if f.typeCondition == typeName {
+				if err := e.collectFields(typeName, f.selections, keys, byKey, visited, depth+1); err != nil {
+					return err
+				}
+			}
+		default:
+			if merged, ok := byKey[s.alias]; ok {
+				if merged.name != s.name {
+					return fmt.Errorf("fields %q and %q conflict on the response key %q", merged.name, s.name, s.alias)
+				}
+				merged.selections = append(merged.selections[:len(merged.selections):len(merged.selections)], s.selections...)
+				continue
+			}
+			byKey[s.alias] = &s
+			*keys = append(*keys, s.alias)
+		}
+	}
+	return nil
+}
+
+// include evaluates the @skip and @include directives
+func (e *executor) include(directives []directive) (bool, error) {
+	for _, d := range directives {
+		value, err := e.resolveValue(d.arguments["if"])
+		if err != nil {
+			return false, err
+		}
+		condition, ok := value.(bool)
+		if !ok {
+			return false, fmt.Errorf("the if argument of @%s has to be a boolean", d.name)
+		}
+		switch d.name {
+		case "skip":
+			if condition {
+				return false, nil
+			}
+		case "include":
+			if !condition {
+				return false, nil
+			}
+		default:
+			return false, fmt.Errorf("unknown directive @%s", d.name)
+		}
+	}
+	return true, nil
+}
+
+// resolveValue replaces the variables of the value by their value
+func (e *executor) resolveValue(value interface{}) (interface{}, error) {
+	switch v := value.(type) {
+	case variable:
+		value, ok := e.variables[string(v)]
+		if !ok {
+			return nil, fmt.Errorf("variable $%s is not defined", v)
+		}
+		return value, nil
+	case enumValue:
+		return string(v), nil
+	case []interface{}:
+		list := make([]interface{}, len(v))
+		for i, item := range v {
+			var err error
+			if list[i], err = e.resolveValue(item); err != nil {
+				return nil, err
+			}
+		}
+		return list, nil
+	case map[string]interface{}:
+		fields := make(map[string]interface{}, len(v))
+		for name, item := range v {
+			var err error
+			if fields[name], err = e.resolveValue(item); err != nil {
+				return nil, err
+			}
+		}
+		return fields, nil
+	}
+	return value, nil
+}
+
+// selectFields resolves the selected fields of the source, of the given type or of a plain struct when
+// nil. Errors in the query fail the whole request, errors of the resolvers are reported with their path
+// and null the field.
+func (e *executor) selectFields(source reflect.Value, typ *object, selections []selection, path []interface{}) (fields, error) {
+	typeName := source.Type().Name()
+	if typ != nil {
+		typeName = typ.name
+	}
+	var keys []string
+	byKey := make(map[string]*selection)
+	if err := e.collectFields(typeName, selections, &keys, byKey, make(map[string]bool), 0); err != nil {
+		return nil, err
+	}
+
+	result := make(fields, 0, len(keys))
+	for _, key := range keys {
+		s := byKey[key]
+		fieldPath := append(path[:len(path):len(path)], key)
+		var value interface{}
+		var f *field
+		if typ != nil {
+			f = typ.fields[s.name]
+		}
+		switch {
+		case s.name == "__typename":
+			value = typeName
+		case f != nil:
+			args, err := e.coerceArguments(f, s)
+			if err != nil {
+				return nil, err
+			}
+			resolved, err := f.resolve(source.Interface(), args)
+			if err != nil {
+				e.errors = append(e.errors, gqlError{err.Error(), fieldPath})
+				break
+			}
+			if value, err = e.complete(reflect.ValueOf(resolved), f.typ, s, fieldPath); err != nil {
+				return nil, err
+			}
+		default:
+			structField := source.FieldByNameFunc(func(name string) bool {
+				return unicode.IsUpper(rune(name[0])) && strings.EqualFold(name, s.name)
+			})
+			if !structField.IsValid() {
+				return nil, fmt.Errorf("cannot query field %q on type %q", s.name, typeName)
+			}
+			if len(s.arguments) > 0 {
+				return nil, fmt.Errorf("field %q of type %q takes no arguments", s.name, typeName)
+			}
+			var err error
+			if value, err = e.complete(structField, nil, s, fieldPath); err != nil {
+				return nil, err
+			}
+		}
+		result = append(result, fieldValue{key, value})
+	}
+	return result, nil
+}
+
+// coerceArguments resolves the arguments of the field, defaulting the ones not given
+func (e *executor) coerceArguments(f *field, s *selection) (arguments, error) {
+	args := make(arguments, len(f.args))
+	for name, defaultValue := range f.args {
+		args[name] = defaultValue
+	}
+	for name, value := range s.arguments {
+		if _, ok := f.args[name]; !ok {
+			return nil, fmt.Errorf("unknown argument %q of field %q", name, s.name)
+		}
+		value, err := e.resolveValue(value)
+		if err != nil {
+			return nil, err
+		}
+		if value != nil {
+			args[name] = value
+		}
+	}
+	return args, nil
+}
+
+// complete turns the value of a field into its JSON result: the scalars as they are, the lists item by
+// item and the objects by their selected fields
+func (e *executor) complete(value reflect.Value, typ *object, s *selection, path []interface{}) (interface{}, error) {
+	for value.Kind() == reflect.Ptr || value.Kind() == reflect.Interface {
+		if value.IsNil() {
+			return nil, nil
+		}
+		value = value.Elem()
+	}
+	if !value.IsValid() {
+		return nil, nil
+	}
+	if value.Kind() == reflect.Slice && value.Type().Elem().Kind() != reflect.Uint8 {
+		list := make([]interface{}, value.Len())
+		for i := range list {
+			var err error
+			if list[i], err = e.complete(value.Index(i), typ, s, append(path[:len(path):len(path)], i)); err != nil {
+				return nil, err
+			}
+		}
+		return list, nil
+	}
+	_, marshaler := value.Interface().(json.Marshaler)
+	if value.Kind() != reflect.Struct || marshaler {
+		if len(s.selections) > 0 {
+			return nil, fmt.Errorf("field %q is a scalar and has no subfields to select", s.name)
+		}
+		return value.Interface(), nil
+	}
+	if len(s.selections) == 0 {
+		return nil, fmt.Errorf("field %q is an object, its subfields have to be selected", s.name)
+	}
+	return e.selectFields(value, typ, s.selections, path)
+}
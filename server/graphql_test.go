@@ -0,0 +1,134 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/andymeneely/git-churn/testutil"
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/src-d/go-git.v4"
+)
+
+func graphQL(t *testing.T, s *Server, query string, variables map[string]interface{}) (int, string) {
+	body, _ := json.Marshal(graphQLRequest{Query: query, Variables: variables})
+	recorder := httptest.NewRecorder()
+	s.Handler().ServeHTTP(recorder, httptest.NewRequest(http.MethodPost, "/graphql", bytes.NewReader(body)))
+	return recorder.Code, recorder.Body.String()
+}
+
+func graphQLServer(t *testing.T) *Server {
+	s := New(time.Hour)
+	s.AllowedRepos = []string{"test"}
+	s.Clone = func(repoUrl string) (*git.Repository, error) {
+		repo := testutil.NewRepo(t)
+		repo.As("alice").Write("a.txt", "1\n2\n3\n").Write("b.txt", "1\n").Commit("first")
+		repo.As("bob").Write("a.txt", "1\nb\n3\n4\n").Commit("second")
+		repo.As("alice").Write("a.txt", "1\nb\n3\n4\n5\n").Commit("third")
+		return repo.Repository, nil
+	}
+	return s
+}
+
+func TestGraphQL(t *testing.T) {
+	assert := assert.New(t)
+	s := graphQLServer(t)
+
+	status, body := graphQL(t, s, `
+		query Hotspots($url: String!, $n: Int = 1) {
+			repository(url: $url) {
+				defaultBranch
+				hotspots(first: $n) { file loc commits top: authors(first: 1) { author churn } }
+				authors(sortBy: "churn") { ...author }
+			}
+		}
+		fragment author on Author { __typename name history(first: 1) { message files { file churn } } }`,
+		map[string]interface{}{"url": "test"})
+	assert.Equal(http.StatusOK, status)
+	assert.JSONEq(`{"data": {"repository": {
+		"defaultBranch": "master",
+		"hotspots": [{"file": "a.txt", "loc": 5, "commits": 3, "top": [{"author": "alice@example.com", "churn": 4}]}],
+		"authors": [
+			{"__typename": "Author", "name": "alice", "history": [{"message": "third", "files": [{"file": "a.txt", "churn": 1}]}]},
+			{"__typename": "Author", "name": "bob", "history": [{"message": "second", "files": [{"file": "a.txt", "churn": 3}]}]}
+		]
+	}}}`, body)
+
+	status, body = graphQL(t, s, `{ repository(url: "test") { commit(revision: "HEAD~1") { authorName insertions deletions churn } } }`, nil)
+	assert.Equal(http.StatusOK, status)
+	assert.JSONEq(`{"data": {"repository": {"commit": {"authorName": "bob", "insertions": 2, "deletions": 1, "churn": 3}}}}`, body)
+
+	status, body = graphQL(t, s, `{ repository(url: "test") { url commit(revision: "missing") { hash } } }`, nil)
+	assert.Equal(http.StatusOK, status)
+	var response struct {
+		Data   map[string]map[string]interface{}
+		Errors []gqlError
+	}
+	assert.Nil(json.Unmarshal([]byte(body), &response))
+	assert.Equal("test", response.Data["repository"]["url"])
+	assert.Nil(response.Data["repository"]["commit"])
+	assert.Equal([]interface{}{"repository", "commit"}, response.Errors[0].Path)
+}
+
+func TestGraphQLRequests(t *testing.T) {
+	assert := assert.New(t)
+	s := graphQLServer(t)
+
+	recorder := httptest.NewRecorder()
+	query := url.Values{"query": {`{ version { version } repository(url: "test") @skip(if: true) { url } }`}}
+	s.Handler().ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/graphql?"+query.Encode(), nil))
+	assert.Equal(http.StatusOK, recorder.Code)
+	assert.Contains(recorder.Body.String(), `{"data":{"version":{"version":`)
+
+	for query, message := range map[string]string{
+		`{ repository(url: "test") { url `:                                "unterminated selection set",
+		`{ repository(url: "test") { size } }`:                            `cannot query field "size" on type "Repository"`,
+		`{ repository(url: "test") { commit(rev: "HEAD") { hash } } }`:    `unknown argument "rev"`,
+		`{ repository(url: "test") { commit }}`:                           "its subfields have to be selected",
+		`{ version { version { major } } }`:                               "has no subfields",
+		`query A { version { version } } query B { version { version } }`: "operationName is required",
+		`mutation { version }`:                                            "not supported",
+		`{ repository(url: $url) { url } }`:                               "variable $url is not defined",
+	} {
+		status, body := graphQL(t, s, query, nil)
+		assert.Equal(http.StatusBadRequest, status, query)
+		var response gqlResponse
+		assert.Nil(json.Unmarshal([]byte(body), &response))
+		assert.Contains(response.Errors[0].Message, message, query)
+	}
+}
+
+// TestGraphQLLimits checks that the queries that would recurse without end or exhaust the server are
+// rejected
+func TestGraphQLLimits(t *testing.T) {
+	assert := assert.New(t)
+	s := graphQLServer(t)
+
+	for query, message := range map[string]string{
+		`query { ...F } fragment F on Query { ...F }`:                                                `cannot spread fragment "F" within itself via F → F`,
+		`{ ...A } fragment A on Query { version { version } ...B } fragment B on Query { ...A }`:     `cannot spread fragment "A" within itself via A → B → A`,
+		`{ ...A } fragment A on Query { repository(url: "test") { ... on Repository { ...A } } }`:    `cannot spread fragment "A" within itself via A → A`,
+		strings.Repeat("{ version ", maxSelectionDepth+1) + strings.Repeat("}", maxSelectionDepth+1): "nested deeper than 64",
+	} {
+		status, body := graphQL(t, s, query, nil)
+		assert.Equal(http.StatusBadRequest, status, query)
+		var response gqlResponse
+		assert.Nil(json.Unmarshal([]byte(body), &response))
+		assert.Contains(response.Errors[0].Message, message, query)
+	}
+
+	// A fragment spread many times over is expanded once per object
+	status, body := graphQL(t, s, `{ ...V ...W } fragment V on Query { version { version } ...W ...W } fragment W on Query { ...V2 ...V2 } fragment V2 on Query { version { version } }`, nil)
+	assert.Equal(http.StatusOK, status)
+	assert.Contains(body, `{"data":{"version":{"version":`)
+
+	recorder := httptest.NewRecorder()
+	s.Handler().ServeHTTP(recorder, httptest.NewRequest(http.MethodPost, "/graphql", strings.NewReader(`{"query": "`+strings.Repeat(" ", maxQuerySize)+`"}`)))
+	assert.Equal(http.StatusBadRequest, recorder.Code)
+	assert.Contains(recorder.Body.String(), "request body too large")
+}
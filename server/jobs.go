@@ -0,0 +1,91 @@
+package server
+
+import (
+	"errors"
+	"net/http"
+	"runtime"
+	"sync"
+)
+
+// DefaultMaxQueued is how many analyses wait for a slot at most before the requests are turned down
+const DefaultMaxQueued = 64
+
+// errQueueFull turns down the requests when the queue is full, to be retried later
+var errQueueFull = &statusError{http.StatusServiceUnavailable, errors.New("too many analyses queued, retry later")}
+
+// JobQueue runs the heavy analyses of the server, the clones and the metrics walking the history, so that
+// simultaneous requests do not exhaust the memory: at most MaxRunning analyses run at a time, at most
+// MaxQueued more wait for their turn and the others are turned down. The requests for an analysis already
+// queued or running wait for its result instead of running it again.
+type JobQueue struct {
+	slots    chan struct{}
+	admitted chan struct{}
+
+	mu   sync.Mutex
+	jobs map[string]*job
+}
+
+// job is an analysis queued or running, whose result is shared by the requests coalesced on it
+type job struct {
+	done   chan struct{}
+	result interface{}
+	err    error
+	// Requests coalesced on the job
+	waiters int
+}
+
+// NewJobQueue returns a queue running maxRunning analyses at a time, runtime.NumCPU() if not positive,
+// and turning down the requests beyond maxQueued waiting ones
+func NewJobQueue(maxRunning, maxQueued int) *JobQueue {
+	if maxRunning <= 0 {
+		maxRunning = runtime.NumCPU()
+	}
+	if maxQueued < 0 {
+		maxQueued = 0
+	}
+	return &JobQueue{
+		slots:    make(chan struct{}, maxRunning),
+		admitted: make(chan struct{}, maxRunning+maxQueued),
+		jobs:     make(map[string]*job),
+	}
+}
+
+// Do runs the analysis identified by the key once a slot is free and returns its result, or the result of
+// the same analysis when already queued or running. When lock is not nil, it is held while running the
+// analysis, before taking a slot so that the analyses waiting for their repository leave the slots to
+// the others. Returns an error answered with 503 when the queue is full.
+func (q *JobQueue) Do(key string, lock sync.Locker, analyze func() (interface{}, error)) (interface{}, error) {
+	q.mu.Lock()
+	if j, ok := q.jobs[key]; ok {
+		j.waiters++
+		q.mu.Unlock()
+		<-j.done
+		return j.result, j.err
+	}
+	select {
+	case q.admitted <- struct{}{}:
+	default:
+		q.mu.Unlock()
+		return nil, errQueueFull
+	}
+	// Reported to the coalesced requests if the analysis panics
+	j := &job{done: make(chan struct{}), err: errors.New("the analysis was aborted")}
+	q.jobs[key] = j
+	q.mu.Unlock()
+	defer func() {
+		q.mu.Lock()
+		delete(q.jobs, key)
+		q.mu.Unlock()
+		<-q.admitted
+		close(j.done)
+	}()
+
+	if lock != nil {
+		lock.Lock()
+		defer lock.Unlock()
+	}
+	q.slots <- struct{}{}
+	defer func() { <-q.slots }()
+	j.result, j.err = analyze()
+	return j.result, j.err
+}
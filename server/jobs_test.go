@@ -0,0 +1,49 @@
+package server
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestJobQueue(t *testing.T) {
+	assert := assert.New(t)
+	q := NewJobQueue(1, 1)
+	started, release := make(chan bool), make(chan bool)
+	runs := 0
+	analyze := func() (interface{}, error) {
+		runs++
+		started <- true
+		<-release
+		return runs, nil
+	}
+
+	// The first analysis runs, the same one is coalesced on it and another one waits for the slot
+	var wg sync.WaitGroup
+	results := make([]interface{}, 3)
+	for i, key := range []string{"a", "a", "b"} {
+		wg.Add(1)
+		go func(i int, key string) {
+			defer wg.Done()
+			results[i], _ = q.Do(key, nil, analyze)
+		}(i, key)
+		if i == 0 {
+			<-started
+		}
+	}
+	for queued := false; !queued; {
+		q.mu.Lock()
+		queued = len(q.jobs) == 2 && q.jobs["a"].waiters == 1
+		q.mu.Unlock()
+	}
+	_, err := q.Do("c", nil, analyze)
+	assert.Equal(errQueueFull, err)
+
+	release <- true
+	<-started
+	release <- true
+	wg.Wait()
+	assert.Equal([]interface{}{1, 1, 2}, results)
+	assert.Equal(2, runs)
+}
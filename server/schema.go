@@ -0,0 +1,356 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	metrics "github.com/andymeneely/git-churn/matrics"
+	"github.com/andymeneely/git-churn/version"
+)
+
+// The schema of /graphql, in the schema language:
+//
+//	type Query {
+//		repository(url: String!): Repository
+//		version: Version
+//	}
+//	type Repository {
+//		url: String!
+//		defaultBranch: String!
+//		commit(revision: String = "HEAD"): Commit
+//		commits(from: String, to: String = "HEAD", author: String, first: Int): [Commit!]!
+//		hotspots(from: String, to: String = "HEAD", first: Int = 10): [Hotspot!]!
+//		authors(from: String, to: String = "HEAD", sortBy: String = "commits", first: Int): [Author!]!
+//		# FileChurnMetrics of the file, or AggrChurMetrics of the commit when no file is given, as /churn
+//		churn(revision: String = "HEAD", file: String, whitespace: Boolean = true): ChurnMetrics
+//	}
+//	type Commit {
+//		hash, author, authorName, when, message, parents, insertions, deletions, churn
+//		files: [FileChurn!]!
+//	}
+//	type FileChurn { file, insertions, deletions, churn }
+//	type Hotspot {
+//		file, commits, churn, loc, score
+//		# The authors and commits of the range that changed the file, counting only its lines
+//		authors(sortBy: String = "churn", first: Int = 3): [Author!]!
+//		history(first: Int): [Commit!]!
+//	}
+//	type Author {
+//		author, name, commits, insertions, deletions, churn, filesTouched, firstCommit, lastCommit, team
+//		history(first: Int): [Commit!]!
+//	}
+//
+// The ranges from..to are the ones of git log, from empty covering the whole history. Their churn is
+// cached per pair of commits, so the nested fields of a query compute it once.
+
+// Largest body of a POST /graphql
+const maxQuerySize = 1 << 20
+
+// graphQLRequest is the body of a POST /graphql, or the parameters of a GET
+type graphQLRequest struct {
+	Query         string                 `json:"query"`
+	OperationName string                 `json:"operationName"`
+	Variables     map[string]interface{} `json:"variables"`
+}
+
+func (s *Server) handleGraphQL(w http.ResponseWriter, r *http.Request) {
+	var request graphQLRequest
+	switch r.Method {
+	case http.MethodGet:
+		query := r.URL.Query()
+		request.Query = query.Get("query")
+		request.OperationName = query.Get("operationName")
+		if variables := query.Get("variables"); variables != "" {
+			if err := json.Unmarshal([]byte(variables), &request.Variables); err != nil {
+				writeGraphQLError(w, http.StatusBadRequest, "invalid variables: "+err.Error())
+				return
+			}
+		}
+	case http.MethodPost:
+		if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxQuerySize)).Decode(&request); err != nil {
+			writeGraphQLError(w, http.StatusBadRequest, "invalid request body: "+err.Error())
+			return
+		}
+	default:
+		writeGraphQLError(w, http.StatusMethodNotAllowed, "only GET and POST are supported")
+		return
+	}
+	if request.Query == "" {
+		writeGraphQLError(w, http.StatusBadRequest, "the query is required")
+		return
+	}
+
+	response, err := execute(s.queryType(), request.Query, request.OperationName, request.Variables)
+	if err != nil {
+		writeGraphQLError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, response)
+}
+
+func writeGraphQLError(w http.ResponseWriter, status int, message string) {
+	writeJSON(w, status, &gqlResponse{Errors: []gqlError{{Message: message}}})
+}
+
+// repositoryNode is a repository queried, its metrics being computed on the clone of the server
+type repositoryNode struct {
+	URL           string
+	DefaultBranch string
+	server        *Server
+	cached        *cachedRepo
+}
+
+// hotspotNode and authorNode are a hotspot and an author along with the commits of the range they were
+// computed on
+type hotspotNode struct {
+	metrics.Hotspot
+	commits []*metrics.CommitChurn
+}
+
+type authorNode struct {
+	metrics.Contributor
+	commits []*metrics.CommitChurn
+}
+
+// queryType returns the root type of the schema
+func (s *Server) queryType() *object {
+	fileChurnType := &object{name: "FileChurn", fields: map[string]*field{
+		"churn": {resolve: func(source interface{}, args arguments) (interface{}, error) {
+			return source.(metrics.FileChurn).Churn(), nil
+		}},
+	}}
+	commitType := &object{name: "Commit", fields: map[string]*field{
+		"churn": {resolve: func(source interface{}, args arguments) (interface{}, error) {
+			commit := source.(metrics.CommitChurn)
+			return commit.Churn(), nil
+		}},
+		"files": {typ: fileChurnType, resolve: func(source interface{}, args arguments) (interface{}, error) {
+			return source.(metrics.CommitChurn).Files, nil
+		}},
+	}}
+	historyArgs := map[string]interface{}{"first": nil}
+	authorType := &object{name: "Author", fields: map[string]*field{
+		"history": {args: historyArgs, typ: commitType, resolve: func(source interface{}, args arguments) (interface{}, error) {
+			return firstCommits(source.(authorNode).commits, args)
+		}},
+	}}
+	hotspotType := &object{name: "Hotspot", fields: map[string]*field{
+		"authors": {args: map[string]interface{}{"sortBy": "churn", "first": 3}, typ: authorType, resolve: func(source interface{}, args arguments) (interface{}, error) {
+			return authors(source.(hotspotNode).commits, args)
+		}},
+		"history": {args: historyArgs, typ: commitType, resolve: func(source interface{}, args arguments) (interface{}, error) {
+			return firstCommits(source.(hotspotNode).commits, args)
+		}},
+	}}
+	rangeArgs := func(args map[string]interface{}) map[string]interface{} {
+		args["from"] = nil
+		args["to"] = "HEAD"
+		return args
+	}
+	repositoryType := &object{name: "Repository", fields: map[string]*field{
+		"commit": {args: map[string]interface{}{"revision": "HEAD"}, typ: commitType, resolve: func(source interface{}, args arguments) (interface{}, error) {
+			revision, err := args.string("revision")
+			if err != nil {
+				return nil, err
+			}
+			return source.(repositoryNode).commit(revision)
+		}},
+		"commits": {args: rangeArgs(map[string]interface{}{"author": nil, "first": nil}), typ: commitType, resolve: func(source interface{}, args arguments) (interface{}, error) {
+			commits, _, _, err := source.(repositoryNode).rangeChurn(args)
+			if err != nil {
+				return nil, err
+			}
+			author, err := args.string("author")
+			if err != nil {
+				return nil, err
+			}
+			if author != "" {
+				commits = authorCommits(commits, author)
+			}
+			return firstCommits(commits, args)
+		}},
+		"hotspots": {args: rangeArgs(map[string]interface{}{"first": 10}), typ: hotspotType, resolve: func(source interface{}, args arguments) (interface{}, error) {
+			return source.(repositoryNode).hotspots(args)
+		}},
+		"authors": {args: rangeArgs(map[string]interface{}{"sortBy": "commits", "first": nil}), typ: authorType, resolve: func(source interface{}, args arguments) (interface{}, error) {
+			commits, _, _, err := source.(repositoryNode).rangeChurn(args)
+			if err != nil {
+				return nil, err
+			}
+			return authors(commits, args)
+		}},
+		"churn": {args: map[string]interface{}{"revision": "HEAD", "file": nil, "whitespace": true}, resolve: func(source interface{}, args arguments) (interface{}, error) {
+			revision, err := args.string("revision")
+			if err != nil {
+				return nil, err
+			}
+			file, err := args.string("file")
+			if err != nil {
+				return nil, err
+			}
+			whitespace, err := args.bool("whitespace")
+			if err != nil {
+				return nil, err
+			}
+			repository := source.(repositoryNode)
+			return repository.server.churn(repository.URL, revision, file, whitespace)
+		}},
+	}}
+	return &object{name: "Query", fields: map[string]*field{
+		"repository": {args: map[string]interface{}{"url": nil}, typ: repositoryType, resolve: func(source interface{}, args arguments) (interface{}, error) {
+			url, err := args.string("url")
+			if err != nil {
+				return nil, err
+			}
+			if url == "" {
+				return nil, errors.New("the url argument is required")
+			}
+			cached, err := s.repository(url)
+			if err != nil {
+				return nil, err
+			}
+			return repositoryNode{URL: url, DefaultBranch: cached.head, server: s, cached: cached}, nil
+		}},
+		"version": {resolve: func(source interface{}, args arguments) (interface{}, error) {
+			return version.Get(), nil
+		}},
+	}}
+}
+
+// commit computes the churn of the commit the revision resolves to, cached per hash
+func (n repositoryNode) commit(revision string) (*metrics.CommitChurn, error) {
+	hash, err := n.server.resolve(n.cached, revision)
+	if err != nil {
+		return nil, err
+	}
+	churn, err := n.server.analyze(n.cached, metrics.ResultKey("commit", hash), func() (interface{}, error) {
+		commit, err := n.cached.repo.CommitObject(*hash)
+		if err != nil {
+			return nil, err
+		}
+		return metrics.GetCommitChurn(n.cached.repo, commit)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return churn.(*metrics.CommitChurn), nil
+}
+
+// rangeChurn computes the churn of the commits of the from and to arguments, cached per pair of hashes,
+// and returns it along with the hashes the range resolved to, from being empty for the whole history
+func (n repositoryNode) rangeChurn(args arguments) ([]*metrics.CommitChurn, string, string, error) {
+	from, err := args.string("from")
+	if err != nil {
+		return nil, "", "", err
+	}
+	to, err := args.string("to")
+	if err != nil {
+		return nil, "", "", err
+	}
+	toHash, err := n.server.resolve(n.cached, to)
+	if err != nil {
+		return nil, "", "", err
+	}
+	to = toHash.String()
+	if from != "" {
+		fromHash, err := n.server.resolve(n.cached, from)
+		if err != nil {
+			return nil, "", "", err
+		}
+		from = fromHash.String()
+	}
+	commits, err := n.server.analyze(n.cached, metrics.ResultKey("range", from, to), func() (interface{}, error) {
+		return metrics.RangeChurn(n.cached.repo, from, to)
+	})
+	if err != nil {
+		return nil, "", "", err
+	}
+	return commits.([]*metrics.CommitChurn), from, to, nil
+}
+
+// hotspots ranks the files of the range by hotspot score, along with the commits changing them
+func (n repositoryNode) hotspots(args arguments) ([]hotspotNode, error) {
+	first, err := args.int("first")
+	if err != nil {
+		return nil, err
+	}
+	commits, from, to, err := n.rangeChurn(args)
+	if err != nil {
+		return nil, err
+	}
+	hotspots, err := n.server.analyze(n.cached, metrics.ResultKey("hotspots", from, to, first), func() (interface{}, error) {
+		return metrics.Hotspots(n.cached.repo, commits, to, first)
+	})
+	if err != nil {
+		return nil, err
+	}
+	nodes := make([]hotspotNode, len(hotspots.([]metrics.Hotspot)))
+	for i, hotspot := range hotspots.([]metrics.Hotspot) {
+		nodes[i] = hotspotNode{hotspot, fileCommits(commits, hotspot.File)}
+	}
+	return nodes, nil
+}
+
+// authors ranks the authors of the commits by the sortBy argument, returning the first ones
+func authors(commits []*metrics.CommitChurn, args arguments) ([]authorNode, error) {
+	sortBy, err := args.string("sortBy")
+	if err != nil {
+		return nil, err
+	}
+	first, err := args.int("first")
+	if err != nil {
+		return nil, err
+	}
+	contributors, err := metrics.Contributors(commits, sortBy)
+	if err != nil {
+		return nil, err
+	}
+	if first > 0 && len(contributors) > first {
+		contributors = contributors[:first]
+	}
+	nodes := make([]authorNode, len(contributors))
+	for i, contributor := range contributors {
+		nodes[i] = authorNode{contributor, authorCommits(commits, contributor.Author)}
+	}
+	return nodes, nil
+}
+
+// firstCommits returns the number of commits of the first argument, all of them when not given
+func firstCommits(commits []*metrics.CommitChurn, args arguments) ([]*metrics.CommitChurn, error) {
+	first, err := args.int("first")
+	if err != nil {
+		return nil, err
+	}
+	if first > 0 && len(commits) > first {
+		commits = commits[:first]
+	}
+	return commits, nil
+}
+
+// authorCommits returns the commits of the author, given by email or name
+func authorCommits(commits []*metrics.CommitChurn, author string) []*metrics.CommitChurn {
+	var authored []*metrics.CommitChurn
+	for _, commit := range commits {
+		if commit.Author == author || commit.AuthorName == author {
+			authored = append(authored, commit)
+		}
+	}
+	return authored
+}
+
+// fileCommits returns the commits changing the file, restricted to the churn of the file
+func fileCommits(commits []*metrics.CommitChurn, file string) []*metrics.CommitChurn {
+	var changing []*metrics.CommitChurn
+	for _, commit := range commits {
+		for _, f := range commit.Files {
+			if f.File == file {
+				restricted := *commit
+				restricted.Insertions, restricted.Deletions, restricted.RecentDeletions = f.Insertions, f.Deletions, f.RecentDeletions
+				restricted.Files = []metrics.FileChurn{f}
+				changing = append(changing, &restricted)
+			}
+		}
+	}
+	return changing
+}
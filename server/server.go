@@ -0,0 +1,388 @@
+// Package server exposes the churn metrics over HTTP, so dashboards and other services can query
+// them without shelling out to the CLI
+package server
+
+import (
+	"container/list"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/andymeneely/git-churn/gitfuncs"
+	metrics "github.com/andymeneely/git-churn/matrics"
+	"github.com/andymeneely/git-churn/schema"
+	"github.com/andymeneely/git-churn/version"
+	"gopkg.in/src-d/go-git.v4"
+	"gopkg.in/src-d/go-git.v4/plumbing"
+	"gopkg.in/src-d/go-git.v4/plumbing/transport"
+)
+
+// DefaultMaxRepos is the number of clones a server keeps by default
+const DefaultMaxRepos = 10
+
+// Server answers the metrics requests, keeping a clone of the repositories it was last asked about
+type Server struct {
+	// Clones a repository, gitfuncs.CloneRepository by default
+	Clone func(repoUrl string) (*git.Repository, error)
+	// How long a clone is used before fetching the new commits of the remote again
+	Refresh time.Duration
+	// Results computed, dropped for a repository when a fetch brings new commits
+	Cache *metrics.ResultCache
+	// Queue the clones and the analyses are run by
+	Jobs *JobQueue
+	// Number of clones kept, the one of the repository least recently asked about being released past it,
+	// no limit if zero
+	MaxRepos int
+	// Prefixes of the URLs of the repositories the server clones, e.g. https://github.com/org/. When empty,
+	// any http, https or ssh URL but the ones of the hosts of the local network.
+	AllowedRepos []string
+
+	mu    sync.Mutex
+	repos map[string]*list.Element
+	lru   *list.List
+}
+
+// cachedRepo is a clone shared by the requests on the same repository. The metrics are computed on
+// the commit HEAD is set to, so its lock is held from setting HEAD to the end of the computation,
+// running one analysis of the repository at a time.
+type cachedRepo struct {
+	sync.Mutex
+	url  string
+	repo *git.Repository
+	// Default branch of the remote, what HEAD stands for
+	head    string
+	fetched time.Time
+	// Evicted from the clones kept, the requests still holding it are turned down
+	released bool
+}
+
+// errReleased turns down the requests on a clone released while they were waiting for it
+var errReleased = &statusError{http.StatusServiceUnavailable, errors.New("the clone of the repository was released, retry later")}
+
+// New returns a server cloning the repositories in memory and refreshing them after the given duration,
+// keeping DefaultMaxRepos clones and the results computed for metrics.DefaultResultTTL and running an
+// analysis per CPU at a time
+func New(refresh time.Duration) *Server {
+	return &Server{
+		Clone:    gitfuncs.CloneRepository,
+		Refresh:  refresh,
+		Cache:    metrics.NewResultCache(metrics.DefaultResultTTL),
+		Jobs:     NewJobQueue(0, DefaultMaxQueued),
+		MaxRepos: DefaultMaxRepos,
+		repos:    make(map[string]*list.Element),
+		lru:      list.New(),
+	}
+}
+
+// Close releases the clones of the repositories, removing their temporary directories, once the server
+// is shut down
+func (s *Server) Close() {
+	s.mu.Lock()
+	lru := s.lru
+	s.repos = make(map[string]*list.Element)
+	s.lru = list.New()
+	s.mu.Unlock()
+	for element := lru.Front(); element != nil; element = element.Next() {
+		release(element.Value.(*cachedRepo))
+	}
+}
+
+// release releases the clone once the analysis running on it, if any, is over
+func release(cached *cachedRepo) {
+	cached.Lock()
+	defer cached.Unlock()
+	cached.released = true
+	gitfuncs.ReleaseRepository(cached.repo)
+}
+
+// Handler returns the routes of the API:
+//
+//	GET /churn?repo=<url>[&commit=<hash>|&branch=<ref>][&file=<path>][&whitespace=false]
+//	GET /graphql?query=<query>[&variables=<json>][&operationName=<name>]
+//	POST /graphql {"query": ..., "variables": ..., "operationName": ...}
+//	GET /version
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/churn", s.handleChurn)
+	mux.HandleFunc("/graphql", s.handleGraphQL)
+	mux.HandleFunc("/version", handleVersion)
+	return mux
+}
+
+// statusError is an error caused by the request or the remote, answered with the given status instead of 500
+type statusError struct {
+	status int
+	err    error
+}
+
+func (e *statusError) Error() string { return e.err.Error() }
+
+func badRequest(format string, args ...interface{}) error {
+	return &statusError{http.StatusBadRequest, fmt.Errorf(format, args...)}
+}
+
+func (s *Server) handleChurn(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, &statusError{http.StatusMethodNotAllowed, errors.New("only GET is supported")})
+		return
+	}
+	query := r.URL.Query()
+	repoUrl := query.Get("repo")
+	if repoUrl == "" {
+		writeError(w, badRequest("the repo parameter is required"))
+		return
+	}
+	revision := query.Get("commit")
+	if revision == "" {
+		revision = query.Get("branch")
+	}
+	if revision == "" {
+		revision = "HEAD"
+	}
+	whitespace := true
+	if value := query.Get("whitespace"); value != "" {
+		var err error
+		if whitespace, err = strconv.ParseBool(value); err != nil {
+			writeError(w, badRequest("invalid whitespace parameter %q", value))
+			return
+		}
+	}
+
+	result, err := s.churn(repoUrl, revision, query.Get("file"), whitespace)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeResult(w, result)
+}
+
+func handleVersion(w http.ResponseWriter, r *http.Request) {
+	writeResult(w, version.Get())
+}
+
+// churn computes the churn metrics of the file, or of all the files when empty, changed by the revision
+func (s *Server) churn(repoUrl, revision, file string, whitespace bool) (interface{}, error) {
+	cached, err := s.repository(repoUrl)
+	if err != nil {
+		return nil, err
+	}
+	hash, err := s.resolve(cached, revision)
+	if err != nil {
+		return nil, err
+	}
+	return s.analyze(cached, metrics.ResultKey("churn", hash, file, whitespace), func() (interface{}, error) {
+		commit, err := cached.repo.CommitObject(*hash)
+		if err != nil {
+			return nil, err
+		}
+		if commit.NumParents() == 0 {
+			return nil, badRequest("%s is the root commit, churn is computed against the parent commit", hash)
+		}
+		if err := gitfuncs.SetHead(cached.repo, *hash); err != nil {
+			return nil, err
+		}
+		return computeChurn(cached.repo, file, whitespace)
+	})
+}
+
+// analyze returns the result of the repository cached under the key, or runs the analysis computing it
+// as a job of the queue, with the lock of the clone held
+func (s *Server) analyze(cached *cachedRepo, key string, analyze func() (interface{}, error)) (interface{}, error) {
+	return s.Cache.Load(cached.url, key, func() (interface{}, error) {
+		return s.Jobs.Do(cached.url+"\x00"+key, cached, func() (interface{}, error) {
+			if cached.released {
+				return nil, errReleased
+			}
+			return analyze()
+		})
+	})
+}
+
+// cached returns the clone of the repository kept, if any, as the one most recently asked about
+func (s *Server) cached(repoUrl string) (*cachedRepo, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	element, ok := s.repos[repoUrl]
+	if !ok {
+		return nil, false
+	}
+	s.lru.MoveToFront(element)
+	return element.Value.(*cachedRepo), true
+}
+
+// repository returns the clone of the repository, cloning it as a job on the first request once its URL is
+// allowed. The requests coming during the clone wait for it rather than cloning again. Past MaxRepos, the
+// clone of the repository least recently asked about is released.
+func (s *Server) repository(repoUrl string) (*cachedRepo, error) {
+	if cached, ok := s.cached(repoUrl); ok {
+		return cached, nil
+	}
+	if err := s.allowRepo(repoUrl); err != nil {
+		return nil, err
+	}
+	result, err := s.Jobs.Do("clone\x00"+repoUrl, nil, func() (interface{}, error) {
+		if cached, ok := s.cached(repoUrl); ok {
+			return cached, nil
+		}
+		repo, err := s.Clone(repoUrl)
+		if err != nil {
+			// The remote failing, rather than the request
+			return nil, &statusError{http.StatusBadGateway, fmt.Errorf("unable to clone %s: %s", repoUrl, err)}
+		}
+		head, err := repo.Head()
+		if err != nil {
+			return nil, err
+		}
+		cached := &cachedRepo{url: repoUrl, repo: repo, head: head.Name().Short(), fetched: time.Now()}
+		s.mu.Lock()
+		s.repos[repoUrl] = s.lru.PushFront(cached)
+		for s.MaxRepos > 0 && s.lru.Len() > s.MaxRepos {
+			oldest := s.lru.Remove(s.lru.Back()).(*cachedRepo)
+			delete(s.repos, oldest.url)
+			// Not waiting for the analysis running on it, which may wait for the slot this job holds
+			go release(oldest)
+		}
+		s.mu.Unlock()
+		return cached, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result.(*cachedRepo), nil
+}
+
+// resolve resolves the revision to a commit of the remote, fetching its new commits first when the clone
+// is older than the refresh duration, which drops the results cached for the repository
+func (s *Server) resolve(cached *cachedRepo, revision string) (*plumbing.Hash, error) {
+	cached.Lock()
+	defer cached.Unlock()
+	if cached.released {
+		return nil, errReleased
+	}
+	if time.Since(cached.fetched) > s.Refresh {
+		updated, err := gitfuncs.FetchNewRefs(cached.repo)
+		if err != nil {
+			return nil, err
+		}
+		if updated {
+			s.Cache.Invalidate(cached.url)
+			gitfuncs.ForgetMailmap(cached.repo)
+		}
+		cached.fetched = time.Now()
+	}
+	if revision == "HEAD" {
+		revision = cached.head
+	}
+	hash, err := gitfuncs.ResolveFetchedRef(cached.repo, revision)
+	if err != nil {
+		return nil, badRequest("%s", err)
+	}
+	return hash, nil
+}
+
+// allowRepo tells whether the server may clone the repository: its URL has to start with one of
+// AllowedRepos, or else be an http, https or ssh URL of a host out of the local network, so that the
+// requests neither read the files of the server nor reach the services only it can
+func (s *Server) allowRepo(repoUrl string) error {
+	if len(s.AllowedRepos) > 0 {
+		for _, prefix := range s.AllowedRepos {
+			if strings.HasPrefix(repoUrl, prefix) {
+				return nil
+			}
+		}
+		return &statusError{http.StatusForbidden, fmt.Errorf("repository %s is not allowed", repoUrl)}
+	}
+	endpoint, err := transport.NewEndpoint(repoUrl)
+	if err != nil {
+		return badRequest("invalid repository URL %s: %s", repoUrl, err)
+	}
+	switch endpoint.Protocol {
+	case "http", "https", "ssh":
+	default:
+		return &statusError{http.StatusForbidden, fmt.Errorf("repository %s is not allowed, only http, https and ssh URLs are", repoUrl)}
+	}
+	ips, err := net.LookupIP(endpoint.Host)
+	if err != nil {
+		return &statusError{http.StatusBadGateway, fmt.Errorf("unable to resolve %s: %s", endpoint.Host, err)}
+	}
+	for _, ip := range ips {
+		if localIP(ip) {
+			return &statusError{http.StatusForbidden, fmt.Errorf("repository %s is not allowed, %s is in the local network", repoUrl, endpoint.Host)}
+		}
+	}
+	return nil
+}
+
+// localIP tells the addresses of the server itself and of its local network
+func localIP(ip net.IP) bool {
+	if ip.IsLoopback() || ip.IsUnspecified() || ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() || ip.IsInterfaceLocalMulticast() {
+		return true
+	}
+	// The private ranges of RFC 1918 and RFC 4193
+	for _, cidr := range []string{"10.0.0.0/8", "172.16.0.0/12", "192.168.0.0/16", "fc00::/7"} {
+		_, private, _ := net.ParseCIDR(cidr)
+		if private.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// computeChurn computes the same metrics as the root command on the commit checked out in the repository
+func computeChurn(repo *git.Repository, file string, whitespace bool) (interface{}, error) {
+	if file == "" {
+		if whitespace {
+			return metrics.AggrChurnMetricsWithWhitespace(repo), nil
+		}
+		return metrics.AggrChurnMetricsWhitespaceExcluded(repo), nil
+	}
+	var result *metrics.FileChurnMetrics
+	var err error
+	if whitespace {
+		result, err = metrics.GetChurnMetricsWithWhitespace(repo, file)
+	} else {
+		result, err = metrics.GetChurnMetricsWhitespaceExcluded(repo, file)
+	}
+	if err != nil {
+		return nil, &statusError{http.StatusUnprocessableEntity, err}
+	}
+	return result, nil
+}
+
+func writeJSON(w http.ResponseWriter, status int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(body)
+}
+
+// writeResult writes a result, stamped with the version of the schema of the results like on the command line
+func writeResult(w http.ResponseWriter, result interface{}) {
+	body, err := json.Marshal(result)
+	if err == nil {
+		body, err = schema.Stamp(body)
+	}
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(append(body, '\n'))
+}
+
+func writeError(w http.ResponseWriter, err error) {
+	status := http.StatusInternalServerError
+	if statusErr, ok := err.(*statusError); ok {
+		status = statusErr.status
+	}
+	if status == http.StatusServiceUnavailable {
+		w.Header().Set("Retry-After", "5")
+	}
+	writeJSON(w, status, map[string]string{"error": err.Error()})
+}
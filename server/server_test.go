@@ -0,0 +1,186 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	metrics "github.com/andymeneely/git-churn/matrics"
+	"github.com/andymeneely/git-churn/testutil"
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/src-d/go-git.v4"
+)
+
+// testRepo commits the successive contents of a.txt, alice authoring the first one and bob the others
+func testRepo(t *testing.T, contents ...string) *git.Repository {
+	repo := testutil.NewRepo(t)
+	for i, content := range contents {
+		if i > 0 {
+			repo.As("bob")
+		}
+		repo.Write("a.txt", content).Commit("commit")
+	}
+	return repo.Repository
+}
+
+func get(t *testing.T, s *Server, url string, body interface{}) int {
+	recorder := httptest.NewRecorder()
+	s.Handler().ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, url, nil))
+	assert.Nil(t, json.NewDecoder(recorder.Body).Decode(body))
+	return recorder.Code
+}
+
+func TestChurn(t *testing.T) {
+	assert := assert.New(t)
+	clones := 0
+	s := New(time.Hour)
+	s.AllowedRepos = []string{"test"}
+	s.Clone = func(repoUrl string) (*git.Repository, error) {
+		clones++
+		return testRepo(t, "1\n2\n3\n", "1\nb\n3\n"), nil
+	}
+
+	var churn metrics.FileChurnMetrics
+	assert.Equal(http.StatusOK, get(t, s, "/churn?repo=test&file=a.txt", &churn))
+	assert.Equal(1, churn.DeletedLinesCount)
+	assert.Equal(1, churn.InteractiveChurnCount)
+	assert.Equal("bob@example.com", churn.CommitAuthor)
+
+	var aggregated metrics.AggrChurMetrics
+	assert.Equal(http.StatusOK, get(t, s, "/churn?repo=test&commit=HEAD&whitespace=false", &aggregated))
+	assert.Equal(1, aggregated.AggrDiffMetrics.Insertions)
+	assert.Equal(1, clones)
+
+	var failure map[string]string
+	assert.Equal(http.StatusBadRequest, get(t, s, "/churn?repo=test&commit=HEAD~1", &failure))
+	assert.Contains(failure["error"], "root commit")
+	assert.Equal(http.StatusBadRequest, get(t, s, "/churn?repo=test&branch=missing", &failure))
+	assert.Equal(http.StatusBadRequest, get(t, s, "/churn", &failure))
+	assert.Equal(http.StatusBadRequest, get(t, s, "/churn?repo=test&whitespace=maybe", &failure))
+}
+
+func TestChurnCloneFailure(t *testing.T) {
+	assert := assert.New(t)
+	s := New(time.Hour)
+	s.AllowedRepos = []string{"test"}
+	s.Clone = func(repoUrl string) (*git.Repository, error) {
+		return nil, errors.New("repository not found")
+	}
+
+	var failure map[string]string
+	assert.Equal(http.StatusBadGateway, get(t, s, "/churn?repo=test", &failure))
+	assert.Equal("unable to clone test: repository not found", failure["error"])
+}
+
+func TestClose(t *testing.T) {
+	assert := assert.New(t)
+	s := New(time.Hour)
+	s.AllowedRepos = []string{"test"}
+	s.Clone = func(repoUrl string) (*git.Repository, error) {
+		return testRepo(t, "1\n", "2\n"), nil
+	}
+	var churn metrics.FileChurnMetrics
+	assert.Equal(http.StatusOK, get(t, s, "/churn?repo=test&file=a.txt", &churn))
+	assert.Len(s.repos, 1)
+	s.Close()
+	assert.Empty(s.repos)
+}
+
+func TestVersion(t *testing.T) {
+	var info map[string]string
+	assert.Equal(t, http.StatusOK, get(t, New(time.Hour), "/version", &info))
+	assert.NotEmpty(t, info["Version"])
+}
+
+func TestChurnCache(t *testing.T) {
+	assert := assert.New(t)
+	s := New(time.Hour)
+	s.AllowedRepos = []string{"test"}
+	s.Clone = func(repoUrl string) (*git.Repository, error) {
+		return testRepo(t, "1\n2\n3\n", "1\nb\n3\n"), nil
+	}
+
+	var churn metrics.FileChurnMetrics
+	for i := 0; i < 2; i++ {
+		assert.Equal(http.StatusOK, get(t, s, "/churn?repo=test&file=a.txt", &churn))
+	}
+	hits, misses := s.Cache.Stats()
+	assert.Equal(1, hits)
+	assert.Equal(1, misses)
+
+	assert.Equal(http.StatusOK, get(t, s, "/churn?repo=test&file=a.txt&whitespace=false", &churn))
+	s.Cache.Invalidate("test")
+	assert.Equal(http.StatusOK, get(t, s, "/churn?repo=test&file=a.txt", &churn))
+	hits, misses = s.Cache.Stats()
+	assert.Equal(1, hits)
+	assert.Equal(3, misses)
+}
+
+func TestMaxRepos(t *testing.T) {
+	assert := assert.New(t)
+	s := New(time.Hour)
+	s.AllowedRepos = []string{"test"}
+	s.MaxRepos = 2
+	clones := make(map[string]int)
+	s.Clone = func(repoUrl string) (*git.Repository, error) {
+		clones[repoUrl]++
+		return testRepo(t, "1\n", "2\n"), nil
+	}
+
+	var churn metrics.FileChurnMetrics
+	for _, repo := range []string{"test1", "test2", "test1", "test3", "test1", "test2"} {
+		assert.Equal(http.StatusOK, get(t, s, "/churn?repo="+repo+"&file=a.txt&whitespace=false", &churn))
+		s.Cache.Invalidate(repo)
+	}
+	// test2 was the least recently asked about when test3 was cloned
+	assert.Equal(map[string]int{"test1": 1, "test2": 2, "test3": 1}, clones)
+	assert.Len(s.repos, 2)
+	assert.Equal(2, s.lru.Len())
+
+	// The requests holding a released clone are turned down
+	released, _ := s.cached("test1")
+	release(released)
+	_, err := s.resolve(released, "HEAD")
+	assert.Equal(errReleased, err)
+	_, err = s.analyze(released, "key", func() (interface{}, error) { return nil, nil })
+	assert.Equal(errReleased, err)
+}
+
+func TestAllowRepo(t *testing.T) {
+	assert := assert.New(t)
+	s := New(time.Hour)
+	for _, repoUrl := range []string{
+		"file:///etc",
+		"/etc",
+		"git://8.8.8.8/repo.git",
+		"https://localhost/repo.git",
+		"http://127.0.0.1:8080/repo.git",
+		"https://169.254.169.254/latest/meta-data",
+		"https://10.0.0.1/repo.git",
+		"https://192.168.1.1/repo.git",
+		"ssh://git@172.16.0.1/repo.git",
+		"ssh://git@[::1]/repo.git",
+		"https://[fd00::1]/repo.git",
+	} {
+		err := s.allowRepo(repoUrl)
+		if assert.NotNil(err, repoUrl) {
+			assert.Equal(http.StatusForbidden, err.(*statusError).status, repoUrl)
+		}
+	}
+	for _, repoUrl := range []string{"https://8.8.8.8/repo.git", "ssh://git@8.8.8.8/repo.git", "git@8.8.8.8:repo.git"} {
+		assert.Nil(s.allowRepo(repoUrl), repoUrl)
+	}
+
+	var failure map[string]string
+	assert.Equal(http.StatusForbidden, get(t, s, "/churn?repo=file:///etc", &failure))
+	assert.Contains(failure["error"], "only http, https and ssh URLs")
+
+	// Only the repositories under the allowed prefixes are cloned
+	s.AllowedRepos = []string{"https://github.com/andymeneely/"}
+	assert.Nil(s.allowRepo("https://github.com/andymeneely/git-churn"))
+	assert.NotNil(s.allowRepo("https://github.com/other/git-churn"))
+	assert.NotNil(s.allowRepo("https://8.8.8.8/repo.git"))
+}
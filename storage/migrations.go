@@ -0,0 +1,80 @@
+package storage
+
+import "fmt"
+
+// migrations are the successive versions of the schema. They are applied in order and never modified
+// once released, a change of the schema is a new migration appended to the list.
+var migrations = []string{
+	`CREATE TABLE commits (
+		repo        TEXT NOT NULL,
+		hash        TEXT NOT NULL,
+		author      TEXT NOT NULL,
+		author_name TEXT NOT NULL,
+		authored_at TIMESTAMP NOT NULL,
+		message     TEXT NOT NULL,
+		parents     INTEGER NOT NULL,
+		insertions  INTEGER NOT NULL,
+		deletions   INTEGER NOT NULL,
+		PRIMARY KEY (repo, hash)
+	)`,
+	`CREATE TABLE file_churn (
+		repo       TEXT NOT NULL,
+		hash       TEXT NOT NULL,
+		file       TEXT NOT NULL,
+		insertions INTEGER NOT NULL,
+		deletions  INTEGER NOT NULL,
+		PRIMARY KEY (repo, hash, file)
+	)`,
+	`CREATE INDEX commits_authored_at ON commits (repo, authored_at)`,
+	`CREATE INDEX file_churn_file ON file_churn (repo, file)`,
+	`CREATE TABLE branch_state (
+		repo        TEXT NOT NULL,
+		branch      TEXT NOT NULL,
+		last_commit TEXT NOT NULL,
+		analyzed_at TIMESTAMP NOT NULL,
+		PRIMARY KEY (repo, branch)
+	)`,
+}
+
+// Migrate applies the migrations not applied yet to the database, recording its version in schema_version
+func (s *Store) Migrate() error {
+	if _, err := s.db.Exec(`CREATE TABLE IF NOT EXISTS schema_version (version INTEGER NOT NULL)`); err != nil {
+		return err
+	}
+	version, err := s.SchemaVersion()
+	if err != nil {
+		return err
+	}
+	if version > len(migrations) {
+		return fmt.Errorf("the database schema version %d is newer than this version of git-churn supports (%d)", version, len(migrations))
+	}
+	for i := version; i < len(migrations); i++ {
+		tx, err := s.db.Begin()
+		if err != nil {
+			return err
+		}
+		if _, err := tx.Exec(migrations[i]); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("migrating the database schema to version %d: %s", i+1, err)
+		}
+		if _, err := tx.Exec(`DELETE FROM schema_version`); err != nil {
+			tx.Rollback()
+			return err
+		}
+		if _, err := tx.Exec(s.rebind(`INSERT INTO schema_version (version) VALUES (?)`), i+1); err != nil {
+			tx.Rollback()
+			return err
+		}
+		if err := tx.Commit(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// SchemaVersion returns the number of migrations applied to the database
+func (s *Store) SchemaVersion() (int, error) {
+	var version int
+	err := s.db.QueryRow(`SELECT COALESCE(MAX(version), 0) FROM schema_version`).Scan(&version)
+	return version, err
+}
@@ -0,0 +1,90 @@
+package parquet
+
+import (
+	"bytes"
+	"encoding/binary"
+)
+
+// Types of the Thrift compact protocol the metadata of Parquet files is encoded with
+const (
+	thriftI32    = 5
+	thriftI64    = 6
+	thriftBinary = 8
+	thriftList   = 9
+	thriftStruct = 12
+)
+
+// thriftWriter encodes Thrift structs with the compact protocol. The fields of a struct are written in
+// increasing id order between begin and end.
+type thriftWriter struct {
+	buf bytes.Buffer
+	// Id of the last field written, per struct being written
+	lastFields []int16
+}
+
+func (t *thriftWriter) begin() {
+	t.lastFields = append(t.lastFields, 0)
+}
+
+func (t *thriftWriter) end() {
+	t.buf.WriteByte(0)
+	t.lastFields = t.lastFields[:len(t.lastFields)-1]
+}
+
+func (t *thriftWriter) field(id int16, fieldType byte) {
+	last := &t.lastFields[len(t.lastFields)-1]
+	if delta := id - *last; delta > 0 && delta <= 15 {
+		t.buf.WriteByte(byte(delta)<<4 | fieldType)
+	} else {
+		t.buf.WriteByte(fieldType)
+		t.varint(int64(id))
+	}
+	*last = id
+}
+
+func (t *thriftWriter) i32(id int16, value int32) {
+	t.field(id, thriftI32)
+	t.varint(int64(value))
+}
+
+func (t *thriftWriter) i64(id int16, value int64) {
+	t.field(id, thriftI64)
+	t.varint(value)
+}
+
+func (t *thriftWriter) string(id int16, value string) {
+	t.field(id, thriftBinary)
+	t.binary(value)
+}
+
+// list writes the header of a list field of n elements of the type, to be followed by the elements
+func (t *thriftWriter) list(id int16, elementType byte, n int) {
+	t.field(id, thriftList)
+	if n < 15 {
+		t.buf.WriteByte(byte(n)<<4 | elementType)
+	} else {
+		t.buf.WriteByte(0xf0 | elementType)
+		t.uvarint(uint64(n))
+	}
+}
+
+// structField begins a struct field, to be ended with end
+func (t *thriftWriter) structField(id int16) {
+	t.field(id, thriftStruct)
+	t.begin()
+}
+
+func (t *thriftWriter) binary(value string) {
+	t.uvarint(uint64(len(value)))
+	t.buf.WriteString(value)
+}
+
+// varint writes a zigzag encoded integer, elements of lists included
+func (t *thriftWriter) varint(value int64) {
+	t.uvarint(uint64(value<<1) ^ uint64(value>>63))
+}
+
+func (t *thriftWriter) uvarint(value uint64) {
+	var b [binary.MaxVarintLen64]byte
+	t.buf.Write(b[:binary.PutUvarint(b[:], value)])
+}
@@ -0,0 +1,273 @@
+// Package parquet writes the per commit and per file churn to Apache Parquet files, to be loaded
+// efficiently into Spark, DuckDB or pandas for research on big histories. The files hold a row group
+// every RowGroupSize rows, of a single uncompressed, PLAIN encoded page per column.
+package parquet
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"time"
+
+	metrics "github.com/andymeneely/git-churn/matrics"
+	"github.com/andymeneely/git-churn/version"
+)
+
+// SchemaVersion is the version of the columns of the files, stored in their key-value metadata under
+// SchemaVersionKey. It is bumped whenever a column is added, removed or changed.
+const (
+	SchemaVersion    = "1"
+	SchemaVersionKey = "git-churn.schema_version"
+)
+
+// DefaultRowGroupSize is the number of rows of the row groups, bounding the memory the writer takes
+const DefaultRowGroupSize = 100000
+
+const magic = "PAR1"
+
+// Physical types, converted types and encodings of the Parquet format
+const (
+	typeInt32                = 1
+	typeInt64                = 2
+	typeByteArray            = 6
+	convertedUTF8            = 0
+	convertedTimestampMillis = 9
+	noConvertedType          = -1
+	encodingPlain            = 0
+	encodingRLE              = 3
+)
+
+// Record is the churn of a file in a commit, a row of the files
+type Record struct {
+	Repo       string
+	Hash       string
+	Author     string
+	AuthorName string
+	AuthoredAt time.Time
+	Parents    int
+	File       string
+	Insertions int
+	Deletions  int
+}
+
+type column struct {
+	name      string
+	physical  int32
+	converted int32
+	write     func(page *bytes.Buffer, record *Record)
+}
+
+// columns of the schema, named like the columns of the commits and file_churn tables of the storage
+var columns = []column{
+	{"repo", typeByteArray, convertedUTF8, func(page *bytes.Buffer, r *Record) { writeString(page, r.Repo) }},
+	{"hash", typeByteArray, convertedUTF8, func(page *bytes.Buffer, r *Record) { writeString(page, r.Hash) }},
+	{"author", typeByteArray, convertedUTF8, func(page *bytes.Buffer, r *Record) { writeString(page, r.Author) }},
+	{"author_name", typeByteArray, convertedUTF8, func(page *bytes.Buffer, r *Record) { writeString(page, r.AuthorName) }},
+	{"authored_at", typeInt64, convertedTimestampMillis, func(page *bytes.Buffer, r *Record) {
+		binary.Write(page, binary.LittleEndian, r.AuthoredAt.UnixNano()/int64(time.Millisecond))
+	}},
+	{"parents", typeInt32, noConvertedType, func(page *bytes.Buffer, r *Record) { writeInt32(page, r.Parents) }},
+	{"file", typeByteArray, convertedUTF8, func(page *bytes.Buffer, r *Record) { writeString(page, r.File) }},
+	{"insertions", typeInt32, noConvertedType, func(page *bytes.Buffer, r *Record) { writeInt32(page, r.Insertions) }},
+	{"deletions", typeInt32, noConvertedType, func(page *bytes.Buffer, r *Record) { writeInt32(page, r.Deletions) }},
+}
+
+func writeString(page *bytes.Buffer, value string) {
+	binary.Write(page, binary.LittleEndian, uint32(len(value)))
+	page.WriteString(value)
+}
+
+func writeInt32(page *bytes.Buffer, value int) {
+	binary.Write(page, binary.LittleEndian, int32(value))
+}
+
+// Writer writes records to a Parquet file, buffering a row group at a time
+type Writer struct {
+	// Rows per row group, DefaultRowGroupSize if 0
+	RowGroupSize int
+
+	w         io.Writer
+	offset    int64
+	records   []Record
+	rows      int64
+	rowGroups []rowGroup
+}
+
+type rowGroup struct {
+	rows   int64
+	size   int64
+	chunks []chunk
+}
+
+// chunk is the single page of a column in a row group
+type chunk struct {
+	offset, size int64
+}
+
+// NewWriter starts a Parquet file on w
+func NewWriter(w io.Writer) (*Writer, error) {
+	writer := &Writer{w: w}
+	return writer, writer.write([]byte(magic))
+}
+
+// Write adds the record to the file
+func (w *Writer) Write(record Record) error {
+	w.records = append(w.records, record)
+	size := w.RowGroupSize
+	if size <= 0 {
+		size = DefaultRowGroupSize
+	}
+	if len(w.records) >= size {
+		return w.flush()
+	}
+	return nil
+}
+
+// WriteCommit adds a record per file of the commit of the repository
+func (w *Writer) WriteCommit(repo string, commit *metrics.CommitChurn) error {
+	for _, file := range commit.Files {
+		err := w.Write(Record{
+			Repo:       repo,
+			Hash:       commit.Hash,
+			Author:     commit.Author,
+			AuthorName: commit.AuthorName,
+			AuthoredAt: commit.When,
+			Parents:    commit.Parents,
+			File:       file.File,
+			Insertions: file.Insertions,
+			Deletions:  file.Deletions,
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Close writes the last row group and the metadata of the file, without closing the underlying writer
+func (w *Writer) Close() error {
+	if err := w.flush(); err != nil {
+		return err
+	}
+	footer := w.footer()
+	if err := w.write(footer); err != nil {
+		return err
+	}
+	var length [4]byte
+	binary.LittleEndian.PutUint32(length[:], uint32(len(footer)))
+	if err := w.write(length[:]); err != nil {
+		return err
+	}
+	return w.write([]byte(magic))
+}
+
+func (w *Writer) write(data []byte) error {
+	n, err := w.w.Write(data)
+	w.offset += int64(n)
+	return err
+}
+
+// flush writes the buffered records as a row group, a page per column
+func (w *Writer) flush() error {
+	if len(w.records) == 0 {
+		return nil
+	}
+	group := rowGroup{rows: int64(len(w.records))}
+	var page bytes.Buffer
+	for _, column := range columns {
+		page.Reset()
+		for i := range w.records {
+			column.write(&page, &w.records[i])
+		}
+		header := pageHeader(len(w.records), page.Len())
+		chunk := chunk{offset: w.offset, size: int64(len(header) + page.Len())}
+		if err := w.write(header); err != nil {
+			return err
+		}
+		if err := w.write(page.Bytes()); err != nil {
+			return err
+		}
+		group.chunks = append(group.chunks, chunk)
+		group.size += chunk.size
+	}
+	w.rows += group.rows
+	w.rowGroups = append(w.rowGroups, group)
+	w.records = w.records[:0]
+	return nil
+}
+
+// pageHeader encodes the PageHeader of a data page of the values, none being null or repeated so that
+// the page holds no levels
+func pageHeader(values, size int) []byte {
+	var t thriftWriter
+	t.begin()
+	t.i32(1, 0) // DATA_PAGE
+	t.i32(2, int32(size))
+	t.i32(3, int32(size))
+	t.structField(5)
+	t.i32(1, int32(values))
+	t.i32(2, encodingPlain)
+	t.i32(3, encodingRLE)
+	t.i32(4, encodingRLE)
+	t.end()
+	t.end()
+	return t.buf.Bytes()
+}
+
+// footer encodes the FileMetaData of the file
+func (w *Writer) footer() []byte {
+	var t thriftWriter
+	t.begin()
+	t.i32(1, 1)
+	t.list(2, thriftStruct, len(columns)+1)
+	t.begin()
+	t.string(4, "schema")
+	t.i32(5, int32(len(columns)))
+	t.end()
+	for _, column := range columns {
+		t.begin()
+		t.i32(1, column.physical)
+		t.i32(3, 0) // REQUIRED
+		t.string(4, column.name)
+		if column.converted != noConvertedType {
+			t.i32(6, column.converted)
+		}
+		t.end()
+	}
+	t.i64(3, w.rows)
+	t.list(4, thriftStruct, len(w.rowGroups))
+	for _, group := range w.rowGroups {
+		t.begin()
+		t.list(1, thriftStruct, len(columns))
+		for i, column := range columns {
+			chunk := group.chunks[i]
+			t.begin()
+			t.i64(2, chunk.offset)
+			t.structField(3)
+			t.i32(1, column.physical)
+			t.list(2, thriftI32, 2)
+			t.varint(encodingPlain)
+			t.varint(encodingRLE)
+			t.list(3, thriftBinary, 1)
+			t.binary(column.name)
+			t.i32(4, 0) // UNCOMPRESSED
+			t.i64(5, group.rows)
+			t.i64(6, chunk.size)
+			t.i64(7, chunk.size)
+			t.i64(9, chunk.offset)
+			t.end()
+			t.end()
+		}
+		t.i64(2, group.size)
+		t.i64(3, group.rows)
+		t.end()
+	}
+	t.list(5, thriftStruct, 1)
+	t.begin()
+	t.string(1, SchemaVersionKey)
+	t.string(2, SchemaVersion)
+	t.end()
+	t.string(6, "git-churn version "+version.Version)
+	t.end()
+	return t.buf.Bytes()
+}
@@ -0,0 +1,171 @@
+package parquet
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+	"time"
+
+	metrics "github.com/andymeneely/git-churn/matrics"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWriter(t *testing.T) {
+	assert := assert.New(t)
+	when := time.Date(2020, 5, 1, 10, 0, 0, 0, time.UTC)
+	var out bytes.Buffer
+	w, err := NewWriter(&out)
+	assert.Nil(err)
+	w.RowGroupSize = 2
+	assert.Nil(w.WriteCommit("repo", &metrics.CommitChurn{Hash: "abc", Author: "alice@example.com", AuthorName: "Alice", When: when, Parents: 1,
+		Files: []metrics.FileChurn{{File: "a.go", Insertions: 3, Deletions: 1}, {File: "b.go", Insertions: 20}}}))
+	assert.Nil(w.WriteCommit("repo", &metrics.CommitChurn{Hash: "def", Author: "bob@example.com", When: when.Add(time.Hour),
+		Files: []metrics.FileChurn{{File: "é.txt", Deletions: 7}}}))
+	assert.Nil(w.Close())
+
+	file := out.Bytes()
+	assert.Equal("PAR1", string(file[:4]))
+	assert.Equal("PAR1", string(file[len(file)-4:]))
+	length := int(binary.LittleEndian.Uint32(file[len(file)-8:]))
+	meta := (&thriftReader{data: file[len(file)-8-length : len(file)-8]}).readStruct()
+
+	assert.Equal(int64(1), meta[1])
+	assert.Equal(int64(3), meta[3])
+	schema := meta[2].([]interface{})
+	assert.Equal(10, len(schema))
+	assert.Equal("schema", schema[0].(map[int16]interface{})[4])
+	assert.Equal(int64(9), schema[0].(map[int16]interface{})[5])
+	assert.Equal("authored_at", schema[5].(map[int16]interface{})[4])
+	assert.Equal(int64(convertedTimestampMillis), schema[5].(map[int16]interface{})[6])
+	keyValue := meta[5].([]interface{})[0].(map[int16]interface{})
+	assert.Equal(SchemaVersionKey, keyValue[1])
+	assert.Equal(SchemaVersion, keyValue[2])
+
+	rowGroups := meta[4].([]interface{})
+	assert.Equal(2, len(rowGroups))
+	var records []Record
+	for _, g := range rowGroups {
+		group := g.(map[int16]interface{})
+		rows := int(group[3].(int64))
+		groupRecords := make([]Record, rows)
+		for i, c := range group[1].([]interface{}) {
+			metaData := c.(map[int16]interface{})[3].(map[int16]interface{})
+			assert.Equal([]interface{}{columns[i].name}, metaData[3])
+			offset := metaData[9].(int64)
+			header := &thriftReader{data: file[offset:]}
+			page := header.readStruct()
+			assert.Equal(int64(rows), page[5].(map[int16]interface{})[1])
+			values := bytes.NewReader(file[offset+int64(header.pos) : offset+int64(header.pos)+page[3].(int64)])
+			for j := range groupRecords {
+				readValue(values, i, &groupRecords[j])
+			}
+			assert.Equal(0, values.Len())
+		}
+		records = append(records, groupRecords...)
+	}
+	assert.Equal([]Record{
+		{Repo: "repo", Hash: "abc", Author: "alice@example.com", AuthorName: "Alice", AuthoredAt: when, Parents: 1, File: "a.go", Insertions: 3, Deletions: 1},
+		{Repo: "repo", Hash: "abc", Author: "alice@example.com", AuthorName: "Alice", AuthoredAt: when, Parents: 1, File: "b.go", Insertions: 20},
+		{Repo: "repo", Hash: "def", Author: "bob@example.com", AuthoredAt: when.Add(time.Hour), File: "é.txt", Deletions: 7},
+	}, records)
+}
+
+// readValue reads the PLAIN encoded value of the column into the record
+func readValue(values *bytes.Reader, column int, record *Record) {
+	readString := func() string {
+		var length uint32
+		binary.Read(values, binary.LittleEndian, &length)
+		s := make([]byte, length)
+		values.Read(s)
+		return string(s)
+	}
+	readInt := func() int {
+		var value int32
+		binary.Read(values, binary.LittleEndian, &value)
+		return int(value)
+	}
+	switch columns[column].name {
+	case "repo":
+		record.Repo = readString()
+	case "hash":
+		record.Hash = readString()
+	case "author":
+		record.Author = readString()
+	case "author_name":
+		record.AuthorName = readString()
+	case "authored_at":
+		var millis int64
+		binary.Read(values, binary.LittleEndian, &millis)
+		record.AuthoredAt = time.Unix(0, millis*int64(time.Millisecond)).UTC()
+	case "parents":
+		record.Parents = readInt()
+	case "file":
+		record.File = readString()
+	case "insertions":
+		record.Insertions = readInt()
+	case "deletions":
+		record.Deletions = readInt()
+	}
+}
+
+// thriftReader decodes the compact protocol into maps of the fields by id, lists into slices, integers
+// into int64 and binaries into strings
+type thriftReader struct {
+	data []byte
+	pos  int
+}
+
+func (r *thriftReader) readStruct() map[int16]interface{} {
+	fields := make(map[int16]interface{})
+	var last int16
+	for {
+		b := r.data[r.pos]
+		r.pos++
+		if b == 0 {
+			return fields
+		}
+		id := last + int16(b>>4)
+		if b>>4 == 0 {
+			id = int16(r.readVarint())
+		}
+		fields[id] = r.readValue(b & 0x0f)
+		last = id
+	}
+}
+
+func (r *thriftReader) readValue(fieldType byte) interface{} {
+	switch fieldType {
+	case thriftI32, thriftI64:
+		return r.readVarint()
+	case thriftBinary:
+		n := int(r.readUvarint())
+		r.pos += n
+		return string(r.data[r.pos-n : r.pos])
+	case thriftList:
+		b := r.data[r.pos]
+		r.pos++
+		n := int(b >> 4)
+		if n == 15 {
+			n = int(r.readUvarint())
+		}
+		list := make([]interface{}, n)
+		for i := range list {
+			list[i] = r.readValue(b & 0x0f)
+		}
+		return list
+	case thriftStruct:
+		return r.readStruct()
+	}
+	panic("unexpected thrift type")
+}
+
+func (r *thriftReader) readVarint() int64 {
+	value := r.readUvarint()
+	return int64(value>>1) ^ -int64(value&1)
+}
+
+func (r *thriftReader) readUvarint() uint64 {
+	value, n := binary.Uvarint(r.data[r.pos:])
+	r.pos += n
+	return value
+}
@@ -0,0 +1,188 @@
+// Package storage persists the computed churn in SQLite or Postgres, so the history of a repository is
+// analyzed once and then queried with SQL or loaded back without recomputation
+package storage
+
+import (
+	"database/sql"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	metrics "github.com/andymeneely/git-churn/matrics"
+	// Database drivers
+	_ "github.com/lib/pq"
+	_ "github.com/mattn/go-sqlite3"
+)
+
+// Store is a database of churn
+type Store struct {
+	db *sql.DB
+	// Postgres uses $1, $2... placeholders instead of ?
+	postgres bool
+}
+
+// Open opens the database given by the DSN, a postgres:// URL or the path of a SQLite file, and migrates
+// its schema to the latest version
+func Open(dsn string) (*Store, error) {
+	driver := "sqlite3"
+	if strings.HasPrefix(dsn, "postgres://") || strings.HasPrefix(dsn, "postgresql://") {
+		driver = "postgres"
+	} else {
+		dsn = strings.TrimPrefix(dsn, "sqlite://")
+	}
+	db, err := sql.Open(driver, dsn)
+	if err != nil {
+		return nil, err
+	}
+	s := &Store{db: db, postgres: driver == "postgres"}
+	if err := s.Migrate(); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return s, nil
+}
+
+func (s *Store) Close() error {
+	return s.db.Close()
+}
+
+// DB gives access to the underlying database, e.g. to run ad hoc queries
+func (s *Store) DB() *sql.DB {
+	return s.db
+}
+
+// rebind rewrites the ? placeholders of the query for the database
+func (s *Store) rebind(query string) string {
+	if !s.postgres {
+		return query
+	}
+	var b strings.Builder
+	n := 0
+	for _, r := range query {
+		if r == '?' {
+			n++
+			b.WriteString("$" + strconv.Itoa(n))
+		} else {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// SaveCommits stores the churn of the commits of the repository. Commits already stored are left untouched.
+func (s *Store) SaveCommits(repo string, commits []*metrics.CommitChurn) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	insertCommit, err := tx.Prepare(s.rebind(`INSERT INTO commits
+		(repo, hash, author, author_name, authored_at, message, parents, insertions, deletions)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?) ON CONFLICT DO NOTHING`))
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	insertFile, err := tx.Prepare(s.rebind(`INSERT INTO file_churn (repo, hash, file, insertions, deletions)
+		VALUES (?, ?, ?, ?, ?) ON CONFLICT DO NOTHING`))
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	for _, commit := range commits {
+		_, err := insertCommit.Exec(repo, commit.Hash, commit.Author, commit.AuthorName, commit.When.UTC(),
+			commit.Message, commit.Parents, commit.Insertions, commit.Deletions)
+		if err != nil {
+			tx.Rollback()
+			return fmt.Errorf("storing commit %s: %s", commit.Hash, err)
+		}
+		for _, file := range commit.Files {
+			if _, err := insertFile.Exec(repo, commit.Hash, file.File, file.Insertions, file.Deletions); err != nil {
+				tx.Rollback()
+				return fmt.Errorf("storing the churn of %s in %s: %s", file.File, commit.Hash, err)
+			}
+		}
+	}
+	return tx.Commit()
+}
+
+// StoredCommits returns the hashes of the commits of the repository already stored
+func (s *Store) StoredCommits(repo string) (map[string]bool, error) {
+	rows, err := s.db.Query(s.rebind(`SELECT hash FROM commits WHERE repo = ?`), repo)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	hashes := make(map[string]bool)
+	for rows.Next() {
+		var hash string
+		if err := rows.Scan(&hash); err != nil {
+			return nil, err
+		}
+		hashes[hash] = true
+	}
+	return hashes, rows.Err()
+}
+
+// LastAnalyzed returns the last commit of the branch stored by a Sync, empty if the branch was never synced
+func (s *Store) LastAnalyzed(repo, branch string) (string, error) {
+	var hash string
+	err := s.db.QueryRow(s.rebind(`SELECT last_commit FROM branch_state WHERE repo = ? AND branch = ?`), repo, branch).Scan(&hash)
+	if err == sql.ErrNoRows {
+		return "", nil
+	}
+	return hash, err
+}
+
+// SetLastAnalyzed records the last commit of the branch stored
+func (s *Store) SetLastAnalyzed(repo, branch, hash string) error {
+	_, err := s.db.Exec(s.rebind(`INSERT INTO branch_state (repo, branch, last_commit, analyzed_at) VALUES (?, ?, ?, ?)
+		ON CONFLICT (repo, branch) DO UPDATE SET last_commit = excluded.last_commit, analyzed_at = excluded.analyzed_at`),
+		repo, branch, hash, time.Now().UTC())
+	return err
+}
+
+// LoadCommits loads the churn of the commits of the repository authored since the given time, newest first
+func (s *Store) LoadCommits(repo string, since time.Time) ([]*metrics.CommitChurn, error) {
+	rows, err := s.db.Query(s.rebind(`SELECT hash, author, author_name, authored_at, message, parents, insertions, deletions
+		FROM commits WHERE repo = ? AND authored_at >= ? ORDER BY authored_at DESC, hash`), repo, since.UTC())
+	if err != nil {
+		return nil, err
+	}
+	var commits []*metrics.CommitChurn
+	byHash := make(map[string]*metrics.CommitChurn)
+	for rows.Next() {
+		commit := new(metrics.CommitChurn)
+		err := rows.Scan(&commit.Hash, &commit.Author, &commit.AuthorName, &commit.When, &commit.Message,
+			&commit.Parents, &commit.Insertions, &commit.Deletions)
+		if err != nil {
+			rows.Close()
+			return nil, err
+		}
+		commits = append(commits, commit)
+		byHash[commit.Hash] = commit
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	rows, err = s.db.Query(s.rebind(`SELECT f.hash, f.file, f.insertions, f.deletions
+		FROM file_churn f JOIN commits c ON c.repo = f.repo AND c.hash = f.hash
+		WHERE f.repo = ? AND c.authored_at >= ? ORDER BY f.file`), repo, since.UTC())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var hash string
+		var file metrics.FileChurn
+		if err := rows.Scan(&hash, &file.File, &file.Insertions, &file.Deletions); err != nil {
+			return nil, err
+		}
+		if commit, ok := byHash[hash]; ok {
+			commit.Files = append(commit.Files, file)
+		}
+	}
+	return commits, rows.Err()
+}
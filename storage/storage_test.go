@@ -0,0 +1,131 @@
+package storage
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	metrics "github.com/andymeneely/git-churn/matrics"
+	"github.com/andymeneely/git-churn/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func openTemp(t *testing.T) (*Store, func()) {
+	dir, err := ioutil.TempDir("", "git-churn-storage")
+	assert.Nil(t, err)
+	s, err := Open(filepath.Join(dir, "churn.db"))
+	assert.Nil(t, err)
+	return s, func() {
+		s.Close()
+		os.RemoveAll(dir)
+	}
+}
+
+func TestSaveAndLoadCommits(t *testing.T) {
+	assert := assert.New(t)
+	s, cleanup := openTemp(t)
+	defer cleanup()
+
+	older := &metrics.CommitChurn{Hash: "aaa", Author: "alice@example.com", AuthorName: "alice", Message: "add",
+		When: time.Date(2020, 1, 1, 10, 0, 0, 0, time.UTC), Insertions: 3, Files: []metrics.FileChurn{{File: "a.go", Insertions: 3}}}
+	newer := &metrics.CommitChurn{Hash: "bbb", Author: "bob@example.com", AuthorName: "bob", Message: "fix", Parents: 1,
+		When: time.Date(2020, 2, 1, 10, 0, 0, 0, time.UTC), Insertions: 1, Deletions: 2,
+		Files: []metrics.FileChurn{{File: "a.go", Insertions: 1, Deletions: 1}, {File: "b.go", Deletions: 1}}}
+	assert.Nil(s.SaveCommits("repo", []*metrics.CommitChurn{older, newer}))
+	// Storing the same commits again is a no-op
+	assert.Nil(s.SaveCommits("repo", []*metrics.CommitChurn{newer}))
+
+	stored, err := s.StoredCommits("repo")
+	assert.Nil(err)
+	assert.Equal(map[string]bool{"aaa": true, "bbb": true}, stored)
+	stored, err = s.StoredCommits("other")
+	assert.Nil(err)
+	assert.Empty(stored)
+
+	commits, err := s.LoadCommits("repo", time.Time{})
+	assert.Nil(err)
+	assert.Equal(2, len(commits))
+	assert.Equal(newer.Hash, commits[0].Hash)
+	assert.True(newer.When.Equal(commits[0].When))
+	assert.Equal(newer.Files, commits[0].Files)
+	assert.Equal(newer.Deletions, commits[0].Deletions)
+
+	commits, err = s.LoadCommits("repo", time.Date(2020, 1, 15, 0, 0, 0, 0, time.UTC))
+	assert.Nil(err)
+	assert.Equal(1, len(commits))
+	assert.Equal(newer.Files, commits[0].Files)
+}
+
+func TestMigrate(t *testing.T) {
+	assert := assert.New(t)
+	s, cleanup := openTemp(t)
+	defer cleanup()
+	version, err := s.SchemaVersion()
+	assert.Nil(err)
+	assert.Equal(len(migrations), version)
+	// Migrating an up to date database does nothing
+	assert.Nil(s.Migrate())
+	version, err = s.SchemaVersion()
+	assert.Nil(err)
+	assert.Equal(len(migrations), version)
+}
+
+func TestRebind(t *testing.T) {
+	assert.Equal(t, "SELECT ? WHERE ?", (&Store{}).rebind("SELECT ? WHERE ?"))
+	assert.Equal(t, "SELECT $1 WHERE $2", (&Store{postgres: true}).rebind("SELECT ? WHERE ?"))
+}
+
+func TestLastAnalyzed(t *testing.T) {
+	assert := assert.New(t)
+	s, cleanup := openTemp(t)
+	defer cleanup()
+	last, err := s.LastAnalyzed("repo", "master")
+	assert.Nil(err)
+	assert.Equal("", last)
+
+	assert.Nil(s.SetLastAnalyzed("repo", "master", "aaa"))
+	assert.Nil(s.SetLastAnalyzed("repo", "master", "bbb"))
+	assert.Nil(s.SetLastAnalyzed("repo", "develop", "ccc"))
+	last, err = s.LastAnalyzed("repo", "master")
+	assert.Nil(err)
+	assert.Equal("bbb", last)
+}
+
+func TestSyncBranchKey(t *testing.T) {
+	assert := assert.New(t)
+	s, cleanup := openTemp(t)
+	defer cleanup()
+	repo := testutil.NewRepo(t)
+	repo.CommitFiles("add", map[string]string{"a.txt": "1\n"})
+	first := repo.CommitFiles("edit", map[string]string{"a.txt": "2\n"})
+
+	added, err := s.Sync(repo.Repository, "repo", "", "HEAD", false)
+	assert.Nil(err)
+	assert.Equal(2, added)
+	// Recorded under the branch HEAD points to
+	last, err := s.LastAnalyzed("repo", "master")
+	assert.Nil(err)
+	assert.Equal(first.Hash.String(), last)
+
+	// The other ways to name the branch pick up where the last sync stopped
+	second := repo.CommitFiles("edit again", map[string]string{"a.txt": "3\n"})
+	repo.SetRef("refs/remotes/origin/master")
+	for _, revision := range []string{"refs/heads/master", "origin/master", "refs/remotes/origin/master", "master"} {
+		_, err = s.Sync(repo.Repository, "repo", "", revision, false)
+		assert.Nil(err)
+		last, err = s.LastAnalyzed("repo", "master")
+		assert.Nil(err)
+		assert.Equal(second.Hash.String(), last)
+	}
+	for _, key := range []string{"HEAD", "refs/heads/master", "origin/master", "refs/remotes/origin/master"} {
+		last, err = s.LastAnalyzed("repo", key)
+		assert.Nil(err)
+		assert.Empty(last, key)
+	}
+
+	repo.Tag("v1")
+	assert.Equal("v1", branchKey(repo.Repository, "v1"))
+	assert.Equal(second.Hash.String(), branchKey(repo.Repository, second.Hash.String()))
+}
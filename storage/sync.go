@@ -0,0 +1,123 @@
+package storage
+
+import (
+	"strings"
+
+	"github.com/andymeneely/git-churn/gitfuncs"
+	"github.com/andymeneely/git-churn/helper"
+	metrics "github.com/andymeneely/git-churn/matrics"
+	. "github.com/andymeneely/git-churn/print"
+	"gopkg.in/src-d/go-git.v4"
+	"gopkg.in/src-d/go-git.v4/plumbing"
+	"gopkg.in/src-d/go-git.v4/plumbing/object"
+)
+
+// Number of commits stored per transaction, so that the churn of a whole history is never held in memory
+const syncBatchSize = 1000
+
+// Sync stores the churn of the commits from..to of the repository, computing it only for the commits not
+// stored yet, and returns the number of commits added.
+//
+// When `from` is empty the whole history leading to `to` is covered, incrementally: the tip of `to` is
+// recorded, and the next Sync of the same `to` only walks the commits added since. The whole history is
+// walked again when the recorded commit is no longer an ancestor of `to`, e.g. after a force push, or
+// when `full` is set. The tip is recorded per branch, whichever way `to` names it, see branchKey.
+func (s *Store) Sync(repo *git.Repository, name, from, to string, full bool) (int, error) {
+	defer helper.Duration(helper.Track("Sync"))
+	toHash, err := gitfuncs.ResolveRef(repo, to)
+	if err != nil {
+		return 0, err
+	}
+	var fromHash plumbing.Hash
+	if from != "" {
+		hash, err := gitfuncs.ResolveRef(repo, from)
+		if err != nil {
+			return 0, err
+		}
+		fromHash = *hash
+	} else if !full {
+		if fromHash, err = s.incrementalStart(repo, name, branchKey(repo, to), *toHash); err != nil {
+			return 0, err
+		}
+	}
+
+	stored := make(map[string]bool)
+	if fromHash.IsZero() {
+		// Skips the commits stored by previous syncs, e.g. of other branches. Storing a commit twice is a
+		// no-op anyway, so the small incremental walks do not bother loading them all.
+		if stored, err = s.StoredCommits(name); err != nil {
+			return 0, err
+		}
+	}
+	added := 0
+	var batch []*metrics.CommitChurn
+	err = gitfuncs.ForEachCommitBetween(repo, fromHash, *toHash, func(commit *object.Commit) error {
+		if stored[commit.Hash.String()] || metrics.Bots.IsBot(commit) {
+			return nil
+		}
+		churn, err := metrics.GetCommitChurn(repo, commit)
+		if err != nil {
+			return err
+		}
+		batch = append(batch, churn)
+		if len(batch) < syncBatchSize {
+			return nil
+		}
+		if err := s.SaveCommits(name, batch); err != nil {
+			return err
+		}
+		added += len(batch)
+		batch = batch[:0]
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	if err := s.SaveCommits(name, batch); err != nil {
+		return 0, err
+	}
+	added += len(batch)
+	if from == "" {
+		// Only a sync of the whole history leaves no gap before the recorded commit
+		if err := s.SetLastAnalyzed(name, branchKey(repo, to), toHash.String()); err != nil {
+			return 0, err
+		}
+	}
+	return added, nil
+}
+
+// incrementalStart returns the commit recorded by the last sync of the branch when the branch still
+// contains it, the zero hash to walk the whole history otherwise
+func (s *Store) incrementalStart(repo *git.Repository, name, branch string, tip plumbing.Hash) (plumbing.Hash, error) {
+	last, err := s.LastAnalyzed(name, branch)
+	if err != nil || last == "" {
+		return plumbing.ZeroHash, err
+	}
+	lastHash := plumbing.NewHash(last)
+	if lastHash == tip {
+		return lastHash, nil
+	}
+	base, err := gitfuncs.MergeBase(repo, lastHash, tip)
+	if err != nil || base.Hash != lastHash {
+		Warning("%s is no longer an ancestor of %s, walking the whole history", last, branch)
+		return plumbing.ZeroHash, nil
+	}
+	Info("Walking the commits of %s since %s", branch, last)
+	return lastHash, nil
+}
+
+// branchKey returns the name the tip of the revision is recorded under, the short name of the branch for
+// every way to name it: main, refs/heads/main, origin/main, refs/remotes/origin/main, and HEAD when it
+// points to main. Other revisions, e.g. tags and hashes, are recorded as given.
+func branchKey(repo *git.Repository, revision string) string {
+	if revision == "HEAD" {
+		if head, err := repo.Reference(plumbing.HEAD, false); err == nil && head.Type() == plumbing.SymbolicReference {
+			revision = head.Target().String()
+		}
+	}
+	name := plumbing.ReferenceName(revision)
+	if name.IsBranch() || name.IsRemote() {
+		revision = name.Short()
+	}
+	return strings.TrimPrefix(revision, git.DefaultRemoteName+"/")
+}
@@ -0,0 +1,159 @@
+// Package survey analyzes many repositories concurrently, e.g. all the repositories of an organization,
+// into one combined report
+package survey
+
+import (
+	"bufio"
+	"io"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/andymeneely/git-churn/gitfuncs"
+	"github.com/andymeneely/git-churn/helper"
+	metrics "github.com/andymeneely/git-churn/matrics"
+	. "github.com/andymeneely/git-churn/print"
+	"gopkg.in/src-d/go-git.v4"
+)
+
+// ReadRepos reads the URLs of the repositories to survey, one per line. Blank lines and the lines
+// starting with # are skipped, as are the repeated URLs.
+func ReadRepos(r io.Reader) ([]string, error) {
+	var repos []string
+	seen := make(map[string]bool)
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") || seen[line] {
+			continue
+		}
+		seen[line] = true
+		repos = append(repos, line)
+	}
+	return repos, scanner.Err()
+}
+
+// RepoReport is the churn of a repository since the start of the survey, or the reason it could not be
+// analyzed
+type RepoReport struct {
+	Error string `json:",omitempty"`
+	metrics.RangeSummary
+	// Hottest files, when asked for
+	Hotspots []metrics.Hotspot `json:",omitempty"`
+}
+
+// Report is the churn of every repository surveyed, keyed by URL, and its totals over the repositories
+// analyzed
+type Report struct {
+	Since      time.Time
+	Analyzed   int
+	Failed     int
+	Commits    int
+	Insertions int `units:"lines"`
+	Deletions  int `units:"lines"`
+	Churn      int `units:"lines"`
+	Repos      map[string]*RepoReport
+}
+
+// Surveyor analyzes repositories with a bounded number of workers
+type Surveyor struct {
+	// Number of repositories analyzed at once
+	Workers int
+	// Start of the window of commits analyzed
+	Since time.Time
+	// Number of most churned files reported per repository, all of them if zero
+	Top int
+	// Number of hottest files reported per repository, none if zero
+	Hotspots int
+	// Clones a repository, gitfuncs.CloneRepository by default
+	Clone func(repoUrl string) (*git.Repository, error)
+	// When set, is told of the number of repositories analyzed so far
+	OnProgress func(helper.Progress)
+}
+
+// New returns a surveyor of the churn since `since` analyzing up to `workers` repositories at once
+func New(workers int, since time.Time, top int) *Surveyor {
+	return &Surveyor{Workers: workers, Since: since, Top: top, Clone: gitfuncs.CloneRepository}
+}
+
+// Run analyzes the default branch of every repository. A repository failing to be cloned or analyzed is
+// reported with its error and does not stop the survey.
+func (s *Surveyor) Run(repos []string) *Report {
+	defer helper.Duration(helper.Track("Survey"))
+	report := &Report{Since: s.Since, Repos: make(map[string]*RepoReport, len(repos))}
+	workers := s.Workers
+	if workers < 1 {
+		workers = 1
+	}
+	if workers > len(repos) {
+		workers = len(repos)
+	}
+
+	var mu sync.Mutex
+	progress := helper.Progress{Task: "repositories", Total: len(repos)}
+	s.report(progress)
+	jobs := make(chan string)
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for repoUrl := range jobs {
+				r := s.analyze(repoUrl)
+				mu.Lock()
+				report.Repos[repoUrl] = r
+				progress.Done += 1
+				s.report(progress)
+				mu.Unlock()
+			}
+		}()
+	}
+	for _, repoUrl := range repos {
+		jobs <- repoUrl
+	}
+	close(jobs)
+	wg.Wait()
+	progress.Finished = true
+	s.report(progress)
+
+	for _, r := range report.Repos {
+		if r.Error != "" {
+			report.Failed += 1
+			continue
+		}
+		report.Analyzed += 1
+		report.Commits += r.Commits
+		report.Insertions += r.Insertions
+		report.Deletions += r.Deletions
+		report.Churn += r.RangeSummary.Churn
+	}
+	return report
+}
+
+func (s *Surveyor) analyze(repoUrl string) *RepoReport {
+	repo, err := s.Clone(repoUrl)
+	if err != nil {
+		Warning("unable to clone %s: %s", repoUrl, err)
+		return &RepoReport{Error: err.Error()}
+	}
+	defer gitfuncs.ReleaseRepository(repo)
+	commits, err := metrics.ChurnSince(repo, "HEAD", s.Since)
+	if err != nil {
+		Warning("unable to analyze %s: %s", repoUrl, err)
+		return &RepoReport{Error: err.Error()}
+	}
+	r := &RepoReport{RangeSummary: metrics.SummarizeRange(commits, s.Top)}
+	if s.Hotspots > 0 {
+		if r.Hotspots, err = metrics.Hotspots(repo, commits, "HEAD", s.Hotspots); err != nil {
+			Warning("unable to find the hotspots of %s: %s", repoUrl, err)
+			return &RepoReport{Error: err.Error()}
+		}
+	}
+	return r
+}
+
+func (s *Surveyor) report(progress helper.Progress) {
+	if s.OnProgress != nil {
+		s.OnProgress(progress)
+	}
+}
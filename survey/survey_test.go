@@ -0,0 +1,78 @@
+package survey
+
+import (
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/andymeneely/git-churn/helper"
+	metrics "github.com/andymeneely/git-churn/matrics"
+	"github.com/andymeneely/git-churn/testutil"
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/src-d/go-git.v4"
+)
+
+func TestReadRepos(t *testing.T) {
+	assert := assert.New(t)
+	repos, err := ReadRepos(strings.NewReader(`
+# Backend
+https://example.com/a.git
+  https://example.com/b.git
+
+https://example.com/a.git
+`))
+	assert.Nil(err)
+	assert.Equal([]string{"https://example.com/a.git", "https://example.com/b.git"}, repos)
+}
+
+func TestRun(t *testing.T) {
+	assert := assert.New(t)
+	var mu sync.Mutex
+	clones := make(map[string]int)
+	s := New(2, testutil.Start, 1)
+	s.Clone = func(repoUrl string) (*git.Repository, error) {
+		mu.Lock()
+		clones[repoUrl]++
+		mu.Unlock()
+		if repoUrl == "broken" {
+			return nil, errors.New("unreachable")
+		}
+		repo := testutil.NewRepo(t)
+		repo.CommitFiles("Add a", map[string]string{"a.txt": "1\n2\n"})
+		if repoUrl == "two" {
+			repo.As("bob").CommitFiles("Change a", map[string]string{"a.txt": "1\nb\nc\n"})
+		}
+		return repo.Repository, nil
+	}
+	var last helper.Progress
+	s.OnProgress = func(progress helper.Progress) { last = progress }
+
+	report := s.Run([]string{"one", "two", "broken"})
+	assert.Equal(map[string]int{"one": 1, "two": 1, "broken": 1}, clones)
+	assert.Equal(helper.Progress{Task: "repositories", Done: 3, Total: 3, Finished: true}, last)
+	assert.Equal(2, report.Analyzed)
+	assert.Equal(1, report.Failed)
+	assert.Equal("unreachable", report.Repos["broken"].Error)
+
+	one, two := report.Repos["one"], report.Repos["two"]
+	assert.Equal(1, one.Commits)
+	assert.Equal(2, one.Insertions)
+	assert.Equal(2, two.Commits)
+	assert.Equal(4, two.Insertions)
+	assert.Equal(1, two.Deletions)
+	assert.Len(two.TopFiles, 1)
+	assert.Equal(3, report.Commits)
+	assert.Equal(6, report.Insertions)
+	assert.Equal(1, report.Deletions)
+	assert.Nil(two.Hotspots)
+
+	s.Hotspots = 1
+	report = s.Run([]string{"two"})
+	assert.Equal([]metrics.Hotspot{{File: "a.txt", Commits: 2, Churn: 5, LOC: 3, Score: 1}}, report.Repos["two"].Hotspots)
+
+	s.Since = time.Now()
+	report = s.Run([]string{"two"})
+	assert.Equal(0, report.Repos["two"].Commits)
+}
@@ -0,0 +1,37 @@
+package testutil
+
+import (
+	"flag"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// Update makes Golden rewrite the golden files with the output of the tests instead of comparing them,
+// when the tests are run with -update
+var Update = flag.Bool("update", false, "rewrite the golden files with the current output of the tests")
+
+// Golden compares the output of a test to the golden file testdata/<name>.golden of the package under
+// test and fails the test with their difference, or rewrites the golden file with -update. A change to
+// an output format shows up as a change to its golden files, to be reviewed like one to the code.
+func Golden(t testing.TB, name string, got []byte) {
+	t.Helper()
+	path := filepath.Join("testdata", name+".golden")
+	if *Update {
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(path, got, 0644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+	want, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatalf("%v, run the tests with -update to write it", err)
+	}
+	assert.Equal(t, string(want), string(got), "the output differs from %s, run the tests with -update if the change is intended", path)
+}
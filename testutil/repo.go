@@ -0,0 +1,265 @@
+// Package testutil builds small git repositories for the tests, commit by commit, so that they do not
+// depend on cloning live repositories over the network
+package testutil
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+
+	"gopkg.in/src-d/go-billy.v4/memfs"
+	"gopkg.in/src-d/go-billy.v4/util"
+	"gopkg.in/src-d/go-git.v4"
+	"gopkg.in/src-d/go-git.v4/plumbing"
+	"gopkg.in/src-d/go-git.v4/plumbing/object"
+	"gopkg.in/src-d/go-git.v4/storage/memory"
+)
+
+// Start is the date of the first commit, the next ones being a day apart by default
+var Start = time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// Repo is a repository being built. Files are written to the worktree and committed, one day after the
+// previous commit, by alice@example.com unless told otherwise. Any failure fails the test right away.
+type Repo struct {
+	*git.Repository
+	// Directory of the repository on disk, empty in memory
+	Dir string
+
+	t         testing.TB
+	w         *git.Worktree
+	author    string
+	when      time.Time
+	committed time.Time
+}
+
+// NewRepo starts a repository in memory
+func NewRepo(t testing.TB) *Repo {
+	repo, err := git.Init(memory.NewStorage(), memfs.New())
+	return newRepo(t, repo, "", err)
+}
+
+// NewDiskRepo starts a repository in a temporary directory, e.g. for the git command line. Remove removes it.
+func NewDiskRepo(t testing.TB) *Repo {
+	dir, err := ioutil.TempDir("", "git-churn-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	repo, err := git.PlainInit(dir, false)
+	return newRepo(t, repo, dir, err)
+}
+
+func newRepo(t testing.TB, repo *git.Repository, dir string, err error) *Repo {
+	if err != nil {
+		t.Fatal(err)
+	}
+	w, err := repo.Worktree()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &Repo{Repository: repo, Dir: dir, t: t, w: w, author: "alice", when: Start}
+}
+
+// NewDivergedRepo starts a repository in memory, checked out on master, whose feature branch diverged from
+// master after the root commit:
+//
+//	root            a.txt 1 2 3, b.txt 1
+//	feature 1 (bob) a.txt +4, c.txt +c
+//	feature 2 (bob) c.txt +d
+//	master          a.txt 1 replaced by 0, b.txt +2
+func NewDivergedRepo(t testing.TB) *Repo {
+	repo := NewRepo(t)
+	repo.CommitFiles("root", map[string]string{"a.txt": "1\n2\n3\n", "b.txt": "1\n"})
+	repo.Branch("feature").As("bob").CommitFiles("feature 1", map[string]string{"a.txt": "1\n2\n3\n4\n", "c.txt": "c\n"})
+	repo.CommitFiles("feature 2", map[string]string{"c.txt": "c\nd\n"})
+	repo.Checkout("master").As("alice").CommitFiles("master", map[string]string{"a.txt": "0\n2\n3\n", "b.txt": "1\n2\n"})
+	return repo
+}
+
+// Remove removes the directory of a repository on disk
+func (r *Repo) Remove() {
+	if r.Dir != "" {
+		os.RemoveAll(r.Dir)
+	}
+}
+
+func (r *Repo) check(err error) {
+	if err != nil {
+		r.t.Helper()
+		r.t.Fatal(err)
+	}
+}
+
+// As makes author@example.com the author and committer of the next commits
+func (r *Repo) As(author string) *Repo {
+	r.author = author
+	return r
+}
+
+// CommittedAt dates the committing of the next commit apart from its authoring, like a rebase or a cherry-pick
+// does
+func (r *Repo) CommittedAt(when time.Time) *Repo {
+	r.committed = when
+	return r
+}
+
+// At dates the next commit, the ones after it following a day apart
+func (r *Repo) At(when time.Time) *Repo {
+	r.when = when
+	return r
+}
+
+// Write writes and stages the file
+func (r *Repo) Write(path, content string) *Repo {
+	return r.WriteBytes(path, []byte(content))
+}
+
+// WriteBytes writes and stages the file, e.g. a binary one
+func (r *Repo) WriteBytes(path string, content []byte) *Repo {
+	r.check(util.WriteFile(r.w.Filesystem, path, content, 0644))
+	_, err := r.w.Add(path)
+	r.check(err)
+	return r
+}
+
+// WriteMode writes and stages the file with the permissions, e.g. 0755 for an executable
+func (r *Repo) WriteMode(path, content string, perm os.FileMode) *Repo {
+	// Writing over a file keeps its permissions
+	r.removeFile(path)
+	r.check(util.WriteFile(r.w.Filesystem, path, []byte(content), perm))
+	_, err := r.w.Add(path)
+	r.check(err)
+	return r
+}
+
+// Symlink makes the file a symbolic link to the target and stages it
+func (r *Repo) Symlink(path, target string) *Repo {
+	r.removeFile(path)
+	r.check(r.w.Filesystem.Symlink(target, path))
+	_, err := r.w.Add(path)
+	r.check(err)
+	return r
+}
+
+// removeFile removes the file from the worktree, if any, without staging its removal
+func (r *Repo) removeFile(path string) {
+	if _, err := r.w.Filesystem.Lstat(path); err == nil {
+		r.check(r.w.Filesystem.Remove(path))
+	}
+}
+
+// WriteFiles writes and stages the files by path
+func (r *Repo) WriteFiles(files map[string]string) *Repo {
+	for path, content := range files {
+		r.Write(path, content)
+	}
+	return r
+}
+
+// Delete deletes and stages the removal of the file
+func (r *Repo) Delete(path string) *Repo {
+	_, err := r.w.Remove(path)
+	r.check(err)
+	return r
+}
+
+// Rename moves the file and stages the move
+func (r *Repo) Rename(from, to string) *Repo {
+	_, err := r.w.Move(from, to)
+	r.check(err)
+	return r
+}
+
+// Commit commits the staged files with the message
+func (r *Repo) Commit(message string) *object.Commit {
+	return r.commit(message, nil)
+}
+
+// CommitFiles writes, stages and commits the files by path, like the snapshots of a history
+func (r *Repo) CommitFiles(message string, files map[string]string) *object.Commit {
+	return r.WriteFiles(files).Commit(message)
+}
+
+func (r *Repo) commit(message string, parents []plumbing.Hash) *object.Commit {
+	signature := &object.Signature{Name: r.author, Email: r.author + "@example.com", When: r.when}
+	committer := *signature
+	if !r.committed.IsZero() {
+		committer.When, r.committed = r.committed, time.Time{}
+	}
+	hash, err := r.w.Commit(message, &git.CommitOptions{Author: signature, Committer: &committer, Parents: parents})
+	r.check(err)
+	r.when = r.when.AddDate(0, 0, 1)
+	commit, err := r.CommitObject(hash)
+	r.check(err)
+	return commit
+}
+
+// Head returns the commit checked out
+func (r *Repo) Head() *object.Commit {
+	head, err := r.Repository.Head()
+	r.check(err)
+	commit, err := r.CommitObject(head.Hash())
+	r.check(err)
+	return commit
+}
+
+// Branch creates the branch at the commit checked out and checks it out
+func (r *Repo) Branch(name string) *Repo {
+	r.check(r.w.Checkout(&git.CheckoutOptions{Branch: plumbing.NewBranchReferenceName(name), Create: true}))
+	return r
+}
+
+// Checkout checks out the branch
+func (r *Repo) Checkout(name string) *Repo {
+	r.check(r.w.Checkout(&git.CheckoutOptions{Branch: plumbing.NewBranchReferenceName(name)}))
+	return r
+}
+
+// Tag tags the commit checked out
+func (r *Repo) Tag(name string) *Repo {
+	_, err := r.CreateTag(name, r.Head().Hash, nil)
+	r.check(err)
+	return r
+}
+
+// SetRef points the reference at the commit checked out, e.g. a remote-tracking branch like
+// refs/remotes/origin/master to mimic a clone
+func (r *Repo) SetRef(name string) *Repo {
+	r.check(r.Storer.SetReference(plumbing.NewHashReference(plumbing.ReferenceName(name), r.Head().Hash)))
+	return r
+}
+
+// Merge merges the branch into the branch checked out with a merge commit. There is no conflict
+// resolution: the files the branch changed since the merge base are taken from the branch.
+func (r *Repo) Merge(branch, message string) *object.Commit {
+	head := r.Head()
+	ref, err := r.Reference(plumbing.NewBranchReferenceName(branch), true)
+	r.check(err)
+	tip, err := r.CommitObject(ref.Hash())
+	r.check(err)
+	bases, err := head.MergeBase(tip)
+	r.check(err)
+	var baseTree *object.Tree
+	if len(bases) > 0 {
+		baseTree, err = bases[0].Tree()
+		r.check(err)
+	}
+	tipTree, err := tip.Tree()
+	r.check(err)
+	changes, err := object.DiffTree(baseTree, tipTree)
+	r.check(err)
+	for _, change := range changes {
+		if change.To.Name == "" {
+			if _, err := r.w.Filesystem.Stat(change.From.Name); err == nil {
+				r.Delete(change.From.Name)
+			}
+			continue
+		}
+		file, err := tipTree.File(change.To.Name)
+		r.check(err)
+		content, err := file.Contents()
+		r.check(err)
+		r.Write(change.To.Name, content)
+	}
+	return r.commit(message, []plumbing.Hash{head.Hash, tip.Hash})
+}
@@ -0,0 +1,71 @@
+package testutil
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/src-d/go-git.v4"
+	"gopkg.in/src-d/go-git.v4/plumbing/object"
+)
+
+func TestRepo(t *testing.T) {
+	assert := assert.New(t)
+	repo := NewRepo(t)
+	first := repo.CommitFiles("initial", map[string]string{"a.txt": "1\n", "b.txt": "b\n"})
+	assert.Equal("alice@example.com", first.Author.Email)
+	assert.Equal(Start, first.Author.When.UTC())
+
+	second := repo.As("bob").Rename("b.txt", "c.txt").WriteBytes("bin.dat", []byte{0, 1, 2}).Commit("rename")
+	assert.Equal("bob@example.com", second.Committer.Email)
+	assert.Equal(Start.AddDate(0, 0, 1), second.Author.When.UTC())
+	stats, err := second.Stats()
+	assert.Nil(err)
+	// go-git counts no lines for binary files
+	assert.Equal([]string{"b.txt", "c.txt"}, []string{stats[0].Name, stats[1].Name})
+	_, err = second.File("bin.dat")
+	assert.Nil(err)
+	repo.Tag("v1.0")
+
+	repo.Branch("feature").CommitFiles("feature", map[string]string{"a.txt": "1\n2\n"})
+	repo.Delete("c.txt").Commit("delete")
+	repo.Checkout("master").CommitFiles("master", map[string]string{"d.txt": "d\n"})
+	merge := repo.Merge("feature", "merge feature")
+	assert.Equal(2, merge.NumParents())
+	assert.Equal(merge.Hash, repo.Head().Hash)
+	content, err := merge.File("a.txt")
+	assert.Nil(err)
+	text, _ := content.Contents()
+	assert.Equal("1\n2\n", text)
+	_, err = merge.File("c.txt")
+	assert.Equal(object.ErrFileNotFound, err)
+	_, err = merge.File("d.txt")
+	assert.Nil(err)
+
+	tag, err := repo.Repository.Tag("v1.0")
+	assert.Nil(err)
+	assert.Equal(second.Hash, tag.Hash())
+}
+
+func TestDiskRepo(t *testing.T) {
+	assert := assert.New(t)
+	repo := NewDiskRepo(t)
+	defer repo.Remove()
+	commit := repo.CommitFiles("initial", map[string]string{"dir/a.txt": "1\n"})
+	_, err := os.Stat(filepath.Join(repo.Dir, "dir", "a.txt"))
+	assert.Nil(err)
+	opened, err := git.PlainOpen(repo.Dir)
+	assert.Nil(err)
+	head, err := opened.Head()
+	assert.Nil(err)
+	assert.Equal(commit.Hash, head.Hash())
+
+	repo.Remove()
+	_, err = os.Stat(repo.Dir)
+	assert.True(os.IsNotExist(err))
+}
+
+func TestGolden(t *testing.T) {
+	Golden(t, "golden", []byte("line 1\nline 2\n"))
+}
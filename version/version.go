@@ -0,0 +1,41 @@
+// Package version holds the build metadata of git-churn, set at link time with
+//
+//	go build -ldflags "-X github.com/andymeneely/git-churn/version.Version=1.0.0 ..."
+//
+// so that the data git-churn produces can be tied to the version producing it.
+package version
+
+import (
+	"fmt"
+	"runtime"
+)
+
+// Set with -ldflags -X, see the Makefile
+var (
+	Version   = "0.1"
+	Commit    = "unknown"
+	BuildDate = "unknown"
+)
+
+type Info struct {
+	Version   string
+	Commit    string
+	BuildDate string
+	GoVersion string
+	Platform  string
+}
+
+// Get returns the build metadata of the running binary
+func Get() Info {
+	return Info{
+		Version:   Version,
+		Commit:    Commit,
+		BuildDate: BuildDate,
+		GoVersion: runtime.Version(),
+		Platform:  runtime.GOOS + "/" + runtime.GOARCH,
+	}
+}
+
+func (i Info) String() string {
+	return fmt.Sprintf("git-churn version %s (commit %s, built %s, %s %s)", i.Version, i.Commit, i.BuildDate, i.GoVersion, i.Platform)
+}
@@ -0,0 +1,317 @@
+// Package webhook receives the push events of GitHub and GitLab and computes the churn of the pushed
+// commits as they come, storing it and forwarding it to another service, instead of polling the
+// repositories
+package webhook
+
+import (
+	"bytes"
+	"container/list"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/andymeneely/git-churn/gitfuncs"
+	metrics "github.com/andymeneely/git-churn/matrics"
+	. "github.com/andymeneely/git-churn/print"
+	"github.com/andymeneely/git-churn/schema"
+	"github.com/andymeneely/git-churn/storage"
+	"gopkg.in/src-d/go-git.v4"
+	"gopkg.in/src-d/go-git.v4/plumbing"
+)
+
+// Largest event read, GitHub caps its payloads at 25 MB
+const maxEventSize = 25 << 20
+
+// Push is a push of commits to a branch
+type Push struct {
+	// Clone URL of the repository
+	Repo   string
+	Ref    string
+	Before string
+	After  string
+}
+
+// ParsePush reads the push event of GitHub or GitLab sent in the request, checking that it is signed,
+// or carries the token, with the secret if not empty. Other events, such as the ping sent when a webhook
+// is created, the pushes of tags and the deletions of branches, give a nil push.
+func ParsePush(r *http.Request, body []byte, secret string) (*Push, error) {
+	var event struct {
+		Ref        string `json:"ref"`
+		Before     string `json:"before"`
+		After      string `json:"after"`
+		Repository struct {
+			CloneURL string `json:"clone_url"`
+		} `json:"repository"`
+		Project struct {
+			GitHTTPURL string `json:"git_http_url"`
+		} `json:"project"`
+	}
+	var repo string
+	switch {
+	case r.Header.Get("X-GitHub-Event") != "":
+		if secret != "" && !validSignature(body, r.Header.Get("X-Hub-Signature-256"), secret) {
+			return nil, errors.New("invalid signature")
+		}
+		if r.Header.Get("X-GitHub-Event") != "push" {
+			return nil, nil
+		}
+		if err := json.Unmarshal(body, &event); err != nil {
+			return nil, err
+		}
+		repo = event.Repository.CloneURL
+	case r.Header.Get("X-Gitlab-Event") != "":
+		if secret != "" && !hmac.Equal([]byte(r.Header.Get("X-Gitlab-Token")), []byte(secret)) {
+			return nil, errors.New("invalid token")
+		}
+		if r.Header.Get("X-Gitlab-Event") != "Push Hook" {
+			return nil, nil
+		}
+		if err := json.Unmarshal(body, &event); err != nil {
+			return nil, err
+		}
+		repo = event.Project.GitHTTPURL
+	default:
+		return nil, errors.New("not a GitHub nor a GitLab event")
+	}
+	if !strings.HasPrefix(event.Ref, "refs/heads/") || isZero(event.After) {
+		return nil, nil
+	}
+	if repo == "" {
+		return nil, errors.New("the event tells no repository")
+	}
+	return &Push{Repo: repo, Ref: event.Ref, Before: event.Before, After: event.After}, nil
+}
+
+// validSignature checks the HMAC-SHA256 signature of the body GitHub sends as sha256=<hex>
+func validSignature(body []byte, signature, secret string) bool {
+	if !strings.HasPrefix(signature, "sha256=") {
+		return false
+	}
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return hmac.Equal([]byte(signature[len("sha256="):]), []byte(hex.EncodeToString(mac.Sum(nil))))
+}
+
+// isZero tells whether the hash is the zero one standing for a branch that does not exist
+func isZero(hash string) bool {
+	return strings.Trim(hash, "0") == ""
+}
+
+// Result is the churn of the commits of a push, or the reason it could not be computed
+type Result struct {
+	Push
+	Received time.Time
+	Error    string `json:",omitempty"`
+	metrics.RangeSummary
+	// Churn of every commit pushed
+	PerCommit []*metrics.CommitChurn `json:",omitempty"`
+}
+
+// DefaultMaxRepos is the number of clones a receiver keeps by default
+const DefaultMaxRepos = 10
+
+// Receiver queues the pushes it receives and computes their churn one after the other, keeping a clone of
+// the repositories last pushed to and fetching it on every push
+type Receiver struct {
+	// Secret the events are signed with, or the token they carry for GitLab, no check if empty
+	Secret string
+	// Clones a repository, gitfuncs.CloneRepository by default
+	Clone func(repoUrl string) (*git.Repository, error)
+	// Number of most churned files in the results, all of them if zero
+	Top int
+	// When set, the churn of the commits is stored in it
+	Store *storage.Store
+	// When set, the results are posted to it as JSON
+	ForwardURL string
+	HTTPClient *http.Client
+	// Number of clones kept, the one of the repository least recently pushed to being released past it,
+	// no limit if zero
+	MaxRepos int
+
+	queue chan *Push
+	repos map[string]*list.Element
+	lru   *list.List
+}
+
+type clonedRepo struct {
+	url  string
+	repo *git.Repository
+}
+
+// New returns a receiver queueing up to queueSize pushes
+func New(secret string, queueSize int) *Receiver {
+	return &Receiver{
+		Secret:     secret,
+		Clone:      gitfuncs.CloneRepository,
+		HTTPClient: &http.Client{Timeout: 30 * time.Second},
+		MaxRepos:   DefaultMaxRepos,
+		queue:      make(chan *Push, queueSize),
+		repos:      make(map[string]*list.Element),
+		lru:        list.New(),
+	}
+}
+
+// Handler returns the route the webhooks are to be configured with:
+//
+//	POST /webhook
+//
+// The pushes are answered with 202 Accepted as soon as they are queued, and with 503 when the queue is full.
+func (r *Receiver) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/webhook", r.handleEvent)
+	return mux
+}
+
+func (r *Receiver) handleEvent(w http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodPost {
+		http.Error(w, "only POST is supported", http.StatusMethodNotAllowed)
+		return
+	}
+	body, err := ioutil.ReadAll(io.LimitReader(req.Body, maxEventSize))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	push, err := ParsePush(req, body, r.Secret)
+	if err != nil {
+		Warning("rejected event: %s", err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if push == nil {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	select {
+	case r.queue <- push:
+		w.WriteHeader(http.StatusAccepted)
+	default:
+		Warning("dropped the push of %s to %s, the queue is full", push.After, push.Repo)
+		http.Error(w, "too many pushes queued", http.StatusServiceUnavailable)
+	}
+}
+
+// Run processes the queued pushes until stop is closed
+func (r *Receiver) Run(stop <-chan struct{}) {
+	for {
+		select {
+		case push := <-r.queue:
+			r.Process(push)
+		case <-stop:
+			return
+		}
+	}
+}
+
+// Process computes the churn of the commits of the push, then stores and forwards it. The commits
+// are the ones reachable from the new tip of the branch but not from the previous one or, when the push
+// creates the branch, not from the default branch of the repository. They are walked in the clone of the
+// repository, not taken from the event. The commits of Bots are left out.
+func (r *Receiver) Process(push *Push) *Result {
+	result := &Result{Push: *push, Received: time.Now()}
+	commits, err := r.pushedCommits(push)
+	if err == nil && r.Store != nil {
+		err = r.Store.SaveCommits(push.Repo, commits)
+	}
+	if err != nil {
+		Warning("unable to compute the churn of the push of %s to %s: %s", push.After, push.Repo, err)
+		result.Error = err.Error()
+	} else {
+		result.RangeSummary = metrics.SummarizeRange(commits, r.Top)
+		result.PerCommit = commits
+		Info("%s %s: %d commits, +%d -%d", push.Repo, push.Ref, result.Commits, result.Insertions, result.Deletions)
+	}
+	if r.ForwardURL != "" {
+		if err := r.forward(result); err != nil {
+			Warning("unable to forward the churn of the push of %s to %s: %s", push.After, push.Repo, err)
+		}
+	}
+	return result
+}
+
+func (r *Receiver) pushedCommits(push *Push) ([]*metrics.CommitChurn, error) {
+	repo, err := r.repository(push.Repo)
+	if err != nil {
+		return nil, err
+	}
+	before := push.Before
+	if isZero(before) {
+		if before, err = branchBase(repo, push); err != nil {
+			return nil, err
+		}
+	}
+	return metrics.RangeChurn(repo, before, push.After)
+}
+
+// branchBase returns where the branch the push creates diverged from the default branch, or an empty
+// base, the whole history, when it is the default branch
+func branchBase(repo *git.Repository, push *Push) (string, error) {
+	defaultBranch, err := gitfuncs.DefaultBranch(repo)
+	if err != nil {
+		return "", err
+	}
+	if plumbing.ReferenceName(push.Ref).Short() == defaultBranch {
+		return "", nil
+	}
+	defaultHash, err := gitfuncs.ResolveFetchedRef(repo, defaultBranch)
+	if err != nil {
+		return "", err
+	}
+	after, err := gitfuncs.ResolveRef(repo, push.After)
+	if err != nil {
+		return "", err
+	}
+	mergeBase, err := gitfuncs.MergeBase(repo, *defaultHash, *after)
+	if err != nil {
+		return "", err
+	}
+	return mergeBase.Hash.String(), nil
+}
+
+// repository returns the clone of the repository up to date with its remote, releasing the clone of the
+// repository least recently pushed to when more than MaxRepos are kept
+func (r *Receiver) repository(repoUrl string) (*git.Repository, error) {
+	if element, ok := r.repos[repoUrl]; ok {
+		r.lru.MoveToFront(element)
+		repo := element.Value.(*clonedRepo).repo
+		return repo, gitfuncs.FetchRefs(repo)
+	}
+	repo, err := r.Clone(repoUrl)
+	if err != nil {
+		return nil, err
+	}
+	r.repos[repoUrl] = r.lru.PushFront(&clonedRepo{url: repoUrl, repo: repo})
+	for r.MaxRepos > 0 && r.lru.Len() > r.MaxRepos {
+		oldest := r.lru.Remove(r.lru.Back()).(*clonedRepo)
+		delete(r.repos, oldest.url)
+		gitfuncs.ReleaseRepository(oldest.repo)
+	}
+	return repo, nil
+}
+
+func (r *Receiver) forward(result *Result) error {
+	body, err := json.Marshal(result)
+	if err != nil {
+		return err
+	}
+	if body, err = schema.Stamp(body); err != nil {
+		return err
+	}
+	resp, err := r.HTTPClient.Post(r.ForwardURL, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("%s answered %s", r.ForwardURL, resp.Status)
+	}
+	return nil
+}
@@ -0,0 +1,173 @@
+package webhook
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/andymeneely/git-churn/gitfuncs"
+	"github.com/andymeneely/git-churn/storage"
+	"github.com/andymeneely/git-churn/testutil"
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/src-d/go-git.v4"
+)
+
+const zero = "0000000000000000000000000000000000000000"
+
+func sign(body, secret string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(body))
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+func event(header map[string]string, body string) *http.Request {
+	req := httptest.NewRequest(http.MethodPost, "/webhook", strings.NewReader(body))
+	for name, value := range header {
+		req.Header.Set(name, value)
+	}
+	return req
+}
+
+func TestParsePush(t *testing.T) {
+	assert := assert.New(t)
+	github := `{"ref": "refs/heads/main", "before": "aaa", "after": "bbb",
+		"repository": {"clone_url": "https://github.com/octo/hello.git"}, "commits": [{"id": "bbb"}]}`
+	push, err := ParsePush(event(map[string]string{"X-GitHub-Event": "push", "X-Hub-Signature-256": sign(github, "s3cret")}, github), []byte(github), "s3cret")
+	assert.Nil(err)
+	assert.Equal(&Push{Repo: "https://github.com/octo/hello.git", Ref: "refs/heads/main", Before: "aaa", After: "bbb"}, push)
+
+	_, err = ParsePush(event(map[string]string{"X-GitHub-Event": "push", "X-Hub-Signature-256": sign(github, "other")}, github), []byte(github), "s3cret")
+	assert.NotNil(err)
+	push, err = ParsePush(event(map[string]string{"X-GitHub-Event": "ping"}, "{}"), []byte("{}"), "")
+	assert.Nil(err)
+	assert.Nil(push)
+
+	gitlab := `{"object_kind": "push", "ref": "refs/heads/main", "before": "` + zero + `", "after": "bbb",
+		"project": {"git_http_url": "https://gitlab.com/group/hello.git"}, "commits": [{"id": "aaa"}, {"id": "bbb"}]}`
+	push, err = ParsePush(event(map[string]string{"X-Gitlab-Event": "Push Hook", "X-Gitlab-Token": "s3cret"}, gitlab), []byte(gitlab), "s3cret")
+	assert.Nil(err)
+	assert.Equal(&Push{Repo: "https://gitlab.com/group/hello.git", Ref: "refs/heads/main", Before: zero, After: "bbb"}, push)
+	_, err = ParsePush(event(map[string]string{"X-Gitlab-Event": "Push Hook", "X-Gitlab-Token": "guess"}, gitlab), []byte(gitlab), "s3cret")
+	assert.NotNil(err)
+
+	// Tags and deleted branches
+	for _, ignored := range []string{
+		`{"ref": "refs/tags/v1", "before": "` + zero + `", "after": "bbb", "repository": {"clone_url": "x"}}`,
+		`{"ref": "refs/heads/main", "before": "bbb", "after": "` + zero + `", "repository": {"clone_url": "x"}}`,
+	} {
+		push, err = ParsePush(event(map[string]string{"X-GitHub-Event": "push"}, ignored), []byte(ignored), "")
+		assert.Nil(err)
+		assert.Nil(push)
+	}
+	_, err = ParsePush(event(nil, github), []byte(github), "")
+	assert.NotNil(err)
+}
+
+func TestReceiver(t *testing.T) {
+	assert := assert.New(t)
+	repo := testutil.NewRepo(t)
+	first := repo.CommitFiles("Add a", map[string]string{"a.txt": "1\n2\n"})
+	second := repo.CommitFiles("Change a", map[string]string{"a.txt": "1\nb\nc\n"})
+	third := repo.As("bob").CommitFiles("Add b", map[string]string{"b.txt": "b\n"})
+	feature := repo.Branch("feature").As("carol").CommitFiles("Add c", map[string]string{"c.txt": "c\n"})
+	repo.Checkout("master")
+
+	dir, err := ioutil.TempDir("", "git-churn-webhook")
+	assert.Nil(err)
+	defer os.RemoveAll(dir)
+	store, err := storage.Open(filepath.Join(dir, "churn.db"))
+	assert.Nil(err)
+	defer store.Close()
+
+	var forwarded []map[string]interface{}
+	forward := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var result map[string]interface{}
+		assert.Nil(json.NewDecoder(r.Body).Decode(&result))
+		forwarded = append(forwarded, result)
+	}))
+	defer forward.Close()
+
+	r := New("", 1)
+	r.Store = store
+	r.ForwardURL = forward.URL
+	r.Clone = func(repoUrl string) (*git.Repository, error) {
+		if repoUrl == "broken" {
+			return nil, fmt.Errorf("unreachable")
+		}
+		return repo.Repository, nil
+	}
+	push := func(body string) int {
+		recorder := httptest.NewRecorder()
+		r.Handler().ServeHTTP(recorder, event(map[string]string{"X-GitHub-Event": "push"}, body))
+		return recorder.Code
+	}
+	body := func(repoUrl, before, after string) string {
+		return fmt.Sprintf(`{"ref": "refs/heads/master", "before": %q, "after": %q, "repository": {"clone_url": %q}}`,
+			before, after, repoUrl)
+	}
+
+	assert.Equal(http.StatusAccepted, push(body("one", first.Hash.String(), third.Hash.String())))
+	// The queue holds a single push
+	assert.Equal(http.StatusServiceUnavailable, push(body("two", first.Hash.String(), third.Hash.String())))
+	result := r.Process(<-r.queue)
+	assert.Equal("", result.Error)
+	assert.Equal(2, result.Commits)
+	assert.Equal(3, result.Insertions)
+	assert.Equal(1, result.Deletions)
+	assert.Len(result.PerCommit, 2)
+	stored, err := store.StoredCommits("one")
+	assert.Nil(err)
+	assert.Equal(map[string]bool{second.Hash.String(): true, third.Hash.String(): true}, stored)
+
+	// A new branch, only the commits since it diverged from the default branch being analyzed
+	result = r.Process(&Push{Repo: "new", Ref: "refs/heads/feature", Before: zero, After: feature.Hash.String()})
+	assert.Equal("", result.Error)
+	assert.Equal(1, result.Commits)
+	assert.Equal("carol@example.com", result.PerCommit[0].Author)
+	// The default branch created, its whole history
+	result = r.Process(&Push{Repo: "created", Ref: "refs/heads/master", Before: zero, After: third.Hash.String()})
+	assert.Equal(3, result.Commits)
+
+	result = r.Process(&Push{Repo: "broken", Ref: "refs/heads/master", Before: zero, After: third.Hash.String()})
+	assert.Equal("unreachable", result.Error)
+
+	assert.Len(forwarded, 4)
+	assert.Equal(float64(2), forwarded[0]["Commits"])
+	assert.Equal("unreachable", forwarded[3]["Error"])
+
+	assert.Equal(http.StatusNoContent, push(`{"ref": "refs/tags/v1", "before": "`+zero+`", "after": "bbb"}`))
+	assert.Equal(http.StatusBadRequest, push("not json"))
+}
+
+func TestReceiverMaxRepos(t *testing.T) {
+	assert := assert.New(t)
+	origin := testutil.NewDiskRepo(t)
+	defer origin.Remove()
+	first := origin.CommitFiles("Add a", map[string]string{"a.txt": "1\n"})
+	second := origin.CommitFiles("Change a", map[string]string{"a.txt": "2\n"})
+
+	r := New("", 1)
+	r.MaxRepos = 2
+	var cloned []string
+	r.Clone = func(repoUrl string) (*git.Repository, error) {
+		cloned = append(cloned, repoUrl)
+		return gitfuncs.CloneRepository(origin.Dir)
+	}
+	for _, repoUrl := range []string{"one", "two", "one", "three", "two"} {
+		result := r.Process(&Push{Repo: repoUrl, Ref: "refs/heads/master", Before: first.Hash.String(), After: second.Hash.String()})
+		assert.Equal("", result.Error)
+	}
+	// two was released when three was cloned, one having been pushed to since
+	assert.Equal([]string{"one", "two", "three", "two"}, cloned)
+	assert.Len(r.repos, 2)
+	assert.Equal(2, r.lru.Len())
+}